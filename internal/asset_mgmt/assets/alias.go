@@ -0,0 +1,110 @@
+package assets
+
+// 管理番号の別名
+// - 旧システム（表計算）のシール番号や、ジャンル統合で付け替える前の番号を asset_master_id に結び付ける
+// - inventory.ResolveManagementNumber が正式な番号と同じく引くため、貸出・廃棄・契約・ラベル印刷でもそのまま使える
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	mysql "github.com/go-sql-driver/mysql"
+
+	"IRIS-backend/internal/asset_mgmt/inventory"
+)
+
+// MaxAliasesPerRequest 1 回で登録できる別名の数
+const MaxAliasesPerRequest = 100
+
+// normalizeAliases 前後の空白を除き、空・重複・長すぎる値を拒否する
+func normalizeAliases(in []string) ([]string, error) {
+	if len(in) == 0 {
+		return nil, ErrInvalid("aliases must not be empty")
+	}
+	if len(in) > MaxAliasesPerRequest {
+		return nil, ErrInvalid("too many aliases")
+	}
+	seen := make(map[string]bool, len(in))
+	out := make([]string, 0, len(in))
+	for _, a := range in {
+		a = strings.TrimSpace(a)
+		if a == "" {
+			return nil, ErrInvalid("alias must not be empty")
+		}
+		if len(a) > 255 {
+			return nil, ErrInvalid("alias is too long")
+		}
+		if seen[a] {
+			continue
+		}
+		seen[a] = true
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+func (s *Service) resolveMasterID(ctx context.Context, managementNumber string) (uint64, error) {
+	id, _, err := inventory.ResolveManagementNumber(ctx, s.db, managementNumber)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound("master not found")
+	}
+	return id, err
+}
+
+// ListManagementNumberAliases 管理番号（別名でも可）のマスタに付いた別名
+func (s *Service) ListManagementNumberAliases(ctx context.Context, managementNumber string) ([]ManagementNumberAliasResponse, error) {
+	id, err := s.resolveMasterID(ctx, managementNumber)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.ListAliasesByMasterID(ctx, id)
+}
+
+// AddManagementNumberAliases 別名をまとめて登録する（1 件でも登録済みなら全体を取り消して 409）
+func (s *Service) AddManagementNumberAliases(ctx context.Context, managementNumber string, req AddManagementNumberAliasesRequest) ([]ManagementNumberAliasResponse, error) {
+	aliases, err := normalizeAliases(req.Aliases)
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.resolveMasterID(ctx, managementNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err := s.store.InsertAliasesTx(ctx, tx, id, aliases); err != nil {
+		var me *mysql.MySQLError
+		if errors.As(err, &me) && me.Number == 1062 {
+			return nil, ErrConflict("alias already registered")
+		}
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	committed = true
+
+	return s.store.ListAliasesByMasterID(ctx, id)
+}
+
+func (s *Service) DeleteManagementNumberAlias(ctx context.Context, alias string) error {
+	if err := s.store.DeleteAlias(ctx, alias); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound("alias not found")
+		}
+		return err
+	}
+	return nil
+}
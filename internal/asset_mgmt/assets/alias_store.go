@@ -0,0 +1,62 @@
+package assets
+
+import (
+	"context"
+	"database/sql"
+)
+
+func (s *Store) ListAliasesByMasterID(ctx context.Context, masterID uint64) ([]ManagementNumberAliasResponse, error) {
+	const q = `
+	SELECT a.management_number, a.asset_master_id, m.management_number, a.created_at
+	FROM asset_management_number_aliases a
+	JOIN assets_master m ON m.asset_master_id = a.asset_master_id
+	WHERE a.asset_master_id = ?
+	ORDER BY a.management_number`
+	rows, err := s.db.QueryContext(ctx, q, masterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]ManagementNumberAliasResponse, 0, 4)
+	for rows.Next() {
+		var a ManagementNumberAliasResponse
+		if err := rows.Scan(&a.Alias, &a.AssetMasterID, &a.ManagementNumber, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// InsertAliasesTx は別名を登録する。正式な管理番号と同じ値は登録できない
+func (s *Store) InsertAliasesTx(ctx context.Context, tx *sql.Tx, masterID uint64, aliases []string) error {
+	for _, alias := range aliases {
+		var taken bool
+		if err := tx.QueryRowContext(ctx,
+			`SELECT EXISTS(SELECT 1 FROM assets_master WHERE management_number = ?)`, alias,
+		).Scan(&taken); err != nil {
+			return err
+		}
+		if taken {
+			return ErrConflict("alias " + alias + " is already a management_number")
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO asset_management_number_aliases (management_number, asset_master_id, created_at)
+			VALUES (?, ?, UTC_TIMESTAMP())`, alias, masterID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) DeleteAlias(ctx context.Context, alias string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM asset_management_number_aliases WHERE management_number = ?`, alias)
+	if err != nil {
+		return err
+	}
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package assets
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeAliases(t *testing.T) {
+	got, err := normalizeAliases([]string{" OFS-0012 ", "OFS-0012", "PC-OLD-1"})
+	if err != nil {
+		t.Fatalf("normalizeAliases returned error: %v", err)
+	}
+	if want := []string{"OFS-0012", "PC-OLD-1"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	cases := map[string][]string{
+		"empty list": nil,
+		"blank":      {"OFS-1", "  "},
+		"too long":   {strings.Repeat("x", 256)},
+		"too many":   make([]string, MaxAliasesPerRequest+1),
+	}
+	for name, in := range cases {
+		if _, err := normalizeAliases(in); toHTTPStatus(err) != 400 {
+			t.Fatalf("%s: expected 400, got %v", name, err)
+		}
+	}
+}
+
+func TestUpdateMasterByMngResolvesAlias(t *testing.T) {
+	created := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	conn := &fakeConn{
+		rows: map[string][][]driver.Value{
+			"asset_management_number_aliases":          {{int64(7), "PC-20260101-00007"}},
+			"FROM assets_master WHERE asset_master_id": {{int64(7), "PC-20260101-00007", "Projector", int64(1), int64(2), "Epson", nil, created}},
+		},
+	}
+	name := "Projector"
+	got, err := NewStore(newFakeDB(conn)).UpdateMasterByMng(context.Background(), "OFS-0012", UpdateAssetMasterRequest{Name: &name})
+	if err != nil {
+		t.Fatalf("UpdateMasterByMng returned error: %v", err)
+	}
+	if got.AssetMasterID != 7 || got.ManagementNumber != "PC-20260101-00007" {
+		t.Fatalf("unexpected master: %+v", got)
+	}
+	update := conn.exec("UPDATE assets_master")
+	if update == nil || !strings.Contains(update.query, "WHERE asset_master_id = ?") || update.args[1] != int64(7) {
+		t.Fatalf("expected the update keyed by the resolved asset_master_id, got %+v", update)
+	}
+}
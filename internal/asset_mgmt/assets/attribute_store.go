@@ -0,0 +1,363 @@
+package assets
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ===== ジャンルごとの追加項目の定義 =====
+
+const attributeDefinitionColumns = `attribute_id, genre_id, attr_key, label, value_type, scope, is_required, enum_values, sort_order, created_at, updated_at`
+
+func scanAttributeDefinition(row interface{ Scan(...any) error }) (*AttributeDefinitionResponse, error) {
+	var d AttributeDefinitionResponse
+	var enumValues sql.NullString
+	if err := row.Scan(&d.AttributeID, &d.GenreID, &d.Key, &d.Label, &d.Type, &d.Scope, &d.Required,
+		&enumValues, &d.SortOrder, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if enumValues.Valid && enumValues.String != "" {
+		if err := json.Unmarshal([]byte(enumValues.String), &d.EnumValues); err != nil {
+			return nil, err
+		}
+	}
+	return &d, nil
+}
+
+func enumValuesJSON(values []string) (*string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	s := string(b)
+	return &s, nil
+}
+
+// ListAttributeDefinitions ジャンルの定義を並び順で返す
+func (s *Store) ListAttributeDefinitions(ctx context.Context, genreID uint) ([]AttributeDefinitionResponse, error) {
+	q := `SELECT ` + attributeDefinitionColumns + ` FROM genre_attribute_definitions
+	WHERE genre_id = ? ORDER BY sort_order, attribute_id`
+	rows, err := s.db.QueryContext(ctx, q, genreID)
+	if err != nil {
+		return nil, err
+	}
+	return collectAttributeDefinitions(rows)
+}
+
+// ListAttributeDefinitionsTx 値を書く前に定義を FOR SHARE で読む（UpdateAttributeDefinition の FOR UPDATE と直列にする）
+func (s *Store) ListAttributeDefinitionsTx(ctx context.Context, tx *sql.Tx, genreID uint) ([]AttributeDefinitionResponse, error) {
+	q := `SELECT ` + attributeDefinitionColumns + ` FROM genre_attribute_definitions
+	WHERE genre_id = ? ORDER BY sort_order, attribute_id FOR SHARE`
+	rows, err := tx.QueryContext(ctx, q, genreID)
+	if err != nil {
+		return nil, err
+	}
+	return collectAttributeDefinitions(rows)
+}
+
+func collectAttributeDefinitions(rows *sql.Rows) ([]AttributeDefinitionResponse, error) {
+	defer rows.Close()
+
+	out := make([]AttributeDefinitionResponse, 0, 8)
+	for rows.Next() {
+		d, err := scanAttributeDefinition(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *d)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) GetAttributeDefinition(ctx context.Context, id uint64) (*AttributeDefinitionResponse, error) {
+	q := `SELECT ` + attributeDefinitionColumns + ` FROM genre_attribute_definitions WHERE attribute_id = ?`
+	return scanAttributeDefinition(s.db.QueryRowContext(ctx, q, id))
+}
+
+// LockAttributeDefinitionTx 定義を FOR UPDATE で読む（選択肢の変更と使用中の確認を直列にする）
+func (s *Store) LockAttributeDefinitionTx(ctx context.Context, tx *sql.Tx, id uint64) (*AttributeDefinitionResponse, error) {
+	q := `SELECT ` + attributeDefinitionColumns + ` FROM genre_attribute_definitions WHERE attribute_id = ? FOR UPDATE`
+	return scanAttributeDefinition(tx.QueryRowContext(ctx, q, id))
+}
+
+func (s *Store) CreateAttributeDefinition(ctx context.Context, genreID uint, in AttributeDefinitionRequest) (*AttributeDefinitionResponse, error) {
+	const q = `
+	INSERT INTO genre_attribute_definitions
+		(genre_id, attr_key, label, value_type, scope, is_required, enum_values, sort_order, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, UTC_TIMESTAMP(), UTC_TIMESTAMP())`
+	enumValues, err := enumValuesJSON(in.EnumValues)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.db.ExecContext(ctx, q, genreID, in.Key, in.Label, in.Type, in.Scope, in.Required, enumValues, in.SortOrder)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return s.GetAttributeDefinition(ctx, uint64(id))
+}
+
+// UpdateAttributeDefinitionTx value_type と scope は変えない（Service 側で確認済み）
+func (s *Store) UpdateAttributeDefinitionTx(ctx context.Context, tx *sql.Tx, id uint64, in AttributeDefinitionRequest) error {
+	const q = `
+	UPDATE genre_attribute_definitions
+	SET attr_key = ?, label = ?, is_required = ?, enum_values = ?, sort_order = ?, updated_at = UTC_TIMESTAMP()
+	WHERE attribute_id = ?`
+	enumValues, err := enumValuesJSON(in.EnumValues)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, q, in.Key, in.Label, in.Required, enumValues, in.SortOrder, id)
+	return err
+}
+
+// DeleteAttributeDefinition 保存済みの値もまとめて消す
+func (s *Store) DeleteAttributeDefinition(ctx context.Context, id uint64) error {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM asset_master_attribute_values WHERE attribute_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM asset_attribute_values WHERE attribute_id = ?`, id); err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM genre_attribute_definitions WHERE attribute_id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		return sql.ErrNoRows
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// CountAttributeValuesTx 項目に texts のいずれかを持つマスタ・個体の数
+func (s *Store) CountAttributeValuesTx(ctx context.Context, tx *sql.Tx, id uint64, texts []string) (int64, error) {
+	if len(texts) == 0 {
+		return 0, nil
+	}
+	in := strings.TrimSuffix(strings.Repeat("?, ", len(texts)), ", ")
+	q := `SELECT
+		(SELECT COUNT(*) FROM asset_master_attribute_values WHERE attribute_id = ? AND value_text IN (` + in + `)) +
+		(SELECT COUNT(*) FROM asset_attribute_values WHERE attribute_id = ? AND value_text IN (` + in + `))`
+	args := make([]any, 0, 2*len(texts)+2)
+	for range 2 {
+		args = append(args, id)
+		for _, t := range texts {
+			args = append(args, t)
+		}
+	}
+	var n int64
+	err := tx.QueryRowContext(ctx, q, args...).Scan(&n)
+	return n, err
+}
+
+// ===== 値 =====
+
+// 一度に IN 句へ入れる ID の数
+const attributeLoadChunk = 500
+
+// LoadMasterAttributes マスタごとの追加項目（現在のジャンルの定義に当たるものだけ）
+func (s *Store) LoadMasterAttributes(ctx context.Context, masterIDs []uint64) (map[uint64]map[string]any, error) {
+	const q = `
+	SELECT v.asset_master_id, d.attr_key, d.value_type, v.value_text
+	FROM asset_master_attribute_values v
+	JOIN genre_attribute_definitions d ON d.attribute_id = v.attribute_id
+	JOIN assets_master m ON m.asset_master_id = v.asset_master_id AND m.genre_id = d.genre_id
+	WHERE v.asset_master_id IN (%s)`
+	return s.loadAttributes(ctx, q, masterIDs)
+}
+
+// LoadAssetAttributes 個体ごとの追加項目（マスタの現在のジャンルの定義に当たるものだけ）
+func (s *Store) LoadAssetAttributes(ctx context.Context, assetIDs []uint64) (map[uint64]map[string]any, error) {
+	const q = `
+	SELECT v.asset_id, d.attr_key, d.value_type, v.value_text
+	FROM asset_attribute_values v
+	JOIN genre_attribute_definitions d ON d.attribute_id = v.attribute_id
+	JOIN assets a ON a.asset_id = v.asset_id
+	JOIN assets_master m ON m.asset_master_id = a.asset_master_id AND m.genre_id = d.genre_id
+	WHERE v.asset_id IN (%s)`
+	return s.loadAttributes(ctx, q, assetIDs)
+}
+
+func (s *Store) loadAttributes(ctx context.Context, query string, ids []uint64) (map[uint64]map[string]any, error) {
+	out := make(map[uint64]map[string]any)
+	for start := 0; start < len(ids); start += attributeLoadChunk {
+		chunk := ids[start:min(start+attributeLoadChunk, len(ids))]
+		args := make([]any, len(chunk))
+		for i, id := range chunk {
+			args[i] = id
+		}
+		q := strings.Replace(query, "%s", strings.TrimSuffix(strings.Repeat("?, ", len(chunk)), ", "), 1)
+		if err := func() error {
+			rows, err := s.db.QueryContext(ctx, q, args...)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var (
+					id             uint64
+					key, typ, text string
+				)
+				if err := rows.Scan(&id, &key, &typ, &text); err != nil {
+					return err
+				}
+				if out[id] == nil {
+					out[id] = map[string]any{}
+				}
+				out[id][key] = attributeJSONValue(typ, text)
+			}
+			return rows.Err()
+		}(); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// StoredMasterAttributeIDs 値が保存されている attribute_id（ジャンルを問わない）
+func (s *Store) StoredMasterAttributeIDs(ctx context.Context, masterID uint64) (map[uint64]bool, error) {
+	return s.storedAttributeIDs(ctx, `SELECT attribute_id FROM asset_master_attribute_values WHERE asset_master_id = ?`, masterID)
+}
+
+func (s *Store) StoredAssetAttributeIDs(ctx context.Context, assetID uint64) (map[uint64]bool, error) {
+	return s.storedAttributeIDs(ctx, `SELECT attribute_id FROM asset_attribute_values WHERE asset_id = ?`, assetID)
+}
+
+func (s *Store) storedAttributeIDs(ctx context.Context, q string, id uint64) (map[uint64]bool, error) {
+	rows, err := s.db.QueryContext(ctx, q, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[uint64]bool{}
+	for rows.Next() {
+		var attrID uint64
+		if err := rows.Scan(&attrID); err != nil {
+			return nil, err
+		}
+		out[attrID] = true
+	}
+	return out, rows.Err()
+}
+
+// SetMasterAttributesTx changes を保存する（nil の項目は削除）
+func (s *Store) SetMasterAttributesTx(ctx context.Context, tx *sql.Tx, masterID uint64, changes map[uint64]*attrValue) error {
+	return setAttributesTx(ctx, tx, "asset_master_attribute_values", "asset_master_id", masterID, changes)
+}
+
+func (s *Store) SetAssetAttributesTx(ctx context.Context, tx *sql.Tx, assetID uint64, changes map[uint64]*attrValue) error {
+	return setAttributesTx(ctx, tx, "asset_attribute_values", "asset_id", assetID, changes)
+}
+
+// table / owner は上の 2 つからだけ渡す固定値
+func setAttributesTx(ctx context.Context, tx *sql.Tx, table, owner string, ownerID uint64, changes map[uint64]*attrValue) error {
+	upsert := `
+	INSERT INTO ` + table + ` (` + owner + `, attribute_id, value_text, value_number, value_date, updated_at)
+	VALUES (?, ?, ?, ?, ?, UTC_TIMESTAMP())
+	ON DUPLICATE KEY UPDATE
+		value_text = VALUES(value_text), value_number = VALUES(value_number),
+		value_date = VALUES(value_date), updated_at = VALUES(updated_at)`
+	del := `DELETE FROM ` + table + ` WHERE ` + owner + ` = ? AND attribute_id = ?`
+
+	// 実行順を固定してロック順を揃える
+	ids := make([]uint64, 0, len(changes))
+	for id := range changes {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, attrID := range ids {
+		v := changes[attrID]
+		if v == nil {
+			if _, err := tx.ExecContext(ctx, del, ownerID, attrID); err != nil {
+				return err
+			}
+			continue
+		}
+		var date *string
+		if v.Date != nil {
+			d := v.Date.Format(attributeDateLayout)
+			date = &d
+		}
+		if _, err := tx.ExecContext(ctx, upsert, ownerID, attrID, v.Text, v.Number, date); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ===== 検索 =====
+
+// attributeFilterCondition 1 つの AttributeFilter を EXISTS 条件にする（m / a は buildSearchAssetsQuery の別名）
+func attributeFilterCondition(f AttributeFilter) (string, []any) {
+	const (
+		text   = "COALESCE(mv.value_text, av.value_text)"
+		number = "COALESCE(mv.value_number, av.value_number)"
+		date   = "COALESCE(mv.value_date, av.value_date)"
+	)
+	conds := []string{"d.genre_id = m.genre_id", "d.attr_key = ?"}
+	args := []any{f.Key}
+
+	if f.Value != nil {
+		// string は部分一致、number は数値として、それ以外は完全一致
+		var num any
+		if n, err := strconv.ParseFloat(*f.Value, 64); err == nil {
+			num = n
+		}
+		conds = append(conds, `CASE d.value_type
+				WHEN 'string' THEN `+text+` LIKE ? ESCAPE '\'
+				WHEN 'number' THEN `+number+` = ?
+				ELSE `+text+` = ? END`)
+		args = append(args, "%"+escapeLike(*f.Value)+"%", num, *f.Value)
+	}
+	for _, b := range []struct {
+		v  *string
+		op string
+	}{{f.Min, ">="}, {f.Max, "<="}} {
+		if b.v == nil {
+			continue
+		}
+		if _, err := time.Parse(attributeDateLayout, *b.v); err == nil {
+			conds = append(conds, date+" "+b.op+" ?")
+			args = append(args, *b.v)
+			continue
+		}
+		n, _ := strconv.ParseFloat(*b.v, 64)
+		conds = append(conds, number+" "+b.op+" ?")
+		args = append(args, n)
+	}
+
+	return `EXISTS (
+			SELECT 1 FROM genre_attribute_definitions AS d
+			LEFT JOIN asset_master_attribute_values AS mv
+				ON mv.attribute_id = d.attribute_id AND mv.asset_master_id = m.asset_master_id
+			LEFT JOIN asset_attribute_values AS av
+				ON av.attribute_id = d.attribute_id AND av.asset_id = a.asset_id
+			WHERE ` + strings.Join(conds, " AND ") + `
+		)`, args
+}
@@ -0,0 +1,500 @@
+package assets
+
+// ジャンルごとの追加項目（カメラのレンズマウント、電池の容量、計測器の校正周期など）
+// - 定義は genre_attribute_definitions にジャンルごとに持ち、scope=master はマスタに、scope=asset は個体に値を持つ
+// - 値は文字列で正規化して保存し、number / date は範囲検索用に数値・日付の列にも入れる
+// - ジャンルを変えると前のジャンルの値は返さなくなる（消さないので戻せば見える）
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	mysql "github.com/go-sql-driver/mysql"
+)
+
+const (
+	AttrTypeString = "string"
+	AttrTypeNumber = "number"
+	AttrTypeDate   = "date"
+	AttrTypeEnum   = "enum"
+
+	AttrScopeMaster = "master"
+	AttrScopeAsset  = "asset"
+
+	// CSV の追加項目の列名は attr.<key>
+	csvAttributePrefix = "attr."
+	// 検索パラメータは attr.<key>, attr.<key>.min, attr.<key>.max
+	searchAttributePrefix = "attr."
+
+	maxAttributeStringLength = 255
+	maxAttributeLabelLength  = 100
+	maxAttributeEnumValues   = 100
+	attributeDateLayout      = "2006-01-02"
+)
+
+var attributeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+var validAttrTypes = map[string]bool{AttrTypeString: true, AttrTypeNumber: true, AttrTypeDate: true, AttrTypeEnum: true}
+
+// attrValue 保存用に正規化した値。Number / Date は type が number / date のときだけ入る
+type attrValue struct {
+	Text   string
+	Number *float64
+	Date   *time.Time
+}
+
+// ===== 定義 =====
+
+func normalizeAttributeDefinition(in AttributeDefinitionRequest) (AttributeDefinitionRequest, error) {
+	in.Key = strings.ToLower(strings.TrimSpace(in.Key))
+	in.Label = strings.TrimSpace(in.Label)
+	in.Type = strings.ToLower(strings.TrimSpace(in.Type))
+	in.Scope = strings.ToLower(strings.TrimSpace(in.Scope))
+	if in.Scope == "" {
+		in.Scope = AttrScopeMaster
+	}
+
+	if !attributeKeyPattern.MatchString(in.Key) {
+		return in, ErrInvalid("key must start with a-z and contain only a-z, 0-9 and _ (max 64)")
+	}
+	if in.Label == "" {
+		return in, ErrInvalid("label is required")
+	}
+	if len([]rune(in.Label)) > maxAttributeLabelLength {
+		return in, ErrInvalid(fmt.Sprintf("label must be at most %d characters", maxAttributeLabelLength))
+	}
+	if !validAttrTypes[in.Type] {
+		return in, ErrInvalid("type must be string, number, date or enum")
+	}
+	if in.Scope != AttrScopeMaster && in.Scope != AttrScopeAsset {
+		return in, ErrInvalid("scope must be master or asset")
+	}
+
+	if in.Type != AttrTypeEnum {
+		if len(in.EnumValues) > 0 {
+			return in, ErrInvalid("enum_values is only allowed for type enum")
+		}
+		in.EnumValues = nil
+		return in, nil
+	}
+	if len(in.EnumValues) == 0 {
+		return in, ErrInvalid("enum_values is required for type enum")
+	}
+	if len(in.EnumValues) > maxAttributeEnumValues {
+		return in, ErrInvalid(fmt.Sprintf("enum_values must have at most %d values", maxAttributeEnumValues))
+	}
+	seen := make(map[string]bool, len(in.EnumValues))
+	values := make([]string, 0, len(in.EnumValues))
+	for _, v := range in.EnumValues {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return in, ErrInvalid("enum_values must not contain empty values")
+		}
+		if len([]rune(v)) > maxAttributeLabelLength {
+			return in, ErrInvalid(fmt.Sprintf("enum value must be at most %d characters", maxAttributeLabelLength))
+		}
+		if seen[v] {
+			return in, ErrInvalid(fmt.Sprintf("duplicate enum value %q", v))
+		}
+		seen[v] = true
+		values = append(values, v)
+	}
+	in.EnumValues = values
+	return in, nil
+}
+
+func mapAttributeDefinitionError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound("attribute not found")
+	}
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		switch me.Number {
+		case 1062:
+			return ErrConflict("an attribute with this key already exists in the genre")
+		case 1452:
+			return ErrInvalid("invalid genre_id")
+		}
+	}
+	return err
+}
+
+func (s *Service) ListAttributeDefinitions(ctx context.Context, genreID uint) ([]AttributeDefinitionResponse, error) {
+	return s.store.ListAttributeDefinitions(ctx, genreID)
+}
+
+func (s *Service) CreateAttributeDefinition(ctx context.Context, genreID uint, in AttributeDefinitionRequest) (AttributeDefinitionResponse, error) {
+	if genreID == 0 {
+		return AttributeDefinitionResponse{}, ErrInvalid("genre_id is required")
+	}
+	in, err := normalizeAttributeDefinition(in)
+	if err != nil {
+		return AttributeDefinitionResponse{}, err
+	}
+	out, err := s.store.CreateAttributeDefinition(ctx, genreID, in)
+	if err != nil {
+		return AttributeDefinitionResponse{}, mapAttributeDefinitionError(err)
+	}
+	return *out, nil
+}
+
+// UpdateAttributeDefinition key・label・required・選択肢・並び順を変える。
+// 使われている選択肢は消せない。required にしても既存の備品はそのまま（次に更新するときに求められる）
+func (s *Service) UpdateAttributeDefinition(ctx context.Context, id uint64, in AttributeDefinitionRequest) (AttributeDefinitionResponse, error) {
+	in, err := normalizeAttributeDefinition(in)
+	if err != nil {
+		return AttributeDefinitionResponse{}, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return AttributeDefinitionResponse{}, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	cur, err := s.store.LockAttributeDefinitionTx(ctx, tx, id)
+	if err != nil {
+		return AttributeDefinitionResponse{}, mapAttributeDefinitionError(err)
+	}
+	if cur.Type != in.Type || cur.Scope != in.Scope {
+		return AttributeDefinitionResponse{}, ErrInvalid("type and scope cannot be changed; delete and recreate the attribute")
+	}
+	if in.Type == AttrTypeEnum {
+		if removed := removedEnumValues(cur.EnumValues, in.EnumValues); len(removed) > 0 {
+			n, err := s.store.CountAttributeValuesTx(ctx, tx, id, removed)
+			if err != nil {
+				return AttributeDefinitionResponse{}, err
+			}
+			if n > 0 {
+				return AttributeDefinitionResponse{}, ErrConflict(fmt.Sprintf("enum values still in use by %d assets: %s", n, strings.Join(removed, ", ")))
+			}
+		}
+	}
+	if err := s.store.UpdateAttributeDefinitionTx(ctx, tx, id, in); err != nil {
+		return AttributeDefinitionResponse{}, mapAttributeDefinitionError(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return AttributeDefinitionResponse{}, err
+	}
+	committed = true
+
+	out, err := s.store.GetAttributeDefinition(ctx, id)
+	if err != nil {
+		return AttributeDefinitionResponse{}, mapAttributeDefinitionError(err)
+	}
+	return *out, nil
+}
+
+// removedEnumValues cur にあって next に無い選択肢
+func removedEnumValues(cur, next []string) []string {
+	keep := make(map[string]bool, len(next))
+	for _, v := range next {
+		keep[v] = true
+	}
+	var removed []string
+	for _, v := range cur {
+		if !keep[v] {
+			removed = append(removed, v)
+		}
+	}
+	return removed
+}
+
+// DeleteAttributeDefinition 定義と保存済みの値を消す
+func (s *Service) DeleteAttributeDefinition(ctx context.Context, id uint64) error {
+	if err := s.store.DeleteAttributeDefinition(ctx, id); err != nil {
+		return mapAttributeDefinitionError(err)
+	}
+	return nil
+}
+
+// ===== 値 =====
+
+func isEmptyAttributeValue(v any) bool {
+	if v == nil {
+		return true
+	}
+	str, ok := v.(string)
+	return ok && strings.TrimSpace(str) == ""
+}
+
+// normalizeAttributeValue JSON（数値は float64）か CSV（文字列）の値を定義の型に合わせて正規化する
+func normalizeAttributeValue(def AttributeDefinitionResponse, v any) (attrValue, error) {
+	str, isString := v.(string)
+	if isString {
+		str = strings.TrimSpace(str)
+	}
+	invalid := func(want string) error {
+		return ErrInvalid(fmt.Sprintf("attribute %q must be %s", def.Key, want))
+	}
+
+	switch def.Type {
+	case AttrTypeString:
+		if !isString {
+			return attrValue{}, invalid("a string")
+		}
+		if len([]rune(str)) > maxAttributeStringLength {
+			return attrValue{}, ErrInvalid(fmt.Sprintf("attribute %q must be at most %d characters", def.Key, maxAttributeStringLength))
+		}
+		return attrValue{Text: str}, nil
+
+	case AttrTypeNumber:
+		var f float64
+		switch n := v.(type) {
+		case float64:
+			f = n
+		case int:
+			f = float64(n)
+		case string:
+			parsed, err := strconv.ParseFloat(str, 64)
+			if err != nil {
+				return attrValue{}, invalid("a number")
+			}
+			f = parsed
+		default:
+			return attrValue{}, invalid("a number")
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return attrValue{}, invalid("a finite number")
+		}
+		return attrValue{Text: strconv.FormatFloat(f, 'f', -1, 64), Number: &f}, nil
+
+	case AttrTypeDate:
+		if !isString {
+			return attrValue{}, invalid("a date (YYYY-MM-DD)")
+		}
+		t, err := time.Parse(attributeDateLayout, str)
+		if err != nil {
+			return attrValue{}, invalid("a date (YYYY-MM-DD)")
+		}
+		return attrValue{Text: t.Format(attributeDateLayout), Date: &t}, nil
+
+	case AttrTypeEnum:
+		if isString {
+			for _, e := range def.EnumValues {
+				if e == str {
+					return attrValue{Text: str}, nil
+				}
+			}
+		}
+		return attrValue{}, invalid("one of " + strings.Join(def.EnumValues, ", "))
+	}
+	return attrValue{}, ErrInternal(fmt.Sprintf("attribute %q has unknown type %q", def.Key, def.Type))
+}
+
+// attributeJSONValue 保存した文字列を返却用の値にする
+func attributeJSONValue(typ, text string) any {
+	if typ == AttrTypeNumber {
+		if f, err := strconv.ParseFloat(text, 64); err == nil {
+			return f
+		}
+	}
+	return text
+}
+
+// validateAttributes は scope の項目の入力を検証し、attribute_id ごとの変更を返す（nil は削除）。
+// stored は保存済みの値がある attribute_id。partial なら指定されたキーだけの更新として必須項目を判定する
+func validateAttributes(defs []AttributeDefinitionResponse, scope string, in map[string]any, stored map[uint64]bool, partial bool) (map[uint64]*attrValue, error) {
+	byKey := make(map[string]AttributeDefinitionResponse, len(defs))
+	for _, d := range defs {
+		byKey[d.Key] = d
+	}
+
+	keys := make([]string, 0, len(in))
+	for k := range in {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	changes := make(map[uint64]*attrValue, len(in))
+	for _, k := range keys {
+		def, ok := byKey[strings.ToLower(strings.TrimSpace(k))]
+		if !ok {
+			return nil, ErrInvalid(fmt.Sprintf("unknown attribute %q for this genre", k))
+		}
+		if def.Scope != scope {
+			return nil, ErrInvalid(fmt.Sprintf("attribute %q belongs to the %s", def.Key, def.Scope))
+		}
+		v := in[k]
+		if isEmptyAttributeValue(v) {
+			if partial {
+				changes[def.AttributeID] = nil
+			}
+			continue
+		}
+		val, err := normalizeAttributeValue(def, v)
+		if err != nil {
+			return nil, err
+		}
+		changes[def.AttributeID] = &val
+	}
+
+	for _, def := range defs {
+		if def.Scope != scope || !def.Required {
+			continue
+		}
+		val, changed := changes[def.AttributeID]
+		if changed && val != nil {
+			continue
+		}
+		if !changed && partial && stored[def.AttributeID] {
+			continue
+		}
+		return nil, ErrInvalid(fmt.Sprintf("attribute %q is required", def.Key))
+	}
+	return changes, nil
+}
+
+// splitAttributesByScope CSV の attr.<key> 列（Master.Attributes に集めてある）のうち scope=asset のものを Asset.Attributes に移す
+func splitAttributesByScope(defs []AttributeDefinitionResponse, req *CreateAssetSetRequest) {
+	scopes := make(map[string]string, len(defs))
+	for _, d := range defs {
+		scopes[d.Key] = d.Scope
+	}
+	for k, v := range req.Master.Attributes {
+		if scopes[k] != AttrScopeAsset {
+			continue
+		}
+		if req.Asset.Attributes == nil {
+			req.Asset.Attributes = map[string]any{}
+		}
+		req.Asset.Attributes[k] = v
+		delete(req.Master.Attributes, k)
+	}
+}
+
+// fillAttributes 返却するマスタ・個体に現在のジャンルの追加項目を入れる
+func (s *Service) fillAttributes(ctx context.Context, masters []*AssetMasterResponse, items []*AssetResponse) error {
+	if len(masters) > 0 {
+		ids := make([]uint64, 0, len(masters))
+		for _, m := range masters {
+			ids = append(ids, m.AssetMasterID)
+		}
+		vals, err := s.store.LoadMasterAttributes(ctx, ids)
+		if err != nil {
+			return err
+		}
+		for _, m := range masters {
+			m.Attributes = vals[m.AssetMasterID]
+		}
+	}
+	if len(items) > 0 {
+		ids := make([]uint64, 0, len(items))
+		for _, a := range items {
+			ids = append(ids, a.AssetID)
+		}
+		vals, err := s.store.LoadAssetAttributes(ctx, ids)
+		if err != nil {
+			return err
+		}
+		for _, a := range items {
+			a.Attributes = vals[a.AssetID]
+		}
+	}
+	return nil
+}
+
+func (s *Service) fillSetAttributes(ctx context.Context, sets []AssetSetResponse) error {
+	masters := make([]*AssetMasterResponse, 0, len(sets))
+	items := make([]*AssetResponse, 0, len(sets))
+	for i := range sets {
+		masters = append(masters, &sets[i].Master)
+		items = append(items, &sets[i].Asset)
+	}
+	return s.fillAttributes(ctx, masters, items)
+}
+
+// ===== 検索条件 =====
+
+// IsAssetSearchQueryKey は ParseAssetSearchQuery が解釈するキーか（attr.<key>[.min|.max] を含む）
+func IsAssetSearchQueryKey(k string) bool {
+	if strings.HasPrefix(k, searchAttributePrefix) {
+		_, _, ok := splitAttributeSearchKey(k)
+		return ok
+	}
+	for _, known := range AssetSearchQueryKeys {
+		if known == k {
+			return true
+		}
+	}
+	return false
+}
+
+// splitAttributeSearchKey attr.<key>[.min|.max] を key と min / max / 空に分ける
+func splitAttributeSearchKey(k string) (key, bound string, ok bool) {
+	rest, found := strings.CutPrefix(k, searchAttributePrefix)
+	if !found {
+		return "", "", false
+	}
+	key = rest
+	if i := strings.IndexByte(rest, '.'); i >= 0 {
+		key, bound = rest[:i], rest[i+1:]
+		if bound != "min" && bound != "max" {
+			return "", "", false
+		}
+	}
+	return key, bound, attributeKeyPattern.MatchString(key)
+}
+
+// parseAttributeFilters は attr.* の検索パラメータを key の順にまとめる
+func parseAttributeFilters(values map[string][]string) ([]AttributeFilter, error) {
+	byKey := map[string]*AttributeFilter{}
+	for k, vs := range values {
+		if !strings.HasPrefix(k, searchAttributePrefix) || len(vs) == 0 {
+			continue
+		}
+		key, bound, ok := splitAttributeSearchKey(k)
+		if !ok {
+			return nil, fmt.Errorf("invalid attribute filter %q", k)
+		}
+		v := strings.TrimSpace(vs[0])
+		if v == "" {
+			continue
+		}
+		f := byKey[key]
+		if f == nil {
+			f = &AttributeFilter{Key: key}
+			byKey[key] = f
+		}
+		switch bound {
+		case "":
+			f.Value = &v
+		case "min", "max":
+			if !isAttributeBound(v) {
+				return nil, fmt.Errorf("%s must be a number or YYYY-MM-DD", k)
+			}
+			if bound == "min" {
+				f.Min = &v
+			} else {
+				f.Max = &v
+			}
+		}
+	}
+	out := make([]AttributeFilter, 0, len(byKey))
+	for _, f := range byKey {
+		out = append(out, *f)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+func isAttributeBound(v string) bool {
+	if _, err := time.Parse(attributeDateLayout, v); err == nil {
+		return true
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	return err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+}
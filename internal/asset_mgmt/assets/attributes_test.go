@@ -0,0 +1,278 @@
+package assets
+
+import (
+	"context"
+	"database/sql/driver"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testAttributeDefinitions() []AttributeDefinitionResponse {
+	return []AttributeDefinitionResponse{
+		{AttributeID: 1, Key: "lens_mount", Type: AttrTypeEnum, Scope: AttrScopeMaster, Required: true, EnumValues: []string{"EF", "RF"}},
+		{AttributeID: 2, Key: "weight_g", Type: AttrTypeNumber, Scope: AttrScopeMaster},
+		{AttributeID: 3, Key: "calibrated_on", Type: AttrTypeDate, Scope: AttrScopeAsset, Required: true},
+		{AttributeID: 4, Key: "memo", Type: AttrTypeString, Scope: AttrScopeAsset},
+	}
+}
+
+func TestNormalizeAttributeDefinition(t *testing.T) {
+	got, err := normalizeAttributeDefinition(AttributeDefinitionRequest{
+		Key: " Lens_Mount ", Label: " マウント ", Type: "ENUM", EnumValues: []string{" EF", "RF "},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Key != "lens_mount" || got.Label != "マウント" || got.Type != AttrTypeEnum || got.Scope != AttrScopeMaster {
+		t.Fatalf("unexpected normalized definition: %+v", got)
+	}
+	if strings.Join(got.EnumValues, ",") != "EF,RF" {
+		t.Fatalf("unexpected enum values: %v", got.EnumValues)
+	}
+
+	bad := []AttributeDefinitionRequest{
+		{Key: "1st", Label: "x", Type: AttrTypeString},
+		{Key: "lens-mount", Label: "x", Type: AttrTypeString},
+		{Key: "k", Label: "", Type: AttrTypeString},
+		{Key: "k", Label: "x", Type: "bool"},
+		{Key: "k", Label: "x", Type: AttrTypeString, Scope: "genre"},
+		{Key: "k", Label: "x", Type: AttrTypeEnum},
+		{Key: "k", Label: "x", Type: AttrTypeEnum, EnumValues: []string{"A", "A"}},
+		{Key: "k", Label: "x", Type: AttrTypeNumber, EnumValues: []string{"A"}},
+	}
+	for _, in := range bad {
+		if _, err := normalizeAttributeDefinition(in); err == nil {
+			t.Errorf("expected error for %+v", in)
+		}
+	}
+}
+
+func TestValidateAttributesOnCreate(t *testing.T) {
+	defs := testAttributeDefinitions()
+
+	got, err := validateAttributes(defs, AttrScopeMaster, map[string]any{"lens_mount": "RF", "weight_g": "650.50"}, nil, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got[1] == nil || got[1].Text != "RF" {
+		t.Fatalf("unexpected lens_mount: %+v", got[1])
+	}
+	if got[2] == nil || got[2].Text != "650.5" || got[2].Number == nil || *got[2].Number != 650.5 {
+		t.Fatalf("unexpected weight_g: %+v", got[2])
+	}
+
+	cases := []struct {
+		name  string
+		scope string
+		in    map[string]any
+		want  string
+	}{
+		{"missing required", AttrScopeMaster, map[string]any{"weight_g": 1.0}, `"lens_mount" is required`},
+		{"empty required", AttrScopeMaster, map[string]any{"lens_mount": " "}, `"lens_mount" is required`},
+		{"unknown key", AttrScopeMaster, map[string]any{"lens_mount": "EF", "color": "red"}, `unknown attribute "color"`},
+		{"wrong scope", AttrScopeMaster, map[string]any{"lens_mount": "EF", "memo": "x"}, `belongs to the asset`},
+		{"enum outside options", AttrScopeMaster, map[string]any{"lens_mount": "E"}, "one of EF, RF"},
+		{"number from bool", AttrScopeMaster, map[string]any{"lens_mount": "EF", "weight_g": true}, "must be a number"},
+		{"bad date", AttrScopeAsset, map[string]any{"calibrated_on": "2026/01/31"}, "YYYY-MM-DD"},
+		{"too long string", AttrScopeAsset, map[string]any{"calibrated_on": "2026-01-31", "memo": strings.Repeat("あ", 256)}, "at most 255"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := validateAttributes(defs, tc.scope, tc.in, nil, false)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestValidateAttributesPartialUpdate(t *testing.T) {
+	defs := testAttributeDefinitions()
+	stored := map[uint64]bool{3: true}
+
+	got, err := validateAttributes(defs, AttrScopeAsset, map[string]any{"memo": nil}, stored, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v, ok := got[4]; !ok || v != nil {
+		t.Fatalf("expected memo to be deleted, got %+v", got)
+	}
+
+	if _, err := validateAttributes(defs, AttrScopeAsset, map[string]any{"calibrated_on": nil}, stored, true); err == nil {
+		t.Fatal("expected error when clearing a required attribute")
+	}
+	// 別ジャンルへ移したときなど、必須項目の値が無ければ求める
+	if _, err := validateAttributes(defs, AttrScopeMaster, nil, stored, true); err == nil {
+		t.Fatal("expected error for missing required master attribute")
+	}
+}
+
+func TestSplitAttributesByScope(t *testing.T) {
+	req := CreateAssetSetRequest{}
+	req.Master.Attributes = map[string]any{"lens_mount": "EF", "calibrated_on": "2026-04-01", "color": "red"}
+
+	splitAttributesByScope(testAttributeDefinitions(), &req)
+
+	if _, ok := req.Asset.Attributes["calibrated_on"]; !ok || len(req.Asset.Attributes) != 1 {
+		t.Fatalf("expected calibrated_on moved to asset, got %v", req.Asset.Attributes)
+	}
+	// 未知のキーは master に残し、検証でエラーにする
+	if len(req.Master.Attributes) != 2 || req.Master.Attributes["color"] != "red" {
+		t.Fatalf("unexpected master attributes: %v", req.Master.Attributes)
+	}
+}
+
+func TestParseAssetSetFromCSVRowCollectsAttributes(t *testing.T) {
+	col := map[string]int{
+		"name": 0, "management_category_id": 1, "genre_id": 2, "manufacturer": 3,
+		"purchased_at": 4, "status_id": 5, "owner": 6, "default_location": 7,
+		"attr.lens_mount": 8, "attr.memo": 9,
+	}
+	rec := []string{"Camera", "1", "5", "Canon", "2026-05-07T00:00:00Z", "1", "HQ", "Shelf", " RF ", ""}
+
+	got, err := parseAssetSetFromCSVRow(rec, col)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.Master.Attributes) != 1 || got.Master.Attributes["lens_mount"] != "RF" {
+		t.Fatalf("unexpected attributes: %v", got.Master.Attributes)
+	}
+}
+
+func TestParseAttributeFilters(t *testing.T) {
+	v := url.Values{}
+	v.Set("attr.lens_mount", "RF")
+	v.Set("attr.weight_g.max", "800")
+	v.Set("attr.calibrated_on.min", "2026-01-01")
+	v.Set("name", "Camera")
+
+	q, err := ParseAssetSearchQuery(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(q.Attributes) != 3 {
+		t.Fatalf("expected 3 attribute filters, got %+v", q.Attributes)
+	}
+	if f := q.Attributes[0]; f.Key != "calibrated_on" || f.Min == nil || *f.Min != "2026-01-01" {
+		t.Fatalf("unexpected filter: %+v", f)
+	}
+	if f := q.Attributes[1]; f.Key != "lens_mount" || f.Value == nil || *f.Value != "RF" {
+		t.Fatalf("unexpected filter: %+v", f)
+	}
+	if f := q.Attributes[2]; f.Key != "weight_g" || f.Max == nil || *f.Max != "800" {
+		t.Fatalf("unexpected filter: %+v", f)
+	}
+
+	for _, k := range []string{"attr.weight_g.avg", "attr.Weight", "attr."} {
+		bad := url.Values{}
+		bad.Set(k, "1")
+		if _, err := ParseAssetSearchQuery(bad); err == nil {
+			t.Errorf("expected error for %s", k)
+		}
+	}
+	bad := url.Values{}
+	bad.Set("attr.weight_g.min", "heavy")
+	if _, err := ParseAssetSearchQuery(bad); err == nil {
+		t.Error("expected error for non-numeric bound")
+	}
+}
+
+func TestIsAssetSearchQueryKey(t *testing.T) {
+	for _, k := range []string{"name", "genre_id", "attr.lens_mount", "attr.weight_g.min"} {
+		if !IsAssetSearchQueryKey(k) {
+			t.Errorf("expected %s to be accepted", k)
+		}
+	}
+	for _, k := range []string{"attr.", "attr.x.y", "unknown"} {
+		if IsAssetSearchQueryKey(k) {
+			t.Errorf("expected %s to be rejected", k)
+		}
+	}
+}
+
+func TestBuildSearchAssetsQueryAttributeFilters(t *testing.T) {
+	value, maxWeight, from := "R_F", "800", "2026-01-01"
+	query, args := buildSearchAssetsQuery(AssetSearchQuery{Attributes: []AttributeFilter{
+		{Key: "lens_mount", Value: &value},
+		{Key: "weight_g", Max: &maxWeight},
+		{Key: "calibrated_on", Min: &from},
+	}})
+
+	for _, frag := range []string{
+		"d.genre_id = m.genre_id",
+		"mv.asset_master_id = m.asset_master_id",
+		"av.asset_id = a.asset_id",
+		"WHEN 'string' THEN COALESCE(mv.value_text, av.value_text) LIKE ? ESCAPE '\\'",
+		"COALESCE(mv.value_number, av.value_number) <= ?",
+		"COALESCE(mv.value_date, av.value_date) >= ?",
+	} {
+		if !strings.Contains(query, frag) {
+			t.Errorf("expected query to contain %q", frag)
+		}
+	}
+	if strings.Count(query, "EXISTS (") != 3 {
+		t.Fatalf("expected one EXISTS per filter:\n%s", query)
+	}
+
+	want := []any{"lens_mount", `%R\_F%`, nil, "R_F", "weight_g", 800.0, "calibrated_on", "2026-01-01"}
+	if len(args) != len(want) {
+		t.Fatalf("unexpected args: %#v", args)
+	}
+	for i := range want {
+		if args[i] != want[i] {
+			t.Fatalf("arg %d: want %#v, got %#v", i, want[i], args[i])
+		}
+	}
+}
+
+func TestUpdateAttributeDefinitionChecksEnumUseUnderLock(t *testing.T) {
+	at := time.Date(2026, time.May, 1, 0, 0, 0, 0, time.UTC)
+	def := []driver.Value{int64(1), int64(3), "lens_mount", "マウント", AttrTypeEnum, AttrScopeMaster, false, `["EF","RF"]`, int64(0), at, at}
+	newConn := func(inUse int64) *fakeConn {
+		return &fakeConn{rows: map[string][][]driver.Value{
+			"WHERE attribute_id = ? FOR UPDATE":                   {def},
+			"FROM genre_attribute_definitions WHERE attribute_id": {def},
+			"COUNT(*)": {{inUse}},
+		}}
+	}
+	req := AttributeDefinitionRequest{Key: "lens_mount", Label: "マウント", Type: AttrTypeEnum, EnumValues: []string{"RF", "E"}}
+
+	conn := newConn(2)
+	svc := NewService(newFakeDB(conn), nil)
+	if _, err := svc.UpdateAttributeDefinition(context.Background(), 1, req); toHTTPStatus(err) != 409 || !strings.Contains(err.Error(), "EF") {
+		t.Fatalf("expected 409 for a removed enum value in use, got %v", err)
+	}
+	if conn.exec("UPDATE genre_attribute_definitions") != nil {
+		t.Fatal("definition must not be updated while a removed value is in use")
+	}
+
+	conn = newConn(0)
+	svc = NewService(newFakeDB(conn), nil)
+	if _, err := svc.UpdateAttributeDefinition(context.Background(), 1, req); err != nil {
+		t.Fatalf("UpdateAttributeDefinition returned error: %v", err)
+	}
+	update := conn.exec("UPDATE genre_attribute_definitions")
+	if update == nil || update.args[3] != `["RF","E"]` {
+		t.Fatalf("expected enum values updated, got %+v", update)
+	}
+}
+
+func TestCreateAssetMasterValidatesAttributesUnderSharedLock(t *testing.T) {
+	at := time.Date(2026, time.May, 1, 0, 0, 0, 0, time.UTC)
+	def := []driver.Value{int64(1), int64(3), "lens_mount", "マウント", AttrTypeEnum, AttrScopeMaster, true, `["EF","RF"]`, int64(0), at, at}
+	// 定義は書き込みと同じ tx で FOR SHARE で読む（ロックなしの読み取りは unexpected query になる）
+	conn := &fakeConn{rows: map[string][][]driver.Value{
+		"ORDER BY sort_order, attribute_id FOR SHARE": {def},
+	}}
+	svc := NewService(newFakeDB(conn), nil)
+
+	req := CreateAssetMasterRequest{Name: "EOS R", Manufacturer: "Canon", ManagementCategoryID: 1, GenreID: 3}
+	if _, err := svc.CreateAssetMaster(context.Background(), req); toHTTPStatus(err) != 400 {
+		t.Fatalf("expected 400 for a missing required attribute, got %v", err)
+	}
+	if len(conn.execs) != 0 {
+		t.Fatalf("nothing should be written, got %+v", conn.execs)
+	}
+}
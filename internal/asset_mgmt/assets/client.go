@@ -2,125 +2,207 @@ package assets
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
+	"database/sql"
+	"errors"
+	"log"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
 
-// JANClient は外部APIとの通信を担当する
-type JANClient struct {
-	yahooAppID string
-	httpClient *http.Client
+// JAN/ISBN から商品情報を引く
+// 1. 手入力の商品カタログ（product_catalog）
+// 2. 外部プロバイダの結果キャッシュ（product_lookup_cache。見つからなかった結果も短めの期限で持つ）
+// 3. 外部プロバイダ（JANClientConfig.Providers の順）
+
+const (
+	DefaultProductCacheTTL         = 30 * 24 * time.Hour
+	DefaultProductNegativeCacheTTL = 24 * time.Hour
+	DefaultProductLookupTimeout    = 5 * time.Second
+)
+
+// DefaultProductProviders 設定で順序を指定しないときの問い合わせ順
+var DefaultProductProviders = []string{ProductProviderOpenBD, ProductProviderYahoo}
+
+// JANClientConfig は config.yaml の product_lookup / yahoo から組み立てる
+type JANClientConfig struct {
+	YahooAppID       string
+	Providers        []string      // 外部プロバイダの問い合わせ順（openbd, yahoo）
+	CacheTTL         time.Duration // 0 なら DefaultProductCacheTTL
+	NegativeCacheTTL time.Duration // 0 なら DefaultProductNegativeCacheTTL
+	Timeout          time.Duration // 0 なら DefaultProductLookupTimeout
 }
 
-func NewJANClient(appID string) *JANClient {
-	return &JANClient{
-		yahooAppID: appID,
-		httpClient: &http.Client{Timeout: 5 * time.Second},
+// providerNames は問い合わせるプロバイダ名（未指定なら DefaultProductProviders）
+func (cfg JANClientConfig) providerNames() []string {
+	if len(cfg.Providers) == 0 {
+		return DefaultProductProviders
 	}
+	return cfg.Providers
 }
 
-// 内部用：Yahoo APIのレスポンス構造体
-type yahooSearchResponse struct {
-	Hits []struct {
-		Name  string `json:"name"`
-		Brand struct {
-			Name string `json:"name"`
-		} `json:"brand"`
-	} `json:"hits"`
+// productCatalog 手入力の商品カタログ。無ければ sql.ErrNoRows
+type productCatalog interface {
+	GetCatalogProduct(ctx context.Context, janCode string) (*ProductCandidate, error)
 }
 
-// 内部用：OpenBDのレスポンス構造体
-type openBDResponse []struct {
-	Summary struct {
-		Title     string `json:"title"`
-		Publisher string `json:"publisher"`
-		Author    string `json:"author"`
-	} `json:"summary"`
+// productCache 外部プロバイダの結果キャッシュ。無い・期限切れなら sql.ErrNoRows
+type productCache interface {
+	GetCachedProduct(ctx context.Context, janCode string, now time.Time) (*cachedProduct, error)
+	PutCachedProduct(ctx context.Context, entry cachedProduct) error
 }
 
-// FetchJANInfo はJAN/ISBNに応じた情報を取得する
-func (c *JANClient) FetchJANInfo(ctx context.Context, janCode string) (JANLookupResponse, error) {
-	isISBN := (strings.HasPrefix(janCode, "978") || strings.HasPrefix(janCode, "979")) && len(janCode) == 13
-
-	if isISBN {
-		res, err := c.lookupOpenBD(ctx, janCode)
-		if err == nil {
-			return res, nil
-		}
-	}
-	return c.lookupYahoo(ctx, janCode)
+type cachedProduct struct {
+	JANCode   string
+	Found     bool
+	Product   JANLookupResponse
+	FetchedAt time.Time
+	ExpiresAt time.Time
 }
 
-func (c *JANClient) lookupOpenBD(ctx context.Context, isbn string) (JANLookupResponse, error) {
-	url := "https://api.openbd.jp/v1/get?isbn=" + isbn
-	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return JANLookupResponse{}, err
-	}
-	defer resp.Body.Close()
+// JANClient はカタログ・キャッシュ・外部プロバイダを順に引く
+type JANClient struct {
+	catalog          productCatalog
+	cache            productCache
+	providers        []ProductLookupProvider
+	skipped          bool // 設定にあるのに問い合わせられないプロバイダがある
+	cacheTTL         time.Duration
+	negativeCacheTTL time.Duration
+	now              func() time.Time
+}
 
-	var obdRes openBDResponse
-	if err := json.NewDecoder(resp.Body).Decode(&obdRes); err != nil {
-		return JANLookupResponse{}, err
+func NewJANClient(cfg JANClientConfig, db *sql.DB) *JANClient {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultProductLookupTimeout
 	}
+	httpClient := &http.Client{Timeout: timeout}
 
-	if len(obdRes) == 0 || obdRes[0].Summary.Title == "" {
-		return JANLookupResponse{}, ErrNotFound("not found in openbd")
+	var (
+		providers []ProductLookupProvider
+		skipped   bool
+	)
+	for _, name := range cfg.providerNames() {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case ProductProviderOpenBD:
+			providers = append(providers, newOpenBDProvider(httpClient, openBDBaseURL))
+		case ProductProviderYahoo:
+			if cfg.YahooAppID == "" {
+				log.Printf("[WARN] product lookup: yahoo skipped (app_id is not configured)")
+				skipped = true
+				continue
+			}
+			providers = append(providers, newYahooProvider(httpClient, yahooBaseURL, cfg.YahooAppID))
+		default:
+			log.Printf("[WARN] product lookup: unknown provider %q ignored", name)
+		}
 	}
 
-	return JANLookupResponse{
-		Name:         obdRes[0].Summary.Title,
-		Manufacturer: obdRes[0].Summary.Publisher,
-	}, nil
+	store := NewStore(db)
+	c := newJANClient(store, store, providers, cfg.CacheTTL, cfg.NegativeCacheTTL)
+	c.skipped = skipped
+	return c
 }
 
-func (c *JANClient) lookupYahoo(ctx context.Context, janCode string) (JANLookupResponse, error) {
-	if c.yahooAppID == "" {
-		return JANLookupResponse{}, ErrInternal("Yahoo API AppID is not configured")
+func newJANClient(catalog productCatalog, cache productCache, providers []ProductLookupProvider, ttl, negativeTTL time.Duration) *JANClient {
+	if ttl <= 0 {
+		ttl = DefaultProductCacheTTL
+	}
+	if negativeTTL <= 0 {
+		negativeTTL = DefaultProductNegativeCacheTTL
+	}
+	return &JANClient{
+		catalog:          catalog,
+		cache:            cache,
+		providers:        providers,
+		cacheTTL:         ttl,
+		negativeCacheTTL: negativeTTL,
+		now:              time.Now,
 	}
+}
 
-	url := fmt.Sprintf("https://shopping.yahooapis.jp/ShoppingWebService/V3/itemSearch?appid=%s&jan_code=%s&results=1", c.yahooAppID, janCode)
-	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+// FetchJANInfo はJAN/ISBNに応じた情報を取得する。最初に当たったところの候補を返す（ジャンルの提案は付けない）。
+// どのプロバイダにも無ければ ErrNotFound（スキップしたプロバイダが無ければキャッシュする）、通信失敗などはそのエラーを返す（キャッシュしない）
+func (c *JANClient) FetchJANInfo(ctx context.Context, janCode string) (JANLookupResponse, error) {
+	janCode = strings.TrimSpace(janCode)
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return JANLookupResponse{}, err
+	p, err := c.catalog.GetCatalogProduct(ctx, janCode)
+	if err == nil {
+		p.Confidence = catalogConfidence
+		p.Source = ProductSourceCatalog
+		return newJANLookupResponse([]ProductCandidate{*p}), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("[WARN] product catalog(%s): %v", janCode, err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return JANLookupResponse{}, ErrInternal(fmt.Sprintf("Yahoo API returned status: %d", resp.StatusCode))
+	now := c.now()
+	entry, err := c.cache.GetCachedProduct(ctx, janCode, now)
+	if err == nil {
+		if !entry.Found {
+			return JANLookupResponse{}, ErrNotFound("item not found by jan_code")
+		}
+		return entry.Product, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("[WARN] product lookup cache(%s): %v", janCode, err)
 	}
 
-	var yRes yahooSearchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&yRes); err != nil {
-		return JANLookupResponse{}, err
+	var firstErr error
+	for _, provider := range c.providers {
+		cands, err := provider.Lookup(ctx, janCode)
+		if err == nil && len(cands) > 0 {
+			res := newJANLookupResponse(cands)
+			c.putCache(ctx, cachedProduct{JANCode: janCode, Found: true, Product: res, FetchedAt: now, ExpiresAt: now.Add(c.cacheTTL)})
+			return res, nil
+		}
+		if err == nil {
+			continue
+		}
+		if isNotFoundError(err) {
+			continue
+		}
+		log.Printf("[WARN] product lookup %s(%s): %v", provider.Name(), janCode, redactLookupError(err))
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		// 一時的な失敗かもしれないので「見つからない」とは記録しない
+		return JANLookupResponse{}, firstErr
 	}
 
-	if len(yRes.Hits) == 0 {
-		return JANLookupResponse{}, ErrNotFound("item not found by jan_code")
+	// 問い合わせなかったプロバイダにはあるかもしれないので「見つからない」とは記録しない
+	if !c.skipped {
+		c.putCache(ctx, cachedProduct{JANCode: janCode, Found: false, FetchedAt: now, ExpiresAt: now.Add(c.negativeCacheTTL)})
 	}
+	return JANLookupResponse{}, ErrNotFound("item not found by jan_code")
+}
 
-	hit := yRes.Hits[0]
-	name := cleanItemName(hit.Name)
-	manufacturer := hit.Brand.Name
+func (c *JANClient) putCache(ctx context.Context, entry cachedProduct) {
+	if err := c.cache.PutCachedProduct(ctx, entry); err != nil {
+		log.Printf("[WARN] product lookup cache(%s): %v", entry.JANCode, err)
+	}
+}
 
-	if manufacturer == "" {
-		parts := strings.Split(name, " ")
-		if len(parts) > 0 {
-			manufacturer = parts[0]
-		}
+// redactLookupError は通信エラーの URL からクエリ（appid を含む）を取り除く
+func redactLookupError(err error) error {
+	var ue *url.Error
+	if !errors.As(err, &ue) {
+		return err
+	}
+	u, perr := url.Parse(ue.URL)
+	if perr != nil {
+		return &url.Error{Op: ue.Op, URL: "(redacted)", Err: ue.Err}
 	}
+	u.RawQuery = ""
+	return &url.Error{Op: ue.Op, URL: u.String(), Err: ue.Err}
+}
 
-	return JANLookupResponse{
-		Name:         name,
-		Manufacturer: manufacturer,
-	}, nil
+func isNotFoundError(err error) bool {
+	var api *APIError
+	return errors.As(err, &api) && api.Code == CodeNotFound
 }
 
 func cleanItemName(name string) string {
@@ -128,4 +210,4 @@ func cleanItemName(name string) string {
 		"【翌日発送】", "", "翌日発送・", "", "送料無料", "", "【新品】", "", "】", " ", "【", "",
 	)
 	return strings.TrimSpace(strings.ReplaceAll(replacer.Replace(name), "　", " "))
-}
\ No newline at end of file
+}
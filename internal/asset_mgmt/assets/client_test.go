@@ -0,0 +1,215 @@
+package assets
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fixtureServer は testdata/product_lookup の記録済みレスポンスを返す openBD / Yahoo の代わり
+func fixtureServer(t *testing.T) (*httptest.Server, *int) {
+	t.Helper()
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+	serve := func(w http.ResponseWriter, name, fallback string) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		body, err := os.ReadFile(filepath.Join("testdata", "product_lookup", name))
+		if err != nil {
+			body = []byte(fallback)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/get", func(w http.ResponseWriter, r *http.Request) {
+		serve(w, "openbd_"+r.URL.Query().Get("isbn")+".json", "[null]")
+	})
+	mux.HandleFunc("/ShoppingWebService/V3/itemSearch", func(w http.ResponseWriter, r *http.Request) {
+		jan := r.URL.Query().Get("jan_code")
+		if jan == "4900000000003" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		serve(w, "yahoo_"+jan+".json", `{"hits":[]}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+type memoryProductStore struct {
+	catalog map[string]ProductCandidate
+	cache   map[string]cachedProduct
+}
+
+func newMemoryProductStore() *memoryProductStore {
+	return &memoryProductStore{catalog: map[string]ProductCandidate{}, cache: map[string]cachedProduct{}}
+}
+
+func (m *memoryProductStore) GetCatalogProduct(_ context.Context, janCode string) (*ProductCandidate, error) {
+	p, ok := m.catalog[janCode]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &p, nil
+}
+
+func (m *memoryProductStore) GetCachedProduct(_ context.Context, janCode string, now time.Time) (*cachedProduct, error) {
+	e, ok := m.cache[janCode]
+	if !ok || !e.ExpiresAt.After(now) {
+		return nil, sql.ErrNoRows
+	}
+	return &e, nil
+}
+
+func (m *memoryProductStore) PutCachedProduct(_ context.Context, e cachedProduct) error {
+	m.cache[e.JANCode] = e
+	return nil
+}
+
+func newFixtureJANClient(t *testing.T, order ...string) (*JANClient, *memoryProductStore, *int) {
+	t.Helper()
+	srv, calls := fixtureServer(t)
+	var providers []ProductLookupProvider
+	for _, name := range order {
+		switch name {
+		case ProductProviderOpenBD:
+			providers = append(providers, newOpenBDProvider(srv.Client(), srv.URL))
+		case ProductProviderYahoo:
+			providers = append(providers, newYahooProvider(srv.Client(), srv.URL, "test-app"))
+		}
+	}
+	store := newMemoryProductStore()
+	c := newJANClient(store, store, providers, time.Hour, time.Minute)
+	now := time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	return c, store, calls
+}
+
+func TestFetchJANInfoUsesProvidersInOrderAndCaches(t *testing.T) {
+	c, store, calls := newFixtureJANClient(t, ProductProviderOpenBD, ProductProviderYahoo)
+
+	book, err := c.FetchJANInfo(context.Background(), "9784873119465")
+	if err != nil || book.Name != "プログラミング言語Go" || book.Manufacturer != "丸善出版" || book.Source != ProductProviderOpenBD ||
+		book.Confidence != openBDConfidence || len(book.Candidates) != 1 {
+		t.Fatalf("unexpected openbd result %#v, %v", book, err)
+	}
+
+	mouse, err := c.FetchJANInfo(context.Background(), "4901234567894")
+	if err != nil || mouse.Name != "ワイヤレスマウス M-XGM10DB ブラック" || mouse.Manufacturer != "エレコム" || mouse.Source != ProductProviderYahoo {
+		t.Fatalf("unexpected yahoo result %#v, %v", mouse, err)
+	}
+	// 同じ型番の 3 出品は 1 件にまとまり、最安値と画像が付く
+	if mouse.Model != "M-XGM10DB" || mouse.Price == nil || *mouse.Price != 1780 || mouse.ImageURL == "" || len(mouse.Candidates) != 2 {
+		t.Fatalf("expected merged candidate, got %#v", mouse)
+	}
+	if mouse.Candidates[1].Name != "マウスパッド 大判 900mm 互換 M-XGM10DB 対応" || mouse.Candidates[1].Confidence >= mouse.Confidence {
+		t.Fatalf("expected accessory ranked below, got %#v", mouse.Candidates)
+	}
+	if *calls != 2 {
+		t.Fatalf("expected openbd skipped for non-ISBN (2 calls), got %d", *calls)
+	}
+
+	// 2 回目はキャッシュから
+	if again, err := c.FetchJANInfo(context.Background(), "4901234567894"); err != nil || !reflect.DeepEqual(again, mouse) {
+		t.Fatalf("expected cached result, got %#v, %v", again, err)
+	}
+	if *calls != 2 {
+		t.Fatalf("expected no HTTP call on cache hit, got %d", *calls)
+	}
+	if e := store.cache["4901234567894"]; e.ExpiresAt != c.now().Add(time.Hour) {
+		t.Fatalf("unexpected cache expiry %v", e.ExpiresAt)
+	}
+}
+
+func TestFetchJANInfoPrefersLocalCatalog(t *testing.T) {
+	c, store, calls := newFixtureJANClient(t, ProductProviderYahoo)
+	store.catalog["4901234567894"] = ProductCandidate{Name: "備品用マウス", Manufacturer: "エレコム"}
+
+	got, err := c.FetchJANInfo(context.Background(), "4901234567894")
+	if err != nil || got.Name != "備品用マウス" || got.Source != ProductSourceCatalog || got.Confidence != catalogConfidence {
+		t.Fatalf("expected catalog entry, got %#v, %v", got, err)
+	}
+	if *calls != 0 {
+		t.Fatalf("expected no HTTP call, got %d", *calls)
+	}
+}
+
+func TestFetchJANInfoNegativeCaching(t *testing.T) {
+	c, store, calls := newFixtureJANClient(t, ProductProviderYahoo)
+
+	if _, err := c.FetchJANInfo(context.Background(), "4912345678904"); toHTTPStatus(err) != http.StatusNotFound {
+		t.Fatalf("expected 404, got %v", err)
+	}
+	if e, ok := store.cache["4912345678904"]; !ok || e.Found || e.ExpiresAt != c.now().Add(time.Minute) {
+		t.Fatalf("expected negative cache entry, got %#v", e)
+	}
+	if _, err := c.FetchJANInfo(context.Background(), "4912345678904"); toHTTPStatus(err) != http.StatusNotFound || *calls != 1 {
+		t.Fatalf("expected cached 404 without HTTP call, got %v (%d calls)", err, *calls)
+	}
+
+	// 期限が切れたら問い合わせ直す
+	later := c.now().Add(2 * time.Minute)
+	c.now = func() time.Time { return later }
+	_, _ = c.FetchJANInfo(context.Background(), "4912345678904")
+	if *calls != 2 {
+		t.Fatalf("expected lookup after negative cache expiry, got %d calls", *calls)
+	}
+}
+
+func TestFetchJANInfoDoesNotCacheProviderErrors(t *testing.T) {
+	c, store, _ := newFixtureJANClient(t, ProductProviderYahoo)
+
+	if _, err := c.FetchJANInfo(context.Background(), "4900000000003"); err == nil || toHTTPStatus(err) == http.StatusNotFound {
+		t.Fatalf("expected provider error, got %v", err)
+	}
+	if _, ok := store.cache["4900000000003"]; ok {
+		t.Fatal("expected provider failure not cached")
+	}
+}
+
+func TestNewJANClientSkipsYahooWithoutAppID(t *testing.T) {
+	c := NewJANClient(JANClientConfig{}, nil)
+	if !c.skipped || len(c.providers) != 1 || c.providers[0].Name() != ProductProviderOpenBD {
+		t.Fatalf("expected yahoo skipped, got skipped=%v providers=%d", c.skipped, len(c.providers))
+	}
+	if c := NewJANClient(JANClientConfig{Providers: []string{"openbd"}}, nil); c.skipped {
+		t.Fatal("providers left out of the config are not skipped")
+	}
+}
+
+func TestFetchJANInfoDoesNotCacheNotFoundWhenProviderSkipped(t *testing.T) {
+	c, store, _ := newFixtureJANClient(t, ProductProviderOpenBD)
+	c.skipped = true
+
+	if _, err := c.FetchJANInfo(context.Background(), "4912345678904"); toHTTPStatus(err) != http.StatusNotFound {
+		t.Fatalf("expected 404, got %v", err)
+	}
+	if _, ok := store.cache["4912345678904"]; ok {
+		t.Fatal("expected not-found result not cached while a provider is skipped")
+	}
+}
+
+func TestRedactLookupErrorDropsQuery(t *testing.T) {
+	err := &url.Error{Op: "Get", URL: "https://shopping.yahooapis.jp/ShoppingWebService/V3/itemSearch?appid=secret&jan_code=4900000000003", Err: context.DeadlineExceeded}
+	got := redactLookupError(err).Error()
+	if strings.Contains(got, "secret") || !strings.Contains(got, "/ShoppingWebService/V3/itemSearch") {
+		t.Fatalf("unexpected redacted error %q", got)
+	}
+	if plain := ErrInternal("API returned status: 500"); redactLookupError(plain) != plain {
+		t.Fatal("errors without a URL must be returned as is")
+	}
+}
@@ -10,6 +10,8 @@ type CreateAssetMasterRequest struct {
 	GenreID              uint    `json:"genre_id" binding:"required"`
 	Manufacturer         string  `json:"manufacturer" binding:"required"`
 	Model                *string `json:"model,omitempty"`
+	// ジャンルごとの追加項目（scope=master のもの）。キーは GET /assets/genres/:genre_id/attributes の key
+	Attributes map[string]any `json:"attributes,omitempty"`
 }
 
 type UpdateAssetMasterRequest struct {
@@ -18,6 +20,8 @@ type UpdateAssetMasterRequest struct {
 	GenreID              *uint   `json:"genre_id,omitempty"`
 	Manufacturer         *string `json:"manufacturer,omitempty"`
 	Model                *string `json:"model,omitempty"`
+	// 指定したキーだけ更新する（null で削除）。ジャンルを変えた場合は新しいジャンルの必須項目が揃っている必要がある
+	Attributes map[string]any `json:"attributes,omitempty"`
 }
 
 type CreateAssetRequest struct {
@@ -32,6 +36,8 @@ type CreateAssetRequest struct {
 	LastCheckedAt   *time.Time `json:"last_checked_at,omitempty"`
 	LastCheckedBy   *string    `json:"last_checked_by,omitempty"`
 	Notes           *string    `json:"notes,omitempty"`
+	// ジャンルごとの追加項目（scope=asset のもの）
+	Attributes map[string]any `json:"attributes,omitempty"`
 }
 
 type UpdateAssetRequest struct {
@@ -45,6 +51,8 @@ type UpdateAssetRequest struct {
 	LastCheckedAt   *time.Time `json:"last_checked_at,omitempty"`
 	LastCheckedBy   *string    `json:"last_checked_by,omitempty"`
 	Notes           *string    `json:"notes,omitempty"`
+	// 指定したキーだけ更新する（null で削除）
+	Attributes map[string]any `json:"attributes,omitempty"`
 }
 
 type CreateAssetSetRequest struct {
@@ -52,6 +60,29 @@ type CreateAssetSetRequest struct {
 	Asset  CreateAssetRequest       `json:"asset" binding:"required"`
 }
 
+// NumberingSchemeRequest 管理番号の書式（POST/PUT /assets/numbering-schemes）。genre_id か management_category_id のどちらか一方
+type NumberingSchemeRequest struct {
+	GenreID              *uint  `json:"genre_id,omitempty"`
+	ManagementCategoryID *uint  `json:"management_category_id,omitempty"`
+	Pattern              string `json:"pattern" binding:"required" example:"{GENRE}-{FY}-{SEQ}"`
+	SequenceScope        string `json:"sequence_scope,omitempty" example:"genre"`       // global（既定）/ genre / category
+	SequenceReset        string `json:"sequence_reset,omitempty" example:"fiscal_year"` // never（既定）/ yearly / fiscal_year
+	Padding              int    `json:"padding,omitempty" example:"4"`                  // 連番の桁数（既定 5）
+	CheckDigit           string `json:"check_digit,omitempty" example:"luhn"`           // 空（なし）/ luhn
+}
+
+// AttributeDefinitionRequest ジャンルごとの追加項目の定義（POST /assets/genres/:genre_id/attributes, PUT /assets/attributes/:attribute_id）
+// type と scope は作成後に変更できない
+type AttributeDefinitionRequest struct {
+	Key        string   `json:"key" binding:"required" example:"lens_mount"` // 英小文字・数字・_（先頭は英字）
+	Label      string   `json:"label" binding:"required" example:"レンズマウント"`
+	Type       string   `json:"type" binding:"required" example:"enum"` // string / number / date / enum
+	Scope      string   `json:"scope,omitempty" example:"master"`       // master（既定。マスタに 1 つ）/ asset（個体ごと）
+	Required   bool     `json:"required"`
+	EnumValues []string `json:"enum_values,omitempty" example:"EF,RF,E"` // type=enum のときの選択肢
+	SortOrder  int      `json:"sort_order" example:"0"`
+}
+
 // ===== Responses =====
 
 type AssetMasterResponse struct {
@@ -63,28 +94,106 @@ type AssetMasterResponse struct {
 	Manufacturer         string    `json:"manufacturer"`
 	Model                *string   `json:"model,omitempty"`
 	CreatedAt            time.Time `json:"created_at"`
+	// ジャンルごとの追加項目（number は数値、それ以外は文字列。date は YYYY-MM-DD）
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+type AttributeDefinitionResponse struct {
+	AttributeID uint64    `json:"attribute_id"`
+	GenreID     uint      `json:"genre_id"`
+	Key         string    `json:"key"`
+	Label       string    `json:"label"`
+	Type        string    `json:"type"`
+	Scope       string    `json:"scope"`
+	Required    bool      `json:"required"`
+	EnumValues  []string  `json:"enum_values,omitempty"`
+	SortOrder   int       `json:"sort_order"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type NumberingSchemeResponse struct {
+	SchemeID             uint64    `json:"scheme_id"`
+	GenreID              *uint     `json:"genre_id"`
+	ManagementCategoryID *uint     `json:"management_category_id"`
+	Pattern              string    `json:"pattern"`
+	SequenceScope        string    `json:"sequence_scope"`
+	SequenceReset        string    `json:"sequence_reset"`
+	Padding              int       `json:"padding"`
+	CheckDigit           string    `json:"check_digit"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
 }
 
 type AssetResponse struct {
-	AssetID          uint64     `json:"asset_id"`
-	AssetMasterID    uint64     `json:"asset_master_id"`
-	ManagementNumber string     `json:"management_number"` //なんかで必要になったから入れたんだけど用途忘れた．削除禁止
-	Name             string     `json:"name"`              //フロントエンドで必要になったから追加．責任分離の観点から将来的に消したい
-	Serial           *string    `json:"serial,omitempty"`
-	Quantity         uint       `json:"quantity"`
-	PurchasedAt      time.Time  `json:"purchased_at"`
-	StatusID         uint       `json:"status_id"`
-	Owner            string     `json:"owner"`
-	DefaultLocation  string     `json:"default_location"`
-	Location         *string    `json:"location,omitempty"`
-	LastCheckedAt    *time.Time `json:"last_checked_at,omitempty"`
-	LastCheckedBy    *string    `json:"last_checked_by,omitempty"`
-	Notes            *string    `json:"notes,omitempty"`
+	AssetID          uint64         `json:"asset_id"`
+	AssetMasterID    uint64         `json:"asset_master_id"`
+	ManagementNumber string         `json:"management_number"` //なんかで必要になったから入れたんだけど用途忘れた．削除禁止
+	Name             string         `json:"name"`              //フロントエンドで必要になったから追加．責任分離の観点から将来的に消したい
+	Serial           *string        `json:"serial,omitempty"`
+	Quantity         uint           `json:"quantity"`
+	PurchasedAt      time.Time      `json:"purchased_at"`
+	StatusID         uint           `json:"status_id"`
+	Owner            string         `json:"owner"`
+	DefaultLocation  string         `json:"default_location"`
+	Location         *string        `json:"location,omitempty"`
+	LastCheckedAt    *time.Time     `json:"last_checked_at,omitempty"`
+	LastCheckedBy    *string        `json:"last_checked_by,omitempty"`
+	Notes            *string        `json:"notes,omitempty"`
+	Attributes       map[string]any `json:"attributes,omitempty"`
 }
 
 type AssetSetResponse struct {
 	Master AssetMasterResponse `json:"master"`
 	Asset  AssetResponse       `json:"asset"`
+	// 別名（旧システムの番号・統合前の番号）で引いたときの指定値。正式な番号は master.management_number
+	ResolvedAlias *string `json:"resolved_alias,omitempty"`
+}
+
+// AddManagementNumberAliasesRequest: POST /assets/masters/:management_number/aliases
+type AddManagementNumberAliasesRequest struct {
+	Aliases []string `json:"aliases" binding:"required"`
+}
+
+type ManagementNumberAliasResponse struct {
+	Alias            string    `json:"alias"`
+	AssetMasterID    uint64    `json:"asset_master_id"`
+	ManagementNumber string    `json:"management_number"` // 正式な管理番号
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// CreateAssetRelationRequest: POST /assets/masters/:management_number/relations（パスの管理番号が親）
+type CreateAssetRelationRequest struct {
+	ChildManagementNumber string  `json:"child_management_number" binding:"required"`
+	RelationType          string  `json:"relation_type"` // bundle（付属品・既定）/ component（構成部品）
+	Quantity              int     `json:"quantity"`      // 親 1 つあたりの数（省略時 1）
+	Note                  *string `json:"note,omitempty"`
+}
+
+type AssetRelationResponse struct {
+	RelationID             uint64    `json:"relation_id"`
+	RelationType           string    `json:"relation_type"`
+	ParentManagementNumber string    `json:"parent_management_number"`
+	ChildManagementNumber  string    `json:"child_management_number"`
+	Quantity               int       `json:"quantity"`
+	Note                   *string   `json:"note,omitempty"`
+	CreatedAt              time.Time `json:"created_at"`
+}
+
+// KitMemberResponse キット貸出で一緒に出る付属品（入れ子は展開済み）
+type KitMemberResponse struct {
+	ManagementNumber       string `json:"management_number"`
+	ParentManagementNumber string `json:"parent_management_number"`
+	Quantity               int    `json:"quantity"` // キット 1 セットあたり
+	Depth                  int    `json:"depth"`
+	AvailableQuantity      int    `json:"available_quantity"`
+}
+
+type AssetRelationsResponse struct {
+	ManagementNumber string                  `json:"management_number"`
+	Parent           *AssetRelationResponse  `json:"parent,omitempty"`
+	Children         []AssetRelationResponse `json:"children"`
+	Kit              []KitMemberResponse     `json:"kit"`
 }
 
 type ImportAssetsResponse struct {
@@ -106,11 +215,81 @@ type ImportRowResult struct {
 }
 
 // JANコード検索レスポンス（必要な項目のみ返す）
+// 先頭の項目は最も確からしい候補（candidates[0]）と同じ
 type JANLookupResponse struct {
-	Name         string `json:"name"`
+	Name             string             `json:"name"`
+	Manufacturer     string             `json:"manufacturer"`
+	Model            string             `json:"model,omitempty" example:"M-XGM10DB"`
+	Price            *int               `json:"price,omitempty" example:"1980"` // 円
+	ImageURL         string             `json:"image_url,omitempty"`
+	SuggestedGenreID *uint              `json:"suggested_genre_id,omitempty"`     // ジャンル推定ルールに当たったとき
+	Confidence       float64            `json:"confidence" example:"0.9"`         // 0〜1
+	Source           string             `json:"source,omitempty" example:"yahoo"` // catalog / openbd / yahoo
+	Candidates       []ProductCandidate `json:"candidates,omitempty"`             // 確からしい順
+}
+
+// ProductCandidate 商品情報の候補 1 件
+type ProductCandidate struct {
+	Name             string  `json:"name"`
+	Manufacturer     string  `json:"manufacturer"`
+	Model            string  `json:"model,omitempty"`
+	Price            *int    `json:"price,omitempty"`
+	ImageURL         string  `json:"image_url,omitempty"`
+	SuggestedGenreID *uint   `json:"suggested_genre_id,omitempty"`
+	Confidence       float64 `json:"confidence"`
+	Source           string  `json:"source"`
+}
+
+// GenreRuleRequest: POST/PUT /assets/genre-rules
+// 商品名・メーカー名に keyword を含む候補へ genre_id を提案する
+type GenreRuleRequest struct {
+	Keyword  string `json:"keyword" binding:"required" example:"マウス"`
+	GenreID  uint   `json:"genre_id" binding:"required" example:"3"`
+	Priority int    `json:"priority" example:"0"` // 大きいほど優先。同じなら長いキーワードを優先
+}
+
+type GenreRuleResponse struct {
+	RuleID    uint64    `json:"rule_id"`
+	Keyword   string    `json:"keyword"`
+	GenreID   uint      `json:"genre_id"`
+	Priority  int       `json:"priority"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PutProductCatalogRequest: PUT /assets/catalog/:jan_code（手入力の商品情報。外部 API より優先される）
+type PutProductCatalogRequest struct {
+	Name         string `json:"name" binding:"required"`
 	Manufacturer string `json:"manufacturer"`
 }
 
+type ProductCatalogEntry struct {
+	JANCode      string    `json:"jan_code"`
+	Name         string    `json:"name"`
+	Manufacturer string    `json:"manufacturer"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ScanResponse: GET /scan/:code
+// kind が management_number / alias / serial なら asset（シリアルが複数に一致したら matches）、jan なら draft が入る
+type ScanResponse struct {
+	Code    string                 `json:"code"`
+	Kind    string                 `json:"kind" example:"management_number"`
+	Asset   *AssetSetResponse      `json:"asset,omitempty"`
+	Matches []AssetSetResponse     `json:"matches,omitempty"`
+	Draft   *ScanRegistrationDraft `json:"draft,omitempty"`
+}
+
+// ScanRegistrationDraft 未登録の JAN から作る登録フォームの初期値
+type ScanRegistrationDraft struct {
+	JANCode      string  `json:"jan_code"`
+	Name         string  `json:"name"`
+	Manufacturer string  `json:"manufacturer"`
+	Model        string  `json:"model,omitempty"`
+	GenreID      *uint   `json:"genre_id,omitempty"`     // ジャンル推定ルールによる提案
+	LookupError  *string `json:"lookup_error,omitempty"` // 商品情報が引けなかった理由
+}
+
 // ===== API Specific Responses =====
 
 // ErrorDetail defines the detail of an API error.
@@ -153,6 +332,7 @@ type AssetSearchQuery struct {
 	AssetID                *uint64
 	AssetMasterID          *uint64
 	GenreID                *uint
+	GenreDescendants       bool // GenreID の子孫ジャンルも含める
 	GenreCode              *string
 	GenreName              *string
 	ManagementCategoryID   *uint
@@ -174,4 +354,15 @@ type AssetSearchQuery struct {
 	QuantityMin            *uint
 	QuantityMax            *uint
 	Notes                  *string
+	Attributes             []AttributeFilter // attr.<key>=... 形式の追加項目の条件（すべて満たすもの）
+	Limit                  int               // 0 なら件数を絞らない
+}
+
+// AttributeFilter 追加項目 1 つの条件。Value は string なら部分一致、それ以外は完全一致。
+// Min / Max は number なら数値、date なら YYYY-MM-DD（どちらも両端を含む）
+type AttributeFilter struct {
+	Key   string
+	Value *string
+	Min   *string
+	Max   *string
 }
@@ -0,0 +1,98 @@
+package assets
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+)
+
+// fakeConn は流れた SQL を記録するだけの database/sql ドライバ。
+// rows / results は SQL に含まれる部分文字列で引く（行が空なら sql.ErrNoRows になる）
+type fakeConn struct {
+	rows    map[string][][]driver.Value
+	results map[string]fakeResult
+	execs   []fakeExec
+}
+
+type fakeExec struct {
+	query string
+	args  []driver.Value
+}
+
+type fakeResult struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+func (r fakeResult) LastInsertId() (int64, error) { return r.lastInsertID, nil }
+func (r fakeResult) RowsAffected() (int64, error) { return r.rowsAffected, nil }
+
+func newFakeDB(c *fakeConn) *sql.DB { return sql.OpenDB(c) }
+
+func (c *fakeConn) Connect(context.Context) (driver.Conn, error)                 { return c, nil }
+func (c *fakeConn) Driver() driver.Driver                                        { return nil }
+func (c *fakeConn) Prepare(string) (driver.Stmt, error)                          { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error                                                 { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                                    { return c, nil }
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) { return c, nil }
+func (c *fakeConn) Commit() error                                                { return nil }
+func (c *fakeConn) Rollback() error                                              { return nil }
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	for key, rows := range c.rows {
+		if strings.Contains(query, key) {
+			return &fakeRows{values: rows}, nil
+		}
+	}
+	return nil, errors.New("unexpected query: " + query)
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e := fakeExec{query: query}
+	for _, a := range args {
+		e.args = append(e.args, a.Value)
+	}
+	c.execs = append(c.execs, e)
+	for key, r := range c.results {
+		if strings.Contains(query, key) {
+			return r, nil
+		}
+	}
+	return fakeResult{}, nil
+}
+
+// exec は query に key を含む Exec を返す（無ければ nil）
+func (c *fakeConn) exec(key string) *fakeExec {
+	for i := range c.execs {
+		if strings.Contains(c.execs[i].query, key) {
+			return &c.execs[i]
+		}
+	}
+	return nil
+}
+
+type fakeRows struct {
+	values [][]driver.Value
+	next   int
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.values) == 0 {
+		return nil
+	}
+	return make([]string, len(r.values[0]))
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
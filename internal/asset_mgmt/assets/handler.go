@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -22,6 +23,12 @@ func RegisterRoutes(r gin.IRoutes, svc *Service) {
 	r.GET("/assets/masters", h.ListAssetMasters)
 	r.GET("/assets/masters/:management_number", h.GetAssetMaster)
 	r.PUT("/assets/masters/:management_number", h.UpdateAssetMaster)
+	r.GET("/assets/masters/:management_number/aliases", h.ListManagementNumberAliases)
+	r.POST("/assets/masters/:management_number/aliases", h.AddManagementNumberAliases)
+	r.DELETE("/assets/aliases/:alias", h.DeleteManagementNumberAlias)
+	r.GET("/assets/masters/:management_number/relations", h.ListAssetRelations)
+	r.POST("/assets/masters/:management_number/relations", h.CreateAssetRelation)
+	r.DELETE("/assets/relations/:relation_id", h.DeleteAssetRelation)
 
 	// assets
 	r.POST("/assets", h.CreateAsset)
@@ -34,17 +41,39 @@ func RegisterRoutes(r gin.IRoutes, svc *Service) {
 	r.GET("/assets/pair/:management_number", h.GetAssetSet)
 	r.POST("/assets/import", h.HandleImportAssets) //curl -X POST "http://localhost:8443/api/v2/assets/import?mode=commit" -F "file=@./asset.csv"
 
+	// 管理番号の書式
+	r.GET("/assets/numbering-schemes", h.ListNumberingSchemes)
+	r.POST("/assets/numbering-schemes", h.CreateNumberingScheme)
+	r.PUT("/assets/numbering-schemes/:scheme_id", h.UpdateNumberingScheme)
+	r.DELETE("/assets/numbering-schemes/:scheme_id", h.DeleteNumberingScheme)
+
+	// ジャンルごとの追加項目
+	r.GET("/assets/genres/:genre_id/attributes", h.ListAttributeDefinitions)
+	r.POST("/assets/genres/:genre_id/attributes", h.CreateAttributeDefinition)
+	r.PUT("/assets/attributes/:attribute_id", h.UpdateAttributeDefinition)
+	r.DELETE("/assets/attributes/:attribute_id", h.DeleteAttributeDefinition)
+
 	// search
 	r.GET("/assets/search", h.SearchAssets)
 
 	// JANコード検索
 	r.GET("/assets/lookup/:jan_code", h.LookupJAN)
+	r.GET("/assets/catalog", h.ListProductCatalog)
+	r.PUT("/assets/catalog/:jan_code", h.PutProductCatalog)
+	r.DELETE("/assets/catalog/:jan_code", h.DeleteProductCatalog)
+	r.GET("/assets/genre-rules", h.ListGenreRules)
+	r.POST("/assets/genre-rules", h.CreateGenreRule)
+	r.PUT("/assets/genre-rules/:rule_id", h.UpdateGenreRule)
+	r.DELETE("/assets/genre-rules/:rule_id", h.DeleteGenreRule)
+
+	// スキャンしたコードの解決（管理番号・別名・シリアル・JAN）
+	r.GET("/scan/:code", h.Scan)
 }
 
 // ===== masters =====
 
 // @Summary      Create a new asset master
-// @Description  Creates a new master record for an asset type. The management_number is generated automatically.
+// @Description  Creates a new master record for an asset type. The management_number is generated from the genre's or category's numbering scheme (see /assets/numbering-schemes).
 // @Tags         assets-masters
 // @Accept       json
 // @Produce      json
@@ -69,6 +98,105 @@ func (h *Handler) CreateAssetMaster(c *gin.Context) {
 	c.JSON(http.StatusCreated, res)
 }
 
+// ===== numbering schemes =====
+
+// @Summary      List management-number schemes
+// @Description  Lists per-genre and per-management-category numbering schemes. Genres without one use their category's scheme, then the default {GENRE}-{YYYYMMDD}-{SEQ} (global sequence, 5 digits).
+// @Tags         assets-masters
+// @Produce      json
+// @Success      200 {array} NumberingSchemeResponse
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/numbering-schemes [get]
+func (h *Handler) ListNumberingSchemes(c *gin.Context) {
+	res, err := h.svc.ListNumberingSchemes(c.Request.Context())
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary      Create a management-number scheme
+// @Description  Registers a numbering scheme for one genre or one management category.
+// @Description  Pattern tokens: {GENRE}, {CATEGORY}, {YYYY}, {YY}, {MM}, {DD}, {YYYYMMDD}, {FY} (fiscal year starting in April) and exactly one {SEQ}.
+// @Description  sequence_scope is global, genre or category; sequence_reset is never, yearly or fiscal_year; check_digit "luhn" appends a Luhn digit.
+// @Tags         assets-masters
+// @Accept       json
+// @Produce      json
+// @Param        scheme body NumberingSchemeRequest true "Numbering scheme"
+// @Success      201 {object} NumberingSchemeResponse
+// @Failure      400 {object} ErrorResponse "Invalid input"
+// @Failure      409 {object} ErrorResponse "The genre or category already has a scheme"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/numbering-schemes [post]
+func (h *Handler) CreateNumberingScheme(c *gin.Context) {
+	var req NumberingSchemeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+	res, err := h.svc.CreateNumberingScheme(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusCreated, res)
+}
+
+// @Summary      Replace a management-number scheme
+// @Description  Replaces all fields of a numbering scheme. Only numbers generated afterwards are affected.
+// @Tags         assets-masters
+// @Accept       json
+// @Produce      json
+// @Param        scheme_id path int true "Scheme ID"
+// @Param        scheme body NumberingSchemeRequest true "Numbering scheme"
+// @Success      200 {object} NumberingSchemeResponse
+// @Failure      400 {object} ErrorResponse "Invalid input"
+// @Failure      404 {object} ErrorResponse "Scheme not found"
+// @Failure      409 {object} ErrorResponse "The genre or category already has a scheme"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/numbering-schemes/{scheme_id} [put]
+func (h *Handler) UpdateNumberingScheme(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("scheme_id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid scheme_id"))
+		return
+	}
+	var req NumberingSchemeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+	res, err := h.svc.UpdateNumberingScheme(c.Request.Context(), id, req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary      Delete a management-number scheme
+// @Description  Deletes a numbering scheme; the genre falls back to its category's scheme or the default.
+// @Tags         assets-masters
+// @Param        scheme_id path int true "Scheme ID"
+// @Success      204 "No Content"
+// @Failure      400 {object} ErrorResponse "Invalid scheme_id"
+// @Failure      404 {object} ErrorResponse "Scheme not found"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/numbering-schemes/{scheme_id} [delete]
+func (h *Handler) DeleteNumberingScheme(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("scheme_id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid scheme_id"))
+		return
+	}
+	if err := h.svc.DeleteNumberingScheme(c.Request.Context(), id); err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
 // @Summary      Get an asset master
 // @Description  Get details of an asset master by its management number.
 // @Tags         assets-masters
@@ -328,6 +456,8 @@ func (h *Handler) RegisterAsset(c *gin.Context) {
 
 // @Summary      Get an asset set (master and instance)
 // @Description  Get both master and instance details for an asset by its management number.
+// @Description  Aliases (legacy sticker numbers, numbers before a genre merge) are accepted; the response then carries resolved_alias
+// @Description  and a Content-Location header with the canonical management number.
 // @Tags         assets-set
 // @Produce      json
 // @Param        management_number path string true "Management Number"
@@ -342,13 +472,259 @@ func (h *Handler) GetAssetSet(c *gin.Context) {
 		c.JSON(toHTTPStatus(err), apiErrFrom(err))
 		return
 	}
+	if res.ResolvedAlias != nil {
+		c.Header("Content-Location", "/assets/pair/"+url.PathEscape(res.Master.ManagementNumber))
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// ===== genre attributes =====
+
+// @Summary      List custom attributes of a genre
+// @Description  Lists the typed custom attributes defined for a genre, in sort_order. Values are returned in the attributes field of masters (scope=master) or assets (scope=asset).
+// @Tags         assets-attributes
+// @Produce      json
+// @Param        genre_id path int true "Genre ID"
+// @Success      200 {array} AttributeDefinitionResponse
+// @Failure      400 {object} ErrorResponse "Invalid genre_id"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/genres/{genre_id}/attributes [get]
+func (h *Handler) ListAttributeDefinitions(c *gin.Context) {
+	genreID, err := parseUint(c.Param("genre_id"))
+	if err != nil || genreID == 0 {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid genre_id"))
+		return
+	}
+	res, err := h.svc.ListAttributeDefinitions(c.Request.Context(), genreID)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary      Define a custom attribute for a genre
+// @Description  type is string, number, date (YYYY-MM-DD) or enum (enum_values required). scope master stores one value per asset master, asset one per asset.
+// @Description  Required attributes must be given when masters/assets of the genre are created, and when a master is moved into the genre.
+// @Tags         assets-attributes
+// @Accept       json
+// @Produce      json
+// @Param        genre_id path int true "Genre ID"
+// @Param        attribute body AttributeDefinitionRequest true "Attribute definition"
+// @Success      201 {object} AttributeDefinitionResponse
+// @Failure      400 {object} ErrorResponse "Invalid input"
+// @Failure      409 {object} ErrorResponse "Key already exists in the genre"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/genres/{genre_id}/attributes [post]
+func (h *Handler) CreateAttributeDefinition(c *gin.Context) {
+	genreID, err := parseUint(c.Param("genre_id"))
+	if err != nil || genreID == 0 {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid genre_id"))
+		return
+	}
+	var req AttributeDefinitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+	res, err := h.svc.CreateAttributeDefinition(c.Request.Context(), genreID, req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusCreated, res)
+}
+
+// @Summary      Update a custom attribute
+// @Description  key, label, required, enum_values and sort_order can be changed; type and scope cannot. Enum values still in use cannot be removed.
+// @Tags         assets-attributes
+// @Accept       json
+// @Produce      json
+// @Param        attribute_id path int true "Attribute ID"
+// @Param        attribute body AttributeDefinitionRequest true "Attribute definition"
+// @Success      200 {object} AttributeDefinitionResponse
+// @Failure      400 {object} ErrorResponse "Invalid input"
+// @Failure      404 {object} ErrorResponse "Attribute not found"
+// @Failure      409 {object} ErrorResponse "Key already exists or enum value in use"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/attributes/{attribute_id} [put]
+func (h *Handler) UpdateAttributeDefinition(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("attribute_id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid attribute_id"))
+		return
+	}
+	var req AttributeDefinitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+	res, err := h.svc.UpdateAttributeDefinition(c.Request.Context(), id, req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
 	c.JSON(http.StatusOK, res)
 }
 
+// @Summary      Delete a custom attribute
+// @Description  Deletes the definition and every stored value of it.
+// @Tags         assets-attributes
+// @Param        attribute_id path int true "Attribute ID"
+// @Success      204 "No Content"
+// @Failure      400 {object} ErrorResponse "Invalid attribute_id"
+// @Failure      404 {object} ErrorResponse "Attribute not found"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/attributes/{attribute_id} [delete]
+func (h *Handler) DeleteAttributeDefinition(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("attribute_id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid attribute_id"))
+		return
+	}
+	if err := h.svc.DeleteAttributeDefinition(c.Request.Context(), id); err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ===== management number aliases =====
+
+// @Summary      List management-number aliases
+// @Description  Lists the aliases (legacy or former numbers) of an asset master. The path accepts the canonical number or an alias.
+// @Tags         assets-masters
+// @Produce      json
+// @Param        management_number path string true "Management Number or alias"
+// @Success      200 {array} ManagementNumberAliasResponse
+// @Failure      404 {object} ErrorResponse "Asset master not found"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/masters/{management_number}/aliases [get]
+func (h *Handler) ListManagementNumberAliases(c *gin.Context) {
+	res, err := h.svc.ListManagementNumberAliases(c.Request.Context(), c.Param("management_number"))
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary      Add management-number aliases
+// @Description  Maps old or alternate identifiers (e.g. legacy OFS-... stickers) to the asset master. All aliases are added in one transaction.
+// @Tags         assets-masters
+// @Accept       json
+// @Produce      json
+// @Param        management_number path string true "Management Number or alias"
+// @Param        request body AddManagementNumberAliasesRequest true "Aliases to add"
+// @Success      201 {array} ManagementNumberAliasResponse
+// @Failure      400 {object} ErrorResponse "Invalid input"
+// @Failure      404 {object} ErrorResponse "Asset master not found"
+// @Failure      409 {object} ErrorResponse "Alias already registered or equal to a management number"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/masters/{management_number}/aliases [post]
+func (h *Handler) AddManagementNumberAliases(c *gin.Context) {
+	var req AddManagementNumberAliasesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+	res, err := h.svc.AddManagementNumberAliases(c.Request.Context(), c.Param("management_number"), req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusCreated, res)
+}
+
+// @Summary      Delete a management-number alias
+// @Tags         assets-masters
+// @Param        alias path string true "Alias"
+// @Success      204 "No Content"
+// @Failure      404 {object} ErrorResponse "Alias not found"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/aliases/{alias} [delete]
+func (h *Handler) DeleteManagementNumberAlias(c *gin.Context) {
+	if err := h.svc.DeleteManagementNumberAlias(c.Request.Context(), c.Param("alias")); err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ===== asset relations =====
+
+// @Summary      List asset relations
+// @Description  Returns the parent, the children and the expanded kit (bundle members down every level, with available stock) of an asset master.
+// @Tags         assets-masters
+// @Produce      json
+// @Param        management_number path string true "Management Number or alias"
+// @Success      200 {object} AssetRelationsResponse
+// @Failure      404 {object} ErrorResponse "Asset master not found"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/masters/{management_number}/relations [get]
+func (h *Handler) ListAssetRelations(c *gin.Context) {
+	res, err := h.svc.ListAssetRelations(c.Request.Context(), c.Param("management_number"))
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary      Add a child asset
+// @Description  Relates another asset master to this one. `bundle` members (accessories) are lent together by POST /lends/kit; `component` only records the parent/child link.
+// @Description  A child belongs to one parent only.
+// @Tags         assets-masters
+// @Accept       json
+// @Produce      json
+// @Param        management_number path string true "Parent Management Number or alias"
+// @Param        request body CreateAssetRelationRequest true "Child to add"
+// @Success      201 {object} AssetRelationsResponse
+// @Failure      400 {object} ErrorResponse "Invalid input or bundles nested too deep"
+// @Failure      404 {object} ErrorResponse "Asset master not found"
+// @Failure      409 {object} ErrorResponse "Child already has a parent, or the relation would create a cycle"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/masters/{management_number}/relations [post]
+func (h *Handler) CreateAssetRelation(c *gin.Context) {
+	var req CreateAssetRelationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+	res, err := h.svc.CreateAssetRelation(c.Request.Context(), c.Param("management_number"), req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusCreated, res)
+}
+
+// @Summary      Delete an asset relation
+// @Tags         assets-masters
+// @Param        relation_id path int true "Relation ID"
+// @Success      204 "No Content"
+// @Failure      400 {object} ErrorResponse "Invalid relation_id"
+// @Failure      404 {object} ErrorResponse "Relation not found"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/relations/{relation_id} [delete]
+func (h *Handler) DeleteAssetRelation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("relation_id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid relation_id"))
+		return
+	}
+	if err := h.svc.DeleteAssetRelation(c.Request.Context(), id); err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
 // ===== batch registration =====
 
 // @Summary      Import assets from a CSV file
 // @Description  Batch import assets by uploading a CSV file. The mode query parameter can be 'dry_run' or 'commit'.
+// @Description  Custom genre attributes go in `attr.<key>` columns; empty cells are left unset. Values are validated against the row's genre like the JSON API.
 // @Tags         assets-batch
 // @Accept       multipart/form-data
 // @Produce      json
@@ -399,14 +775,19 @@ func (h *Handler) HandleImportAssets(c *gin.Context) {
 // @Description  Searches joined asset master and asset rows with exact, partial, prefix, and range filters.
 // @Description  `q` searches across management number, name, manufacturer, model, and serial.
 // @Description  `management_number`, `asset_id`, `asset_master_id`, `genre_id`, `genre_code`, `management_category_id`, and `status_id` are exact-match filters.
+// @Description  `include_descendants=true` widens `genre_id` to the genre and all of its descendant genres.
 // @Description  `management_number_prefix` is a prefix filter. Text filters such as `name`, `manufacturer`, `model`, `serial`, `owner`, `default_location`, `location`, `last_checked_by`, and `notes` use partial matches.
 // @Description  `*_to` date filters accept `YYYY-MM-DD` or RFC3339. A date-only `*_to` value is treated as an inclusive day by converting it to the next UTC day internally.
+// @Description  Custom genre attributes (see /assets/genres/{genre_id}/attributes) are filtered with `attr.<key>=value` (partial match for string, exact match otherwise)
+// @Description  and `attr.<key>.min` / `attr.<key>.max` (inclusive; a number, or `YYYY-MM-DD` for date attributes).
 // @Description  Examples:
 // @Description  - GET /assets/search?management_number=OFS-20250901-0001
 // @Description  - GET /assets/search?genre_id=10&status_id=1
+// @Description  - GET /assets/search?genre_id=2&include_descendants=true
 // @Description  - GET /assets/search?q=ThinkPad
 // @Description  - GET /assets/search?manufacturer=Lenovo&model=X1
 // @Description  - GET /assets/search?created_from=2026-01-01&created_to=2026-03-31
+// @Description  - GET /assets/search?genre_id=5&attr.lens_mount=RF&attr.weight_g.max=800
 // @Tags         assets-search
 // @Produce      json
 // @Param        q                        query string false "Cross-field partial search on management_number, name, manufacturer, model, and serial"
@@ -415,6 +796,7 @@ func (h *Handler) HandleImportAssets(c *gin.Context) {
 // @Param        asset_id                 query int    false "Exact match on asset ID"
 // @Param        asset_master_id          query int    false "Exact match on asset master ID"
 // @Param        genre_id                 query int    false "Exact match on genre ID"
+// @Param        include_descendants      query bool   false "Also match descendant genres of genre_id"
 // @Param        genre_code               query string false "Exact match on genre code"
 // @Param        genre_name               query string false "Partial match on genre name"
 // @Param        management_category_id   query int    false "Exact match on management category ID"
@@ -460,7 +842,10 @@ func (h *Handler) SearchAssets(c *gin.Context) {
 // ==== JANコード検索 ====
 
 // @Summary      Lookup product info by JAN/ISBN code
-// @Description  Fetches product name and manufacturer from external APIs using a JAN or ISBN code.
+// @Description  Fetches product candidates using a JAN or ISBN code: the local product catalog first, then cached results,
+// @Description  then the external providers (openBD, Yahoo Shopping) in the configured order. Not-found results are cached for a shorter time.
+// @Description  Candidates (up to 5) carry model, price, image_url, a confidence score (0-1) and a suggested_genre_id from /assets/genre-rules;
+// @Description  the top-level fields repeat the most likely candidate.
 // @Tags         assets-lookup
 // @Produce      json
 // @Param        jan_code path string true "JAN or ISBN code"
@@ -484,6 +869,176 @@ func (h *Handler) LookupJAN(c *gin.Context) {
 	c.JSON(http.StatusOK, res)
 }
 
+// @Summary      List the local product catalog
+// @Description  Lists manually curated JAN entries, which take precedence over external lookups.
+// @Tags         assets-lookup
+// @Produce      json
+// @Success      200 {array} ProductCatalogEntry
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/catalog [get]
+func (h *Handler) ListProductCatalog(c *gin.Context) {
+	res, err := h.svc.ListProductCatalog(c.Request.Context())
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary      Create or replace a product catalog entry
+// @Tags         assets-lookup
+// @Accept       json
+// @Produce      json
+// @Param        jan_code path string true "JAN or ISBN code"
+// @Param        entry body PutProductCatalogRequest true "Product info"
+// @Success      200 {object} ProductCatalogEntry
+// @Failure      400 {object} ErrorResponse "Invalid input"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/catalog/{jan_code} [put]
+func (h *Handler) PutProductCatalog(c *gin.Context) {
+	var req PutProductCatalogRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+	res, err := h.svc.PutProductCatalog(c.Request.Context(), c.Param("jan_code"), req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary      Delete a product catalog entry
+// @Tags         assets-lookup
+// @Param        jan_code path string true "JAN or ISBN code"
+// @Success      204 "No Content"
+// @Failure      404 {object} ErrorResponse "Entry not found"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/catalog/{jan_code} [delete]
+func (h *Handler) DeleteProductCatalog(c *gin.Context) {
+	if err := h.svc.DeleteProductCatalog(c.Request.Context(), c.Param("jan_code")); err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary      List genre suggestion rules
+// @Description  Lists keyword rules used to suggest a genre_id for JAN lookup candidates. Rules pointing at disabled genres are listed but not applied.
+// @Tags         assets-lookup
+// @Produce      json
+// @Success      200 {array} GenreRuleResponse
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/genre-rules [get]
+func (h *Handler) ListGenreRules(c *gin.Context) {
+	res, err := h.svc.ListGenreRules(c.Request.Context())
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary      Create a genre suggestion rule
+// @Description  A candidate whose name, manufacturer or model contains keyword (case-insensitive) is suggested genre_id.
+// @Description  When several rules match, the highest priority wins, then the longest keyword.
+// @Tags         assets-lookup
+// @Accept       json
+// @Produce      json
+// @Param        rule body GenreRuleRequest true "Genre rule"
+// @Success      201 {object} GenreRuleResponse
+// @Failure      400 {object} ErrorResponse "Invalid input"
+// @Failure      409 {object} ErrorResponse "Keyword already exists"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/genre-rules [post]
+func (h *Handler) CreateGenreRule(c *gin.Context) {
+	var req GenreRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+	res, err := h.svc.CreateGenreRule(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusCreated, res)
+}
+
+// @Summary      Replace a genre suggestion rule
+// @Tags         assets-lookup
+// @Accept       json
+// @Produce      json
+// @Param        rule_id path int true "Rule ID"
+// @Param        rule body GenreRuleRequest true "Genre rule"
+// @Success      200 {object} GenreRuleResponse
+// @Failure      400 {object} ErrorResponse "Invalid input"
+// @Failure      404 {object} ErrorResponse "Rule not found"
+// @Failure      409 {object} ErrorResponse "Keyword already exists"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/genre-rules/{rule_id} [put]
+func (h *Handler) UpdateGenreRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("rule_id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid rule_id"))
+		return
+	}
+	var req GenreRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+	res, err := h.svc.UpdateGenreRule(c.Request.Context(), id, req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary      Delete a genre suggestion rule
+// @Tags         assets-lookup
+// @Param        rule_id path int true "Rule ID"
+// @Success      204 "No Content"
+// @Failure      400 {object} ErrorResponse "Invalid rule_id"
+// @Failure      404 {object} ErrorResponse "Rule not found"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/genre-rules/{rule_id} [delete]
+func (h *Handler) DeleteGenreRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("rule_id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid rule_id"))
+		return
+	}
+	if err := h.svc.DeleteGenreRule(c.Request.Context(), id); err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary      Resolve a scanned code
+// @Description  Classifies a scanned barcode/QR code and resolves it in this order: management number, alias, serial number (exact), JAN/ISBN.
+// @Description  Existing assets are returned in asset (or matches when a serial is shared). A valid JAN that matches nothing returns a registration draft
+// @Description  pre-filled from the product lookup; draft.lookup_error is set when the lookup failed.
+// @Tags         assets-lookup
+// @Produce      json
+// @Param        code path string true "Scanned code"
+// @Success      200 {object} ScanResponse
+// @Failure      400 {object} ErrorResponse "Code is required"
+// @Failure      404 {object} ErrorResponse "No asset or product matches the code"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /scan/{code} [get]
+func (h *Handler) Scan(c *gin.Context) {
+	res, err := h.svc.Scan(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
 // ===== helpers =====
 
 func atoiDef(s string, d int) int {
@@ -498,80 +1053,102 @@ func atoiDef(s string, d int) int {
 }
 
 func buildAssetSearchQuery(c *gin.Context) (AssetSearchQuery, error) {
+	return ParseAssetSearchQuery(c.Request.URL.Query())
+}
+
+// AssetSearchQueryKeys は ParseAssetSearchQuery が解釈するキー（ほかに追加項目の attr.<key>[.min|.max]。IsAssetSearchQueryKey を参照）
+var AssetSearchQueryKeys = []string{
+	"q", "management_number", "management_number_prefix", "asset_id", "asset_master_id",
+	"genre_id", "include_descendants", "genre_code", "genre_name", "management_category_id", "name", "manufacturer",
+	"model", "serial", "status_id", "owner", "default_location", "location",
+	"purchased_from", "purchased_to", "created_from", "created_to",
+	"last_checked_from", "last_checked_to", "last_checked_by", "quantity_min", "quantity_max", "notes",
+}
+
+// ParseAssetSearchQuery は /assets/search と同じキーの検索条件を AssetSearchQuery にする（ラベル一括印刷などからも使う）
+func ParseAssetSearchQuery(v url.Values) (AssetSearchQuery, error) {
 	var q AssetSearchQuery
 
-	q.Q = trimmedQueryValue(c, "q")
-	q.ManagementNumber = trimmedQueryValue(c, "management_number")
-	q.ManagementNumberPrefix = trimmedQueryValue(c, "management_number_prefix")
-	q.GenreCode = trimmedQueryValue(c, "genre_code")
-	q.GenreName = trimmedQueryValue(c, "genre_name")
-	q.Name = trimmedQueryValue(c, "name")
-	q.Manufacturer = trimmedQueryValue(c, "manufacturer")
-	q.Model = trimmedQueryValue(c, "model")
-	q.Serial = trimmedQueryValue(c, "serial")
-	q.Owner = trimmedQueryValue(c, "owner")
-	q.DefaultLocation = trimmedQueryValue(c, "default_location")
-	q.Location = trimmedQueryValue(c, "location")
-	q.LastCheckedBy = trimmedQueryValue(c, "last_checked_by")
-	q.Notes = trimmedQueryValue(c, "notes")
+	q.Q = trimmedQueryValue(v, "q")
+	q.ManagementNumber = trimmedQueryValue(v, "management_number")
+	q.ManagementNumberPrefix = trimmedQueryValue(v, "management_number_prefix")
+	q.GenreCode = trimmedQueryValue(v, "genre_code")
+	q.GenreName = trimmedQueryValue(v, "genre_name")
+	q.Name = trimmedQueryValue(v, "name")
+	q.Manufacturer = trimmedQueryValue(v, "manufacturer")
+	q.Model = trimmedQueryValue(v, "model")
+	q.Serial = trimmedQueryValue(v, "serial")
+	q.Owner = trimmedQueryValue(v, "owner")
+	q.DefaultLocation = trimmedQueryValue(v, "default_location")
+	q.Location = trimmedQueryValue(v, "location")
+	q.LastCheckedBy = trimmedQueryValue(v, "last_checked_by")
+	q.Notes = trimmedQueryValue(v, "notes")
 
 	var err error
-	if q.AssetID, err = parseOptionalUint64Query(c, "asset_id"); err != nil {
+	if q.AssetID, err = parseOptionalUint64Query(v, "asset_id"); err != nil {
 		return AssetSearchQuery{}, err
 	}
-	if q.AssetMasterID, err = parseOptionalUint64Query(c, "asset_master_id"); err != nil {
+	if q.AssetMasterID, err = parseOptionalUint64Query(v, "asset_master_id"); err != nil {
 		return AssetSearchQuery{}, err
 	}
-	if q.GenreID, err = parseOptionalUintQuery(c, "genre_id"); err != nil {
+	if q.GenreID, err = parseOptionalUintQuery(v, "genre_id"); err != nil {
 		return AssetSearchQuery{}, err
 	}
-	if q.ManagementCategoryID, err = parseOptionalUintQuery(c, "management_category_id"); err != nil {
+	if raw := strings.TrimSpace(v.Get("include_descendants")); raw != "" {
+		if q.GenreDescendants, err = strconv.ParseBool(raw); err != nil {
+			return AssetSearchQuery{}, fmt.Errorf("include_descendants must be bool")
+		}
+	}
+	if q.ManagementCategoryID, err = parseOptionalUintQuery(v, "management_category_id"); err != nil {
 		return AssetSearchQuery{}, err
 	}
-	if q.StatusID, err = parseOptionalUintQuery(c, "status_id"); err != nil {
+	if q.StatusID, err = parseOptionalUintQuery(v, "status_id"); err != nil {
 		return AssetSearchQuery{}, err
 	}
-	if q.QuantityMin, err = parseOptionalUintQuery(c, "quantity_min"); err != nil {
+	if q.QuantityMin, err = parseOptionalUintQuery(v, "quantity_min"); err != nil {
 		return AssetSearchQuery{}, err
 	}
-	if q.QuantityMax, err = parseOptionalUintQuery(c, "quantity_max"); err != nil {
+	if q.QuantityMax, err = parseOptionalUintQuery(v, "quantity_max"); err != nil {
 		return AssetSearchQuery{}, err
 	}
 
 	// *_to は日付のみが渡された場合に「その日を含む上限」として扱えるよう、
 	// 次の UTC 日付へ丸めてから SQL 側で半開区間 (<) にする。
-	if q.PurchasedFrom, err = parseOptionalTimeQuery(c, "purchased_from", false); err != nil {
+	if q.PurchasedFrom, err = parseOptionalTimeQuery(v, "purchased_from", false); err != nil {
+		return AssetSearchQuery{}, err
+	}
+	if q.PurchasedTo, err = parseOptionalTimeQuery(v, "purchased_to", true); err != nil {
 		return AssetSearchQuery{}, err
 	}
-	if q.PurchasedTo, err = parseOptionalTimeQuery(c, "purchased_to", true); err != nil {
+	if q.CreatedFrom, err = parseOptionalTimeQuery(v, "created_from", false); err != nil {
 		return AssetSearchQuery{}, err
 	}
-	if q.CreatedFrom, err = parseOptionalTimeQuery(c, "created_from", false); err != nil {
+	if q.CreatedTo, err = parseOptionalTimeQuery(v, "created_to", true); err != nil {
 		return AssetSearchQuery{}, err
 	}
-	if q.CreatedTo, err = parseOptionalTimeQuery(c, "created_to", true); err != nil {
+	if q.LastCheckedFrom, err = parseOptionalTimeQuery(v, "last_checked_from", false); err != nil {
 		return AssetSearchQuery{}, err
 	}
-	if q.LastCheckedFrom, err = parseOptionalTimeQuery(c, "last_checked_from", false); err != nil {
+	if q.LastCheckedTo, err = parseOptionalTimeQuery(v, "last_checked_to", true); err != nil {
 		return AssetSearchQuery{}, err
 	}
-	if q.LastCheckedTo, err = parseOptionalTimeQuery(c, "last_checked_to", true); err != nil {
+	if q.Attributes, err = parseAttributeFilters(v); err != nil {
 		return AssetSearchQuery{}, err
 	}
 
 	return q, nil
 }
 
-func trimmedQueryValue(c *gin.Context, key string) *string {
-	v := strings.TrimSpace(c.Query(key))
+func trimmedQueryValue(values url.Values, key string) *string {
+	v := strings.TrimSpace(values.Get(key))
 	if v == "" {
 		return nil
 	}
 	return &v
 }
 
-func parseOptionalUint64Query(c *gin.Context, key string) (*uint64, error) {
-	v := strings.TrimSpace(c.Query(key))
+func parseOptionalUint64Query(values url.Values, key string) (*uint64, error) {
+	v := strings.TrimSpace(values.Get(key))
 	if v == "" {
 		return nil, nil
 	}
@@ -582,8 +1159,8 @@ func parseOptionalUint64Query(c *gin.Context, key string) (*uint64, error) {
 	return &n, nil
 }
 
-func parseOptionalUintQuery(c *gin.Context, key string) (*uint, error) {
-	v := strings.TrimSpace(c.Query(key))
+func parseOptionalUintQuery(values url.Values, key string) (*uint, error) {
+	v := strings.TrimSpace(values.Get(key))
 	if v == "" {
 		return nil, nil
 	}
@@ -594,8 +1171,8 @@ func parseOptionalUintQuery(c *gin.Context, key string) (*uint, error) {
 	return &n, nil
 }
 
-func parseOptionalTimeQuery(c *gin.Context, key string, endExclusive bool) (*time.Time, error) {
-	v := strings.TrimSpace(c.Query(key))
+func parseOptionalTimeQuery(values url.Values, key string, endExclusive bool) (*time.Time, error) {
+	v := strings.TrimSpace(values.Get(key))
 	if v == "" {
 		return nil, nil
 	}
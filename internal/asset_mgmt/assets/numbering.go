@@ -0,0 +1,288 @@
+package assets
+
+// 管理番号の採番
+// - 書式はジャンル別・管理区分別に management_number_schemes へ登録する（ジャンル > 管理区分 > 既定 の順に選ぶ）
+// - 連番は management_number_sequences に範囲（scope_key）ごとに持ち、マスタ INSERT と同じトランザクションで進める
+// - 既定は従来と同じ見た目の {GENRE}-{YYYYMMDD}-{SEQ}（全体の連番を 5 桁）。
+//   従来は {SEQ} に asset_master_id を使っていたので、全体の連番は初回に asset_master_id の最大値の続きから始める
+// - 日付は従来どおり DB の UTC 時刻（assets_master.created_at と同じ値）
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	mysql "github.com/go-sql-driver/mysql"
+)
+
+const (
+	SequenceScopeGlobal   = "global"   // 全備品で 1 つの連番
+	SequenceScopeGenre    = "genre"    // ジャンルごと
+	SequenceScopeCategory = "category" // 管理区分ごと
+
+	SequenceResetNever      = "never"
+	SequenceResetYearly     = "yearly"      // 暦年で 1 から
+	SequenceResetFiscalYear = "fiscal_year" // 年度（FiscalYearStartMonth 始まり）で 1 から
+
+	CheckDigitNone = ""
+	CheckDigitLuhn = "luhn" // 番号中の数字から Luhn（mod 10）で 1 桁を末尾に付ける
+
+	DefaultNumberPattern = "{GENRE}-{YYYYMMDD}-{SEQ}"
+	DefaultNumberPadding = 5
+	MaxNumberPadding     = 12
+
+	// 年度の開始月（{FY} と fiscal_year リセットに使う）
+	FiscalYearStartMonth = time.April
+)
+
+// 書式に使えるトークン
+//
+//	{GENRE}    ジャンルの genre_code（階層があっても備品に付けたジャンル自身のコード）
+//	{CATEGORY} management_category_id
+//	{YYYY} {YY} {MM} {DD} {YYYYMMDD}  採番日
+//	{FY}       年度（4 桁）
+//	{SEQ}      連番（padding 桁で 0 埋め）。必ず 1 つ含める
+var numberTokens = map[string]bool{
+	"GENRE": true, "CATEGORY": true, "YYYY": true, "YY": true, "MM": true, "DD": true,
+	"YYYYMMDD": true, "FY": true, "SEQ": true,
+}
+
+// defaultNumberingScheme スキーム未登録のジャンル・管理区分に使う
+func defaultNumberingScheme() NumberingSchemeResponse {
+	return NumberingSchemeResponse{
+		Pattern:       DefaultNumberPattern,
+		SequenceScope: SequenceScopeGlobal,
+		SequenceReset: SequenceResetNever,
+		Padding:       DefaultNumberPadding,
+		CheckDigit:    CheckDigitNone,
+	}
+}
+
+// numberValues 書式に埋める値
+type numberValues struct {
+	GenreCode  string
+	CategoryID uint
+	At         time.Time
+	Seq        int64
+}
+
+// validateNumberPattern 未知のトークン・閉じていない括弧・URL に使えない文字を拒否し、{SEQ} がちょうど 1 つあること
+func validateNumberPattern(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return ErrInvalid("pattern is required")
+	}
+	seq := 0
+	for rest := pattern; rest != ""; {
+		if rest[0] == '{' {
+			end := strings.IndexByte(rest, '}')
+			if end < 0 {
+				return ErrInvalid("pattern has an unclosed '{'")
+			}
+			tok := rest[1:end]
+			if !numberTokens[tok] {
+				return ErrInvalid(fmt.Sprintf("unknown pattern token {%s}", tok))
+			}
+			if tok == "SEQ" {
+				seq++
+			}
+			rest = rest[end+1:]
+			continue
+		}
+		c := rest[0]
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return ErrInvalid(fmt.Sprintf("pattern may only contain letters, digits, '-', '_', '.' and tokens (got %q)", c))
+		}
+		rest = rest[1:]
+	}
+	if seq != 1 {
+		return ErrInvalid("pattern must contain {SEQ} exactly once")
+	}
+	return nil
+}
+
+// renderManagementNumber 書式に値を埋め、必要ならチェックディジットを付ける（書式は検証済みであること）
+func renderManagementNumber(scheme NumberingSchemeResponse, v numberValues) string {
+	var b strings.Builder
+	for rest := scheme.Pattern; rest != ""; {
+		if rest[0] != '{' {
+			b.WriteByte(rest[0])
+			rest = rest[1:]
+			continue
+		}
+		end := strings.IndexByte(rest, '}')
+		switch rest[1:end] {
+		case "GENRE":
+			b.WriteString(v.GenreCode)
+		case "CATEGORY":
+			b.WriteString(strconv.FormatUint(uint64(v.CategoryID), 10))
+		case "YYYY":
+			b.WriteString(v.At.Format("2006"))
+		case "YY":
+			b.WriteString(v.At.Format("06"))
+		case "MM":
+			b.WriteString(v.At.Format("01"))
+		case "DD":
+			b.WriteString(v.At.Format("02"))
+		case "YYYYMMDD":
+			b.WriteString(v.At.Format("20060102"))
+		case "FY":
+			b.WriteString(strconv.Itoa(fiscalYear(v.At)))
+		case "SEQ":
+			fmt.Fprintf(&b, "%0*d", scheme.Padding, v.Seq)
+		}
+		rest = rest[end+1:]
+	}
+	out := b.String()
+	if scheme.CheckDigit == CheckDigitLuhn {
+		out += strconv.Itoa(luhnCheckDigit(out))
+	}
+	return out
+}
+
+// luhnCheckDigit s に含まれる数字（それ以外は無視）に付ける Luhn のチェックディジット
+func luhnCheckDigit(s string) int {
+	sum := 0
+	double := true // 付けるチェックディジットの左隣から 2 倍する
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return (10 - sum%10) % 10
+}
+
+func fiscalYear(t time.Time) int {
+	if t.Month() < FiscalYearStartMonth {
+		return t.Year() - 1
+	}
+	return t.Year()
+}
+
+// sequenceScopeKey 連番を共有する範囲のキー（例: "genre:12:2026", "category:3:FY2025"）
+func sequenceScopeKey(scheme NumberingSchemeResponse, genreID, categoryID uint, at time.Time) string {
+	key := SequenceScopeGlobal
+	switch scheme.SequenceScope {
+	case SequenceScopeGenre:
+		key = fmt.Sprintf("genre:%d", genreID)
+	case SequenceScopeCategory:
+		key = fmt.Sprintf("category:%d", categoryID)
+	}
+	switch scheme.SequenceReset {
+	case SequenceResetYearly:
+		key += fmt.Sprintf(":%d", at.Year())
+	case SequenceResetFiscalYear:
+		key += fmt.Sprintf(":FY%d", fiscalYear(at))
+	}
+	return key
+}
+
+// normalizeNumberingScheme 登録・更新の入力を検証し、省略値を埋める
+func normalizeNumberingScheme(in NumberingSchemeRequest) (NumberingSchemeRequest, error) {
+	if (in.GenreID == nil) == (in.ManagementCategoryID == nil) {
+		return in, ErrInvalid("exactly one of genre_id or management_category_id is required")
+	}
+	in.Pattern = strings.TrimSpace(in.Pattern)
+	if err := validateNumberPattern(in.Pattern); err != nil {
+		return in, err
+	}
+
+	in.SequenceScope = strings.TrimSpace(in.SequenceScope)
+	switch in.SequenceScope {
+	case "":
+		in.SequenceScope = SequenceScopeGlobal
+	case SequenceScopeGlobal, SequenceScopeGenre, SequenceScopeCategory:
+	default:
+		return in, ErrInvalid("sequence_scope must be global, genre or category")
+	}
+
+	in.SequenceReset = strings.TrimSpace(in.SequenceReset)
+	switch in.SequenceReset {
+	case "":
+		in.SequenceReset = SequenceResetNever
+	case SequenceResetNever, SequenceResetYearly, SequenceResetFiscalYear:
+	default:
+		return in, ErrInvalid("sequence_reset must be never, yearly or fiscal_year")
+	}
+
+	if in.Padding == 0 {
+		in.Padding = DefaultNumberPadding
+	}
+	if in.Padding < 1 || in.Padding > MaxNumberPadding {
+		return in, ErrInvalid(fmt.Sprintf("padding must be between 1 and %d", MaxNumberPadding))
+	}
+
+	in.CheckDigit = strings.TrimSpace(in.CheckDigit)
+	if in.CheckDigit != CheckDigitNone && in.CheckDigit != CheckDigitLuhn {
+		return in, ErrInvalid("check_digit must be empty or luhn")
+	}
+	return in, nil
+}
+
+// ===== service =====
+
+func mapNumberingSchemeError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound("numbering scheme not found")
+	}
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		switch me.Number {
+		case 1062:
+			return ErrConflict("a numbering scheme for this genre or management category already exists")
+		case 1452:
+			return ErrInvalid("invalid management_category_id or genre_id")
+		}
+	}
+	return err
+}
+
+func (s *Service) ListNumberingSchemes(ctx context.Context) ([]NumberingSchemeResponse, error) {
+	return s.store.ListNumberingSchemes(ctx)
+}
+
+func (s *Service) CreateNumberingScheme(ctx context.Context, in NumberingSchemeRequest) (NumberingSchemeResponse, error) {
+	in, err := normalizeNumberingScheme(in)
+	if err != nil {
+		return NumberingSchemeResponse{}, err
+	}
+	out, err := s.store.CreateNumberingScheme(ctx, in)
+	if err != nil {
+		return NumberingSchemeResponse{}, mapNumberingSchemeError(err)
+	}
+	return *out, nil
+}
+
+// UpdateNumberingScheme 書式を置き換える。以後の採番から使われ、採番済みの管理番号は変わらない
+func (s *Service) UpdateNumberingScheme(ctx context.Context, id uint64, in NumberingSchemeRequest) (NumberingSchemeResponse, error) {
+	in, err := normalizeNumberingScheme(in)
+	if err != nil {
+		return NumberingSchemeResponse{}, err
+	}
+	out, err := s.store.UpdateNumberingScheme(ctx, id, in)
+	if err != nil {
+		return NumberingSchemeResponse{}, mapNumberingSchemeError(err)
+	}
+	return *out, nil
+}
+
+// DeleteNumberingScheme 削除後は管理区分別か既定の書式に戻る
+func (s *Service) DeleteNumberingScheme(ctx context.Context, id uint64) error {
+	if err := s.store.DeleteNumberingScheme(ctx, id); err != nil {
+		return mapNumberingSchemeError(err)
+	}
+	return nil
+}
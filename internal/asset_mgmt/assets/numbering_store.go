@@ -0,0 +1,200 @@
+package assets
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// 採番時に既存の番号（旧書式の番号・別名を含む）とぶつかったら連番を進めてやり直す回数
+const maxNumberingAttempts = 100
+
+const numberingSchemeColumns = `
+	scheme_id, genre_id, management_category_id, pattern, sequence_scope, sequence_reset,
+	padding, check_digit, created_at, updated_at`
+
+func scanNumberingScheme(row interface{ Scan(...any) error }) (*NumberingSchemeResponse, error) {
+	var (
+		out      NumberingSchemeResponse
+		genreID  sql.NullInt64
+		category sql.NullInt64
+	)
+	if err := row.Scan(
+		&out.SchemeID, &genreID, &category, &out.Pattern, &out.SequenceScope, &out.SequenceReset,
+		&out.Padding, &out.CheckDigit, &out.CreatedAt, &out.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if genreID.Valid {
+		v := uint(genreID.Int64)
+		out.GenreID = &v
+	}
+	if category.Valid {
+		v := uint(category.Int64)
+		out.ManagementCategoryID = &v
+	}
+	return &out, nil
+}
+
+func (s *Store) ListNumberingSchemes(ctx context.Context) ([]NumberingSchemeResponse, error) {
+	q := `SELECT ` + numberingSchemeColumns + ` FROM management_number_schemes ORDER BY scheme_id`
+	rows, err := s.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]NumberingSchemeResponse, 0, 8)
+	for rows.Next() {
+		sc, err := scanNumberingScheme(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *sc)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) GetNumberingScheme(ctx context.Context, id uint64) (*NumberingSchemeResponse, error) {
+	q := `SELECT ` + numberingSchemeColumns + ` FROM management_number_schemes WHERE scheme_id = ?`
+	return scanNumberingScheme(s.db.QueryRowContext(ctx, q, id))
+}
+
+func (s *Store) CreateNumberingScheme(ctx context.Context, in NumberingSchemeRequest) (*NumberingSchemeResponse, error) {
+	const q = `
+	INSERT INTO management_number_schemes
+		(genre_id, management_category_id, pattern, sequence_scope, sequence_reset, padding, check_digit, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, UTC_TIMESTAMP(), UTC_TIMESTAMP())`
+	res, err := s.db.ExecContext(ctx, q, in.GenreID, in.ManagementCategoryID, in.Pattern,
+		in.SequenceScope, in.SequenceReset, in.Padding, in.CheckDigit)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return s.GetNumberingScheme(ctx, uint64(id))
+}
+
+// UpdateNumberingScheme は全項目を置き換える。採番済みの番号は変わらない
+func (s *Store) UpdateNumberingScheme(ctx context.Context, id uint64, in NumberingSchemeRequest) (*NumberingSchemeResponse, error) {
+	const q = `
+	UPDATE management_number_schemes
+	SET genre_id = ?, management_category_id = ?, pattern = ?, sequence_scope = ?, sequence_reset = ?,
+		padding = ?, check_digit = ?, updated_at = UTC_TIMESTAMP()
+	WHERE scheme_id = ?`
+	if _, err := s.db.ExecContext(ctx, q, in.GenreID, in.ManagementCategoryID, in.Pattern,
+		in.SequenceScope, in.SequenceReset, in.Padding, in.CheckDigit, id); err != nil {
+		return nil, err
+	}
+	// updated_at が変わるため 0 件なら存在しない
+	return s.GetNumberingScheme(ctx, id)
+}
+
+func (s *Store) DeleteNumberingScheme(ctx context.Context, id uint64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM management_number_schemes WHERE scheme_id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// NextManagementNumberTx はマスタに付ける管理番号と採番日時（DB の UTC 時刻）を決める。
+// 連番の行は tx の終わりまでロックされるため、同じ範囲の採番は直列になる
+func (s *Store) NextManagementNumberTx(ctx context.Context, tx *sql.Tx, in CreateAssetMasterRequest) (string, time.Time, error) {
+	var at time.Time
+	if err := tx.QueryRowContext(ctx, `SELECT UTC_TIMESTAMP()`).Scan(&at); err != nil {
+		return "", time.Time{}, err
+	}
+
+	var genreCode string
+	err := tx.QueryRowContext(ctx, `SELECT genre_code FROM asset_genres WHERE genre_id = ?`, in.GenreID).Scan(&genreCode)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", time.Time{}, ErrInvalid("invalid management_category_id or genre_id")
+	}
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	// ジャンル別 > 管理区分別 > 既定
+	q := `SELECT ` + numberingSchemeColumns + ` FROM management_number_schemes
+	WHERE genre_id = ? OR (genre_id IS NULL AND management_category_id = ?)
+	ORDER BY genre_id IS NULL
+	LIMIT 1`
+	scheme, err := scanNumberingScheme(tx.QueryRowContext(ctx, q, in.GenreID, in.ManagementCategoryID))
+	if errors.Is(err, sql.ErrNoRows) {
+		d := defaultNumberingScheme()
+		scheme, err = &d, nil
+	}
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	key := sequenceScopeKey(*scheme, in.GenreID, in.ManagementCategoryID, at)
+	// 全体の連番は、従来 {SEQ} に使っていた asset_master_id の続きから始める
+	var seed int64
+	if key == SequenceScopeGlobal {
+		if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(asset_master_id), 0) FROM assets_master`).Scan(&seed); err != nil {
+			return "", time.Time{}, err
+		}
+	}
+	for i := 0; i < maxNumberingAttempts; i++ {
+		seq, err := nextSequenceTx(ctx, tx, key, seed)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		mng := renderManagementNumber(*scheme, numberValues{
+			GenreCode:  genreCode,
+			CategoryID: in.ManagementCategoryID,
+			At:         at,
+			Seq:        seq,
+		})
+		var taken bool
+		if err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS(SELECT 1 FROM assets_master WHERE management_number = ?)
+				OR EXISTS(SELECT 1 FROM asset_management_number_aliases WHERE management_number = ?)`,
+			mng, mng,
+		).Scan(&taken); err != nil {
+			return "", time.Time{}, err
+		}
+		if !taken {
+			return mng, at, nil
+		}
+	}
+	return "", time.Time{}, ErrConflict("could not find a free management_number")
+}
+
+// nextSequenceTx は範囲 key の連番を 1 進めて返す（初回は seed + 1）
+func nextSequenceTx(ctx context.Context, tx *sql.Tx, key string, seed int64) (int64, error) {
+	const q = `
+	INSERT INTO management_number_sequences (scope_key, last_value)
+	VALUES (?, LAST_INSERT_ID(?))
+	ON DUPLICATE KEY UPDATE last_value = LAST_INSERT_ID(last_value + 1)`
+	res, err := tx.ExecContext(ctx, q, key, seed+1)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// InsertMasterTx は採番済みの管理番号でマスタを登録する
+func (s *Store) InsertMasterTx(ctx context.Context, tx *sql.Tx, in CreateAssetMasterRequest, mng string, createdAt time.Time) (uint64, error) {
+	const q = `
+	INSERT INTO assets_master
+	(management_number, name, management_category_id, genre_id, manufacturer, model, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`
+	res, err := tx.ExecContext(ctx, q, mng, in.Name, in.ManagementCategoryID, in.GenreID, in.Manufacturer, in.Model, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
@@ -0,0 +1,115 @@
+package assets
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+// 既定の書式は従来の番号と同じ見た目になる（連番の続きは NextManagementNumberTx が asset_master_id から始める）
+func TestRenderManagementNumberDefaultPattern(t *testing.T) {
+	got := renderManagementNumber(defaultNumberingScheme(), numberValues{
+		GenreCode: "PC",
+		At:        time.Date(2026, time.May, 7, 3, 0, 0, 0, time.UTC),
+		Seq:       42,
+	})
+	if got != "PC-20260507-00042" {
+		t.Fatalf("unexpected default number %q", got)
+	}
+}
+
+func TestRenderManagementNumberTokensAndCheckDigit(t *testing.T) {
+	scheme := NumberingSchemeResponse{Pattern: "{GENRE}{CATEGORY}-FY{FY}-{YY}{MM}{DD}-{SEQ}", Padding: 3}
+	v := numberValues{GenreCode: "CAM", CategoryID: 2, At: time.Date(2027, time.March, 31, 0, 0, 0, 0, time.UTC), Seq: 7}
+	if got := renderManagementNumber(scheme, v); got != "CAM2-FY2026-270331-007" {
+		t.Fatalf("unexpected number %q", got)
+	}
+
+	scheme = NumberingSchemeResponse{Pattern: "A-{SEQ}", Padding: 4, CheckDigit: CheckDigitLuhn}
+	// 7992739871 の Luhn チェックディジットは 3
+	v = numberValues{Seq: 7992739871}
+	if got := renderManagementNumber(scheme, v); got != "A-79927398713" {
+		t.Fatalf("unexpected luhn number %q", got)
+	}
+}
+
+func TestSequenceScopeKey(t *testing.T) {
+	at := time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		scope, reset, want string
+	}{
+		{SequenceScopeGlobal, SequenceResetNever, "global"},
+		{SequenceScopeGenre, SequenceResetYearly, "genre:12:2026"},
+		{SequenceScopeCategory, SequenceResetFiscalYear, "category:3:FY2025"},
+	}
+	for _, tc := range cases {
+		scheme := NumberingSchemeResponse{SequenceScope: tc.scope, SequenceReset: tc.reset}
+		if got := sequenceScopeKey(scheme, 12, 3, at); got != tc.want {
+			t.Fatalf("%s/%s: got %q, want %q", tc.scope, tc.reset, got, tc.want)
+		}
+	}
+}
+
+func TestNormalizeNumberingScheme(t *testing.T) {
+	genre := uint(4)
+	got, err := normalizeNumberingScheme(NumberingSchemeRequest{GenreID: &genre, Pattern: " {GENRE}-{SEQ} "})
+	if err != nil {
+		t.Fatalf("normalizeNumberingScheme returned error: %v", err)
+	}
+	if got.Pattern != "{GENRE}-{SEQ}" || got.SequenceScope != SequenceScopeGlobal || got.SequenceReset != SequenceResetNever || got.Padding != DefaultNumberPadding {
+		t.Fatalf("expected defaults filled, got %#v", got)
+	}
+
+	category := uint(1)
+	cases := map[string]NumberingSchemeRequest{
+		"no target":     {Pattern: "{SEQ}"},
+		"both targets":  {GenreID: &genre, ManagementCategoryID: &category, Pattern: "{SEQ}"},
+		"no seq":        {GenreID: &genre, Pattern: "{GENRE}-{YYYY}"},
+		"two seq":       {GenreID: &genre, Pattern: "{SEQ}-{SEQ}"},
+		"unknown token": {GenreID: &genre, Pattern: "{ID}-{SEQ}"},
+		"unclosed":      {GenreID: &genre, Pattern: "{SEQ"},
+		"slash":         {GenreID: &genre, Pattern: "A/{SEQ}"},
+		"scope":         {GenreID: &genre, Pattern: "{SEQ}", SequenceScope: "owner"},
+		"reset":         {GenreID: &genre, Pattern: "{SEQ}", SequenceReset: "monthly"},
+		"padding":       {GenreID: &genre, Pattern: "{SEQ}", Padding: MaxNumberPadding + 1},
+		"check digit":   {GenreID: &genre, Pattern: "{SEQ}", CheckDigit: "mod11"},
+	}
+	for name, in := range cases {
+		if _, err := normalizeNumberingScheme(in); toHTTPStatus(err) != 400 {
+			t.Fatalf("%s: expected 400, got %v", name, err)
+		}
+	}
+}
+
+func TestNextManagementNumberTxSeedsGlobalSequenceFromMasterID(t *testing.T) {
+	at := time.Date(2026, time.May, 7, 3, 0, 0, 0, time.UTC)
+	conn := &fakeConn{
+		rows: map[string][][]driver.Value{
+			"SELECT UTC_TIMESTAMP()":         {{at}},
+			"SELECT genre_code":              {{"PC"}},
+			"FROM management_number_schemes": {},
+			"MAX(asset_master_id)":           {{int64(41)}},
+			"SELECT EXISTS":                  {{false}},
+		},
+		results: map[string]fakeResult{"management_number_sequences": {lastInsertID: 42}},
+	}
+	db := newFakeDB(conn)
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	mng, _, err := NewStore(db).NextManagementNumberTx(context.Background(), tx, CreateAssetMasterRequest{GenreID: 1, ManagementCategoryID: 1})
+	if err != nil {
+		t.Fatalf("NextManagementNumberTx returned error: %v", err)
+	}
+	if mng != "PC-20260507-00042" {
+		t.Fatalf("unexpected number %q", mng)
+	}
+	seq := conn.exec("management_number_sequences")
+	if seq == nil || seq.args[0] != SequenceScopeGlobal || seq.args[1] != int64(42) {
+		t.Fatalf("expected global sequence seeded after asset_master_id 41, got %+v", seq)
+	}
+}
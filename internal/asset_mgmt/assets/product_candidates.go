@@ -0,0 +1,214 @@
+package assets
+
+// 商品情報の候補づくり
+// - Yahoo は同じ商品を複数ストアが出しているので、JAN が一致する出品は型番（無ければ商品名）ごとにまとめる
+// - 確からしさ（confidence, 0〜1）は「JAN の一致・メーカー/型番が取れたか・同じ商品を出しているストアの割合」から付ける
+// - ジャンルの提案は登録済みのキーワード規則（asset_genre_rules）を商品名・メーカー名に当てる
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// MaxProductCandidates 1 回の検索で返す候補の上限
+	MaxProductCandidates = 5
+
+	catalogConfidence  = 1.0  // 手入力の商品カタログ
+	openBDConfidence   = 0.95 // ISBN で 1 冊に決まる
+	yahooMaxConfidence = 0.9  // ストアの出品情報なので満点にはしない
+)
+
+// 型番と間違えやすい容量・寸法など（"500ml", "1.5m", "256GB"）
+var unitTokenPattern = regexp.MustCompile(`(?i)^\d+(\.\d+)?(mm|cm|m|g|kg|ml|l|mb|gb|tb|w|v|a|mah|hz|ghz|inch|in|p|pcs)$`)
+
+// toHalfWidth 全角英数字・記号を半角にする
+func toHalfWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '！' && r <= '～':
+			return r - '！' + '!'
+		case r == '　':
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// extractModelNumber 商品名から型番らしい語（英字と数字を両方含む英数字・記号の並び）のうち最も長いものを取り出す。無ければ ""
+func extractModelNumber(name string) string {
+	fields := strings.FieldsFunc(toHalfWidth(name), func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("()[]【】「」『』（）・,、", r)
+	})
+	best := ""
+	for _, f := range fields {
+		f = strings.Trim(f, "-_./")
+		if len(f) < 4 || len(f) > 30 || unitTokenPattern.MatchString(f) {
+			continue
+		}
+		var letter, digit, other bool
+		for _, r := range f {
+			switch {
+			case r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z':
+				letter = true
+			case r >= '0' && r <= '9':
+				digit = true
+			case r == '-' || r == '_' || r == '.' || r == '/':
+			default:
+				other = true
+			}
+		}
+		if letter && digit && !other && len(f) > len(best) {
+			best = f
+		}
+	}
+	return best
+}
+
+// yahooCandidates 検索結果を商品ごとにまとめて確からしい順の候補にする
+func yahooCandidates(janCode string, hits []yahooHit) []ProductCandidate {
+	type group struct {
+		cand   ProductCandidate
+		count  int
+		janHit bool
+	}
+	groups := map[string]*group{}
+	order := make([]string, 0, len(hits))
+
+	for _, hit := range hits {
+		name := cleanItemName(hit.Name)
+		if name == "" {
+			continue
+		}
+		model := extractModelNumber(name)
+		// 型番でまとめるのは JAN が一致する出品だけ（「〇〇対応」のアクセサリなどを混ぜない）
+		key := "name:" + name
+		if model != "" && hit.JANCode == janCode {
+			key = "model:" + strings.ToUpper(model)
+		}
+
+		g, ok := groups[key]
+		if !ok {
+			g = &group{cand: ProductCandidate{Name: name, Model: model, Source: ProductProviderYahoo}}
+			groups[key] = g
+			order = append(order, key)
+		}
+		g.count++
+		g.janHit = g.janHit || hit.JANCode == janCode
+		// 最初の出品に無い項目は同じ商品の別の出品から補う。価格は最安
+		if g.cand.Manufacturer == "" {
+			g.cand.Manufacturer = strings.TrimSpace(hit.Brand.Name)
+		}
+		if g.cand.ImageURL == "" {
+			g.cand.ImageURL = firstNonEmpty(hit.Image.Medium, hit.Image.Small)
+		}
+		if hit.Price != nil && (g.cand.Price == nil || *hit.Price < *g.cand.Price) {
+			p := *hit.Price
+			g.cand.Price = &p
+		}
+	}
+
+	total := 0
+	for _, g := range groups {
+		total += g.count
+	}
+	out := make([]ProductCandidate, 0, len(order))
+	for _, key := range order {
+		g := groups[key]
+		score := 0.4
+		if g.janHit {
+			score += 0.25
+		}
+		if g.cand.Manufacturer != "" {
+			score += 0.1
+		}
+		if g.cand.Model != "" {
+			score += 0.1
+		}
+		score += 0.15 * float64(g.count) / float64(total)
+		g.cand.Confidence = roundConfidence(math.Min(score, yahooMaxConfidence))
+		out = append(out, g.cand)
+	}
+	sortCandidates(out)
+	return out
+}
+
+func firstNonEmpty(vs ...string) string {
+	for _, v := range vs {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func roundConfidence(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// sortCandidates 確からしい順（同点はプロバイダが返した順）
+func sortCandidates(cands []ProductCandidate) {
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].Confidence > cands[j].Confidence })
+}
+
+// newJANLookupResponse 候補を上限まで詰め、先頭の候補を代表としてトップレベルにも載せる
+func newJANLookupResponse(cands []ProductCandidate) JANLookupResponse {
+	sortCandidates(cands)
+	if len(cands) > MaxProductCandidates {
+		cands = cands[:MaxProductCandidates]
+	}
+	var out JANLookupResponse
+	out.Candidates = cands
+	out.syncBest()
+	return out
+}
+
+// syncBest トップレベルの項目を candidates[0] に合わせる
+func (r *JANLookupResponse) syncBest() {
+	if len(r.Candidates) == 0 {
+		return
+	}
+	best := r.Candidates[0]
+	r.Name = best.Name
+	r.Manufacturer = best.Manufacturer
+	r.Model = best.Model
+	r.Price = best.Price
+	r.ImageURL = best.ImageURL
+	r.SuggestedGenreID = best.SuggestedGenreID
+	r.Confidence = best.Confidence
+	r.Source = best.Source
+}
+
+// matchGenreRule 商品名・メーカー名にキーワードを含む規則のうち、優先度 → キーワードの長さ → 登録順 で選ぶ
+func matchGenreRule(rules []GenreRuleResponse, c ProductCandidate) *GenreRuleResponse {
+	text := strings.ToLower(toHalfWidth(c.Name + " " + c.Manufacturer + " " + c.Model))
+	var best *GenreRuleResponse
+	for i := range rules {
+		r := &rules[i]
+		kw := strings.ToLower(toHalfWidth(r.Keyword))
+		if kw == "" || !strings.Contains(text, kw) {
+			continue
+		}
+		if best == nil ||
+			r.Priority > best.Priority ||
+			r.Priority == best.Priority && len([]rune(r.Keyword)) > len([]rune(best.Keyword)) {
+			best = r
+		}
+	}
+	return best
+}
+
+// applyGenreRules 各候補にジャンルの提案を付ける
+func applyGenreRules(rules []GenreRuleResponse, res *JANLookupResponse) {
+	for i := range res.Candidates {
+		res.Candidates[i].SuggestedGenreID = nil
+		if r := matchGenreRule(rules, res.Candidates[i]); r != nil {
+			id := r.GenreID
+			res.Candidates[i].SuggestedGenreID = &id
+		}
+	}
+	res.syncBest()
+}
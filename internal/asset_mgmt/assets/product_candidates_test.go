@@ -0,0 +1,51 @@
+package assets
+
+import "testing"
+
+func TestExtractModelNumber(t *testing.T) {
+	cases := map[string]string{
+		"ワイヤレスマウス M-XGM10DB ブラック":       "M-XGM10DB",
+		"ノートPC ＴＨＩＮＫＰＡＤ Ｅ１４ 256GB":      "",
+		"LANケーブル 1.5m CAT6 LD-GPN/BU15": "LD-GPN/BU15",
+		"【在庫あり】 (EW-M754T) インクジェット":     "EW-M754T",
+		"単3電池 4本パック":                    "",
+	}
+	for name, want := range cases {
+		if got := extractModelNumber(name); got != want {
+			t.Fatalf("%q: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestMatchGenreRulePrefersPriorityThenLongerKeyword(t *testing.T) {
+	rules := []GenreRuleResponse{
+		{RuleID: 1, Keyword: "マウス", GenreID: 10},
+		{RuleID: 2, Keyword: "ワイヤレスマウス", GenreID: 11},
+		{RuleID: 3, Keyword: "mouse", GenreID: 12},
+		{RuleID: 4, Keyword: "ＥＬＥＣＯＭ", GenreID: 13, Priority: 5},
+	}
+	if r := matchGenreRule(rules, ProductCandidate{Name: "ワイヤレスマウス 静音"}); r == nil || r.RuleID != 2 {
+		t.Fatalf("expected longer keyword, got %#v", r)
+	}
+	if r := matchGenreRule(rules, ProductCandidate{Name: "Bluetooth Mouse", Manufacturer: "Elecom"}); r == nil || r.RuleID != 4 {
+		t.Fatalf("expected higher priority (case/width-insensitive), got %#v", r)
+	}
+	if r := matchGenreRule(rules, ProductCandidate{Name: "キーボード"}); r != nil {
+		t.Fatalf("expected no match, got %#v", r)
+	}
+}
+
+func TestApplyGenreRulesUpdatesBestCandidate(t *testing.T) {
+	res := newJANLookupResponse([]ProductCandidate{
+		{Name: "マウスパッド", Confidence: 0.5, Source: ProductProviderYahoo},
+		{Name: "ワイヤレスマウス", Confidence: 0.8, Source: ProductProviderYahoo},
+	})
+	applyGenreRules([]GenreRuleResponse{{Keyword: "ワイヤレス", GenreID: 3}}, &res)
+
+	if res.Name != "ワイヤレスマウス" || res.SuggestedGenreID == nil || *res.SuggestedGenreID != 3 {
+		t.Fatalf("expected top-level fields from best candidate, got %#v", res)
+	}
+	if res.Candidates[1].SuggestedGenreID != nil {
+		t.Fatalf("expected no suggestion for second candidate, got %v", *res.Candidates[1].SuggestedGenreID)
+	}
+}
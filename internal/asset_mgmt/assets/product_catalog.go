@@ -0,0 +1,105 @@
+package assets
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	mysql "github.com/go-sql-driver/mysql"
+)
+
+// ===== 商品カタログの管理 =====
+
+func (s *Service) ListProductCatalog(ctx context.Context) ([]ProductCatalogEntry, error) {
+	return s.store.ListCatalogProducts(ctx)
+}
+
+// PutProductCatalog は手入力の商品情報を登録・上書きする。以後の検索ではキャッシュや外部 API より優先される
+func (s *Service) PutProductCatalog(ctx context.Context, janCode string, in PutProductCatalogRequest) (ProductCatalogEntry, error) {
+	janCode = strings.TrimSpace(janCode)
+	name := strings.TrimSpace(in.Name)
+	if janCode == "" || name == "" {
+		return ProductCatalogEntry{}, ErrInvalid("jan_code and name are required")
+	}
+	e, err := s.store.UpsertCatalogProduct(ctx, janCode, name, strings.TrimSpace(in.Manufacturer))
+	if err != nil {
+		return ProductCatalogEntry{}, err
+	}
+	return *e, nil
+}
+
+func (s *Service) DeleteProductCatalog(ctx context.Context, janCode string) error {
+	if err := s.store.DeleteCatalogProduct(ctx, strings.TrimSpace(janCode)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound("catalog entry not found")
+		}
+		return err
+	}
+	return nil
+}
+
+// ===== ジャンル推定ルール =====
+
+func normalizeGenreRule(in GenreRuleRequest) (GenreRuleRequest, error) {
+	in.Keyword = strings.TrimSpace(in.Keyword)
+	if in.Keyword == "" || in.GenreID == 0 {
+		return in, ErrInvalid("keyword and genre_id are required")
+	}
+	if len([]rune(in.Keyword)) > 100 {
+		return in, ErrInvalid("keyword must be at most 100 characters")
+	}
+	return in, nil
+}
+
+func mapGenreRuleError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound("genre rule not found")
+	}
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		switch me.Number {
+		case 1062:
+			return ErrConflict("a rule with this keyword already exists")
+		case 1452:
+			return ErrInvalid("invalid genre_id")
+		}
+	}
+	return err
+}
+
+// ListGenreRules 無効化されたジャンルを指す規則も含めて返す（検索では使われない）
+func (s *Service) ListGenreRules(ctx context.Context) ([]GenreRuleResponse, error) {
+	return s.store.ListGenreRules(ctx, false)
+}
+
+func (s *Service) CreateGenreRule(ctx context.Context, in GenreRuleRequest) (GenreRuleResponse, error) {
+	in, err := normalizeGenreRule(in)
+	if err != nil {
+		return GenreRuleResponse{}, err
+	}
+	out, err := s.store.CreateGenreRule(ctx, in)
+	if err != nil {
+		return GenreRuleResponse{}, mapGenreRuleError(err)
+	}
+	return *out, nil
+}
+
+func (s *Service) UpdateGenreRule(ctx context.Context, id uint64, in GenreRuleRequest) (GenreRuleResponse, error) {
+	in, err := normalizeGenreRule(in)
+	if err != nil {
+		return GenreRuleResponse{}, err
+	}
+	out, err := s.store.UpdateGenreRule(ctx, id, in)
+	if err != nil {
+		return GenreRuleResponse{}, mapGenreRuleError(err)
+	}
+	return *out, nil
+}
+
+func (s *Service) DeleteGenreRule(ctx context.Context, id uint64) error {
+	if err := s.store.DeleteGenreRule(ctx, id); err != nil {
+		return mapGenreRuleError(err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package assets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	ProductProviderOpenBD = "openbd"
+	ProductProviderYahoo  = "yahoo"
+
+	// ProductSourceCatalog 手入力の商品カタログから引いた結果
+	ProductSourceCatalog = "catalog"
+
+	openBDBaseURL = "https://api.openbd.jp"
+	yahooBaseURL  = "https://shopping.yahooapis.jp"
+
+	// Yahoo の検索で取る件数（まとめた後の候補は MaxProductCandidates 件まで）
+	yahooSearchResults = 20
+)
+
+// ProductLookupProvider は外部の商品情報 API。候補は確からしい順に返す。扱えないコード・該当なしは ErrNotFound を返す
+type ProductLookupProvider interface {
+	Name() string
+	Lookup(ctx context.Context, janCode string) ([]ProductCandidate, error)
+}
+
+// 内部用：Yahoo APIのレスポンス構造体
+type yahooSearchResponse struct {
+	Hits []yahooHit `json:"hits"`
+}
+
+type yahooHit struct {
+	Name    string `json:"name"`
+	JANCode string `json:"janCode"`
+	Price   *int   `json:"price"`
+	Image   struct {
+		Small  string `json:"small"`
+		Medium string `json:"medium"`
+	} `json:"image"`
+	Brand struct {
+		Name string `json:"name"`
+	} `json:"brand"`
+}
+
+// 内部用：OpenBDのレスポンス構造体
+type openBDResponse []*struct {
+	Summary struct {
+		Title     string `json:"title"`
+		Publisher string `json:"publisher"`
+		Author    string `json:"author"`
+		Cover     string `json:"cover"`
+	} `json:"summary"`
+}
+
+func isISBN(code string) bool {
+	return (strings.HasPrefix(code, "978") || strings.HasPrefix(code, "979")) && len(code) == 13
+}
+
+// getJSON は baseURL 以下へ GET して JSON を読む（テストでは httptest のサーバを向ける）
+func getJSON(ctx context.Context, httpClient *http.Client, rawURL string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ErrInternal(fmt.Sprintf("API returned status: %d", resp.StatusCode))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// ===== openBD（書籍。ISBN のみ） =====
+
+type openBDProvider struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+func newOpenBDProvider(httpClient *http.Client, baseURL string) *openBDProvider {
+	return &openBDProvider{httpClient: httpClient, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func (p *openBDProvider) Name() string { return ProductProviderOpenBD }
+
+func (p *openBDProvider) Lookup(ctx context.Context, isbn string) ([]ProductCandidate, error) {
+	if !isISBN(isbn) {
+		return nil, ErrNotFound("not an isbn")
+	}
+
+	var obdRes openBDResponse
+	if err := getJSON(ctx, p.httpClient, p.baseURL+"/v1/get?isbn="+url.QueryEscape(isbn), &obdRes); err != nil {
+		return nil, err
+	}
+
+	if len(obdRes) == 0 || obdRes[0] == nil || obdRes[0].Summary.Title == "" {
+		return nil, ErrNotFound("not found in openbd")
+	}
+
+	// ISBN で 1 冊に決まるので候補は 1 件
+	summary := obdRes[0].Summary
+	return []ProductCandidate{{
+		Name:         summary.Title,
+		Manufacturer: summary.Publisher,
+		ImageURL:     summary.Cover,
+		Confidence:   openBDConfidence,
+		Source:       ProductProviderOpenBD,
+	}}, nil
+}
+
+// ===== Yahoo!ショッピング =====
+
+type yahooProvider struct {
+	httpClient *http.Client
+	baseURL    string
+	appID      string
+}
+
+func newYahooProvider(httpClient *http.Client, baseURL, appID string) *yahooProvider {
+	return &yahooProvider{httpClient: httpClient, baseURL: strings.TrimRight(baseURL, "/"), appID: appID}
+}
+
+func (p *yahooProvider) Name() string { return ProductProviderYahoo }
+
+func (p *yahooProvider) Lookup(ctx context.Context, janCode string) ([]ProductCandidate, error) {
+	// 同じ商品を複数ストアが出しているので多めに取ってまとめる
+	q := url.Values{"appid": {p.appID}, "jan_code": {janCode}, "results": {strconv.Itoa(yahooSearchResults)}}
+
+	var yRes yahooSearchResponse
+	if err := getJSON(ctx, p.httpClient, p.baseURL+"/ShoppingWebService/V3/itemSearch?"+q.Encode(), &yRes); err != nil {
+		return nil, err
+	}
+
+	if len(yRes.Hits) == 0 {
+		return nil, ErrNotFound("item not found by jan_code")
+	}
+	return yahooCandidates(janCode, yRes.Hits), nil
+}
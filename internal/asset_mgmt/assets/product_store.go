@@ -0,0 +1,205 @@
+package assets
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// ===== 手入力の商品カタログ =====
+
+func (s *Store) GetCatalogProduct(ctx context.Context, janCode string) (*ProductCandidate, error) {
+	const q = `SELECT name, manufacturer FROM product_catalog WHERE jan_code = ?`
+	var out ProductCandidate
+	if err := s.db.QueryRowContext(ctx, q, janCode).Scan(&out.Name, &out.Manufacturer); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (s *Store) ListCatalogProducts(ctx context.Context) ([]ProductCatalogEntry, error) {
+	const q = `SELECT jan_code, name, manufacturer, updated_at FROM product_catalog ORDER BY jan_code`
+	rows, err := s.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]ProductCatalogEntry, 0, 16)
+	for rows.Next() {
+		var e ProductCatalogEntry
+		if err := rows.Scan(&e.JANCode, &e.Name, &e.Manufacturer, &e.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) UpsertCatalogProduct(ctx context.Context, janCode, name, manufacturer string) (*ProductCatalogEntry, error) {
+	const q = `
+	INSERT INTO product_catalog (jan_code, name, manufacturer, updated_at)
+	VALUES (?, ?, ?, UTC_TIMESTAMP())
+	ON DUPLICATE KEY UPDATE name = VALUES(name), manufacturer = VALUES(manufacturer), updated_at = VALUES(updated_at)`
+	if _, err := s.db.ExecContext(ctx, q, janCode, name, manufacturer); err != nil {
+		return nil, err
+	}
+
+	var e ProductCatalogEntry
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT jan_code, name, manufacturer, updated_at FROM product_catalog WHERE jan_code = ?`, janCode,
+	).Scan(&e.JANCode, &e.Name, &e.Manufacturer, &e.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (s *Store) DeleteCatalogProduct(ctx context.Context, janCode string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM product_catalog WHERE jan_code = ?`, janCode)
+	if err != nil {
+		return err
+	}
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ===== 外部プロバイダの結果キャッシュ =====
+// name / manufacturer / provider は代表の候補。candidates に候補全体を JSON で持つ（無い行は代表だけの 1 件として読む）
+
+func (s *Store) GetCachedProduct(ctx context.Context, janCode string, now time.Time) (*cachedProduct, error) {
+	const q = `
+	SELECT jan_code, found, name, manufacturer, provider, candidates, fetched_at, expires_at
+	FROM product_lookup_cache
+	WHERE jan_code = ? AND expires_at > ?`
+	var (
+		e            cachedProduct
+		name         sql.NullString
+		manufacturer sql.NullString
+		provider     sql.NullString
+		candidates   []byte
+	)
+	if err := s.db.QueryRowContext(ctx, q, janCode, now.UTC()).Scan(
+		&e.JANCode, &e.Found, &name, &manufacturer, &provider, &candidates, &e.FetchedAt, &e.ExpiresAt,
+	); err != nil {
+		return nil, err
+	}
+	if !e.Found {
+		return &e, nil
+	}
+	var cands []ProductCandidate
+	if len(candidates) > 0 {
+		if err := json.Unmarshal(candidates, &cands); err != nil {
+			return nil, err
+		}
+	}
+	if len(cands) == 0 {
+		cands = []ProductCandidate{{Name: name.String, Manufacturer: manufacturer.String, Source: provider.String}}
+	}
+	e.Product = newJANLookupResponse(cands)
+	return &e, nil
+}
+
+func (s *Store) PutCachedProduct(ctx context.Context, e cachedProduct) error {
+	const q = `
+	INSERT INTO product_lookup_cache (jan_code, found, name, manufacturer, provider, candidates, fetched_at, expires_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE
+		found = VALUES(found), name = VALUES(name), manufacturer = VALUES(manufacturer),
+		provider = VALUES(provider), candidates = VALUES(candidates),
+		fetched_at = VALUES(fetched_at), expires_at = VALUES(expires_at)`
+	var name, manufacturer, provider, candidates *string
+	if e.Found {
+		name, manufacturer, provider = &e.Product.Name, &e.Product.Manufacturer, &e.Product.Source
+		b, err := json.Marshal(e.Product.Candidates)
+		if err != nil {
+			return err
+		}
+		c := string(b)
+		candidates = &c
+	}
+	_, err := s.db.ExecContext(ctx, q, e.JANCode, e.Found, name, manufacturer, provider, candidates, e.FetchedAt.UTC(), e.ExpiresAt.UTC())
+	return err
+}
+
+// ===== ジャンル推定ルール =====
+
+const genreRuleColumns = `rule_id, keyword, genre_id, priority, created_at, updated_at`
+
+func scanGenreRule(row interface{ Scan(...any) error }) (*GenreRuleResponse, error) {
+	var r GenreRuleResponse
+	if err := row.Scan(&r.RuleID, &r.Keyword, &r.GenreID, &r.Priority, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ListGenreRules activeOnly なら無効化されたジャンルを指す規則を除く
+func (s *Store) ListGenreRules(ctx context.Context, activeOnly bool) ([]GenreRuleResponse, error) {
+	q := `SELECT r.rule_id, r.keyword, r.genre_id, r.priority, r.created_at, r.updated_at
+	FROM asset_genre_rules r
+	JOIN asset_genres g ON g.genre_id = r.genre_id`
+	if activeOnly {
+		q += ` WHERE g.is_disabled = 0`
+	}
+	q += ` ORDER BY r.priority DESC, r.rule_id`
+	rows, err := s.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]GenreRuleResponse, 0, 16)
+	for rows.Next() {
+		r, err := scanGenreRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *r)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) GetGenreRule(ctx context.Context, id uint64) (*GenreRuleResponse, error) {
+	q := `SELECT ` + genreRuleColumns + ` FROM asset_genre_rules WHERE rule_id = ?`
+	return scanGenreRule(s.db.QueryRowContext(ctx, q, id))
+}
+
+func (s *Store) CreateGenreRule(ctx context.Context, in GenreRuleRequest) (*GenreRuleResponse, error) {
+	const q = `
+	INSERT INTO asset_genre_rules (keyword, genre_id, priority, created_at, updated_at)
+	VALUES (?, ?, ?, UTC_TIMESTAMP(), UTC_TIMESTAMP())`
+	res, err := s.db.ExecContext(ctx, q, in.Keyword, in.GenreID, in.Priority)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return s.GetGenreRule(ctx, uint64(id))
+}
+
+func (s *Store) UpdateGenreRule(ctx context.Context, id uint64, in GenreRuleRequest) (*GenreRuleResponse, error) {
+	const q = `
+	UPDATE asset_genre_rules
+	SET keyword = ?, genre_id = ?, priority = ?, updated_at = UTC_TIMESTAMP()
+	WHERE rule_id = ?`
+	if _, err := s.db.ExecContext(ctx, q, in.Keyword, in.GenreID, in.Priority, id); err != nil {
+		return nil, err
+	}
+	return s.GetGenreRule(ctx, id)
+}
+
+func (s *Store) DeleteGenreRule(ctx context.Context, id uint64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM asset_genre_rules WHERE rule_id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
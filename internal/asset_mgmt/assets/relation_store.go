@@ -0,0 +1,78 @@
+package assets
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// relationLink 子から親へたどった関係 1 段分
+type relationLink struct {
+	ParentMasterID uint64
+	Type           string
+}
+
+// LockMastersTx は関係を張る 2 つのマスタ行を asset_master_id 順にロックする
+func (s *Store) LockMastersTx(ctx context.Context, tx *sql.Tx, a, b uint64) error {
+	rows, err := tx.QueryContext(ctx, `
+	SELECT asset_master_id FROM assets_master
+	WHERE asset_master_id IN (?, ?)
+	ORDER BY asset_master_id
+	FOR UPDATE`, a, b)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ParentChainTx は masterID の親、その親…と根までたどる
+func (s *Store) ParentChainTx(ctx context.Context, tx *sql.Tx, masterID uint64) ([]relationLink, error) {
+	var chain []relationLink
+	seen := map[uint64]bool{masterID: true}
+	for cur := masterID; ; {
+		var l relationLink
+		err := tx.QueryRowContext(ctx,
+			`SELECT parent_master_id, relation_type FROM asset_relations WHERE child_master_id = ?`, cur,
+		).Scan(&l.ParentMasterID, &l.Type)
+		if errors.Is(err, sql.ErrNoRows) {
+			return chain, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, l)
+		// 既存データが壊れていても止まるように
+		if seen[l.ParentMasterID] {
+			return chain, nil
+		}
+		seen[l.ParentMasterID] = true
+		cur = l.ParentMasterID
+	}
+}
+
+func (s *Store) InsertRelationTx(ctx context.Context, tx *sql.Tx, parentID, childID uint64, in CreateAssetRelationRequest) error {
+	_, err := tx.ExecContext(ctx, `
+	INSERT INTO asset_relations
+		(parent_master_id, child_master_id, relation_type, quantity, note, created_at)
+	VALUES (?, ?, ?, ?, ?, UTC_TIMESTAMP())`,
+		parentID, childID, in.RelationType, in.Quantity, in.Note)
+	return err
+}
+
+func (s *Store) DeleteRelation(ctx context.Context, relationID uint64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM asset_relations WHERE relation_id = ?`, relationID)
+	if err != nil {
+		return err
+	}
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package assets
+
+// 資産の関係（親子・付属品）
+// - プロジェクタとリモコン・ケーブル・ケースのように、別の管理番号どうしを親子で結ぶ
+// - bundle は lend のキット貸出（POST /lends/kit）で親と一緒に貸し出され、返却・廃棄で欠けると警告が出る
+// - component は構成部品の記録だけ。貸出では展開しない
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	mysql "github.com/go-sql-driver/mysql"
+
+	"IRIS-backend/internal/asset_mgmt/inventory"
+)
+
+// MaxRelationQuantity 親 1 つあたりに付けられる数の上限
+const MaxRelationQuantity = 1000
+
+func normalizeRelationRequest(in CreateAssetRelationRequest) (CreateAssetRelationRequest, error) {
+	in.ChildManagementNumber = strings.TrimSpace(in.ChildManagementNumber)
+	if in.ChildManagementNumber == "" {
+		return in, ErrInvalid("child_management_number is required")
+	}
+
+	in.RelationType = strings.ToLower(strings.TrimSpace(in.RelationType))
+	switch in.RelationType {
+	case "":
+		in.RelationType = inventory.RelationBundle
+	case inventory.RelationBundle, inventory.RelationComponent:
+	default:
+		return in, ErrInvalid("relation_type must be bundle or component")
+	}
+
+	if in.Quantity == 0 {
+		in.Quantity = 1
+	}
+	if in.Quantity < 0 || in.Quantity > MaxRelationQuantity {
+		return in, ErrInvalid(fmt.Sprintf("quantity must be between 1 and %d", MaxRelationQuantity))
+	}
+
+	if in.Note != nil {
+		note := strings.TrimSpace(*in.Note)
+		if note == "" {
+			in.Note = nil
+		} else if len([]rune(note)) > 255 {
+			return in, ErrInvalid("note is too long")
+		} else {
+			in.Note = &note
+		}
+	}
+	return in, nil
+}
+
+// checkRelationChain は循環と付属品の入れ子の深さを確かめる。
+// chain は親から根へたどった関係、childBundleHeight は子の下にある付属品の階層数
+func checkRelationChain(parentID, childID uint64, relationType string, chain []relationLink, childBundleHeight int) error {
+	if parentID == childID {
+		return ErrInvalid("an asset cannot be related to itself")
+	}
+	for _, l := range chain {
+		if l.ParentMasterID == childID {
+			return ErrConflict("relation would create a cycle")
+		}
+	}
+	if relationType != inventory.RelationBundle {
+		return nil
+	}
+
+	// キットは bundle が続く一番上の親から展開されるので、そこからの深さで数える
+	depth := 1 + childBundleHeight
+	for _, l := range chain {
+		if l.Type != inventory.RelationBundle {
+			break
+		}
+		depth++
+	}
+	if depth > inventory.MaxBundleDepth {
+		return ErrInvalid(fmt.Sprintf("bundles can be nested at most %d levels", inventory.MaxBundleDepth))
+	}
+	return nil
+}
+
+func toAssetRelationResponse(r inventory.Relation) AssetRelationResponse {
+	out := AssetRelationResponse{
+		RelationID:             r.RelationID,
+		RelationType:           r.Type,
+		ParentManagementNumber: r.ParentManagementNumber,
+		ChildManagementNumber:  r.ChildManagementNumber,
+		Quantity:               r.Quantity,
+		CreatedAt:              r.CreatedAt,
+	}
+	if r.Note.Valid {
+		v := r.Note.String
+		out.Note = &v
+	}
+	return out
+}
+
+// ListAssetRelations 管理番号（別名でも可）の親・子と、キット貸出で展開される付属品
+func (s *Service) ListAssetRelations(ctx context.Context, managementNumber string) (AssetRelationsResponse, error) {
+	id, canonical, err := inventory.ResolveManagementNumber(ctx, s.db, managementNumber)
+	if errors.Is(err, sql.ErrNoRows) {
+		return AssetRelationsResponse{}, ErrNotFound("master not found")
+	}
+	if err != nil {
+		return AssetRelationsResponse{}, err
+	}
+
+	rels, err := inventory.ListRelationsByMasterID(ctx, s.db, id)
+	if err != nil {
+		return AssetRelationsResponse{}, err
+	}
+	out := AssetRelationsResponse{
+		ManagementNumber: canonical,
+		Children:         make([]AssetRelationResponse, 0, len(rels)),
+		Kit:              []KitMemberResponse{},
+	}
+	for _, r := range rels {
+		resp := toAssetRelationResponse(r)
+		if r.ChildMasterID == id {
+			out.Parent = &resp
+			continue
+		}
+		out.Children = append(out.Children, resp)
+	}
+
+	members, err := inventory.ListBundleMembers(ctx, s.db, id)
+	if err != nil {
+		return AssetRelationsResponse{}, err
+	}
+	parents := map[uint64]string{id: canonical}
+	for _, m := range members {
+		parents[m.AssetMasterID] = m.ManagementNumber
+	}
+	for _, m := range members {
+		available, err := inventory.GetAvailableQuantityByMasterID(ctx, s.db, int64(m.AssetMasterID))
+		if err != nil {
+			return AssetRelationsResponse{}, err
+		}
+		out.Kit = append(out.Kit, KitMemberResponse{
+			ManagementNumber:       m.ManagementNumber,
+			ParentManagementNumber: parents[m.ParentMasterID],
+			Quantity:               m.Quantity,
+			Depth:                  m.Depth,
+			AvailableQuantity:      available,
+		})
+	}
+	return out, nil
+}
+
+// CreateAssetRelation 管理番号のマスタを親として子を結ぶ（子が別の親に属していれば 409）
+func (s *Service) CreateAssetRelation(ctx context.Context, managementNumber string, req CreateAssetRelationRequest) (AssetRelationsResponse, error) {
+	req, err := normalizeRelationRequest(req)
+	if err != nil {
+		return AssetRelationsResponse{}, err
+	}
+	parentID, err := s.resolveMasterID(ctx, managementNumber)
+	if err != nil {
+		return AssetRelationsResponse{}, err
+	}
+	childID, err := s.resolveMasterID(ctx, req.ChildManagementNumber)
+	if err != nil {
+		return AssetRelationsResponse{}, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return AssetRelationsResponse{}, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err := s.store.LockMastersTx(ctx, tx, parentID, childID); err != nil {
+		return AssetRelationsResponse{}, err
+	}
+	chain, err := s.store.ParentChainTx(ctx, tx, parentID)
+	if err != nil {
+		return AssetRelationsResponse{}, err
+	}
+	childHeight := 0
+	if req.RelationType == inventory.RelationBundle {
+		members, err := inventory.ListBundleMembers(ctx, tx, childID)
+		if err != nil {
+			return AssetRelationsResponse{}, err
+		}
+		for _, m := range members {
+			childHeight = max(childHeight, m.Depth)
+		}
+	}
+	if err := checkRelationChain(parentID, childID, req.RelationType, chain, childHeight); err != nil {
+		return AssetRelationsResponse{}, err
+	}
+
+	if err := s.store.InsertRelationTx(ctx, tx, parentID, childID, req); err != nil {
+		var me *mysql.MySQLError
+		if errors.As(err, &me) && me.Number == 1062 {
+			return AssetRelationsResponse{}, ErrConflict(req.ChildManagementNumber + " already belongs to another parent")
+		}
+		return AssetRelationsResponse{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return AssetRelationsResponse{}, err
+	}
+	committed = true
+
+	return s.ListAssetRelations(ctx, managementNumber)
+}
+
+func (s *Service) DeleteAssetRelation(ctx context.Context, relationID uint64) error {
+	if err := s.store.DeleteRelation(ctx, relationID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound("relation not found")
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package assets
+
+import (
+	"strings"
+	"testing"
+
+	"IRIS-backend/internal/asset_mgmt/inventory"
+)
+
+func TestNormalizeRelationRequest(t *testing.T) {
+	note := "  "
+	got, err := normalizeRelationRequest(CreateAssetRelationRequest{ChildManagementNumber: " PRJ-0001-R ", Note: &note})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.ChildManagementNumber != "PRJ-0001-R" || got.RelationType != inventory.RelationBundle || got.Quantity != 1 || got.Note != nil {
+		t.Fatalf("unexpected normalized request: %+v", got)
+	}
+
+	got, err = normalizeRelationRequest(CreateAssetRelationRequest{ChildManagementNumber: "PC-1-MEM", RelationType: " Component ", Quantity: 2})
+	if err != nil || got.RelationType != inventory.RelationComponent || got.Quantity != 2 {
+		t.Fatalf("unexpected result: %+v, %v", got, err)
+	}
+
+	// 上限は文字数で数える（255 文字の日本語はバイトでは超えるが通る）
+	jp := strings.Repeat("付", 255)
+	if got, err := normalizeRelationRequest(CreateAssetRelationRequest{ChildManagementNumber: "A", Note: &jp}); err != nil || *got.Note != jp {
+		t.Fatalf("expected a 255-character note accepted, got %v", err)
+	}
+
+	long := strings.Repeat("x", 256)
+	cases := map[string]CreateAssetRelationRequest{
+		"no child":      {ChildManagementNumber: " "},
+		"unknown type":  {ChildManagementNumber: "A", RelationType: "accessory"},
+		"negative qty":  {ChildManagementNumber: "A", Quantity: -1},
+		"too many":      {ChildManagementNumber: "A", Quantity: MaxRelationQuantity + 1},
+		"note too long": {ChildManagementNumber: "A", Note: &long},
+	}
+	for name, in := range cases {
+		if _, err := normalizeRelationRequest(in); toHTTPStatus(err) != 400 {
+			t.Fatalf("%s: expected 400, got %v", name, err)
+		}
+	}
+}
+
+func TestCheckRelationChain(t *testing.T) {
+	bundle := func(parent uint64) relationLink {
+		return relationLink{ParentMasterID: parent, Type: inventory.RelationBundle}
+	}
+	component := func(parent uint64) relationLink {
+		return relationLink{ParentMasterID: parent, Type: inventory.RelationComponent}
+	}
+
+	if err := checkRelationChain(1, 1, inventory.RelationBundle, nil, 0); toHTTPStatus(err) != 400 {
+		t.Fatalf("expected 400 for self relation, got %v", err)
+	}
+	// 3 → 2 → 1 の下に 1 を付けると循環する
+	if err := checkRelationChain(3, 1, inventory.RelationComponent, []relationLink{bundle(2), component(1)}, 0); toHTTPStatus(err) != 409 {
+		t.Fatalf("expected 409 for cycle, got %v", err)
+	}
+
+	// 親の上に bundle が 3 段、子の下に 1 段 → 3 + 1 + 1 = 5 段はちょうど上限
+	chain := []relationLink{bundle(2), bundle(3), bundle(4)}
+	if err := checkRelationChain(1, 10, inventory.RelationBundle, chain, 1); err != nil {
+		t.Fatalf("unexpected error at max depth: %v", err)
+	}
+	if err := checkRelationChain(1, 10, inventory.RelationBundle, chain, 2); toHTTPStatus(err) != 400 {
+		t.Fatalf("expected 400 when nesting too deep, got %v", err)
+	}
+	// component を挟むとキットはそこで切れる
+	chain = []relationLink{bundle(2), component(3), bundle(4), bundle(5)}
+	if err := checkRelationChain(1, 10, inventory.RelationBundle, chain, 3); err != nil {
+		t.Fatalf("unexpected error across a component link: %v", err)
+	}
+	// component どうしは深さを問わない
+	if err := checkRelationChain(1, 10, inventory.RelationComponent, chain, 9); err != nil {
+		t.Fatalf("unexpected error for component: %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package assets
+
+// スキャンしたコードの解決（GET /scan/:code）
+// 管理番号 → 別名 → シリアル → JAN の順に試し、既存の備品に当たればそれを、
+// 当たらなければ JAN として商品情報を引いて登録の下書きを返す
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+
+	"IRIS-backend/internal/asset_mgmt/inventory"
+)
+
+const (
+	ScanKindManagementNumber = "management_number"
+	ScanKindAlias            = "alias"
+	ScanKindSerial           = "serial"
+	ScanKindJAN              = "jan"
+)
+
+// maxScanSerialMatches シリアルが一致した備品を返す上限
+const maxScanSerialMatches = 10
+
+// isValidJAN JAN-13 / JAN-8（ISBN-13 を含む）の桁数とチェックディジット
+func isValidJAN(code string) bool {
+	if len(code) != 13 && len(code) != 8 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		if i == len(code)-1 {
+			break
+		}
+		// 右端（チェックディジット）の左隣から 3, 1, 3, ... の重み
+		w := 1
+		if (len(code)-1-i)%2 == 1 {
+			w = 3
+		}
+		sum += int(c-'0') * w
+	}
+	return int(code[len(code)-1]-'0') == (10-sum%10)%10
+}
+
+// Scan はコードを分類して備品か登録の下書きに解決する
+func (s *Service) Scan(ctx context.Context, code string) (ScanResponse, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ScanResponse{}, ErrInvalid("code is required")
+	}
+	out := ScanResponse{Code: code}
+
+	// 1) 管理番号・別名
+	_, canonical, err := inventory.ResolveManagementNumber(ctx, s.db, code)
+	switch {
+	case err == nil:
+		set, err := s.GetAssetSet(ctx, canonical)
+		if err != nil {
+			return ScanResponse{}, err
+		}
+		out.Kind = ScanKindManagementNumber
+		if canonical != code {
+			out.Kind = ScanKindAlias
+			set.ResolvedAlias = &code
+		}
+		out.Asset = &set
+		return out, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return ScanResponse{}, err
+	}
+
+	// 2) シリアル（完全一致）
+	mngs, err := s.store.FindManagementNumbersBySerial(ctx, code, maxScanSerialMatches)
+	if err != nil {
+		return ScanResponse{}, err
+	}
+	if len(mngs) > 0 {
+		out.Kind = ScanKindSerial
+		for _, mng := range mngs {
+			set, err := s.GetAssetSet(ctx, mng)
+			if err != nil {
+				return ScanResponse{}, err
+			}
+			out.Matches = append(out.Matches, set)
+		}
+		if len(out.Matches) == 1 {
+			out.Asset = &out.Matches[0]
+			out.Matches = nil
+		}
+		return out, nil
+	}
+
+	// 3) JAN: 商品情報が引けなくても JAN だけ入った下書きを返す
+	if isValidJAN(code) {
+		out.Kind = ScanKindJAN
+		draft := &ScanRegistrationDraft{JANCode: code}
+		if info, err := s.LookupJAN(ctx, code); err != nil {
+			msg := lookupErrorMessage(code, err)
+			draft.LookupError = &msg
+		} else {
+			draft.Name = info.Name
+			draft.Manufacturer = info.Manufacturer
+			draft.Model = info.Model
+			draft.GenreID = info.SuggestedGenreID
+		}
+		out.Draft = draft
+		return out, nil
+	}
+
+	return ScanResponse{}, ErrNotFound("no asset or product matches the code")
+}
+
+// lookupErrorMessage は下書きに載せる理由を返す。
+// 通信エラーなどは URL（appid を含む）が混ざるので、詳細はログだけに出して固定の文言にする
+func lookupErrorMessage(janCode string, err error) string {
+	var api *APIError
+	if errors.As(err, &api) {
+		return api.Message
+	}
+	log.Printf("[WARN] scan lookup(%s): %v", janCode, redactLookupError(err))
+	return "product lookup failed"
+}
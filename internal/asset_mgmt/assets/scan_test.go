@@ -0,0 +1,35 @@
+package assets
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestIsValidJAN(t *testing.T) {
+	cases := map[string]bool{
+		"4901234567894": true,  // JAN-13
+		"9784873119465": true,  // ISBN-13
+		"49968545":      true,  // JAN-8
+		"4901234567890": false, // チェックディジット違い
+		"490123456789":  false, // 12 桁
+		"OFS-00012":     false,
+		"49012345678a4": false,
+	}
+	for code, want := range cases {
+		if got := isValidJAN(code); got != want {
+			t.Fatalf("isValidJAN(%q) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+func TestLookupErrorMessageHidesInternalErrors(t *testing.T) {
+	if got := lookupErrorMessage("4901234567894", ErrNotFound("product not found")); got != "product not found" {
+		t.Fatalf("expected the APIError message, got %q", got)
+	}
+	leak := &url.Error{Op: "Get", URL: "https://example.com/search?appid=secret&jan_code=4901234567894", Err: errors.New("timeout")}
+	if got := lookupErrorMessage("4901234567894", leak); strings.Contains(got, "secret") || got != "product lookup failed" {
+		t.Fatalf("expected a fixed message, got %q", got)
+	}
+}
@@ -121,6 +121,45 @@ func TestBuildSearchAssetsQueryUsesCombinedFilters(t *testing.T) {
 	}
 }
 
+func TestBuildSearchAssetsQueryIncludesGenreDescendants(t *testing.T) {
+	genreID := uint(2)
+
+	query, args := buildSearchAssetsQuery(AssetSearchQuery{GenreID: &genreID, GenreDescendants: true})
+	if !strings.Contains(query, "WITH RECURSIVE subtree") || strings.Contains(query, "m.genre_id = ?") {
+		t.Fatalf("expected subtree condition instead of exact genre match, got:\n%s", query)
+	}
+	if len(args) != 1 || args[0] != genreID {
+		t.Fatalf("expected genre_id as the only arg, got %#v", args)
+	}
+
+	values := map[string][]string{"genre_id": {"2"}, "include_descendants": {"yes"}}
+	if _, err := ParseAssetSearchQuery(values); err == nil {
+		t.Fatal("expected invalid include_descendants rejected")
+	}
+	values["include_descendants"] = []string{"true"}
+	q, err := ParseAssetSearchQuery(values)
+	if err != nil || !q.GenreDescendants {
+		t.Fatalf("expected include_descendants parsed, got %#v, %v", q, err)
+	}
+}
+
+func TestBuildSearchAssetsQueryAppliesLimit(t *testing.T) {
+	name := "Projector"
+
+	query, args := buildSearchAssetsQuery(AssetSearchQuery{Name: &name})
+	if strings.Contains(query, "LIMIT") || len(args) != 1 {
+		t.Fatalf("expected no limit by default, got:\n%s %#v", query, args)
+	}
+
+	query, args = buildSearchAssetsQuery(AssetSearchQuery{Name: &name, Limit: 501})
+	if !strings.HasSuffix(strings.TrimSpace(query), "ORDER BY m.asset_master_id, a.asset_id\n\t\tLIMIT ?") {
+		t.Fatalf("expected LIMIT after ORDER BY, got:\n%s", query)
+	}
+	if len(args) != 2 || args[1] != 501 {
+		t.Fatalf("expected limit as the last arg, got %#v", args)
+	}
+}
+
 func TestBuildAssetSearchQueryParsesDatesAndNumbers(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -12,7 +12,6 @@ import (
 	"time"
 
 	mysql "github.com/go-sql-driver/mysql"
-	ulid "github.com/oklog/ulid/v2"
 )
 
 type Service struct {
@@ -32,7 +31,18 @@ func NewService(db *sql.DB, janClient *JANClient) *Service {
 // LookupJAN は Service としての窓口
 func (s *Service) LookupJAN(ctx context.Context, janCode string) (JANLookupResponse, error) {
 	// 実際の通信は Client に任せる
-	return s.JANClient.FetchJANInfo(ctx, janCode)
+	res, err := s.JANClient.FetchJANInfo(ctx, janCode)
+	if err != nil {
+		return res, err
+	}
+	// ジャンルの提案はキャッシュせず、その時点の規則で付ける
+	rules, err := s.store.ListGenreRules(ctx, true)
+	if err != nil {
+		log.Printf("[WARN] genre rules: %v", err)
+		return res, nil
+	}
+	applyGenreRules(rules, &res)
+	return res, nil
 }
 
 // ===== Master =====
@@ -42,46 +52,75 @@ func (s *Service) CreateAssetMaster(ctx context.Context, in CreateAssetMasterReq
 		in.ManagementCategoryID == 0 || in.GenreID == 0 {
 		return AssetMasterResponse{}, ErrInvalid("name, manufacturer, management_category_id, genre_id are required")
 	}
-
-	// 仮管理番号（UNIQUEを満たす）
-	tmpMng := "TMP-" + ulid.Make().String()
-
-	// 1) 仮INSERT → PK取得
-	id, err := s.store.InsertMasterTmp(ctx, in, tmpMng)
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
 	if err != nil {
-		var me *mysql.MySQLError
-		if errors.As(err, &me) {
-			switch me.Number {
-			case 1062: // duplicate key
-				return AssetMasterResponse{}, ErrConflict("management_number already exists")
-			case 1452: // foreign key constraint fails
-				return AssetMasterResponse{}, ErrInvalid("invalid management_category_id or genre_id")
-			}
-		}
 		return AssetMasterResponse{}, err
 	}
-
-	// 2) 確定管理番号に置換（DBの created_at と genres.genre_code を使用）
-	if err := s.store.UpdateMngToFinal(ctx, id, tmpMng, 5 /*パディング桁*/); err != nil {
-		// if errors.Is(err, ErrConflict) {
-		// 	return AssetMasterResponse{}, ErrConflict("conflict while finalizing management_number")
-		// }
-		var ae *APIError
-		if errors.As(err, &ae) && ae.Code == CodeConflict {
-			return AssetMasterResponse{}, ErrConflict("conflict while finalizing management_number")
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
 		}
+	}()
 
+	attrs, err := s.validateNewAttributesTx(ctx, tx, in.GenreID, AttrScopeMaster, in.Attributes)
+	if err != nil {
 		return AssetMasterResponse{}, err
 	}
+	id, err := s.insertMasterTx(ctx, tx, in)
+	if err != nil {
+		return AssetMasterResponse{}, err
+	}
+	if err := s.store.SetMasterAttributesTx(ctx, tx, id, attrs); err != nil {
+		return AssetMasterResponse{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return AssetMasterResponse{}, err
+	}
+	committed = true
 
-	// 3) IDで取得して返却
+	// IDで取得して返却
 	out, err := s.store.GetMasterByID(ctx, id)
 	if err != nil {
 		return AssetMasterResponse{}, err
 	}
+	if err := s.fillAttributes(ctx, []*AssetMasterResponse{out}, nil); err != nil {
+		return AssetMasterResponse{}, err
+	}
 	return *out, nil
 }
 
+// validateNewAttributesTx 新規登録するマスタ・個体の追加項目を genreID の定義で検証する（定義は tx 内で共有ロック）
+func (s *Service) validateNewAttributesTx(ctx context.Context, tx *sql.Tx, genreID uint, scope string, in map[string]any) (map[uint64]*attrValue, error) {
+	defs, err := s.store.ListAttributeDefinitionsTx(ctx, tx, genreID)
+	if err != nil {
+		return nil, err
+	}
+	return validateAttributes(defs, scope, in, nil, false)
+}
+
+// insertMasterTx は管理番号を採番してマスタを登録する（採番スキームは numbering.go）
+func (s *Service) insertMasterTx(ctx context.Context, tx *sql.Tx, in CreateAssetMasterRequest) (uint64, error) {
+	mng, createdAt, err := s.store.NextManagementNumberTx(ctx, tx, in)
+	if err != nil {
+		return 0, err
+	}
+	id, err := s.store.InsertMasterTx(ctx, tx, in, mng, createdAt)
+	if err != nil {
+		var me *mysql.MySQLError
+		if errors.As(err, &me) {
+			switch me.Number {
+			case 1062: // duplicate key
+				return 0, ErrConflict("management_number already exists")
+			case 1452: // foreign key constraint fails
+				return 0, ErrInvalid("invalid management_category_id or genre_id")
+			}
+		}
+		return 0, err
+	}
+	return id, nil
+}
+
 func (s *Service) GetAssetMaster(ctx context.Context, managementNumber string) (AssetMasterResponse, error) {
 	out, err := s.store.GetMasterByMng(ctx, managementNumber)
 	if err != nil {
@@ -90,6 +129,9 @@ func (s *Service) GetAssetMaster(ctx context.Context, managementNumber string) (
 		}
 		return AssetMasterResponse{}, err
 	}
+	if err := s.fillAttributes(ctx, []*AssetMasterResponse{out}, nil); err != nil {
+		return AssetMasterResponse{}, err
+	}
 	return *out, nil
 }
 
@@ -98,10 +140,20 @@ func (s *Service) ListAssetMasters(ctx context.Context, p Page, q AssetSearchQue
 	if err != nil {
 		return nil, 0, err
 	}
+	masters := make([]*AssetMasterResponse, len(items))
+	for i := range items {
+		masters[i] = &items[i]
+	}
+	if err := s.fillAttributes(ctx, masters, nil); err != nil {
+		return nil, 0, err
+	}
 	return items, total, nil
 }
 
 func (s *Service) UpdateAssetMaster(ctx context.Context, managementNumber string, in UpdateAssetMasterRequest) (AssetMasterResponse, error) {
+	if in.GenreID != nil || len(in.Attributes) > 0 {
+		return s.updateAssetMasterWithAttributes(ctx, managementNumber, in)
+	}
 	out, err := s.store.UpdateMasterByMng(ctx, managementNumber, in)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -109,6 +161,71 @@ func (s *Service) UpdateAssetMaster(ctx context.Context, managementNumber string
 		}
 		return AssetMasterResponse{}, err
 	}
+	if err := s.fillAttributes(ctx, []*AssetMasterResponse{out}, nil); err != nil {
+		return AssetMasterResponse{}, err
+	}
+	return *out, nil
+}
+
+// updateAssetMasterWithAttributes ジャンルか追加項目が変わる更新。変更後のジャンルの定義で検証し、マスタと値を同じトランザクションで書く
+func (s *Service) updateAssetMasterWithAttributes(ctx context.Context, managementNumber string, in UpdateAssetMasterRequest) (AssetMasterResponse, error) {
+	cur, err := s.store.GetMasterByMng(ctx, managementNumber)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return AssetMasterResponse{}, ErrNotFound("master not found")
+		}
+		return AssetMasterResponse{}, err
+	}
+	genreID := cur.GenreID
+	if in.GenreID != nil {
+		genreID = *in.GenreID
+	}
+	stored, err := s.store.StoredMasterAttributeIDs(ctx, cur.AssetMasterID)
+	if err != nil {
+		return AssetMasterResponse{}, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return AssetMasterResponse{}, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	defs, err := s.store.ListAttributeDefinitionsTx(ctx, tx, genreID)
+	if err != nil {
+		return AssetMasterResponse{}, err
+	}
+	attrs, err := validateAttributes(defs, AttrScopeMaster, in.Attributes, stored, true)
+	if err != nil {
+		return AssetMasterResponse{}, err
+	}
+	if err := s.store.UpdateMasterTx(ctx, tx, cur.AssetMasterID, in); err != nil {
+		var me *mysql.MySQLError
+		if errors.As(err, &me) && me.Number == 1452 {
+			return AssetMasterResponse{}, ErrInvalid("invalid management_category_id or genre_id")
+		}
+		return AssetMasterResponse{}, err
+	}
+	if err := s.store.SetMasterAttributesTx(ctx, tx, cur.AssetMasterID, attrs); err != nil {
+		return AssetMasterResponse{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return AssetMasterResponse{}, err
+	}
+	committed = true
+
+	out, err := s.store.GetMasterByID(ctx, cur.AssetMasterID)
+	if err != nil {
+		return AssetMasterResponse{}, err
+	}
+	if err := s.fillAttributes(ctx, []*AssetMasterResponse{out}, nil); err != nil {
+		return AssetMasterResponse{}, err
+	}
 	return *out, nil
 }
 
@@ -140,11 +257,38 @@ func (s *Service) CreateAsset(ctx context.Context, in CreateAssetRequest) (Asset
 		return AssetResponse{}, ErrInvalid("purchased_at required")
 	}
 
-	id, mgmt, err := s.store.CreateAssetTx(ctx, in, masterID)
+	master, err := s.store.GetMasterByID(ctx, masterID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return AssetResponse{}, ErrInvalid("asset_master_id not found")
+		}
 		return AssetResponse{}, err
 	}
 
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return AssetResponse{}, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	attrs, err := s.validateNewAttributesTx(ctx, tx, master.GenreID, AttrScopeAsset, in.Attributes)
+	if err != nil {
+		return AssetResponse{}, err
+	}
+	id, mgmt, err := s.store.CreateAssetTx(ctx, tx, in, masterID, attrs)
+	if err != nil {
+		return AssetResponse{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return AssetResponse{}, err
+	}
+	committed = true
+
 	return AssetResponse{
 		AssetID:          id,
 		ManagementNumber: mgmt,
@@ -160,6 +304,9 @@ func (s *Service) GetAsset(ctx context.Context, id uint64) (AssetResponse, error
 		}
 		return AssetResponse{}, err
 	}
+	if err := s.fillAttributes(ctx, nil, []*AssetResponse{out}); err != nil {
+		return AssetResponse{}, err
+	}
 	return *out, nil
 }
 
@@ -168,6 +315,13 @@ func (s *Service) ListAssets(ctx context.Context, q AssetSearchQuery, p Page) ([
 	if err != nil {
 		return nil, 0, err
 	}
+	ptrs := make([]*AssetResponse, len(items))
+	for i := range items {
+		ptrs[i] = &items[i]
+	}
+	if err := s.fillAttributes(ctx, nil, ptrs); err != nil {
+		return nil, 0, err
+	}
 	return items, total, nil
 }
 
@@ -177,6 +331,9 @@ func (s *Service) UpdateAsset(ctx context.Context, id uint64, in UpdateAssetRequ
 	if in.Quantity != nil && int(*in.Quantity) < 0 {
 		return AssetResponse{}, ErrInvalid("quantity must be >= 0")
 	}
+	if len(in.Attributes) > 0 {
+		return s.updateAssetWithAttributes(ctx, id, in)
+	}
 	out, err := s.store.UpdateAssetByID(ctx, id, in)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -184,6 +341,71 @@ func (s *Service) UpdateAsset(ctx context.Context, id uint64, in UpdateAssetRequ
 		}
 		return AssetResponse{}, err
 	}
+	if err := s.fillAttributes(ctx, nil, []*AssetResponse{out}); err != nil {
+		return AssetResponse{}, err
+	}
+	return *out, nil
+}
+
+// updateAssetWithAttributes 追加項目を含む更新。マスタのジャンルの定義で検証し、個体と値を同じトランザクションで書く
+func (s *Service) updateAssetWithAttributes(ctx context.Context, id uint64, in UpdateAssetRequest) (AssetResponse, error) {
+	cur, err := s.store.GetAssetByID(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return AssetResponse{}, ErrNotFound("asset not found")
+		}
+		return AssetResponse{}, err
+	}
+	master, err := s.store.GetMasterByID(ctx, cur.AssetMasterID)
+	if err != nil {
+		return AssetResponse{}, err
+	}
+	stored, err := s.store.StoredAssetAttributeIDs(ctx, id)
+	if err != nil {
+		return AssetResponse{}, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return AssetResponse{}, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	defs, err := s.store.ListAttributeDefinitionsTx(ctx, tx, master.GenreID)
+	if err != nil {
+		return AssetResponse{}, err
+	}
+	attrs, err := validateAttributes(defs, AttrScopeAsset, in.Attributes, stored, true)
+	if err != nil {
+		return AssetResponse{}, err
+	}
+	if err := s.store.UpdateAssetTx(ctx, tx, id, in); err != nil {
+		var me *mysql.MySQLError
+		if errors.As(err, &me) && me.Number == 1452 {
+			return AssetResponse{}, ErrInvalid("invalid foreign key (status_id etc)")
+		}
+		return AssetResponse{}, err
+	}
+	if err := s.store.SetAssetAttributesTx(ctx, tx, id, attrs); err != nil {
+		return AssetResponse{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return AssetResponse{}, err
+	}
+	committed = true
+
+	out, err := s.store.GetAssetByID(ctx, id)
+	if err != nil {
+		return AssetResponse{}, err
+	}
+	if err := s.fillAttributes(ctx, nil, []*AssetResponse{out}); err != nil {
+		return AssetResponse{}, err
+	}
 	return *out, nil
 }
 
@@ -219,32 +441,26 @@ func (s *Service) CreateAssetSet(ctx context.Context, req CreateAssetSetRequest)
 		}
 	}()
 
-	// 1) master 仮INSERT
-	tmpMng := "TMP-" + ulid.Make().String()
-	masterID, err := s.store.InsertMasterTmpTx(ctx, tx, req.Master, tmpMng)
+	// ---- validate attributes（定義は tx 内で共有ロック） ----
+	defs, err := s.store.ListAttributeDefinitionsTx(ctx, tx, req.Master.GenreID)
 	if err != nil {
-		var me *mysql.MySQLError
-		if errors.As(err, &me) {
-			if me.Number == 1452 {
-				return AssetSetResponse{}, ErrInvalid("invalid management_category_id or genre_id")
-			}
-			if me.Number == 1062 {
-				return AssetSetResponse{}, ErrConflict("management_number already exists")
-			}
-		}
 		return AssetSetResponse{}, err
 	}
-
-	// 2) master 確定番号に置換
-	if err := s.store.UpdateMngToFinalTx(ctx, tx, masterID, tmpMng, 5); err != nil {
-		var ae *APIError
-		if errors.As(err, &ae) && ae.Code == CodeConflict {
-			return AssetSetResponse{}, ErrConflict("conflict while finalizing management_number")
-		}
+	masterAttrs, err := validateAttributes(defs, AttrScopeMaster, req.Master.Attributes, nil, false)
+	if err != nil {
+		return AssetSetResponse{}, err
+	}
+	assetAttrs, err := validateAttributes(defs, AttrScopeAsset, req.Asset.Attributes, nil, false)
+	if err != nil {
+		return AssetSetResponse{}, err
+	}
+	// 1) master 採番 + INSERT
+	masterID, err := s.insertMasterTx(ctx, tx, req.Master)
+	if err != nil {
 		return AssetSetResponse{}, err
 	}
 
-	// 3) asset INSERT
+	// 2) asset INSERT
 	assetID, err := s.store.InsertAssetTx(ctx, tx, req.Asset, masterID)
 	if err != nil {
 		var me *mysql.MySQLError
@@ -259,6 +475,14 @@ func (s *Service) CreateAssetSet(ctx context.Context, req CreateAssetSetRequest)
 		return AssetSetResponse{}, err
 	}
 
+	// 3) 追加項目
+	if err := s.store.SetMasterAttributesTx(ctx, tx, masterID, masterAttrs); err != nil {
+		return AssetSetResponse{}, err
+	}
+	if err := s.store.SetAssetAttributesTx(ctx, tx, assetID, assetAttrs); err != nil {
+		return AssetSetResponse{}, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return AssetSetResponse{}, err
 	}
@@ -273,6 +497,9 @@ func (s *Service) CreateAssetSet(ctx context.Context, req CreateAssetSetRequest)
 	if err != nil {
 		return AssetSetResponse{}, err
 	}
+	if err := s.fillAttributes(ctx, []*AssetMasterResponse{m}, []*AssetResponse{a}); err != nil {
+		return AssetSetResponse{}, err
+	}
 
 	return AssetSetResponse{Master: *m, Asset: *a}, nil
 }
@@ -285,6 +512,9 @@ func (s *Service) GetAssetSet(ctx context.Context, managementNumber string) (Ass
 		}
 		return AssetSetResponse{}, err
 	}
+	if err := s.fillAttributes(ctx, []*AssetMasterResponse{&out.Master}, []*AssetResponse{&out.Asset}); err != nil {
+		return AssetSetResponse{}, err
+	}
 	return *out, nil
 }
 
@@ -321,6 +551,8 @@ func (s *Service) ImportAssetsCSV(ctx context.Context, r *csv.Reader, mode strin
 	validCats, _ := s.store.LoadManagementCategoryIDSet(ctx)
 	validGenres, _ := s.store.LoadGenreIDSet(ctx)
 	validStatus, _ := s.store.LoadStatusIDSet(ctx)
+	// ジャンルごとの追加項目の定義（行に出てきたジャンルだけ読む）
+	attrDefs := map[uint][]AttributeDefinitionResponse{}
 
 	rowNum := 1 // ヘッダを1行目として数えるならここから。データ行だけにしたいなら 0からでOK
 	for {
@@ -366,6 +598,26 @@ func (s *Service) ImportAssetsCSV(ctx context.Context, r *csv.Reader, mode strin
 			}
 		}
 
+		// 追加項目（attr.<key> 列）を scope で振り分けて検証
+		defs, ok := attrDefs[req.Master.GenreID]
+		if !ok {
+			if defs, err = s.store.ListAttributeDefinitions(ctx, req.Master.GenreID); err != nil {
+				return out, err
+			}
+			attrDefs[req.Master.GenreID] = defs
+		}
+		splitAttributesByScope(defs, &req)
+		if _, err := validateAttributes(defs, AttrScopeMaster, req.Master.Attributes, nil, false); err != nil {
+			msg := err.Error()
+			out.Results = append(out.Results, ImportRowResult{Row: rowNum, Ok: false, Error: &msg})
+			continue
+		}
+		if _, err := validateAttributes(defs, AttrScopeAsset, req.Asset.Attributes, nil, false); err != nil {
+			msg := err.Error()
+			out.Results = append(out.Results, ImportRowResult{Row: rowNum, Ok: false, Error: &msg})
+			continue
+		}
+
 		// dry_run: ここまででOKにする
 		if mode == "dry_run" {
 			out.Results = append(out.Results, ImportRowResult{Row: rowNum, Ok: true})
@@ -503,6 +755,21 @@ func parseAssetSetFromCSVRow(rec []string, col map[string]int) (CreateAssetSetRe
 		req.Asset.Notes = &notes
 	}
 
+	// ---- attributes ----
+	// attr.<key> 列はいったん master に集め、ジャンルの定義を見て呼び元で asset のものを振り分ける
+	for k := range col {
+		key, ok := strings.CutPrefix(k, csvAttributePrefix)
+		if !ok {
+			continue
+		}
+		if v := get(k); v != "" {
+			if req.Master.Attributes == nil {
+				req.Master.Attributes = map[string]any{}
+			}
+			req.Master.Attributes[key] = v
+		}
+	}
+
 	// 最低限の必須チェック（Ginのbinding相当）
 	if req.Master.Name == "" || req.Master.Manufacturer == "" || req.Master.ManagementCategoryID == 0 || req.Master.GenreID == 0 {
 		return req, ErrInvalid("master fields required")
@@ -520,6 +787,9 @@ func (s *Service) SearchAssets(ctx context.Context, q AssetSearchQuery) ([]Asset
 	if err != nil {
 		return nil, err
 	}
+	if err := s.fillSetAttributes(ctx, out); err != nil {
+		return nil, err
+	}
 	return out, nil
 }
 
@@ -8,6 +8,8 @@ import (
 	"log"
 	"strings"
 	"time"
+
+	"IRIS-backend/internal/asset_mgmt/inventory"
 )
 
 type Store struct{ db *sql.DB }
@@ -16,42 +18,8 @@ func NewStore(db *sql.DB) *Store { return &Store{db: db} }
 
 // ===== master =====
 
-// 1) 仮INSERT（created_at は DB時刻）
-func (s *Store) InsertMasterTmp(ctx context.Context, in CreateAssetMasterRequest, tmpMng string) (uint64, error) {
-	const q = `
-	INSERT INTO assets_master
-	(management_number, name, management_category_id, genre_id, manufacturer, model, created_at)
-	VALUES (?, ?, ?, ?, ?, ?, UTC_TIMESTAMP())`
-	res, err := s.db.ExecContext(ctx, q, tmpMng, in.Name, in.ManagementCategoryID, in.GenreID, in.Manufacturer, in.Model)
-	if err != nil {
-		return 0, err
-	}
-	id, err := res.LastInsertId()
-	if err != nil {
-		return 0, err
-	}
-	return uint64(id), nil
-}
+// 採番と INSERT は numbering_store.go（NextManagementNumberTx / InsertMasterTx）
 
-// 2) 確定番号に置換（DBの created_at / genres.genre_code 利用）
-func (s *Store) UpdateMngToFinal(ctx context.Context, id uint64, tmpMng string, pad int) error {
-	q := fmt.Sprintf(`
-	UPDATE assets_master m
-	JOIN asset_genres g ON g.genre_id = m.genre_id
-	SET m.management_number = CONCAT(g.genre_code, '-', DATE_FORMAT(m.created_at, '%%Y%%m%%d'), '-', LPAD(m.asset_master_id, %d, '0'))
-	WHERE m.asset_master_id = ? AND m.management_number = ?`, pad)
-
-	res, err := s.db.ExecContext(ctx, q, id, tmpMng)
-	if err != nil {
-		return err
-	}
-	if aff, _ := res.RowsAffected(); aff != 1 {
-		return ErrConflict("no row updated")
-	}
-	return nil
-}
-
-// 3) 取得
 func (s *Store) GetMasterByID(ctx context.Context, id uint64) (*AssetMasterResponse, error) {
 	const q = `
 	SELECT asset_master_id, management_number, name, management_category_id, genre_id, manufacturer, model, created_at
@@ -66,34 +34,56 @@ func (s *Store) GetMasterByID(ctx context.Context, id uint64) (*AssetMasterRespo
 	return &out, nil
 }
 
+// GetMasterByMng 管理番号（別名でも可）でマスタを引く
 func (s *Store) GetMasterByMng(ctx context.Context, mng string) (*AssetMasterResponse, error) {
-	const q = `
-	SELECT asset_master_id, management_number, name, management_category_id, genre_id, manufacturer, model, created_at
-	FROM assets_master WHERE management_number = ?`
-	var r AssetMasterResponse
-	if err := s.db.QueryRowContext(ctx, q, mng).Scan(
-		&r.AssetMasterID, &r.ManagementNumber, &r.Name, &r.ManagementCategoryID, &r.GenreID,
-		&r.Manufacturer, &r.Model, &r.CreatedAt,
-	); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, sql.ErrNoRows
-		}
+	id, err := s.GetMasterIDByMng(ctx, mng)
+	if err != nil {
 		return nil, err
 	}
-	return &r, nil
+	return s.GetMasterByID(ctx, id)
 }
 
 func (s *Store) GetMasterIDByMng(ctx context.Context, mng string) (uint64, error) {
-	const q = `SELECT asset_master_id FROM assets_master WHERE management_number = ?`
-	var id uint64
-	if err := s.db.QueryRowContext(ctx, q, mng).Scan(&id); err != nil {
+	id, _, err := inventory.ResolveManagementNumber(ctx, s.db, mng)
+	if err != nil {
 		return 0, err
 	}
 	return id, nil
 }
 
 func (s *Store) UpdateMasterByMng(ctx context.Context, mng string, in UpdateAssetMasterRequest) (*AssetMasterResponse, error) {
-	// 動的アップデート
+	id, err := s.GetMasterIDByMng(ctx, mng)
+	if err != nil {
+		return nil, err
+	}
+	sets, args := masterUpdateSets(in)
+	if len(sets) == 0 {
+		// 変更なしでも現行値を返す
+		return s.GetMasterByID(ctx, id)
+	}
+	args = append(args, id)
+	q := fmt.Sprintf(`UPDATE assets_master SET %s WHERE asset_master_id = ?`, strings.Join(sets, ", "))
+
+	if _, err := s.db.ExecContext(ctx, q, args...); err != nil {
+		return nil, err
+	}
+	return s.GetMasterByID(ctx, id)
+}
+
+// UpdateMasterTx 追加項目と同じトランザクションで更新するとき用（存在は呼び出し側で確認済み）
+func (s *Store) UpdateMasterTx(ctx context.Context, tx *sql.Tx, masterID uint64, in UpdateAssetMasterRequest) error {
+	sets, args := masterUpdateSets(in)
+	if len(sets) == 0 {
+		return nil
+	}
+	args = append(args, masterID)
+	q := fmt.Sprintf(`UPDATE assets_master SET %s WHERE asset_master_id = ?`, strings.Join(sets, ", "))
+	_, err := tx.ExecContext(ctx, q, args...)
+	return err
+}
+
+// masterUpdateSets 動的アップデートの SET 句
+func masterUpdateSets(in UpdateAssetMasterRequest) ([]string, []any) {
 	sets := []string{}
 	args := []any{}
 	if in.Name != nil {
@@ -116,21 +106,7 @@ func (s *Store) UpdateMasterByMng(ctx context.Context, mng string, in UpdateAsse
 		sets = append(sets, "model = ?")
 		args = append(args, *in.Model)
 	}
-	if len(sets) == 0 {
-		// 変更なしでも現行値を返す
-		return s.GetMasterByMng(ctx, mng)
-	}
-	args = append(args, mng)
-	q := fmt.Sprintf(`UPDATE assets_master SET %s WHERE management_number = ?`, strings.Join(sets, ", "))
-
-	res, err := s.db.ExecContext(ctx, q, args...)
-	if err != nil {
-		return nil, err
-	}
-	if aff, _ := res.RowsAffected(); aff == 0 {
-		return nil, sql.ErrNoRows
-	}
-	return s.GetMasterByMng(ctx, mng)
+	return sets, args
 }
 
 func (s *Store) ListMasters(ctx context.Context, p Page, q AssetSearchQuery) ([]AssetMasterResponse, int64, error) {
@@ -235,23 +211,14 @@ type assetRow struct {
 type sqlNullString struct{ sql.NullString }
 type sqlNullTime struct{ sql.NullTime }
 
+// CreateAssetTx 個体と追加項目を登録して管理番号を返す（コミットは呼び出し側）
 func (s *Store) CreateAssetTx(
 	ctx context.Context,
+	tx *sql.Tx,
 	in CreateAssetRequest,
 	masterID uint64,
+	attrs map[uint64]*attrValue,
 ) (assetID uint64, managementNumber string, err error) {
-
-	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
-	if err != nil {
-		return 0, "", err
-	}
-	// 失敗時は必ずRollback
-	defer func() {
-		if err != nil {
-			_ = tx.Rollback()
-		}
-	}()
-
 	const qIns = `
 		INSERT INTO assets
 			(asset_master_id, serial, quantity, purchased_at, status_id, owner, default_location,
@@ -280,13 +247,13 @@ func (s *Store) CreateAssetTx(
 	}
 	assetID = uint64(id64)
 
-	const qMgmt = `SELECT management_number FROM assets_master WHERE asset_master_id = ?`
-	if err = tx.QueryRowContext(ctx, qMgmt, masterID).Scan(&managementNumber); err != nil {
-		log.Printf("Failed to resolve management_number for masterID=%d: %v", masterID, err)
+	if err = s.SetAssetAttributesTx(ctx, tx, assetID, attrs); err != nil {
 		return 0, "", err
 	}
 
-	if err = tx.Commit(); err != nil {
+	const qMgmt = `SELECT management_number FROM assets_master WHERE asset_master_id = ?`
+	if err = tx.QueryRowContext(ctx, qMgmt, masterID).Scan(&managementNumber); err != nil {
+		log.Printf("Failed to resolve management_number for masterID=%d: %v", masterID, err)
 		return 0, "", err
 	}
 	return assetID, managementNumber, nil
@@ -332,6 +299,40 @@ func (s *Store) GetAssetByID(ctx context.Context, id uint64) (*AssetResponse, er
 }
 
 func (s *Store) UpdateAssetByID(ctx context.Context, id uint64, in UpdateAssetRequest) (*AssetResponse, error) {
+	sets, args := assetUpdateSets(in)
+	if len(sets) == 0 {
+		return s.GetAssetByID(ctx, id)
+	}
+
+	args = append(args, id)
+	q := fmt.Sprintf(`UPDATE assets SET %s WHERE asset_id = ?`, strings.Join(sets, ", "))
+	res, err := s.db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		// ほんとに存在しないのか、値が変わってないだけなのか確認する
+		if _, err := s.GetAssetByID(ctx, id); err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows // ガチで存在しない → 404
+		}
+	}
+
+	return s.GetAssetByID(ctx, id)
+}
+
+// UpdateAssetTx 追加項目と同じトランザクションで更新するとき用（存在は呼び出し側で確認済み）
+func (s *Store) UpdateAssetTx(ctx context.Context, tx *sql.Tx, id uint64, in UpdateAssetRequest) error {
+	sets, args := assetUpdateSets(in)
+	if len(sets) == 0 {
+		return nil
+	}
+	args = append(args, id)
+	q := fmt.Sprintf(`UPDATE assets SET %s WHERE asset_id = ?`, strings.Join(sets, ", "))
+	_, err := tx.ExecContext(ctx, q, args...)
+	return err
+}
+
+func assetUpdateSets(in UpdateAssetRequest) ([]string, []any) {
 	sets := []string{}
 	args := []any{}
 	if in.Serial != nil {
@@ -374,25 +375,7 @@ func (s *Store) UpdateAssetByID(ctx context.Context, id uint64, in UpdateAssetRe
 		sets = append(sets, "notes = ?")
 		args = append(args, *in.Notes)
 	}
-
-	if len(sets) == 0 {
-		return s.GetAssetByID(ctx, id)
-	}
-
-	args = append(args, id)
-	q := fmt.Sprintf(`UPDATE assets SET %s WHERE asset_id = ?`, strings.Join(sets, ", "))
-	res, err := s.db.ExecContext(ctx, q, args...)
-	if err != nil {
-		return nil, err
-	}
-	if aff, _ := res.RowsAffected(); aff == 0 {
-		// ほんとに存在しないのか、値が変わってないだけなのか確認する
-		if _, err := s.GetAssetByID(ctx, id); err == sql.ErrNoRows {
-			return nil, sql.ErrNoRows // ガチで存在しない → 404
-		}
-	}
-
-	return s.GetAssetByID(ctx, id)
+	return sets, args
 }
 
 func (s *Store) ListAssets(ctx context.Context, q AssetSearchQuery, p Page) ([]AssetResponse, int64, error) {
@@ -513,7 +496,13 @@ func (s *Store) ListAssets(ctx context.Context, q AssetSearchQuery, p Page) ([]A
 	return out, total, nil
 }
 
+// GetAssetSetByMng は管理番号か別名で引く。別名で引いた場合は ResolvedAlias に指定された番号を入れる
 func (s *Store) GetAssetSetByMng(ctx context.Context, mng string) (*AssetSetResponse, error) {
+	id, canonical, err := inventory.ResolveManagementNumber(ctx, s.db, mng)
+	if err != nil {
+		return nil, err
+	}
+
 	const q = `
 		SELECT
 			m.asset_master_id,
@@ -523,10 +512,10 @@ func (s *Store) GetAssetSetByMng(ctx context.Context, mng string) (*AssetSetResp
 		FROM assets_master AS m
 		JOIN assets AS a
 			ON a.asset_master_id = m.asset_master_id
-		WHERE m.management_number = ?;
+		WHERE m.asset_master_id = ?;
 	`
 
-	row := s.db.QueryRowContext(ctx, q, mng)
+	row := s.db.QueryRowContext(ctx, q, id)
 
 	// NULLになり得る列
 	var modelNS sql.NullString
@@ -554,7 +543,7 @@ func (s *Store) GetAssetSetByMng(ctx context.Context, mng string) (*AssetSetResp
 		defaultLocation    string
 	)
 
-	err := row.Scan(
+	err = row.Scan(
 		&masterID,
 		&managementNumber, &name, &managementCategory, &genreID, &manufacturer, &modelNS, &createdAt,
 		&assetID, &assetMasterID, &serialNS, &quantity, &purchasedAt, &statusID,
@@ -594,44 +583,40 @@ func (s *Store) GetAssetSetByMng(ctx context.Context, mng string) (*AssetSetResp
 			Notes:            ptrString(notesNS),
 		},
 	}
+	if canonical != mng {
+		r.ResolvedAlias = &mng
+	}
 
 	return r, nil
 }
 
-// ===== master and asset =====
-func (s *Store) InsertMasterTmpTx(ctx context.Context, tx *sql.Tx, in CreateAssetMasterRequest, tmpMng string) (uint64, error) {
+// FindManagementNumbersBySerial シリアルが完全一致する備品のマスタの管理番号（最大 limit 件）
+func (s *Store) FindManagementNumbersBySerial(ctx context.Context, serial string, limit int) ([]string, error) {
 	const q = `
-	INSERT INTO assets_master
-	(management_number, name, management_category_id, genre_id, manufacturer, model, created_at)
-	VALUES (?, ?, ?, ?, ?, ?, UTC_TIMESTAMP())`
-	res, err := tx.ExecContext(ctx, q, tmpMng, in.Name, in.ManagementCategoryID, in.GenreID, in.Manufacturer, in.Model)
-	if err != nil {
-		return 0, err
-	}
-	id, err := res.LastInsertId()
+	SELECT DISTINCT m.management_number
+	FROM assets a
+	JOIN assets_master m ON m.asset_master_id = a.asset_master_id
+	WHERE a.serial = ?
+	ORDER BY m.management_number
+	LIMIT ?`
+	rows, err := s.db.QueryContext(ctx, q, serial, limit)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	return uint64(id), nil
-}
-
-func (s *Store) UpdateMngToFinalTx(ctx context.Context, tx *sql.Tx, id uint64, tmpMng string, pad int) error {
-	q := fmt.Sprintf(`
-	UPDATE assets_master m
-	JOIN asset_genres g ON g.genre_id = m.genre_id
-	SET m.management_number = CONCAT(g.genre_code, '-', DATE_FORMAT(m.created_at, '%%Y%%m%%d'), '-', LPAD(m.asset_master_id, %d, '0'))
-	WHERE m.asset_master_id = ? AND m.management_number = ?`, pad)
+	defer rows.Close()
 
-	res, err := tx.ExecContext(ctx, q, id, tmpMng)
-	if err != nil {
-		return err
-	}
-	if aff, _ := res.RowsAffected(); aff != 1 {
-		return ErrConflict("no row updated")
+	var out []string
+	for rows.Next() {
+		var mng string
+		if err := rows.Scan(&mng); err != nil {
+			return nil, err
+		}
+		out = append(out, mng)
 	}
-	return nil
+	return out, rows.Err()
 }
 
+// ===== master and asset =====
 func (s *Store) InsertAssetTx(ctx context.Context, tx *sql.Tx, in CreateAssetRequest, masterID uint64) (uint64, error) {
 	const qIns = `
 	INSERT INTO assets
@@ -674,7 +659,7 @@ func (s *Store) InsertAssetTx(ctx context.Context, tx *sql.Tx, in CreateAssetReq
 
 // ===== batch import =====
 func (s *Store) LoadManagementCategoryIDSet(ctx context.Context) (map[uint]bool, error) {
-	const q = `SELECT management_category_id FROM asset_management_categories`
+	const q = `SELECT management_category_id FROM asset_management_categories WHERE is_disabled = FALSE`
 	rows, err := s.db.QueryContext(ctx, q)
 	if err != nil {
 		return map[uint]bool{}, err
@@ -712,7 +697,7 @@ func (s *Store) LoadGenreIDSet(ctx context.Context) (map[uint]bool, error) {
 }
 
 func (s *Store) LoadStatusIDSet(ctx context.Context) (map[uint]bool, error) {
-	const q = `SELECT status_id FROM asset_statuses`
+	const q = `SELECT status_id FROM asset_statuses WHERE is_disabled = FALSE`
 	rows, err := s.db.QueryContext(ctx, q)
 	if err != nil {
 		return map[uint]bool{}, err
@@ -818,6 +803,16 @@ func (s *Store) SearchAssets(ctx context.Context, q AssetSearchQuery) ([]AssetSe
 	return results, nil
 }
 
+// genreSubtreeCondition 指定ジャンルとその子孫ジャンル（asset_genres.parent_genre_id をたどる）に一致
+const genreSubtreeCondition = `m.genre_id IN (
+			WITH RECURSIVE subtree AS (
+				SELECT genre_id FROM asset_genres WHERE genre_id = ?
+				UNION ALL
+				SELECT c.genre_id FROM asset_genres AS c JOIN subtree ON c.parent_genre_id = subtree.genre_id
+			)
+			SELECT genre_id FROM subtree
+		)`
+
 func buildSearchAssetsQuery(q AssetSearchQuery) (string, []any) {
 	const baseSelect = `
 		SELECT
@@ -857,7 +852,10 @@ func buildSearchAssetsQuery(q AssetSearchQuery) (string, []any) {
 		where = append(where, "a.asset_master_id = ?")
 		args = append(args, *q.AssetMasterID)
 	}
-	if q.GenreID != nil {
+	if q.GenreID != nil && q.GenreDescendants {
+		where = append(where, genreSubtreeCondition)
+		args = append(args, *q.GenreID)
+	} else if q.GenreID != nil {
 		where = append(where, "m.genre_id = ?")
 		args = append(args, *q.GenreID)
 	}
@@ -945,8 +943,17 @@ func buildSearchAssetsQuery(q AssetSearchQuery) (string, []any) {
 		where = append(where, "COALESCE(a.notes, '') LIKE ? ESCAPE '\\'")
 		args = append(args, "%"+escapeLike(*q.Notes)+"%")
 	}
+	for _, f := range q.Attributes {
+		cond, condArgs := attributeFilterCondition(f)
+		where = append(where, cond)
+		args = append(args, condArgs...)
+	}
 
-	query := baseSelect + "\n\t\tWHERE " + strings.Join(where, "\n\t\t  AND ") + "\n\t\tORDER BY m.asset_master_id, a.asset_id;"
+	query := baseSelect + "\n\t\tWHERE " + strings.Join(where, "\n\t\t  AND ") + "\n\t\tORDER BY m.asset_master_id, a.asset_id"
+	if q.Limit > 0 {
+		query += "\n\t\tLIMIT ?"
+		args = append(args, q.Limit)
+	}
 	return query, args
 }
 
@@ -0,0 +1,134 @@
+package attachments
+
+// 添付ファイルの中身の扱い
+// - MIME はクライアントの申告ではなく先頭 512 バイトから判定し、許可した種類だけ受け付ける
+// - 画像は縮小版（JPEG）を作る。壊れた画像・巨大な画像は縮小版なしで保存する
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// ThumbnailSize 縮小版の長辺（px）
+	ThumbnailSize = 256
+	// 縮小版を作る画像の画素数の上限（展開するとメモリを食うため）
+	maxThumbnailSourcePixels = 40_000_000
+
+	maxFileNameLength = 255
+	sniffLen          = 512
+)
+
+// Office 文書（中身は zip）は拡張子で種類を決める
+var officeContentTypes = map[string]string{
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+}
+
+// 受け付ける MIME（判定結果の ; より前）
+var allowedContentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"text/plain":      true,
+	"text/csv":        true,
+}
+
+// detectContentType 先頭バイトとファイル名から MIME を決める。許可していない種類は ErrInvalid
+func detectContentType(head []byte, fileName string) (string, error) {
+	sniffed := http.DetectContentType(head)
+	mediaType := strings.TrimSpace(strings.SplitN(sniffed, ";", 2)[0])
+	ext := strings.ToLower(filepath.Ext(fileName))
+
+	switch {
+	case mediaType == "application/zip":
+		if ct, ok := officeContentTypes[ext]; ok {
+			return ct, nil
+		}
+	case mediaType == "text/plain" && ext == ".csv":
+		return "text/csv; charset=utf-8", nil
+	case allowedContentTypes[mediaType]:
+		return sniffed, nil
+	}
+	return "", ErrInvalid(fmt.Sprintf("unsupported file type: %s", mediaType))
+}
+
+func isImage(contentType string) bool {
+	return strings.HasPrefix(contentType, "image/")
+}
+
+// sanitizeFileName ダウンロード時に使う表示名。パス・制御文字を除き、長すぎれば切る
+func sanitizeFileName(name string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, strings.ToValidUTF8(name, ""))
+	name = strings.TrimSpace(name)
+	for len(name) > maxFileNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "" || name == "." || name == ".." {
+		return "file"
+	}
+	return name
+}
+
+// makeThumbnail 長辺 ThumbnailSize の JPEG を作る（小さい画像は拡大しない）
+func makeThumbnail(r io.ReadSeeker) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > maxThumbnailSourcePixels {
+		return nil, fmt.Errorf("image too large for thumbnail: %dx%d", cfg.Width, cfg.Height)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	w, h := cfg.Width, cfg.Height
+	if w > ThumbnailSize || h > ThumbnailSize {
+		if w >= h {
+			w, h = ThumbnailSize, max(1, h*ThumbnailSize/cfg.Width)
+		} else {
+			w, h = max(1, w*ThumbnailSize/cfg.Height), ThumbnailSize
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	// 透過部分は白にする（JPEG は透過を持てない）
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,41 @@
+package attachments
+
+import "time"
+
+// ===== Requests =====
+
+// UploadAttachmentRequest: POST /assets/masters/:management_number/attachments（multipart の file 以外の項目）
+type UploadAttachmentRequest struct {
+	Kind string `form:"kind"` // photo / invoice / manual / other（省略時は画像なら photo、それ以外は other）
+	Note string `form:"note"`
+}
+
+// ===== Responses =====
+
+type AttachmentResponse struct {
+	AttachmentID     uint64    `json:"attachment_id"`
+	ManagementNumber string    `json:"management_number" example:"PC-20260507-00042"`
+	Kind             string    `json:"kind" example:"invoice"`
+	FileName         string    `json:"file_name" example:"invoice-2026-04.pdf"`
+	ContentType      string    `json:"content_type" example:"application/pdf"` // 中身から判定した MIME
+	SizeBytes        int64     `json:"size_bytes"`
+	SHA256           string    `json:"sha256"`
+	HasThumbnail     bool      `json:"has_thumbnail"`
+	Note             string    `json:"note,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	DownloadURL      string    `json:"download_url"`
+	ThumbnailURL     string    `json:"thumbnail_url,omitempty"`
+}
+
+// ===== API Specific Responses =====
+
+// ErrorDetail defines the detail of an API error.
+type ErrorDetail struct {
+	Code    string `json:"code" example:"INVALID_ARGUMENT"`
+	Message string `json:"message" example:"invalid input"`
+}
+
+// ErrorResponse defines the standard error response format.
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
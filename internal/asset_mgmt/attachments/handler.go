@@ -0,0 +1,222 @@
+package attachments
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipart の file 以外（kind, note, 境界）に見込む余裕
+const multipartOverhead = 1 << 20
+
+type Handler struct {
+	svc *Service
+}
+
+func RegisterRoutes(r gin.IRoutes, svc *Service) {
+	h := &Handler{svc: svc}
+
+	r.GET("/assets/masters/:management_number/attachments", h.ListAttachments)
+	r.POST("/assets/masters/:management_number/attachments", h.UploadAttachment)
+	r.GET("/assets/masters/:management_number/attachments/:attachment_id", h.DownloadAttachment)
+	r.GET("/assets/masters/:management_number/attachments/:attachment_id/thumbnail", h.DownloadThumbnail)
+	r.DELETE("/assets/masters/:management_number/attachments/:attachment_id", h.DeleteAttachment)
+}
+
+// @Summary      List attachments of an asset master
+// @Description  Lists photos, invoices, manuals and other files attached to an asset master (newest first). Aliases of the management number are accepted.
+// @Tags         attachments
+// @Produce      json
+// @Param        management_number path string true "Management Number"
+// @Param        kind query string false "photo, invoice, manual or other"
+// @Success      200 {array} AttachmentResponse
+// @Failure      400 {object} ErrorResponse "Invalid kind"
+// @Failure      404 {object} ErrorResponse "Asset master not found"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/masters/{management_number}/attachments [get]
+func (h *Handler) ListAttachments(c *gin.Context) {
+	res, err := h.svc.ListAttachments(c.Request.Context(), c.Param("management_number"), c.Query("kind"))
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary      Upload an attachment
+// @Description  Uploads a file for an asset master. The type is detected from the content (JPEG, PNG, GIF, WebP, PDF, text/CSV, docx/xlsx/pptx);
+// @Description  other types and files over the size limit are rejected. Images get a JPEG thumbnail.
+// @Description  Identical content (same SHA-256) is stored once; uploading it again to the same asset returns the existing attachment with 200.
+// @Tags         attachments
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        management_number path string true "Management Number"
+// @Param        file formData file true "File"
+// @Param        kind formData string false "photo, invoice, manual or other (default: photo for images, otherwise other)"
+// @Param        note formData string false "Note"
+// @Success      201 {object} AttachmentResponse
+// @Success      200 {object} AttachmentResponse "Same content was already attached"
+// @Failure      400 {object} ErrorResponse "Invalid input, unsupported type or too large"
+// @Failure      404 {object} ErrorResponse "Asset master not found"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/masters/{management_number}/attachments [post]
+func (h *Handler) UploadAttachment(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.svc.MaxBytes()+multipartOverhead)
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "file exceeds "+strconv.FormatInt(h.svc.MaxBytes(), 10)+" bytes"))
+			return
+		}
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "file is required"))
+		return
+	}
+	var req UploadAttachmentRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid form"))
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "failed to read file"))
+		return
+	}
+	defer f.Close()
+
+	res, created, err := h.svc.UploadAttachment(c.Request.Context(), c.Param("management_number"), fh.Filename, req, f)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.Header("Location", res.DownloadURL)
+	if !created {
+		c.JSON(http.StatusOK, res)
+		return
+	}
+	c.JSON(http.StatusCreated, res)
+}
+
+// @Summary      Download an attachment
+// @Description  Returns the file with its detected Content-Type. Images and PDFs are served inline, other files as downloads.
+// @Tags         attachments
+// @Produce      octet-stream
+// @Param        management_number path string true "Management Number"
+// @Param        attachment_id path int true "Attachment ID"
+// @Success      200 {file} file
+// @Success      304 "Not Modified (If-None-Match matched the ETag)"
+// @Failure      400 {object} ErrorResponse "Invalid attachment_id"
+// @Failure      404 {object} ErrorResponse "Attachment not found"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/masters/{management_number}/attachments/{attachment_id} [get]
+func (h *Handler) DownloadAttachment(c *gin.Context) {
+	h.download(c, false)
+}
+
+// @Summary      Download an attachment thumbnail
+// @Description  Returns the JPEG thumbnail (longest side 256px) of an image attachment.
+// @Tags         attachments
+// @Produce      jpeg
+// @Param        management_number path string true "Management Number"
+// @Param        attachment_id path int true "Attachment ID"
+// @Success      200 {file} file
+// @Failure      400 {object} ErrorResponse "Invalid attachment_id"
+// @Failure      404 {object} ErrorResponse "Attachment or thumbnail not found"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/masters/{management_number}/attachments/{attachment_id}/thumbnail [get]
+func (h *Handler) DownloadThumbnail(c *gin.Context) {
+	h.download(c, true)
+}
+
+func (h *Handler) download(c *gin.Context, thumbnail bool) {
+	id, err := strconv.ParseUint(c.Param("attachment_id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid attachment_id"))
+		return
+	}
+	a, rc, err := h.svc.OpenAttachment(c.Request.Context(), c.Param("management_number"), id, thumbnail)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	defer rc.Close()
+
+	// 中身は sha256 で決まるので ETag にそのまま使える
+	etag := `"` + a.SHA256 + `"`
+	if thumbnail {
+		etag = `"` + a.SHA256 + `-thumb"`
+	}
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, max-age=86400")
+	c.Header("X-Content-Type-Options", "nosniff")
+	if match := c.GetHeader("If-None-Match"); match != "" && strings.Contains(match, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	if thumbnail {
+		c.DataFromReader(http.StatusOK, -1, "image/jpeg", rc, map[string]string{
+			"Content-Disposition": mime.FormatMediaType("inline", map[string]string{"filename": "thumbnail.jpg"}),
+		})
+		return
+	}
+	disposition := "attachment"
+	if isImage(a.ContentType) || strings.HasPrefix(a.ContentType, "application/pdf") {
+		disposition = "inline"
+	}
+	c.DataFromReader(http.StatusOK, a.SizeBytes, a.ContentType, rc, map[string]string{
+		"Content-Disposition": mime.FormatMediaType(disposition, map[string]string{"filename": a.FileName}),
+	})
+}
+
+// @Summary      Delete an attachment
+// @Description  Deletes an attachment. The stored file is removed when no other asset references the same content.
+// @Tags         attachments
+// @Param        management_number path string true "Management Number"
+// @Param        attachment_id path int true "Attachment ID"
+// @Success      204 "No Content"
+// @Failure      400 {object} ErrorResponse "Invalid attachment_id"
+// @Failure      404 {object} ErrorResponse "Attachment not found"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/masters/{management_number}/attachments/{attachment_id} [delete]
+func (h *Handler) DeleteAttachment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("attachment_id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid attachment_id"))
+		return
+	}
+	if err := h.svc.DeleteAttachment(c.Request.Context(), c.Param("management_number"), id); err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ===== helpers =====
+
+type errDTO struct {
+	Error struct {
+		Code    Code   `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func apiErr(code Code, msg string) errDTO {
+	var e errDTO
+	e.Error.Code = code
+	e.Error.Message = msg
+	return e
+}
+
+func apiErrFrom(err error) errDTO {
+	var api *APIError
+	if errors.As(err, &api) {
+		return apiErr(api.Code, api.Message)
+	}
+	return apiErr(CodeInternal, err.Error())
+}
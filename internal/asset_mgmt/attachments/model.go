@@ -0,0 +1,19 @@
+package attachments
+
+// createAttachmentInput: asset_attachments の 1 行 + 実体（attachment_blobs）の情報
+type createAttachmentInput struct {
+	AssetMasterID uint64
+	Kind          string
+	FileName      string
+	Note          string
+	SHA256        string
+	ContentType   string
+	SizeBytes     int64
+	HasThumbnail  bool
+}
+
+// blobRef: 実体を消すときに必要な情報
+type blobRef struct {
+	SHA256       string
+	HasThumbnail bool
+}
@@ -0,0 +1,289 @@
+package attachments
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	KindPhoto   = "photo"
+	KindInvoice = "invoice"
+	KindManual  = "manual"
+	KindOther   = "other"
+
+	// DefaultMaxBytes 1 ファイルの上限の既定値
+	DefaultMaxBytes int64 = 20 << 20
+	maxNoteLength         = 500
+)
+
+var validKinds = map[string]bool{KindPhoto: true, KindInvoice: true, KindManual: true, KindOther: true}
+
+type attachmentStore interface {
+	ResolveManagementNumber(ctx context.Context, managementNumber string) (uint64, string, error)
+	ListAttachments(ctx context.Context, assetMasterID uint64, kind string) ([]AttachmentResponse, error)
+	GetAttachment(ctx context.Context, assetMasterID, attachmentID uint64) (*AttachmentResponse, error)
+	CreateAttachment(ctx context.Context, in createAttachmentInput, putBlob func(ctx context.Context) error) (*AttachmentResponse, bool, error)
+	DeleteAttachment(ctx context.Context, assetMasterID, attachmentID uint64) (blobRef, bool, error)
+	DeleteOrphanBlob(ctx context.Context, sha256 string, deleteBlob func(ctx context.Context, ref blobRef) error) error
+}
+
+// Config 添付ファイルの設定（config.yaml の attachments）
+type Config struct {
+	MaxBytes int64   // 1 ファイルの上限（0 なら DefaultMaxBytes）
+	TempDir  string  // 受信中のファイルを置く場所（空なら OS の既定）
+	Storage  Storage // 実体の保存先
+}
+
+type Service struct {
+	store    attachmentStore
+	storage  Storage
+	maxBytes int64
+	tempDir  string
+}
+
+func NewService(db *sql.DB, cfg Config) *Service {
+	return newServiceWithStore(NewStore(db), cfg)
+}
+
+func newServiceWithStore(store attachmentStore, cfg Config) *Service {
+	maxBytes := cfg.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	return &Service{store: store, storage: cfg.Storage, maxBytes: maxBytes, tempDir: cfg.TempDir}
+}
+
+// MaxBytes ハンドラがリクエスト本文を制限するのに使う
+func (s *Service) MaxBytes() int64 { return s.maxBytes }
+
+// 保存先のキー（中身の sha256 で決まるので同じ中身は 1 つだけ保存される）
+func objectKey(sum string) string    { return "objects/" + sum[:2] + "/" + sum }
+func thumbnailKey(sum string) string { return "thumbnails/" + sum[:2] + "/" + sum + ".jpg" }
+
+func (s *Service) resolveMaster(ctx context.Context, managementNumber string) (uint64, string, error) {
+	mng := strings.TrimSpace(managementNumber)
+	if mng == "" {
+		return 0, "", ErrInvalid("management_number is required")
+	}
+	id, canonical, err := s.store.ResolveManagementNumber(ctx, mng)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, "", ErrNotFound("asset master not found")
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	return id, canonical, nil
+}
+
+func withURLs(a AttachmentResponse) AttachmentResponse {
+	base := "/assets/masters/" + url.PathEscape(a.ManagementNumber) + "/attachments/" + strconv.FormatUint(a.AttachmentID, 10)
+	a.DownloadURL = base
+	a.ThumbnailURL = ""
+	if a.HasThumbnail {
+		a.ThumbnailURL = base + "/thumbnail"
+	}
+	return a
+}
+
+func normalizeKind(kind, contentType string) (string, error) {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if kind == "" {
+		if isImage(contentType) {
+			return KindPhoto, nil
+		}
+		return KindOther, nil
+	}
+	if !validKinds[kind] {
+		return "", ErrInvalid("kind must be photo, invoice, manual or other")
+	}
+	return kind, nil
+}
+
+func (s *Service) ListAttachments(ctx context.Context, managementNumber, kind string) ([]AttachmentResponse, error) {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if kind != "" && !validKinds[kind] {
+		return nil, ErrInvalid("kind must be photo, invoice, manual or other")
+	}
+	masterID, _, err := s.resolveMaster(ctx, managementNumber)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.store.ListAttachments(ctx, masterID, kind)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i] = withURLs(items[i])
+	}
+	return items, nil
+}
+
+// UploadAttachment は受信したファイルを一時ファイルに書きながら sha256 を取り、中身から MIME を判定して保存する。
+// 同じ備品に同じ中身が既にあれば既存の添付を返す（created=false）
+func (s *Service) UploadAttachment(ctx context.Context, managementNumber, fileName string, meta UploadAttachmentRequest, file io.Reader) (AttachmentResponse, bool, error) {
+	if s.storage == nil {
+		return AttachmentResponse{}, false, ErrInternal("attachment storage is not configured")
+	}
+	note := strings.TrimSpace(meta.Note)
+	if len([]rune(note)) > maxNoteLength {
+		return AttachmentResponse{}, false, ErrInvalid(fmt.Sprintf("note must be at most %d characters", maxNoteLength))
+	}
+	masterID, _, err := s.resolveMaster(ctx, managementNumber)
+	if err != nil {
+		return AttachmentResponse{}, false, err
+	}
+
+	tmp, err := os.CreateTemp(s.tempDir, "attachment-*")
+	if err != nil {
+		return AttachmentResponse{}, false, ErrInternal(err.Error())
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), io.LimitReader(file, s.maxBytes+1))
+	if err != nil {
+		return AttachmentResponse{}, false, ErrInternal(err.Error())
+	}
+	if n == 0 {
+		return AttachmentResponse{}, false, ErrInvalid("file is empty")
+	}
+	if n > s.maxBytes {
+		return AttachmentResponse{}, false, ErrInvalid(fmt.Sprintf("file exceeds %d bytes", s.maxBytes))
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+
+	head := make([]byte, sniffLen)
+	m, err := tmp.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return AttachmentResponse{}, false, ErrInternal(err.Error())
+	}
+	name := sanitizeFileName(fileName)
+	contentType, err := detectContentType(head[:m], name)
+	if err != nil {
+		return AttachmentResponse{}, false, err
+	}
+	kind, err := normalizeKind(meta.Kind, contentType)
+	if err != nil {
+		return AttachmentResponse{}, false, err
+	}
+
+	var thumb []byte
+	if isImage(contentType) {
+		if thumb, err = makeThumbnail(io.NewSectionReader(tmp, 0, n)); err != nil {
+			log.Printf("[WARN] attachment thumbnail(%s): %v", sum, err)
+			thumb = nil
+		}
+	}
+
+	in := createAttachmentInput{
+		AssetMasterID: masterID,
+		Kind:          kind,
+		FileName:      name,
+		Note:          note,
+		SHA256:        sum,
+		ContentType:   contentType,
+		SizeBytes:     n,
+		HasThumbnail:  thumb != nil,
+	}
+	out, created, err := s.store.CreateAttachment(ctx, in, func(ctx context.Context) error {
+		if err := s.storage.Put(ctx, objectKey(sum), io.NewSectionReader(tmp, 0, n), n, contentType); err != nil {
+			return err
+		}
+		if thumb != nil {
+			return s.storage.Put(ctx, thumbnailKey(sum), bytes.NewReader(thumb), int64(len(thumb)), "image/jpeg")
+		}
+		return nil
+	})
+	if err != nil {
+		return AttachmentResponse{}, false, err
+	}
+	return withURLs(*out), created, nil
+}
+
+// OpenAttachment は添付の情報と中身を返す（thumbnail なら縮小版）。呼び出し側で Close する
+func (s *Service) OpenAttachment(ctx context.Context, managementNumber string, attachmentID uint64, thumbnail bool) (AttachmentResponse, io.ReadCloser, error) {
+	if s.storage == nil {
+		return AttachmentResponse{}, nil, ErrInternal("attachment storage is not configured")
+	}
+	a, err := s.getAttachment(ctx, managementNumber, attachmentID)
+	if err != nil {
+		return AttachmentResponse{}, nil, err
+	}
+	key := objectKey(a.SHA256)
+	if thumbnail {
+		if !a.HasThumbnail {
+			return AttachmentResponse{}, nil, ErrNotFound("attachment has no thumbnail")
+		}
+		key = thumbnailKey(a.SHA256)
+	}
+	rc, err := s.storage.Get(ctx, key)
+	if errors.Is(err, ErrObjectNotFound) {
+		log.Printf("[ERROR] attachment %d: object %s is missing from storage", a.AttachmentID, key)
+		return AttachmentResponse{}, nil, ErrInternal("attachment file is missing from storage")
+	}
+	if err != nil {
+		return AttachmentResponse{}, nil, err
+	}
+	return a, rc, nil
+}
+
+func (s *Service) getAttachment(ctx context.Context, managementNumber string, attachmentID uint64) (AttachmentResponse, error) {
+	masterID, _, err := s.resolveMaster(ctx, managementNumber)
+	if err != nil {
+		return AttachmentResponse{}, err
+	}
+	a, err := s.store.GetAttachment(ctx, masterID, attachmentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return AttachmentResponse{}, ErrNotFound("attachment not found")
+	}
+	if err != nil {
+		return AttachmentResponse{}, err
+	}
+	return withURLs(*a), nil
+}
+
+// DeleteAttachment 他の備品が同じ中身を参照していなければ実体と縮小版も消す
+func (s *Service) DeleteAttachment(ctx context.Context, managementNumber string, attachmentID uint64) error {
+	if s.storage == nil {
+		return ErrInternal("attachment storage is not configured")
+	}
+	masterID, _, err := s.resolveMaster(ctx, managementNumber)
+	if err != nil {
+		return err
+	}
+	ref, orphaned, err := s.store.DeleteAttachment(ctx, masterID, attachmentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound("attachment not found")
+	}
+	if err != nil || !orphaned {
+		return err
+	}
+
+	// 添付はコミット済みなので、実体を消せなくても削除は成功として返す
+	err = s.store.DeleteOrphanBlob(ctx, ref.SHA256, func(ctx context.Context, ref blobRef) error {
+		if ref.HasThumbnail {
+			if err := s.storage.Delete(ctx, thumbnailKey(ref.SHA256)); err != nil {
+				return err
+			}
+		}
+		return s.storage.Delete(ctx, objectKey(ref.SHA256))
+	})
+	if err != nil {
+		log.Printf("[WARN] attachment blob %s: %v", ref.SHA256, err)
+	}
+	return nil
+}
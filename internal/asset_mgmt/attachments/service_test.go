@@ -0,0 +1,335 @@
+package attachments
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakeAttachmentStore はメモリ上で asset_attachments / attachment_blobs を再現する
+type fakeAttachmentStore struct {
+	masters     map[string]uint64
+	attachments map[uint64]AttachmentResponse
+	masterOf    map[uint64]uint64
+	blobs       map[string]int // sha256 → ref_count（参照が無くなっても行は DeleteOrphanBlob まで残る）
+	thumbs      map[string]bool
+	nextID      uint64
+}
+
+func newFakeAttachmentStore() *fakeAttachmentStore {
+	return &fakeAttachmentStore{
+		masters:     map[string]uint64{"PC-1": 1, "PC-2": 2},
+		attachments: map[uint64]AttachmentResponse{},
+		masterOf:    map[uint64]uint64{},
+		blobs:       map[string]int{},
+		thumbs:      map[string]bool{},
+	}
+}
+
+func (f *fakeAttachmentStore) ResolveManagementNumber(_ context.Context, mng string) (uint64, string, error) {
+	id, ok := f.masters[mng]
+	if !ok {
+		return 0, "", sql.ErrNoRows
+	}
+	return id, mng, nil
+}
+
+func (f *fakeAttachmentStore) ListAttachments(_ context.Context, masterID uint64, kind string) ([]AttachmentResponse, error) {
+	var out []AttachmentResponse
+	for id, a := range f.attachments {
+		if f.masterOf[id] == masterID && (kind == "" || a.Kind == kind) {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeAttachmentStore) GetAttachment(_ context.Context, masterID, id uint64) (*AttachmentResponse, error) {
+	a, ok := f.attachments[id]
+	if !ok || f.masterOf[id] != masterID {
+		return nil, sql.ErrNoRows
+	}
+	return &a, nil
+}
+
+func (f *fakeAttachmentStore) CreateAttachment(ctx context.Context, in createAttachmentInput, putBlob func(context.Context) error) (*AttachmentResponse, bool, error) {
+	for id, a := range f.attachments {
+		if f.masterOf[id] == in.AssetMasterID && a.SHA256 == in.SHA256 {
+			return &a, false, nil
+		}
+	}
+	if f.blobs[in.SHA256] == 0 {
+		if err := putBlob(ctx); err != nil {
+			return nil, false, err
+		}
+	}
+	f.blobs[in.SHA256]++
+	f.thumbs[in.SHA256] = in.HasThumbnail
+	f.nextID++
+	var mng string
+	for m, id := range f.masters {
+		if id == in.AssetMasterID {
+			mng = m
+		}
+	}
+	a := AttachmentResponse{
+		AttachmentID: f.nextID, ManagementNumber: mng, Kind: in.Kind,
+		FileName: in.FileName, ContentType: in.ContentType, SizeBytes: in.SizeBytes, SHA256: in.SHA256,
+		HasThumbnail: in.HasThumbnail, Note: in.Note,
+	}
+	f.attachments[a.AttachmentID] = a
+	f.masterOf[a.AttachmentID] = in.AssetMasterID
+	return &a, true, nil
+}
+
+func (f *fakeAttachmentStore) DeleteAttachment(_ context.Context, masterID, id uint64) (blobRef, bool, error) {
+	a, ok := f.attachments[id]
+	if !ok || f.masterOf[id] != masterID {
+		return blobRef{}, false, sql.ErrNoRows
+	}
+	delete(f.attachments, id)
+	f.blobs[a.SHA256]--
+	return blobRef{SHA256: a.SHA256}, f.blobs[a.SHA256] == 0, nil
+}
+
+func (f *fakeAttachmentStore) DeleteOrphanBlob(ctx context.Context, sha256 string, deleteBlob func(context.Context, blobRef) error) error {
+	refs, ok := f.blobs[sha256]
+	if !ok || refs > 0 {
+		return nil
+	}
+	if err := deleteBlob(ctx, blobRef{SHA256: sha256, HasThumbnail: f.thumbs[sha256]}); err != nil {
+		return err
+	}
+	delete(f.blobs, sha256)
+	delete(f.thumbs, sha256)
+	return nil
+}
+
+// failingDeleteStorage は Delete だけ失敗する保存先
+type failingDeleteStorage struct{ Storage }
+
+func (failingDeleteStorage) Delete(context.Context, string) error {
+	return errors.New("storage unavailable")
+}
+
+func assertCode(t *testing.T, err error, want Code) {
+	t.Helper()
+	var api *APIError
+	if !errors.As(err, &api) || api.Code != want {
+		t.Fatalf("expected %s, got %v", want, err)
+	}
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newTestService(t *testing.T, maxBytes int64) (*Service, *fakeAttachmentStore, Storage) {
+	t.Helper()
+	store := newFakeAttachmentStore()
+	storage := NewLocalStorage(t.TempDir())
+	return newServiceWithStore(store, Config{MaxBytes: maxBytes, TempDir: t.TempDir(), Storage: storage}), store, storage
+}
+
+func readObject(t *testing.T, s Storage, key string) ([]byte, error) {
+	t.Helper()
+	rc, err := s.Get(context.Background(), key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func TestUploadImageSniffsTypeAndMakesThumbnail(t *testing.T) {
+	svc, _, storage := newTestService(t, 0)
+	data := testPNG(t, 600, 300)
+
+	// 拡張子が .jpg でも中身から image/png と判定する
+	res, created, err := svc.UploadAttachment(context.Background(), "PC-1", "C:\\photos\\front.jpg", UploadAttachmentRequest{}, bytes.NewReader(data))
+	if err != nil || !created {
+		t.Fatalf("UploadAttachment returned %v (created=%v)", err, created)
+	}
+	if res.ContentType != "image/png" || res.Kind != KindPhoto || res.FileName != "front.jpg" || res.SizeBytes != int64(len(data)) {
+		t.Fatalf("unexpected attachment %#v", res)
+	}
+	if !res.HasThumbnail || res.ThumbnailURL != "/assets/masters/PC-1/attachments/1/thumbnail" {
+		t.Fatalf("expected thumbnail, got %#v", res)
+	}
+
+	stored, err := readObject(t, storage, objectKey(res.SHA256))
+	if err != nil || !bytes.Equal(stored, data) {
+		t.Fatalf("expected original stored, got %d bytes, %v", len(stored), err)
+	}
+	thumb, err := readObject(t, storage, thumbnailKey(res.SHA256))
+	if err != nil {
+		t.Fatalf("thumbnail not stored: %v", err)
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(thumb))
+	if err != nil || cfg.Width != ThumbnailSize || cfg.Height != ThumbnailSize/2 {
+		t.Fatalf("unexpected thumbnail %+v, %v", cfg, err)
+	}
+}
+
+func TestUploadRejectsUnsupportedEmptyAndOversizedFiles(t *testing.T) {
+	svc, _, _ := newTestService(t, 1024)
+	ctx := context.Background()
+
+	_, _, err := svc.UploadAttachment(ctx, "PC-1", "setup.pdf", UploadAttachmentRequest{}, bytes.NewReader([]byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff")))
+	assertCode(t, err, CodeInvalidArgument)
+
+	_, _, err = svc.UploadAttachment(ctx, "PC-1", "page.html", UploadAttachmentRequest{}, strings.NewReader("<html><script>alert(1)</script></html>"))
+	assertCode(t, err, CodeInvalidArgument)
+
+	_, _, err = svc.UploadAttachment(ctx, "PC-1", "empty.txt", UploadAttachmentRequest{}, strings.NewReader(""))
+	assertCode(t, err, CodeInvalidArgument)
+
+	_, _, err = svc.UploadAttachment(ctx, "PC-1", "big.txt", UploadAttachmentRequest{}, strings.NewReader(strings.Repeat("a", 1025)))
+	assertCode(t, err, CodeInvalidArgument)
+
+	_, _, err = svc.UploadAttachment(ctx, "PC-1", "a.txt", UploadAttachmentRequest{Kind: "receipt"}, strings.NewReader("hello"))
+	assertCode(t, err, CodeInvalidArgument)
+
+	_, _, err = svc.UploadAttachment(ctx, "NOPE", "a.txt", UploadAttachmentRequest{}, strings.NewReader("hello"))
+	assertCode(t, err, CodeNotFound)
+}
+
+func TestUploadDeduplicatesByChecksum(t *testing.T) {
+	svc, store, storage := newTestService(t, 0)
+	ctx := context.Background()
+	pdf := []byte("%PDF-1.7\n1 0 obj\n<<>>\nendobj\n%%EOF\n")
+
+	first, created, err := svc.UploadAttachment(ctx, "PC-1", "invoice.pdf", UploadAttachmentRequest{Kind: "Invoice"}, bytes.NewReader(pdf))
+	if err != nil || !created || first.Kind != KindInvoice || first.ContentType != "application/pdf" || first.HasThumbnail {
+		t.Fatalf("unexpected first upload %#v, %v", first, err)
+	}
+
+	// 同じ備品に同じ中身 → 既存を返す
+	again, created, err := svc.UploadAttachment(ctx, "PC-1", "invoice-copy.pdf", UploadAttachmentRequest{}, bytes.NewReader(pdf))
+	if err != nil || created || again.AttachmentID != first.AttachmentID {
+		t.Fatalf("expected existing attachment, got %#v (created=%v), %v", again, created, err)
+	}
+
+	// 別の備品なら添付は増えるが実体は 1 つ
+	other, created, err := svc.UploadAttachment(ctx, "PC-2", "invoice.pdf", UploadAttachmentRequest{}, bytes.NewReader(pdf))
+	if err != nil || !created || other.SHA256 != first.SHA256 || store.blobs[first.SHA256] != 2 {
+		t.Fatalf("expected shared blob, got %#v, refs=%d, %v", other, store.blobs[first.SHA256], err)
+	}
+
+	// 1 つ消しても実体は残り、最後の参照を消すと実体も消える
+	if err := svc.DeleteAttachment(ctx, "PC-1", first.AttachmentID); err != nil {
+		t.Fatalf("DeleteAttachment returned %v", err)
+	}
+	if _, err := readObject(t, storage, objectKey(first.SHA256)); err != nil {
+		t.Fatalf("expected blob kept while referenced, got %v", err)
+	}
+	if err := svc.DeleteAttachment(ctx, "PC-2", other.AttachmentID); err != nil {
+		t.Fatalf("DeleteAttachment returned %v", err)
+	}
+	if _, err := readObject(t, storage, objectKey(first.SHA256)); !errors.Is(err, ErrObjectNotFound) {
+		t.Fatalf("expected blob removed, got %v", err)
+	}
+	assertCode(t, svc.DeleteAttachment(ctx, "PC-2", other.AttachmentID), CodeNotFound)
+}
+
+func TestDeleteAttachmentKeepsOrphanBlobWhenStorageDeleteFails(t *testing.T) {
+	store := newFakeAttachmentStore()
+	storage := NewLocalStorage(t.TempDir())
+	svc := newServiceWithStore(store, Config{TempDir: t.TempDir(), Storage: failingDeleteStorage{storage}})
+	ctx := context.Background()
+
+	res, _, err := svc.UploadAttachment(ctx, "PC-1", "notes.txt", UploadAttachmentRequest{}, strings.NewReader("manual notes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 添付はコミット済みなので、実体を消せなくても削除は成功する
+	if err := svc.DeleteAttachment(ctx, "PC-1", res.AttachmentID); err != nil {
+		t.Fatalf("DeleteAttachment returned %v", err)
+	}
+	if refs, ok := store.blobs[res.SHA256]; !ok || refs != 0 {
+		t.Fatalf("expected an unreferenced blob row kept for a later cleanup, got %d (present=%v)", refs, ok)
+	}
+	if _, err := readObject(t, storage, objectKey(res.SHA256)); err != nil {
+		t.Fatalf("expected the object left in storage, got %v", err)
+	}
+
+	// 同じ中身をもう一度登録すると、残った行を使って実体を置き直す
+	again, created, err := svc.UploadAttachment(ctx, "PC-2", "notes.txt", UploadAttachmentRequest{}, strings.NewReader("manual notes"))
+	if err != nil || !created || store.blobs[again.SHA256] != 1 {
+		t.Fatalf("unexpected re-upload %#v (created=%v), refs=%d, %v", again, created, store.blobs[again.SHA256], err)
+	}
+}
+
+func TestOpenAttachmentChecksOwnerAndThumbnail(t *testing.T) {
+	svc, _, _ := newTestService(t, 0)
+	ctx := context.Background()
+	res, _, err := svc.UploadAttachment(ctx, "PC-1", "notes.txt", UploadAttachmentRequest{}, strings.NewReader("manual notes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = svc.OpenAttachment(ctx, "PC-2", res.AttachmentID, false)
+	assertCode(t, err, CodeNotFound)
+	_, _, err = svc.OpenAttachment(ctx, "PC-1", res.AttachmentID, true)
+	assertCode(t, err, CodeNotFound)
+
+	a, rc, err := svc.OpenAttachment(ctx, "PC-1", res.AttachmentID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	body, _ := io.ReadAll(rc)
+	if string(body) != "manual notes" || a.ContentType != "text/plain; charset=utf-8" || a.Kind != KindOther {
+		t.Fatalf("unexpected attachment %#v / %q", a, body)
+	}
+}
+
+func TestDetectContentTypeOfficeAndCSV(t *testing.T) {
+	zipHead := []byte("PK\x03\x04\x14\x00\x06\x00")
+	if ct, err := detectContentType(zipHead, "spec.xlsx"); err != nil || !strings.Contains(ct, "spreadsheetml") {
+		t.Fatalf("expected xlsx, got %q, %v", ct, err)
+	}
+	if _, err := detectContentType(zipHead, "archive.zip"); err == nil {
+		t.Fatal("expected plain zip rejected")
+	}
+	if ct, err := detectContentType([]byte("a,b\n1,2\n"), "list.CSV"); err != nil || ct != "text/csv; charset=utf-8" {
+		t.Fatalf("expected csv, got %q, %v", ct, err)
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	cases := map[string]string{
+		"../../etc/passwd":      "passwd",
+		"C:\\Users\\a\\請求書.pdf": "請求書.pdf",
+		"a\"b\r\n.txt":          "ab.txt",
+		"..":                    "file",
+		"":                      "file",
+	}
+	for in, want := range cases {
+		if got := sanitizeFileName(in); got != want {
+			t.Fatalf("sanitizeFileName(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := sanitizeFileName(strings.Repeat("あ", 200)); len(got) > maxFileNameLength {
+		t.Fatalf("expected truncated name, got %d bytes", len(got))
+	}
+}
@@ -0,0 +1,130 @@
+package attachments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+// ErrObjectNotFound 保存先にオブジェクトが無い
+var ErrObjectNotFound = errors.New("object not found")
+
+// Storage 添付ファイルの実体の保存先。key は "objects/ab/<sha256>" のような / 区切りの相対パス
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// StorageConfig config.yaml の attachments から組み立てる
+type StorageConfig struct {
+	Driver string // local（既定） / s3
+	Dir    string // local の保存先（空なら添付は無効）
+	S3     S3Config
+}
+
+// NewStorage は設定に応じた保存先を返す。local で Dir が空なら nil（添付は無効）
+func NewStorage(cfg StorageConfig) (Storage, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Driver)) {
+	case "", StorageLocal:
+		if strings.TrimSpace(cfg.Dir) == "" {
+			return nil, nil
+		}
+		return NewLocalStorage(cfg.Dir), nil
+	case StorageS3:
+		return NewS3Storage(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown attachment storage driver %q", cfg.Driver)
+	}
+}
+
+// validKey 保存先の外を指す key（絶対パス・..）を拒否する
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	return path.Clean(key) == key && !strings.HasPrefix(key, "../") && key != ".."
+}
+
+// ===== ローカルファイルシステム =====
+
+type localStorage struct {
+	root string
+}
+
+func NewLocalStorage(root string) Storage {
+	return &localStorage{root: root}
+}
+
+func (s *localStorage) path(key string) (string, error) {
+	if !validKey(key) {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+// Put は一時ファイルに書いてから置き換える（途中で失敗しても壊れたファイルを残さない）
+func (s *localStorage) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	saved := false
+	defer func() {
+		if !saved {
+			os.Remove(f.Name())
+		}
+	}()
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), p); err != nil {
+		return err
+	}
+	saved = true
+	return nil
+}
+
+func (s *localStorage) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+	return f, err
+}
+
+func (s *localStorage) Delete(_ context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package attachments
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalStorageRejectsKeysOutsideRoot(t *testing.T) {
+	s := NewLocalStorage(t.TempDir())
+	for _, key := range []string{"../x", "/etc/passwd", "a/../../x", "a\\b", ""} {
+		if err := s.Put(context.Background(), key, strings.NewReader("x"), 1, ""); err == nil {
+			t.Fatalf("expected key %q rejected", key)
+		}
+	}
+}
+
+func TestNewStorageDisablesLocalStorageWithoutDir(t *testing.T) {
+	for _, driver := range []string{"", "local"} {
+		if s, err := NewStorage(StorageConfig{Driver: driver, Dir: " "}); err != nil || s != nil {
+			t.Fatalf("expected driver %q without dir disabled, got %v, %v", driver, s, err)
+		}
+	}
+	if s, err := NewStorage(StorageConfig{Dir: t.TempDir()}); err != nil || s == nil {
+		t.Fatalf("expected local storage, got %v, %v", s, err)
+	}
+}
+
+func TestS3StorageSignsPathStyleRequests(t *testing.T) {
+	objects := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/20261018/ap-northeast-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=") ||
+			r.Header.Get("X-Amz-Date") != "20261018T090000Z" || r.Header.Get("X-Amz-Content-Sha256") != s3UnsignedPayload {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			b, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = string(b)
+		case http.MethodGet:
+			v, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = io.WriteString(w, v)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	s := newS3Storage(srv.Client(), S3Config{
+		Endpoint: srv.URL, Region: "ap-northeast-1", Bucket: "lims", Prefix: "att",
+		AccessKeyID: "AKID", SecretAccessKey: "secret",
+	})
+	s.now = func() time.Time { return time.Date(2026, time.October, 18, 9, 0, 0, 0, time.UTC) }
+	ctx := context.Background()
+
+	if err := s.Put(ctx, "objects/ab/abc", strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatalf("Put returned %v", err)
+	}
+	if _, ok := objects["/lims/att/objects/ab/abc"]; !ok {
+		t.Fatalf("expected path-style key, got %v", objects)
+	}
+	rc, err := s.Get(ctx, "objects/ab/abc")
+	if err != nil {
+		t.Fatalf("Get returned %v", err)
+	}
+	body, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(body) != "hello" {
+		t.Fatalf("unexpected body %q", body)
+	}
+	if err := s.Delete(ctx, "objects/ab/abc"); err != nil {
+		t.Fatalf("Delete returned %v", err)
+	}
+	if _, err := s.Get(ctx, "objects/ab/abc"); err != ErrObjectNotFound {
+		t.Fatalf("expected ErrObjectNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package attachments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"IRIS-backend/internal/asset_mgmt/inventory"
+	platformdb "IRIS-backend/internal/platform/db"
+)
+
+// 実体は attachment_blobs に sha256 ごとに 1 行（ref_count 件の asset_attachments から参照される）。
+// 実体の保存は blob 行をロックしたトランザクションの中で行う。
+// 削除は添付行を消すトランザクションをコミットしてから、参照の無くなった（ref_count = 0）blob 行をロックし直して行い、
+// 同じ中身の登録が交差しても参照されている実体を消さないようにする
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+const attachmentSelect = `
+	SELECT a.attachment_id, m.management_number, a.kind, a.file_name, b.content_type, b.size_bytes,
+		a.sha256, b.has_thumbnail, COALESCE(a.note, ''), a.created_at
+	FROM asset_attachments a
+	JOIN attachment_blobs b ON b.sha256 = a.sha256
+	JOIN assets_master m ON m.asset_master_id = a.asset_master_id`
+
+func scanAttachment(row interface{ Scan(...any) error }) (*AttachmentResponse, error) {
+	var a AttachmentResponse
+	if err := row.Scan(&a.AttachmentID, &a.ManagementNumber, &a.Kind, &a.FileName, &a.ContentType, &a.SizeBytes,
+		&a.SHA256, &a.HasThumbnail, &a.Note, &a.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (s *Store) ResolveManagementNumber(ctx context.Context, managementNumber string) (uint64, string, error) {
+	return inventory.ResolveManagementNumber(ctx, s.db, managementNumber)
+}
+
+func (s *Store) ListAttachments(ctx context.Context, assetMasterID uint64, kind string) ([]AttachmentResponse, error) {
+	q := attachmentSelect + ` WHERE a.asset_master_id = ?`
+	args := []any{assetMasterID}
+	if kind != "" {
+		q += ` AND a.kind = ?`
+		args = append(args, kind)
+	}
+	q += ` ORDER BY a.created_at DESC, a.attachment_id DESC`
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]AttachmentResponse, 0, 8)
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *a)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) GetAttachment(ctx context.Context, assetMasterID, attachmentID uint64) (*AttachmentResponse, error) {
+	q := attachmentSelect + ` WHERE a.asset_master_id = ? AND a.attachment_id = ?`
+	return scanAttachment(s.db.QueryRowContext(ctx, q, assetMasterID, attachmentID))
+}
+
+// CreateAttachment は添付を登録する。同じ備品に同じ中身が既にあればそれを返し（created=false）、
+// 実体がまだ無ければ putBlob で保存する
+func (s *Store) CreateAttachment(ctx context.Context, in createAttachmentInput, putBlob func(ctx context.Context) error) (*AttachmentResponse, bool, error) {
+	var (
+		id      uint64
+		created bool
+	)
+	err := platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		// blob 行を作る・ロックする（ref_count は添付行を作るときに増やす）
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO attachment_blobs (sha256, content_type, size_bytes, has_thumbnail, ref_count, created_at)
+			VALUES (?, ?, ?, ?, 0, UTC_TIMESTAMP())
+			ON DUPLICATE KEY UPDATE ref_count = ref_count`,
+			in.SHA256, in.ContentType, in.SizeBytes, in.HasThumbnail)
+		if err != nil {
+			return err
+		}
+		// 新しい blob（挿入で 1 行）か、参照の無くなった blob なら実体を保存する
+		needPut := true
+		if aff, _ := res.RowsAffected(); aff != 1 {
+			if err := tx.QueryRowContext(ctx,
+				`SELECT ref_count = 0 FROM attachment_blobs WHERE sha256 = ? FOR UPDATE`, in.SHA256,
+			).Scan(&needPut); err != nil {
+				return err
+			}
+		}
+
+		err = tx.QueryRowContext(ctx,
+			`SELECT attachment_id FROM asset_attachments WHERE asset_master_id = ? AND sha256 = ?`,
+			in.AssetMasterID, in.SHA256,
+		).Scan(&id)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if needPut {
+			if err := putBlob(ctx); err != nil {
+				return err
+			}
+		}
+		res, err = tx.ExecContext(ctx, `
+			INSERT INTO asset_attachments (asset_master_id, kind, file_name, sha256, note, created_at)
+			VALUES (?, ?, ?, ?, NULLIF(?, ''), UTC_TIMESTAMP())`,
+			in.AssetMasterID, in.Kind, in.FileName, in.SHA256, in.Note)
+		if err != nil {
+			return err
+		}
+		lastID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		id, created = uint64(lastID), true
+		_, err = tx.ExecContext(ctx, `UPDATE attachment_blobs SET ref_count = ref_count + 1 WHERE sha256 = ?`, in.SHA256)
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	out, err := s.GetAttachment(ctx, in.AssetMasterID, id)
+	return out, created, err
+}
+
+// DeleteAttachment は添付を消す。最後の参照だったら orphaned を返す（実体は DeleteOrphanBlob で消す）
+func (s *Store) DeleteAttachment(ctx context.Context, assetMasterID, attachmentID uint64) (ref blobRef, orphaned bool, err error) {
+	err = platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		if err := tx.QueryRowContext(ctx,
+			`SELECT sha256 FROM asset_attachments WHERE asset_master_id = ? AND attachment_id = ?`,
+			assetMasterID, attachmentID,
+		).Scan(&ref.SHA256); err != nil {
+			return err
+		}
+		var refCount int
+		if err := tx.QueryRowContext(ctx,
+			`SELECT ref_count FROM attachment_blobs WHERE sha256 = ? FOR UPDATE`, ref.SHA256,
+		).Scan(&refCount); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM asset_attachments WHERE attachment_id = ?`, attachmentID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE attachment_blobs SET ref_count = ref_count - 1 WHERE sha256 = ?`, ref.SHA256); err != nil {
+			return err
+		}
+		orphaned = refCount <= 1
+		return nil
+	})
+	return ref, orphaned, err
+}
+
+// DeleteOrphanBlob は参照の無い blob の実体を deleteBlob で消してから行を消す。
+// その間に同じ中身が登録されて参照が戻っていれば何もしない。
+// 実体の削除に失敗しても ref_count = 0 の行が残るだけで、次の登録で実体を置き直す
+func (s *Store) DeleteOrphanBlob(ctx context.Context, sha256 string, deleteBlob func(ctx context.Context, ref blobRef) error) error {
+	return platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		ref := blobRef{SHA256: sha256}
+		var refCount int
+		err := tx.QueryRowContext(ctx,
+			`SELECT ref_count, has_thumbnail FROM attachment_blobs WHERE sha256 = ? FOR UPDATE`, sha256,
+		).Scan(&refCount, &ref.HasThumbnail)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if refCount > 0 {
+			return nil
+		}
+		if err := deleteBlob(ctx, ref); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM attachment_blobs WHERE sha256 = ? AND ref_count = 0`, sha256)
+		return err
+	})
+}
@@ -2,38 +2,56 @@ package computers
 
 import "time"
 
+// ComputerAddressRequest は NIC 1つ分のアドレス。mac_address と ip_address の少なくとも一方が必要
+type ComputerAddressRequest struct {
+	InterfaceName *string `json:"interface_name,omitempty" example:"eth0"`
+	MACAddress    *string `json:"mac_address,omitempty" example:"aa:bb:cc:dd:ee:ff"`
+	IPAddress     *string `json:"ip_address,omitempty" example:"10.0.3.15"`
+	IsPrimary     bool    `json:"is_primary,omitempty"`
+}
+
+// ip_address / mac_address は主 NIC の値。addresses を渡す場合は省略するか主 NIC と一致させる
 type CreateComputerDetailRequest struct {
-	AssetMasterID uint64  `json:"asset_master_id" binding:"required"`
-	Hostname      *string `json:"hostname,omitempty"`
-	IPAddress     *string `json:"ip_address,omitempty"`
-	MACAddress    *string `json:"mac_address,omitempty"`
-	OS            *string `json:"os,omitempty"`
-	Purpose       *string `json:"purpose,omitempty"`
-	LoginUser     *string `json:"login_user,omitempty"`
-	Note          *string `json:"note,omitempty"`
+	AssetMasterID uint64                   `json:"asset_master_id" binding:"required"`
+	Hostname      *string                  `json:"hostname,omitempty"`
+	IPAddress     *string                  `json:"ip_address,omitempty"`
+	MACAddress    *string                  `json:"mac_address,omitempty"`
+	Addresses     []ComputerAddressRequest `json:"addresses,omitempty"`
+	OS            *string                  `json:"os,omitempty"`
+	Purpose       *string                  `json:"purpose,omitempty"`
+	LoginUser     *string                  `json:"login_user,omitempty"`
+	Note          *string                  `json:"note,omitempty"`
 }
 
+// addresses を渡すと NIC 一覧を丸ごと置き換える。ip_address / mac_address だけなら主 NIC を更新する
 type UpdateComputerDetailRequest struct {
-	Hostname   *string `json:"hostname,omitempty"`
-	IPAddress  *string `json:"ip_address,omitempty"`
-	MACAddress *string `json:"mac_address,omitempty"`
-	OS         *string `json:"os,omitempty"`
-	Purpose    *string `json:"purpose,omitempty"`
-	LoginUser  *string `json:"login_user,omitempty"`
-	Note       *string `json:"note,omitempty"`
+	Hostname   *string                   `json:"hostname,omitempty"`
+	IPAddress  *string                   `json:"ip_address,omitempty"`
+	MACAddress *string                   `json:"mac_address,omitempty"`
+	Addresses  *[]ComputerAddressRequest `json:"addresses,omitempty"`
+	OS         *string                   `json:"os,omitempty"`
+	Purpose    *string                   `json:"purpose,omitempty"`
+	LoginUser  *string                   `json:"login_user,omitempty"`
+	Note       *string                   `json:"note,omitempty"`
 }
 
+// CreateComputerPartRequest の spec_attributes は part_type_id の spec_schema に沿って検証される
 type CreateComputerPartRequest struct {
-	AssetMasterID uint64  `json:"asset_master_id" binding:"required"`
-	UsageStatusID uint    `json:"usage_status_id" binding:"required"`
-	Specification *string `json:"spec,omitempty"`
-	Note          *string `json:"note,omitempty"`
+	AssetMasterID  uint64         `json:"asset_master_id" binding:"required"`
+	UsageStatusID  uint           `json:"usage_status_id" binding:"required"`
+	PartTypeID     *uint          `json:"part_type_id,omitempty"`
+	Specification  *string        `json:"spec,omitempty"`
+	SpecAttributes map[string]any `json:"spec_attributes,omitempty"`
+	Note           *string        `json:"note,omitempty"`
 }
 
+// UpdateComputerPartRequest の spec_attributes は指定時に丸ごと置き換える（{} で空にする）
 type UpdateComputerPartRequest struct {
-	UsageStatusID *uint   `json:"usage_status_id,omitempty"`
-	Specification *string `json:"spec,omitempty"`
-	Note          *string `json:"note,omitempty"`
+	UsageStatusID  *uint          `json:"usage_status_id,omitempty"`
+	PartTypeID     *uint          `json:"part_type_id,omitempty"`
+	Specification  *string        `json:"spec,omitempty"`
+	SpecAttributes map[string]any `json:"spec_attributes,omitempty"`
+	Note           *string        `json:"note,omitempty"`
 }
 
 type CreateComputerConfigurationRequest struct {
@@ -53,37 +71,105 @@ type UpdateComputerConfigurationRequest struct {
 	Note              *string `json:"note,omitempty"`
 }
 
+// CreatePartSwapRequest は稼働中の部品を同じ部品種別の別部品に入れ替える。swapped_at は YYYY-MM-DD（省略時は当日）
+type CreatePartSwapRequest struct {
+	RemovedPartAssetMasterID   uint64  `json:"removed_part_asset_master_id" binding:"required"`
+	InstalledPartAssetMasterID uint64  `json:"installed_part_asset_master_id" binding:"required"`
+	RemovedUsageStatusID       uint    `json:"removed_usage_status_id" binding:"required"`
+	Reason                     *string `json:"reason,omitempty" example:"disk failure"`
+	SwappedAt                  *string `json:"swapped_at,omitempty" example:"2026-10-18"`
+}
+
 type ComputerDetailResponse struct {
-	ComputerDetailID uint64    `json:"computer_detail_id"`
-	AssetMasterID    uint64    `json:"asset_master_id"`
-	ManagementNumber string    `json:"management_number"`
-	AssetName        string    `json:"asset_name"`
-	Hostname         *string   `json:"hostname,omitempty"`
-	IPAddress        *string   `json:"ip_address,omitempty"`
-	MACAddress       *string   `json:"mac_address,omitempty"`
-	OS               *string   `json:"os,omitempty"`
-	Purpose          *string   `json:"purpose,omitempty"`
-	LoginUser        *string   `json:"login_user,omitempty"`
-	Note             *string   `json:"note,omitempty"`
-	CreatedAt        time.Time `json:"created_at"`
-	UpdatedAt        time.Time `json:"updated_at"`
+	ComputerDetailID uint64                    `json:"computer_detail_id"`
+	AssetMasterID    uint64                    `json:"asset_master_id"`
+	ManagementNumber string                    `json:"management_number"`
+	AssetName        string                    `json:"asset_name"`
+	Hostname         *string                   `json:"hostname,omitempty"`
+	IPAddress        *string                   `json:"ip_address,omitempty"`
+	MACAddress       *string                   `json:"mac_address,omitempty"`
+	OS               *string                   `json:"os,omitempty"`
+	Purpose          *string                   `json:"purpose,omitempty"`
+	LoginUser        *string                   `json:"login_user,omitempty"`
+	Addresses        []ComputerAddressResponse `json:"addresses"`
+	Note             *string                   `json:"note,omitempty"`
+	// 以下はエージェントの自己申告（POST /computer-details/ingest）から反映する
+	CPU        *string            `json:"cpu,omitempty"`
+	RAMMB      *uint64            `json:"ram_mb,omitempty"`
+	StorageGB  *float64           `json:"storage_gb,omitempty"`
+	Disks      []IngestDiskReport `json:"disks,omitempty"`
+	LastSeenAt *time.Time         `json:"last_seen_at,omitempty"`
+	CreatedAt  time.Time          `json:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+}
+
+type ComputerAddressResponse struct {
+	InterfaceName *string `json:"interface_name,omitempty"`
+	MACAddress    *string `json:"mac_address,omitempty"`
+	IPAddress     *string `json:"ip_address,omitempty"`
+	IsPrimary     bool    `json:"is_primary"`
+}
+
+// ComputerAddressLookupResponse は IP / MAC の逆引き結果。effective_to が無ければ現在も使用中
+type ComputerAddressLookupResponse struct {
+	AssetMasterID    uint64     `json:"asset_master_id"`
+	ManagementNumber string     `json:"management_number"`
+	AssetName        string     `json:"asset_name"`
+	Hostname         *string    `json:"hostname,omitempty"`
+	InterfaceName    *string    `json:"interface_name,omitempty"`
+	MACAddress       *string    `json:"mac_address,omitempty"`
+	IPAddress        *string    `json:"ip_address,omitempty"`
+	IsPrimary        bool       `json:"is_primary"`
+	EffectiveFrom    time.Time  `json:"effective_from"`
+	EffectiveTo      *time.Time `json:"effective_to,omitempty"`
+}
+
+// ComputerDetailVersionResponse は computer_details の1版。effective_to が無ければ現行の値
+type ComputerDetailVersionResponse struct {
+	HistoryID        uint64     `json:"history_id"`
+	AssetMasterID    uint64     `json:"asset_master_id"`
+	ManagementNumber string     `json:"management_number"`
+	AssetName        string     `json:"asset_name"`
+	Hostname         *string    `json:"hostname,omitempty"`
+	IPAddress        *string    `json:"ip_address,omitempty"`
+	MACAddress       *string    `json:"mac_address,omitempty"`
+	OS               *string    `json:"os,omitempty"`
+	LoginUser        *string    `json:"login_user,omitempty"`
+	EffectiveFrom    time.Time  `json:"effective_from"`
+	EffectiveTo      *time.Time `json:"effective_to,omitempty"`
 }
 
 type ComputerPartResponse struct {
-	ComputerPartID            uint64    `json:"computer_part_id"`
-	AssetMasterID             uint64    `json:"asset_master_id"`
-	ManagementNumber          string    `json:"management_number"`
-	AssetName                 string    `json:"asset_name"`
-	UsageStatusID             uint      `json:"usage_status_id"`
-	UsageStatusName           string    `json:"usage_status_name"`
-	UsageStatusDisplayName    string    `json:"usage_status_display_name"`
-	ActivePartTypeID          *uint     `json:"active_part_type_id"`
-	ActivePartTypeName        *string   `json:"active_part_type_name"`
-	ActivePartTypeDisplayName *string   `json:"active_part_type_display_name"`
-	Specification             *string   `json:"spec,omitempty"`
-	Note                      *string   `json:"note,omitempty"`
-	CreatedAt                 time.Time `json:"created_at"`
-	UpdatedAt                 time.Time `json:"updated_at"`
+	ComputerPartID            uint64         `json:"computer_part_id"`
+	AssetMasterID             uint64         `json:"asset_master_id"`
+	ManagementNumber          string         `json:"management_number"`
+	AssetName                 string         `json:"asset_name"`
+	UsageStatusID             uint           `json:"usage_status_id"`
+	UsageStatusName           string         `json:"usage_status_name"`
+	UsageStatusDisplayName    string         `json:"usage_status_display_name"`
+	ActivePartTypeID          *uint          `json:"active_part_type_id"`
+	ActivePartTypeName        *string        `json:"active_part_type_name"`
+	ActivePartTypeDisplayName *string        `json:"active_part_type_display_name"`
+	PartTypeID                *uint          `json:"part_type_id,omitempty"`
+	PartTypeName              *string        `json:"part_type_name,omitempty"`
+	Specification             *string        `json:"spec,omitempty"`
+	SpecAttributes            map[string]any `json:"spec_attributes,omitempty"`
+	Note                      *string        `json:"note,omitempty"`
+	CreatedAt                 time.Time      `json:"created_at"`
+	UpdatedAt                 time.Time      `json:"updated_at"`
+}
+
+type PartSwapResponse struct {
+	PartSwapID            uint64                        `json:"part_swap_id"`
+	ComputerAssetMasterID uint64                        `json:"computer_asset_master_id"`
+	PartTypeID            uint                          `json:"part_type_id"`
+	RemovedUsageStatusID  uint                          `json:"removed_usage_status_id"`
+	Reason                *string                       `json:"reason,omitempty"`
+	SwappedAt             time.Time                     `json:"swapped_at"`
+	SwappedBy             *string                       `json:"swapped_by,omitempty"`
+	Removed               ComputerConfigurationResponse `json:"removed"`
+	Installed             ComputerConfigurationResponse `json:"installed"`
+	CreatedAt             time.Time                     `json:"created_at"`
 }
 
 type ComputerConfigurationResponse struct {
@@ -104,20 +190,209 @@ type ComputerConfigurationResponse struct {
 	UpdatedAt                time.Time  `json:"updated_at"`
 }
 
+// PartTypeResponse の label は lang（ja / en）に合わせた表示名。英語名が無ければ display_name を使う
 type PartTypeResponse struct {
-	PartTypeID  uint    `json:"part_type_id"`
-	Name        string  `json:"name"`
-	DisplayName string  `json:"display_name"`
-	Note        *string `json:"note,omitempty"`
+	PartTypeID    uint            `json:"part_type_id"`
+	Name          string          `json:"name"`
+	DisplayName   string          `json:"display_name"`
+	DisplayNameEN *string         `json:"display_name_en,omitempty"`
+	Label         string          `json:"label"`
+	SpecSchema    []PartSpecField `json:"spec_schema"`
+	IsDisabled    bool            `json:"is_disabled"`
+	Note          *string         `json:"note,omitempty"`
+}
+
+type CreatePartTypeRequest struct {
+	Name          string          `json:"name" binding:"required" example:"gpu"`
+	DisplayName   string          `json:"display_name" binding:"required" example:"グラフィックボード"`
+	DisplayNameEN *string         `json:"display_name_en,omitempty" example:"Graphics card"`
+	SpecSchema    []PartSpecField `json:"spec_schema,omitempty"`
+	Note          *string         `json:"note,omitempty"`
+}
+
+// UpdatePartTypeRequest の display_name_en / note は空文字で消去、is_disabled=true は使用中なら 409
+type UpdatePartTypeRequest struct {
+	Name          *string          `json:"name,omitempty"`
+	DisplayName   *string          `json:"display_name,omitempty"`
+	DisplayNameEN *string          `json:"display_name_en,omitempty"`
+	SpecSchema    *[]PartSpecField `json:"spec_schema,omitempty"`
+	Note          *string          `json:"note,omitempty"`
+	IsDisabled    *bool            `json:"is_disabled,omitempty"`
+}
+
+// PartSpecField は part_types.spec_schema の1項目。rollup が sum の数値項目は BOM で合計される。
+// summary（ram_gb / storage_gb）を付けた項目の合計は端末全体の RAM / ストレージ容量に数える
+type PartSpecField struct {
+	Key      string   `json:"key" example:"capacity_gb"`
+	Label    string   `json:"label,omitempty" example:"Capacity"`
+	Type     string   `json:"type" example:"integer"`
+	Unit     *string  `json:"unit,omitempty" example:"GB"`
+	Required bool     `json:"required,omitempty"`
+	Enum     []string `json:"enum,omitempty"`
+	Rollup   string   `json:"rollup,omitempty" example:"sum"`
+	Summary  string   `json:"summary,omitempty" example:"ram_gb"`
+}
+
+// ComputerBOMResponse は端末に取り付けられている部品を部品種別ごとにまとめたもの。
+// at を指定したときは installed_at / removed_at から当時の構成を復元する（spec は現在の値）
+type ComputerBOMResponse struct {
+	ComputerAssetMasterID uint64            `json:"computer_asset_master_id"`
+	At                    *time.Time        `json:"at,omitempty"`
+	RAMGB                 float64           `json:"ram_gb"`
+	StorageGB             float64           `json:"storage_gb"`
+	PartTypes             []BOMPartTypeNode `json:"part_types"`
+}
+
+type BOMPartTypeNode struct {
+	PartTypeID  uint               `json:"part_type_id"`
+	Name        string             `json:"name"`
+	DisplayName string             `json:"display_name"`
+	Totals      map[string]float64 `json:"totals"`
+	Parts       []BOMPart          `json:"parts"`
+}
+
+type BOMPart struct {
+	ComputerConfigurationID uint64         `json:"computer_configuration_id"`
+	PartAssetMasterID       uint64         `json:"part_asset_master_id"`
+	ManagementNumber        string         `json:"management_number"`
+	AssetName               string         `json:"asset_name"`
+	InstalledAt             *time.Time     `json:"installed_at,omitempty"`
+	RemovedAt               *time.Time     `json:"removed_at,omitempty"`
+	Specification           *string        `json:"spec,omitempty"`
+	SpecAttributes          map[string]any `json:"spec_attributes,omitempty"`
 }
 
 type UsageStatusResponse struct {
 	UsageStatusID uint    `json:"usage_status_id"`
 	Name          string  `json:"name"`
 	DisplayName   string  `json:"display_name"`
+	DisplayNameEN *string `json:"display_name_en,omitempty"`
+	Label         string  `json:"label"`
+	IsDisabled    bool    `json:"is_disabled"`
 	Note          *string `json:"note,omitempty"`
 }
 
+type CreateUsageStatusRequest struct {
+	Name          string  `json:"name" binding:"required" example:"spare"`
+	DisplayName   string  `json:"display_name" binding:"required" example:"予備"`
+	DisplayNameEN *string `json:"display_name_en,omitempty" example:"Spare"`
+	Note          *string `json:"note,omitempty"`
+}
+
+type UpdateUsageStatusRequest struct {
+	Name          *string `json:"name,omitempty"`
+	DisplayName   *string `json:"display_name,omitempty"`
+	DisplayNameEN *string `json:"display_name_en,omitempty"`
+	Note          *string `json:"note,omitempty"`
+	IsDisabled    *bool   `json:"is_disabled,omitempty"`
+}
+
+type IngestDiskReport struct {
+	Name   string  `json:"name"`
+	Model  *string `json:"model,omitempty"`
+	SizeGB float64 `json:"size_gb"`
+}
+
+// IngestComputerReportRequest はエージェントが送ってくるマシンの自己申告
+type IngestComputerReportRequest struct {
+	Hostname     string             `json:"hostname" binding:"required"`
+	MACAddresses []string           `json:"mac_addresses,omitempty"`
+	IPAddress    *string            `json:"ip_address,omitempty"`
+	OS           *string            `json:"os,omitempty"`
+	OSBuild      *string            `json:"os_build,omitempty"`
+	CPU          *string            `json:"cpu,omitempty"`
+	RAMMB        *uint64            `json:"ram_mb,omitempty"`
+	Disks        []IngestDiskReport `json:"disks,omitempty"`
+	Serial       *string            `json:"serial,omitempty"`
+	LoginUser    *string            `json:"login_user,omitempty"`
+}
+
+type IngestComputerReportResponse struct {
+	Matched       bool                    `json:"matched"`
+	MatchedBy     *string                 `json:"matched_by,omitempty" example:"serial"`
+	AssetMasterID *uint64                 `json:"asset_master_id,omitempty"`
+	Detail        *ComputerDetailResponse `json:"detail,omitempty"`
+	ReviewID      *uint64                 `json:"review_id,omitempty"`
+	ReviewReason  *string                 `json:"review_reason,omitempty" example:"no_match"`
+	ReceivedAt    time.Time               `json:"received_at"`
+}
+
+type IngestReviewResponse struct {
+	ReviewID              uint64                      `json:"review_id"`
+	Status                string                      `json:"status" example:"pending"`
+	Reason                string                      `json:"reason" example:"no_match"`
+	Hostname              string                      `json:"hostname"`
+	Serial                *string                     `json:"serial,omitempty"`
+	MACAddresses          []string                    `json:"mac_addresses"`
+	Report                IngestComputerReportRequest `json:"report"`
+	ReportCount           int                         `json:"report_count"`
+	ReportedBy            string                      `json:"reported_by"`
+	FirstSeenAt           time.Time                   `json:"first_seen_at"`
+	LastSeenAt            time.Time                   `json:"last_seen_at"`
+	ResolvedAssetMasterID *uint64                     `json:"resolved_asset_master_id,omitempty"`
+	ResolvedBy            *string                     `json:"resolved_by,omitempty"`
+	ResolvedAt            *time.Time                  `json:"resolved_at,omitempty"`
+}
+
+// ResolveIngestReviewRequest は asset_master_id を指定すればその資産に紐付け、省略すれば却下扱い
+type ResolveIngestReviewRequest struct {
+	AssetMasterID *uint64 `json:"asset_master_id,omitempty"`
+}
+
+type Page struct {
+	Limit  int
+	Offset int
+	Order  string // "asc" or "desc"
+}
+
+type ComputerDetailFilter struct {
+	Q                *string
+	Hostname         *string
+	IPAddress        *string
+	MACAddress       *string
+	OS               *string
+	LoginUser        *string
+	ManagementNumber *string
+	CPU              *string
+	MinRAMMB         *uint64
+	MinStorageGB     *uint64
+}
+
+type ComputerPartFilter struct {
+	Q                *string
+	UsageStatusID    *uint
+	ActivePartTypeID *uint
+	Installed        *bool
+	Spec             *string
+}
+
+type ComputerConfigurationFilter struct {
+	ComputerAssetMasterID *uint64
+	PartAssetMasterID     *uint64
+	PartTypeID            *uint
+	Active                *bool
+	InstalledFrom         *time.Time
+	InstalledTo           *time.Time
+}
+
+type ListComputerDetailsResponse struct {
+	Items      []ComputerDetailResponse `json:"items"`
+	Total      int64                    `json:"total" example:"100"`
+	NextOffset int                      `json:"next_offset" example:"50"`
+}
+
+type ListComputerPartsResponse struct {
+	Items      []ComputerPartResponse `json:"items"`
+	Total      int64                  `json:"total" example:"100"`
+	NextOffset int                    `json:"next_offset" example:"50"`
+}
+
+type ListComputerConfigurationsResponse struct {
+	Items      []ComputerConfigurationResponse `json:"items"`
+	Total      int64                           `json:"total" example:"100"`
+	NextOffset int                             `json:"next_offset" example:"50"`
+}
+
 type ErrorDetail struct {
 	Code    string `json:"code" example:"INVALID_ARGUMENT"`
 	Message string `json:"message" example:"invalid json"`
@@ -126,3 +401,49 @@ type ErrorDetail struct {
 type ErrorResponse struct {
 	Error ErrorDetail `json:"error"`
 }
+
+// PartSlotLimitRequest は機種（assets_master.model）ごとの部品種別の搭載上限。model を省略すると全機種の既定になる
+type PartSlotLimitRequest struct {
+	Model      *string `json:"model,omitempty" example:"OptiPlex 7010"`
+	PartTypeID uint    `json:"part_type_id" binding:"required"`
+	MaxCount   uint    `json:"max_count" example:"4"`
+	Note       *string `json:"note,omitempty"`
+}
+
+type PartSlotLimitResponse struct {
+	SlotLimitID  uint64    `json:"slot_limit_id"`
+	Model        *string   `json:"model,omitempty"`
+	PartTypeID   uint      `json:"part_type_id"`
+	PartTypeName string    `json:"part_type_name"`
+	MaxCount     uint      `json:"max_count"`
+	Note         *string   `json:"note,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// CompatibilityRuleRequest は部品の spec_attributes に対する互換性ルール。
+// kind=allowed_values は attribute の値が allowed_values に含まれること、
+// kind=match_installed は同じ端末に取り付け済みの同種部品と attribute が一致することを求める
+type CompatibilityRuleRequest struct {
+	Name          string   `json:"name" binding:"required" example:"DDR5 only"`
+	Model         *string  `json:"model,omitempty" example:"OptiPlex 7010"`
+	PartTypeID    uint     `json:"part_type_id" binding:"required"`
+	Attribute     string   `json:"attribute" binding:"required" example:"memory_type"`
+	Kind          string   `json:"kind" binding:"required" example:"allowed_values"`
+	AllowedValues []string `json:"allowed_values,omitempty" example:"DDR5"`
+	Note          *string  `json:"note,omitempty"`
+}
+
+type CompatibilityRuleResponse struct {
+	RuleID        uint64    `json:"rule_id"`
+	Name          string    `json:"name"`
+	Model         *string   `json:"model,omitempty"`
+	PartTypeID    uint      `json:"part_type_id"`
+	PartTypeName  string    `json:"part_type_name"`
+	Attribute     string    `json:"attribute"`
+	Kind          string    `json:"kind"`
+	AllowedValues []string  `json:"allowed_values"`
+	Note          *string   `json:"note,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
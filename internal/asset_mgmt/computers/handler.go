@@ -1,11 +1,16 @@
 package computers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"log"
 
+	"IRIS-backend/internal/platform/auth"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,19 +22,135 @@ func RegisterRoutes(r gin.IRoutes, svc *Service) {
 	h := &Handler{svc: svc}
 
 	r.POST("/computer-details", h.CreateComputerDetail)
+	r.GET("/computer-details", h.ListComputerDetails)
 	r.GET("/computer-details/:asset_master_id", h.GetComputerDetail)
 	r.PUT("/computer-details/:asset_master_id", h.UpdateComputerDetail)
+	r.GET("/computer-details/:asset_master_id/history", h.ListComputerDetailHistory)
+	r.GET("/computer-details/by-ip/:ip", h.FindComputerDetailsByIP)
+	r.GET("/computer-details/by-mac/:mac", h.FindComputerDetailsByMAC)
 
 	r.POST("/computer-parts", h.CreateComputerPart)
+	r.GET("/computer-parts", h.ListComputerParts)
 	r.GET("/computer-parts/:asset_master_id", h.GetComputerPart)
 	r.PUT("/computer-parts/:asset_master_id", h.UpdateComputerPart)
 
 	r.POST("/computer-configurations", h.CreateComputerConfiguration)
+	r.GET("/computer-configurations", h.SearchComputerConfigurations)
 	r.PUT("/computer-configurations/:computer_configuration_id", h.UpdateComputerConfiguration)
 	r.GET("/computers/:computer_asset_master_id/configurations", h.ListComputerConfigurations)
+	r.POST("/computers/:computer_asset_master_id/part-swaps", h.SwapComputerPart)
+	r.GET("/computers/:computer_asset_master_id/bom", h.GetComputerBOM)
 
 	r.GET("/part-types", h.ListPartTypes)
+	r.POST("/part-types", h.CreatePartType)
+	r.PUT("/part-types/:part_type_id", h.UpdatePartType)
 	r.GET("/usage-statuses", h.ListUsageStatuses)
+	r.POST("/usage-statuses", h.CreateUsageStatus)
+	r.PUT("/usage-statuses/:usage_status_id", h.UpdateUsageStatus)
+
+	r.GET("/part-slot-limits", h.ListPartSlotLimits)
+	r.POST("/part-slot-limits", h.CreatePartSlotLimit)
+	r.PUT("/part-slot-limits/:slot_limit_id", h.UpdatePartSlotLimit)
+	r.DELETE("/part-slot-limits/:slot_limit_id", h.DeletePartSlotLimit)
+	r.GET("/part-compatibility-rules", h.ListCompatibilityRules)
+	r.POST("/part-compatibility-rules", h.CreateCompatibilityRule)
+	r.PUT("/part-compatibility-rules/:rule_id", h.UpdateCompatibilityRule)
+	r.DELETE("/part-compatibility-rules/:rule_id", h.DeleteCompatibilityRule)
+}
+
+// RegisterAgentRoutes はマシン常駐エージェント向けのルートと、その報告の確認キュー。認証付きのグループに登録すること
+// （確認キューの解決者は認証済みのユーザー ID で記録する）
+func RegisterAgentRoutes(r gin.IRoutes, svc *Service) {
+	h := &Handler{svc: svc}
+
+	r.POST("/computer-details/ingest", h.IngestComputerReport)
+	r.GET("/computer-ingest/reviews", h.ListIngestReviews)
+	r.POST("/computer-ingest/reviews/:review_id/resolve", h.ResolveIngestReview)
+}
+
+// @Summary      Ingest a machine self-report
+// @Description  Accepts an agent report, matches it to an asset by serial then MAC, and updates the computer detail and last-seen timestamp. Unmatched machines are queued for review.
+// @Tags         computers-ingest
+// @Accept       json
+// @Produce      json
+// @Param        report body IngestComputerReportRequest true "Machine self-report"
+// @Success      200 {object} IngestComputerReportResponse "matched and applied"
+// @Success      202 {object} IngestComputerReportResponse "queued for review"
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /computer-details/ingest [post]
+func (h *Handler) IngestComputerReport(c *gin.Context) {
+	var req IngestComputerReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+
+	out, err := h.svc.IngestComputerReport(c.Request.Context(), req, c.GetString(auth.CtxUserIDKey))
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	if !out.Matched {
+		c.JSON(http.StatusAccepted, out)
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// @Summary      List ingest reviews
+// @Description  Lists agent reports that could not be matched to an asset.
+// @Tags         computers-ingest
+// @Produce      json
+// @Param        status query string false "pending | resolved | dismissed | all" default(pending)
+// @Success      200 {array}  IngestReviewResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /computer-ingest/reviews [get]
+func (h *Handler) ListIngestReviews(c *gin.Context) {
+	out, err := h.svc.ListIngestReviews(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// @Summary      Resolve an ingest review
+// @Description  Links the reported machine to an asset and applies the report, or dismisses the review when asset_master_id is omitted.
+// @Tags         computers-ingest
+// @Accept       json
+// @Produce      json
+// @Param        review_id path int true "Review ID"
+// @Param        resolution body ResolveIngestReviewRequest true "Resolution"
+// @Success      200 {object} IngestReviewResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Failure      409 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /computer-ingest/reviews/{review_id}/resolve [post]
+func (h *Handler) ResolveIngestReview(c *gin.Context) {
+	reviewID, ok := parseUint64Path(c, "review_id")
+	if !ok {
+		return
+	}
+
+	var req ResolveIngestReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+
+	out, err := h.svc.ResolveIngestReview(c.Request.Context(), reviewID, req, c.GetString(auth.CtxUserIDKey))
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, out)
 }
 
 // @Summary      Create a computer detail
@@ -60,6 +181,57 @@ func (h *Handler) CreateComputerDetail(c *gin.Context) {
 	c.JSON(http.StatusCreated, out)
 }
 
+// @Summary      List computer details
+// @Description  Lists computer details with optional search by hostname, IP, MAC, OS, login user or the hardware reported by the agent.
+// @Tags         computers-details
+// @Produce      json
+// @Param        q                 query string false "Free text over hostname, IP, MAC, OS, login user, management number and name"
+// @Param        hostname          query string false "Hostname (partial match)"
+// @Param        ip_address        query string false "IP address (prefix match)"
+// @Param        mac_address       query string false "MAC address (any notation)"
+// @Param        os                query string false "OS (partial match)"
+// @Param        login_user        query string false "Login user (partial match)"
+// @Param        management_number query string false "Management number"
+// @Param        cpu               query string false "CPU reported by the agent (partial match)"
+// @Param        min_ram_mb        query int    false "Minimum RAM reported by the agent (MB)"
+// @Param        min_storage_gb    query int    false "Minimum total disk size reported by the agent (GB)"
+// @Param        limit             query int    false "Number of items to return" default(50)
+// @Param        offset            query int    false "Offset for pagination" default(0)
+// @Param        order             query string false "Sort order ('asc' or 'desc')" Enums(asc, desc) default(desc)
+// @Success      200 {object} ListComputerDetailsResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /computer-details [get]
+func (h *Handler) ListComputerDetails(c *gin.Context) {
+	f := ComputerDetailFilter{
+		Q:                trimmedQueryValue(c, "q"),
+		Hostname:         trimmedQueryValue(c, "hostname"),
+		IPAddress:        trimmedQueryValue(c, "ip_address"),
+		MACAddress:       trimmedQueryValue(c, "mac_address"),
+		OS:               trimmedQueryValue(c, "os"),
+		LoginUser:        trimmedQueryValue(c, "login_user"),
+		ManagementNumber: trimmedQueryValue(c, "management_number"),
+		CPU:              trimmedQueryValue(c, "cpu"),
+	}
+	var err error
+	if f.MinRAMMB, err = parseOptionalUint64Query(c, "min_ram_mb"); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, err.Error()))
+		return
+	}
+	if f.MinStorageGB, err = parseOptionalUint64Query(c, "min_storage_gb"); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, err.Error()))
+		return
+	}
+	p := pageFromQuery(c)
+
+	items, total, err := h.svc.ListComputerDetails(c.Request.Context(), p, f)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, ListComputerDetailsResponse{Items: items, Total: total, NextOffset: nextOffset(total, p)})
+}
+
 // @Summary      Get a computer detail
 // @Description  Retrieves a detail record by asset master ID.
 // @Tags         computers-details
@@ -94,6 +266,7 @@ func (h *Handler) GetComputerDetail(c *gin.Context) {
 // @Success      200 {object} ComputerDetailResponse
 // @Failure      400 {object} ErrorResponse
 // @Failure      404 {object} ErrorResponse
+// @Failure      409 {object} ErrorResponse
 // @Failure      500 {object} ErrorResponse
 // @Router       /computer-details/{asset_master_id} [put]
 func (h *Handler) UpdateComputerDetail(c *gin.Context) {
@@ -116,6 +289,68 @@ func (h *Handler) UpdateComputerDetail(c *gin.Context) {
 	c.JSON(http.StatusOK, out)
 }
 
+// @Summary      List computer detail history
+// @Description  Lists every version of a computer detail (hostname, IP, MAC, OS, login user), newest first.
+// @Tags         computers-details
+// @Produce      json
+// @Param        asset_master_id path int true "Asset master ID"
+// @Success      200 {array}  ComputerDetailVersionResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /computer-details/{asset_master_id}/history [get]
+func (h *Handler) ListComputerDetailHistory(c *gin.Context) {
+	assetMasterID, ok := parseUint64Path(c, "asset_master_id")
+	if !ok {
+		return
+	}
+
+	out, err := h.svc.ListComputerDetailHistory(c.Request.Context(), assetMasterID)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// @Summary      Find computers by IP address
+// @Description  Returns the network interfaces that used the IP address at the given time.
+// @Tags         computers-details
+// @Produce      json
+// @Param        ip path  string true  "IP address"
+// @Param        at query string false "Point in time (YYYY-MM-DD or RFC3339). Defaults to now."
+// @Success      200 {array}  ComputerAddressLookupResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /computer-details/by-ip/{ip} [get]
+func (h *Handler) FindComputerDetailsByIP(c *gin.Context) {
+	out, err := h.svc.FindComputerDetailsByIP(c.Request.Context(), c.Param("ip"), c.Query("at"))
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// @Summary      Find computers by MAC address
+// @Description  Returns the network interfaces that used the MAC address at the given time.
+// @Tags         computers-details
+// @Produce      json
+// @Param        mac path  string true  "MAC address"
+// @Param        at  query string false "Point in time (YYYY-MM-DD or RFC3339). Defaults to now."
+// @Success      200 {array}  ComputerAddressLookupResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /computer-details/by-mac/{mac} [get]
+func (h *Handler) FindComputerDetailsByMAC(c *gin.Context) {
+	out, err := h.svc.FindComputerDetailsByMAC(c.Request.Context(), c.Param("mac"), c.Query("at"))
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
 // @Summary      Create a computer part
 // @Description  Creates a part record linked to an existing asset master.
 // @Tags         computers-parts
@@ -143,6 +378,50 @@ func (h *Handler) CreateComputerPart(c *gin.Context) {
 	c.JSON(http.StatusCreated, out)
 }
 
+// @Summary      List computer parts
+// @Description  Lists computer parts filtered by usage status, active part type or spec text.
+// @Tags         computers-parts
+// @Produce      json
+// @Param        q                   query string false "Free text over spec, note, management number and name"
+// @Param        usage_status_id     query int    false "Usage status ID"
+// @Param        active_part_type_id query int    false "Part type of the active configuration"
+// @Param        installed           query bool   false "true: currently installed, false: not installed"
+// @Param        spec                query string false "Spec (partial match)"
+// @Param        limit               query int    false "Number of items to return" default(50)
+// @Param        offset              query int    false "Offset for pagination" default(0)
+// @Param        order               query string false "Sort order ('asc' or 'desc')" Enums(asc, desc) default(desc)
+// @Success      200 {object} ListComputerPartsResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /computer-parts [get]
+func (h *Handler) ListComputerParts(c *gin.Context) {
+	f := ComputerPartFilter{
+		Q:    trimmedQueryValue(c, "q"),
+		Spec: trimmedQueryValue(c, "spec"),
+	}
+	var err error
+	if f.UsageStatusID, err = parseOptionalUintQuery(c, "usage_status_id"); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, err.Error()))
+		return
+	}
+	if f.ActivePartTypeID, err = parseOptionalUintQuery(c, "active_part_type_id"); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, err.Error()))
+		return
+	}
+	if f.Installed, err = parseOptionalBoolQuery(c, "installed"); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, err.Error()))
+		return
+	}
+	p := pageFromQuery(c)
+
+	items, total, err := h.svc.ListComputerParts(c.Request.Context(), p, f)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, ListComputerPartsResponse{Items: items, Total: total, NextOffset: nextOffset(total, p)})
+}
+
 // @Summary      Get a computer part
 // @Description  Retrieves a part record by asset master ID.
 // @Tags         computers-parts
@@ -250,6 +529,98 @@ func (h *Handler) ListComputerConfigurations(c *gin.Context) {
 	c.JSON(http.StatusOK, out)
 }
 
+// @Summary      Swap a computer part
+// @Description  Removes an installed part and installs another registered computer part of the same part type in one transaction, setting the removed part's usage status and recording the reason. `swapped_at` uses `YYYY-MM-DD` and defaults to today.
+// @Tags         computers-configurations
+// @Accept       json
+// @Produce      json
+// @Param        computer_asset_master_id path int true "Computer asset master ID"
+// @Param        partSwap body CreatePartSwapRequest true "Part swap"
+// @Success      201 {object} PartSwapResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Failure      409 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /computers/{computer_asset_master_id}/part-swaps [post]
+func (h *Handler) SwapComputerPart(c *gin.Context) {
+	computerAssetMasterID, ok := parseUint64Path(c, "computer_asset_master_id")
+	if !ok {
+		return
+	}
+
+	var req CreatePartSwapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+
+	out, err := h.svc.SwapComputerPart(c.Request.Context(), computerAssetMasterID, req, c.GetString(auth.CtxUserIDKey))
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.Header("Location", "/computers/"+strconv.FormatUint(computerAssetMasterID, 10)+"/configurations")
+	c.JSON(http.StatusCreated, out)
+}
+
+// @Summary      Get a computer bill of materials
+// @Description  Returns the installed parts grouped by part type, with numeric spec attributes marked `rollup: sum` totalled per part type. Totals of fields marked `summary: ram_gb` or `summary: storage_gb` are also added up into the computer-level `ram_gb` and `storage_gb`. With `at`, the configuration at that time is reconstructed from installed_at/removed_at.
+// @Tags         computers-configurations
+// @Produce      json
+// @Param        computer_asset_master_id path  int    true  "Computer asset master ID"
+// @Param        at                       query string false "Point in time (YYYY-MM-DD or RFC3339). Defaults to current configuration."
+// @Success      200 {object} ComputerBOMResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /computers/{computer_asset_master_id}/bom [get]
+func (h *Handler) GetComputerBOM(c *gin.Context) {
+	computerAssetMasterID, ok := parseUint64Path(c, "computer_asset_master_id")
+	if !ok {
+		return
+	}
+
+	out, err := h.svc.GetComputerBOM(c.Request.Context(), computerAssetMasterID, c.Query("at"))
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// @Summary      Search computer configurations
+// @Description  Lists configurations filtered by computer, part, part type, active/removed state and installed date range.
+// @Tags         computers-configurations
+// @Produce      json
+// @Param        computer_asset_master_id query int    false "Computer asset master ID"
+// @Param        part_asset_master_id     query int    false "Part asset master ID"
+// @Param        part_type_id             query int    false "Part type ID"
+// @Param        active                   query bool   false "true: not removed, false: removed"
+// @Param        installed_from           query string false "Installed on or after (YYYY-MM-DD or RFC3339)"
+// @Param        installed_to             query string false "Installed on or before (YYYY-MM-DD or RFC3339)"
+// @Param        limit                    query int    false "Number of items to return" default(50)
+// @Param        offset                   query int    false "Offset for pagination" default(0)
+// @Param        order                    query string false "Sort order ('asc' or 'desc')" Enums(asc, desc) default(desc)
+// @Success      200 {object} ListComputerConfigurationsResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /computer-configurations [get]
+func (h *Handler) SearchComputerConfigurations(c *gin.Context) {
+	f, err := buildConfigurationFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, err.Error()))
+		return
+	}
+	p := pageFromQuery(c)
+
+	items, total, err := h.svc.SearchComputerConfigurations(c.Request.Context(), p, f)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, ListComputerConfigurationsResponse{Items: items, Total: total, NextOffset: nextOffset(total, p)})
+}
+
 // @Summary      Update a computer configuration
 // @Description  Updates an existing configuration row. `installed_at` and `removed_at` use `YYYY-MM-DD`; an empty string clears the value.
 // @Tags         computers-configurations
@@ -284,14 +655,77 @@ func (h *Handler) UpdateComputerConfiguration(c *gin.Context) {
 }
 
 // @Summary      List part types
-// @Description  Lists master data for computer part types.
+// @Description  Lists master data for computer part types. Set `all=1` to include disabled ones; `lang` selects the `label` language.
 // @Tags         computers-masters
 // @Produce      json
+// @Param        all  query string false "Include disabled part types if '1', 'true', 'yes', or 'all'"
+// @Param        lang query string false "Label language" Enums(ja, en) default(ja)
 // @Success      200 {array} PartTypeResponse
 // @Failure      500 {object} ErrorResponse
 // @Router       /part-types [get]
 func (h *Handler) ListPartTypes(c *gin.Context) {
-	out, err := h.svc.ListPartTypes(c.Request.Context())
+	out, err := h.svc.ListPartTypes(c.Request.Context(), c.Query("all"), c.Query("lang"))
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// @Summary      Create a part type
+// @Description  Registers a computer part type. `name` must be unique.
+// @Tags         computers-masters
+// @Accept       json
+// @Produce      json
+// @Param        lang     query string false "Label language" Enums(ja, en) default(ja)
+// @Param        partType body CreatePartTypeRequest true "Part type"
+// @Success      201 {object} PartTypeResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      409 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /part-types [post]
+func (h *Handler) CreatePartType(c *gin.Context) {
+	var req CreatePartTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+
+	out, err := h.svc.CreatePartType(c.Request.Context(), req, c.Query("lang"))
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+// @Summary      Update a part type
+// @Description  Updates a part type. Setting `is_disabled` to true fails with 409 while active configurations use it.
+// @Tags         computers-masters
+// @Accept       json
+// @Produce      json
+// @Param        part_type_id path  int    true  "Part type ID"
+// @Param        lang         query string false "Label language" Enums(ja, en) default(ja)
+// @Param        partType     body  UpdatePartTypeRequest true "Part type patch"
+// @Success      200 {object} PartTypeResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Failure      409 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /part-types/{part_type_id} [put]
+func (h *Handler) UpdatePartType(c *gin.Context) {
+	partTypeID, ok := parseUintPath(c, "part_type_id")
+	if !ok {
+		return
+	}
+
+	var req UpdatePartTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+
+	out, err := h.svc.UpdatePartType(c.Request.Context(), partTypeID, req, c.Query("lang"))
 	if err != nil {
 		c.JSON(toHTTPStatus(err), apiErrFrom(err))
 		return
@@ -300,14 +734,16 @@ func (h *Handler) ListPartTypes(c *gin.Context) {
 }
 
 // @Summary      List usage statuses
-// @Description  Lists master data for computer part usage statuses.
+// @Description  Lists master data for computer part usage statuses. Set `all=1` to include disabled ones; `lang` selects the `label` language.
 // @Tags         computers-masters
 // @Produce      json
+// @Param        all  query string false "Include disabled usage statuses if '1', 'true', 'yes', or 'all'"
+// @Param        lang query string false "Label language" Enums(ja, en) default(ja)
 // @Success      200 {array} UsageStatusResponse
 // @Failure      500 {object} ErrorResponse
 // @Router       /usage-statuses [get]
 func (h *Handler) ListUsageStatuses(c *gin.Context) {
-	out, err := h.svc.ListUsageStatuses(c.Request.Context())
+	out, err := h.svc.ListUsageStatuses(c.Request.Context(), c.Query("all"), c.Query("lang"))
 	if err != nil {
 		c.JSON(toHTTPStatus(err), apiErrFrom(err))
 		return
@@ -315,6 +751,286 @@ func (h *Handler) ListUsageStatuses(c *gin.Context) {
 	c.JSON(http.StatusOK, out)
 }
 
+// @Summary      Create a usage status
+// @Description  Registers a computer part usage status. `name` must be unique.
+// @Tags         computers-masters
+// @Accept       json
+// @Produce      json
+// @Param        lang        query string false "Label language" Enums(ja, en) default(ja)
+// @Param        usageStatus body CreateUsageStatusRequest true "Usage status"
+// @Success      201 {object} UsageStatusResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      409 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /usage-statuses [post]
+func (h *Handler) CreateUsageStatus(c *gin.Context) {
+	var req CreateUsageStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+
+	out, err := h.svc.CreateUsageStatus(c.Request.Context(), req, c.Query("lang"))
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+// @Summary      Update a usage status
+// @Description  Updates a usage status. Setting `is_disabled` to true fails with 409 while computer parts still have it.
+// @Tags         computers-masters
+// @Accept       json
+// @Produce      json
+// @Param        usage_status_id path  int    true  "Usage status ID"
+// @Param        lang            query string false "Label language" Enums(ja, en) default(ja)
+// @Param        usageStatus     body  UpdateUsageStatusRequest true "Usage status patch"
+// @Success      200 {object} UsageStatusResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Failure      409 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /usage-statuses/{usage_status_id} [put]
+func (h *Handler) UpdateUsageStatus(c *gin.Context) {
+	usageStatusID, ok := parseUintPath(c, "usage_status_id")
+	if !ok {
+		return
+	}
+
+	var req UpdateUsageStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+
+	out, err := h.svc.UpdateUsageStatus(c.Request.Context(), usageStatusID, req, c.Query("lang"))
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// @Summary      List part slot limits
+// @Description  Lists how many active parts of each part type a computer model can hold. Limits without a model apply to every model; when nothing matches, one part per type is allowed.
+// @Tags         computers-masters
+// @Produce      json
+// @Param        part_type_id query int false "Part type ID"
+// @Success      200 {array}  PartSlotLimitResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /part-slot-limits [get]
+func (h *Handler) ListPartSlotLimits(c *gin.Context) {
+	partTypeID, err := parseOptionalUintQuery(c, "part_type_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, err.Error()))
+		return
+	}
+
+	out, err := h.svc.ListPartSlotLimits(c.Request.Context(), partTypeID)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// @Summary      Create a part slot limit
+// @Description  Registers the slot limit for a part type on a computer model (assets_master.model).
+// @Tags         computers-masters
+// @Accept       json
+// @Produce      json
+// @Param        slotLimit body PartSlotLimitRequest true "Slot limit"
+// @Success      201 {object} PartSlotLimitResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      409 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /part-slot-limits [post]
+func (h *Handler) CreatePartSlotLimit(c *gin.Context) {
+	var req PartSlotLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+
+	out, err := h.svc.CreatePartSlotLimit(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+// @Summary      Update a part slot limit
+// @Description  Replaces a slot limit.
+// @Tags         computers-masters
+// @Accept       json
+// @Produce      json
+// @Param        slot_limit_id path int true "Slot limit ID"
+// @Param        slotLimit body PartSlotLimitRequest true "Slot limit"
+// @Success      200 {object} PartSlotLimitResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Failure      409 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /part-slot-limits/{slot_limit_id} [put]
+func (h *Handler) UpdatePartSlotLimit(c *gin.Context) {
+	slotLimitID, ok := parseUint64Path(c, "slot_limit_id")
+	if !ok {
+		return
+	}
+
+	var req PartSlotLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+
+	out, err := h.svc.UpdatePartSlotLimit(c.Request.Context(), slotLimitID, req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// @Summary      Delete a part slot limit
+// @Description  Deletes a slot limit. The model falls back to the default limit.
+// @Tags         computers-masters
+// @Param        slot_limit_id path int true "Slot limit ID"
+// @Success      204 "No Content"
+// @Failure      400 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /part-slot-limits/{slot_limit_id} [delete]
+func (h *Handler) DeletePartSlotLimit(c *gin.Context) {
+	slotLimitID, ok := parseUint64Path(c, "slot_limit_id")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.DeletePartSlotLimit(c.Request.Context(), slotLimitID); err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary      List part compatibility rules
+// @Description  Lists compatibility rules evaluated against part spec attributes when a part is installed.
+// @Tags         computers-masters
+// @Produce      json
+// @Param        part_type_id query int false "Part type ID"
+// @Success      200 {array}  CompatibilityRuleResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /part-compatibility-rules [get]
+func (h *Handler) ListCompatibilityRules(c *gin.Context) {
+	partTypeID, err := parseOptionalUintQuery(c, "part_type_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, err.Error()))
+		return
+	}
+
+	out, err := h.svc.ListCompatibilityRules(c.Request.Context(), partTypeID)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// @Summary      Create a part compatibility rule
+// @Description  Registers a rule. `allowed_values` requires the attribute to be one of the values; `match_installed` requires it to match parts of the same type already installed.
+// @Tags         computers-masters
+// @Accept       json
+// @Produce      json
+// @Param        rule body CompatibilityRuleRequest true "Compatibility rule"
+// @Success      201 {object} CompatibilityRuleResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      409 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /part-compatibility-rules [post]
+func (h *Handler) CreateCompatibilityRule(c *gin.Context) {
+	var req CompatibilityRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+
+	out, err := h.svc.CreateCompatibilityRule(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+// @Summary      Update a part compatibility rule
+// @Description  Replaces a compatibility rule.
+// @Tags         computers-masters
+// @Accept       json
+// @Produce      json
+// @Param        rule_id path int true "Rule ID"
+// @Param        rule body CompatibilityRuleRequest true "Compatibility rule"
+// @Success      200 {object} CompatibilityRuleResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Failure      409 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /part-compatibility-rules/{rule_id} [put]
+func (h *Handler) UpdateCompatibilityRule(c *gin.Context) {
+	ruleID, ok := parseUint64Path(c, "rule_id")
+	if !ok {
+		return
+	}
+
+	var req CompatibilityRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+
+	out, err := h.svc.UpdateCompatibilityRule(c.Request.Context(), ruleID, req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// @Summary      Delete a part compatibility rule
+// @Description  Deletes a compatibility rule.
+// @Tags         computers-masters
+// @Param        rule_id path int true "Rule ID"
+// @Success      204 "No Content"
+// @Failure      400 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /part-compatibility-rules/{rule_id} [delete]
+func (h *Handler) DeleteCompatibilityRule(c *gin.Context) {
+	ruleID, ok := parseUint64Path(c, "rule_id")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.DeleteCompatibilityRule(c.Request.Context(), ruleID); err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func parseUintPath(c *gin.Context, key string) (uint, bool) {
+	value, err := strconv.ParseUint(c.Param(key), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, key+" must be uint"))
+		return 0, false
+	}
+	return uint(value), true
+}
+
 func parseUint64Path(c *gin.Context, key string) (uint64, bool) {
 	value, err := strconv.ParseUint(c.Param(key), 10, 64)
 	if err != nil {
@@ -324,6 +1040,123 @@ func parseUint64Path(c *gin.Context, key string) (uint64, bool) {
 	return value, true
 }
 
+func buildConfigurationFilter(c *gin.Context) (ComputerConfigurationFilter, error) {
+	var f ComputerConfigurationFilter
+	var err error
+	if f.ComputerAssetMasterID, err = parseOptionalUint64Query(c, "computer_asset_master_id"); err != nil {
+		return ComputerConfigurationFilter{}, err
+	}
+	if f.PartAssetMasterID, err = parseOptionalUint64Query(c, "part_asset_master_id"); err != nil {
+		return ComputerConfigurationFilter{}, err
+	}
+	if f.PartTypeID, err = parseOptionalUintQuery(c, "part_type_id"); err != nil {
+		return ComputerConfigurationFilter{}, err
+	}
+	if f.Active, err = parseOptionalBoolQuery(c, "active"); err != nil {
+		return ComputerConfigurationFilter{}, err
+	}
+	// installed_to は日付のみなら「その日を含む上限」として翌日0時の半開区間にする
+	if f.InstalledFrom, err = parseOptionalTimeQuery(c, "installed_from", false); err != nil {
+		return ComputerConfigurationFilter{}, err
+	}
+	if f.InstalledTo, err = parseOptionalTimeQuery(c, "installed_to", true); err != nil {
+		return ComputerConfigurationFilter{}, err
+	}
+	return f, nil
+}
+
+func pageFromQuery(c *gin.Context) Page {
+	return normalizePage(Page{
+		Limit:  atoiDef(c.Query("limit"), defaultPageLimit),
+		Offset: atoiDef(c.Query("offset"), 0),
+		Order:  strings.ToLower(c.DefaultQuery("order", "desc")),
+	})
+}
+
+func nextOffset(total int64, p Page) int {
+	n := p.Offset + p.Limit
+	if n >= int(total) {
+		return 0
+	}
+	return n
+}
+
+func atoiDef(s string, d int) int {
+	if s == "" {
+		return d
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return d
+	}
+	return n
+}
+
+func trimmedQueryValue(c *gin.Context, key string) *string {
+	v := strings.TrimSpace(c.Query(key))
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+func parseOptionalUint64Query(c *gin.Context, key string) (*uint64, error) {
+	v := strings.TrimSpace(c.Query(key))
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be uint64", key)
+	}
+	return &n, nil
+}
+
+func parseOptionalUintQuery(c *gin.Context, key string) (*uint, error) {
+	v := strings.TrimSpace(c.Query(key))
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseUint(v, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be uint", key)
+	}
+	u := uint(n)
+	return &u, nil
+}
+
+func parseOptionalBoolQuery(c *gin.Context, key string) (*bool, error) {
+	v := strings.TrimSpace(c.Query(key))
+	if v == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be true or false", key)
+	}
+	return &b, nil
+}
+
+func parseOptionalTimeQuery(c *gin.Context, key string, endExclusive bool) (*time.Time, error) {
+	v := strings.TrimSpace(c.Query(key))
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		t = t.UTC()
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be YYYY-MM-DD or RFC3339", key)
+	}
+	if endExclusive {
+		t = t.AddDate(0, 0, 1)
+	}
+	t = t.UTC()
+	return &t, nil
+}
+
 type errDTO struct {
 	Error struct {
 		Code    Code   `json:"code"`
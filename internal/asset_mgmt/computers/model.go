@@ -12,38 +12,73 @@ type nullableTimeField struct {
 	Value *time.Time
 }
 
+// computerAddressInput は正規化済みの NIC。スライスの先頭が主 NIC
+type computerAddressInput struct {
+	InterfaceName *string
+	MACAddress    *string
+	IPAddress     *string
+	IsPrimary     bool
+}
+
+// addressConflict は他の資産が現在使っているアドレス
+type addressConflict struct {
+	AssetMasterID    uint64
+	ManagementNumber string
+	MACAddress       *string
+	IPAddress        *string
+}
+
 type createComputerDetailInput struct {
 	AssetMasterID uint64
 	Hostname      *string
 	IPAddress     *string
 	MACAddress    *string
+	Addresses     []computerAddressInput
 	OS            *string
 	Purpose       *string
 	LoginUser     *string
 	Note          *string
+	ChangedAt     time.Time
 }
 
 type updateComputerDetailInput struct {
 	Hostname   nullableStringField
 	IPAddress  nullableStringField
 	MACAddress nullableStringField
+	Addresses  *[]computerAddressInput
+	// Candidates は他の資産と重複していないかを書き込み時に確かめる MAC / IP
+	Candidates []computerAddressInput
 	OS         nullableStringField
 	Purpose    nullableStringField
 	LoginUser  nullableStringField
 	Note       nullableStringField
+	ChangedAt  time.Time
 }
 
 type createComputerPartInput struct {
-	AssetMasterID uint64
-	UsageStatusID uint
-	Specification *string
-	Note          *string
+	AssetMasterID  uint64
+	UsageStatusID  uint
+	PartTypeID     *uint
+	Specification  *string
+	SpecAttributes map[string]any
+	Note           *string
 }
 
 type updateComputerPartInput struct {
-	UsageStatusID *uint
-	Specification nullableStringField
-	Note          nullableStringField
+	UsageStatusID  *uint
+	PartTypeID     *uint
+	Specification  nullableStringField
+	SpecAttributes map[string]any
+	Note           nullableStringField
+}
+
+// bomEntry は BOM 組み立て用の構成行（部品種別の spec_schema 付き）
+type bomEntry struct {
+	PartTypeID          uint
+	PartTypeName        string
+	PartTypeDisplayName string
+	SpecSchema          []PartSpecField
+	Part                BOMPart
 }
 
 type createComputerConfigurationInput struct {
@@ -53,6 +88,7 @@ type createComputerConfigurationInput struct {
 	InstalledAt           *time.Time
 	RemovedAt             *time.Time
 	Note                  *string
+	SlotLimit             uint // 取り付け中（RemovedAt が nil）のときに数え直す搭載上限
 }
 
 type updateComputerConfigurationInput struct {
@@ -63,6 +99,20 @@ type updateComputerConfigurationInput struct {
 	Note              nullableStringField
 }
 
+// partSwapInput は取り外し・取り付け・取り外した部品の状態変更を1トランザクションで行うための入力
+type partSwapInput struct {
+	ComputerAssetMasterID      uint64
+	PartTypeID                 uint
+	RemovedConfigurationID     uint64
+	RemovedPartAssetMasterID   uint64
+	InstalledPartAssetMasterID uint64
+	RemovedUsageStatusID       uint
+	SlotLimit                  uint
+	Reason                     *string
+	SwappedAt                  time.Time
+	SwappedBy                  string
+}
+
 type resolvedComputerConfiguration struct {
 	ComputerAssetMasterID uint64
 	PartAssetMasterID     uint64
@@ -70,3 +120,155 @@ type resolvedComputerConfiguration struct {
 	InstalledAt           *time.Time
 	RemovedAt             *time.Time
 }
+
+const (
+	ingestMatchedBySerial = "serial"
+	ingestMatchedByMAC    = "mac"
+
+	ingestReviewPending   = "pending"
+	ingestReviewResolved  = "resolved"
+	ingestReviewDismissed = "dismissed"
+
+	ingestReasonNoMatch         = "no_match"
+	ingestReasonAmbiguousSerial = "ambiguous_serial"
+	ingestReasonAmbiguousMAC    = "ambiguous_mac"
+	ingestReasonAddressConflict = "address_conflict"
+)
+
+// ingestReportInput は正規化済みの自己申告。computer_details への反映と生データ保存の両方に使う
+type ingestReportInput struct {
+	Hostname     string
+	IPAddress    *string
+	MACAddress   *string
+	MACAddresses []string
+	Addresses    []computerAddressInput
+	OS           *string
+	LoginUser    *string
+	Serial       *string
+	CPU          *string
+	RAMMB        *uint64
+	StorageGB    *float64 // ディスク容量の合計
+	Disks        *string  // JSON 配列。報告にディスクが無ければ nil（既存の値を残す）
+	Payload      []byte
+	ReportedBy   string
+	ReceivedAt   time.Time
+}
+
+type ingestReviewInput struct {
+	Report      ingestReportInput
+	Fingerprint string
+	Reason      string
+}
+
+type ingestReviewResolution struct {
+	AssetMasterID *uint64
+	Report        *ingestReportInput
+	ResolvedBy    string
+	ResolvedAt    time.Time
+}
+
+func sameAddresses(a, b []computerAddressInput) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !sameStringPtr(a[i].InterfaceName, b[i].InterfaceName) ||
+			!sameStringPtr(a[i].MACAddress, b[i].MACAddress) ||
+			!sameStringPtr(a[i].IPAddress, b[i].IPAddress) ||
+			a[i].IsPrimary != b[i].IsPrimary {
+			return false
+		}
+	}
+	return true
+}
+
+func sameStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// withPrimaryAddress は主 NIC（先頭）の mac/ip を差し替えた一覧を返す。両方空になった主 NIC は外し、次の NIC を主にする
+func withPrimaryAddress(current []computerAddressInput, mac, ip *string) []computerAddressInput {
+	out := make([]computerAddressInput, 0, len(current)+1)
+	if mac != nil || ip != nil {
+		primary := computerAddressInput{MACAddress: mac, IPAddress: ip, IsPrimary: true}
+		if len(current) > 0 {
+			primary.InterfaceName = current[0].InterfaceName
+		}
+		out = append(out, primary)
+	}
+	if len(current) > 1 {
+		out = append(out, current[1:]...)
+	}
+	for i := range out {
+		out[i].IsPrimary = i == 0
+	}
+	return out
+}
+
+// slotLimit は端末の機種に合う搭載上限。機種名・部品種別名は 409 の説明に使う
+// （Model は機種別の上限が当たったときだけ入る）
+type slotLimit struct {
+	MaxCount     uint
+	Model        *string
+	PartTypeName string
+}
+
+const (
+	// 機種・部品種別に搭載上限が登録されていないときの上限
+	defaultSlotLimit = 1
+
+	compatibilityAllowedValues  = "allowed_values"
+	compatibilityMatchInstalled = "match_installed"
+)
+
+type partSlotLimitInput struct {
+	Model      *string
+	PartTypeID uint
+	MaxCount   uint
+	Note       *string
+}
+
+type compatibilityRuleInput struct {
+	Name          string
+	Model         *string
+	PartTypeID    uint
+	Attribute     string
+	Kind          string
+	AllowedValues []string
+	Note          *string
+}
+
+type createPartTypeInput struct {
+	Name          string
+	DisplayName   string
+	DisplayNameEN *string
+	SpecSchema    []PartSpecField
+	Note          *string
+}
+
+type updatePartTypeInput struct {
+	Name          *string
+	DisplayName   *string
+	DisplayNameEN nullableStringField
+	SpecSchema    *[]PartSpecField
+	Note          nullableStringField
+	IsDisabled    *bool
+}
+
+type createUsageStatusInput struct {
+	Name          string
+	DisplayName   string
+	DisplayNameEN *string
+	Note          *string
+}
+
+type updateUsageStatusInput struct {
+	Name          *string
+	DisplayName   *string
+	DisplayNameEN nullableStringField
+	Note          nullableStringField
+	IsDisabled    *bool
+}
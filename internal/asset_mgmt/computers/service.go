@@ -3,7 +3,12 @@ package computers
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
 	"strings"
 	"time"
 
@@ -18,32 +23,79 @@ type computerStore interface {
 	CreateComputerDetail(ctx context.Context, in createComputerDetailInput) (*ComputerDetailResponse, error)
 	GetComputerDetailByAssetMasterID(ctx context.Context, assetMasterID uint64) (*ComputerDetailResponse, error)
 	UpdateComputerDetailByAssetMasterID(ctx context.Context, assetMasterID uint64, patch updateComputerDetailInput) (*ComputerDetailResponse, error)
+	ListComputerDetails(ctx context.Context, p Page, f ComputerDetailFilter) ([]ComputerDetailResponse, int64, error)
+	ListComputerDetailHistory(ctx context.Context, assetMasterID uint64) ([]ComputerDetailVersionResponse, error)
+	FindComputerAddressesByIP(ctx context.Context, ip string, at time.Time) ([]ComputerAddressLookupResponse, error)
+	FindComputerAddressesByMAC(ctx context.Context, mac string, at time.Time) ([]ComputerAddressLookupResponse, error)
 
 	CreateComputerPart(ctx context.Context, in createComputerPartInput) (*ComputerPartResponse, error)
 	GetComputerPartByAssetMasterID(ctx context.Context, assetMasterID uint64) (*ComputerPartResponse, error)
 	UpdateComputerPartByAssetMasterID(ctx context.Context, assetMasterID uint64, patch updateComputerPartInput) (*ComputerPartResponse, error)
+	ListComputerParts(ctx context.Context, p Page, f ComputerPartFilter) ([]ComputerPartResponse, int64, error)
 
 	CreateComputerConfiguration(ctx context.Context, in createComputerConfigurationInput) (*ComputerConfigurationResponse, error)
 	GetComputerConfigurationByID(ctx context.Context, computerConfigurationID uint64) (*ComputerConfigurationResponse, error)
 	ListComputerConfigurationsByComputerAssetMasterID(ctx context.Context, computerAssetMasterID uint64) ([]ComputerConfigurationResponse, error)
 	UpdateComputerConfigurationByID(ctx context.Context, computerConfigurationID uint64, patch updateComputerConfigurationInput) (*ComputerConfigurationResponse, error)
+	ListComputerConfigurations(ctx context.Context, p Page, f ComputerConfigurationFilter) ([]ComputerConfigurationResponse, int64, error)
 	ActiveConfigurationExistsForPart(ctx context.Context, partAssetMasterID uint64, excludeID *uint64) (bool, error)
-	ActiveConfigurationExistsForComputerPartType(ctx context.Context, computerAssetMasterID uint64, partTypeID uint, excludeID *uint64) (bool, error)
+	CountActiveConfigurationsForComputerPartType(ctx context.Context, computerAssetMasterID uint64, partTypeID uint, excludeID *uint64) (int, error)
+	GetSlotLimit(ctx context.Context, computerAssetMasterID uint64, partTypeID uint) (slotLimit, error)
+	ListApplicableCompatibilityRules(ctx context.Context, computerAssetMasterID uint64, partTypeID uint) ([]CompatibilityRuleResponse, error)
+	ListPartSlotLimits(ctx context.Context, partTypeID *uint) ([]PartSlotLimitResponse, error)
+	GetPartSlotLimitByID(ctx context.Context, slotLimitID uint64) (*PartSlotLimitResponse, error)
+	CreatePartSlotLimit(ctx context.Context, in partSlotLimitInput) (*PartSlotLimitResponse, error)
+	UpdatePartSlotLimitByID(ctx context.Context, slotLimitID uint64, in partSlotLimitInput) (*PartSlotLimitResponse, error)
+	DeletePartSlotLimitByID(ctx context.Context, slotLimitID uint64) error
+	ListCompatibilityRules(ctx context.Context, partTypeID *uint) ([]CompatibilityRuleResponse, error)
+	GetCompatibilityRuleByID(ctx context.Context, ruleID uint64) (*CompatibilityRuleResponse, error)
+	CreateCompatibilityRule(ctx context.Context, in compatibilityRuleInput) (*CompatibilityRuleResponse, error)
+	UpdateCompatibilityRuleByID(ctx context.Context, ruleID uint64, in compatibilityRuleInput) (*CompatibilityRuleResponse, error)
+	DeleteCompatibilityRuleByID(ctx context.Context, ruleID uint64) error
+	GetActiveConfigurationForPart(ctx context.Context, computerAssetMasterID uint64, partAssetMasterID uint64) (*ComputerConfigurationResponse, error)
+	SwapComputerPart(ctx context.Context, in partSwapInput) (*PartSwapResponse, error)
 
-	ListPartTypes(ctx context.Context) ([]PartTypeResponse, error)
-	ListUsageStatuses(ctx context.Context) ([]UsageStatusResponse, error)
+	ListPartTypes(ctx context.Context, includeDisabled bool) ([]PartTypeResponse, error)
+	GetPartTypeByID(ctx context.Context, partTypeID uint) (*PartTypeResponse, error)
+	CreatePartType(ctx context.Context, in createPartTypeInput) (*PartTypeResponse, error)
+	UpdatePartTypeByID(ctx context.Context, partTypeID uint, patch updatePartTypeInput) (*PartTypeResponse, error)
+	GetPartTypeSpecSchema(ctx context.Context, partTypeID uint) ([]PartSpecField, error)
+	ListBOMEntries(ctx context.Context, computerAssetMasterID uint64, at *time.Time) ([]bomEntry, error)
+	ListUsageStatuses(ctx context.Context, includeDisabled bool) ([]UsageStatusResponse, error)
+	GetUsageStatusByID(ctx context.Context, usageStatusID uint) (*UsageStatusResponse, error)
+	CreateUsageStatus(ctx context.Context, in createUsageStatusInput) (*UsageStatusResponse, error)
+	UpdateUsageStatusByID(ctx context.Context, usageStatusID uint, patch updateUsageStatusInput) (*UsageStatusResponse, error)
+
+	FindAssetMasterIDsBySerial(ctx context.Context, serial string) ([]uint64, error)
+	FindAssetMasterIDsByMAC(ctx context.Context, macAddresses []string) ([]uint64, error)
+	ApplyIngestReport(ctx context.Context, assetMasterID uint64, matchedBy string, in ingestReportInput) (*ComputerDetailResponse, error)
+	QueueIngestReview(ctx context.Context, in ingestReviewInput) (uint64, error)
+	ListIngestReviews(ctx context.Context, status *string) ([]IngestReviewResponse, error)
+	GetIngestReviewByID(ctx context.Context, reviewID uint64) (*IngestReviewResponse, error)
+	ResolveIngestReview(ctx context.Context, reviewID uint64, in ingestReviewResolution) (*IngestReviewResponse, error)
+}
+
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now().UTC()
 }
 
 type Service struct {
 	store computerStore
+	clock Clock
 }
 
 func NewService(db *sql.DB) *Service {
-	return &Service{store: NewStore(db)}
+	return &Service{store: NewStore(db), clock: realClock{}}
 }
 
 func newServiceWithStore(store computerStore) *Service {
-	return &Service{store: store}
+	return &Service{store: store, clock: realClock{}}
 }
 
 func (s *Service) CreateComputerDetail(ctx context.Context, req CreateComputerDetailRequest) (ComputerDetailResponse, error) {
@@ -53,18 +105,28 @@ func (s *Service) CreateComputerDetail(ctx context.Context, req CreateComputerDe
 	if err := s.requireAssetMaster(ctx, req.AssetMasterID, "asset_master_id not found"); err != nil {
 		return ComputerDetailResponse{}, err
 	}
+	addrs, err := buildAddressSet(req.MACAddress, req.IPAddress, req.Addresses)
+	if err != nil {
+		return ComputerDetailResponse{}, err
+	}
+	primaryMAC, primaryIP := primaryAddress(addrs)
 
 	out, err := s.store.CreateComputerDetail(ctx, createComputerDetailInput{
 		AssetMasterID: req.AssetMasterID,
 		Hostname:      normalizeOptionalString(req.Hostname),
-		IPAddress:     normalizeOptionalString(req.IPAddress),
-		MACAddress:    normalizeOptionalString(req.MACAddress),
+		IPAddress:     primaryIP,
+		MACAddress:    primaryMAC,
+		Addresses:     addrs,
 		OS:            normalizeOptionalString(req.OS),
 		Purpose:       normalizeOptionalString(req.Purpose),
 		LoginUser:     normalizeOptionalString(req.LoginUser),
 		Note:          normalizeOptionalString(req.Note),
+		ChangedAt:     s.clock.Now(),
 	})
 	if err != nil {
+		if conflict := addressConflictError(err); conflict != nil {
+			return ComputerDetailResponse{}, conflict
+		}
 		return ComputerDetailResponse{}, mapCreateMySQLError(err, "computer detail already exists", "invalid asset_master_id")
 	}
 	return *out, nil
@@ -82,24 +144,99 @@ func (s *Service) GetComputerDetail(ctx context.Context, assetMasterID uint64) (
 }
 
 func (s *Service) UpdateComputerDetail(ctx context.Context, assetMasterID uint64, req UpdateComputerDetailRequest) (ComputerDetailResponse, error) {
-	out, err := s.store.UpdateComputerDetailByAssetMasterID(ctx, assetMasterID, updateComputerDetailInput{
-		Hostname:   normalizeNullableStringField(req.Hostname),
-		IPAddress:  normalizeNullableStringField(req.IPAddress),
-		MACAddress: normalizeNullableStringField(req.MACAddress),
-		OS:         normalizeNullableStringField(req.OS),
-		Purpose:    normalizeNullableStringField(req.Purpose),
-		LoginUser:  normalizeNullableStringField(req.LoginUser),
-		Note:       normalizeNullableStringField(req.Note),
-	})
+	patch := updateComputerDetailInput{
+		Hostname:  normalizeNullableStringField(req.Hostname),
+		OS:        normalizeNullableStringField(req.OS),
+		Purpose:   normalizeNullableStringField(req.Purpose),
+		LoginUser: normalizeNullableStringField(req.LoginUser),
+		Note:      normalizeNullableStringField(req.Note),
+		ChangedAt: s.clock.Now(),
+	}
+
+	if req.Addresses != nil {
+		addrs, err := buildAddressSet(req.MACAddress, req.IPAddress, *req.Addresses)
+		if err != nil {
+			return ComputerDetailResponse{}, err
+		}
+		mac, ip := primaryAddress(addrs)
+		patch.Addresses = &addrs
+		patch.MACAddress = nullableStringField{Set: true, Value: mac}
+		patch.IPAddress = nullableStringField{Set: true, Value: ip}
+		patch.Candidates = addrs
+	} else {
+		var err error
+		if patch.MACAddress, err = normalizeMACField(req.MACAddress); err != nil {
+			return ComputerDetailResponse{}, err
+		}
+		if patch.IPAddress, err = normalizeIPField(req.IPAddress); err != nil {
+			return ComputerDetailResponse{}, err
+		}
+		if patch.MACAddress.Value != nil || patch.IPAddress.Value != nil {
+			patch.Candidates = []computerAddressInput{{MACAddress: patch.MACAddress.Value, IPAddress: patch.IPAddress.Value}}
+		}
+	}
+
+	out, err := s.store.UpdateComputerDetailByAssetMasterID(ctx, assetMasterID, patch)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return ComputerDetailResponse{}, ErrNotFound("computer detail not found")
 		}
+		if conflict := addressConflictError(err); conflict != nil {
+			return ComputerDetailResponse{}, conflict
+		}
 		return ComputerDetailResponse{}, err
 	}
 	return *out, nil
 }
 
+func (s *Service) ListComputerDetails(ctx context.Context, p Page, f ComputerDetailFilter) ([]ComputerDetailResponse, int64, error) {
+	if f.MACAddress != nil {
+		mac, err := normalizeMACAddress(*f.MACAddress)
+		if err != nil {
+			return nil, 0, err
+		}
+		f.MACAddress = &mac
+	}
+	return s.store.ListComputerDetails(ctx, normalizePage(p), f)
+}
+
+func (s *Service) ListComputerDetailHistory(ctx context.Context, assetMasterID uint64) ([]ComputerDetailVersionResponse, error) {
+	exists, err := s.store.AssetMasterExists(ctx, assetMasterID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound("asset master not found")
+	}
+	return s.store.ListComputerDetailHistory(ctx, assetMasterID)
+}
+
+// FindComputerDetailsByIP は時刻 at（省略時は現在）にその IP を使っていた NIC を返す
+func (s *Service) FindComputerDetailsByIP(ctx context.Context, rawIP string, rawAt string) ([]ComputerAddressLookupResponse, error) {
+	ip, err := normalizeIPAddress(rawIP)
+	if err != nil {
+		return nil, err
+	}
+	at, err := s.parseAt(rawAt)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.FindComputerAddressesByIP(ctx, ip, at)
+}
+
+// FindComputerDetailsByMAC は時刻 at（省略時は現在）にその MAC を使っていた NIC を返す
+func (s *Service) FindComputerDetailsByMAC(ctx context.Context, rawMAC string, rawAt string) ([]ComputerAddressLookupResponse, error) {
+	mac, err := normalizeMACAddress(rawMAC)
+	if err != nil {
+		return nil, err
+	}
+	at, err := s.parseAt(rawAt)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.FindComputerAddressesByMAC(ctx, mac, at)
+}
+
 func (s *Service) CreateComputerPart(ctx context.Context, req CreateComputerPartRequest) (ComputerPartResponse, error) {
 	if req.AssetMasterID == 0 {
 		return ComputerPartResponse{}, ErrInvalid("asset_master_id is required")
@@ -113,12 +250,17 @@ func (s *Service) CreateComputerPart(ctx context.Context, req CreateComputerPart
 	if err := s.requireUsageStatus(ctx, req.UsageStatusID); err != nil {
 		return ComputerPartResponse{}, err
 	}
+	if err := s.validatePartSpec(ctx, req.PartTypeID, req.SpecAttributes); err != nil {
+		return ComputerPartResponse{}, err
+	}
 
 	out, err := s.store.CreateComputerPart(ctx, createComputerPartInput{
-		AssetMasterID: req.AssetMasterID,
-		UsageStatusID: req.UsageStatusID,
-		Specification: normalizeOptionalString(req.Specification),
-		Note:          normalizeOptionalString(req.Note),
+		AssetMasterID:  req.AssetMasterID,
+		UsageStatusID:  req.UsageStatusID,
+		PartTypeID:     req.PartTypeID,
+		Specification:  normalizeOptionalString(req.Specification),
+		SpecAttributes: req.SpecAttributes,
+		Note:           normalizeOptionalString(req.Note),
 	})
 	if err != nil {
 		return ComputerPartResponse{}, mapCreateMySQLError(err, "computer part already exists", "invalid asset_master_id or usage_status_id")
@@ -146,11 +288,33 @@ func (s *Service) UpdateComputerPart(ctx context.Context, assetMasterID uint64,
 			return ComputerPartResponse{}, err
 		}
 	}
+	if req.PartTypeID != nil || req.SpecAttributes != nil {
+		// 片方だけ変わるときも、変更後の部品種別と属性の組で検証する
+		current, err := s.store.GetComputerPartByAssetMasterID(ctx, assetMasterID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ComputerPartResponse{}, ErrNotFound("computer part not found")
+			}
+			return ComputerPartResponse{}, err
+		}
+		partTypeID, attrs := current.PartTypeID, current.SpecAttributes
+		if req.PartTypeID != nil {
+			partTypeID = req.PartTypeID
+		}
+		if req.SpecAttributes != nil {
+			attrs = req.SpecAttributes
+		}
+		if err := s.validatePartSpec(ctx, partTypeID, attrs); err != nil {
+			return ComputerPartResponse{}, err
+		}
+	}
 
 	out, err := s.store.UpdateComputerPartByAssetMasterID(ctx, assetMasterID, updateComputerPartInput{
-		UsageStatusID: req.UsageStatusID,
-		Specification: normalizeNullableStringField(req.Specification),
-		Note:          normalizeNullableStringField(req.Note),
+		UsageStatusID:  req.UsageStatusID,
+		PartTypeID:     req.PartTypeID,
+		Specification:  normalizeNullableStringField(req.Specification),
+		SpecAttributes: req.SpecAttributes,
+		Note:           normalizeNullableStringField(req.Note),
 	})
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -161,6 +325,10 @@ func (s *Service) UpdateComputerPart(ctx context.Context, assetMasterID uint64,
 	return *out, nil
 }
 
+func (s *Service) ListComputerParts(ctx context.Context, p Page, f ComputerPartFilter) ([]ComputerPartResponse, int64, error) {
+	return s.store.ListComputerParts(ctx, normalizePage(p), f)
+}
+
 func (s *Service) CreateComputerConfiguration(ctx context.Context, req CreateComputerConfigurationRequest) (ComputerConfigurationResponse, error) {
 	if req.ComputerAssetMasterID == 0 || req.PartAssetMasterID == 0 || req.PartTypeID == 0 {
 		return ComputerConfigurationResponse{}, ErrInvalid("computer_asset_master_id, part_asset_master_id, part_type_id are required")
@@ -187,8 +355,9 @@ func (s *Service) CreateComputerConfiguration(ctx context.Context, req CreateCom
 		return ComputerConfigurationResponse{}, err
 	}
 
+	var limit slotLimit
 	if removedAt == nil {
-		if err := s.ensureActiveConfigurationAvailable(ctx, req.PartAssetMasterID, req.ComputerAssetMasterID, req.PartTypeID, nil); err != nil {
+		if limit, err = s.ensureActiveConfigurationAvailable(ctx, req.PartAssetMasterID, req.ComputerAssetMasterID, req.PartTypeID, nil); err != nil {
 			return ComputerConfigurationResponse{}, err
 		}
 	}
@@ -200,8 +369,16 @@ func (s *Service) CreateComputerConfiguration(ctx context.Context, req CreateCom
 		InstalledAt:           installedAt,
 		RemovedAt:             removedAt,
 		Note:                  normalizeOptionalString(req.Note),
+		SlotLimit:             limit.MaxCount,
 	})
 	if err != nil {
+		// 事前チェックの後に同時の取り付けで埋まった
+		switch {
+		case errors.Is(err, errPartInUse):
+			return ComputerConfigurationResponse{}, ErrConflict("part asset is already assigned to an active computer configuration")
+		case errors.Is(err, errSlotInUse):
+			return ComputerConfigurationResponse{}, ErrConflict(slotLimitExceededMessage(limit))
+		}
 		return ComputerConfigurationResponse{}, mapCreateMySQLError(err, "computer configuration already exists", "invalid computer configuration reference")
 	}
 	return *out, nil
@@ -218,6 +395,13 @@ func (s *Service) ListComputerConfigurations(ctx context.Context, computerAssetM
 	return s.store.ListComputerConfigurationsByComputerAssetMasterID(ctx, computerAssetMasterID)
 }
 
+func (s *Service) SearchComputerConfigurations(ctx context.Context, p Page, f ComputerConfigurationFilter) ([]ComputerConfigurationResponse, int64, error) {
+	if f.InstalledFrom != nil && f.InstalledTo != nil && !f.InstalledFrom.Before(*f.InstalledTo) {
+		return nil, 0, ErrInvalid("installed_from must be before installed_to")
+	}
+	return s.store.ListComputerConfigurations(ctx, normalizePage(p), f)
+}
+
 func (s *Service) UpdateComputerConfiguration(ctx context.Context, computerConfigurationID uint64, req UpdateComputerConfigurationRequest) (ComputerConfigurationResponse, error) {
 	current, err := s.store.GetComputerConfigurationByID(ctx, computerConfigurationID)
 	if err != nil {
@@ -277,7 +461,7 @@ func (s *Service) UpdateComputerConfiguration(ctx context.Context, computerConfi
 		return ComputerConfigurationResponse{}, err
 	}
 	if resolved.RemovedAt == nil {
-		if err := s.ensureActiveConfigurationAvailable(ctx, resolved.PartAssetMasterID, resolved.ComputerAssetMasterID, resolved.PartTypeID, &computerConfigurationID); err != nil {
+		if _, err := s.ensureActiveConfigurationAvailable(ctx, resolved.PartAssetMasterID, resolved.ComputerAssetMasterID, resolved.PartTypeID, &computerConfigurationID); err != nil {
 			return ComputerConfigurationResponse{}, err
 		}
 	}
@@ -298,12 +482,743 @@ func (s *Service) UpdateComputerConfiguration(ctx context.Context, computerConfi
 	return *out, nil
 }
 
-func (s *Service) ListPartTypes(ctx context.Context) ([]PartTypeResponse, error) {
-	return s.store.ListPartTypes(ctx)
+// SwapComputerPart は稼働中の部品を同じ部品種別の別部品に入れ替える。
+// 取り外し・取り付け・旧部品の usage_status 更新は store 側で1トランザクションにまとめる
+func (s *Service) SwapComputerPart(ctx context.Context, computerAssetMasterID uint64, req CreatePartSwapRequest, swappedBy string) (PartSwapResponse, error) {
+	if req.RemovedPartAssetMasterID == 0 || req.InstalledPartAssetMasterID == 0 || req.RemovedUsageStatusID == 0 {
+		return PartSwapResponse{}, ErrInvalid("removed_part_asset_master_id, installed_part_asset_master_id, removed_usage_status_id are required")
+	}
+	if req.RemovedPartAssetMasterID == req.InstalledPartAssetMasterID {
+		return PartSwapResponse{}, ErrInvalid("installed part must differ from removed part")
+	}
+	installed, err := s.store.GetComputerPartByAssetMasterID(ctx, req.InstalledPartAssetMasterID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return PartSwapResponse{}, ErrInvalid("installed part is not registered as a computer part")
+		}
+		return PartSwapResponse{}, err
+	}
+	if err := s.requireUsageStatus(ctx, req.RemovedUsageStatusID); err != nil {
+		return PartSwapResponse{}, err
+	}
+	if _, err := s.store.GetComputerPartByAssetMasterID(ctx, req.RemovedPartAssetMasterID); err != nil {
+		if err == sql.ErrNoRows {
+			return PartSwapResponse{}, ErrInvalid("removed part is not registered as a computer part")
+		}
+		return PartSwapResponse{}, err
+	}
+
+	swappedAt, err := parseOptionalDateInput("swapped_at", req.SwappedAt)
+	if err != nil {
+		return PartSwapResponse{}, err
+	}
+	if swappedAt == nil {
+		today := s.clock.Now().Truncate(24 * time.Hour)
+		swappedAt = &today
+	}
+
+	current, err := s.store.GetActiveConfigurationForPart(ctx, computerAssetMasterID, req.RemovedPartAssetMasterID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return PartSwapResponse{}, ErrNotFound("removed part is not installed in this computer")
+		}
+		return PartSwapResponse{}, err
+	}
+	if err := validateConfigurationDates(current.InstalledAt, swappedAt); err != nil {
+		return PartSwapResponse{}, ErrInvalid("swapped_at must be on or after the removed part's installed_at")
+	}
+	// 種別が登録されていない部品は、取り付け先の構成の種別に従う
+	if installed.PartTypeID != nil && *installed.PartTypeID != current.PartTypeID {
+		return PartSwapResponse{}, ErrInvalid(fmt.Sprintf("installed part must have part_type_id %d like the removed part", current.PartTypeID))
+	}
+	// 旧部品の構成行を除外して通常の取り付けと同じ検証を通す（枠は交換で空く前提）
+	limit, err := s.ensureActiveConfigurationAvailable(ctx, req.InstalledPartAssetMasterID, computerAssetMasterID, current.PartTypeID, &current.ComputerConfigurationID)
+	if err != nil {
+		return PartSwapResponse{}, err
+	}
+
+	out, err := s.store.SwapComputerPart(ctx, partSwapInput{
+		ComputerAssetMasterID:      computerAssetMasterID,
+		PartTypeID:                 current.PartTypeID,
+		RemovedConfigurationID:     current.ComputerConfigurationID,
+		RemovedPartAssetMasterID:   req.RemovedPartAssetMasterID,
+		InstalledPartAssetMasterID: req.InstalledPartAssetMasterID,
+		RemovedUsageStatusID:       req.RemovedUsageStatusID,
+		SlotLimit:                  limit.MaxCount,
+		Reason:                     normalizeOptionalString(req.Reason),
+		SwappedAt:                  *swappedAt,
+		SwappedBy:                  swappedBy,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errSwapConfigurationClosed):
+			return PartSwapResponse{}, ErrConflict("removed part was uninstalled by another operation")
+		case errors.Is(err, errPartInUse):
+			return PartSwapResponse{}, ErrConflict("part asset is already assigned to an active computer configuration")
+		case errors.Is(err, errSlotInUse):
+			return PartSwapResponse{}, ErrConflict(slotLimitExceededMessage(limit))
+		}
+		return PartSwapResponse{}, mapCreateMySQLError(err, "part swap conflicts with an existing configuration", "invalid part swap reference")
+	}
+	return *out, nil
+}
+
+// GetComputerBOM は端末の部品構成を部品種別ごとにまとめ、rollup 項目を合計して返す。
+// at（YYYY-MM-DD / RFC3339）を指定すると当時の構成を復元する
+func (s *Service) GetComputerBOM(ctx context.Context, computerAssetMasterID uint64, rawAt string) (ComputerBOMResponse, error) {
+	exists, err := s.store.AssetMasterExists(ctx, computerAssetMasterID)
+	if err != nil {
+		return ComputerBOMResponse{}, err
+	}
+	if !exists {
+		return ComputerBOMResponse{}, ErrNotFound("computer asset master not found")
+	}
+
+	var at *time.Time
+	if strings.TrimSpace(rawAt) != "" {
+		t, err := s.parseAt(rawAt)
+		if err != nil {
+			return ComputerBOMResponse{}, err
+		}
+		at = &t
+	}
+
+	entries, err := s.store.ListBOMEntries(ctx, computerAssetMasterID, at)
+	if err != nil {
+		return ComputerBOMResponse{}, err
+	}
+	nodes, ramGB, storageGB := buildBOM(entries)
+	return ComputerBOMResponse{
+		ComputerAssetMasterID: computerAssetMasterID,
+		At:                    at,
+		RAMGB:                 ramGB,
+		StorageGB:             storageGB,
+		PartTypes:             nodes,
+	}, nil
+}
+
+// ListPartTypes は all が真なら無効化済みも含めて返す。label は lang（ja / en）で選ぶ
+func (s *Service) ListPartTypes(ctx context.Context, all string, lang string) ([]PartTypeResponse, error) {
+	out, err := s.store.ListPartTypes(ctx, parseBoolish(all))
+	if err != nil {
+		return nil, err
+	}
+	for i := range out {
+		out[i].Label = localizedLabel(out[i].DisplayName, out[i].DisplayNameEN, lang)
+	}
+	return out, nil
+}
+
+func (s *Service) CreatePartType(ctx context.Context, req CreatePartTypeRequest, lang string) (PartTypeResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	displayName := strings.TrimSpace(req.DisplayName)
+	if name == "" || displayName == "" {
+		return PartTypeResponse{}, ErrInvalid("name and display_name are required")
+	}
+	schema := req.SpecSchema
+	if schema == nil {
+		schema = []PartSpecField{}
+	}
+	if err := validateSpecSchema(schema); err != nil {
+		return PartTypeResponse{}, err
+	}
+
+	out, err := s.store.CreatePartType(ctx, createPartTypeInput{
+		Name:          name,
+		DisplayName:   displayName,
+		DisplayNameEN: normalizeOptionalString(req.DisplayNameEN),
+		SpecSchema:    schema,
+		Note:          normalizeOptionalString(req.Note),
+	})
+	if err != nil {
+		return PartTypeResponse{}, mapCreateMySQLError(err, "part type name already exists", "invalid part type")
+	}
+	out.Label = localizedLabel(out.DisplayName, out.DisplayNameEN, lang)
+	return *out, nil
+}
+
+func (s *Service) UpdatePartType(ctx context.Context, partTypeID uint, req UpdatePartTypeRequest, lang string) (PartTypeResponse, error) {
+	patch := updatePartTypeInput{
+		DisplayNameEN: normalizeNullableStringField(req.DisplayNameEN),
+		SpecSchema:    req.SpecSchema,
+		Note:          normalizeNullableStringField(req.Note),
+		IsDisabled:    req.IsDisabled,
+	}
+	var err error
+	if patch.Name, err = requiredTrimmed("name", req.Name); err != nil {
+		return PartTypeResponse{}, err
+	}
+	if patch.DisplayName, err = requiredTrimmed("display_name", req.DisplayName); err != nil {
+		return PartTypeResponse{}, err
+	}
+	if patch.SpecSchema != nil {
+		if err := validateSpecSchema(*patch.SpecSchema); err != nil {
+			return PartTypeResponse{}, err
+		}
+	}
+
+	out, err := s.store.UpdatePartTypeByID(ctx, partTypeID, patch)
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			return PartTypeResponse{}, ErrNotFound("part type not found")
+		case errors.Is(err, errReferenceInUse):
+			return PartTypeResponse{}, ErrConflict("part type is used by active computer configurations")
+		}
+		return PartTypeResponse{}, mapCreateMySQLError(err, "part type name already exists", "invalid part type")
+	}
+	out.Label = localizedLabel(out.DisplayName, out.DisplayNameEN, lang)
+	return *out, nil
+}
+
+func (s *Service) ListUsageStatuses(ctx context.Context, all string, lang string) ([]UsageStatusResponse, error) {
+	out, err := s.store.ListUsageStatuses(ctx, parseBoolish(all))
+	if err != nil {
+		return nil, err
+	}
+	for i := range out {
+		out[i].Label = localizedLabel(out[i].DisplayName, out[i].DisplayNameEN, lang)
+	}
+	return out, nil
+}
+
+func (s *Service) CreateUsageStatus(ctx context.Context, req CreateUsageStatusRequest, lang string) (UsageStatusResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	displayName := strings.TrimSpace(req.DisplayName)
+	if name == "" || displayName == "" {
+		return UsageStatusResponse{}, ErrInvalid("name and display_name are required")
+	}
+
+	out, err := s.store.CreateUsageStatus(ctx, createUsageStatusInput{
+		Name:          name,
+		DisplayName:   displayName,
+		DisplayNameEN: normalizeOptionalString(req.DisplayNameEN),
+		Note:          normalizeOptionalString(req.Note),
+	})
+	if err != nil {
+		return UsageStatusResponse{}, mapCreateMySQLError(err, "usage status name already exists", "invalid usage status")
+	}
+	out.Label = localizedLabel(out.DisplayName, out.DisplayNameEN, lang)
+	return *out, nil
+}
+
+func (s *Service) UpdateUsageStatus(ctx context.Context, usageStatusID uint, req UpdateUsageStatusRequest, lang string) (UsageStatusResponse, error) {
+	patch := updateUsageStatusInput{
+		DisplayNameEN: normalizeNullableStringField(req.DisplayNameEN),
+		Note:          normalizeNullableStringField(req.Note),
+		IsDisabled:    req.IsDisabled,
+	}
+	var err error
+	if patch.Name, err = requiredTrimmed("name", req.Name); err != nil {
+		return UsageStatusResponse{}, err
+	}
+	if patch.DisplayName, err = requiredTrimmed("display_name", req.DisplayName); err != nil {
+		return UsageStatusResponse{}, err
+	}
+
+	out, err := s.store.UpdateUsageStatusByID(ctx, usageStatusID, patch)
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			return UsageStatusResponse{}, ErrNotFound("usage status not found")
+		case errors.Is(err, errReferenceInUse):
+			return UsageStatusResponse{}, ErrConflict("usage status is assigned to computer parts")
+		}
+		return UsageStatusResponse{}, mapCreateMySQLError(err, "usage status name already exists", "invalid usage status")
+	}
+	out.Label = localizedLabel(out.DisplayName, out.DisplayNameEN, lang)
+	return *out, nil
+}
+
+// IngestComputerReport はエージェントの自己申告を serial → MAC の順で資産に突き合わせる。
+// 一意に決まれば computer_details に反映し、決まらなければレビュー待ちに積む。
+func (s *Service) IngestComputerReport(ctx context.Context, req IngestComputerReportRequest, reportedBy string) (IngestComputerReportResponse, error) {
+	receivedAt := s.clock.Now()
+	in, err := buildIngestReportInput(req, reportedBy, receivedAt)
+	if err != nil {
+		return IngestComputerReportResponse{}, err
+	}
+
+	reason := ingestReasonNoMatch
+	if in.Serial != nil {
+		ids, err := s.store.FindAssetMasterIDsBySerial(ctx, *in.Serial)
+		if err != nil {
+			return IngestComputerReportResponse{}, err
+		}
+		if len(ids) == 1 {
+			return s.applyIngestReport(ctx, ids[0], ingestMatchedBySerial, in)
+		}
+		if len(ids) > 1 {
+			reason = ingestReasonAmbiguousSerial
+		}
+	}
+
+	ids, err := s.store.FindAssetMasterIDsByMAC(ctx, in.MACAddresses)
+	if err != nil {
+		return IngestComputerReportResponse{}, err
+	}
+	if len(ids) == 1 {
+		return s.applyIngestReport(ctx, ids[0], ingestMatchedByMAC, in)
+	}
+	if len(ids) > 1 {
+		reason = ingestReasonAmbiguousMAC
+	}
+
+	reviewID, err := s.store.QueueIngestReview(ctx, ingestReviewInput{
+		Report:      in,
+		Fingerprint: ingestFingerprint(in),
+		Reason:      reason,
+	})
+	if err != nil {
+		return IngestComputerReportResponse{}, err
+	}
+	return IngestComputerReportResponse{
+		Matched:      false,
+		ReviewID:     &reviewID,
+		ReviewReason: &reason,
+		ReceivedAt:   receivedAt,
+	}, nil
+}
+
+func (s *Service) ListIngestReviews(ctx context.Context, rawStatus string) ([]IngestReviewResponse, error) {
+	status := strings.ToLower(strings.TrimSpace(rawStatus))
+	switch status {
+	case "":
+		status = ingestReviewPending
+	case "all":
+		return s.store.ListIngestReviews(ctx, nil)
+	case ingestReviewPending, ingestReviewResolved, ingestReviewDismissed:
+	default:
+		return nil, ErrInvalid("status must be one of pending, resolved, dismissed, all")
+	}
+	return s.store.ListIngestReviews(ctx, &status)
+}
+
+func (s *Service) ResolveIngestReview(ctx context.Context, reviewID uint64, req ResolveIngestReviewRequest, resolvedBy string) (IngestReviewResponse, error) {
+	if strings.TrimSpace(resolvedBy) == "" {
+		return IngestReviewResponse{}, ErrInvalid("resolved_by is required")
+	}
+	review, err := s.store.GetIngestReviewByID(ctx, reviewID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return IngestReviewResponse{}, ErrNotFound("ingest review not found")
+		}
+		return IngestReviewResponse{}, err
+	}
+	if review.Status != ingestReviewPending {
+		return IngestReviewResponse{}, ErrConflict("ingest review already " + review.Status)
+	}
+
+	resolution := ingestReviewResolution{
+		ResolvedBy: resolvedBy,
+		ResolvedAt: s.clock.Now(),
+	}
+	if req.AssetMasterID != nil {
+		if err := s.requireAssetMaster(ctx, *req.AssetMasterID, "asset_master_id not found"); err != nil {
+			return IngestReviewResponse{}, err
+		}
+		in, err := buildIngestReportInput(review.Report, review.ReportedBy, review.LastSeenAt)
+		if err != nil {
+			return IngestReviewResponse{}, err
+		}
+		resolution.AssetMasterID = req.AssetMasterID
+		resolution.Report = &in
+	}
+
+	out, err := s.store.ResolveIngestReview(ctx, reviewID, resolution)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return IngestReviewResponse{}, ErrNotFound("ingest review not found")
+		}
+		if errors.Is(err, errIngestReviewClosed) {
+			return IngestReviewResponse{}, ErrConflict("ingest review already closed")
+		}
+		if conflict := addressConflictError(err); conflict != nil {
+			return IngestReviewResponse{}, conflict
+		}
+		return IngestReviewResponse{}, err
+	}
+	return *out, nil
+}
+
+func (s *Service) applyIngestReport(ctx context.Context, assetMasterID uint64, matchedBy string, in ingestReportInput) (IngestComputerReportResponse, error) {
+	if current, err := s.store.GetComputerDetailByAssetMasterID(ctx, assetMasterID); err == nil {
+		in.Addresses = keepInterfaceNames(in.Addresses, current.Addresses)
+	} else if err != sql.ErrNoRows {
+		return IngestComputerReportResponse{}, err
+	}
+
+	detail, err := s.store.ApplyIngestReport(ctx, assetMasterID, matchedBy, in)
+	if err != nil {
+		if addressConflictError(err) == nil {
+			return IngestComputerReportResponse{}, err
+		}
+		// 他の端末のアドレスを名乗っている報告は自動で反映せず人に見てもらう
+		reason := ingestReasonAddressConflict
+		reviewID, err := s.store.QueueIngestReview(ctx, ingestReviewInput{
+			Report:      in,
+			Fingerprint: ingestFingerprint(in),
+			Reason:      reason,
+		})
+		if err != nil {
+			return IngestComputerReportResponse{}, err
+		}
+		return IngestComputerReportResponse{
+			Matched:       false,
+			AssetMasterID: &assetMasterID,
+			ReviewID:      &reviewID,
+			ReviewReason:  &reason,
+			ReceivedAt:    in.ReceivedAt,
+		}, nil
+	}
+	return IngestComputerReportResponse{
+		Matched:       true,
+		MatchedBy:     &matchedBy,
+		AssetMasterID: &assetMasterID,
+		Detail:        detail,
+		ReceivedAt:    in.ReceivedAt,
+	}, nil
+}
+
+// buildIngestReportInput は自己申告を正規化する。正規化後の内容を payload として保存する
+func buildIngestReportInput(req IngestComputerReportRequest, reportedBy string, receivedAt time.Time) (ingestReportInput, error) {
+	req.Hostname = strings.TrimSpace(req.Hostname)
+	if req.Hostname == "" {
+		return ingestReportInput{}, ErrInvalid("hostname is required")
+	}
+
+	macs := make([]string, 0, len(req.MACAddresses))
+	seen := make(map[string]struct{}, len(req.MACAddresses))
+	for _, raw := range req.MACAddresses {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		mac, err := normalizeMACAddress(raw)
+		if err != nil {
+			return ingestReportInput{}, err
+		}
+		if _, ok := seen[mac]; ok {
+			continue
+		}
+		seen[mac] = struct{}{}
+		macs = append(macs, mac)
+	}
+	req.MACAddresses = macs
+
+	req.IPAddress = normalizeOptionalString(req.IPAddress)
+	if req.IPAddress != nil {
+		ip, err := normalizeIPAddress(*req.IPAddress)
+		if err != nil {
+			return ingestReportInput{}, err
+		}
+		req.IPAddress = &ip
+	}
+	req.OS = normalizeOptionalString(req.OS)
+	req.OSBuild = normalizeOptionalString(req.OSBuild)
+	req.CPU = normalizeOptionalString(req.CPU)
+	req.LoginUser = normalizeOptionalString(req.LoginUser)
+	req.Serial = normalizeSerial(req.Serial)
+
+	disks := make([]IngestDiskReport, 0, len(req.Disks))
+	var storageGB float64
+	for _, d := range req.Disks {
+		d.Name = strings.TrimSpace(d.Name)
+		if d.Name == "" {
+			return ingestReportInput{}, ErrInvalid("disk name is required")
+		}
+		if d.SizeGB < 0 {
+			return ingestReportInput{}, ErrInvalid("disk size_gb must be >= 0")
+		}
+		d.Model = normalizeOptionalString(d.Model)
+		storageGB += d.SizeGB
+		disks = append(disks, d)
+	}
+	req.Disks = disks
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return ingestReportInput{}, err
+	}
+
+	in := ingestReportInput{
+		Hostname:     req.Hostname,
+		IPAddress:    req.IPAddress,
+		MACAddresses: macs,
+		OS:           joinOS(req.OS, req.OSBuild),
+		LoginUser:    req.LoginUser,
+		Serial:       req.Serial,
+		CPU:          req.CPU,
+		RAMMB:        req.RAMMB,
+		Payload:      payload,
+		ReportedBy:   reportedBy,
+		ReceivedAt:   receivedAt,
+	}
+	if len(disks) > 0 {
+		b, err := json.Marshal(disks)
+		if err != nil {
+			return ingestReportInput{}, err
+		}
+		diskJSON := string(b)
+		in.Disks, in.StorageGB = &diskJSON, &storageGB
+	}
+	if len(macs) > 0 {
+		in.MACAddress = &macs[0]
+	}
+	// 報告に NIC が1つも無ければ既存の NIC 一覧は触らない（nil）
+	for i := range macs {
+		a := computerAddressInput{MACAddress: &macs[i], IsPrimary: i == 0}
+		if i == 0 {
+			a.IPAddress = req.IPAddress
+		}
+		in.Addresses = append(in.Addresses, a)
+	}
+	if len(macs) == 0 && req.IPAddress != nil {
+		in.Addresses = []computerAddressInput{{IPAddress: req.IPAddress, IsPrimary: true}}
+	}
+	return in, nil
+}
+
+// parseAt は RFC3339 か YYYY-MM-DD（その日の 00:00 UTC）を受け付ける。空なら現在時刻
+func (s *Service) parseAt(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return s.clock.Now(), nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, ErrInvalid("at must be YYYY-MM-DD or RFC3339")
+	}
+	return t.UTC(), nil
+}
+
+// addressConflictError は書き込み時に見つかったアドレスの重複を 409 にする。重複でなければ nil
+func addressConflictError(err error) error {
+	var inUse *addressInUseError
+	if !errors.As(err, &inUse) {
+		return nil
+	}
+	return ErrConflict(inUse.Error())
+}
+
+// buildAddressSet は NIC 一覧を正規化して主 NIC を先頭に並べる。
+// addresses が無ければ単一の mac/ip を主 NIC として扱う
+func buildAddressSet(rawMAC, rawIP *string, reqs []ComputerAddressRequest) ([]computerAddressInput, error) {
+	legacyMAC, err := normalizeOptionalMAC(rawMAC)
+	if err != nil {
+		return nil, err
+	}
+	legacyIP, err := normalizeOptionalIP(rawIP)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(reqs) == 0 {
+		if legacyMAC == nil && legacyIP == nil {
+			return []computerAddressInput{}, nil
+		}
+		return []computerAddressInput{{MACAddress: legacyMAC, IPAddress: legacyIP, IsPrimary: true}}, nil
+	}
+
+	out := make([]computerAddressInput, 0, len(reqs))
+	seenMAC := make(map[string]struct{}, len(reqs))
+	seenIP := make(map[string]struct{}, len(reqs))
+	primaryIdx := -1
+	for i, r := range reqs {
+		mac, err := normalizeOptionalMAC(r.MACAddress)
+		if err != nil {
+			return nil, err
+		}
+		ip, err := normalizeOptionalIP(r.IPAddress)
+		if err != nil {
+			return nil, err
+		}
+		if mac == nil && ip == nil {
+			return nil, ErrInvalid("each address needs mac_address or ip_address")
+		}
+		if mac != nil {
+			if _, ok := seenMAC[*mac]; ok {
+				return nil, ErrInvalid("duplicate mac_address " + *mac)
+			}
+			seenMAC[*mac] = struct{}{}
+		}
+		if ip != nil {
+			if _, ok := seenIP[*ip]; ok {
+				return nil, ErrInvalid("duplicate ip_address " + *ip)
+			}
+			seenIP[*ip] = struct{}{}
+		}
+		if r.IsPrimary {
+			if primaryIdx >= 0 {
+				return nil, ErrInvalid("only one address can be primary")
+			}
+			primaryIdx = i
+		}
+		out = append(out, computerAddressInput{
+			InterfaceName: normalizeOptionalString(r.InterfaceName),
+			MACAddress:    mac,
+			IPAddress:     ip,
+		})
+	}
+	if primaryIdx > 0 {
+		out[0], out[primaryIdx] = out[primaryIdx], out[0]
+	}
+	out[0].IsPrimary = true
+
+	if (legacyMAC != nil && !sameStringPtr(legacyMAC, out[0].MACAddress)) ||
+		(legacyIP != nil && !sameStringPtr(legacyIP, out[0].IPAddress)) {
+		return nil, ErrInvalid("ip_address/mac_address must match the primary address")
+	}
+	return out, nil
+}
+
+func primaryAddress(addrs []computerAddressInput) (mac, ip *string) {
+	if len(addrs) == 0 {
+		return nil, nil
+	}
+	return addrs[0].MACAddress, addrs[0].IPAddress
+}
+
+// keepInterfaceNames はエージェントが知らないインターフェース名を、同じ MAC の既存 NIC から引き継ぐ
+func keepInterfaceNames(addrs []computerAddressInput, current []ComputerAddressResponse) []computerAddressInput {
+	for i := range addrs {
+		if addrs[i].MACAddress == nil {
+			continue
+		}
+		for _, c := range current {
+			if c.MACAddress != nil && *c.MACAddress == *addrs[i].MACAddress {
+				addrs[i].InterfaceName = c.InterfaceName
+				break
+			}
+		}
+	}
+	return addrs
+}
+
+func normalizeOptionalMAC(raw *string) (*string, error) {
+	v := normalizeOptionalString(raw)
+	if v == nil {
+		return nil, nil
+	}
+	mac, err := normalizeMACAddress(*v)
+	if err != nil {
+		return nil, err
+	}
+	return &mac, nil
+}
+
+func normalizeOptionalIP(raw *string) (*string, error) {
+	v := normalizeOptionalString(raw)
+	if v == nil {
+		return nil, nil
+	}
+	ip, err := normalizeIPAddress(*v)
+	if err != nil {
+		return nil, err
+	}
+	return &ip, nil
+}
+
+func normalizeMACField(raw *string) (nullableStringField, error) {
+	f := normalizeNullableStringField(raw)
+	if f.Value == nil {
+		return f, nil
+	}
+	mac, err := normalizeMACAddress(*f.Value)
+	if err != nil {
+		return nullableStringField{}, err
+	}
+	f.Value = &mac
+	return f, nil
+}
+
+func normalizeIPField(raw *string) (nullableStringField, error) {
+	f := normalizeNullableStringField(raw)
+	if f.Value == nil {
+		return f, nil
+	}
+	ip, err := normalizeIPAddress(*f.Value)
+	if err != nil {
+		return nullableStringField{}, err
+	}
+	f.Value = &ip
+	return f, nil
+}
+
+// normalizeIPAddress は IPv4 / IPv6 を netip で解釈して正規表記にする（IPv4-mapped IPv6 は IPv4 に戻す）
+func normalizeIPAddress(raw string) (string, error) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(raw))
+	if err != nil {
+		return "", ErrInvalid("invalid ip address: " + raw)
+	}
+	return addr.Unmap().String(), nil
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeMACAddress は aa-bb-.. / AABB.CCDD.. などを小文字コロン区切りに揃える
+func normalizeMACAddress(raw string) (string, error) {
+	hw, err := net.ParseMAC(strings.TrimSpace(raw))
+	if err != nil || len(hw) != 6 {
+		return "", ErrInvalid("invalid mac address: " + raw)
+	}
+	return strings.ToLower(hw.String()), nil
+}
+
+// BIOS が埋めずに出荷した既定値はシリアルとして扱わない
+var placeholderSerials = map[string]struct{}{
+	"to be filled by o.e.m.": {},
+	"default string":         {},
+	"system serial number":   {},
+	"not specified":          {},
+	"none":                   {},
+	"0":                      {},
+}
+
+func normalizeSerial(raw *string) *string {
+	v := normalizeOptionalString(raw)
+	if v == nil {
+		return nil
+	}
+	if _, ok := placeholderSerials[strings.ToLower(*v)]; ok {
+		return nil
+	}
+	return v
+}
+
+func joinOS(osName, osBuild *string) *string {
+	switch {
+	case osName == nil && osBuild == nil:
+		return nil
+	case osBuild == nil:
+		return osName
+	case osName == nil:
+		return osBuild
+	}
+	v := *osName + " (" + *osBuild + ")"
+	return &v
 }
 
-func (s *Service) ListUsageStatuses(ctx context.Context) ([]UsageStatusResponse, error) {
-	return s.store.ListUsageStatuses(ctx)
+// ingestFingerprint は未照合マシンのレビューを同一マシン単位でまとめるためのキー
+func ingestFingerprint(in ingestReportInput) string {
+	if in.Serial != nil {
+		return "serial:" + *in.Serial
+	}
+	if len(in.MACAddresses) > 0 {
+		macs := append([]string(nil), in.MACAddresses...)
+		sort.Strings(macs)
+		return "mac:" + macs[0]
+	}
+	return "host:" + strings.ToLower(in.Hostname)
 }
 
 func (s *Service) requireAssetMaster(ctx context.Context, assetMasterID uint64, msg string) error {
@@ -339,25 +1254,252 @@ func (s *Service) requirePartType(ctx context.Context, partTypeID uint) error {
 	return nil
 }
 
-func (s *Service) ensureActiveConfigurationAvailable(ctx context.Context, partAssetMasterID uint64, computerAssetMasterID uint64, partTypeID uint, excludeID *uint64) error {
-	partInUse, err := s.store.ActiveConfigurationExistsForPart(ctx, partAssetMasterID, excludeID)
+func (s *Service) validatePartSpec(ctx context.Context, partTypeID *uint, attrs map[string]any) error {
+	if partTypeID == nil {
+		if len(attrs) > 0 {
+			return ErrInvalid("part_type_id is required when spec_attributes is given")
+		}
+		return nil
+	}
+	if *partTypeID == 0 {
+		return ErrInvalid("part_type_id must be greater than 0")
+	}
+	schema, err := s.store.GetPartTypeSpecSchema(ctx, *partTypeID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrInvalid("part_type_id not found")
+		}
 		return err
 	}
+	return validateSpecAttributes(schema, attrs)
+}
+
+// ensureActiveConfigurationAvailable は部品を端末に取り付けられるかを検証し、適用した搭載上限を返す。
+// 部品の二重取り付け・機種ごとの搭載上限・互換性ルールの順に見る
+func (s *Service) ensureActiveConfigurationAvailable(ctx context.Context, partAssetMasterID uint64, computerAssetMasterID uint64, partTypeID uint, excludeID *uint64) (slotLimit, error) {
+	partInUse, err := s.store.ActiveConfigurationExistsForPart(ctx, partAssetMasterID, excludeID)
+	if err != nil {
+		return slotLimit{}, err
+	}
 	if partInUse {
-		return ErrConflict("part asset is already assigned to an active computer configuration")
+		return slotLimit{}, ErrConflict("part asset is already assigned to an active computer configuration")
+	}
+
+	limit, err := s.store.GetSlotLimit(ctx, computerAssetMasterID, partTypeID)
+	if err != nil {
+		return slotLimit{}, err
+	}
+	active, err := s.store.CountActiveConfigurationsForComputerPartType(ctx, computerAssetMasterID, partTypeID, excludeID)
+	if err != nil {
+		return slotLimit{}, err
+	}
+	if uint(active) >= limit.MaxCount {
+		return slotLimit{}, ErrConflict(slotLimitExceededMessage(limit))
+	}
+
+	if err := s.ensureCompatible(ctx, partAssetMasterID, computerAssetMasterID, partTypeID, excludeID); err != nil {
+		return slotLimit{}, err
+	}
+	return limit, nil
+}
+
+// slotLimitExceededMessage は搭載上限の 409 に機種・部品種別・上限を載せる
+func slotLimitExceededMessage(limit slotLimit) string {
+	if limit.Model == nil {
+		return fmt.Sprintf("slot limit reached: at most %d %s part(s) by default", limit.MaxCount, limit.PartTypeName)
+	}
+	return fmt.Sprintf("slot limit reached: model %s allows at most %d %s part(s)", *limit.Model, limit.MaxCount, limit.PartTypeName)
+}
+
+func (s *Service) ensureCompatible(ctx context.Context, partAssetMasterID uint64, computerAssetMasterID uint64, partTypeID uint, excludeID *uint64) error {
+	rules, err := s.store.ListApplicableCompatibilityRules(ctx, computerAssetMasterID, partTypeID)
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+
+	var attrs map[string]any
+	part, err := s.store.GetComputerPartByAssetMasterID(ctx, partAssetMasterID)
+	switch {
+	case err == nil:
+		attrs = part.SpecAttributes
+	case err != sql.ErrNoRows:
+		return err
+	}
+
+	entries, err := s.store.ListBOMEntries(ctx, computerAssetMasterID, nil)
+	if err != nil {
+		return err
 	}
+	installed := make([]map[string]any, 0, len(entries))
+	for _, e := range entries {
+		if e.PartTypeID != partTypeID || (excludeID != nil && e.Part.ComputerConfigurationID == *excludeID) {
+			continue
+		}
+		installed = append(installed, e.Part.SpecAttributes)
+	}
+	return evaluateCompatibility(rules, attrs, installed)
+}
+
+func (s *Service) ListPartSlotLimits(ctx context.Context, partTypeID *uint) ([]PartSlotLimitResponse, error) {
+	return s.store.ListPartSlotLimits(ctx, partTypeID)
+}
+
+func (s *Service) CreatePartSlotLimit(ctx context.Context, req PartSlotLimitRequest) (PartSlotLimitResponse, error) {
+	in, err := s.buildPartSlotLimitInput(ctx, req)
+	if err != nil {
+		return PartSlotLimitResponse{}, err
+	}
+	out, err := s.store.CreatePartSlotLimit(ctx, in)
+	if err != nil {
+		return PartSlotLimitResponse{}, mapCreateMySQLError(err, "slot limit for this model and part_type_id already exists", "invalid part_type_id")
+	}
+	return *out, nil
+}
 
-	slotInUse, err := s.store.ActiveConfigurationExistsForComputerPartType(ctx, computerAssetMasterID, partTypeID, excludeID)
+func (s *Service) UpdatePartSlotLimit(ctx context.Context, slotLimitID uint64, req PartSlotLimitRequest) (PartSlotLimitResponse, error) {
+	in, err := s.buildPartSlotLimitInput(ctx, req)
+	if err != nil {
+		return PartSlotLimitResponse{}, err
+	}
+	out, err := s.store.UpdatePartSlotLimitByID(ctx, slotLimitID, in)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return PartSlotLimitResponse{}, ErrNotFound("slot limit not found")
+		}
+		return PartSlotLimitResponse{}, mapCreateMySQLError(err, "slot limit for this model and part_type_id already exists", "invalid part_type_id")
+	}
+	return *out, nil
+}
+
+func (s *Service) DeletePartSlotLimit(ctx context.Context, slotLimitID uint64) error {
+	if err := s.store.DeletePartSlotLimitByID(ctx, slotLimitID); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound("slot limit not found")
+		}
 		return err
 	}
-	if slotInUse {
-		return ErrConflict("computer already has an active configuration for this part_type_id")
+	return nil
+}
+
+func (s *Service) buildPartSlotLimitInput(ctx context.Context, req PartSlotLimitRequest) (partSlotLimitInput, error) {
+	if req.PartTypeID == 0 {
+		return partSlotLimitInput{}, ErrInvalid("part_type_id is required")
+	}
+	if req.MaxCount < 1 {
+		return partSlotLimitInput{}, ErrInvalid("max_count must be >= 1")
+	}
+	if err := s.requirePartType(ctx, req.PartTypeID); err != nil {
+		return partSlotLimitInput{}, err
+	}
+	return partSlotLimitInput{
+		Model:      normalizeOptionalString(req.Model),
+		PartTypeID: req.PartTypeID,
+		MaxCount:   req.MaxCount,
+		Note:       normalizeOptionalString(req.Note),
+	}, nil
+}
+
+func (s *Service) ListCompatibilityRules(ctx context.Context, partTypeID *uint) ([]CompatibilityRuleResponse, error) {
+	return s.store.ListCompatibilityRules(ctx, partTypeID)
+}
+
+func (s *Service) CreateCompatibilityRule(ctx context.Context, req CompatibilityRuleRequest) (CompatibilityRuleResponse, error) {
+	in, err := s.buildCompatibilityRuleInput(ctx, req)
+	if err != nil {
+		return CompatibilityRuleResponse{}, err
+	}
+	out, err := s.store.CreateCompatibilityRule(ctx, in)
+	if err != nil {
+		return CompatibilityRuleResponse{}, mapCreateMySQLError(err, "compatibility rule name already exists", "invalid part_type_id")
+	}
+	return *out, nil
+}
+
+func (s *Service) UpdateCompatibilityRule(ctx context.Context, ruleID uint64, req CompatibilityRuleRequest) (CompatibilityRuleResponse, error) {
+	in, err := s.buildCompatibilityRuleInput(ctx, req)
+	if err != nil {
+		return CompatibilityRuleResponse{}, err
+	}
+	out, err := s.store.UpdateCompatibilityRuleByID(ctx, ruleID, in)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return CompatibilityRuleResponse{}, ErrNotFound("compatibility rule not found")
+		}
+		return CompatibilityRuleResponse{}, mapCreateMySQLError(err, "compatibility rule name already exists", "invalid part_type_id")
+	}
+	return *out, nil
+}
+
+func (s *Service) DeleteCompatibilityRule(ctx context.Context, ruleID uint64) error {
+	if err := s.store.DeleteCompatibilityRuleByID(ctx, ruleID); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound("compatibility rule not found")
+		}
+		return err
 	}
 	return nil
 }
 
+func (s *Service) buildCompatibilityRuleInput(ctx context.Context, req CompatibilityRuleRequest) (compatibilityRuleInput, error) {
+	in := compatibilityRuleInput{
+		Name:       strings.TrimSpace(req.Name),
+		Model:      normalizeOptionalString(req.Model),
+		PartTypeID: req.PartTypeID,
+		Attribute:  strings.TrimSpace(req.Attribute),
+		Kind:       strings.ToLower(strings.TrimSpace(req.Kind)),
+		Note:       normalizeOptionalString(req.Note),
+	}
+	if in.Name == "" || in.Attribute == "" || in.PartTypeID == 0 {
+		return compatibilityRuleInput{}, ErrInvalid("name, part_type_id, attribute are required")
+	}
+
+	switch in.Kind {
+	case compatibilityAllowedValues:
+		for _, v := range req.AllowedValues {
+			if v = strings.TrimSpace(v); v != "" && !containsString(in.AllowedValues, v) {
+				in.AllowedValues = append(in.AllowedValues, v)
+			}
+		}
+		if len(in.AllowedValues) == 0 {
+			return compatibilityRuleInput{}, ErrInvalid("allowed_values is required for kind allowed_values")
+		}
+	case compatibilityMatchInstalled:
+		in.AllowedValues = []string{}
+	default:
+		return compatibilityRuleInput{}, ErrInvalid("kind must be allowed_values or match_installed")
+	}
+
+	schema, err := s.store.GetPartTypeSpecSchema(ctx, in.PartTypeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return compatibilityRuleInput{}, ErrInvalid("part_type_id not found")
+		}
+		return compatibilityRuleInput{}, err
+	}
+	if len(schema) > 0 && !specSchemaHasKey(schema, in.Attribute) {
+		return compatibilityRuleInput{}, ErrInvalid("attribute is not defined in the part type spec_schema")
+	}
+	return in, nil
+}
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+func normalizePage(p Page) Page {
+	if p.Limit <= 0 {
+		p.Limit = defaultPageLimit
+	}
+	if p.Limit > maxPageLimit {
+		p.Limit = maxPageLimit
+	}
+	if p.Offset < 0 {
+		p.Offset = 0
+	}
+	return p
+}
+
 func parseOptionalDateInput(field string, raw *string) (*time.Time, error) {
 	if raw == nil {
 		return nil, nil
@@ -390,6 +1532,31 @@ func parseNullableDateField(field string, raw *string) (nullableTimeField, error
 	return nullableTimeField{Set: true, Value: &t}, nil
 }
 
+// localizedLabel は lang=en なら英語名を、それ以外（既定 ja）や英語名未登録なら display_name を返す
+func localizedLabel(displayName string, displayNameEN *string, lang string) string {
+	if strings.EqualFold(strings.TrimSpace(lang), "en") && displayNameEN != nil {
+		return *displayNameEN
+	}
+	return displayName
+}
+
+func parseBoolish(s string) bool {
+	s = strings.TrimSpace(strings.ToLower(s))
+	return s == "1" || s == "true" || s == "yes" || s == "all"
+}
+
+// requiredTrimmed は更新時に指定された必須項目を trim し、空なら 400 にする
+func requiredTrimmed(field string, raw *string) (*string, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	v := strings.TrimSpace(*raw)
+	if v == "" {
+		return nil, ErrInvalid(field + " must not be empty")
+	}
+	return &v, nil
+}
+
 func validateConfigurationDates(installedAt, removedAt *time.Time) error {
 	if installedAt != nil && removedAt != nil && removedAt.Before(*installedAt) {
 		return ErrInvalid("removed_at must be on or after installed_at")
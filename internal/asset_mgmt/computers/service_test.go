@@ -3,6 +3,7 @@ package computers
 import (
 	"context"
 	"database/sql"
+	"strings"
 	"testing"
 	"time"
 )
@@ -139,6 +140,641 @@ func TestParseOptionalDateInputNormalizesUTC(t *testing.T) {
 	}
 }
 
+func TestIngestComputerReportMatchesBySerialFirst(t *testing.T) {
+	store := &fakeComputerStore{
+		serialMatches: []uint64{42},
+		macMatches:    []uint64{7},
+	}
+	svc := newServiceWithStore(store)
+
+	out, err := svc.IngestComputerReport(context.Background(), IngestComputerReportRequest{
+		Hostname:     "pc-01",
+		MACAddresses: []string{"AA-BB-CC-DD-EE-FF"},
+		Serial:       strPtr("SN123"),
+	}, "agent")
+	if err != nil {
+		t.Fatalf("IngestComputerReport returned error: %v", err)
+	}
+	if !out.Matched || store.appliedAssetID != 42 || store.appliedMatchedBy != ingestMatchedBySerial {
+		t.Fatalf("expected serial match to asset 42, got %#v (applied=%d by %q)", out, store.appliedAssetID, store.appliedMatchedBy)
+	}
+}
+
+func TestIngestComputerReportFallsBackToNormalizedMAC(t *testing.T) {
+	store := &fakeComputerStore{macMatches: []uint64{7}}
+	svc := newServiceWithStore(store)
+
+	out, err := svc.IngestComputerReport(context.Background(), IngestComputerReportRequest{
+		Hostname:     "pc-01",
+		MACAddresses: []string{"AA-BB-CC-DD-EE-FF", "aa:bb:cc:dd:ee:ff"},
+		Serial:       strPtr("To be filled by O.E.M."),
+	}, "agent")
+	if err != nil {
+		t.Fatalf("IngestComputerReport returned error: %v", err)
+	}
+	if !out.Matched || store.appliedMatchedBy != ingestMatchedByMAC {
+		t.Fatalf("expected mac match, got %#v", out)
+	}
+	if len(store.lastMACLookup) != 1 || store.lastMACLookup[0] != "aa:bb:cc:dd:ee:ff" {
+		t.Fatalf("expected one normalized mac lookup, got %v", store.lastMACLookup)
+	}
+}
+
+func TestIngestComputerReportMapsHardwareFacts(t *testing.T) {
+	store := &fakeComputerStore{serialMatches: []uint64{42}}
+	svc := newServiceWithStore(store)
+
+	ram := uint64(16384)
+	_, err := svc.IngestComputerReport(context.Background(), IngestComputerReportRequest{
+		Hostname: "pc-01",
+		Serial:   strPtr("SN123"),
+		CPU:      strPtr(" Intel Core i5-12400 "),
+		RAMMB:    &ram,
+		Disks: []IngestDiskReport{
+			{Name: "nvme0n1", Model: strPtr("Samsung 980"), SizeGB: 476.9},
+			{Name: "sda", SizeGB: 931.5},
+		},
+	}, "agent")
+	if err != nil {
+		t.Fatalf("IngestComputerReport returned error: %v", err)
+	}
+	in := store.appliedInput
+	if in == nil || in.CPU == nil || *in.CPU != "Intel Core i5-12400" || in.RAMMB == nil || *in.RAMMB != ram {
+		t.Fatalf("unexpected cpu/ram mapping: %+v", in)
+	}
+	if in.StorageGB == nil || *in.StorageGB < 1408.39 || *in.StorageGB > 1408.41 {
+		t.Fatalf("expected summed storage, got %v", in.StorageGB)
+	}
+	if in.Disks == nil || !strings.Contains(*in.Disks, `"name":"sda"`) {
+		t.Fatalf("expected disks json, got %v", in.Disks)
+	}
+
+	_, err = svc.IngestComputerReport(context.Background(), IngestComputerReportRequest{
+		Hostname: "pc-01",
+		Disks:    []IngestDiskReport{{Name: "sda", SizeGB: -1}},
+	}, "agent")
+	if apiErr, ok := err.(*APIError); !ok || apiErr.Code != CodeInvalidArgument {
+		t.Fatalf("expected INVALID_ARGUMENT for negative disk size, got %v", err)
+	}
+}
+
+func TestIngestComputerReportQueuesAmbiguousMatchForReview(t *testing.T) {
+	store := &fakeComputerStore{serialMatches: []uint64{1, 2}}
+	svc := newServiceWithStore(store)
+
+	out, err := svc.IngestComputerReport(context.Background(), IngestComputerReportRequest{
+		Hostname: "pc-02",
+		Serial:   strPtr("DUP"),
+	}, "agent")
+	if err != nil {
+		t.Fatalf("IngestComputerReport returned error: %v", err)
+	}
+	if out.Matched || out.ReviewID == nil {
+		t.Fatalf("expected review queue, got %#v", out)
+	}
+	if store.queuedReview == nil || store.queuedReview.Reason != ingestReasonAmbiguousSerial || store.queuedReview.Fingerprint != "serial:DUP" {
+		t.Fatalf("unexpected queued review: %#v", store.queuedReview)
+	}
+}
+
+func TestIngestComputerReportRejectsInvalidMAC(t *testing.T) {
+	svc := newServiceWithStore(&fakeComputerStore{})
+
+	_, err := svc.IngestComputerReport(context.Background(), IngestComputerReportRequest{
+		Hostname:     "pc-03",
+		MACAddresses: []string{"not-a-mac"},
+	}, "agent")
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.Code != CodeInvalidArgument {
+		t.Fatalf("expected INVALID_ARGUMENT, got %v", err)
+	}
+}
+
+func TestResolveIngestReviewRequiresResolver(t *testing.T) {
+	svc := newServiceWithStore(&fakeComputerStore{})
+
+	_, err := svc.ResolveIngestReview(context.Background(), 1, ResolveIngestReviewRequest{}, " ")
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.Code != CodeInvalidArgument {
+		t.Fatalf("expected INVALID_ARGUMENT without a resolver, got %v", err)
+	}
+}
+
+func TestFindComputerDetailsByMACNormalizesAndParsesAt(t *testing.T) {
+	store := &fakeComputerStore{}
+	svc := newServiceWithStore(store)
+
+	if _, err := svc.FindComputerDetailsByMAC(context.Background(), "AA-BB-CC-00-11-22", "2026-03-15"); err != nil {
+		t.Fatalf("FindComputerDetailsByMAC returned error: %v", err)
+	}
+	if store.lastHistoryLookup != "aa:bb:cc:00:11:22" {
+		t.Fatalf("expected normalized mac, got %q", store.lastHistoryLookup)
+	}
+	if !store.lastHistoryAt.Equal(time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected at: %v", store.lastHistoryAt)
+	}
+}
+
+func TestFindComputerDetailsByIPRejectsInvalidInput(t *testing.T) {
+	svc := newServiceWithStore(&fakeComputerStore{})
+
+	for _, tc := range []struct{ ip, at string }{
+		{"10.0.3.999", ""},
+		{"10.0.3.15", "March"},
+	} {
+		_, err := svc.FindComputerDetailsByIP(context.Background(), tc.ip, tc.at)
+		apiErr, ok := err.(*APIError)
+		if !ok || apiErr.Code != CodeInvalidArgument {
+			t.Fatalf("ip=%q at=%q: expected INVALID_ARGUMENT, got %v", tc.ip, tc.at, err)
+		}
+	}
+}
+
+func TestListComputerDetailsNormalizesMACAndClampsPage(t *testing.T) {
+	store := &fakeComputerStore{}
+	svc := newServiceWithStore(store)
+
+	_, _, err := svc.ListComputerDetails(context.Background(), Page{Limit: 1000, Offset: -5}, ComputerDetailFilter{
+		MACAddress: strPtr("AABB.CCDD.EEFF"),
+	})
+	if err != nil {
+		t.Fatalf("ListComputerDetails returned error: %v", err)
+	}
+	if store.lastDetailFilter.MACAddress == nil || *store.lastDetailFilter.MACAddress != "aa:bb:cc:dd:ee:ff" {
+		t.Fatalf("expected normalized mac filter, got %#v", store.lastDetailFilter.MACAddress)
+	}
+	if store.lastPage.Limit != maxPageLimit || store.lastPage.Offset != 0 {
+		t.Fatalf("expected clamped page, got %#v", store.lastPage)
+	}
+}
+
+func TestSearchComputerConfigurationsRejectsInvertedInstalledRange(t *testing.T) {
+	svc := newServiceWithStore(&fakeComputerStore{})
+	from := time.Date(2026, time.June, 10, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	_, _, err := svc.SearchComputerConfigurations(context.Background(), Page{}, ComputerConfigurationFilter{
+		InstalledFrom: &from,
+		InstalledTo:   &to,
+	})
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.Code != CodeInvalidArgument {
+		t.Fatalf("expected INVALID_ARGUMENT, got %v", err)
+	}
+}
+
+func TestCreateComputerDetailNormalizesAddressesAndPutsPrimaryFirst(t *testing.T) {
+	store := &fakeComputerStore{assetExists: true}
+	svc := newServiceWithStore(store)
+
+	_, err := svc.CreateComputerDetail(context.Background(), CreateComputerDetailRequest{
+		AssetMasterID: 1,
+		Addresses: []ComputerAddressRequest{
+			{InterfaceName: strPtr("wlan0"), MACAddress: strPtr("AA-BB-CC-00-11-33")},
+			{InterfaceName: strPtr("eth0"), MACAddress: strPtr("AABB.CC00.1122"), IPAddress: strPtr(" ::ffff:10.0.3.15 "), IsPrimary: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateComputerDetail returned error: %v", err)
+	}
+	in := store.createdDetail
+	if in == nil || len(in.Addresses) != 2 {
+		t.Fatalf("expected 2 addresses, got %+v", in)
+	}
+	if !in.Addresses[0].IsPrimary || *in.Addresses[0].InterfaceName != "eth0" {
+		t.Fatalf("expected eth0 as primary first, got %+v", in.Addresses[0])
+	}
+	if *in.MACAddress != "aa:bb:cc:00:11:22" || *in.IPAddress != "10.0.3.15" {
+		t.Fatalf("expected legacy columns to mirror normalized primary, got mac=%v ip=%v", *in.MACAddress, *in.IPAddress)
+	}
+}
+
+func TestCreateComputerDetailRejectsInvalidAddressSets(t *testing.T) {
+	cases := map[string][]ComputerAddressRequest{
+		"two primaries": {
+			{MACAddress: strPtr("aa:bb:cc:00:11:22"), IsPrimary: true},
+			{MACAddress: strPtr("aa:bb:cc:00:11:33"), IsPrimary: true},
+		},
+		"duplicate mac": {
+			{MACAddress: strPtr("aa:bb:cc:00:11:22")},
+			{MACAddress: strPtr("AA-BB-CC-00-11-22")},
+		},
+		"empty entry": {
+			{InterfaceName: strPtr("eth0")},
+		},
+		"invalid ip": {
+			{IPAddress: strPtr("10.0.3.256")},
+		},
+	}
+	for name, addrs := range cases {
+		store := &fakeComputerStore{assetExists: true}
+		svc := newServiceWithStore(store)
+
+		_, err := svc.CreateComputerDetail(context.Background(), CreateComputerDetailRequest{AssetMasterID: 1, Addresses: addrs})
+		apiErr, ok := err.(*APIError)
+		if !ok || apiErr.Code != CodeInvalidArgument {
+			t.Fatalf("%s: expected INVALID_ARGUMENT, got %v", name, err)
+		}
+		if store.createdDetail != nil {
+			t.Fatalf("%s: create should not be called", name)
+		}
+	}
+}
+
+func TestUpdateComputerDetailRejectsAddressUsedByAnotherAsset(t *testing.T) {
+	mac := "aa:bb:cc:00:11:22"
+	store := &fakeComputerStore{
+		addressErr: &addressInUseError{Field: "mac_address", Value: mac, ManagementNumber: "PC-0002"},
+	}
+	svc := newServiceWithStore(store)
+
+	_, err := svc.UpdateComputerDetail(context.Background(), 1, UpdateComputerDetailRequest{MACAddress: strPtr("AA:BB:CC:00:11:22")})
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.Code != CodeConflict {
+		t.Fatalf("expected CONFLICT, got %v", err)
+	}
+	if !strings.Contains(apiErr.Message, "PC-0002") {
+		t.Fatalf("expected conflicting management number in message, got %q", apiErr.Message)
+	}
+	if c := store.lastUpdateDetailPatch.Candidates; len(c) != 1 || c[0].MACAddress == nil || *c[0].MACAddress != "aa:bb:cc:00:11:22" {
+		t.Fatalf("expected normalized mac to be checked inside the update, got %+v", c)
+	}
+}
+
+func TestIngestComputerReportQueuesReviewOnAddressConflict(t *testing.T) {
+	mac := "aa:bb:cc:00:11:22"
+	store := &fakeComputerStore{
+		serialMatches: []uint64{1},
+		addressErr:    &addressInUseError{Field: "mac_address", Value: mac, ManagementNumber: "PC-0002"},
+	}
+	svc := newServiceWithStore(store)
+
+	out, err := svc.IngestComputerReport(context.Background(), IngestComputerReportRequest{
+		Hostname:     "pc-01",
+		Serial:       strPtr("SN-1"),
+		MACAddresses: []string{mac},
+	}, "agent")
+	if err != nil {
+		t.Fatalf("IngestComputerReport returned error: %v", err)
+	}
+	if out.Matched || store.queuedReview == nil || store.queuedReview.Reason != ingestReasonAddressConflict {
+		t.Fatalf("expected address_conflict review, got %+v / %+v", out, store.queuedReview)
+	}
+	if store.appliedAssetID != 0 {
+		t.Fatal("report should not be applied on address conflict")
+	}
+}
+
+func TestSwapComputerPartUsesRemovedPartTypeAndExcludesOldRow(t *testing.T) {
+	installedAt := time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)
+	store := &fakeComputerStore{
+		assetExists:       true,
+		usageStatusExists: true,
+		partResponse:      &ComputerPartResponse{AssetMasterID: 20},
+		activeConfiguration: &ComputerConfigurationResponse{
+			ComputerConfigurationID: 7,
+			ComputerAssetMasterID:   10,
+			PartAssetMasterID:       20,
+			PartTypeID:              3,
+			InstalledAt:             &installedAt,
+		},
+	}
+	svc := newServiceWithStore(store)
+
+	_, err := svc.SwapComputerPart(context.Background(), 10, CreatePartSwapRequest{
+		RemovedPartAssetMasterID:   20,
+		InstalledPartAssetMasterID: 21,
+		RemovedUsageStatusID:       4,
+		Reason:                     strPtr(" disk failure "),
+		SwappedAt:                  strPtr("2026-10-18"),
+	}, "u1")
+	if err != nil {
+		t.Fatalf("SwapComputerPart returned error: %v", err)
+	}
+	in := store.swapInput
+	if in == nil || in.PartTypeID != 3 || in.RemovedConfigurationID != 7 || in.InstalledPartAssetMasterID != 21 {
+		t.Fatalf("unexpected swap input: %+v", in)
+	}
+	if in.Reason == nil || *in.Reason != "disk failure" || in.SwappedBy != "u1" {
+		t.Fatalf("expected trimmed reason and swapped_by, got %+v", in)
+	}
+}
+
+func TestSwapComputerPartRejectsBusyReplacementAndUninstalledPart(t *testing.T) {
+	installedAt := time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)
+	req := CreatePartSwapRequest{RemovedPartAssetMasterID: 20, InstalledPartAssetMasterID: 21, RemovedUsageStatusID: 4}
+
+	busy := &fakeComputerStore{
+		assetExists:         true,
+		usageStatusExists:   true,
+		activePartExists:    true,
+		partResponse:        &ComputerPartResponse{AssetMasterID: 20},
+		activeConfiguration: &ComputerConfigurationResponse{ComputerConfigurationID: 7, PartTypeID: 3, InstalledAt: &installedAt},
+	}
+	_, err := newServiceWithStore(busy).SwapComputerPart(context.Background(), 10, req, "")
+	if apiErr, ok := err.(*APIError); !ok || apiErr.Code != CodeConflict {
+		t.Fatalf("expected CONFLICT for busy replacement, got %v", err)
+	}
+
+	notInstalled := &fakeComputerStore{
+		assetExists:       true,
+		usageStatusExists: true,
+		partResponse:      &ComputerPartResponse{AssetMasterID: 20},
+	}
+	_, err = newServiceWithStore(notInstalled).SwapComputerPart(context.Background(), 10, req, "")
+	if apiErr, ok := err.(*APIError); !ok || apiErr.Code != CodeNotFound {
+		t.Fatalf("expected NOT_FOUND for uninstalled part, got %v", err)
+	}
+	if busy.swapInput != nil || notInstalled.swapInput != nil {
+		t.Fatal("swap should not be called")
+	}
+}
+
+func TestSwapComputerPartRequiresRegisteredPartOfSameType(t *testing.T) {
+	installedAt := time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)
+	req := CreatePartSwapRequest{RemovedPartAssetMasterID: 20, InstalledPartAssetMasterID: 21, RemovedUsageStatusID: 4}
+	otherType := uint(5)
+
+	cases := map[string]map[uint64]*ComputerPartResponse{
+		"unregistered": {20: {AssetMasterID: 20}},
+		"other type":   {20: {AssetMasterID: 20}, 21: {AssetMasterID: 21, PartTypeID: &otherType}},
+	}
+	for name, parts := range cases {
+		store := &fakeComputerStore{
+			assetExists:         true,
+			usageStatusExists:   true,
+			parts:               parts,
+			activeConfiguration: &ComputerConfigurationResponse{ComputerConfigurationID: 7, PartTypeID: 3, InstalledAt: &installedAt},
+		}
+		_, err := newServiceWithStore(store).SwapComputerPart(context.Background(), 10, req, "")
+		if apiErr, ok := err.(*APIError); !ok || apiErr.Code != CodeInvalidArgument {
+			t.Fatalf("%s: expected INVALID_ARGUMENT, got %v", name, err)
+		}
+		if store.swapInput != nil {
+			t.Fatalf("%s: swap should not be called", name)
+		}
+	}
+}
+
+func TestCreateComputerPartValidatesSpecAttributesAgainstSchema(t *testing.T) {
+	schema := []PartSpecField{
+		{Key: "capacity_gb", Type: specTypeInteger, Required: true, Rollup: specRollupSum},
+		{Key: "interface", Type: specTypeString, Enum: []string{"SATA", "NVMe"}},
+	}
+	partTypeID := uint(2)
+
+	cases := []struct {
+		name  string
+		attrs map[string]any
+		ok    bool
+	}{
+		{"valid", map[string]any{"capacity_gb": float64(512), "interface": "NVMe"}, true},
+		{"missing required", map[string]any{"interface": "SATA"}, false},
+		{"unknown key", map[string]any{"capacity_gb": float64(512), "rpm": float64(7200)}, false},
+		{"not integer", map[string]any{"capacity_gb": 1.5}, false},
+		{"outside enum", map[string]any{"capacity_gb": float64(512), "interface": "IDE"}, false},
+	}
+	for _, tc := range cases {
+		store := &fakeComputerStore{assetExists: true, usageStatusExists: true, partTypeExists: true, specSchema: schema}
+		svc := newServiceWithStore(store)
+
+		_, err := svc.CreateComputerPart(context.Background(), CreateComputerPartRequest{
+			AssetMasterID:  1,
+			UsageStatusID:  1,
+			PartTypeID:     &partTypeID,
+			SpecAttributes: tc.attrs,
+		})
+		if tc.ok {
+			if err != nil || store.createdPart == nil {
+				t.Fatalf("%s: expected success, got %v", tc.name, err)
+			}
+			continue
+		}
+		apiErr, ok := err.(*APIError)
+		if !ok || apiErr.Code != CodeInvalidArgument {
+			t.Fatalf("%s: expected INVALID_ARGUMENT, got %v", tc.name, err)
+		}
+	}
+}
+
+func TestBuildBOMGroupsByPartTypeAndSumsRollups(t *testing.T) {
+	memory := []PartSpecField{{Key: "capacity_gb", Type: specTypeInteger, Rollup: specRollupSum, Summary: specSummaryRAM}, {Key: "speed_mhz", Type: specTypeInteger}}
+	storage := []PartSpecField{{Key: "capacity_gb", Type: specTypeInteger, Rollup: specRollupSum, Summary: specSummaryStorage}}
+	entries := []bomEntry{
+		{PartTypeID: 1, PartTypeName: "memory", SpecSchema: memory, Part: BOMPart{PartAssetMasterID: 10, SpecAttributes: map[string]any{"capacity_gb": float64(16), "speed_mhz": float64(3200)}}},
+		{PartTypeID: 1, PartTypeName: "memory", SpecSchema: memory, Part: BOMPart{PartAssetMasterID: 11, SpecAttributes: map[string]any{"capacity_gb": float64(16)}}},
+		{PartTypeID: 2, PartTypeName: "storage", SpecSchema: storage, Part: BOMPart{PartAssetMasterID: 12}},
+		{PartTypeID: 2, PartTypeName: "storage", SpecSchema: storage, Part: BOMPart{PartAssetMasterID: 13, SpecAttributes: map[string]any{"capacity_gb": float64(512)}}},
+		{PartTypeID: 3, PartTypeName: "gpu", SpecSchema: []PartSpecField{{Key: "vram_gb", Type: specTypeInteger, Rollup: specRollupSum}}, Part: BOMPart{PartAssetMasterID: 14, SpecAttributes: map[string]any{"vram_gb": float64(8)}}},
+	}
+
+	got, ramGB, storageGB := buildBOM(entries)
+	if ramGB != 32 || storageGB != 512 {
+		t.Fatalf("expected 32GB RAM and 512GB storage for the computer, got %v / %v", ramGB, storageGB)
+	}
+	if len(got) != 3 || len(got[0].Parts) != 2 || len(got[1].Parts) != 2 {
+		t.Fatalf("unexpected grouping: %+v", got)
+	}
+	if got[0].Totals["capacity_gb"] != 32 {
+		t.Fatalf("expected 32GB memory total, got %v", got[0].Totals)
+	}
+	if _, ok := got[0].Totals["speed_mhz"]; ok {
+		t.Fatal("speed_mhz is not a rollup field")
+	}
+	if total, ok := got[1].Totals["capacity_gb"]; !ok || total != 512 {
+		t.Fatalf("expected part without attributes to count as zero, got %v", got[1].Totals)
+	}
+}
+
+func TestCreateComputerConfigurationAllowsUpToSlotLimit(t *testing.T) {
+	newStore := func(active int) *fakeComputerStore {
+		return &fakeComputerStore{assetExists: true, partTypeExists: true, activeCount: active, slotLimit: 4}
+	}
+	req := CreateComputerConfigurationRequest{ComputerAssetMasterID: 10, PartAssetMasterID: 20, PartTypeID: 1}
+
+	ok := newStore(3)
+	if _, err := newServiceWithStore(ok).CreateComputerConfiguration(context.Background(), req); err != nil {
+		t.Fatalf("expected 4th DIMM to fit, got %v", err)
+	}
+	// 上限はストアのトランザクション内でもう一度数える
+	if ok.createdConfiguration == nil || ok.createdConfiguration.SlotLimit != 4 {
+		t.Fatalf("expected slot limit 4 passed to the store, got %+v", ok.createdConfiguration)
+	}
+
+	// 事前チェックの後に同時の取り付けで埋まった
+	raced := newStore(3)
+	raced.createConfigurationErr = errSlotInUse
+	_, err := newServiceWithStore(raced).CreateComputerConfiguration(context.Background(), req)
+	if apiErr, isAPIErr := err.(*APIError); !isAPIErr || apiErr.Code != CodeConflict || !strings.Contains(apiErr.Message, "slot limit reached") {
+		t.Fatalf("expected CONFLICT when the store finds the slots full, got %v", err)
+	}
+
+	full := newStore(4)
+	_, err = newServiceWithStore(full).CreateComputerConfiguration(context.Background(), req)
+	apiErr, isAPIErr := err.(*APIError)
+	if !isAPIErr || apiErr.Code != CodeConflict {
+		t.Fatalf("expected CONFLICT when slots are full, got %v", err)
+	}
+	for _, want := range []string{"OptiPlex 7010", "memory", "4"} {
+		if !strings.Contains(apiErr.Message, want) {
+			t.Fatalf("expected %q in slot limit message, got %q", want, apiErr.Message)
+		}
+	}
+	if full.createConfigurationCalled {
+		t.Fatal("create should not be called when slots are full")
+	}
+}
+
+func TestSlotLimitExceededMessageNamesModelOnlyForModelRules(t *testing.T) {
+	got := slotLimitExceededMessage(slotLimit{MaxCount: 2, Model: strPtr("OptiPlex 7010"), PartTypeName: "memory"})
+	if got != "slot limit reached: model OptiPlex 7010 allows at most 2 memory part(s)" {
+		t.Fatalf("unexpected message %q", got)
+	}
+	got = slotLimitExceededMessage(slotLimit{MaxCount: 1, PartTypeName: "cpu"})
+	if strings.Contains(got, "model") || !strings.Contains(got, "at most 1 cpu part(s) by default") {
+		t.Fatalf("unexpected message for the generic limit %q", got)
+	}
+}
+
+func TestPartSlotLimitRequiresPositiveMaxCount(t *testing.T) {
+	store := &fakeComputerStore{partTypeExists: true}
+	_, err := newServiceWithStore(store).CreatePartSlotLimit(context.Background(), PartSlotLimitRequest{PartTypeID: 1})
+	if apiErr, ok := err.(*APIError); !ok || apiErr.Code != CodeInvalidArgument {
+		t.Fatalf("expected INVALID_ARGUMENT for max_count 0, got %v", err)
+	}
+}
+
+func TestCreateComputerConfigurationNamesViolatedCompatibilityRule(t *testing.T) {
+	store := &fakeComputerStore{
+		assetExists:    true,
+		partTypeExists: true,
+		slotLimit:      4,
+		activeCount:    1,
+		partResponse:   &ComputerPartResponse{SpecAttributes: map[string]any{"memory_type": "DDR4"}},
+		rules: []CompatibilityRuleResponse{
+			{Name: "same generation", Attribute: "memory_type", Kind: compatibilityMatchInstalled},
+		},
+		bomEntries: []bomEntry{
+			{PartTypeID: 1, Part: BOMPart{ComputerConfigurationID: 5, SpecAttributes: map[string]any{"memory_type": "DDR5"}}},
+		},
+	}
+	svc := newServiceWithStore(store)
+
+	_, err := svc.CreateComputerConfiguration(context.Background(), CreateComputerConfigurationRequest{
+		ComputerAssetMasterID: 10, PartAssetMasterID: 20, PartTypeID: 1,
+	})
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.Code != CodeInvalidArgument || !strings.Contains(apiErr.Message, `"same generation"`) {
+		t.Fatalf("expected INVALID_ARGUMENT naming the rule, got %v", err)
+	}
+	if store.createConfigurationCalled {
+		t.Fatal("create should not be called on incompatible part")
+	}
+}
+
+func TestEvaluateCompatibilityAllowedValues(t *testing.T) {
+	rules := []CompatibilityRuleResponse{{Name: "M.2 only", Attribute: "interface", Kind: compatibilityAllowedValues, AllowedValues: []string{"NVMe"}}}
+
+	if err := evaluateCompatibility(rules, map[string]any{"interface": "NVMe"}, nil); err != nil {
+		t.Fatalf("expected NVMe to pass, got %v", err)
+	}
+	for _, attrs := range []map[string]any{{"interface": "SATA"}, {}} {
+		if err := evaluateCompatibility(rules, attrs, nil); err == nil {
+			t.Fatalf("expected %v to violate the rule", attrs)
+		}
+	}
+}
+
+func TestCreateCompatibilityRuleValidatesKindAndAttribute(t *testing.T) {
+	schema := []PartSpecField{{Key: "memory_type", Type: specTypeString}}
+	cases := map[string]CompatibilityRuleRequest{
+		"unknown kind":      {Name: "r", PartTypeID: 1, Attribute: "memory_type", Kind: "between"},
+		"no values":         {Name: "r", PartTypeID: 1, Attribute: "memory_type", Kind: compatibilityAllowedValues},
+		"unknown attribute": {Name: "r", PartTypeID: 1, Attribute: "socket", Kind: compatibilityMatchInstalled},
+	}
+	for name, req := range cases {
+		store := &fakeComputerStore{partTypeExists: true, specSchema: schema}
+		_, err := newServiceWithStore(store).CreateCompatibilityRule(context.Background(), req)
+		if apiErr, ok := err.(*APIError); !ok || apiErr.Code != CodeInvalidArgument {
+			t.Fatalf("%s: expected INVALID_ARGUMENT, got %v", name, err)
+		}
+	}
+
+	store := &fakeComputerStore{partTypeExists: true, specSchema: schema}
+	_, err := newServiceWithStore(store).CreateCompatibilityRule(context.Background(), CompatibilityRuleRequest{
+		Name: " DDR5 only ", PartTypeID: 1, Attribute: "memory_type", Kind: "Allowed_Values", AllowedValues: []string{"DDR5", " DDR5 "},
+	})
+	if err != nil {
+		t.Fatalf("CreateCompatibilityRule returned error: %v", err)
+	}
+	if store.createdRule.Name != "DDR5 only" || store.createdRule.Kind != compatibilityAllowedValues || len(store.createdRule.AllowedValues) != 1 {
+		t.Fatalf("unexpected normalized rule: %+v", store.createdRule)
+	}
+}
+
+func TestListPartTypesLocalizesLabel(t *testing.T) {
+	en := "Graphics card"
+	store := &fakeComputerStore{partTypes: []PartTypeResponse{
+		{PartTypeID: 1, DisplayName: "グラフィックボード", DisplayNameEN: &en},
+		{PartTypeID: 2, DisplayName: "メモリ"},
+	}}
+	svc := newServiceWithStore(store)
+
+	out, err := svc.ListPartTypes(context.Background(), "", "EN")
+	if err != nil {
+		t.Fatalf("ListPartTypes returned error: %v", err)
+	}
+	if out[0].Label != "Graphics card" || out[1].Label != "メモリ" {
+		t.Fatalf("expected english label with japanese fallback, got %q / %q", out[0].Label, out[1].Label)
+	}
+}
+
+func TestCreatePartTypeValidatesSpecSchema(t *testing.T) {
+	cases := map[string][]PartSpecField{
+		"unknown type":   {{Key: "socket", Type: "text"}},
+		"duplicate key":  {{Key: "size", Type: specTypeString}, {Key: "size", Type: specTypeString}},
+		"sum on string":  {{Key: "socket", Type: specTypeString, Rollup: specRollupSum}},
+		"enum on number": {{Key: "speed", Type: specTypeNumber, Enum: []string{"1"}}},
+		"summary no sum": {{Key: "capacity_gb", Type: specTypeInteger, Summary: specSummaryRAM}},
+		"bad summary":    {{Key: "capacity_gb", Type: specTypeInteger, Rollup: specRollupSum, Summary: "vram_gb"}},
+	}
+	for name, schema := range cases {
+		store := &fakeComputerStore{}
+		_, err := newServiceWithStore(store).CreatePartType(context.Background(), CreatePartTypeRequest{Name: "x", DisplayName: "x", SpecSchema: schema}, "")
+		if apiErr, ok := err.(*APIError); !ok || apiErr.Code != CodeInvalidArgument {
+			t.Fatalf("%s: expected INVALID_ARGUMENT, got %v", name, err)
+		}
+		if store.createdPartType != nil {
+			t.Fatalf("%s: create should not be called", name)
+		}
+	}
+}
+
+func TestValidateSpecAttributesRejectsUnknownStoredType(t *testing.T) {
+	err := validateSpecAttributes([]PartSpecField{{Key: "socket", Type: "text"}}, map[string]any{"socket": "AM4"})
+	if apiErr, ok := err.(*APIError); !ok || apiErr.Code != CodeInvalidArgument {
+		t.Fatalf("expected INVALID_ARGUMENT, got %v", err)
+	}
+}
+
+func TestDisablingReferenceInUseReturnsConflict(t *testing.T) {
+	disabled := true
+	svc := newServiceWithStore(&fakeComputerStore{updateErr: errReferenceInUse})
+
+	_, err := svc.UpdatePartType(context.Background(), 1, UpdatePartTypeRequest{IsDisabled: &disabled}, "")
+	if apiErr, ok := err.(*APIError); !ok || apiErr.Code != CodeConflict {
+		t.Fatalf("part type: expected CONFLICT, got %v", err)
+	}
+	_, err = svc.UpdateUsageStatus(context.Background(), 1, UpdateUsageStatusRequest{IsDisabled: &disabled}, "")
+	if apiErr, ok := err.(*APIError); !ok || apiErr.Code != CodeConflict {
+		t.Fatalf("usage status: expected CONFLICT, got %v", err)
+	}
+	_, err = svc.UpdateUsageStatus(context.Background(), 1, UpdateUsageStatusRequest{Name: strPtr("  ")}, "")
+	if apiErr, ok := err.(*APIError); !ok || apiErr.Code != CodeInvalidArgument {
+		t.Fatalf("blank name: expected INVALID_ARGUMENT, got %v", err)
+	}
+}
+
 type fakeComputerStore struct {
 	assetExists                  bool
 	usageStatusExists            bool
@@ -147,12 +783,48 @@ type fakeComputerStore struct {
 	activeComputerPartTypeExists bool
 
 	createConfigurationCalled bool
+	createdConfiguration      *createComputerConfigurationInput
+	createConfigurationErr    error
 	updateConfigurationCalled bool
 
 	getConfigurationResponse *ComputerConfigurationResponse
 	updateDetailResponse     *ComputerDetailResponse
 
 	lastUpdateDetailPatch updateComputerDetailInput
+
+	serialMatches    []uint64
+	macMatches       []uint64
+	lastMACLookup    []string
+	appliedAssetID   uint64
+	appliedMatchedBy string
+	appliedInput     *ingestReportInput
+	queuedReview     *ingestReviewInput
+
+	lastHistoryLookup string
+	lastHistoryAt     time.Time
+
+	addressErr    error
+	createdDetail *createComputerDetailInput
+
+	partResponse *ComputerPartResponse
+	parts        map[uint64]*ComputerPartResponse
+	specSchema   []PartSpecField
+	createdPart  *createComputerPartInput
+
+	activeCount int
+	slotLimit   uint
+	rules       []CompatibilityRuleResponse
+	bomEntries  []bomEntry
+	createdRule *compatibilityRuleInput
+
+	partTypes           []PartTypeResponse
+	createdPartType     *createPartTypeInput
+	updateErr           error
+	activeConfiguration *ComputerConfigurationResponse
+	swapInput           *partSwapInput
+
+	lastPage         Page
+	lastDetailFilter ComputerDetailFilter
 }
 
 func (f *fakeComputerStore) AssetMasterExists(context.Context, uint64) (bool, error) {
@@ -167,8 +839,12 @@ func (f *fakeComputerStore) PartTypeExists(context.Context, uint) (bool, error)
 	return f.partTypeExists, nil
 }
 
-func (f *fakeComputerStore) CreateComputerDetail(context.Context, createComputerDetailInput) (*ComputerDetailResponse, error) {
-	return nil, nil
+func (f *fakeComputerStore) CreateComputerDetail(_ context.Context, in createComputerDetailInput) (*ComputerDetailResponse, error) {
+	if f.addressErr != nil {
+		return nil, f.addressErr
+	}
+	f.createdDetail = &in
+	return &ComputerDetailResponse{AssetMasterID: in.AssetMasterID}, nil
 }
 
 func (f *fakeComputerStore) GetComputerDetailByAssetMasterID(context.Context, uint64) (*ComputerDetailResponse, error) {
@@ -177,26 +853,75 @@ func (f *fakeComputerStore) GetComputerDetailByAssetMasterID(context.Context, ui
 
 func (f *fakeComputerStore) UpdateComputerDetailByAssetMasterID(_ context.Context, _ uint64, patch updateComputerDetailInput) (*ComputerDetailResponse, error) {
 	f.lastUpdateDetailPatch = patch
+	if f.addressErr != nil {
+		return nil, f.addressErr
+	}
 	if f.updateDetailResponse == nil {
 		return nil, sql.ErrNoRows
 	}
 	return f.updateDetailResponse, nil
 }
 
-func (f *fakeComputerStore) CreateComputerPart(context.Context, createComputerPartInput) (*ComputerPartResponse, error) {
-	return nil, nil
+func (f *fakeComputerStore) ListComputerDetails(_ context.Context, p Page, filter ComputerDetailFilter) ([]ComputerDetailResponse, int64, error) {
+	f.lastPage = p
+	f.lastDetailFilter = filter
+	return []ComputerDetailResponse{}, 0, nil
 }
 
-func (f *fakeComputerStore) GetComputerPartByAssetMasterID(context.Context, uint64) (*ComputerPartResponse, error) {
-	return nil, sql.ErrNoRows
+func (f *fakeComputerStore) ListComputerParts(_ context.Context, p Page, _ ComputerPartFilter) ([]ComputerPartResponse, int64, error) {
+	f.lastPage = p
+	return []ComputerPartResponse{}, 0, nil
+}
+
+func (f *fakeComputerStore) ListComputerConfigurations(_ context.Context, p Page, _ ComputerConfigurationFilter) ([]ComputerConfigurationResponse, int64, error) {
+	f.lastPage = p
+	return []ComputerConfigurationResponse{}, 0, nil
+}
+
+func (f *fakeComputerStore) ListComputerDetailHistory(context.Context, uint64) ([]ComputerDetailVersionResponse, error) {
+	return []ComputerDetailVersionResponse{}, nil
+}
+
+func (f *fakeComputerStore) FindComputerAddressesByIP(_ context.Context, ip string, at time.Time) ([]ComputerAddressLookupResponse, error) {
+	f.lastHistoryLookup = ip
+	f.lastHistoryAt = at
+	return []ComputerAddressLookupResponse{}, nil
+}
+
+func (f *fakeComputerStore) FindComputerAddressesByMAC(_ context.Context, mac string, at time.Time) ([]ComputerAddressLookupResponse, error) {
+	f.lastHistoryLookup = mac
+	f.lastHistoryAt = at
+	return []ComputerAddressLookupResponse{}, nil
+}
+
+func (f *fakeComputerStore) CreateComputerPart(_ context.Context, in createComputerPartInput) (*ComputerPartResponse, error) {
+	f.createdPart = &in
+	return &ComputerPartResponse{AssetMasterID: in.AssetMasterID}, nil
+}
+
+func (f *fakeComputerStore) GetComputerPartByAssetMasterID(_ context.Context, assetMasterID uint64) (*ComputerPartResponse, error) {
+	if f.parts != nil {
+		if p, ok := f.parts[assetMasterID]; ok {
+			return p, nil
+		}
+		return nil, sql.ErrNoRows
+	}
+	if f.partResponse == nil {
+		return nil, sql.ErrNoRows
+	}
+	return f.partResponse, nil
 }
 
 func (f *fakeComputerStore) UpdateComputerPartByAssetMasterID(context.Context, uint64, updateComputerPartInput) (*ComputerPartResponse, error) {
 	return nil, sql.ErrNoRows
 }
 
-func (f *fakeComputerStore) CreateComputerConfiguration(context.Context, createComputerConfigurationInput) (*ComputerConfigurationResponse, error) {
+func (f *fakeComputerStore) CreateComputerConfiguration(_ context.Context, in createComputerConfigurationInput) (*ComputerConfigurationResponse, error) {
 	f.createConfigurationCalled = true
+	f.createdConfiguration = &in
+	if f.createConfigurationErr != nil {
+		return nil, f.createConfigurationErr
+	}
 	return &ComputerConfigurationResponse{}, nil
 }
 
@@ -220,18 +945,163 @@ func (f *fakeComputerStore) ActiveConfigurationExistsForPart(context.Context, ui
 	return f.activePartExists, nil
 }
 
-func (f *fakeComputerStore) ActiveConfigurationExistsForComputerPartType(context.Context, uint64, uint, *uint64) (bool, error) {
-	return f.activeComputerPartTypeExists, nil
+func (f *fakeComputerStore) CountActiveConfigurationsForComputerPartType(context.Context, uint64, uint, *uint64) (int, error) {
+	if f.activeComputerPartTypeExists {
+		return 1, nil
+	}
+	return f.activeCount, nil
+}
+
+func (f *fakeComputerStore) GetSlotLimit(context.Context, uint64, uint) (slotLimit, error) {
+	if f.slotLimit == 0 {
+		return slotLimit{MaxCount: defaultSlotLimit}, nil
+	}
+	return slotLimit{MaxCount: f.slotLimit, Model: strPtr("OptiPlex 7010"), PartTypeName: "memory"}, nil
+}
+
+func (f *fakeComputerStore) ListApplicableCompatibilityRules(context.Context, uint64, uint) ([]CompatibilityRuleResponse, error) {
+	return f.rules, nil
+}
+
+func (f *fakeComputerStore) ListPartSlotLimits(context.Context, *uint) ([]PartSlotLimitResponse, error) {
+	return []PartSlotLimitResponse{}, nil
+}
+
+func (f *fakeComputerStore) GetPartSlotLimitByID(context.Context, uint64) (*PartSlotLimitResponse, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeComputerStore) CreatePartSlotLimit(context.Context, partSlotLimitInput) (*PartSlotLimitResponse, error) {
+	return &PartSlotLimitResponse{}, nil
+}
+
+func (f *fakeComputerStore) UpdatePartSlotLimitByID(context.Context, uint64, partSlotLimitInput) (*PartSlotLimitResponse, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeComputerStore) DeletePartSlotLimitByID(context.Context, uint64) error {
+	return sql.ErrNoRows
+}
+
+func (f *fakeComputerStore) ListCompatibilityRules(context.Context, *uint) ([]CompatibilityRuleResponse, error) {
+	return f.rules, nil
+}
+
+func (f *fakeComputerStore) GetCompatibilityRuleByID(context.Context, uint64) (*CompatibilityRuleResponse, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeComputerStore) CreateCompatibilityRule(_ context.Context, in compatibilityRuleInput) (*CompatibilityRuleResponse, error) {
+	f.createdRule = &in
+	return &CompatibilityRuleResponse{Name: in.Name}, nil
+}
+
+func (f *fakeComputerStore) UpdateCompatibilityRuleByID(context.Context, uint64, compatibilityRuleInput) (*CompatibilityRuleResponse, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeComputerStore) DeleteCompatibilityRuleByID(context.Context, uint64) error {
+	return sql.ErrNoRows
+}
+
+func (f *fakeComputerStore) GetActiveConfigurationForPart(context.Context, uint64, uint64) (*ComputerConfigurationResponse, error) {
+	if f.activeConfiguration == nil {
+		return nil, sql.ErrNoRows
+	}
+	return f.activeConfiguration, nil
+}
+
+func (f *fakeComputerStore) SwapComputerPart(_ context.Context, in partSwapInput) (*PartSwapResponse, error) {
+	f.swapInput = &in
+	return &PartSwapResponse{ComputerAssetMasterID: in.ComputerAssetMasterID, PartTypeID: in.PartTypeID}, nil
 }
 
-func (f *fakeComputerStore) ListPartTypes(context.Context) ([]PartTypeResponse, error) {
-	return []PartTypeResponse{}, nil
+func (f *fakeComputerStore) GetPartTypeSpecSchema(context.Context, uint) ([]PartSpecField, error) {
+	if !f.partTypeExists {
+		return nil, sql.ErrNoRows
+	}
+	return f.specSchema, nil
 }
 
-func (f *fakeComputerStore) ListUsageStatuses(context.Context) ([]UsageStatusResponse, error) {
+func (f *fakeComputerStore) ListBOMEntries(context.Context, uint64, *time.Time) ([]bomEntry, error) {
+	return f.bomEntries, nil
+}
+
+func (f *fakeComputerStore) ListPartTypes(context.Context, bool) ([]PartTypeResponse, error) {
+	return f.partTypes, nil
+}
+
+func (f *fakeComputerStore) GetPartTypeByID(context.Context, uint) (*PartTypeResponse, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeComputerStore) CreatePartType(_ context.Context, in createPartTypeInput) (*PartTypeResponse, error) {
+	f.createdPartType = &in
+	return &PartTypeResponse{Name: in.Name, DisplayName: in.DisplayName, DisplayNameEN: in.DisplayNameEN}, nil
+}
+
+func (f *fakeComputerStore) UpdatePartTypeByID(context.Context, uint, updatePartTypeInput) (*PartTypeResponse, error) {
+	if f.updateErr != nil {
+		return nil, f.updateErr
+	}
+	return &PartTypeResponse{}, nil
+}
+
+func (f *fakeComputerStore) ListUsageStatuses(context.Context, bool) ([]UsageStatusResponse, error) {
 	return []UsageStatusResponse{}, nil
 }
 
+func (f *fakeComputerStore) GetUsageStatusByID(context.Context, uint) (*UsageStatusResponse, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeComputerStore) CreateUsageStatus(_ context.Context, in createUsageStatusInput) (*UsageStatusResponse, error) {
+	return &UsageStatusResponse{Name: in.Name, DisplayName: in.DisplayName}, nil
+}
+
+func (f *fakeComputerStore) UpdateUsageStatusByID(context.Context, uint, updateUsageStatusInput) (*UsageStatusResponse, error) {
+	if f.updateErr != nil {
+		return nil, f.updateErr
+	}
+	return &UsageStatusResponse{}, nil
+}
+
+func (f *fakeComputerStore) FindAssetMasterIDsBySerial(context.Context, string) ([]uint64, error) {
+	return f.serialMatches, nil
+}
+
+func (f *fakeComputerStore) FindAssetMasterIDsByMAC(_ context.Context, macAddresses []string) ([]uint64, error) {
+	f.lastMACLookup = macAddresses
+	return f.macMatches, nil
+}
+
+func (f *fakeComputerStore) ApplyIngestReport(_ context.Context, assetMasterID uint64, matchedBy string, in ingestReportInput) (*ComputerDetailResponse, error) {
+	if f.addressErr != nil {
+		return nil, f.addressErr
+	}
+	f.appliedAssetID = assetMasterID
+	f.appliedInput = &in
+	f.appliedMatchedBy = matchedBy
+	return &ComputerDetailResponse{AssetMasterID: assetMasterID}, nil
+}
+
+func (f *fakeComputerStore) QueueIngestReview(_ context.Context, in ingestReviewInput) (uint64, error) {
+	f.queuedReview = &in
+	return 1, nil
+}
+
+func (f *fakeComputerStore) ListIngestReviews(context.Context, *string) ([]IngestReviewResponse, error) {
+	return []IngestReviewResponse{}, nil
+}
+
+func (f *fakeComputerStore) GetIngestReviewByID(context.Context, uint64) (*IngestReviewResponse, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeComputerStore) ResolveIngestReview(context.Context, uint64, ingestReviewResolution) (*IngestReviewResponse, error) {
+	return nil, sql.ErrNoRows
+}
+
 func strPtr(v string) *string {
 	return &v
 }
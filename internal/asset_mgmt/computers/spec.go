@@ -0,0 +1,225 @@
+package computers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	specTypeString  = "string"
+	specTypeNumber  = "number"
+	specTypeInteger = "integer"
+	specTypeBoolean = "boolean"
+
+	specRollupSum = "sum"
+
+	// rollup の合計を端末全体の RAM / ストレージ容量（GB）に足し込む
+	specSummaryRAM     = "ram_gb"
+	specSummaryStorage = "storage_gb"
+)
+
+// validateSpecAttributes は spec_attributes を部品種別の spec_schema と突き合わせる。
+// スキーマに無いキー・型違い・enum 外の値・必須項目の欠落を 400 にする
+func validateSpecAttributes(schema []PartSpecField, attrs map[string]any) error {
+	fields := make(map[string]PartSpecField, len(schema))
+	for _, f := range schema {
+		fields[f.Key] = f
+	}
+
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		f, ok := fields[k]
+		if !ok {
+			return ErrInvalid(fmt.Sprintf("spec_attributes.%s is not defined for this part type", k))
+		}
+		if err := validateSpecValue(f, attrs[k]); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range schema {
+		if !f.Required {
+			continue
+		}
+		if v, ok := attrs[f.Key]; !ok || v == nil {
+			return ErrInvalid(fmt.Sprintf("spec_attributes.%s is required", f.Key))
+		}
+	}
+	return nil
+}
+
+func validateSpecValue(f PartSpecField, v any) error {
+	if v == nil {
+		return nil
+	}
+	mismatch := ErrInvalid(fmt.Sprintf("spec_attributes.%s must be %s", f.Key, f.Type))
+
+	switch f.Type {
+	case specTypeString:
+		s, ok := v.(string)
+		if !ok {
+			return mismatch
+		}
+		if len(f.Enum) > 0 && !containsString(f.Enum, s) {
+			return ErrInvalid(fmt.Sprintf("spec_attributes.%s must be one of %v", f.Key, f.Enum))
+		}
+	case specTypeNumber:
+		if _, ok := v.(float64); !ok {
+			return mismatch
+		}
+	case specTypeInteger:
+		n, ok := v.(float64)
+		if !ok || n != math.Trunc(n) {
+			return mismatch
+		}
+	case specTypeBoolean:
+		if _, ok := v.(bool); !ok {
+			return mismatch
+		}
+	default:
+		return ErrInvalid(fmt.Sprintf("part type spec_schema has unknown type %q for %s", f.Type, f.Key))
+	}
+	return nil
+}
+
+// rollupSpecTotals は rollup: sum の数値項目を部品ごとに合計する
+func rollupSpecTotals(schema []PartSpecField, parts []BOMPart) map[string]float64 {
+	totals := map[string]float64{}
+	for _, f := range schema {
+		if f.Rollup != specRollupSum || (f.Type != specTypeNumber && f.Type != specTypeInteger) {
+			continue
+		}
+		totals[f.Key] = 0
+		for _, p := range parts {
+			if n, ok := p.SpecAttributes[f.Key].(float64); ok {
+				totals[f.Key] += n
+			}
+		}
+	}
+	return totals
+}
+
+// buildBOM は部品種別ごとに構成行をまとめる（entries は part_type_id 順に並んでいる前提）。
+// summary が付いた項目の合計は端末全体の RAM / ストレージ容量として返す
+func buildBOM(entries []bomEntry) (nodes []BOMPartTypeNode, ramGB, storageGB float64) {
+	nodes = make([]BOMPartTypeNode, 0, 4)
+	schemas := make([][]PartSpecField, 0, 4)
+	for _, e := range entries {
+		if len(nodes) == 0 || nodes[len(nodes)-1].PartTypeID != e.PartTypeID {
+			nodes = append(nodes, BOMPartTypeNode{
+				PartTypeID:  e.PartTypeID,
+				Name:        e.PartTypeName,
+				DisplayName: e.PartTypeDisplayName,
+				Parts:       []BOMPart{},
+			})
+			schemas = append(schemas, e.SpecSchema)
+		}
+		node := &nodes[len(nodes)-1]
+		node.Parts = append(node.Parts, e.Part)
+	}
+	for i := range nodes {
+		nodes[i].Totals = rollupSpecTotals(schemas[i], nodes[i].Parts)
+		for _, f := range schemas[i] {
+			switch f.Summary {
+			case specSummaryRAM:
+				ramGB += nodes[i].Totals[f.Key]
+			case specSummaryStorage:
+				storageGB += nodes[i].Totals[f.Key]
+			}
+		}
+	}
+	return nodes, ramGB, storageGB
+}
+
+// validateSpecSchema は part_types に保存する spec_schema の形を検証する
+func validateSpecSchema(schema []PartSpecField) error {
+	seen := make(map[string]struct{}, len(schema))
+	for i := range schema {
+		f := &schema[i]
+		f.Key = strings.TrimSpace(f.Key)
+		if f.Key == "" {
+			return ErrInvalid("spec_schema key is required")
+		}
+		if _, ok := seen[f.Key]; ok {
+			return ErrInvalid("duplicate spec_schema key " + f.Key)
+		}
+		seen[f.Key] = struct{}{}
+
+		switch f.Type {
+		case specTypeString, specTypeNumber, specTypeInteger, specTypeBoolean:
+		default:
+			return ErrInvalid(fmt.Sprintf("spec_schema.%s type must be string, number, integer or boolean", f.Key))
+		}
+		if len(f.Enum) > 0 && f.Type != specTypeString {
+			return ErrInvalid(fmt.Sprintf("spec_schema.%s enum is only allowed for string", f.Key))
+		}
+		switch f.Rollup {
+		case "":
+		case specRollupSum:
+			if f.Type != specTypeNumber && f.Type != specTypeInteger {
+				return ErrInvalid(fmt.Sprintf("spec_schema.%s rollup sum needs a numeric type", f.Key))
+			}
+		default:
+			return ErrInvalid(fmt.Sprintf("spec_schema.%s rollup must be sum", f.Key))
+		}
+		switch f.Summary {
+		case "":
+		case specSummaryRAM, specSummaryStorage:
+			if f.Rollup != specRollupSum {
+				return ErrInvalid(fmt.Sprintf("spec_schema.%s summary needs rollup sum", f.Key))
+			}
+		default:
+			return ErrInvalid(fmt.Sprintf("spec_schema.%s summary must be ram_gb or storage_gb", f.Key))
+		}
+	}
+	return nil
+}
+
+func specSchemaHasKey(schema []PartSpecField, key string) bool {
+	for _, f := range schema {
+		if f.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluateCompatibility は取り付けようとしている部品の属性 attrs を互換性ルールで検証する。
+// installed は同じ端末に取り付け済みの同種部品の属性。違反したルール名をエラーに含める
+func evaluateCompatibility(rules []CompatibilityRuleResponse, attrs map[string]any, installed []map[string]any) error {
+	for _, r := range rules {
+		v, ok := attrs[r.Attribute]
+		if !ok || v == nil {
+			return compatibilityViolation(r, fmt.Sprintf("part has no %s in spec_attributes", r.Attribute))
+		}
+		value := fmt.Sprint(v)
+
+		switch r.Kind {
+		case compatibilityAllowedValues:
+			if !containsString(r.AllowedValues, value) {
+				return compatibilityViolation(r, fmt.Sprintf("%s=%s is not one of %v", r.Attribute, value, r.AllowedValues))
+			}
+		case compatibilityMatchInstalled:
+			for _, other := range installed {
+				ov, ok := other[r.Attribute]
+				if !ok || ov == nil {
+					continue
+				}
+				if fmt.Sprint(ov) != value {
+					return compatibilityViolation(r, fmt.Sprintf("%s=%s does not match installed %s=%v", r.Attribute, value, r.Attribute, ov))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func compatibilityViolation(r CompatibilityRuleResponse, detail string) error {
+	return ErrInvalid(fmt.Sprintf("compatibility rule %q violated: %s", r.Name, detail))
+}
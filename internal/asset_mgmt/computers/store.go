@@ -3,9 +3,27 @@ package computers
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
+
+	platformdb "IRIS-backend/internal/platform/db"
+)
+
+// errIngestReviewClosed は解決・却下済みのレビューを再度締めようとしたとき
+var errIngestReviewClosed = errors.New("ingest review already closed")
+
+// errReferenceInUse は使用中の部品種別・利用状況を無効化しようとしたとき
+var errReferenceInUse = errors.New("reference value in use")
+
+// 取り付け・部品交換のトランザクション内で、事前チェック後に状態が変わっていたとき
+var (
+	errSwapConfigurationClosed = errors.New("configuration already removed")
+	errPartInUse               = errors.New("part already installed")
+	errSlotInUse               = errors.New("part type slot already in use")
 )
 
 type Store struct {
@@ -21,11 +39,11 @@ func (s *Store) AssetMasterExists(ctx context.Context, assetMasterID uint64) (bo
 }
 
 func (s *Store) UsageStatusExists(ctx context.Context, usageStatusID uint) (bool, error) {
-	return s.exists(ctx, "SELECT 1 FROM usage_status WHERE usage_status_id = ?", usageStatusID)
+	return s.exists(ctx, "SELECT 1 FROM usage_status WHERE usage_status_id = ? AND is_disabled = 0", usageStatusID)
 }
 
 func (s *Store) PartTypeExists(ctx context.Context, partTypeID uint) (bool, error) {
-	return s.exists(ctx, "SELECT 1 FROM part_types WHERE part_type_id = ?", partTypeID)
+	return s.exists(ctx, "SELECT 1 FROM part_types WHERE part_type_id = ? AND is_disabled = 0", partTypeID)
 }
 
 func (s *Store) CreateComputerDetail(ctx context.Context, in createComputerDetailInput) (*ComputerDetailResponse, error) {
@@ -34,24 +52,35 @@ func (s *Store) CreateComputerDetail(ctx context.Context, in createComputerDetai
 		(asset_master_id, hostname, ip_address, mac_address, os, purpose, login_user, note)
 	VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
 
-	if _, err := s.db.ExecContext(ctx, q,
-		in.AssetMasterID,
-		in.Hostname,
-		in.IPAddress,
-		in.MACAddress,
-		in.OS,
-		in.Purpose,
-		in.LoginUser,
-		in.Note,
-	); err != nil {
+	err := platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		if err := lockAddressConflictsTx(ctx, tx, in.AssetMasterID, in.Addresses); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, q,
+			in.AssetMasterID,
+			in.Hostname,
+			in.IPAddress,
+			in.MACAddress,
+			in.OS,
+			in.Purpose,
+			in.LoginUser,
+			in.Note,
+		); err != nil {
+			return err
+		}
+		if err := replaceAddressesTx(ctx, tx, in.AssetMasterID, in.Addresses, in.ChangedAt); err != nil {
+			return err
+		}
+		return recordDetailVersionTx(ctx, tx, in.AssetMasterID, in.ChangedAt)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return s.GetComputerDetailByAssetMasterID(ctx, in.AssetMasterID)
 }
 
-func (s *Store) GetComputerDetailByAssetMasterID(ctx context.Context, assetMasterID uint64) (*ComputerDetailResponse, error) {
-	const q = `
+const computerDetailSelect = `
 	SELECT
 		cd.computer_detail_id,
 		cd.asset_master_id,
@@ -64,41 +93,104 @@ func (s *Store) GetComputerDetailByAssetMasterID(ctx context.Context, assetMaste
 		cd.purpose,
 		cd.login_user,
 		cd.note,
+		cd.cpu,
+		cd.ram_mb,
+		cd.storage_gb,
+		cd.disks,
+		cd.last_seen_at,
 		cd.created_at,
 		cd.updated_at
 	FROM computer_details cd
-	JOIN assets_master am ON am.asset_master_id = cd.asset_master_id
+	JOIN assets_master am ON am.asset_master_id = cd.asset_master_id`
+
+func (s *Store) GetComputerDetailByAssetMasterID(ctx context.Context, assetMasterID uint64) (*ComputerDetailResponse, error) {
+	q := computerDetailSelect + `
 	WHERE cd.asset_master_id = ?`
 
-	var out ComputerDetailResponse
-	var hostname, ipAddress, macAddress, osValue, purpose, loginUser, note sql.NullString
-	if err := s.db.QueryRowContext(ctx, q, assetMasterID).Scan(
-		&out.ComputerDetailID,
-		&out.AssetMasterID,
-		&out.ManagementNumber,
-		&out.AssetName,
-		&hostname,
-		&ipAddress,
-		&macAddress,
-		&osValue,
-		&purpose,
-		&loginUser,
-		&note,
-		&out.CreatedAt,
-		&out.UpdatedAt,
-	); err != nil {
+	item, err := scanComputerDetail(s.db.QueryRowContext(ctx, q, assetMasterID))
+	if err != nil {
+		return nil, err
+	}
+	items := []ComputerDetailResponse{item}
+	if err := s.attachAddresses(ctx, items); err != nil {
 		return nil, err
 	}
+	return &items[0], nil
+}
 
-	out.Hostname = ptrString(hostname)
-	out.IPAddress = ptrString(ipAddress)
-	out.MACAddress = ptrString(macAddress)
-	out.OS = ptrString(osValue)
-	out.Purpose = ptrString(purpose)
-	out.LoginUser = ptrString(loginUser)
-	out.Note = ptrString(note)
+// ListComputerDetails は computer_details を条件で絞り込んでページングして返す
+func (s *Store) ListComputerDetails(ctx context.Context, p Page, f ComputerDetailFilter) ([]ComputerDetailResponse, int64, error) {
+	where := []string{"1=1"}
+	args := make([]any, 0, 8)
 
-	return &out, nil
+	if f.Q != nil {
+		like := "%" + escapeLike(*f.Q) + "%"
+		where = append(where, `(cd.hostname LIKE ? OR cd.ip_address LIKE ? OR cd.mac_address LIKE ? OR cd.os LIKE ?
+			OR cd.login_user LIKE ? OR cd.cpu LIKE ? OR am.management_number LIKE ? OR am.name LIKE ?)`)
+		args = append(args, like, like, like, like, like, like, like, like)
+	}
+	appendLikeFilter("cd.hostname", f.Hostname, &where, &args)
+	if f.IPAddress != nil {
+		// 前方一致にしてサブネット単位（10.0.3.）でも探せるようにする
+		where = append(where, "cd.ip_address LIKE ?")
+		args = append(args, escapeLike(*f.IPAddress)+"%")
+	}
+	if f.MACAddress != nil {
+		where = append(where, "LOWER(REPLACE(cd.mac_address, '-', ':')) = ?")
+		args = append(args, *f.MACAddress)
+	}
+	appendLikeFilter("cd.os", f.OS, &where, &args)
+	appendLikeFilter("cd.login_user", f.LoginUser, &where, &args)
+	appendLikeFilter("cd.cpu", f.CPU, &where, &args)
+	if f.MinRAMMB != nil {
+		where = append(where, "cd.ram_mb >= ?")
+		args = append(args, *f.MinRAMMB)
+	}
+	if f.MinStorageGB != nil {
+		where = append(where, "cd.storage_gb >= ?")
+		args = append(args, *f.MinStorageGB)
+	}
+	if f.ManagementNumber != nil {
+		where = append(where, "am.management_number = ?")
+		args = append(args, *f.ManagementNumber)
+	}
+
+	whereSQL := " WHERE " + strings.Join(where, " AND ")
+	q := computerDetailSelect + whereSQL + `
+	ORDER BY cd.asset_master_id ` + orderSQL(p.Order) + `
+	LIMIT ? OFFSET ?`
+
+	rows, err := s.db.QueryContext(ctx, q, append(append([]any{}, args...), p.Limit, p.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	out := make([]ComputerDetailResponse, 0, p.Limit)
+	for rows.Next() {
+		item, err := scanComputerDetail(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if err := s.attachAddresses(ctx, out); err != nil {
+		return nil, 0, err
+	}
+
+	countQ := `
+	SELECT COUNT(*)
+	FROM computer_details cd
+	JOIN assets_master am ON am.asset_master_id = cd.asset_master_id` + whereSQL
+	total, err := s.count(ctx, countQ, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
 }
 
 func (s *Store) UpdateComputerDetailByAssetMasterID(ctx context.Context, assetMasterID uint64, patch updateComputerDetailInput) (*ComputerDetailResponse, error) {
@@ -119,29 +211,301 @@ func (s *Store) UpdateComputerDetailByAssetMasterID(ctx context.Context, assetMa
 
 	args = append(args, assetMasterID)
 	q := fmt.Sprintf("UPDATE computer_details SET %s WHERE asset_master_id = ?", strings.Join(sets, ", "))
-	res, err := s.db.ExecContext(ctx, q, args...)
+	err := platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		var dummy int
+		if err := tx.QueryRowContext(ctx,
+			"SELECT 1 FROM computer_details WHERE asset_master_id = ? FOR UPDATE", assetMasterID,
+		).Scan(&dummy); err != nil {
+			return err
+		}
+		if err := lockAddressConflictsTx(ctx, tx, assetMasterID, patch.Candidates); err != nil {
+			return err
+		}
+		if err := seedDetailHistoryTx(ctx, tx, assetMasterID); err != nil {
+			return err
+		}
+		if err := seedAddressesTx(ctx, tx, assetMasterID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+			return err
+		}
+		switch {
+		case patch.Addresses != nil:
+			if err := replaceAddressesTx(ctx, tx, assetMasterID, *patch.Addresses, patch.ChangedAt); err != nil {
+				return err
+			}
+		case patch.IPAddress.Set || patch.MACAddress.Set:
+			if err := syncPrimaryAddressTx(ctx, tx, assetMasterID, patch.ChangedAt); err != nil {
+				return err
+			}
+		}
+		return recordDetailVersionTx(ctx, tx, assetMasterID, patch.ChangedAt)
+	})
 	if err != nil {
 		return nil, err
 	}
-	if aff, _ := res.RowsAffected(); aff == 0 {
-		if _, err := s.GetComputerDetailByAssetMasterID(ctx, assetMasterID); err == sql.ErrNoRows {
-			return nil, sql.ErrNoRows
+
+	return s.GetComputerDetailByAssetMasterID(ctx, assetMasterID)
+}
+
+// ListComputerDetailHistory は資産の computer_details の版を新しい順に返す
+func (s *Store) ListComputerDetailHistory(ctx context.Context, assetMasterID uint64) ([]ComputerDetailVersionResponse, error) {
+	q := `SELECT ` + detailHistoryColumns + `
+	FROM computer_detail_history h
+	JOIN assets_master am ON am.asset_master_id = h.asset_master_id
+	WHERE h.asset_master_id = ?
+	ORDER BY h.effective_from DESC, h.history_id DESC`
+
+	return s.queryDetailHistory(ctx, q, assetMasterID)
+}
+
+// addressInUseError は書き込もうとした MAC / IP を他の資産が現在使っているとき
+type addressInUseError struct {
+	Field            string
+	Value            string
+	ManagementNumber string
+}
+
+func (e *addressInUseError) Error() string {
+	return fmt.Sprintf("%s %s is already assigned to %s", e.Field, e.Value, e.ManagementNumber)
+}
+
+// lockAddressConflictsTx は addrs の MAC / IP を他の資産が現在使っていないかを、該当行をロックしたうえで確かめる。
+// 使われていれば *addressInUseError を返す
+func lockAddressConflictsTx(ctx context.Context, tx platformdb.DBTX, assetMasterID uint64, addrs []computerAddressInput) error {
+	macs := make([]string, 0, len(addrs))
+	ips := make([]string, 0, len(addrs))
+	for _, a := range addrs {
+		if a.MACAddress != nil {
+			macs = append(macs, *a.MACAddress)
+		}
+		if a.IPAddress != nil {
+			ips = append(ips, *a.IPAddress)
 		}
 	}
+	if len(macs) == 0 && len(ips) == 0 {
+		return nil
+	}
 
-	return s.GetComputerDetailByAssetMasterID(ctx, assetMasterID)
+	conflicts, err := findAddressConflictsTx(ctx, tx, assetMasterID, macs, ips)
+	if err != nil {
+		return err
+	}
+	for _, c := range conflicts {
+		if c.MACAddress != nil && containsString(macs, *c.MACAddress) {
+			return &addressInUseError{Field: "mac_address", Value: *c.MACAddress, ManagementNumber: c.ManagementNumber}
+		}
+		if c.IPAddress != nil && containsString(ips, *c.IPAddress) {
+			return &addressInUseError{Field: "ip_address", Value: *c.IPAddress, ManagementNumber: c.ManagementNumber}
+		}
+	}
+	return nil
+}
+
+// findAddressConflictsTx は他の資産が現在使っている MAC / IP のうち、macs / ips に含まれるものを FOR UPDATE で返す。
+// computer_addresses 導入前のまま触られていない computer_details の値も対象にする
+func findAddressConflictsTx(ctx context.Context, tx platformdb.DBTX, assetMasterID uint64, macs []string, ips []string) ([]addressConflict, error) {
+	addrCond, addrArgs := addressMatchCondition("a.mac_address", "a.ip_address", macs, ips)
+	legacyCond, legacyArgs := addressMatchCondition("LOWER(REPLACE(cd.mac_address, '-', ':'))", "cd.ip_address", macs, ips)
+
+	q := `
+	(SELECT a.asset_master_id, am.management_number, a.mac_address, a.ip_address
+	FROM computer_addresses a
+	JOIN assets_master am ON am.asset_master_id = a.asset_master_id
+	WHERE a.effective_to IS NULL
+		AND a.asset_master_id <> ?
+		AND ` + addrCond + `
+	FOR UPDATE)
+	UNION ALL
+	(SELECT cd.asset_master_id, am.management_number, LOWER(REPLACE(cd.mac_address, '-', ':')), cd.ip_address
+	FROM computer_details cd
+	JOIN assets_master am ON am.asset_master_id = cd.asset_master_id
+	WHERE cd.asset_master_id <> ?
+		AND NOT EXISTS (SELECT 1 FROM computer_addresses a2 WHERE a2.asset_master_id = cd.asset_master_id)
+		AND ` + legacyCond + `
+	FOR UPDATE)`
+
+	args := make([]any, 0, 2+len(addrArgs)+len(legacyArgs))
+	args = append(args, assetMasterID)
+	args = append(args, addrArgs...)
+	args = append(args, assetMasterID)
+	args = append(args, legacyArgs...)
+
+	rows, err := tx.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]addressConflict, 0, 1)
+	for rows.Next() {
+		var c addressConflict
+		var mac, ip sql.NullString
+		if err := rows.Scan(&c.AssetMasterID, &c.ManagementNumber, &mac, &ip); err != nil {
+			return nil, err
+		}
+		c.MACAddress = ptrString(mac)
+		c.IPAddress = ptrString(ip)
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FindComputerAddressesByIP は時刻 at に ip を使っていた NIC を返す
+func (s *Store) FindComputerAddressesByIP(ctx context.Context, ip string, at time.Time) ([]ComputerAddressLookupResponse, error) {
+	return s.queryAddressLookup(ctx, "a.ip_address = ?", ip, at)
+}
+
+// FindComputerAddressesByMAC は時刻 at に mac を使っていた NIC を返す
+func (s *Store) FindComputerAddressesByMAC(ctx context.Context, mac string, at time.Time) ([]ComputerAddressLookupResponse, error) {
+	return s.queryAddressLookup(ctx, "a.mac_address = ?", mac, at)
+}
+
+func (s *Store) queryAddressLookup(ctx context.Context, cond string, value string, at time.Time) ([]ComputerAddressLookupResponse, error) {
+	q := `
+	SELECT
+		a.asset_master_id,
+		am.management_number,
+		am.name,
+		h.hostname,
+		a.interface_name,
+		a.mac_address,
+		a.ip_address,
+		a.is_primary,
+		a.effective_from,
+		a.effective_to
+	FROM computer_addresses a
+	JOIN assets_master am ON am.asset_master_id = a.asset_master_id
+	LEFT JOIN computer_detail_history h
+		ON h.asset_master_id = a.asset_master_id
+		AND h.effective_from <= ?
+		AND (h.effective_to IS NULL OR h.effective_to > ?)
+	WHERE ` + cond + `
+		AND a.effective_from <= ?
+		AND (a.effective_to IS NULL OR a.effective_to > ?)
+	ORDER BY a.effective_from DESC, a.address_id DESC`
+
+	rows, err := s.db.QueryContext(ctx, q, at, at, value, at, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]ComputerAddressLookupResponse, 0, 2)
+	for rows.Next() {
+		var item ComputerAddressLookupResponse
+		var hostname, iface, mac, ip sql.NullString
+		var effectiveTo sql.NullTime
+		if err := rows.Scan(
+			&item.AssetMasterID,
+			&item.ManagementNumber,
+			&item.AssetName,
+			&hostname,
+			&iface,
+			&mac,
+			&ip,
+			&item.IsPrimary,
+			&item.EffectiveFrom,
+			&effectiveTo,
+		); err != nil {
+			return nil, err
+		}
+		item.Hostname = ptrString(hostname)
+		item.InterfaceName = ptrString(iface)
+		item.MACAddress = ptrString(mac)
+		item.IPAddress = ptrString(ip)
+		item.EffectiveTo = ptrTime(effectiveTo)
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// attachAddresses は各 computer_details に現在の NIC 一覧を主 NIC 先頭で詰める
+func (s *Store) attachAddresses(ctx context.Context, items []ComputerDetailResponse) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	index := make(map[uint64]int, len(items))
+	placeholders := make([]string, 0, len(items))
+	args := make([]any, 0, len(items))
+	for i := range items {
+		items[i].Addresses = []ComputerAddressResponse{}
+		index[items[i].AssetMasterID] = i
+		placeholders = append(placeholders, "?")
+		args = append(args, items[i].AssetMasterID)
+	}
+
+	q := `
+	SELECT asset_master_id, interface_name, mac_address, ip_address, is_primary
+	FROM computer_addresses
+	WHERE effective_to IS NULL
+		AND asset_master_id IN (` + strings.Join(placeholders, ", ") + `)
+	ORDER BY asset_master_id, is_primary DESC, address_id`
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	found := make(map[uint64]bool, len(items))
+	for rows.Next() {
+		var assetMasterID uint64
+		var a ComputerAddressResponse
+		var iface, mac, ip sql.NullString
+		if err := rows.Scan(&assetMasterID, &iface, &mac, &ip, &a.IsPrimary); err != nil {
+			return err
+		}
+		a.InterfaceName = ptrString(iface)
+		a.MACAddress = ptrString(mac)
+		a.IPAddress = ptrString(ip)
+		if i, ok := index[assetMasterID]; ok {
+			items[i].Addresses = append(items[i].Addresses, a)
+			found[assetMasterID] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// computer_addresses 導入前のデータは単一カラムの値を主 NIC として見せる
+	for i := range items {
+		if found[items[i].AssetMasterID] || (items[i].IPAddress == nil && items[i].MACAddress == nil) {
+			continue
+		}
+		items[i].Addresses = []ComputerAddressResponse{{
+			MACAddress: items[i].MACAddress,
+			IPAddress:  items[i].IPAddress,
+			IsPrimary:  true,
+		}}
+	}
+	return nil
 }
 
 func (s *Store) CreateComputerPart(ctx context.Context, in createComputerPartInput) (*ComputerPartResponse, error) {
 	const q = `
 	INSERT INTO computer_parts
-		(asset_master_id, usage_status_id, spec, note)
-	VALUES (?, ?, ?, ?)`
+		(asset_master_id, usage_status_id, part_type_id, spec, spec_attributes, note)
+	VALUES (?, ?, ?, ?, ?, ?)`
 
+	attrs, err := marshalSpecAttributes(in.SpecAttributes)
+	if err != nil {
+		return nil, err
+	}
 	if _, err := s.db.ExecContext(ctx, q,
 		in.AssetMasterID,
 		in.UsageStatusID,
+		in.PartTypeID,
 		in.Specification,
+		attrs,
 		in.Note,
 	); err != nil {
 		return nil, err
@@ -150,8 +514,22 @@ func (s *Store) CreateComputerPart(ctx context.Context, in createComputerPartInp
 	return s.GetComputerPartByAssetMasterID(ctx, in.AssetMasterID)
 }
 
-func (s *Store) GetComputerPartByAssetMasterID(ctx context.Context, assetMasterID uint64) (*ComputerPartResponse, error) {
-	const q = `
+const computerPartFrom = `
+	FROM computer_parts cp
+	JOIN assets_master am ON am.asset_master_id = cp.asset_master_id
+	JOIN usage_status us ON us.usage_status_id = cp.usage_status_id
+	LEFT JOIN computer_configurations cc ON cc.computer_configuration_id = (
+		SELECT cc2.computer_configuration_id
+		FROM computer_configurations cc2
+		WHERE cc2.part_asset_master_id = cp.asset_master_id
+			AND cc2.removed_at IS NULL
+		ORDER BY cc2.computer_configuration_id DESC
+		LIMIT 1
+	)
+	LEFT JOIN part_types pt ON pt.part_type_id = cc.part_type_id
+	LEFT JOIN part_types spt ON spt.part_type_id = cp.part_type_id`
+
+const computerPartSelect = `
 	SELECT
 		cp.computer_part_id,
 		cp.asset_master_id,
@@ -166,19 +544,13 @@ func (s *Store) GetComputerPartByAssetMasterID(ctx context.Context, assetMasterI
 		cp.spec,
 		cp.note,
 		cp.created_at,
-		cp.updated_at
-	FROM computer_parts cp
-	JOIN assets_master am ON am.asset_master_id = cp.asset_master_id
-	JOIN usage_status us ON us.usage_status_id = cp.usage_status_id
-	LEFT JOIN computer_configurations cc ON cc.computer_configuration_id = (
-		SELECT cc2.computer_configuration_id
-		FROM computer_configurations cc2
-		WHERE cc2.part_asset_master_id = cp.asset_master_id
-			AND cc2.removed_at IS NULL
-		ORDER BY cc2.computer_configuration_id DESC
-		LIMIT 1
-	)
-	LEFT JOIN part_types pt ON pt.part_type_id = cc.part_type_id
+		cp.updated_at,
+		cp.part_type_id,
+		spt.name,
+		cp.spec_attributes` + computerPartFrom
+
+func (s *Store) GetComputerPartByAssetMasterID(ctx context.Context, assetMasterID uint64) (*ComputerPartResponse, error) {
+	q := computerPartSelect + `
 	WHERE cp.asset_master_id = ?`
 
 	row := s.db.QueryRowContext(ctx, q, assetMasterID)
@@ -189,15 +561,84 @@ func (s *Store) GetComputerPartByAssetMasterID(ctx context.Context, assetMasterI
 	return &item, nil
 }
 
+// ListComputerParts は computer_parts を条件で絞り込んでページングして返す
+func (s *Store) ListComputerParts(ctx context.Context, p Page, f ComputerPartFilter) ([]ComputerPartResponse, int64, error) {
+	where := []string{"1=1"}
+	args := make([]any, 0, 6)
+
+	if f.Q != nil {
+		like := "%" + escapeLike(*f.Q) + "%"
+		where = append(where, "(cp.spec LIKE ? OR cp.note LIKE ? OR am.management_number LIKE ? OR am.name LIKE ?)")
+		args = append(args, like, like, like, like)
+	}
+	if f.UsageStatusID != nil {
+		where = append(where, "cp.usage_status_id = ?")
+		args = append(args, *f.UsageStatusID)
+	}
+	if f.ActivePartTypeID != nil {
+		where = append(where, "cc.part_type_id = ?")
+		args = append(args, *f.ActivePartTypeID)
+	}
+	if f.Installed != nil {
+		if *f.Installed {
+			where = append(where, "cc.computer_configuration_id IS NOT NULL")
+		} else {
+			where = append(where, "cc.computer_configuration_id IS NULL")
+		}
+	}
+	appendLikeFilter("cp.spec", f.Spec, &where, &args)
+
+	whereSQL := " WHERE " + strings.Join(where, " AND ")
+	q := computerPartSelect + whereSQL + `
+	ORDER BY cp.asset_master_id ` + orderSQL(p.Order) + `
+	LIMIT ? OFFSET ?`
+
+	rows, err := s.db.QueryContext(ctx, q, append(append([]any{}, args...), p.Limit, p.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	out := make([]ComputerPartResponse, 0, p.Limit)
+	for rows.Next() {
+		item, err := scanComputerPart(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.count(ctx, "SELECT COUNT(*)"+computerPartFrom+whereSQL, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
+
 func (s *Store) UpdateComputerPartByAssetMasterID(ctx context.Context, assetMasterID uint64, patch updateComputerPartInput) (*ComputerPartResponse, error) {
-	sets := make([]string, 0, 3)
-	args := make([]any, 0, 3)
+	sets := make([]string, 0, 5)
+	args := make([]any, 0, 5)
 
 	if patch.UsageStatusID != nil {
 		sets = append(sets, "usage_status_id = ?")
 		args = append(args, *patch.UsageStatusID)
 	}
+	if patch.PartTypeID != nil {
+		sets = append(sets, "part_type_id = ?")
+		args = append(args, *patch.PartTypeID)
+	}
 	appendNullableStringUpdate("spec", patch.Specification, &sets, &args)
+	if patch.SpecAttributes != nil {
+		attrs, err := marshalSpecAttributes(patch.SpecAttributes)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, "spec_attributes = ?")
+		args = append(args, attrs)
+	}
 	appendNullableStringUpdate("note", patch.Note, &sets, &args)
 
 	if len(sets) == 0 {
@@ -219,25 +660,49 @@ func (s *Store) UpdateComputerPartByAssetMasterID(ctx context.Context, assetMast
 	return s.GetComputerPartByAssetMasterID(ctx, assetMasterID)
 }
 
+// CreateComputerConfiguration は取り付け中の構成なら、ロックを取ってから部品の使用中と搭載上限を数え直して登録する
 func (s *Store) CreateComputerConfiguration(ctx context.Context, in createComputerConfigurationInput) (*ComputerConfigurationResponse, error) {
 	const q = `
 	INSERT INTO computer_configurations
 		(computer_asset_master_id, part_asset_master_id, part_type_id, installed_at, removed_at, note)
 	VALUES (?, ?, ?, ?, ?, ?)`
 
-	res, err := s.db.ExecContext(ctx, q,
-		in.ComputerAssetMasterID,
-		in.PartAssetMasterID,
-		in.PartTypeID,
-		in.InstalledAt,
-		in.RemovedAt,
-		in.Note,
-	)
-	if err != nil {
-		return nil, err
-	}
+	var id int64
+	err := platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		if in.RemovedAt == nil {
+			if err := lockComputerSlotsTx(ctx, tx, in.ComputerAssetMasterID, in.PartAssetMasterID); err != nil {
+				return err
+			}
+			inUse, err := activeConfigurationExistsForPart(ctx, tx, in.PartAssetMasterID, nil)
+			if err != nil {
+				return err
+			}
+			if inUse {
+				return errPartInUse
+			}
+			active, err := countActiveConfigurationsForComputerPartType(ctx, tx, in.ComputerAssetMasterID, in.PartTypeID, nil)
+			if err != nil {
+				return err
+			}
+			if uint(active) >= in.SlotLimit {
+				return errSlotInUse
+			}
+		}
 
-	id, err := res.LastInsertId()
+		res, err := tx.ExecContext(ctx, q,
+			in.ComputerAssetMasterID,
+			in.PartAssetMasterID,
+			in.PartTypeID,
+			in.InstalledAt,
+			in.RemovedAt,
+			in.Note,
+		)
+		if err != nil {
+			return err
+		}
+		id, err = res.LastInsertId()
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -245,13 +710,36 @@ func (s *Store) CreateComputerConfiguration(ctx context.Context, in createComput
 	return s.GetComputerConfigurationByID(ctx, uint64(id))
 }
 
-func (s *Store) GetComputerConfigurationByID(ctx context.Context, computerConfigurationID uint64) (*ComputerConfigurationResponse, error) {
-	const q = `
-	SELECT
-		c.computer_configuration_id,
-		c.computer_asset_master_id,
-		cm.management_number,
-		cm.name,
+// lockComputerSlotsTx は端末と部品のマスタ行、端末の取り付け中の構成行を FOR UPDATE でロックする。
+// 構成がまだ無い端末でも同時の取り付け・交換が直列になるよう、マスタ行も id 順に押さえる
+func lockComputerSlotsTx(ctx context.Context, tx platformdb.DBTX, computerAssetMasterID, partAssetMasterID uint64) error {
+	ids := []uint64{computerAssetMasterID, partAssetMasterID}
+	slices.Sort(ids)
+	for _, id := range ids {
+		var one int
+		if err := tx.QueryRowContext(ctx, "SELECT 1 FROM assets_master WHERE asset_master_id = ? FOR UPDATE", id).Scan(&one); err != nil {
+			return err
+		}
+	}
+	rows, err := tx.QueryContext(ctx,
+		"SELECT computer_configuration_id FROM computer_configurations WHERE computer_asset_master_id = ? AND removed_at IS NULL FOR UPDATE",
+		computerAssetMasterID,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+	}
+	return rows.Err()
+}
+
+const computerConfigurationSelect = `
+	SELECT
+		c.computer_configuration_id,
+		c.computer_asset_master_id,
+		cm.management_number,
+		cm.name,
 		c.part_asset_master_id,
 		pm.management_number,
 		pm.name,
@@ -266,50 +754,1211 @@ func (s *Store) GetComputerConfigurationByID(ctx context.Context, computerConfig
 	FROM computer_configurations c
 	JOIN assets_master cm ON cm.asset_master_id = c.computer_asset_master_id
 	JOIN assets_master pm ON pm.asset_master_id = c.part_asset_master_id
-	JOIN part_types pt ON pt.part_type_id = c.part_type_id
+	JOIN part_types pt ON pt.part_type_id = c.part_type_id`
+
+func (s *Store) GetComputerConfigurationByID(ctx context.Context, computerConfigurationID uint64) (*ComputerConfigurationResponse, error) {
+	q := computerConfigurationSelect + `
 	WHERE c.computer_configuration_id = ?`
 
-	return s.queryComputerConfiguration(ctx, q, computerConfigurationID)
+	return s.queryComputerConfiguration(ctx, q, computerConfigurationID)
+}
+
+func (s *Store) ListComputerConfigurationsByComputerAssetMasterID(ctx context.Context, computerAssetMasterID uint64) ([]ComputerConfigurationResponse, error) {
+	q := computerConfigurationSelect + `
+	WHERE c.computer_asset_master_id = ?
+	ORDER BY c.removed_at IS NULL DESC, c.part_type_id ASC, c.computer_configuration_id DESC`
+
+	rows, err := s.db.QueryContext(ctx, q, computerAssetMasterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]ComputerConfigurationResponse, 0, 8)
+	for rows.Next() {
+		item, err := scanComputerConfiguration(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListComputerConfigurations は computer_configurations を条件で絞り込んでページングして返す
+func (s *Store) ListComputerConfigurations(ctx context.Context, p Page, f ComputerConfigurationFilter) ([]ComputerConfigurationResponse, int64, error) {
+	where := []string{"1=1"}
+	args := make([]any, 0, 6)
+
+	if f.ComputerAssetMasterID != nil {
+		where = append(where, "c.computer_asset_master_id = ?")
+		args = append(args, *f.ComputerAssetMasterID)
+	}
+	if f.PartAssetMasterID != nil {
+		where = append(where, "c.part_asset_master_id = ?")
+		args = append(args, *f.PartAssetMasterID)
+	}
+	if f.PartTypeID != nil {
+		where = append(where, "c.part_type_id = ?")
+		args = append(args, *f.PartTypeID)
+	}
+	if f.Active != nil {
+		if *f.Active {
+			where = append(where, "c.removed_at IS NULL")
+		} else {
+			where = append(where, "c.removed_at IS NOT NULL")
+		}
+	}
+	if f.InstalledFrom != nil {
+		where = append(where, "c.installed_at >= ?")
+		args = append(args, *f.InstalledFrom)
+	}
+	if f.InstalledTo != nil {
+		where = append(where, "c.installed_at < ?")
+		args = append(args, *f.InstalledTo)
+	}
+
+	whereSQL := " WHERE " + strings.Join(where, " AND ")
+	q := computerConfigurationSelect + whereSQL + `
+	ORDER BY c.computer_configuration_id ` + orderSQL(p.Order) + `
+	LIMIT ? OFFSET ?`
+
+	rows, err := s.db.QueryContext(ctx, q, append(append([]any{}, args...), p.Limit, p.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	out := make([]ComputerConfigurationResponse, 0, p.Limit)
+	for rows.Next() {
+		item, err := scanComputerConfiguration(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.count(ctx, "SELECT COUNT(*) FROM computer_configurations c"+whereSQL, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
+
+func (s *Store) UpdateComputerConfigurationByID(ctx context.Context, computerConfigurationID uint64, patch updateComputerConfigurationInput) (*ComputerConfigurationResponse, error) {
+	sets := make([]string, 0, 5)
+	args := make([]any, 0, 5)
+
+	if patch.PartAssetMasterID != nil {
+		sets = append(sets, "part_asset_master_id = ?")
+		args = append(args, *patch.PartAssetMasterID)
+	}
+	if patch.PartTypeID != nil {
+		sets = append(sets, "part_type_id = ?")
+		args = append(args, *patch.PartTypeID)
+	}
+	appendNullableTimeUpdate("installed_at", patch.InstalledAt, &sets, &args)
+	appendNullableTimeUpdate("removed_at", patch.RemovedAt, &sets, &args)
+	appendNullableStringUpdate("note", patch.Note, &sets, &args)
+
+	if len(sets) == 0 {
+		return s.GetComputerConfigurationByID(ctx, computerConfigurationID)
+	}
+
+	args = append(args, computerConfigurationID)
+	q := fmt.Sprintf("UPDATE computer_configurations SET %s WHERE computer_configuration_id = ?", strings.Join(sets, ", "))
+	res, err := s.db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		if _, err := s.GetComputerConfigurationByID(ctx, computerConfigurationID); err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+	}
+
+	return s.GetComputerConfigurationByID(ctx, computerConfigurationID)
+}
+
+func (s *Store) ActiveConfigurationExistsForPart(ctx context.Context, partAssetMasterID uint64, excludeID *uint64) (bool, error) {
+	return activeConfigurationExistsForPart(ctx, s.db, partAssetMasterID, excludeID)
+}
+
+func (s *Store) CountActiveConfigurationsForComputerPartType(ctx context.Context, computerAssetMasterID uint64, partTypeID uint, excludeID *uint64) (int, error) {
+	return countActiveConfigurationsForComputerPartType(ctx, s.db, computerAssetMasterID, partTypeID, excludeID)
+}
+
+// GetSlotLimit は端末の機種に合う搭載上限を返す。機種別 → 全機種の既定 → defaultSlotLimit の順に探す
+func (s *Store) GetSlotLimit(ctx context.Context, computerAssetMasterID uint64, partTypeID uint) (slotLimit, error) {
+	const q = `
+	SELECT l.model, pt.name, l.max_count
+	FROM assets_master am
+	JOIN part_types pt ON pt.part_type_id = ?
+	LEFT JOIN part_slot_limits l
+		ON l.part_type_id = pt.part_type_id
+		AND (l.model IS NULL OR l.model = am.model)
+	WHERE am.asset_master_id = ?
+	ORDER BY l.model IS NULL ASC
+	LIMIT 1`
+
+	var model sql.NullString
+	var maxCount sql.NullInt64
+	out := slotLimit{MaxCount: defaultSlotLimit}
+	err := s.db.QueryRowContext(ctx, q, partTypeID, computerAssetMasterID).Scan(&model, &out.PartTypeName, &maxCount)
+	if err == sql.ErrNoRows {
+		return out, nil
+	}
+	if err != nil {
+		return slotLimit{}, err
+	}
+	// 全機種の既定や defaultSlotLimit が当たったときは機種名を付けない
+	out.Model = ptrString(model)
+	if maxCount.Valid {
+		out.MaxCount = uint(maxCount.Int64)
+	}
+	return out, nil
+}
+
+const partSlotLimitSelect = `
+	SELECT
+		l.slot_limit_id,
+		l.model,
+		l.part_type_id,
+		pt.name,
+		l.max_count,
+		l.note,
+		l.created_at,
+		l.updated_at
+	FROM part_slot_limits l
+	JOIN part_types pt ON pt.part_type_id = l.part_type_id`
+
+func (s *Store) ListPartSlotLimits(ctx context.Context, partTypeID *uint) ([]PartSlotLimitResponse, error) {
+	q := partSlotLimitSelect
+	args := make([]any, 0, 1)
+	if partTypeID != nil {
+		q += ` WHERE l.part_type_id = ?`
+		args = append(args, *partTypeID)
+	}
+	q += ` ORDER BY l.part_type_id ASC, l.model IS NULL DESC, l.model ASC`
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]PartSlotLimitResponse, 0, 8)
+	for rows.Next() {
+		item, err := scanPartSlotLimit(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *Store) GetPartSlotLimitByID(ctx context.Context, slotLimitID uint64) (*PartSlotLimitResponse, error) {
+	item, err := scanPartSlotLimit(s.db.QueryRowContext(ctx, partSlotLimitSelect+` WHERE l.slot_limit_id = ?`, slotLimitID))
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (s *Store) CreatePartSlotLimit(ctx context.Context, in partSlotLimitInput) (*PartSlotLimitResponse, error) {
+	res, err := s.db.ExecContext(ctx,
+		"INSERT INTO part_slot_limits (model, part_type_id, max_count, note) VALUES (?, ?, ?, ?)",
+		in.Model, in.PartTypeID, in.MaxCount, in.Note,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPartSlotLimitByID(ctx, uint64(id))
+}
+
+func (s *Store) UpdatePartSlotLimitByID(ctx context.Context, slotLimitID uint64, in partSlotLimitInput) (*PartSlotLimitResponse, error) {
+	if _, err := s.GetPartSlotLimitByID(ctx, slotLimitID); err != nil {
+		return nil, err
+	}
+	if _, err := s.db.ExecContext(ctx,
+		"UPDATE part_slot_limits SET model = ?, part_type_id = ?, max_count = ?, note = ? WHERE slot_limit_id = ?",
+		in.Model, in.PartTypeID, in.MaxCount, in.Note, slotLimitID,
+	); err != nil {
+		return nil, err
+	}
+	return s.GetPartSlotLimitByID(ctx, slotLimitID)
+}
+
+func (s *Store) DeletePartSlotLimitByID(ctx context.Context, slotLimitID uint64) error {
+	return s.deleteByID(ctx, "DELETE FROM part_slot_limits WHERE slot_limit_id = ?", slotLimitID)
+}
+
+const compatibilityRuleSelect = `
+	SELECT
+		r.rule_id,
+		r.name,
+		r.model,
+		r.part_type_id,
+		pt.name,
+		r.attribute,
+		r.kind,
+		r.allowed_values,
+		r.note,
+		r.created_at,
+		r.updated_at
+	FROM part_compatibility_rules r
+	JOIN part_types pt ON pt.part_type_id = r.part_type_id`
+
+func (s *Store) ListCompatibilityRules(ctx context.Context, partTypeID *uint) ([]CompatibilityRuleResponse, error) {
+	q := compatibilityRuleSelect
+	args := make([]any, 0, 1)
+	if partTypeID != nil {
+		q += ` WHERE r.part_type_id = ?`
+		args = append(args, *partTypeID)
+	}
+	q += ` ORDER BY r.part_type_id ASC, r.rule_id ASC`
+	return s.queryCompatibilityRules(ctx, q, args...)
+}
+
+// ListApplicableCompatibilityRules は端末の機種と部品種別に当てはまるルールを返す（model が NULL のルールは全機種に適用）
+func (s *Store) ListApplicableCompatibilityRules(ctx context.Context, computerAssetMasterID uint64, partTypeID uint) ([]CompatibilityRuleResponse, error) {
+	q := compatibilityRuleSelect + `
+	JOIN assets_master am ON am.asset_master_id = ?
+	WHERE r.part_type_id = ?
+		AND (r.model IS NULL OR r.model = am.model)
+	ORDER BY r.rule_id ASC`
+	return s.queryCompatibilityRules(ctx, q, computerAssetMasterID, partTypeID)
+}
+
+func (s *Store) GetCompatibilityRuleByID(ctx context.Context, ruleID uint64) (*CompatibilityRuleResponse, error) {
+	item, err := scanCompatibilityRule(s.db.QueryRowContext(ctx, compatibilityRuleSelect+` WHERE r.rule_id = ?`, ruleID))
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (s *Store) CreateCompatibilityRule(ctx context.Context, in compatibilityRuleInput) (*CompatibilityRuleResponse, error) {
+	allowed, err := json.Marshal(in.AllowedValues)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.db.ExecContext(ctx, `
+	INSERT INTO part_compatibility_rules
+		(name, model, part_type_id, attribute, kind, allowed_values, note)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		in.Name, in.Model, in.PartTypeID, in.Attribute, in.Kind, string(allowed), in.Note,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return s.GetCompatibilityRuleByID(ctx, uint64(id))
+}
+
+func (s *Store) UpdateCompatibilityRuleByID(ctx context.Context, ruleID uint64, in compatibilityRuleInput) (*CompatibilityRuleResponse, error) {
+	if _, err := s.GetCompatibilityRuleByID(ctx, ruleID); err != nil {
+		return nil, err
+	}
+	allowed, err := json.Marshal(in.AllowedValues)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.db.ExecContext(ctx, `
+	UPDATE part_compatibility_rules
+	SET name = ?, model = ?, part_type_id = ?, attribute = ?, kind = ?, allowed_values = ?, note = ?
+	WHERE rule_id = ?`,
+		in.Name, in.Model, in.PartTypeID, in.Attribute, in.Kind, string(allowed), in.Note, ruleID,
+	); err != nil {
+		return nil, err
+	}
+	return s.GetCompatibilityRuleByID(ctx, ruleID)
+}
+
+func (s *Store) DeleteCompatibilityRuleByID(ctx context.Context, ruleID uint64) error {
+	return s.deleteByID(ctx, "DELETE FROM part_compatibility_rules WHERE rule_id = ?", ruleID)
+}
+
+func (s *Store) queryCompatibilityRules(ctx context.Context, query string, args ...any) ([]CompatibilityRuleResponse, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]CompatibilityRuleResponse, 0, 8)
+	for rows.Next() {
+		item, err := scanCompatibilityRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *Store) deleteByID(ctx context.Context, query string, id uint64) error {
+	res, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetActiveConfigurationForPart は computer に現在取り付けられている part の構成行を返す
+func (s *Store) GetActiveConfigurationForPart(ctx context.Context, computerAssetMasterID uint64, partAssetMasterID uint64) (*ComputerConfigurationResponse, error) {
+	q := computerConfigurationSelect + `
+	WHERE c.computer_asset_master_id = ? AND c.part_asset_master_id = ? AND c.removed_at IS NULL
+	ORDER BY c.computer_configuration_id DESC
+	LIMIT 1`
+
+	return s.queryComputerConfiguration(ctx, q, computerAssetMasterID, partAssetMasterID)
+}
+
+// SwapComputerPart は旧部品の取り外し・新部品の取り付け・旧部品の usage_status 更新・交換記録を
+// 1トランザクションで行う。途中で他の操作と競合したら何も反映しない
+func (s *Store) SwapComputerPart(ctx context.Context, in partSwapInput) (*PartSwapResponse, error) {
+	var swapID uint64
+	err := platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		if err := lockComputerSlotsTx(ctx, tx, in.ComputerAssetMasterID, in.InstalledPartAssetMasterID); err != nil {
+			return err
+		}
+		var removedAt sql.NullTime
+		if err := tx.QueryRowContext(ctx,
+			"SELECT removed_at FROM computer_configurations WHERE computer_configuration_id = ? FOR UPDATE",
+			in.RemovedConfigurationID,
+		).Scan(&removedAt); err != nil {
+			return err
+		}
+		if removedAt.Valid {
+			return errSwapConfigurationClosed
+		}
+
+		inUse, err := activeConfigurationExistsForPart(ctx, tx, in.InstalledPartAssetMasterID, nil)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return errPartInUse
+		}
+		active, err := countActiveConfigurationsForComputerPartType(ctx, tx, in.ComputerAssetMasterID, in.PartTypeID, &in.RemovedConfigurationID)
+		if err != nil {
+			return err
+		}
+		if uint(active) >= in.SlotLimit {
+			return errSlotInUse
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE computer_configurations SET removed_at = ? WHERE computer_configuration_id = ?",
+			in.SwappedAt, in.RemovedConfigurationID,
+		); err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, `
+		INSERT INTO computer_configurations
+			(computer_asset_master_id, part_asset_master_id, part_type_id, installed_at, removed_at, note)
+		VALUES (?, ?, ?, ?, NULL, NULL)`,
+			in.ComputerAssetMasterID, in.InstalledPartAssetMasterID, in.PartTypeID, in.SwappedAt,
+		)
+		if err != nil {
+			return err
+		}
+		installedID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE computer_parts SET usage_status_id = ? WHERE asset_master_id = ?",
+			in.RemovedUsageStatusID, in.RemovedPartAssetMasterID,
+		); err != nil {
+			return err
+		}
+
+		res, err = tx.ExecContext(ctx, `
+		INSERT INTO computer_part_swaps
+			(computer_asset_master_id, part_type_id, removed_configuration_id, installed_configuration_id,
+			 removed_part_asset_master_id, installed_part_asset_master_id, removed_usage_status_id,
+			 reason, swapped_at, swapped_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			in.ComputerAssetMasterID,
+			in.PartTypeID,
+			in.RemovedConfigurationID,
+			installedID,
+			in.RemovedPartAssetMasterID,
+			in.InstalledPartAssetMasterID,
+			in.RemovedUsageStatusID,
+			in.Reason,
+			in.SwappedAt,
+			nullIfEmpty(in.SwappedBy),
+		)
+		if err != nil {
+			return err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		swapID = uint64(id)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetPartSwapByID(ctx, swapID)
+}
+
+func (s *Store) GetPartSwapByID(ctx context.Context, partSwapID uint64) (*PartSwapResponse, error) {
+	const q = `
+	SELECT
+		part_swap_id,
+		computer_asset_master_id,
+		part_type_id,
+		removed_configuration_id,
+		installed_configuration_id,
+		removed_usage_status_id,
+		reason,
+		swapped_at,
+		swapped_by,
+		created_at
+	FROM computer_part_swaps
+	WHERE part_swap_id = ?`
+
+	var out PartSwapResponse
+	var removedID, installedID uint64
+	var reason, swappedBy sql.NullString
+	if err := s.db.QueryRowContext(ctx, q, partSwapID).Scan(
+		&out.PartSwapID,
+		&out.ComputerAssetMasterID,
+		&out.PartTypeID,
+		&removedID,
+		&installedID,
+		&out.RemovedUsageStatusID,
+		&reason,
+		&out.SwappedAt,
+		&swappedBy,
+		&out.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	out.Reason = ptrString(reason)
+	out.SwappedBy = ptrString(swappedBy)
+
+	removed, err := s.GetComputerConfigurationByID(ctx, removedID)
+	if err != nil {
+		return nil, err
+	}
+	installed, err := s.GetComputerConfigurationByID(ctx, installedID)
+	if err != nil {
+		return nil, err
+	}
+	out.Removed = *removed
+	out.Installed = *installed
+	return &out, nil
+}
+
+const partTypeSelect = `
+	SELECT part_type_id, name, display_name, display_name_en, spec_schema, is_disabled, note
+	FROM part_types`
+
+func (s *Store) ListPartTypes(ctx context.Context, includeDisabled bool) ([]PartTypeResponse, error) {
+	q := partTypeSelect
+	if !includeDisabled {
+		q += ` WHERE is_disabled = 0`
+	}
+	q += ` ORDER BY part_type_id`
+
+	rows, err := s.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]PartTypeResponse, 0, 8)
+	for rows.Next() {
+		item, err := scanPartType(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *Store) GetPartTypeByID(ctx context.Context, partTypeID uint) (*PartTypeResponse, error) {
+	item, err := scanPartType(s.db.QueryRowContext(ctx, partTypeSelect+` WHERE part_type_id = ?`, partTypeID))
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (s *Store) CreatePartType(ctx context.Context, in createPartTypeInput) (*PartTypeResponse, error) {
+	schema, err := json.Marshal(in.SpecSchema)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.db.ExecContext(ctx, `
+	INSERT INTO part_types (name, display_name, display_name_en, spec_schema, note)
+	VALUES (?, ?, ?, ?, ?)`,
+		in.Name, in.DisplayName, in.DisplayNameEN, string(schema), in.Note,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPartTypeByID(ctx, uint(id))
+}
+
+// UpdatePartTypeByID は無効化するとき、稼働中の構成が残っていれば errReferenceInUse を返す
+func (s *Store) UpdatePartTypeByID(ctx context.Context, partTypeID uint, patch updatePartTypeInput) (*PartTypeResponse, error) {
+	sets := make([]string, 0, 6)
+	args := make([]any, 0, 6)
+
+	if patch.Name != nil {
+		sets = append(sets, "name = ?")
+		args = append(args, *patch.Name)
+	}
+	if patch.DisplayName != nil {
+		sets = append(sets, "display_name = ?")
+		args = append(args, *patch.DisplayName)
+	}
+	appendNullableStringUpdate("display_name_en", patch.DisplayNameEN, &sets, &args)
+	if patch.SpecSchema != nil {
+		schema, err := json.Marshal(*patch.SpecSchema)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, "spec_schema = ?")
+		args = append(args, string(schema))
+	}
+	appendNullableStringUpdate("note", patch.Note, &sets, &args)
+	if patch.IsDisabled != nil {
+		sets = append(sets, "is_disabled = ?")
+		args = append(args, *patch.IsDisabled)
+	}
+
+	err := platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		var dummy int
+		if err := tx.QueryRowContext(ctx, "SELECT 1 FROM part_types WHERE part_type_id = ? FOR UPDATE", partTypeID).Scan(&dummy); err != nil {
+			return err
+		}
+		if patch.IsDisabled != nil && *patch.IsDisabled {
+			inUse, err := existsIn(ctx, tx,
+				"SELECT 1 FROM computer_configurations WHERE part_type_id = ? AND removed_at IS NULL LIMIT 1", partTypeID)
+			if err != nil {
+				return err
+			}
+			if inUse {
+				return errReferenceInUse
+			}
+		}
+		if len(sets) == 0 {
+			return nil
+		}
+		q := fmt.Sprintf("UPDATE part_types SET %s WHERE part_type_id = ?", strings.Join(sets, ", "))
+		_, err := tx.ExecContext(ctx, q, append(args, partTypeID)...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPartTypeByID(ctx, partTypeID)
+}
+
+// GetPartTypeSpecSchema は部品種別の spec_schema を返す（未定義なら空）
+func (s *Store) GetPartTypeSpecSchema(ctx context.Context, partTypeID uint) ([]PartSpecField, error) {
+	var schema []byte
+	if err := s.db.QueryRowContext(ctx,
+		"SELECT spec_schema FROM part_types WHERE part_type_id = ?", partTypeID,
+	).Scan(&schema); err != nil {
+		return nil, err
+	}
+	return unmarshalSpecSchema(schema)
+}
+
+// ListBOMEntries は computer に取り付けられている構成行を返す。
+// at が nil なら現在、指定があれば installed_at <= at < removed_at の行を返す
+func (s *Store) ListBOMEntries(ctx context.Context, computerAssetMasterID uint64, at *time.Time) ([]bomEntry, error) {
+	q := `
+	SELECT
+		c.part_type_id,
+		pt.name,
+		pt.display_name,
+		pt.spec_schema,
+		c.computer_configuration_id,
+		c.part_asset_master_id,
+		pm.management_number,
+		pm.name,
+		c.installed_at,
+		c.removed_at,
+		cp.spec,
+		cp.spec_attributes
+	FROM computer_configurations c
+	JOIN assets_master pm ON pm.asset_master_id = c.part_asset_master_id
+	JOIN part_types pt ON pt.part_type_id = c.part_type_id
+	LEFT JOIN computer_parts cp ON cp.asset_master_id = c.part_asset_master_id
+	WHERE c.computer_asset_master_id = ?`
+	args := []any{computerAssetMasterID}
+	if at == nil {
+		q += ` AND c.removed_at IS NULL`
+	} else {
+		q += ` AND (c.installed_at IS NULL OR c.installed_at <= ?) AND (c.removed_at IS NULL OR c.removed_at > ?)`
+		args = append(args, *at, *at)
+	}
+	q += ` ORDER BY c.part_type_id ASC, c.installed_at ASC, c.computer_configuration_id ASC`
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]bomEntry, 0, 8)
+	for rows.Next() {
+		var e bomEntry
+		var schema, attrs []byte
+		var installedAt, removedAt sql.NullTime
+		var spec sql.NullString
+		if err := rows.Scan(
+			&e.PartTypeID,
+			&e.PartTypeName,
+			&e.PartTypeDisplayName,
+			&schema,
+			&e.Part.ComputerConfigurationID,
+			&e.Part.PartAssetMasterID,
+			&e.Part.ManagementNumber,
+			&e.Part.AssetName,
+			&installedAt,
+			&removedAt,
+			&spec,
+			&attrs,
+		); err != nil {
+			return nil, err
+		}
+		if e.SpecSchema, err = unmarshalSpecSchema(schema); err != nil {
+			return nil, err
+		}
+		if e.Part.SpecAttributes, err = unmarshalSpecAttributes(attrs); err != nil {
+			return nil, err
+		}
+		e.Part.InstalledAt = ptrTime(installedAt)
+		e.Part.RemovedAt = ptrTime(removedAt)
+		e.Part.Specification = ptrString(spec)
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+const usageStatusSelect = `
+	SELECT usage_status_id, name, display_name, display_name_en, is_disabled, note
+	FROM usage_status`
+
+func (s *Store) ListUsageStatuses(ctx context.Context, includeDisabled bool) ([]UsageStatusResponse, error) {
+	q := usageStatusSelect
+	if !includeDisabled {
+		q += ` WHERE is_disabled = 0`
+	}
+	q += ` ORDER BY usage_status_id`
+
+	rows, err := s.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]UsageStatusResponse, 0, 8)
+	for rows.Next() {
+		item, err := scanUsageStatus(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *Store) GetUsageStatusByID(ctx context.Context, usageStatusID uint) (*UsageStatusResponse, error) {
+	item, err := scanUsageStatus(s.db.QueryRowContext(ctx, usageStatusSelect+` WHERE usage_status_id = ?`, usageStatusID))
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (s *Store) CreateUsageStatus(ctx context.Context, in createUsageStatusInput) (*UsageStatusResponse, error) {
+	res, err := s.db.ExecContext(ctx, `
+	INSERT INTO usage_status (name, display_name, display_name_en, note)
+	VALUES (?, ?, ?, ?)`,
+		in.Name, in.DisplayName, in.DisplayNameEN, in.Note,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return s.GetUsageStatusByID(ctx, uint(id))
+}
+
+// UpdateUsageStatusByID は無効化するとき、その状態の部品が残っていれば errReferenceInUse を返す
+func (s *Store) UpdateUsageStatusByID(ctx context.Context, usageStatusID uint, patch updateUsageStatusInput) (*UsageStatusResponse, error) {
+	sets := make([]string, 0, 5)
+	args := make([]any, 0, 5)
+
+	if patch.Name != nil {
+		sets = append(sets, "name = ?")
+		args = append(args, *patch.Name)
+	}
+	if patch.DisplayName != nil {
+		sets = append(sets, "display_name = ?")
+		args = append(args, *patch.DisplayName)
+	}
+	appendNullableStringUpdate("display_name_en", patch.DisplayNameEN, &sets, &args)
+	appendNullableStringUpdate("note", patch.Note, &sets, &args)
+	if patch.IsDisabled != nil {
+		sets = append(sets, "is_disabled = ?")
+		args = append(args, *patch.IsDisabled)
+	}
+
+	err := platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		var dummy int
+		if err := tx.QueryRowContext(ctx, "SELECT 1 FROM usage_status WHERE usage_status_id = ? FOR UPDATE", usageStatusID).Scan(&dummy); err != nil {
+			return err
+		}
+		if patch.IsDisabled != nil && *patch.IsDisabled {
+			inUse, err := existsIn(ctx, tx, "SELECT 1 FROM computer_parts WHERE usage_status_id = ? LIMIT 1", usageStatusID)
+			if err != nil {
+				return err
+			}
+			if inUse {
+				return errReferenceInUse
+			}
+		}
+		if len(sets) == 0 {
+			return nil
+		}
+		q := fmt.Sprintf("UPDATE usage_status SET %s WHERE usage_status_id = ?", strings.Join(sets, ", "))
+		_, err := tx.ExecContext(ctx, q, append(args, usageStatusID)...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetUsageStatusByID(ctx, usageStatusID)
+}
+
+func (s *Store) FindAssetMasterIDsBySerial(ctx context.Context, serial string) ([]uint64, error) {
+	const q = `
+	SELECT DISTINCT asset_master_id
+	FROM assets
+	WHERE serial = ?
+	ORDER BY asset_master_id`
+
+	return s.queryIDs(ctx, q, serial)
+}
+
+// FindAssetMasterIDsByMAC は登録済み computer_details の MAC（表記揺れを吸収）から資産を引く
+func (s *Store) FindAssetMasterIDsByMAC(ctx context.Context, macAddresses []string) ([]uint64, error) {
+	if len(macAddresses) == 0 {
+		return nil, nil
+	}
+	placeholders := make([]string, 0, len(macAddresses))
+	args := make([]any, 0, len(macAddresses))
+	for _, mac := range macAddresses {
+		placeholders = append(placeholders, "?")
+		args = append(args, mac)
+	}
+
+	q := `
+	SELECT DISTINCT asset_master_id
+	FROM computer_details
+	WHERE LOWER(REPLACE(mac_address, '-', ':')) IN (` + strings.Join(placeholders, ", ") + `)
+	ORDER BY asset_master_id`
+
+	return s.queryIDs(ctx, q, args...)
+}
+
+func (s *Store) ApplyIngestReport(ctx context.Context, assetMasterID uint64, matchedBy string, in ingestReportInput) (*ComputerDetailResponse, error) {
+	err := platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		if err := applyIngestReportTx(ctx, tx, assetMasterID, in); err != nil {
+			return err
+		}
+		return insertIngestReportTx(ctx, tx, &assetMasterID, nil, &matchedBy, in)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetComputerDetailByAssetMasterID(ctx, assetMasterID)
+}
+
+// QueueIngestReview は同じマシン（fingerprint）の pending レビューがあれば最新の報告で上書きし、なければ作成する
+func (s *Store) QueueIngestReview(ctx context.Context, in ingestReviewInput) (uint64, error) {
+	var reviewID uint64
+	err := platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		macs := strings.Join(in.Report.MACAddresses, ",")
+
+		err := tx.QueryRowContext(ctx, `
+		SELECT review_id
+		FROM computer_ingest_reviews
+		WHERE fingerprint = ? AND status = ?
+		LIMIT 1
+		FOR UPDATE`, in.Fingerprint, ingestReviewPending).Scan(&reviewID)
+		switch {
+		case err == sql.ErrNoRows:
+			res, err := tx.ExecContext(ctx, `
+			INSERT INTO computer_ingest_reviews
+				(fingerprint, status, reason, hostname, serial, mac_addresses, payload, report_count, reported_by, first_seen_at, last_seen_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, 1, ?, ?, ?)`,
+				in.Fingerprint,
+				ingestReviewPending,
+				in.Reason,
+				in.Report.Hostname,
+				in.Report.Serial,
+				macs,
+				in.Report.Payload,
+				in.Report.ReportedBy,
+				in.Report.ReceivedAt,
+				in.Report.ReceivedAt,
+			)
+			if err != nil {
+				return err
+			}
+			id, err := res.LastInsertId()
+			if err != nil {
+				return err
+			}
+			reviewID = uint64(id)
+		case err != nil:
+			return err
+		default:
+			if _, err := tx.ExecContext(ctx, `
+			UPDATE computer_ingest_reviews
+			SET reason = ?, hostname = ?, serial = ?, mac_addresses = ?, payload = ?,
+				report_count = report_count + 1, reported_by = ?, last_seen_at = ?
+			WHERE review_id = ?`,
+				in.Reason,
+				in.Report.Hostname,
+				in.Report.Serial,
+				macs,
+				in.Report.Payload,
+				in.Report.ReportedBy,
+				in.Report.ReceivedAt,
+				reviewID,
+			); err != nil {
+				return err
+			}
+		}
+
+		return insertIngestReportTx(ctx, tx, nil, &reviewID, nil, in.Report)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return reviewID, nil
+}
+
+func (s *Store) ListIngestReviews(ctx context.Context, status *string) ([]IngestReviewResponse, error) {
+	q := `SELECT ` + ingestReviewColumns + `
+	FROM computer_ingest_reviews`
+	args := make([]any, 0, 1)
+	if status != nil {
+		q += ` WHERE status = ?`
+		args = append(args, *status)
+	}
+	q += ` ORDER BY last_seen_at DESC, review_id DESC`
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]IngestReviewResponse, 0, 8)
+	for rows.Next() {
+		item, err := scanIngestReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *Store) GetIngestReviewByID(ctx context.Context, reviewID uint64) (*IngestReviewResponse, error) {
+	q := `SELECT ` + ingestReviewColumns + `
+	FROM computer_ingest_reviews
+	WHERE review_id = ?`
+
+	item, err := scanIngestReview(s.db.QueryRowContext(ctx, q, reviewID))
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// ResolveIngestReview は pending のレビューを締める。資産が指定されていれば報告内容をその資産へ反映する
+func (s *Store) ResolveIngestReview(ctx context.Context, reviewID uint64, in ingestReviewResolution) (*IngestReviewResponse, error) {
+	err := platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		var status string
+		if err := tx.QueryRowContext(ctx,
+			"SELECT status FROM computer_ingest_reviews WHERE review_id = ? FOR UPDATE", reviewID,
+		).Scan(&status); err != nil {
+			return err
+		}
+		if status != ingestReviewPending {
+			return errIngestReviewClosed
+		}
+
+		next := ingestReviewDismissed
+		if in.AssetMasterID != nil && in.Report != nil {
+			next = ingestReviewResolved
+			if err := applyIngestReportTx(ctx, tx, *in.AssetMasterID, *in.Report); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx,
+				"UPDATE computer_ingest_reports SET asset_master_id = ? WHERE review_id = ?",
+				*in.AssetMasterID, reviewID,
+			); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.ExecContext(ctx, `
+		UPDATE computer_ingest_reviews
+		SET status = ?, resolved_asset_master_id = ?, resolved_by = ?, resolved_at = ?
+		WHERE review_id = ?`,
+			next, in.AssetMasterID, nullIfEmpty(in.ResolvedBy), in.ResolvedAt, reviewID,
+		)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetIngestReviewByID(ctx, reviewID)
+}
+
+const ingestReviewColumns = `
+	review_id,
+	status,
+	reason,
+	hostname,
+	serial,
+	mac_addresses,
+	payload,
+	report_count,
+	reported_by,
+	first_seen_at,
+	last_seen_at,
+	resolved_asset_master_id,
+	resolved_by,
+	resolved_at`
+
+func applyIngestReportTx(ctx context.Context, tx platformdb.DBTX, assetMasterID uint64, in ingestReportInput) error {
+	// 自己申告に無い項目（用途・備考など手入力の値）は上書きしない
+	const q = `
+	INSERT INTO computer_details
+		(asset_master_id, hostname, ip_address, mac_address, os, login_user,
+		cpu, ram_mb, storage_gb, disks, last_seen_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE
+		hostname = VALUES(hostname),
+		ip_address = COALESCE(VALUES(ip_address), ip_address),
+		mac_address = COALESCE(VALUES(mac_address), mac_address),
+		os = COALESCE(VALUES(os), os),
+		login_user = COALESCE(VALUES(login_user), login_user),
+		cpu = COALESCE(VALUES(cpu), cpu),
+		ram_mb = COALESCE(VALUES(ram_mb), ram_mb),
+		storage_gb = COALESCE(VALUES(storage_gb), storage_gb),
+		disks = COALESCE(VALUES(disks), disks),
+		last_seen_at = VALUES(last_seen_at)`
+
+	if err := lockAddressConflictsTx(ctx, tx, assetMasterID, in.Addresses); err != nil {
+		return err
+	}
+	if err := seedDetailHistoryTx(ctx, tx, assetMasterID); err != nil {
+		return err
+	}
+	if err := seedAddressesTx(ctx, tx, assetMasterID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, q,
+		assetMasterID,
+		in.Hostname,
+		in.IPAddress,
+		in.MACAddress,
+		in.OS,
+		in.LoginUser,
+		in.CPU,
+		in.RAMMB,
+		in.StorageGB,
+		in.Disks,
+		in.ReceivedAt,
+	); err != nil {
+		return err
+	}
+	if in.Addresses != nil {
+		if err := replaceAddressesTx(ctx, tx, assetMasterID, in.Addresses, in.ReceivedAt); err != nil {
+			return err
+		}
+	}
+	return recordDetailVersionTx(ctx, tx, assetMasterID, in.ReceivedAt)
+}
+
+const detailHistoryColumns = `
+	h.history_id,
+	h.asset_master_id,
+	am.management_number,
+	am.name,
+	h.hostname,
+	h.ip_address,
+	h.mac_address,
+	h.os,
+	h.login_user,
+	h.effective_from,
+	h.effective_to`
+
+// seedDetailHistoryTx は履歴導入前から存在する computer_details について、
+// 更新前の値を created_at 起点の版として1件だけ起こしておく
+func seedDetailHistoryTx(ctx context.Context, tx platformdb.DBTX, assetMasterID uint64) error {
+	const q = `
+	INSERT INTO computer_detail_history
+		(asset_master_id, hostname, ip_address, mac_address, os, login_user, effective_from, effective_to)
+	SELECT cd.asset_master_id, cd.hostname, cd.ip_address, cd.mac_address, cd.os, cd.login_user, cd.created_at, NULL
+	FROM computer_details cd
+	WHERE cd.asset_master_id = ?
+		AND NOT EXISTS (SELECT 1 FROM computer_detail_history h WHERE h.asset_master_id = cd.asset_master_id)`
+
+	_, err := tx.ExecContext(ctx, q, assetMasterID)
+	return err
+}
+
+// recordDetailVersionTx は computer_details の現在値が最新版と異なるときだけ、
+// 最新版を at で閉じて新しい版を追加する（last_seen_at だけの更新では版を増やさない）
+func recordDetailVersionTx(ctx context.Context, tx platformdb.DBTX, assetMasterID uint64, at time.Time) error {
+	const unchangedQ = `
+	SELECT 1
+	FROM computer_details cd
+	JOIN computer_detail_history h
+		ON h.asset_master_id = cd.asset_master_id AND h.effective_to IS NULL
+	WHERE cd.asset_master_id = ?
+		AND h.hostname <=> cd.hostname
+		AND h.ip_address <=> cd.ip_address
+		AND h.mac_address <=> cd.mac_address
+		AND h.os <=> cd.os
+		AND h.login_user <=> cd.login_user
+	LIMIT 1`
+
+	var dummy int
+	err := tx.QueryRowContext(ctx, unchangedQ, assetMasterID).Scan(&dummy)
+	if err == nil {
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE computer_detail_history SET effective_to = ? WHERE asset_master_id = ? AND effective_to IS NULL",
+		at, assetMasterID,
+	); err != nil {
+		return err
+	}
+
+	const insertQ = `
+	INSERT INTO computer_detail_history
+		(asset_master_id, hostname, ip_address, mac_address, os, login_user, effective_from, effective_to)
+	SELECT asset_master_id, hostname, ip_address, mac_address, os, login_user, ?, NULL
+	FROM computer_details
+	WHERE asset_master_id = ?`
+
+	_, err = tx.ExecContext(ctx, insertQ, at, assetMasterID)
+	return err
+}
+
+// seedAddressesTx は computer_addresses 導入前の computer_details について、
+// 単一カラムの ip/mac を created_at 起点の主 NIC として起こしておく
+func seedAddressesTx(ctx context.Context, tx platformdb.DBTX, assetMasterID uint64) error {
+	const q = `
+	INSERT INTO computer_addresses
+		(asset_master_id, interface_name, mac_address, ip_address, is_primary, effective_from, effective_to)
+	SELECT cd.asset_master_id, NULL, LOWER(REPLACE(cd.mac_address, '-', ':')), cd.ip_address, 1, cd.created_at, NULL
+	FROM computer_details cd
+	WHERE cd.asset_master_id = ?
+		AND (cd.mac_address IS NOT NULL OR cd.ip_address IS NOT NULL)
+		AND NOT EXISTS (SELECT 1 FROM computer_addresses a WHERE a.asset_master_id = cd.asset_master_id)`
+
+	_, err := tx.ExecContext(ctx, q, assetMasterID)
+	return err
 }
 
-func (s *Store) ListComputerConfigurationsByComputerAssetMasterID(ctx context.Context, computerAssetMasterID uint64) ([]ComputerConfigurationResponse, error) {
+func loadCurrentAddressesTx(ctx context.Context, tx platformdb.DBTX, assetMasterID uint64) ([]computerAddressInput, error) {
 	const q = `
-	SELECT
-		c.computer_configuration_id,
-		c.computer_asset_master_id,
-		cm.management_number,
-		cm.name,
-		c.part_asset_master_id,
-		pm.management_number,
-		pm.name,
-		c.part_type_id,
-		pt.name,
-		pt.display_name,
-		c.installed_at,
-		c.removed_at,
-		c.note,
-		c.created_at,
-		c.updated_at
-	FROM computer_configurations c
-	JOIN assets_master cm ON cm.asset_master_id = c.computer_asset_master_id
-	JOIN assets_master pm ON pm.asset_master_id = c.part_asset_master_id
-	JOIN part_types pt ON pt.part_type_id = c.part_type_id
-	WHERE c.computer_asset_master_id = ?
-	ORDER BY c.removed_at IS NULL DESC, c.part_type_id ASC, c.computer_configuration_id DESC`
+	SELECT interface_name, mac_address, ip_address, is_primary
+	FROM computer_addresses
+	WHERE asset_master_id = ? AND effective_to IS NULL
+	ORDER BY is_primary DESC, address_id`
 
-	rows, err := s.db.QueryContext(ctx, q, computerAssetMasterID)
+	rows, err := tx.QueryContext(ctx, q, assetMasterID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	out := make([]ComputerConfigurationResponse, 0, 8)
+	out := make([]computerAddressInput, 0, 2)
 	for rows.Next() {
-		item, err := scanComputerConfiguration(rows)
-		if err != nil {
+		var a computerAddressInput
+		var iface, mac, ip sql.NullString
+		if err := rows.Scan(&iface, &mac, &ip, &a.IsPrimary); err != nil {
 			return nil, err
 		}
-		out = append(out, item)
+		a.InterfaceName = ptrString(iface)
+		a.MACAddress = ptrString(mac)
+		a.IPAddress = ptrString(ip)
+		out = append(out, a)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -317,83 +1966,84 @@ func (s *Store) ListComputerConfigurationsByComputerAssetMasterID(ctx context.Co
 	return out, nil
 }
 
-func (s *Store) UpdateComputerConfigurationByID(ctx context.Context, computerConfigurationID uint64, patch updateComputerConfigurationInput) (*ComputerConfigurationResponse, error) {
-	sets := make([]string, 0, 5)
-	args := make([]any, 0, 5)
-
-	if patch.PartAssetMasterID != nil {
-		sets = append(sets, "part_asset_master_id = ?")
-		args = append(args, *patch.PartAssetMasterID)
+// replaceAddressesTx は NIC 一覧が変わったときだけ現行の版を at で閉じて新しい一覧を追加する
+func replaceAddressesTx(ctx context.Context, tx platformdb.DBTX, assetMasterID uint64, addrs []computerAddressInput, at time.Time) error {
+	current, err := loadCurrentAddressesTx(ctx, tx, assetMasterID)
+	if err != nil {
+		return err
 	}
-	if patch.PartTypeID != nil {
-		sets = append(sets, "part_type_id = ?")
-		args = append(args, *patch.PartTypeID)
+	if sameAddresses(current, addrs) {
+		return nil
 	}
-	appendNullableTimeUpdate("installed_at", patch.InstalledAt, &sets, &args)
-	appendNullableTimeUpdate("removed_at", patch.RemovedAt, &sets, &args)
-	appendNullableStringUpdate("note", patch.Note, &sets, &args)
 
-	if len(sets) == 0 {
-		return s.GetComputerConfigurationByID(ctx, computerConfigurationID)
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE computer_addresses SET effective_to = ? WHERE asset_master_id = ? AND effective_to IS NULL",
+		at, assetMasterID,
+	); err != nil {
+		return err
 	}
 
-	args = append(args, computerConfigurationID)
-	q := fmt.Sprintf("UPDATE computer_configurations SET %s WHERE computer_configuration_id = ?", strings.Join(sets, ", "))
-	res, err := s.db.ExecContext(ctx, q, args...)
-	if err != nil {
-		return nil, err
-	}
-	if aff, _ := res.RowsAffected(); aff == 0 {
-		if _, err := s.GetComputerConfigurationByID(ctx, computerConfigurationID); err == sql.ErrNoRows {
-			return nil, sql.ErrNoRows
+	const q = `
+	INSERT INTO computer_addresses
+		(asset_master_id, interface_name, mac_address, ip_address, is_primary, effective_from, effective_to)
+	VALUES (?, ?, ?, ?, ?, ?, NULL)`
+
+	for _, a := range addrs {
+		if _, err := tx.ExecContext(ctx, q, assetMasterID, a.InterfaceName, a.MACAddress, a.IPAddress, a.IsPrimary, at); err != nil {
+			return err
 		}
 	}
-
-	return s.GetComputerConfigurationByID(ctx, computerConfigurationID)
+	return nil
 }
 
-func (s *Store) ActiveConfigurationExistsForPart(ctx context.Context, partAssetMasterID uint64, excludeID *uint64) (bool, error) {
-	query := "SELECT 1 FROM computer_configurations WHERE part_asset_master_id = ? AND removed_at IS NULL"
-	args := []any{partAssetMasterID}
-	if excludeID != nil {
-		query += " AND computer_configuration_id <> ?"
-		args = append(args, *excludeID)
+// syncPrimaryAddressTx は単一カラムの ip/mac だけが更新されたときに主 NIC をそれに合わせる
+func syncPrimaryAddressTx(ctx context.Context, tx platformdb.DBTX, assetMasterID uint64, at time.Time) error {
+	var ip, mac sql.NullString
+	if err := tx.QueryRowContext(ctx,
+		"SELECT ip_address, mac_address FROM computer_details WHERE asset_master_id = ?", assetMasterID,
+	).Scan(&ip, &mac); err != nil {
+		return err
 	}
-	query += " LIMIT 1"
-	return s.exists(ctx, query, args...)
-}
 
-func (s *Store) ActiveConfigurationExistsForComputerPartType(ctx context.Context, computerAssetMasterID uint64, partTypeID uint, excludeID *uint64) (bool, error) {
-	query := "SELECT 1 FROM computer_configurations WHERE computer_asset_master_id = ? AND part_type_id = ? AND removed_at IS NULL"
-	args := []any{computerAssetMasterID, partTypeID}
-	if excludeID != nil {
-		query += " AND computer_configuration_id <> ?"
-		args = append(args, *excludeID)
+	current, err := loadCurrentAddressesTx(ctx, tx, assetMasterID)
+	if err != nil {
+		return err
 	}
-	query += " LIMIT 1"
-	return s.exists(ctx, query, args...)
+	return replaceAddressesTx(ctx, tx, assetMasterID, withPrimaryAddress(current, ptrString(mac), ptrString(ip)), at)
 }
 
-func (s *Store) ListPartTypes(ctx context.Context) ([]PartTypeResponse, error) {
+func insertIngestReportTx(ctx context.Context, tx platformdb.DBTX, assetMasterID *uint64, reviewID *uint64, matchedBy *string, in ingestReportInput) error {
 	const q = `
-	SELECT part_type_id, name, display_name, note
-	FROM part_types
-	ORDER BY part_type_id`
+	INSERT INTO computer_ingest_reports
+		(asset_master_id, review_id, matched_by, hostname, serial, payload, reported_by, received_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
 
-	rows, err := s.db.QueryContext(ctx, q)
+	_, err := tx.ExecContext(ctx, q,
+		assetMasterID,
+		reviewID,
+		matchedBy,
+		in.Hostname,
+		in.Serial,
+		in.Payload,
+		in.ReportedBy,
+		in.ReceivedAt,
+	)
+	return err
+}
+
+func (s *Store) queryDetailHistory(ctx context.Context, query string, args ...any) ([]ComputerDetailVersionResponse, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	out := make([]PartTypeResponse, 0, 8)
+	out := make([]ComputerDetailVersionResponse, 0, 8)
 	for rows.Next() {
-		var item PartTypeResponse
-		var note sql.NullString
-		if err := rows.Scan(&item.PartTypeID, &item.Name, &item.DisplayName, &note); err != nil {
+		item, err := scanDetailVersion(rows)
+		if err != nil {
 			return nil, err
 		}
-		item.Note = ptrString(note)
 		out = append(out, item)
 	}
 	if err := rows.Err(); err != nil {
@@ -402,27 +2052,20 @@ func (s *Store) ListPartTypes(ctx context.Context) ([]PartTypeResponse, error) {
 	return out, nil
 }
 
-func (s *Store) ListUsageStatuses(ctx context.Context) ([]UsageStatusResponse, error) {
-	const q = `
-	SELECT usage_status_id, name, display_name, note
-	FROM usage_status
-	ORDER BY usage_status_id`
-
-	rows, err := s.db.QueryContext(ctx, q)
+func (s *Store) queryIDs(ctx context.Context, query string, args ...any) ([]uint64, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	out := make([]UsageStatusResponse, 0, 8)
+	out := make([]uint64, 0, 2)
 	for rows.Next() {
-		var item UsageStatusResponse
-		var note sql.NullString
-		if err := rows.Scan(&item.UsageStatusID, &item.Name, &item.DisplayName, &note); err != nil {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
 			return nil, err
 		}
-		item.Note = ptrString(note)
-		out = append(out, item)
+		out = append(out, id)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -430,9 +2073,44 @@ func (s *Store) ListUsageStatuses(ctx context.Context) ([]UsageStatusResponse, e
 	return out, nil
 }
 
+// addressMatchCondition は "(mac IN (..) OR ip IN (..))" を組み立てる
+func addressMatchCondition(macColumn, ipColumn string, macs, ips []string) (string, []any) {
+	parts := make([]string, 0, 2)
+	args := make([]any, 0, len(macs)+len(ips))
+	if len(macs) > 0 {
+		parts = append(parts, macColumn+" IN ("+placeholders(len(macs))+")")
+		for _, m := range macs {
+			args = append(args, m)
+		}
+	}
+	if len(ips) > 0 {
+		parts = append(parts, ipColumn+" IN ("+placeholders(len(ips))+")")
+		for _, ip := range ips {
+			args = append(args, ip)
+		}
+	}
+	return "(" + strings.Join(parts, " OR ") + ")", args
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func (s *Store) count(ctx context.Context, query string, args ...any) (int64, error) {
+	var total int64
+	if err := s.db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
 func (s *Store) exists(ctx context.Context, query string, args ...any) (bool, error) {
+	return existsIn(ctx, s.db, query, args...)
+}
+
+func existsIn(ctx context.Context, q platformdb.DBTX, query string, args ...any) (bool, error) {
 	var dummy int
-	err := s.db.QueryRowContext(ctx, query, args...).Scan(&dummy)
+	err := q.QueryRowContext(ctx, query, args...).Scan(&dummy)
 	if err == sql.ErrNoRows {
 		return false, nil
 	}
@@ -442,6 +2120,33 @@ func (s *Store) exists(ctx context.Context, query string, args ...any) (bool, er
 	return true, nil
 }
 
+// activeConfigurationExistsForPart / countActiveConfigurationsForComputerPartType は通常の登録・更新の事前チェックと
+// 部品交換のトランザクション内の再チェックで共用する
+func activeConfigurationExistsForPart(ctx context.Context, q platformdb.DBTX, partAssetMasterID uint64, excludeID *uint64) (bool, error) {
+	query := "SELECT 1 FROM computer_configurations WHERE part_asset_master_id = ? AND removed_at IS NULL"
+	args := []any{partAssetMasterID}
+	if excludeID != nil {
+		query += " AND computer_configuration_id <> ?"
+		args = append(args, *excludeID)
+	}
+	query += " LIMIT 1"
+	return existsIn(ctx, q, query, args...)
+}
+
+func countActiveConfigurationsForComputerPartType(ctx context.Context, q platformdb.DBTX, computerAssetMasterID uint64, partTypeID uint, excludeID *uint64) (int, error) {
+	query := "SELECT COUNT(*) FROM computer_configurations WHERE computer_asset_master_id = ? AND part_type_id = ? AND removed_at IS NULL"
+	args := []any{computerAssetMasterID, partTypeID}
+	if excludeID != nil {
+		query += " AND computer_configuration_id <> ?"
+		args = append(args, *excludeID)
+	}
+	var n int
+	if err := q.QueryRowContext(ctx, query, args...).Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
 func (s *Store) queryComputerConfiguration(ctx context.Context, query string, args ...any) (*ComputerConfigurationResponse, error) {
 	row := s.db.QueryRowContext(ctx, query, args...)
 	item, err := scanComputerConfiguration(row)
@@ -455,6 +2160,62 @@ type scanner interface {
 	Scan(dest ...any) error
 }
 
+func scanComputerDetail(s scanner) (ComputerDetailResponse, error) {
+	var out ComputerDetailResponse
+	var hostname, ipAddress, macAddress, osValue, purpose, loginUser, note, cpu sql.NullString
+	var ramMB sql.NullInt64
+	var storageGB sql.NullFloat64
+	var disks []byte
+	var lastSeenAt sql.NullTime
+	err := s.Scan(
+		&out.ComputerDetailID,
+		&out.AssetMasterID,
+		&out.ManagementNumber,
+		&out.AssetName,
+		&hostname,
+		&ipAddress,
+		&macAddress,
+		&osValue,
+		&purpose,
+		&loginUser,
+		&note,
+		&cpu,
+		&ramMB,
+		&storageGB,
+		&disks,
+		&lastSeenAt,
+		&out.CreatedAt,
+		&out.UpdatedAt,
+	)
+	if err != nil {
+		return ComputerDetailResponse{}, err
+	}
+
+	out.Hostname = ptrString(hostname)
+	out.IPAddress = ptrString(ipAddress)
+	out.MACAddress = ptrString(macAddress)
+	out.OS = ptrString(osValue)
+	out.Purpose = ptrString(purpose)
+	out.LoginUser = ptrString(loginUser)
+	out.Note = ptrString(note)
+	out.CPU = ptrString(cpu)
+	if ramMB.Valid {
+		v := uint64(ramMB.Int64)
+		out.RAMMB = &v
+	}
+	if storageGB.Valid {
+		v := storageGB.Float64
+		out.StorageGB = &v
+	}
+	if len(disks) > 0 {
+		if err := json.Unmarshal(disks, &out.Disks); err != nil {
+			return ComputerDetailResponse{}, err
+		}
+	}
+	out.LastSeenAt = ptrTime(lastSeenAt)
+	return out, nil
+}
+
 func scanComputerConfiguration(s scanner) (ComputerConfigurationResponse, error) {
 	var out ComputerConfigurationResponse
 	var installedAt, removedAt sql.NullTime
@@ -490,7 +2251,10 @@ func scanComputerPart(s scanner) (ComputerPartResponse, error) {
 	var out ComputerPartResponse
 	var activePartTypeID sql.NullInt64
 	var activePartTypeName, activePartTypeDisplayName sql.NullString
+	var partTypeID sql.NullInt64
+	var partTypeName sql.NullString
 	var spec, note sql.NullString
+	var attrs []byte
 	err := s.Scan(
 		&out.ComputerPartID,
 		&out.AssetMasterID,
@@ -506,6 +2270,9 @@ func scanComputerPart(s scanner) (ComputerPartResponse, error) {
 		&note,
 		&out.CreatedAt,
 		&out.UpdatedAt,
+		&partTypeID,
+		&partTypeName,
+		&attrs,
 	)
 	if err != nil {
 		return ComputerPartResponse{}, err
@@ -514,8 +2281,215 @@ func scanComputerPart(s scanner) (ComputerPartResponse, error) {
 	out.ActivePartTypeID = ptrUint(activePartTypeID)
 	out.ActivePartTypeName = ptrString(activePartTypeName)
 	out.ActivePartTypeDisplayName = ptrString(activePartTypeDisplayName)
+	out.PartTypeID = ptrUint(partTypeID)
+	out.PartTypeName = ptrString(partTypeName)
 	out.Specification = ptrString(spec)
+	if out.SpecAttributes, err = unmarshalSpecAttributes(attrs); err != nil {
+		return ComputerPartResponse{}, err
+	}
+	out.Note = ptrString(note)
+	return out, nil
+}
+
+func scanPartType(s scanner) (PartTypeResponse, error) {
+	var out PartTypeResponse
+	var displayNameEN, note sql.NullString
+	var schema []byte
+	if err := s.Scan(
+		&out.PartTypeID,
+		&out.Name,
+		&out.DisplayName,
+		&displayNameEN,
+		&schema,
+		&out.IsDisabled,
+		&note,
+	); err != nil {
+		return PartTypeResponse{}, err
+	}
+	var err error
+	if out.SpecSchema, err = unmarshalSpecSchema(schema); err != nil {
+		return PartTypeResponse{}, err
+	}
+	out.DisplayNameEN = ptrString(displayNameEN)
+	out.Note = ptrString(note)
+	return out, nil
+}
+
+func scanUsageStatus(s scanner) (UsageStatusResponse, error) {
+	var out UsageStatusResponse
+	var displayNameEN, note sql.NullString
+	if err := s.Scan(
+		&out.UsageStatusID,
+		&out.Name,
+		&out.DisplayName,
+		&displayNameEN,
+		&out.IsDisabled,
+		&note,
+	); err != nil {
+		return UsageStatusResponse{}, err
+	}
+	out.DisplayNameEN = ptrString(displayNameEN)
+	out.Note = ptrString(note)
+	return out, nil
+}
+
+func scanPartSlotLimit(s scanner) (PartSlotLimitResponse, error) {
+	var out PartSlotLimitResponse
+	var model, note sql.NullString
+	if err := s.Scan(
+		&out.SlotLimitID,
+		&model,
+		&out.PartTypeID,
+		&out.PartTypeName,
+		&out.MaxCount,
+		&note,
+		&out.CreatedAt,
+		&out.UpdatedAt,
+	); err != nil {
+		return PartSlotLimitResponse{}, err
+	}
+	out.Model = ptrString(model)
+	out.Note = ptrString(note)
+	return out, nil
+}
+
+func scanCompatibilityRule(s scanner) (CompatibilityRuleResponse, error) {
+	var out CompatibilityRuleResponse
+	var model, note sql.NullString
+	var allowed []byte
+	if err := s.Scan(
+		&out.RuleID,
+		&out.Name,
+		&model,
+		&out.PartTypeID,
+		&out.PartTypeName,
+		&out.Attribute,
+		&out.Kind,
+		&allowed,
+		&note,
+		&out.CreatedAt,
+		&out.UpdatedAt,
+	); err != nil {
+		return CompatibilityRuleResponse{}, err
+	}
+	out.Model = ptrString(model)
 	out.Note = ptrString(note)
+	out.AllowedValues = []string{}
+	if len(allowed) > 0 {
+		if err := json.Unmarshal(allowed, &out.AllowedValues); err != nil {
+			return CompatibilityRuleResponse{}, fmt.Errorf("invalid part_compatibility_rules.allowed_values: %w", err)
+		}
+	}
+	return out, nil
+}
+
+func unmarshalSpecSchema(raw []byte) ([]PartSpecField, error) {
+	out := []PartSpecField{}
+	if len(raw) == 0 {
+		return out, nil
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("invalid part_types.spec_schema: %w", err)
+	}
+	return out, nil
+}
+
+func unmarshalSpecAttributes(raw []byte) (map[string]any, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var out map[string]any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("invalid computer_parts.spec_attributes: %w", err)
+	}
+	return out, nil
+}
+
+// marshalSpecAttributes は空の属性を NULL として保存する
+func marshalSpecAttributes(attrs map[string]any) (any, error) {
+	if len(attrs) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(attrs)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func scanDetailVersion(s scanner) (ComputerDetailVersionResponse, error) {
+	var out ComputerDetailVersionResponse
+	var hostname, ipAddress, macAddress, osValue, loginUser sql.NullString
+	var effectiveTo sql.NullTime
+	err := s.Scan(
+		&out.HistoryID,
+		&out.AssetMasterID,
+		&out.ManagementNumber,
+		&out.AssetName,
+		&hostname,
+		&ipAddress,
+		&macAddress,
+		&osValue,
+		&loginUser,
+		&out.EffectiveFrom,
+		&effectiveTo,
+	)
+	if err != nil {
+		return ComputerDetailVersionResponse{}, err
+	}
+
+	out.Hostname = ptrString(hostname)
+	out.IPAddress = ptrString(ipAddress)
+	out.MACAddress = ptrString(macAddress)
+	out.OS = ptrString(osValue)
+	out.LoginUser = ptrString(loginUser)
+	out.EffectiveTo = ptrTime(effectiveTo)
+	return out, nil
+}
+
+func scanIngestReview(s scanner) (IngestReviewResponse, error) {
+	var out IngestReviewResponse
+	var serial, resolvedBy sql.NullString
+	var macs string
+	var payload []byte
+	var resolvedAssetMasterID sql.NullInt64
+	var resolvedAt sql.NullTime
+	err := s.Scan(
+		&out.ReviewID,
+		&out.Status,
+		&out.Reason,
+		&out.Hostname,
+		&serial,
+		&macs,
+		&payload,
+		&out.ReportCount,
+		&out.ReportedBy,
+		&out.FirstSeenAt,
+		&out.LastSeenAt,
+		&resolvedAssetMasterID,
+		&resolvedBy,
+		&resolvedAt,
+	)
+	if err != nil {
+		return IngestReviewResponse{}, err
+	}
+
+	out.Serial = ptrString(serial)
+	out.MACAddresses = []string{}
+	if macs != "" {
+		out.MACAddresses = strings.Split(macs, ",")
+	}
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &out.Report); err != nil {
+			return IngestReviewResponse{}, err
+		}
+	}
+	if resolvedAssetMasterID.Valid {
+		v := uint64(resolvedAssetMasterID.Int64)
+		out.ResolvedAssetMasterID = &v
+	}
+	out.ResolvedBy = ptrString(resolvedBy)
+	out.ResolvedAt = ptrTime(resolvedAt)
 	return out, nil
 }
 
@@ -543,6 +2517,36 @@ func appendNullableTimeUpdate(column string, field nullableTimeField, sets *[]st
 	*args = append(*args, *field.Value)
 }
 
+func appendLikeFilter(column string, value *string, where *[]string, args *[]any) {
+	if value == nil {
+		return
+	}
+	*where = append(*where, column+" LIKE ?")
+	*args = append(*args, "%"+escapeLike(*value)+"%")
+}
+
+// LIKE用のエスケープ（ユーザーが % や _ を入力してもワイルドカードにならないように）
+func escapeLike(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "%", "\\%")
+	s = strings.ReplaceAll(s, "_", "\\_")
+	return s
+}
+
+func orderSQL(order string) string {
+	if strings.ToLower(order) == "asc" {
+		return "ASC"
+	}
+	return "DESC"
+}
+
+func nullIfEmpty(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
 func ptrString(ns sql.NullString) *string {
 	if !ns.Valid {
 		return nil
@@ -78,6 +78,27 @@ func TestScanComputerPartLeavesActivePartTypeNilWhenNoActiveConfiguration(t *tes
 	}
 }
 
+func TestScanComputerPartDecodesSpecAttributes(t *testing.T) {
+	item, err := scanComputerPart(scannerFunc(func(dest ...any) error {
+		*(dest[0].(*uint64)) = 23
+		*(dest[1].(*uint64)) = 303
+		*(dest[14].(*sql.NullInt64)) = sql.NullInt64{Int64: 3, Valid: true}
+		*(dest[15].(*sql.NullString)) = sql.NullString{String: "memory", Valid: true}
+		*(dest[16].(*[]byte)) = []byte(`{"capacity_gb":16,"speed_mhz":3200}`)
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("scanComputerPart returned error: %v", err)
+	}
+
+	if item.PartTypeID == nil || *item.PartTypeID != 3 {
+		t.Fatalf("expected part type id 3, got %#v", item.PartTypeID)
+	}
+	if item.SpecAttributes["capacity_gb"] != float64(16) {
+		t.Fatalf("expected decoded capacity_gb, got %#v", item.SpecAttributes)
+	}
+}
+
 type scannerFunc func(dest ...any) error
 
 func (f scannerFunc) Scan(dest ...any) error {
@@ -0,0 +1,80 @@
+package contracts
+
+import "time"
+
+const (
+	ContractTypeLicense  = "license"
+	ContractTypeSupport  = "support"
+	ContractTypeWarranty = "warranty"
+	ContractTypeOther    = "other"
+)
+
+type CreateContractRequest struct {
+	ContractType      string   `json:"contract_type" binding:"required"`
+	Name              string   `json:"name" binding:"required"`
+	Vendor            string   `json:"vendor" binding:"required"`
+	StartOn           string   `json:"start_on" binding:"required"`
+	EndOn             string   `json:"end_on" binding:"required"`
+	Seats             *int     `json:"seats,omitempty"`
+	Cost              *float64 `json:"cost,omitempty"`
+	OwnerID           string   `json:"owner_id" binding:"required"`
+	NotifyDaysBefore  *int     `json:"notify_days_before,omitempty"`
+	ManagementNumbers []string `json:"management_numbers,omitempty"`
+	Note              *string  `json:"note,omitempty"`
+}
+
+type UpdateContractRequest struct {
+	ContractType *string  `json:"contract_type,omitempty"`
+	Name         *string  `json:"name,omitempty"`
+	Vendor       *string  `json:"vendor,omitempty"`
+	StartOn      *string  `json:"start_on,omitempty"`
+	EndOn        *string  `json:"end_on,omitempty"`
+	Seats        *int     `json:"seats,omitempty"`
+	Cost         *float64 `json:"cost,omitempty"`
+	// seats / cost を未設定（NULL）に戻す。値の指定と同時には使えない
+	ClearSeats        bool      `json:"clear_seats,omitempty"`
+	ClearCost         bool      `json:"clear_cost,omitempty"`
+	OwnerID           *string   `json:"owner_id,omitempty"`
+	NotifyDaysBefore  *int      `json:"notify_days_before,omitempty"`
+	ManagementNumbers *[]string `json:"management_numbers,omitempty"`
+	Note              *string   `json:"note,omitempty"`
+}
+
+type ContractAssetResponse struct {
+	AssetMasterID    uint64 `json:"asset_master_id"`
+	ManagementNumber string `json:"management_number"`
+	Name             string `json:"name"`
+}
+
+type ContractResponse struct {
+	ContractID       uint64                  `json:"contract_id"`
+	ContractType     string                  `json:"contract_type"`
+	Name             string                  `json:"name"`
+	Vendor           string                  `json:"vendor"`
+	StartOn          time.Time               `json:"start_on"`
+	EndOn            time.Time               `json:"end_on"`
+	Seats            *int                    `json:"seats,omitempty"`
+	Cost             *float64                `json:"cost,omitempty"`
+	OwnerID          string                  `json:"owner_id"`
+	NotifyDaysBefore int                     `json:"notify_days_before"`
+	DaysRemaining    int                     `json:"days_remaining"`
+	Assets           []ContractAssetResponse `json:"assets"`
+	Note             *string                 `json:"note,omitempty"`
+	CreatedAt        time.Time               `json:"created_at"`
+	UpdatedAt        time.Time               `json:"updated_at"`
+}
+
+type ListContractsResponse struct {
+	Items      []ContractResponse `json:"items"`
+	Total      int64              `json:"total" example:"100"`
+	NextOffset int                `json:"next_offset" example:"50"`
+}
+
+type ErrorDetail struct {
+	Code    string `json:"code" example:"INVALID_ARGUMENT"`
+	Message string `json:"message" example:"invalid json"`
+}
+
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
@@ -0,0 +1,210 @@
+package contracts
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	svc *Service
+}
+
+func RegisterRoutes(r gin.IRoutes, svc *Service) {
+	h := &Handler{svc: svc}
+
+	r.POST("/contracts", h.CreateContract)
+	r.GET("/contracts", h.ListContracts)
+	r.GET("/contracts/expiring", h.ListExpiringContracts)
+	r.GET("/contracts/:contract_id", h.GetContract)
+	r.PUT("/contracts/:contract_id", h.UpdateContract)
+}
+
+// @Summary      Create a contract
+// @Description  Creates a license, support contract or warranty and links it to management numbers.
+// @Tags         contracts
+// @Accept       json
+// @Produce      json
+// @Param        contract body CreateContractRequest true "Contract"
+// @Success      201 {object} ContractResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      409 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /contracts [post]
+func (h *Handler) CreateContract(c *gin.Context) {
+	var req CreateContractRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+
+	out, err := h.svc.CreateContract(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.Header("Location", "/contracts/"+strconv.FormatUint(out.ContractID, 10))
+	c.JSON(http.StatusCreated, out)
+}
+
+// @Summary      List contracts
+// @Description  Lists contracts ordered by end date.
+// @Tags         contracts
+// @Produce      json
+// @Param        contract_type     query string false "license | support | warranty | other"
+// @Param        vendor            query string false "Vendor (partial match)"
+// @Param        owner_id          query string false "Owner ID"
+// @Param        management_number query string false "Linked management number (an alias also matches)"
+// @Param        limit             query int    false "Number of items to return" default(50)
+// @Param        offset            query int    false "Offset for pagination" default(0)
+// @Success      200 {object} ListContractsResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /contracts [get]
+func (h *Handler) ListContracts(c *gin.Context) {
+	f := ContractFilter{
+		ContractType:     queryPtr(c, "contract_type"),
+		Vendor:           queryPtr(c, "vendor"),
+		OwnerID:          queryPtr(c, "owner_id"),
+		ManagementNumber: queryPtr(c, "management_number"),
+		Limit:            atoiDef(c.Query("limit"), 50),
+		Offset:           atoiDef(c.Query("offset"), 0),
+	}
+
+	items, total, err := h.svc.ListContracts(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	next := f.Offset + len(items)
+	if next >= int(total) {
+		next = 0
+	}
+	c.JSON(http.StatusOK, ListContractsResponse{Items: items, Total: total, NextOffset: next})
+}
+
+// @Summary      List expiring contracts
+// @Description  Lists contracts whose end date falls between today and today + within.
+// @Tags         contracts
+// @Produce      json
+// @Param        within query string false "Window such as 60d, 8w or 72h" default(30d)
+// @Success      200 {array}  ContractResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /contracts/expiring [get]
+func (h *Handler) ListExpiringContracts(c *gin.Context) {
+	items, err := h.svc.ListExpiring(c.Request.Context(), c.Query("within"))
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// @Summary      Get a contract
+// @Description  Retrieves a contract with its linked assets.
+// @Tags         contracts
+// @Produce      json
+// @Param        contract_id path int true "Contract ID"
+// @Success      200 {object} ContractResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /contracts/{contract_id} [get]
+func (h *Handler) GetContract(c *gin.Context) {
+	contractID, ok := parseUint64Path(c, "contract_id")
+	if !ok {
+		return
+	}
+
+	out, err := h.svc.GetContract(c.Request.Context(), contractID)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// @Summary      Update a contract
+// @Description  Updates contract fields. When management_numbers is given, linked assets are replaced. Seats and cost can be reset to unset with clear_seats / clear_cost.
+// @Tags         contracts
+// @Accept       json
+// @Produce      json
+// @Param        contract_id path int true "Contract ID"
+// @Param        contract body UpdateContractRequest true "Contract patch"
+// @Success      200 {object} ContractResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Failure      409 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Router       /contracts/{contract_id} [put]
+func (h *Handler) UpdateContract(c *gin.Context) {
+	contractID, ok := parseUint64Path(c, "contract_id")
+	if !ok {
+		return
+	}
+
+	var req UpdateContractRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, "invalid json"))
+		return
+	}
+
+	out, err := h.svc.UpdateContract(c.Request.Context(), contractID, req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), apiErrFrom(err))
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func queryPtr(c *gin.Context, key string) *string {
+	v := strings.TrimSpace(c.Query(key))
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+func atoiDef(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+func parseUint64Path(c *gin.Context, key string) (uint64, bool) {
+	value, err := strconv.ParseUint(c.Param(key), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiErr(CodeInvalidArgument, key+" must be uint64"))
+		return 0, false
+	}
+	return value, true
+}
+
+type errDTO struct {
+	Error struct {
+		Code    Code   `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func apiErr(code Code, msg string) errDTO {
+	var e errDTO
+	e.Error.Code = code
+	e.Error.Message = msg
+	return e
+}
+
+func apiErrFrom(err error) errDTO {
+	if api, ok := err.(*APIError); ok {
+		return apiErr(api.Code, api.Message)
+	}
+	return apiErr(CodeInternal, err.Error())
+}
@@ -0,0 +1,55 @@
+package contracts
+
+import "time"
+
+type createContractInput struct {
+	ContractType     string
+	Name             string
+	Vendor           string
+	StartOn          time.Time
+	EndOn            time.Time
+	Seats            *int
+	Cost             *float64
+	OwnerID          string
+	NotifyDaysBefore int
+	AssetMasterIDs   []uint64
+	Note             *string
+}
+
+type updateContractInput struct {
+	ContractType     *string
+	Name             *string
+	Vendor           *string
+	StartOn          *time.Time
+	EndOn            *time.Time
+	Seats            *int
+	Cost             *float64
+	ClearSeats       bool
+	ClearCost        bool
+	OwnerID          *string
+	NotifyDaysBefore *int
+	AssetMasterIDs   *[]uint64
+	Note             *string
+}
+
+type ContractFilter struct {
+	ContractType     *string
+	Vendor           *string
+	OwnerID          *string
+	ManagementNumber *string // 別名でも可（Service で AssetMasterID に解決する）
+	AssetMasterID    *uint64
+	Limit            int
+	Offset           int
+}
+
+// ExpiryNotice は期限切れ前通知1件分の内容
+type ExpiryNotice struct {
+	ContractID    uint64
+	ContractType  string
+	Name          string
+	Vendor        string
+	OwnerID       string
+	EndOn         time.Time
+	DaysRemaining int
+	Assets        []ContractAssetResponse
+}
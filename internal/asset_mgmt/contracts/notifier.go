@@ -0,0 +1,26 @@
+package contracts
+
+import (
+	"context"
+	"log"
+	"strings"
+)
+
+// Notifier は期限切れ前の通知を契約オーナーに届ける
+type Notifier interface {
+	Notify(ctx context.Context, n ExpiryNotice) error
+}
+
+// LogNotifier は通知内容をログに出すだけの Notifier（メール等の送信手段が決まるまでの既定）
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(_ context.Context, n ExpiryNotice) error {
+	mngs := make([]string, 0, len(n.Assets))
+	for _, a := range n.Assets {
+		mngs = append(mngs, a.ManagementNumber)
+	}
+	log.Printf("[NOTICE] contract expiring: owner=%s contract_id=%d type=%s name=%q vendor=%q end_on=%s days_remaining=%d assets=[%s]",
+		n.OwnerID, n.ContractID, n.ContractType, n.Name, n.Vendor,
+		n.EndOn.Format("2006-01-02"), n.DaysRemaining, strings.Join(mngs, ","))
+	return nil
+}
@@ -0,0 +1,462 @@
+package contracts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	mysql "github.com/go-sql-driver/mysql"
+)
+
+const (
+	defaultNotifyDaysBefore = 30
+	defaultExpiringWithin   = 30 * 24 * time.Hour
+	maxListLimit            = 200
+)
+
+type contractStore interface {
+	ResolveMasterID(ctx context.Context, managementNumber string) (uint64, error)
+
+	CreateContract(ctx context.Context, in createContractInput) (*ContractResponse, error)
+	GetContractByID(ctx context.Context, contractID uint64) (*ContractResponse, error)
+	ListContracts(ctx context.Context, f ContractFilter) ([]ContractResponse, int64, error)
+	UpdateContractByID(ctx context.Context, contractID uint64, patch updateContractInput) (*ContractResponse, error)
+
+	ListExpiringContracts(ctx context.Context, from, to time.Time) ([]ContractResponse, error)
+	ListContractsDueForNotice(ctx context.Context, today time.Time) ([]ContractResponse, error)
+	RecordExpiryNotice(ctx context.Context, contractID uint64, endOn time.Time, notifiedAt time.Time) error
+}
+
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now().UTC()
+}
+
+type Service struct {
+	store contractStore
+	clock Clock
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{store: NewStore(db), clock: realClock{}}
+}
+
+func newServiceWithStore(store contractStore, clock Clock) *Service {
+	return &Service{store: store, clock: clock}
+}
+
+func (s *Service) CreateContract(ctx context.Context, req CreateContractRequest) (ContractResponse, error) {
+	contractType, err := normalizeContractType(req.ContractType)
+	if err != nil {
+		return ContractResponse{}, err
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return ContractResponse{}, ErrInvalid("name is required")
+	}
+	vendor := strings.TrimSpace(req.Vendor)
+	if vendor == "" {
+		return ContractResponse{}, ErrInvalid("vendor is required")
+	}
+	ownerID := strings.TrimSpace(req.OwnerID)
+	if ownerID == "" {
+		return ContractResponse{}, ErrInvalid("owner_id is required")
+	}
+	startOn, err := parseDate("start_on", req.StartOn)
+	if err != nil {
+		return ContractResponse{}, err
+	}
+	endOn, err := parseDate("end_on", req.EndOn)
+	if err != nil {
+		return ContractResponse{}, err
+	}
+	if err := validateContractPeriod(startOn, endOn); err != nil {
+		return ContractResponse{}, err
+	}
+	if err := validateSeatsAndCost(req.Seats, req.Cost); err != nil {
+		return ContractResponse{}, err
+	}
+	notifyDays := defaultNotifyDaysBefore
+	if req.NotifyDaysBefore != nil {
+		if *req.NotifyDaysBefore < 0 {
+			return ContractResponse{}, ErrInvalid("notify_days_before must be >= 0")
+		}
+		notifyDays = *req.NotifyDaysBefore
+	}
+	assetMasterIDs, err := s.resolveManagementNumbers(ctx, req.ManagementNumbers)
+	if err != nil {
+		return ContractResponse{}, err
+	}
+
+	out, err := s.store.CreateContract(ctx, createContractInput{
+		ContractType:     contractType,
+		Name:             name,
+		Vendor:           vendor,
+		StartOn:          startOn,
+		EndOn:            endOn,
+		Seats:            req.Seats,
+		Cost:             req.Cost,
+		OwnerID:          ownerID,
+		NotifyDaysBefore: notifyDays,
+		AssetMasterIDs:   assetMasterIDs,
+		Note:             normalizeOptionalString(req.Note),
+	})
+	if err != nil {
+		return ContractResponse{}, mapMySQLError(err)
+	}
+	return s.withDaysRemaining(*out), nil
+}
+
+func (s *Service) GetContract(ctx context.Context, contractID uint64) (ContractResponse, error) {
+	out, err := s.store.GetContractByID(ctx, contractID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ContractResponse{}, ErrNotFound("contract not found")
+		}
+		return ContractResponse{}, err
+	}
+	return s.withDaysRemaining(*out), nil
+}
+
+func (s *Service) ListContracts(ctx context.Context, f ContractFilter) ([]ContractResponse, int64, error) {
+	if f.ContractType != nil {
+		ct, err := normalizeContractType(*f.ContractType)
+		if err != nil {
+			return nil, 0, err
+		}
+		f.ContractType = &ct
+	}
+	if f.Limit <= 0 || f.Limit > maxListLimit {
+		f.Limit = 50
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+
+	if f.ManagementNumber != nil {
+		id, err := s.store.ResolveMasterID(ctx, *f.ManagementNumber)
+		if errors.Is(err, sql.ErrNoRows) {
+			return []ContractResponse{}, 0, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		f.AssetMasterID = &id
+	}
+
+	items, total, err := s.store.ListContracts(ctx, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range items {
+		items[i] = s.withDaysRemaining(items[i])
+	}
+	return items, total, nil
+}
+
+func (s *Service) UpdateContract(ctx context.Context, contractID uint64, req UpdateContractRequest) (ContractResponse, error) {
+	current, err := s.store.GetContractByID(ctx, contractID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ContractResponse{}, ErrNotFound("contract not found")
+		}
+		return ContractResponse{}, err
+	}
+
+	var patch updateContractInput
+	if req.ContractType != nil {
+		ct, err := normalizeContractType(*req.ContractType)
+		if err != nil {
+			return ContractResponse{}, err
+		}
+		patch.ContractType = &ct
+	}
+	if patch.Name, err = requiredStringPatch("name", req.Name); err != nil {
+		return ContractResponse{}, err
+	}
+	if patch.Vendor, err = requiredStringPatch("vendor", req.Vendor); err != nil {
+		return ContractResponse{}, err
+	}
+	if patch.OwnerID, err = requiredStringPatch("owner_id", req.OwnerID); err != nil {
+		return ContractResponse{}, err
+	}
+
+	startOn, endOn := current.StartOn, current.EndOn
+	if req.StartOn != nil {
+		t, err := parseDate("start_on", *req.StartOn)
+		if err != nil {
+			return ContractResponse{}, err
+		}
+		patch.StartOn, startOn = &t, t
+	}
+	if req.EndOn != nil {
+		t, err := parseDate("end_on", *req.EndOn)
+		if err != nil {
+			return ContractResponse{}, err
+		}
+		patch.EndOn, endOn = &t, t
+	}
+	if err := validateContractPeriod(startOn, endOn); err != nil {
+		return ContractResponse{}, err
+	}
+	if err := validateSeatsAndCost(req.Seats, req.Cost); err != nil {
+		return ContractResponse{}, err
+	}
+	if req.ClearSeats && req.Seats != nil {
+		return ContractResponse{}, ErrInvalid("seats and clear_seats cannot be used together")
+	}
+	if req.ClearCost && req.Cost != nil {
+		return ContractResponse{}, ErrInvalid("cost and clear_cost cannot be used together")
+	}
+	patch.Seats = req.Seats
+	patch.Cost = req.Cost
+	patch.ClearSeats = req.ClearSeats
+	patch.ClearCost = req.ClearCost
+	if req.NotifyDaysBefore != nil {
+		if *req.NotifyDaysBefore < 0 {
+			return ContractResponse{}, ErrInvalid("notify_days_before must be >= 0")
+		}
+		patch.NotifyDaysBefore = req.NotifyDaysBefore
+	}
+	if req.Note != nil {
+		note := strings.TrimSpace(*req.Note)
+		patch.Note = &note
+	}
+	if req.ManagementNumbers != nil {
+		ids, err := s.resolveManagementNumbers(ctx, *req.ManagementNumbers)
+		if err != nil {
+			return ContractResponse{}, err
+		}
+		patch.AssetMasterIDs = &ids
+	}
+
+	out, err := s.store.UpdateContractByID(ctx, contractID, patch)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ContractResponse{}, ErrNotFound("contract not found")
+		}
+		return ContractResponse{}, mapMySQLError(err)
+	}
+	return s.withDaysRemaining(*out), nil
+}
+
+// ListExpiring は今日から within 以内に終了する契約を返す（既に終了したものは含めない）
+func (s *Service) ListExpiring(ctx context.Context, rawWithin string) ([]ContractResponse, error) {
+	within, err := parseWithin(rawWithin)
+	if err != nil {
+		return nil, err
+	}
+
+	today := truncateToDate(s.clock.Now())
+	items, err := s.store.ListExpiringContracts(ctx, today, today.Add(within))
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i] = s.withDaysRemaining(items[i])
+	}
+	return items, nil
+}
+
+// NotifyExpiring は通知期間に入った未通知の契約について notifier を呼び、通知済みとして記録する。
+// 1件の失敗で止めず、次回の実行で再送する。
+func (s *Service) NotifyExpiring(ctx context.Context, notifier Notifier) (int, error) {
+	now := s.clock.Now()
+	due, err := s.store.ListContractsDueForNotice(ctx, truncateToDate(now))
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, c := range due {
+		c = s.withDaysRemaining(c)
+		notice := ExpiryNotice{
+			ContractID:    c.ContractID,
+			ContractType:  c.ContractType,
+			Name:          c.Name,
+			Vendor:        c.Vendor,
+			OwnerID:       c.OwnerID,
+			EndOn:         c.EndOn,
+			DaysRemaining: c.DaysRemaining,
+			Assets:        c.Assets,
+		}
+		if err := notifier.Notify(ctx, notice); err != nil {
+			log.Printf("[WARN] contract expiry notify failed (contract_id=%d): %v", c.ContractID, err)
+			continue
+		}
+		if err := s.store.RecordExpiryNotice(ctx, c.ContractID, c.EndOn, now); err != nil {
+			log.Printf("[WARN] contract expiry notice record failed (contract_id=%d): %v", c.ContractID, err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// RunExpiryNotifier は起動直後と interval ごとに NotifyExpiring を実行する。ctx がキャンセルされると戻る。
+func (s *Service) RunExpiryNotifier(ctx context.Context, notifier Notifier, interval time.Duration) {
+	run := func() {
+		if n, err := s.NotifyExpiring(ctx, notifier); err != nil {
+			log.Printf("[WARN] contract expiry notifier: %v", err)
+		} else if n > 0 {
+			log.Printf("[INFO] contract expiry notifier: %d notice(s) sent", n)
+		}
+	}
+
+	run()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
+
+func (s *Service) resolveManagementNumbers(ctx context.Context, mngs []string) ([]uint64, error) {
+	ids := make([]uint64, 0, len(mngs))
+	seen := make(map[uint64]struct{}, len(mngs))
+	for _, raw := range mngs {
+		mng := strings.TrimSpace(raw)
+		if mng == "" {
+			return nil, ErrInvalid("management_numbers must not contain empty values")
+		}
+		id, err := s.store.ResolveMasterID(ctx, mng)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, ErrInvalid("management_number not found: " + mng)
+			}
+			return nil, err
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (s *Service) withDaysRemaining(c ContractResponse) ContractResponse {
+	c.DaysRemaining = daysBetween(truncateToDate(s.clock.Now()), truncateToDate(c.EndOn))
+	return c
+}
+
+// parseWithin は "60d" / "8w" / Go の duration（"72h"）を受け付ける。空なら30日。
+func parseWithin(raw string) (time.Duration, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return defaultExpiringWithin, nil
+	}
+
+	unit := raw[len(raw)-1]
+	if unit == 'd' || unit == 'w' {
+		n, err := strconv.Atoi(raw[:len(raw)-1])
+		if err != nil || n < 0 {
+			return 0, ErrInvalid("within must be like 60d, 8w or 72h")
+		}
+		days := n
+		if unit == 'w' {
+			days = n * 7
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return 0, ErrInvalid("within must be like 60d, 8w or 72h")
+	}
+	return d, nil
+}
+
+func normalizeContractType(raw string) (string, error) {
+	ct := strings.ToLower(strings.TrimSpace(raw))
+	switch ct {
+	case ContractTypeLicense, ContractTypeSupport, ContractTypeWarranty, ContractTypeOther:
+		return ct, nil
+	}
+	return "", ErrInvalid(fmt.Sprintf("contract_type must be one of %s, %s, %s, %s",
+		ContractTypeLicense, ContractTypeSupport, ContractTypeWarranty, ContractTypeOther))
+}
+
+func parseDate(field, raw string) (time.Time, error) {
+	t, err := time.Parse("2006-01-02", strings.TrimSpace(raw))
+	if err != nil {
+		return time.Time{}, ErrInvalid(field + " must be YYYY-MM-DD")
+	}
+	return t.UTC(), nil
+}
+
+func validateContractPeriod(startOn, endOn time.Time) error {
+	if endOn.Before(startOn) {
+		return ErrInvalid("end_on must be on or after start_on")
+	}
+	return nil
+}
+
+func validateSeatsAndCost(seats *int, cost *float64) error {
+	if seats != nil && *seats < 0 {
+		return ErrInvalid("seats must be >= 0")
+	}
+	if cost != nil && *cost < 0 {
+		return ErrInvalid("cost must be >= 0")
+	}
+	return nil
+}
+
+func requiredStringPatch(field string, raw *string) (*string, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	v := strings.TrimSpace(*raw)
+	if v == "" {
+		return nil, ErrInvalid(field + " must not be empty")
+	}
+	return &v, nil
+}
+
+func normalizeOptionalString(raw *string) *string {
+	if raw == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*raw)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+func truncateToDate(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+func mapMySQLError(err error) error {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		switch me.Number {
+		case 1062:
+			return ErrConflict("duplicate management_numbers")
+		case 1452:
+			return ErrInvalid("invalid reference")
+		}
+	}
+	return err
+}
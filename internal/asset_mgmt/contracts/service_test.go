@@ -0,0 +1,252 @@
+package contracts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseWithin(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want time.Duration
+	}{
+		{"", 30 * 24 * time.Hour},
+		{"60d", 60 * 24 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+		{"72h", 72 * time.Hour},
+	}
+	for _, tc := range cases {
+		got, err := parseWithin(tc.raw)
+		if err != nil {
+			t.Fatalf("parseWithin(%q) returned error: %v", tc.raw, err)
+		}
+		if got != tc.want {
+			t.Fatalf("parseWithin(%q) = %v, want %v", tc.raw, got, tc.want)
+		}
+	}
+
+	for _, raw := range []string{"d", "-1d", "abc", "10x"} {
+		if _, err := parseWithin(raw); err == nil {
+			t.Fatalf("parseWithin(%q) expected error", raw)
+		}
+	}
+}
+
+func TestCreateContractRejectsEndBeforeStart(t *testing.T) {
+	store := &fakeContractStore{}
+	svc := newServiceWithStore(store, fixedClock{})
+
+	_, err := svc.CreateContract(context.Background(), CreateContractRequest{
+		ContractType: "license",
+		Name:         "Office",
+		Vendor:       "MS",
+		StartOn:      "2026-04-01",
+		EndOn:        "2026-03-31",
+		OwnerID:      "u1",
+	})
+	assertCode(t, err, CodeInvalidArgument)
+	if store.created != nil {
+		t.Fatal("create should not be called on invalid period")
+	}
+}
+
+func TestCreateContractResolvesManagementNumbersAndDefaults(t *testing.T) {
+	store := &fakeContractStore{masterIDs: map[string]uint64{"PC-1": 11, "PC-2": 12}}
+	svc := newServiceWithStore(store, fixedClock{})
+
+	_, err := svc.CreateContract(context.Background(), CreateContractRequest{
+		ContractType:      " Warranty ",
+		Name:              "Extended warranty",
+		Vendor:            "Dell",
+		StartOn:           "2026-01-01",
+		EndOn:             "2027-01-01",
+		OwnerID:           "u1",
+		ManagementNumbers: []string{"PC-1", "PC-2", "PC-1"},
+	})
+	if err != nil {
+		t.Fatalf("CreateContract returned error: %v", err)
+	}
+	if store.created.ContractType != ContractTypeWarranty {
+		t.Fatalf("expected normalized contract_type, got %q", store.created.ContractType)
+	}
+	if store.created.NotifyDaysBefore != defaultNotifyDaysBefore {
+		t.Fatalf("expected default notify_days_before, got %d", store.created.NotifyDaysBefore)
+	}
+	if len(store.created.AssetMasterIDs) != 2 {
+		t.Fatalf("expected deduplicated asset ids, got %v", store.created.AssetMasterIDs)
+	}
+}
+
+func TestUpdateContractClearsSeatsAndCost(t *testing.T) {
+	seats := 10
+	store := &fakeContractStore{current: &ContractResponse{
+		ContractID: 1,
+		StartOn:    time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		EndOn:      time.Date(2027, 3, 31, 0, 0, 0, 0, time.UTC),
+		Seats:      &seats,
+	}}
+	svc := newServiceWithStore(store, fixedClock{})
+
+	if _, err := svc.UpdateContract(context.Background(), 1, UpdateContractRequest{ClearSeats: true, ClearCost: true}); err != nil {
+		t.Fatalf("UpdateContract returned error: %v", err)
+	}
+	if store.updated == nil || !store.updated.ClearSeats || !store.updated.ClearCost || store.updated.Seats != nil {
+		t.Fatalf("unexpected patch: %+v", store.updated)
+	}
+
+	store.updated = nil
+	_, err := svc.UpdateContract(context.Background(), 1, UpdateContractRequest{Seats: &seats, ClearSeats: true})
+	assertCode(t, err, CodeInvalidArgument)
+	if store.updated != nil {
+		t.Fatal("update should not be called when seats and clear_seats are both given")
+	}
+}
+
+func TestCreateContractRejectsUnknownManagementNumber(t *testing.T) {
+	store := &fakeContractStore{masterIDs: map[string]uint64{}}
+	svc := newServiceWithStore(store, fixedClock{})
+
+	_, err := svc.CreateContract(context.Background(), CreateContractRequest{
+		ContractType:      "support",
+		Name:              "Support",
+		Vendor:            "V",
+		StartOn:           "2026-01-01",
+		EndOn:             "2027-01-01",
+		OwnerID:           "u1",
+		ManagementNumbers: []string{"NOPE"},
+	})
+	assertCode(t, err, CodeInvalidArgument)
+}
+
+func TestListContractsResolvesManagementNumberFilter(t *testing.T) {
+	// 別名も正式な番号と同じく asset_master_id に解決される
+	store := &fakeContractStore{masterIDs: map[string]uint64{"PC-1": 11, "OLD-PC-1": 11}}
+	svc := newServiceWithStore(store, fixedClock{})
+
+	mng := "OLD-PC-1"
+	if _, _, err := svc.ListContracts(context.Background(), ContractFilter{ManagementNumber: &mng}); err != nil {
+		t.Fatalf("ListContracts returned error: %v", err)
+	}
+	if store.listed == nil || store.listed.AssetMasterID == nil || *store.listed.AssetMasterID != 11 {
+		t.Fatalf("expected filter by asset_master_id 11, got %+v", store.listed)
+	}
+
+	store.listed = nil
+	unknown := "NOPE"
+	items, total, err := svc.ListContracts(context.Background(), ContractFilter{ManagementNumber: &unknown})
+	if err != nil || len(items) != 0 || total != 0 || store.listed != nil {
+		t.Fatalf("expected an empty result for an unknown number, got %v, %d, %v (listed=%+v)", items, total, err, store.listed)
+	}
+}
+
+func TestNotifyExpiringRecordsOnlySuccessfulNotices(t *testing.T) {
+	endOn := time.Date(2026, 10, 28, 0, 0, 0, 0, time.UTC)
+	store := &fakeContractStore{
+		due: []ContractResponse{
+			{ContractID: 1, EndOn: endOn},
+			{ContractID: 2, EndOn: endOn},
+		},
+	}
+	svc := newServiceWithStore(store, fixedClock{})
+	notifier := &fakeNotifier{failFor: 2}
+
+	sent, err := svc.NotifyExpiring(context.Background(), notifier)
+	if err != nil {
+		t.Fatalf("NotifyExpiring returned error: %v", err)
+	}
+	if sent != 1 {
+		t.Fatalf("expected 1 notice sent, got %d", sent)
+	}
+	if len(store.recorded) != 1 || store.recorded[0] != 1 {
+		t.Fatalf("expected only contract 1 recorded, got %v", store.recorded)
+	}
+	if notifier.notices[0].DaysRemaining != 10 {
+		t.Fatalf("expected days_remaining 10, got %d", notifier.notices[0].DaysRemaining)
+	}
+}
+
+func assertCode(t *testing.T, err error, code Code) {
+	t.Helper()
+	var api *APIError
+	if !errors.As(err, &api) || api.Code != code {
+		t.Fatalf("expected %s, got %v", code, err)
+	}
+}
+
+type fixedClock struct{}
+
+func (fixedClock) Now() time.Time {
+	return time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+}
+
+type fakeNotifier struct {
+	failFor uint64
+	notices []ExpiryNotice
+}
+
+func (n *fakeNotifier) Notify(_ context.Context, notice ExpiryNotice) error {
+	if notice.ContractID == n.failFor {
+		return errors.New("smtp down")
+	}
+	n.notices = append(n.notices, notice)
+	return nil
+}
+
+type fakeContractStore struct {
+	masterIDs map[string]uint64
+	created   *createContractInput
+	current   *ContractResponse
+	updated   *updateContractInput
+	listed    *ContractFilter
+	due       []ContractResponse
+	recorded  []uint64
+}
+
+func (f *fakeContractStore) ResolveMasterID(_ context.Context, managementNumber string) (uint64, error) {
+	id, ok := f.masterIDs[managementNumber]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	return id, nil
+}
+
+func (f *fakeContractStore) CreateContract(_ context.Context, in createContractInput) (*ContractResponse, error) {
+	f.created = &in
+	return &ContractResponse{ContractID: 1, EndOn: in.EndOn}, nil
+}
+
+func (f *fakeContractStore) GetContractByID(context.Context, uint64) (*ContractResponse, error) {
+	if f.current == nil {
+		return nil, sql.ErrNoRows
+	}
+	return f.current, nil
+}
+
+func (f *fakeContractStore) ListContracts(_ context.Context, filter ContractFilter) ([]ContractResponse, int64, error) {
+	f.listed = &filter
+	return nil, 0, nil
+}
+
+func (f *fakeContractStore) UpdateContractByID(_ context.Context, _ uint64, patch updateContractInput) (*ContractResponse, error) {
+	if f.current == nil {
+		return nil, sql.ErrNoRows
+	}
+	f.updated = &patch
+	return f.current, nil
+}
+
+func (f *fakeContractStore) ListExpiringContracts(context.Context, time.Time, time.Time) ([]ContractResponse, error) {
+	return nil, nil
+}
+
+func (f *fakeContractStore) ListContractsDueForNotice(context.Context, time.Time) ([]ContractResponse, error) {
+	return f.due, nil
+}
+
+func (f *fakeContractStore) RecordExpiryNotice(_ context.Context, contractID uint64, _ time.Time, _ time.Time) error {
+	f.recorded = append(f.recorded, contractID)
+	return nil
+}
@@ -0,0 +1,370 @@
+package contracts
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"IRIS-backend/internal/asset_mgmt/inventory"
+	platformdb "IRIS-backend/internal/platform/db"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+const contractColumns = `
+	c.contract_id,
+	c.contract_type,
+	c.name,
+	c.vendor,
+	c.start_on,
+	c.end_on,
+	c.seats,
+	c.cost,
+	c.owner_id,
+	c.notify_days_before,
+	c.note,
+	c.created_at,
+	c.updated_at`
+
+func (s *Store) ResolveMasterID(ctx context.Context, managementNumber string) (uint64, error) {
+	return inventory.ResolveMasterID(ctx, s.db, managementNumber)
+}
+
+func (s *Store) CreateContract(ctx context.Context, in createContractInput) (*ContractResponse, error) {
+	const q = `
+	INSERT INTO contracts
+		(contract_type, name, vendor, start_on, end_on, seats, cost, owner_id, notify_days_before, note)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	var contractID uint64
+	err := platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		res, err := tx.ExecContext(ctx, q,
+			in.ContractType,
+			in.Name,
+			in.Vendor,
+			in.StartOn,
+			in.EndOn,
+			in.Seats,
+			in.Cost,
+			in.OwnerID,
+			in.NotifyDaysBefore,
+			in.Note,
+		)
+		if err != nil {
+			return err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		contractID = uint64(id)
+		return replaceContractAssets(ctx, tx, contractID, in.AssetMasterIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetContractByID(ctx, contractID)
+}
+
+func (s *Store) GetContractByID(ctx context.Context, contractID uint64) (*ContractResponse, error) {
+	q := `SELECT ` + contractColumns + `
+	FROM contracts c
+	WHERE c.contract_id = ?`
+
+	item, err := scanContract(s.db.QueryRowContext(ctx, q, contractID))
+	if err != nil {
+		return nil, err
+	}
+	items := []ContractResponse{item}
+	if err := s.attachAssets(ctx, items); err != nil {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+func (s *Store) ListContracts(ctx context.Context, f ContractFilter) ([]ContractResponse, int64, error) {
+	where := []string{"1=1"}
+	args := make([]any, 0, 4)
+
+	if f.ContractType != nil {
+		where = append(where, "c.contract_type = ?")
+		args = append(args, *f.ContractType)
+	}
+	if f.Vendor != nil {
+		where = append(where, "c.vendor LIKE ? ESCAPE '\\\\'")
+		args = append(args, "%"+escapeLike(*f.Vendor)+"%")
+	}
+	if f.OwnerID != nil {
+		where = append(where, "c.owner_id = ?")
+		args = append(args, *f.OwnerID)
+	}
+	if f.AssetMasterID != nil {
+		where = append(where, `EXISTS (
+			SELECT 1 FROM contract_assets ca
+			WHERE ca.contract_id = c.contract_id AND ca.asset_master_id = ?)`)
+		args = append(args, *f.AssetMasterID)
+	}
+
+	whereSQL := " WHERE " + strings.Join(where, " AND ")
+
+	q := `SELECT ` + contractColumns + `
+	FROM contracts c` + whereSQL + `
+	ORDER BY c.end_on ASC, c.contract_id ASC
+	LIMIT ? OFFSET ?`
+
+	out, err := s.queryContracts(ctx, q, append(append([]any{}, args...), f.Limit, f.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM contracts c`+whereSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
+
+func (s *Store) UpdateContractByID(ctx context.Context, contractID uint64, patch updateContractInput) (*ContractResponse, error) {
+	sets := make([]string, 0, 10)
+	args := make([]any, 0, 10)
+
+	appendSet := func(column string, value any) {
+		sets = append(sets, column+" = ?")
+		args = append(args, value)
+	}
+	if patch.ContractType != nil {
+		appendSet("contract_type", *patch.ContractType)
+	}
+	if patch.Name != nil {
+		appendSet("name", *patch.Name)
+	}
+	if patch.Vendor != nil {
+		appendSet("vendor", *patch.Vendor)
+	}
+	if patch.StartOn != nil {
+		appendSet("start_on", *patch.StartOn)
+	}
+	if patch.EndOn != nil {
+		appendSet("end_on", *patch.EndOn)
+	}
+	if patch.Seats != nil {
+		appendSet("seats", *patch.Seats)
+	} else if patch.ClearSeats {
+		sets = append(sets, "seats = NULL")
+	}
+	if patch.Cost != nil {
+		appendSet("cost", *patch.Cost)
+	} else if patch.ClearCost {
+		sets = append(sets, "cost = NULL")
+	}
+	if patch.OwnerID != nil {
+		appendSet("owner_id", *patch.OwnerID)
+	}
+	if patch.NotifyDaysBefore != nil {
+		appendSet("notify_days_before", *patch.NotifyDaysBefore)
+	}
+	if patch.Note != nil {
+		// 空にしたメモは作成時と同じく NULL にする
+		sets = append(sets, "note = NULLIF(?, '')")
+		args = append(args, *patch.Note)
+	}
+
+	err := platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		var dummy int
+		if err := tx.QueryRowContext(ctx, "SELECT 1 FROM contracts WHERE contract_id = ? FOR UPDATE", contractID).Scan(&dummy); err != nil {
+			return err
+		}
+		if len(sets) > 0 {
+			q := fmt.Sprintf("UPDATE contracts SET %s WHERE contract_id = ?", strings.Join(sets, ", "))
+			if _, err := tx.ExecContext(ctx, q, append(args, contractID)...); err != nil {
+				return err
+			}
+		}
+		if patch.AssetMasterIDs != nil {
+			return replaceContractAssets(ctx, tx, contractID, *patch.AssetMasterIDs)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetContractByID(ctx, contractID)
+}
+
+// ListExpiringContracts は end_on が [from, to] に入る契約を期限の近い順に返す
+func (s *Store) ListExpiringContracts(ctx context.Context, from, to time.Time) ([]ContractResponse, error) {
+	q := `SELECT ` + contractColumns + `
+	FROM contracts c
+	WHERE c.end_on >= ? AND c.end_on <= ?
+	ORDER BY c.end_on ASC, c.contract_id ASC`
+
+	return s.queryContracts(ctx, q, from, to)
+}
+
+// ListContractsDueForNotice は各契約の notify_days_before に入っていて、
+// 現在の end_on に対してまだ通知していない契約を返す
+func (s *Store) ListContractsDueForNotice(ctx context.Context, today time.Time) ([]ContractResponse, error) {
+	q := `SELECT ` + contractColumns + `
+	FROM contracts c
+	WHERE c.end_on >= ?
+		AND c.end_on <= DATE_ADD(?, INTERVAL c.notify_days_before DAY)
+		AND NOT EXISTS (
+			SELECT 1 FROM contract_notifications n
+			WHERE n.contract_id = c.contract_id AND n.end_on = c.end_on
+		)
+	ORDER BY c.end_on ASC, c.contract_id ASC`
+
+	return s.queryContracts(ctx, q, today, today)
+}
+
+func (s *Store) RecordExpiryNotice(ctx context.Context, contractID uint64, endOn time.Time, notifiedAt time.Time) error {
+	const q = `
+	INSERT INTO contract_notifications (contract_id, end_on, notified_at)
+	VALUES (?, ?, ?)`
+
+	_, err := s.db.ExecContext(ctx, q, contractID, endOn, notifiedAt)
+	return err
+}
+
+func (s *Store) queryContracts(ctx context.Context, query string, args ...any) ([]ContractResponse, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]ContractResponse, 0, 8)
+	for rows.Next() {
+		item, err := scanContract(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.attachAssets(ctx, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// attachAssets は契約ごとの紐付け資産をまとめて1クエリで取得して詰める
+func (s *Store) attachAssets(ctx context.Context, items []ContractResponse) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	index := make(map[uint64]int, len(items))
+	placeholders := make([]string, 0, len(items))
+	args := make([]any, 0, len(items))
+	for i := range items {
+		items[i].Assets = []ContractAssetResponse{}
+		index[items[i].ContractID] = i
+		placeholders = append(placeholders, "?")
+		args = append(args, items[i].ContractID)
+	}
+
+	q := `
+	SELECT ca.contract_id, am.asset_master_id, am.management_number, am.name
+	FROM contract_assets ca
+	JOIN assets_master am ON am.asset_master_id = ca.asset_master_id
+	WHERE ca.contract_id IN (` + strings.Join(placeholders, ", ") + `)
+	ORDER BY ca.contract_id, am.management_number`
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var contractID uint64
+		var a ContractAssetResponse
+		if err := rows.Scan(&contractID, &a.AssetMasterID, &a.ManagementNumber, &a.Name); err != nil {
+			return err
+		}
+		if i, ok := index[contractID]; ok {
+			items[i].Assets = append(items[i].Assets, a)
+		}
+	}
+	return rows.Err()
+}
+
+func replaceContractAssets(ctx context.Context, tx platformdb.DBTX, contractID uint64, assetMasterIDs []uint64) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM contract_assets WHERE contract_id = ?", contractID); err != nil {
+		return err
+	}
+	for _, id := range assetMasterIDs {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO contract_assets (contract_id, asset_master_id) VALUES (?, ?)",
+			contractID, id,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanContract(s scanner) (ContractResponse, error) {
+	var out ContractResponse
+	var seats sql.NullInt64
+	var cost sql.NullFloat64
+	var note sql.NullString
+	err := s.Scan(
+		&out.ContractID,
+		&out.ContractType,
+		&out.Name,
+		&out.Vendor,
+		&out.StartOn,
+		&out.EndOn,
+		&seats,
+		&cost,
+		&out.OwnerID,
+		&out.NotifyDaysBefore,
+		&note,
+		&out.CreatedAt,
+		&out.UpdatedAt,
+	)
+	if err != nil {
+		return ContractResponse{}, err
+	}
+
+	if seats.Valid {
+		v := int(seats.Int64)
+		out.Seats = &v
+	}
+	if cost.Valid {
+		v := cost.Float64
+		out.Cost = &v
+	}
+	if note.Valid {
+		v := note.String
+		out.Note = &v
+	}
+	return out, nil
+}
+
+// LIKE用のエスケープ（ユーザーが % や _ を入力してもワイルドカードにならないように）
+func escapeLike(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "%", "\\%")
+	s = strings.ReplaceAll(s, "_", "\\_")
+	return s
+}
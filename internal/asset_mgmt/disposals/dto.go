@@ -19,6 +19,8 @@ type DisposalResponse struct {
 	Reason           *string   `json:"reason,omitempty"`
 	ProcessedByID    *string   `json:"processed_by_id,omitempty"`
 	DisposedAt       time.Time `json:"disposed_at"`
+	// 付属品・構成部品の関係で欠けが出たとき（廃棄自体は行う）
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // ---- List payload ----
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"database/sql"
 	"errors"
+	"fmt"
 	"log"
 	"strings"
 	"time"
@@ -69,10 +70,12 @@ func (s *Service) CreateDisposal(ctx context.Context, managementNumber string, i
 	var resp DisposalResponse
 	err := s.withTx(ctx, func(tx *sql.Tx) error {
 		// master解決
-		masterID, err := s.store.ResolveMasterIDTx(ctx, tx, managementNumber)
+		masterID, canonical, err := s.store.ResolveMasterIDTx(ctx, tx, managementNumber)
 		if err != nil {
 			return err
 		}
+		// 別名で指定されても廃棄記録は正式な管理番号で残す
+		managementNumber = canonical
 
 		// 在庫ロック & 廃棄計画作成
 		lockedRows, err := s.store.LockAssetRows(ctx, tx, masterID)
@@ -113,6 +116,26 @@ func (s *Service) CreateDisposal(ctx context.Context, managementNumber string, i
 			return err
 		}
 
+		// 付属品・構成部品の関係で欠けが出るなら警告を返す
+		remaining, err := s.store.RemainingQuantity(ctx, tx, masterID)
+		if err != nil {
+			return err
+		}
+		relations, err := s.store.ListRelations(ctx, tx, masterID)
+		if err != nil {
+			return err
+		}
+		// 子として要る数は親の残数で決まる（親は 1 つだけ）
+		parentRemaining := 0
+		for _, r := range relations {
+			if r.ChildMasterID != masterID {
+				continue
+			}
+			if parentRemaining, err = s.store.RemainingQuantity(ctx, tx, r.ParentMasterID); err != nil {
+				return err
+			}
+		}
+
 		resp = DisposalResponse{
 			DisposalULID:     duid,
 			ManagementNumber: managementNumber,
@@ -120,6 +143,7 @@ func (s *Service) CreateDisposal(ctx context.Context, managementNumber string, i
 			Reason:           in.Reason,
 			ProcessedByID:    in.ProcessedByID,
 			DisposedAt:       now,
+			Warnings:         relationWarnings(masterID, remaining, parentRemaining, relations),
 		}
 		return nil
 	})
@@ -171,6 +195,49 @@ func (s *Service) ListDisposals(ctx context.Context, f DisposalFilter, p Page) (
 }
 
 // ---- helpers ----
+
+// relationWarnings は廃棄後の残数で欠けるキット・構成部品を警告にする。
+// 子として親の残数 × 1 つあたりの数に足りなくなった関係と、親が残 0 になったのに残っている子を挙げる
+func relationWarnings(masterID uint64, remaining, parentRemaining int, relations []inventory.Relation) []string {
+	var (
+		warnings   []string
+		bundles    []string
+		components []string
+		self       string
+	)
+	for _, r := range relations {
+		if r.ChildMasterID == masterID {
+			needed := r.Quantity * parentRemaining
+			if remaining >= needed {
+				continue
+			}
+			if r.Type == inventory.RelationBundle {
+				warnings = append(warnings, fmt.Sprintf("kit %s is incomplete: %s has %d left, %d needed",
+					r.ParentManagementNumber, r.ChildManagementNumber, remaining, needed))
+			} else {
+				warnings = append(warnings, fmt.Sprintf("%s is missing component %s: %d left, %d needed",
+					r.ParentManagementNumber, r.ChildManagementNumber, remaining, needed))
+			}
+			continue
+		}
+		self = r.ParentManagementNumber
+		if r.Type == inventory.RelationBundle {
+			bundles = append(bundles, r.ChildManagementNumber)
+		} else {
+			components = append(components, r.ChildManagementNumber)
+		}
+	}
+	if remaining == 0 {
+		if len(bundles) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s has no stock left but still has bundle members: %s", self, strings.Join(bundles, ", ")))
+		}
+		if len(components) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s has no stock left but still has components: %s", self, strings.Join(components, ", ")))
+		}
+	}
+	return warnings
+}
+
 func toNullString(s *string) (ns sql.NullString) {
 	if s != nil && strings.TrimSpace(*s) != "" {
 		ns.Valid, ns.String = true, *s
@@ -0,0 +1,52 @@
+package disposals
+
+import (
+	"strings"
+	"testing"
+
+	"IRIS-backend/internal/asset_mgmt/inventory"
+)
+
+func TestRelationWarnings(t *testing.T) {
+	rels := []inventory.Relation{
+		{Type: inventory.RelationBundle, ParentMasterID: 1, ParentManagementNumber: "PRJ-0001", ChildMasterID: 2, ChildManagementNumber: "PRJ-0001-CBL", Quantity: 2},
+	}
+
+	// 親 1 台に 2 本必要なケーブルが 1 本になった
+	w := relationWarnings(2, 1, 1, rels)
+	if len(w) != 1 || !strings.Contains(w[0], "kit PRJ-0001 is incomplete") || !strings.Contains(w[0], "1 left, 2 needed") {
+		t.Fatalf("unexpected warnings: %v", w)
+	}
+	if w := relationWarnings(2, 2, 1, rels); len(w) != 0 {
+		t.Fatalf("expected no warning while enough remain, got %v", w)
+	}
+	// 親が 3 台あれば 6 本要る
+	w = relationWarnings(2, 5, 3, rels)
+	if len(w) != 1 || !strings.Contains(w[0], "5 left, 6 needed") {
+		t.Fatalf("unexpected warnings for 3 parents: %v", w)
+	}
+	// 親が残っていなければ子は足りなくならない
+	if w := relationWarnings(2, 0, 0, rels); len(w) != 0 {
+		t.Fatalf("expected no warning without parent stock, got %v", w)
+	}
+
+	rels = []inventory.Relation{
+		{Type: inventory.RelationComponent, ParentMasterID: 5, ParentManagementNumber: "PC-0005", ChildMasterID: 1, ChildManagementNumber: "PRJ-0001", Quantity: 1},
+		{Type: inventory.RelationBundle, ParentMasterID: 1, ParentManagementNumber: "PRJ-0001", ChildMasterID: 3, ChildManagementNumber: "PRJ-0001-R", Quantity: 1},
+		{Type: inventory.RelationBundle, ParentMasterID: 1, ParentManagementNumber: "PRJ-0001", ChildMasterID: 4, ChildManagementNumber: "PRJ-0001-CASE", Quantity: 1},
+	}
+	// 付属品を持ち、自身も構成部品であるマスタを全数廃棄
+	w = relationWarnings(1, 0, 1, rels)
+	if len(w) != 2 {
+		t.Fatalf("expected 2 warnings, got %v", w)
+	}
+	if !strings.Contains(w[0], "PC-0005 is missing component PRJ-0001") {
+		t.Fatalf("unexpected warning: %s", w[0])
+	}
+	if !strings.Contains(w[1], "PRJ-0001 has no stock left but still has bundle members: PRJ-0001-R, PRJ-0001-CASE") {
+		t.Fatalf("unexpected warning: %s", w[1])
+	}
+	if w := relationWarnings(1, 1, 1, rels); len(w) != 0 {
+		t.Fatalf("expected no warning while the parent has stock, got %v", w)
+	}
+}
@@ -21,8 +21,16 @@ func (s *Store) ResolveMasterID(ctx context.Context, managementNumber string) (u
 	return s.resolveMasterID(ctx, s.db, managementNumber)
 }
 
-func (s *Store) ResolveMasterIDTx(ctx context.Context, tx *sql.Tx, managementNumber string) (uint64, error) {
-	return s.resolveMasterID(ctx, tx, managementNumber)
+// ResolveMasterIDTx は別名でも引けるよう、asset_master_id と正式な管理番号を返す
+func (s *Store) ResolveMasterIDTx(ctx context.Context, tx *sql.Tx, managementNumber string) (uint64, string, error) {
+	id, canonical, err := inventory.ResolveManagementNumber(ctx, tx, managementNumber)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, "", ErrNotFound("assets_master not found")
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	return id, canonical, nil
 }
 
 func (s *Store) resolveMasterID(ctx context.Context, q platformdb.DBTX, managementNumber string) (uint64, error) {
@@ -61,6 +69,15 @@ func (s *Store) ReconcileAssetStatus(ctx context.Context, tx *sql.Tx, masterID u
 	return inventory.ReconcileAssetStatus(ctx, tx, int64(masterID))
 }
 
+// RemainingQuantity は廃棄後に在庫として数える数量
+func (s *Store) RemainingQuantity(ctx context.Context, tx *sql.Tx, masterID uint64) (int, error) {
+	return inventory.GetTotalQuantityByMasterID(ctx, tx, int64(masterID))
+}
+
+func (s *Store) ListRelations(ctx context.Context, tx *sql.Tx, masterID uint64) ([]inventory.Relation, error) {
+	return inventory.ListRelationsByMasterID(ctx, tx, masterID)
+}
+
 // --- disposals ---
 
 func (s *Store) InsertDisposal(ctx context.Context, tx *sql.Tx, m *Disposal) (uint64, error) {
@@ -7,13 +7,26 @@ import (
 	platformdb "IRIS-backend/internal/platform/db"
 )
 
+// asset_statuses.system_role: 在庫・貸出から自動で割り当てる状態
 const (
-	StatusNormal           = 1
-	StatusLent             = 4
-	StatusZeroStock        = 5
-	ManagementCategoryLend = 1
+	StatusRoleNormal    = "normal"
+	StatusRoleLent      = "lent"
+	StatusRoleZeroStock = "zero_stock"
 )
 
+// StatusFlags asset_statuses の意味づけ（dbmng の /asset-statuses で管理）
+type StatusFlags struct {
+	CountsAsAvailable bool   // 数量を在庫（貸出可能数の母数）に数える
+	IsTerminal        bool   // 廃棄・紛失など。自動で別の状態へ移さない
+	SystemRole        string // normal / lent / zero_stock（役割なしは ""）
+}
+
+// Catalog 状態と管理区分のフラグ
+type Catalog struct {
+	Statuses map[int]StatusFlags
+	Lendable map[int]bool // management_category_id → is_lendable
+}
+
 type LockedAssetRow struct {
 	AssetID  uint64
 	Quantity int
@@ -24,18 +37,38 @@ type QuantityAdjustment struct {
 	Delta   int
 }
 
+// ResolveMasterID は管理番号か、その別名（旧システムの番号・統合前の番号）から asset_master_id を引く
 func ResolveMasterID(ctx context.Context, q platformdb.DBTX, managementNumber string) (uint64, error) {
+	id, _, err := ResolveManagementNumber(ctx, q, managementNumber)
+	return id, err
+}
+
+// ResolveManagementNumber は asset_master_id と正式な管理番号を返す。
+// 別名（asset_management_number_aliases）で引いた場合、返る管理番号は引数と異なる
+func ResolveManagementNumber(ctx context.Context, q platformdb.DBTX, managementNumber string) (uint64, string, error) {
 	const query = `
-SELECT asset_master_id
-FROM assets_master
-WHERE management_number = ?
+SELECT r.asset_master_id, r.management_number
+FROM (
+	SELECT asset_master_id, management_number, 0 AS is_alias
+	FROM assets_master
+	WHERE management_number = ?
+	UNION ALL
+	SELECT m.asset_master_id, m.management_number, 1 AS is_alias
+	FROM asset_management_number_aliases a
+	JOIN assets_master m ON m.asset_master_id = a.asset_master_id
+	WHERE a.management_number = ?
+) r
+ORDER BY r.is_alias
 LIMIT 1`
 
-	var id uint64
-	if err := q.QueryRowContext(ctx, query, managementNumber).Scan(&id); err != nil {
-		return 0, err
+	var (
+		id        uint64
+		canonical string
+	)
+	if err := q.QueryRowContext(ctx, query, managementNumber, managementNumber).Scan(&id, &canonical); err != nil {
+		return 0, "", err
 	}
-	return id, nil
+	return id, canonical, nil
 }
 
 func GetManagementCategoryIDByMasterID(ctx context.Context, q platformdb.DBTX, assetMasterID int64) (int, error) {
@@ -83,11 +116,15 @@ FOR UPDATE`
 	return locked, nil
 }
 
+// GetTotalQuantityByMasterID は在庫に数える状態（counts_as_available）の行の数量合計を返す
 func GetTotalQuantityByMasterID(ctx context.Context, q platformdb.DBTX, assetMasterID int64) (int, error) {
 	const query = `
-SELECT COALESCE(SUM(quantity), 0)
-FROM assets
-WHERE asset_master_id = ?`
+SELECT COALESCE(SUM(a.quantity), 0)
+FROM assets a
+LEFT JOIN asset_statuses s
+	ON s.status_id = a.status_id
+WHERE a.asset_master_id = ?
+	AND COALESCE(s.counts_as_available, TRUE)`
 
 	var total int
 	if err := q.QueryRowContext(ctx, query, assetMasterID).Scan(&total); err != nil {
@@ -185,17 +222,90 @@ WHERE asset_id = ?`
 	return nil
 }
 
-func DetermineStatus(totalQty int, outstandingQty int, managementCategoryID int) int {
-	if totalQty <= 0 {
-		return StatusZeroStock
+// LoadCatalog は状態・管理区分のフラグを読む
+func LoadCatalog(ctx context.Context, q platformdb.DBTX) (Catalog, error) {
+	c := Catalog{Statuses: map[int]StatusFlags{}, Lendable: map[int]bool{}}
+
+	rows, err := q.QueryContext(ctx, `
+SELECT status_id, counts_as_available, is_terminal, COALESCE(system_role, '')
+FROM asset_statuses`)
+	if err != nil {
+		return Catalog{}, err
+	}
+	for rows.Next() {
+		var (
+			id    int
+			flags StatusFlags
+		)
+		if err := rows.Scan(&id, &flags.CountsAsAvailable, &flags.IsTerminal, &flags.SystemRole); err != nil {
+			rows.Close()
+			return Catalog{}, err
+		}
+		c.Statuses[id] = flags
+	}
+	if err := rows.Close(); err != nil {
+		return Catalog{}, err
+	}
+
+	rows, err = q.QueryContext(ctx, `
+SELECT management_category_id, is_lendable
+FROM asset_management_categories`)
+	if err != nil {
+		return Catalog{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			id       int
+			lendable bool
+		)
+		if err := rows.Scan(&id, &lendable); err != nil {
+			return Catalog{}, err
+		}
+		c.Lendable[id] = lendable
+	}
+	return c, rows.Err()
+}
+
+func (c Catalog) roleStatus(role string) (int, bool) {
+	for id, f := range c.Statuses {
+		if f.SystemRole == role {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// DetermineStatus は 1 行分の状態を決める。
+// 終端の状態と、役割が無く在庫に数えない状態（修理中など手動で付けたもの）はそのまま残す。
+// それ以外は在庫 0 → zero_stock、貸出可の管理区分で貸出中 → lent、それ以外 → normal。
+// 役割を持つ状態が登録されていなければ今の状態のまま
+func DetermineStatus(c Catalog, currentStatusID, totalQty, outstandingQty, managementCategoryID int) int {
+	if cur, ok := c.Statuses[currentStatusID]; ok && cur.SystemRole == "" {
+		if cur.IsTerminal || !cur.CountsAsAvailable {
+			return currentStatusID
+		}
 	}
-	if managementCategoryID == ManagementCategoryLend && outstandingQty > 0 {
-		return StatusLent
+
+	role := StatusRoleNormal
+	switch {
+	case totalQty <= 0:
+		role = StatusRoleZeroStock
+	case c.Lendable[managementCategoryID] && outstandingQty > 0:
+		role = StatusRoleLent
+	}
+	if id, ok := c.roleStatus(role); ok {
+		return id
 	}
-	return StatusNormal
+	return currentStatusID
 }
 
 func ReconcileAssetStatus(ctx context.Context, q platformdb.DBTX, assetMasterID int64) error {
+	catalog, err := LoadCatalog(ctx, q)
+	if err != nil {
+		return err
+	}
+
 	managementCategoryID, err := GetManagementCategoryIDByMasterID(ctx, q, assetMasterID)
 	if err != nil {
 		return err
@@ -211,11 +321,39 @@ func ReconcileAssetStatus(ctx context.Context, q platformdb.DBTX, assetMasterID
 		return err
 	}
 
-	statusID := DetermineStatus(totalQty, outstandingQty, managementCategoryID)
+	rows, err := q.QueryContext(ctx, `
+SELECT asset_id, status_id
+FROM assets
+WHERE asset_master_id = ?`, assetMasterID)
+	if err != nil {
+		return err
+	}
+	next := map[uint64]int{}
+	for rows.Next() {
+		var (
+			assetID uint64
+			current int
+		)
+		if err := rows.Scan(&assetID, &current); err != nil {
+			rows.Close()
+			return err
+		}
+		if s := DetermineStatus(catalog, current, totalQty, outstandingQty, managementCategoryID); s != current {
+			next[assetID] = s
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
 	const query = `
 UPDATE assets
 SET status_id = ?
-WHERE asset_master_id = ?`
-	_, err = q.ExecContext(ctx, query, statusID, assetMasterID)
-	return err
+WHERE asset_id = ?`
+	for assetID, statusID := range next {
+		if _, err := q.ExecContext(ctx, query, statusID, assetID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
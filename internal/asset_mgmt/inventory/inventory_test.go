@@ -41,24 +41,54 @@ func TestComputeDisposalPlanRejectsInsufficientStock(t *testing.T) {
 }
 
 func TestDetermineStatus(t *testing.T) {
+	const (
+		normal    = 1
+		repair    = 2
+		disposed  = 3
+		lent      = 4
+		zeroStock = 5
+		reserved  = 6
+	)
+	catalog := Catalog{
+		Statuses: map[int]StatusFlags{
+			normal:    {CountsAsAvailable: true, SystemRole: StatusRoleNormal},
+			repair:    {},
+			disposed:  {IsTerminal: true},
+			lent:      {CountsAsAvailable: true, SystemRole: StatusRoleLent},
+			zeroStock: {CountsAsAvailable: true, SystemRole: StatusRoleZeroStock},
+			reserved:  {CountsAsAvailable: true},
+		},
+		Lendable: map[int]bool{1: true, 2: false},
+	}
+
 	cases := []struct {
 		name                 string
+		current              int
 		totalQty             int
 		outstandingQty       int
 		managementCategoryID int
 		want                 int
 	}{
-		{name: "zero stock", totalQty: 0, outstandingQty: 0, managementCategoryID: 1, want: StatusZeroStock},
-		{name: "lend outstanding", totalQty: 3, outstandingQty: 1, managementCategoryID: ManagementCategoryLend, want: StatusLent},
-		{name: "normal", totalQty: 3, outstandingQty: 0, managementCategoryID: ManagementCategoryLend, want: StatusNormal},
-		{name: "non lend category stays normal", totalQty: 3, outstandingQty: 2, managementCategoryID: 2, want: StatusNormal},
+		{name: "zero stock", current: normal, totalQty: 0, outstandingQty: 0, managementCategoryID: 1, want: zeroStock},
+		{name: "lend outstanding", current: normal, totalQty: 3, outstandingQty: 1, managementCategoryID: 1, want: lent},
+		{name: "normal", current: lent, totalQty: 3, outstandingQty: 0, managementCategoryID: 1, want: normal},
+		{name: "non lendable category stays normal", current: normal, totalQty: 3, outstandingQty: 2, managementCategoryID: 2, want: normal},
+		{name: "terminal kept", current: disposed, totalQty: 0, outstandingQty: 0, managementCategoryID: 1, want: disposed},
+		{name: "manual hold kept", current: repair, totalQty: 3, outstandingQty: 1, managementCategoryID: 1, want: repair},
+		{name: "available manual status reconciled", current: reserved, totalQty: 3, outstandingQty: 1, managementCategoryID: 1, want: lent},
+		{name: "unknown status reconciled", current: 99, totalQty: 3, outstandingQty: 0, managementCategoryID: 1, want: normal},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			if got := DetermineStatus(tc.totalQty, tc.outstandingQty, tc.managementCategoryID); got != tc.want {
+			if got := DetermineStatus(catalog, tc.current, tc.totalQty, tc.outstandingQty, tc.managementCategoryID); got != tc.want {
 				t.Fatalf("DetermineStatus() = %d, want %d", got, tc.want)
 			}
 		})
 	}
+
+	// 役割を持つ状態が無ければ今の状態のまま
+	if got := DetermineStatus(Catalog{}, 7, 0, 0, 1); got != 7 {
+		t.Fatalf("expected current status kept without roles, got %d", got)
+	}
 }
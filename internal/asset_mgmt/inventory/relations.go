@@ -0,0 +1,112 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	platformdb "IRIS-backend/internal/platform/db"
+)
+
+// 資産の関係（asset_relations）
+// - bundle: 付属品（リモコン・ケーブル・ケースなど）。キット貸出で親と一緒に貸し出し、返却・廃棄で欠けたら警告する
+// - component: 構成部品。親子として記録するだけで、貸出では展開しない
+// 子は 1 つの親にだけ属する（child_master_id は一意）
+const (
+	RelationBundle    = "bundle"
+	RelationComponent = "component"
+)
+
+// MaxBundleDepth 付属品の入れ子の上限（キット展開でたどる階層数）
+const MaxBundleDepth = 5
+
+type Relation struct {
+	RelationID             uint64
+	Type                   string
+	ParentMasterID         uint64
+	ParentManagementNumber string
+	ChildMasterID          uint64
+	ChildManagementNumber  string
+	Quantity               int // 親 1 つあたりの数
+	Note                   sql.NullString
+	CreatedAt              time.Time
+}
+
+// BundleMember キットを 1 セット貸し出すときに一緒に出す付属品
+type BundleMember struct {
+	AssetMasterID    uint64
+	ManagementNumber string
+	ParentMasterID   uint64
+	Quantity         int // 親キット 1 セットあたり（入れ子は掛け合わせた数）
+	Depth            int // 直下の付属品が 1
+}
+
+// ListRelationsByMasterID は asset_master_id が親か子になっている関係を返す
+func ListRelationsByMasterID(ctx context.Context, q platformdb.DBTX, assetMasterID uint64) ([]Relation, error) {
+	const query = `
+SELECT r.relation_id, r.relation_type,
+	r.parent_master_id, p.management_number,
+	r.child_master_id, c.management_number,
+	r.quantity, r.note, r.created_at
+FROM asset_relations r
+JOIN assets_master p ON p.asset_master_id = r.parent_master_id
+JOIN assets_master c ON c.asset_master_id = r.child_master_id
+WHERE r.parent_master_id = ? OR r.child_master_id = ?
+ORDER BY r.relation_type, c.management_number`
+
+	rows, err := q.QueryContext(ctx, query, assetMasterID, assetMasterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Relation, 0, 4)
+	for rows.Next() {
+		var r Relation
+		if err := rows.Scan(
+			&r.RelationID, &r.Type,
+			&r.ParentMasterID, &r.ParentManagementNumber,
+			&r.ChildMasterID, &r.ChildManagementNumber,
+			&r.Quantity, &r.Note, &r.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// ListBundleMembers は付属品を入れ子まで展開して返す（親は含まない）
+func ListBundleMembers(ctx context.Context, q platformdb.DBTX, rootMasterID uint64) ([]BundleMember, error) {
+	const query = `
+WITH RECURSIVE kit (parent_master_id, child_master_id, quantity, depth) AS (
+	SELECT parent_master_id, child_master_id, quantity, 1
+	FROM asset_relations
+	WHERE parent_master_id = ? AND relation_type = 'bundle'
+	UNION ALL
+	SELECT r.parent_master_id, r.child_master_id, k.quantity * r.quantity, k.depth + 1
+	FROM asset_relations r
+	JOIN kit k ON r.parent_master_id = k.child_master_id
+	WHERE r.relation_type = 'bundle' AND k.depth < ?
+)
+SELECT k.child_master_id, m.management_number, k.parent_master_id, k.quantity, k.depth
+FROM kit k
+JOIN assets_master m ON m.asset_master_id = k.child_master_id
+ORDER BY k.depth, m.management_number`
+
+	rows, err := q.QueryContext(ctx, query, rootMasterID, MaxBundleDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]BundleMember, 0, 4)
+	for rows.Next() {
+		var m BundleMember
+		if err := rows.Scan(&m.AssetMasterID, &m.ManagementNumber, &m.ParentMasterID, &m.Quantity, &m.Depth); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
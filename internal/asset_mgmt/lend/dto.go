@@ -31,6 +31,25 @@ type CreateReturnByKeyRequest struct {
 	Note          *string `json:"note,omitempty"`
 }
 
+// キット貸出リクエスト（親と付属品 bundle をまとめて貸し出す）
+type CreateKitLendRequest struct {
+	ManagementNumber string `json:"management_number" binding:"required"` // 親の管理番号
+	Quantity         int    `json:"quantity"`                             // キットのセット数（省略時 1）
+	BorrowerID       string `json:"borrower_id" binding:"required"`
+	// "2006-01-02" 形式の文字列を想定（DATE）
+	DueOn    *string `json:"due_on,omitempty"`
+	LentByID *string `json:"lent_by_id,omitempty"`
+	Note     *string `json:"note,omitempty"`
+}
+
+// キット返却リクエスト
+type CreateKitReturnRequest struct {
+	ProcessedByID *string `json:"processed_by_id,omitempty"`
+	Note          *string `json:"note,omitempty"`
+	// 戻ってこなかった管理番号（別名でも可）。その貸出は返却せずに残す
+	Missing []string `json:"missing,omitempty"`
+}
+
 // 貸出レスポンス
 type LendResponse struct {
 	LendID           int64      `json:"lend_id"`
@@ -45,6 +64,13 @@ type LendResponse struct {
 	Note             *string    `json:"note,omitempty"`
 	Returned         bool       `json:"returned"`
 	ReturnedQuantity int        `json:"returned_quantity"`
+	KitULID          *string    `json:"kit_ulid,omitempty"`
+}
+
+// キット貸出レスポンス（lends の先頭が親）
+type KitLendResponse struct {
+	KitULID string         `json:"kit_ulid"`
+	Lends   []LendResponse `json:"lends"`
 }
 
 // 返却レスポンス
@@ -57,6 +83,26 @@ type ReturnResponse struct {
 	ReturnedAt    time.Time `json:"returned_at"`
 	Note          *string   `json:"note,omitempty"`
 	// 返却元の貸出情報を一部返したいならここに追加
+	KitULID *string `json:"kit_ulid,omitempty"`
+	// キット貸出の一部だけが戻ったとき、まだ戻っていない貸出
+	KitOutstanding []KitOutstandingItem `json:"kit_outstanding,omitempty"`
+	Warnings       []string             `json:"warnings,omitempty"`
+}
+
+// キットの未返却分
+type KitOutstandingItem struct {
+	LendID              int64  `json:"lend_id"`
+	LendULID            string `json:"lend_ulid"`
+	ManagementNumber    string `json:"management_number"`
+	OutstandingQuantity int    `json:"outstanding_quantity"`
+}
+
+// キット返却レスポンス
+type KitReturnResponse struct {
+	KitULID     string               `json:"kit_ulid"`
+	Returns     []ReturnResponse     `json:"returns"`
+	Outstanding []KitOutstandingItem `json:"outstanding"`
+	Warnings    []string             `json:"warnings,omitempty"`
 }
 
 // ---- API Specific Responses ----
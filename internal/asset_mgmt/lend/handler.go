@@ -18,6 +18,8 @@ func RegisterRoutes(r gin.IRoutes, svc *Service) {
 	h := &LendHandler{svc: svc}
 	// 貸出登録
 	r.POST("/lends", h.CreateLend)
+	// キット貸出（付属品をまとめて）
+	r.POST("/lends/kit", h.CreateKitLend)
 	// 貸出単一取得
 	r.GET("/lends/:lend_id", h.GetLend)
 	// 貸出履歴リスト
@@ -25,6 +27,7 @@ func RegisterRoutes(r gin.IRoutes, svc *Service) {
 	// 返却登録
 	r.POST("/returns", h.CreateReturn)
 	r.POST("/returns/key/:lend_key", h.CreateReturnByLendKey)
+	r.POST("/returns/kit/:kit_ulid", h.CreateKitReturn)
 	// 返却単一取得
 	r.GET("/returns/:return_id", h.GetReturn)
 	// 返却履歴リスト
@@ -57,6 +60,34 @@ func (h *LendHandler) CreateLend(c *gin.Context) {
 	c.JSON(http.StatusCreated, resp)
 }
 
+// @Summary      Create a kit lend
+// @Description  Lends an asset together with all of its bundle members (accessories, expanded through nested bundles). Every lend shares the returned kit_ulid.
+// @Description  Quantity is the number of kits; member quantities are multiplied by it.
+// @Tags         lends
+// @Accept       json
+// @Produce      json
+// @Param        lend body CreateKitLendRequest true "Kit lend to create"
+// @Success      201 {object} KitLendResponse
+// @Failure      400 {object} ErrorResponse "Invalid input or asset without bundle members"
+// @Failure      404 {object} ErrorResponse "Asset not found"
+// @Failure      409 {object} ErrorResponse "Insufficient stock of the asset or a member"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /lends/kit [post]
+func (h *LendHandler) CreateKitLend(c *gin.Context) {
+	var req CreateKitLendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpx.WriteError(c, http.StatusBadRequest, ErrCodeInvalidArgument, err.Error())
+		return
+	}
+
+	resp, err := h.svc.CreateKitLend(c.Request.Context(), req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
 // @Summary      Create a return record
 // @Description  Register a return for a specific lend record.
 // @Tags         returns
@@ -140,6 +171,35 @@ func (h *LendHandler) CreateReturnByLendKey(c *gin.Context) {
 	c.JSON(http.StatusCreated, resp)
 }
 
+// @Summary      Return a kit
+// @Description  Returns everything still outstanding in a kit lend, except the management numbers listed in missing.
+// @Description  When anything stays outstanding the response carries it in outstanding and a warning.
+// @Tags         returns
+// @Accept       json
+// @Produce      json
+// @Param        kit_ulid path string true "Kit ULID"
+// @Param        return body CreateKitReturnRequest true "Return details"
+// @Success      201 {object} KitReturnResponse
+// @Failure      400 {object} ErrorResponse "Invalid input"
+// @Failure      404 {object} ErrorResponse "Kit lend not found"
+// @Failure      409 {object} ErrorResponse "Nothing left to return"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /returns/kit/{kit_ulid} [post]
+func (h *LendHandler) CreateKitReturn(c *gin.Context) {
+	var req CreateKitReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpx.WriteError(c, http.StatusBadRequest, ErrCodeInvalidArgument, err.Error())
+		return
+	}
+
+	resp, err := h.svc.CreateKitReturn(c.Request.Context(), c.Param("kit_ulid"), req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
 // @Summary      Get a lend record
 // @Description  Get details of a lend record by its ID or ULID.
 // @Tags         lends
@@ -174,6 +234,7 @@ func (h *LendHandler) GetLend(c *gin.Context) {
 // @Param        asset_master_id query int false "Filter by asset master ID"
 // @Param        management_number query string false "Filter by management number"
 // @Param        returned query bool false "Filter by returned status (true/false)"
+// @Param        kit_ulid query string false "Filter by kit lend"
 // @Param        limit query int false "Number of items to return" default(50)
 // @Param        offset query int false "Offset for pagination" default(0)
 // @Success      200 {array} LendResponse
@@ -183,6 +244,7 @@ func (h *LendHandler) ListLends(c *gin.Context) {
 	filter := LendFilter{
 		BorrowerID:       c.Query("borrower_id"),
 		ManagementNumber: c.Query("management_number"),
+		KitULID:          c.Query("kit_ulid"),
 	}
 
 	assetMasterIDStr := c.Query("asset_master_id")
@@ -0,0 +1,277 @@
+package lend
+
+// キット貸出
+// - 親の管理番号を指定すると、asset_relations の付属品（bundle）を入れ子までたどり、全員分の貸出を 1 トランザクションで作る
+// - 作った貸出は kit_ulid を共有する。返却は通常の /returns でも、/returns/kit/:kit_ulid でまとめてでもよい
+// - 一部だけが戻ったときは、返却レスポンスに未返却分と警告を載せる
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"IRIS-backend/internal/asset_mgmt/inventory"
+)
+
+// kitLendItem キット貸出で作る貸出 1 件分
+type kitLendItem struct {
+	AssetMasterID    int64
+	ManagementNumber string
+	Quantity         int
+}
+
+// planKitLend は親を先頭に、付属品の数量をセット数で掛けた貸出の並びを作る
+func planKitLend(rootID int64, rootManagementNumber string, sets int, members []inventory.BundleMember) []kitLendItem {
+	items := make([]kitLendItem, 0, len(members)+1)
+	items = append(items, kitLendItem{AssetMasterID: rootID, ManagementNumber: rootManagementNumber, Quantity: sets})
+	for _, m := range members {
+		items = append(items, kitLendItem{
+			AssetMasterID:    int64(m.AssetMasterID),
+			ManagementNumber: m.ManagementNumber,
+			Quantity:         m.Quantity * sets,
+		})
+	}
+	return items
+}
+
+// kitWarnings はキットの未返却分を人が読める警告にする
+func kitWarnings(outstanding []KitOutstandingItem) []string {
+	if len(outstanding) == 0 {
+		return nil
+	}
+	parts := make([]string, 0, len(outstanding))
+	for _, it := range outstanding {
+		parts = append(parts, fmt.Sprintf("%s x%d", it.ManagementNumber, it.OutstandingQuantity))
+	}
+	return []string{"kit is incomplete; not yet returned: " + strings.Join(parts, ", ")}
+}
+
+// kitMissingMasters は missing に挙げた管理番号（別名でも可）を asset_master_id にする。
+// キットに含まれないもの・存在しないものは 400
+func kitMissingMasters(lends []*Lend, missing []string, resolve func(managementNumber string) (int64, error)) (map[int64]bool, error) {
+	out := make(map[int64]bool, len(missing))
+	for _, m := range missing {
+		if m = strings.TrimSpace(m); m == "" {
+			continue
+		}
+		id, err := resolve(m)
+		var de *DomainError
+		if errors.As(err, &de) && de.Code == ErrCodeNotFound {
+			return nil, NewInvalidArgumentError(m + " is not part of the kit")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !slices.ContainsFunc(lends, func(l *Lend) bool { return l.AssetMasterID == id }) {
+			return nil, NewInvalidArgumentError(m + " is not part of the kit")
+		}
+		out[id] = true
+	}
+	return out, nil
+}
+
+// キット貸出登録
+func (s *Service) CreateKitLend(ctx context.Context, req CreateKitLendRequest) (*KitLendResponse, error) {
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+	if req.Quantity < 0 {
+		return nil, NewInvalidArgumentError("quantity must be > 0")
+	}
+	if req.BorrowerID == "" {
+		return nil, NewInvalidArgumentError("borrower_id is required")
+	}
+
+	kitULID, err := s.id.New()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	dueOnTime, dueOnValid, err := parseDueOnUTC(req.DueOn)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.store.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	rootID, canonical, err := s.store.ResolveMasterIDTx(ctx, tx, req.ManagementNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := inventory.ListBundleMembers(ctx, tx, uint64(rootID))
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		err = NewInvalidArgumentError(canonical + " has no bundle members; use POST /lends")
+		return nil, err
+	}
+	items := planKitLend(rootID, canonical, req.Quantity, members)
+
+	// 行ロックは asset_master_id 順に取り、キットどうしの同時貸出でデッドロックしないようにする
+	locking := slices.Clone(items)
+	slices.SortFunc(locking, func(a, b kitLendItem) int { return cmp.Compare(a.AssetMasterID, b.AssetMasterID) })
+	for _, it := range locking {
+		if _, err = s.store.LockAssetRowsByMasterID(ctx, tx, it.AssetMasterID); err != nil {
+			return nil, err
+		}
+	}
+	var short []string
+	for _, it := range items {
+		availableQty, availableErr := s.store.GetAvailableQuantityByMasterIDTx(ctx, tx, it.AssetMasterID)
+		if availableErr != nil {
+			err = availableErr
+			return nil, err
+		}
+		if it.Quantity > availableQty {
+			short = append(short, it.ManagementNumber)
+		}
+	}
+	if len(short) > 0 {
+		err = NewConflictError("lend quantity exceeds available stock: " + strings.Join(short, ", "))
+		return nil, err
+	}
+
+	resp := &KitLendResponse{KitULID: kitULID, Lends: make([]LendResponse, 0, len(items))}
+	for _, it := range items {
+		lendULID, idErr := s.id.New()
+		if idErr != nil {
+			err = idErr
+			return nil, err
+		}
+		lend := &Lend{
+			LendULID:      lendULID,
+			AssetMasterID: it.AssetMasterID,
+			Quantity:      it.Quantity,
+			BorrowerID:    req.BorrowerID,
+			LentAt:        now,
+		}
+		lend.ManagementNumber = sql.NullString{String: it.ManagementNumber, Valid: true}
+		lend.KitULID = sql.NullString{String: kitULID, Valid: true}
+		if dueOnValid {
+			lend.DueOn = sql.NullTime{Time: dueOnTime, Valid: true}
+		}
+		if req.LentByID != nil && *req.LentByID != "" {
+			lend.LentByID = sql.NullString{String: *req.LentByID, Valid: true}
+		}
+		if req.Note != nil && *req.Note != "" {
+			lend.Note = sql.NullString{String: *req.Note, Valid: true}
+		}
+
+		if err = s.applyLendTx(ctx, tx, lend); err != nil {
+			return nil, err
+		}
+		resp.Lends = append(resp.Lends, buildLendResponse(lend, 0))
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// キット返却（missing に挙げた管理番号・別名以外の未返却分をすべて返す）
+func (s *Service) CreateKitReturn(ctx context.Context, kitULID string, req CreateKitReturnRequest) (*KitReturnResponse, error) {
+	kitULID = strings.TrimSpace(kitULID)
+	if kitULID == "" {
+		return nil, NewInvalidArgumentError("kit_ulid is required")
+	}
+
+	tx, err := s.store.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	lends, err := ListKitLendsTx(ctx, tx, kitULID)
+	if err != nil {
+		return nil, err
+	}
+	if len(lends) == 0 {
+		err = NewNotFoundError("kit lend not found")
+		return nil, err
+	}
+
+	missing, err := kitMissingMasters(lends, req.Missing, func(managementNumber string) (int64, error) {
+		id, _, err := s.store.ResolveMasterIDTx(ctx, tx, managementNumber)
+		return id, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	resp := &KitReturnResponse{KitULID: kitULID, Returns: []ReturnResponse{}}
+	for _, lend := range lends {
+		if missing[lend.AssetMasterID] {
+			continue
+		}
+		totalReturned, totalErr := GetTotalReturnedQuantityTx(ctx, tx, lend.LendID)
+		if totalErr != nil {
+			err = totalErr
+			return nil, err
+		}
+		remaining := lend.Quantity - totalReturned
+		if remaining <= 0 {
+			continue
+		}
+
+		returnULID, idErr := s.id.New()
+		if idErr != nil {
+			err = idErr
+			return nil, err
+		}
+		ret := &Return{
+			ReturnULID: returnULID,
+			LendID:     lend.LendID,
+			Quantity:   remaining,
+			ReturnedAt: now,
+		}
+		if req.ProcessedByID != nil && *req.ProcessedByID != "" {
+			ret.ProcessedByID = sql.NullString{String: *req.ProcessedByID, Valid: true}
+		}
+		if req.Note != nil && *req.Note != "" {
+			ret.Note = sql.NullString{String: *req.Note, Valid: true}
+		}
+
+		if err = s.applyReturnTx(ctx, tx, lend, totalReturned, ret); err != nil {
+			return nil, err
+		}
+		r := buildReturnResponse(ret)
+		r.KitULID = &kitULID
+		resp.Returns = append(resp.Returns, r)
+	}
+	if len(resp.Returns) == 0 {
+		err = NewConflictError("nothing left to return for this kit")
+		return nil, err
+	}
+
+	resp.Outstanding, err = ListKitOutstanding(ctx, tx, kitULID)
+	if err != nil {
+		return nil, err
+	}
+	resp.Warnings = kitWarnings(resp.Outstanding)
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
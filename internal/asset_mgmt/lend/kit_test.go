@@ -0,0 +1,73 @@
+package lend
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"IRIS-backend/internal/asset_mgmt/inventory"
+)
+
+func TestPlanKitLend(t *testing.T) {
+	members := []inventory.BundleMember{
+		{AssetMasterID: 11, ManagementNumber: "PRJ-0001-CASE", ParentMasterID: 10, Quantity: 1, Depth: 1},
+		{AssetMasterID: 12, ManagementNumber: "PRJ-0001-CBL", ParentMasterID: 10, Quantity: 2, Depth: 1},
+		{AssetMasterID: 13, ManagementNumber: "PRJ-0001-R-BAT", ParentMasterID: 11, Quantity: 2, Depth: 2},
+	}
+
+	got := planKitLend(10, "PRJ-0001", 3, members)
+	want := []kitLendItem{
+		{AssetMasterID: 10, ManagementNumber: "PRJ-0001", Quantity: 3},
+		{AssetMasterID: 11, ManagementNumber: "PRJ-0001-CASE", Quantity: 3},
+		{AssetMasterID: 12, ManagementNumber: "PRJ-0001-CBL", Quantity: 6},
+		{AssetMasterID: 13, ManagementNumber: "PRJ-0001-R-BAT", Quantity: 6},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d items, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("item %d: want %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestKitWarnings(t *testing.T) {
+	if w := kitWarnings(nil); w != nil {
+		t.Fatalf("expected no warning for a complete kit, got %v", w)
+	}
+
+	w := kitWarnings([]KitOutstandingItem{
+		{LendID: 2, ManagementNumber: "PRJ-0001-R", OutstandingQuantity: 1},
+		{LendID: 3, ManagementNumber: "PRJ-0001-CBL", OutstandingQuantity: 2},
+	})
+	if len(w) != 1 || !strings.Contains(w[0], "PRJ-0001-R x1, PRJ-0001-CBL x2") {
+		t.Fatalf("unexpected warnings: %v", w)
+	}
+}
+
+func TestKitMissingMastersResolvesAliases(t *testing.T) {
+	lends := []*Lend{{LendID: 1, AssetMasterID: 10}, {LendID: 2, AssetMasterID: 12}}
+	ids := map[string]int64{"PRJ-0001": 10, "PRJ-0001-CBL": 12, "OLD-CBL": 12, "PC-0002": 20}
+	resolve := func(mng string) (int64, error) {
+		id, ok := ids[mng]
+		if !ok {
+			return 0, NewNotFoundError("asset master not found for given management_number")
+		}
+		return id, nil
+	}
+
+	// 別名で挙げても正式な番号の貸出に当たる
+	got, err := kitMissingMasters(lends, []string{" OLD-CBL ", "", "PRJ-0001-CBL"}, resolve)
+	if err != nil || len(got) != 1 || !got[12] {
+		t.Fatalf("unexpected missing set %v, %v", got, err)
+	}
+
+	for _, m := range []string{"PC-0002", "NOPE"} {
+		_, err := kitMissingMasters(lends, []string{m}, resolve)
+		var de *DomainError
+		if !errors.As(err, &de) || de.Code != ErrCodeInvalidArgument || !strings.Contains(de.Message, m+" is not part of the kit") {
+			t.Fatalf("%s: expected invalid argument, got %v", m, err)
+		}
+	}
+}
@@ -18,6 +18,7 @@ type Lend struct {
 	LentAt           time.Time
 	Note             sql.NullString
 	Returned         bool
+	KitULID          sql.NullString // キット貸出でまとめて作った貸出に共通
 }
 
 // Return は returns テーブルの1行を表す
@@ -37,6 +38,7 @@ type LendFilter struct {
 	AssetMasterID    *int64
 	ManagementNumber string
 	Returned         *bool
+	KitULID          string
 	Limit            int
 	Offset           int
 }
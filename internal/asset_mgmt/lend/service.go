@@ -96,12 +96,14 @@ func (s *Service) CreateLend(ctx context.Context, req CreateLendRequest) (*LendR
 			return nil, NewInvalidArgumentError("either asset_master_id or management_number is required")
 		}
 
-		id, resolveErr := s.store.ResolveMasterIDTx(ctx, tx, *req.ManagementNumber)
+		id, canonical, resolveErr := s.store.ResolveMasterIDTx(ctx, tx, *req.ManagementNumber)
 		if resolveErr != nil {
 			err = resolveErr
 			return nil, err
 		}
 		assetMasterID = id
+		// 別名で指定されても貸出記録は正式な管理番号で残す
+		req.ManagementNumber = &canonical
 	}
 
 	if _, lockErr := s.store.LockAssetRowsByMasterID(ctx, tx, assetMasterID); lockErr != nil {
@@ -146,18 +148,8 @@ func (s *Service) CreateLend(ctx context.Context, req CreateLendRequest) (*LendR
 		lend.Note.Valid = true
 	}
 
-	if insertErr := s.store.InsertLendTx(ctx, tx, lend); insertErr != nil {
-		err = insertErr
-		return nil, err
-	}
-
-	if updateLocationErr := s.store.UpdateAssetLocationTx(ctx, tx, assetMasterID, req.BorrowerID); updateLocationErr != nil {
-		err = updateLocationErr
-		return nil, err
-	}
-
-	if reconcileErr := s.store.ReconcileAssetStatusTx(ctx, tx, assetMasterID); reconcileErr != nil {
-		err = reconcileErr
+	if lendErr := s.applyLendTx(ctx, tx, lend); lendErr != nil {
+		err = lendErr
 		return nil, err
 	}
 
@@ -226,57 +218,66 @@ func (s *Service) CreateReturn(ctx context.Context, req CreateReturnRequest) (*R
 		ret.Note.Valid = true
 	}
 
-	err = InsertReturnTx(ctx, tx, ret)
+	err = s.applyReturnTx(ctx, tx, lend, totalReturned, ret)
 	if err != nil {
 		return nil, err
 	}
 
-	if newTotal == lend.Quantity && !lend.Returned {
-		err = UpdateLendReturnedFlagTx(ctx, tx, lend.LendID, true)
-		if err != nil {
+	resp := buildReturnResponse(ret)
+	if lend.KitULID.Valid {
+		outstanding, kitErr := ListKitOutstanding(ctx, tx, lend.KitULID.String)
+		if kitErr != nil {
+			err = kitErr
 			return nil, err
 		}
+		kitULID := lend.KitULID.String
+		resp.KitULID = &kitULID
+		resp.KitOutstanding = outstanding
+		resp.Warnings = kitWarnings(outstanding)
 	}
 
-	outstandingQty, err := inventory.GetOutstandingQuantityByMasterID(ctx, tx, lend.AssetMasterID)
+	err = tx.Commit()
 	if err != nil {
 		return nil, err
 	}
-	if outstandingQty == 0 {
-		err = s.store.ResetAssetLocationToDefaultTx(ctx, tx, lend.AssetMasterID)
-		if err != nil {
-			return nil, err
-		}
-	}
 
-	err = s.store.ReconcileAssetStatusTx(ctx, tx, lend.AssetMasterID)
-	if err != nil {
-		return nil, err
+	return &resp, nil
+}
+
+// applyLendTx は貸出を記録し、資産の設置場所と状態を合わせる（在庫はロック・確認済みであること）
+func (s *Service) applyLendTx(ctx context.Context, tx *sql.Tx, lend *Lend) error {
+	if err := s.store.InsertLendTx(ctx, tx, lend); err != nil {
+		return err
 	}
+	if err := s.store.UpdateAssetLocationTx(ctx, tx, lend.AssetMasterID, lend.BorrowerID); err != nil {
+		return err
+	}
+	return s.store.ReconcileAssetStatusTx(ctx, tx, lend.AssetMasterID)
+}
 
-	err = tx.Commit()
-	if err != nil {
-		return nil, err
+// applyReturnTx は返却を記録し、貸出の返却済みフラグ・設置場所・状態を合わせる（lend は FOR UPDATE で取得済みであること）
+func (s *Service) applyReturnTx(ctx context.Context, tx *sql.Tx, lend *Lend, totalReturned int, ret *Return) error {
+	if err := InsertReturnTx(ctx, tx, ret); err != nil {
+		return err
 	}
 
-	resp := ReturnResponse{
-		ReturnID:   ret.ReturnID,
-		ReturnULID: ret.ReturnULID,
-		LendID:     ret.LendID,
-		Quantity:   ret.Quantity,
+	if totalReturned+ret.Quantity == lend.Quantity && !lend.Returned {
+		if err := UpdateLendReturnedFlagTx(ctx, tx, lend.LendID, true); err != nil {
+			return err
+		}
 	}
 
-	if ret.ProcessedByID.Valid {
-		val := ret.ProcessedByID.String
-		resp.ProcessedByID = &val
+	outstandingQty, err := inventory.GetOutstandingQuantityByMasterID(ctx, tx, lend.AssetMasterID)
+	if err != nil {
+		return err
 	}
-	resp.ReturnedAt = ret.ReturnedAt
-	if ret.Note.Valid {
-		val := ret.Note.String
-		resp.Note = &val
+	if outstandingQty == 0 {
+		if err := s.store.ResetAssetLocationToDefaultTx(ctx, tx, lend.AssetMasterID); err != nil {
+			return err
+		}
 	}
 
-	return &resp, nil
+	return s.store.ReconcileAssetStatusTx(ctx, tx, lend.AssetMasterID)
 }
 
 // 貸出単一取得
@@ -456,6 +457,29 @@ func buildLendResponse(lend *Lend, returnedQty int) LendResponse {
 		val := lend.Note.String
 		resp.Note = &val
 	}
+	if lend.KitULID.Valid {
+		val := lend.KitULID.String
+		resp.KitULID = &val
+	}
+	return resp
+}
+
+func buildReturnResponse(ret *Return) ReturnResponse {
+	resp := ReturnResponse{
+		ReturnID:   ret.ReturnID,
+		ReturnULID: ret.ReturnULID,
+		LendID:     ret.LendID,
+		Quantity:   ret.Quantity,
+		ReturnedAt: ret.ReturnedAt,
+	}
+	if ret.ProcessedByID.Valid {
+		val := ret.ProcessedByID.String
+		resp.ProcessedByID = &val
+	}
+	if ret.Note.Valid {
+		val := ret.Note.String
+		resp.Note = &val
+	}
 	return resp
 }
 
@@ -30,8 +30,8 @@ func insertLend(ctx context.Context, q platformdb.DBTX, lend *Lend) error {
 	query := `
 	INSERT INTO lends
 	(lend_ulid, asset_master_id, management_number, quantity, borrower_id,
-	due_on, lent_by_id, lent_at, note, returned)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	due_on, lent_by_id, lent_at, note, returned, kit_ulid)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	var managementNumber interface{}
 	if lend.ManagementNumber.Valid {
@@ -62,6 +62,11 @@ func insertLend(ctx context.Context, q platformdb.DBTX, lend *Lend) error {
 		note = nil
 	}
 
+	var kitULID interface{}
+	if lend.KitULID.Valid {
+		kitULID = lend.KitULID.String
+	}
+
 	res, err := q.ExecContext(ctx, query,
 		lend.LendULID,
 		lend.AssetMasterID,
@@ -73,6 +78,7 @@ func insertLend(ctx context.Context, q platformdb.DBTX, lend *Lend) error {
 		lend.LentAt,
 		note,
 		lend.Returned,
+		kitULID,
 	)
 	if err != nil {
 		return err
@@ -108,7 +114,7 @@ func getAvailableQuantityByMasterID(ctx context.Context, q platformdb.DBTX, asse
 func (s *Store) GetLendByID(ctx context.Context, lendID int64) (*Lend, error) {
 	query := `
 	SELECT lend_id, lend_ulid, asset_master_id, management_number, quantity,
-		borrower_id, due_on, lent_by_id, lent_at, note, returned
+		borrower_id, due_on, lent_by_id, lent_at, note, returned, kit_ulid
 	FROM lends
 	WHERE lend_id = ?
 	`
@@ -128,6 +134,7 @@ func (s *Store) GetLendByID(ctx context.Context, lendID int64) (*Lend, error) {
 		&lend.LentAt,
 		&lend.Note,
 		&returnedInt,
+		&lend.KitULID,
 	)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, NewNotFoundError("lend not found")
@@ -143,7 +150,7 @@ func (s *Store) GetLendByID(ctx context.Context, lendID int64) (*Lend, error) {
 func (s *Store) GetLendByULID(ctx context.Context, lendULID string) (*Lend, error) {
 	query := `
 	SELECT lend_id, lend_ulid, asset_master_id, management_number, quantity,
-		borrower_id, due_on, lent_by_id, lent_at, note, returned
+		borrower_id, due_on, lent_by_id, lent_at, note, returned, kit_ulid
 	FROM lends
 	WHERE lend_ulid = ?
 	LIMIT 1
@@ -164,6 +171,7 @@ func (s *Store) GetLendByULID(ctx context.Context, lendULID string) (*Lend, erro
 		&lend.LentAt,
 		&lend.Note,
 		&returnedInt,
+		&lend.KitULID,
 	)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, NewNotFoundError("lend not found")
@@ -179,7 +187,7 @@ func (s *Store) GetLendByULID(ctx context.Context, lendULID string) (*Lend, erro
 func (s *Store) ListLends(ctx context.Context, filter LendFilter) ([]*Lend, error) {
 	query := `
 	SELECT lend_id, lend_ulid, asset_master_id, management_number, quantity,
-		borrower_id, due_on, lent_by_id, lent_at, note, returned
+		borrower_id, due_on, lent_by_id, lent_at, note, returned, kit_ulid
 	FROM lends
 	WHERE 1 = 1
 	`
@@ -208,6 +216,10 @@ func (s *Store) ListLends(ctx context.Context, filter LendFilter) ([]*Lend, erro
 		}
 		args = append(args, r)
 	}
+	if filter.KitULID != "" {
+		conds = append(conds, "kit_ulid = ?")
+		args = append(args, filter.KitULID)
+	}
 
 	if len(conds) > 0 {
 		query = query + " AND " + strings.Join(conds, " AND ")
@@ -243,6 +255,7 @@ func (s *Store) ListLends(ctx context.Context, filter LendFilter) ([]*Lend, erro
 			&lend.LentAt,
 			&lend.Note,
 			&returnedInt,
+			&lend.KitULID,
 		)
 		if err != nil {
 			return nil, err
@@ -525,7 +538,7 @@ func UpdateLendReturnedFlagTx(ctx context.Context, tx *sql.Tx, lendID int64, ret
 func GetLendByIDTx(ctx context.Context, tx *sql.Tx, lendID int64) (*Lend, error) {
 	query := `
 	SELECT lend_id, lend_ulid, asset_master_id, management_number, quantity,
-		borrower_id, due_on, lent_by_id, lent_at, note, returned
+		borrower_id, due_on, lent_by_id, lent_at, note, returned, kit_ulid
 	FROM lends
 	WHERE lend_id = ?
 	FOR UPDATE
@@ -545,6 +558,7 @@ func GetLendByIDTx(ctx context.Context, tx *sql.Tx, lendID int64) (*Lend, error)
 		&lend.LentAt,
 		&lend.Note,
 		&returnedInt,
+		&lend.KitULID,
 	)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, NewNotFoundError("lend not found")
@@ -556,6 +570,84 @@ func GetLendByIDTx(ctx context.Context, tx *sql.Tx, lendID int64) (*Lend, error)
 	return &lend, nil
 }
 
+// トランザクション内でキットの貸出をまとめてロックして取得（親が先頭）
+func ListKitLendsTx(ctx context.Context, tx *sql.Tx, kitULID string) ([]*Lend, error) {
+	query := `
+	SELECT lend_id, lend_ulid, asset_master_id, management_number, quantity,
+		borrower_id, due_on, lent_by_id, lent_at, note, returned, kit_ulid
+	FROM lends
+	WHERE kit_ulid = ?
+	ORDER BY lend_id
+	FOR UPDATE
+	`
+	rows, err := tx.QueryContext(ctx, query, kitULID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lends []*Lend
+	for rows.Next() {
+		var lend Lend
+		var returnedInt int
+		err := rows.Scan(
+			&lend.LendID,
+			&lend.LendULID,
+			&lend.AssetMasterID,
+			&lend.ManagementNumber,
+			&lend.Quantity,
+			&lend.BorrowerID,
+			&lend.DueOn,
+			&lend.LentByID,
+			&lend.LentAt,
+			&lend.Note,
+			&returnedInt,
+			&lend.KitULID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		lend.Returned = returnedInt != 0
+		lends = append(lends, &lend)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return lends, nil
+}
+
+// キットのうち、まだ戻っていない貸出
+func ListKitOutstanding(ctx context.Context, q platformdb.DBTX, kitULID string) ([]KitOutstandingItem, error) {
+	query := `
+	SELECT l.lend_id, l.lend_ulid, COALESCE(l.management_number, ''),
+		l.quantity - COALESCE(SUM(r.quantity), 0) AS outstanding_qty
+	FROM lends l
+	LEFT JOIN returns r ON r.lend_id = l.lend_id
+	WHERE l.kit_ulid = ?
+	GROUP BY l.lend_id, l.lend_ulid, l.management_number, l.quantity
+	HAVING outstanding_qty > 0
+	ORDER BY l.lend_id
+	`
+	rows, err := q.QueryContext(ctx, query, kitULID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []KitOutstandingItem{}
+	for rows.Next() {
+		var it KitOutstandingItem
+		if err := rows.Scan(&it.LendID, &it.LendULID, &it.ManagementNumber, &it.OutstandingQuantity); err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 // 管理番号から asset_master_id を引く
 func (s *Store) ResolveMasterID(ctx context.Context, managementNumber string) (int64, error) {
 	if managementNumber == "" {
@@ -565,11 +657,19 @@ func (s *Store) ResolveMasterID(ctx context.Context, managementNumber string) (i
 	return s.resolveMasterID(ctx, s.db, managementNumber)
 }
 
-func (s *Store) ResolveMasterIDTx(ctx context.Context, tx *sql.Tx, managementNumber string) (int64, error) {
+// ResolveMasterIDTx は別名でも引けるよう、asset_master_id と正式な管理番号を返す
+func (s *Store) ResolveMasterIDTx(ctx context.Context, tx *sql.Tx, managementNumber string) (int64, string, error) {
 	if managementNumber == "" {
-		return 0, NewInvalidArgumentError("management_number is required")
+		return 0, "", NewInvalidArgumentError("management_number is required")
+	}
+	assetMasterID, canonical, err := inventory.ResolveManagementNumber(ctx, tx, managementNumber)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, "", NewNotFoundError("asset master not found for given management_number")
+	}
+	if err != nil {
+		return 0, "", err
 	}
-	return s.resolveMasterID(ctx, tx, managementNumber)
+	return int64(assetMasterID), canonical, nil
 }
 
 func (s *Store) resolveMasterID(ctx context.Context, q platformdb.DBTX, managementNumber string) (int64, error) {
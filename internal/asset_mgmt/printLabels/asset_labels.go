@@ -0,0 +1,213 @@
+package printLabels
+
+// 管理番号・検索条件からのラベル生成
+// - 備品データ（assets_master + assets）を LabelData の col_b..col_e に割り当てる
+// - 割り当ては config の label.columns で変更でき、リクエストごとにも上書きできる
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"IRIS-backend/internal/asset_mgmt/assets"
+)
+
+// 一度に印刷できるラベル数（検索条件の指定ミスで大量に印刷しないため）
+const maxLabelsPerJob = 500
+
+// ラベル列に割り当てられる備品の項目
+const (
+	LabelFieldManagementNumber     = "management_number"
+	LabelFieldName                 = "name"
+	LabelFieldManufacturer         = "manufacturer"
+	LabelFieldModel                = "model"
+	LabelFieldSerial               = "serial"
+	LabelFieldOwner                = "owner"
+	LabelFieldDefaultLocation      = "default_location"
+	LabelFieldLocation             = "location"
+	LabelFieldPurchasedAt          = "purchased_at"
+	LabelFieldGenreID              = "genre_id"
+	LabelFieldManagementCategoryID = "management_category_id"
+	LabelFieldNotes                = "notes"
+)
+
+var labelColumnKeys = []string{"col_b", "col_c", "col_d", "col_e"}
+
+// DefaultLabelColumns 既定の割り当て（col_e はバーコードの値）
+var DefaultLabelColumns = map[string]string{
+	"col_b": LabelFieldName,
+	"col_c": LabelFieldOwner,
+	"col_d": LabelFieldManagementNumber,
+	"col_e": LabelFieldManagementNumber,
+}
+
+// assetLookup 備品データの参照先（assets.Store）
+type assetLookup interface {
+	GetAssetSetByMng(ctx context.Context, mng string) (*assets.AssetSetResponse, error)
+	SearchAssets(ctx context.Context, q assets.AssetSearchQuery) ([]assets.AssetSetResponse, error)
+}
+
+// resolveLabelColumns base（config）に override（リクエスト）を重ねて検証する
+func resolveLabelColumns(base, override map[string]string) (map[string]string, error) {
+	out := make(map[string]string, len(labelColumnKeys))
+	for k, v := range DefaultLabelColumns {
+		out[k] = v
+	}
+	for _, m := range []map[string]string{base, override} {
+		for k, v := range m {
+			if !containsKey(labelColumnKeys, k) {
+				return nil, ErrInvalid(fmt.Sprintf("unknown label column %q (must be col_b..col_e)", k))
+			}
+			v = strings.TrimSpace(v)
+			if v != "" && !isLabelField(v) {
+				return nil, ErrInvalid(fmt.Sprintf("unknown asset field %q for %s", v, k))
+			}
+			out[k] = v
+		}
+	}
+	if out["col_e"] == "" {
+		return nil, ErrInvalid("col_e must be mapped to an asset field (it is encoded as the code)")
+	}
+	return out, nil
+}
+
+func isLabelField(f string) bool {
+	_, ok := assetFieldValue(assets.AssetSetResponse{}, f)
+	return ok
+}
+
+func containsKey(keys []string, k string) bool {
+	for _, v := range keys {
+		if v == k {
+			return true
+		}
+	}
+	return false
+}
+
+// assetFieldValue 備品の項目値を文字列で返す。未知の項目なら ok=false
+func assetFieldValue(a assets.AssetSetResponse, field string) (string, bool) {
+	deref := func(p *string) string {
+		if p == nil {
+			return ""
+		}
+		return *p
+	}
+
+	switch field {
+	case LabelFieldManagementNumber:
+		return a.Master.ManagementNumber, true
+	case LabelFieldName:
+		return a.Master.Name, true
+	case LabelFieldManufacturer:
+		return a.Master.Manufacturer, true
+	case LabelFieldModel:
+		return deref(a.Master.Model), true
+	case LabelFieldSerial:
+		return deref(a.Asset.Serial), true
+	case LabelFieldOwner:
+		return a.Asset.Owner, true
+	case LabelFieldDefaultLocation:
+		return a.Asset.DefaultLocation, true
+	case LabelFieldLocation:
+		return deref(a.Asset.Location), true
+	case LabelFieldPurchasedAt:
+		if a.Asset.PurchasedAt.IsZero() {
+			return "", true
+		}
+		return a.Asset.PurchasedAt.Format("2006-01-02"), true
+	case LabelFieldGenreID:
+		return fmt.Sprint(a.Master.GenreID), true
+	case LabelFieldManagementCategoryID:
+		return fmt.Sprint(a.Master.ManagementCategoryID), true
+	case LabelFieldNotes:
+		return deref(a.Asset.Notes), true
+	default:
+		return "", false
+	}
+}
+
+func buildAssetLabel(a assets.AssetSetResponse, columns map[string]string) LabelData {
+	col := func(k string) string {
+		v, _ := assetFieldValue(a, columns[k])
+		return v
+	}
+	return LabelData{
+		Checked: true,
+		ColB:    col("col_b"),
+		ColC:    col("col_c"),
+		ColD:    col("col_d"),
+		ColE:    col("col_e"),
+	}
+}
+
+// lookupLabelAssets 管理番号（指定順・重複除去）または検索条件で備品を集める
+func (s *Service) lookupLabelAssets(ctx context.Context, mngs []string, search map[string]string) ([]assets.AssetSetResponse, error) {
+	if len(mngs) > 0 && len(search) > 0 {
+		return nil, ErrInvalid("specify either management_numbers or search, not both")
+	}
+
+	if len(mngs) > 0 {
+		if len(mngs) > maxLabelsPerJob {
+			return nil, ErrInvalid(fmt.Sprintf("too many management_numbers (max %d)", maxLabelsPerJob))
+		}
+		out := make([]assets.AssetSetResponse, 0, len(mngs))
+		seen := make(map[string]struct{}, len(mngs))
+		var missing []string
+		for _, raw := range mngs {
+			mng := strings.TrimSpace(raw)
+			if mng == "" {
+				return nil, ErrInvalid("management_numbers must not contain empty values")
+			}
+			if _, ok := seen[mng]; ok {
+				continue
+			}
+			seen[mng] = struct{}{}
+
+			a, err := s.assets.GetAssetSetByMng(ctx, mng)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					missing = append(missing, mng)
+					continue
+				}
+				return nil, ErrInternal(err.Error())
+			}
+			out = append(out, *a)
+		}
+		if len(missing) > 0 {
+			return nil, ErrInvalid("management_number not found: " + strings.Join(missing, ", "))
+		}
+		return out, nil
+	}
+
+	if len(search) == 0 {
+		return nil, ErrInvalid("management_numbers or search is required")
+	}
+	values := url.Values{}
+	for k, v := range search {
+		if !assets.IsAssetSearchQueryKey(k) {
+			return nil, ErrInvalid(fmt.Sprintf("unknown search key %q", k))
+		}
+		values.Set(k, v)
+	}
+	q, err := assets.ParseAssetSearchQuery(values)
+	if err != nil {
+		return nil, ErrInvalid(err.Error())
+	}
+	// 上限を 1 件超えたかだけ分かればよいので、それ以上は読まない
+	q.Limit = maxLabelsPerJob + 1
+	found, err := s.assets.SearchAssets(ctx, q)
+	if err != nil {
+		return nil, ErrInternal(err.Error())
+	}
+	if len(found) == 0 {
+		return nil, ErrInvalid("no assets matched the search")
+	}
+	if len(found) > maxLabelsPerJob {
+		return nil, ErrInvalid(fmt.Sprintf("search matched more than %d assets; narrow the search", maxLabelsPerJob))
+	}
+	return found, nil
+}
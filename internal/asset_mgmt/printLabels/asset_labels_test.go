@@ -0,0 +1,132 @@
+package printLabels
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"IRIS-backend/internal/asset_mgmt/assets"
+)
+
+type fakeAssetLookup struct {
+	byMng    map[string]assets.AssetSetResponse
+	found    []assets.AssetSetResponse
+	searched *assets.AssetSearchQuery
+}
+
+func (f *fakeAssetLookup) GetAssetSetByMng(ctx context.Context, mng string) (*assets.AssetSetResponse, error) {
+	a, ok := f.byMng[mng]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &a, nil
+}
+
+func (f *fakeAssetLookup) SearchAssets(ctx context.Context, q assets.AssetSearchQuery) ([]assets.AssetSetResponse, error) {
+	f.searched = &q
+	return f.found, nil
+}
+
+func assetSet(mng, name, owner string) assets.AssetSetResponse {
+	loc := "B-201"
+	return assets.AssetSetResponse{
+		Master: assets.AssetMasterResponse{ManagementNumber: mng, Name: name},
+		Asset:  assets.AssetResponse{ManagementNumber: mng, Owner: owner, DefaultLocation: "B-1", Location: &loc},
+	}
+}
+
+func TestPrintByManagementNumbersFillsColumnsFromAssets(t *testing.T) {
+	store := newFakePrintJobStore()
+	svc := newTestService(store, nil)
+	svc.assets = &fakeAssetLookup{byMng: map[string]assets.AssetSetResponse{
+		"OFS-1": assetSet("OFS-1", "Projector", "Office"),
+		"OFS-2": assetSet("OFS-2", "Switch", "NW"),
+	}}
+	svc.columns = map[string]string{"col_c": LabelFieldDefaultLocation}
+
+	res, err := svc.PrintByManagementNumbers(context.Background(), PrintByManagementNumberRequest{
+		ManagementNumbers: []string{"OFS-2", "OFS-1", "OFS-2"},
+		Columns:           map[string]string{"col_d": LabelFieldLocation},
+		Width:             12,
+		Type:              "qrcode",
+	})
+	if err != nil {
+		t.Fatalf("PrintByManagementNumbers returned error: %v", err)
+	}
+	if res.LabelCount != 2 {
+		t.Fatalf("expected 2 labels, got %d", res.LabelCount)
+	}
+
+	got := store.created[0].Labels
+	want := LabelData{Checked: true, ColB: "Switch", ColC: "B-1", ColD: "B-201", ColE: "OFS-2"}
+	if len(got) != 2 || got[0] != want || got[1].ColE != "OFS-1" {
+		t.Fatalf("unexpected labels %#v", got)
+	}
+}
+
+func TestPrintByManagementNumbersReportsMissing(t *testing.T) {
+	store := newFakePrintJobStore()
+	svc := newTestService(store, nil)
+	svc.assets = &fakeAssetLookup{byMng: map[string]assets.AssetSetResponse{
+		"OFS-1": assetSet("OFS-1", "Projector", "Office"),
+	}}
+
+	_, err := svc.PrintByManagementNumbers(context.Background(), PrintByManagementNumberRequest{
+		ManagementNumbers: []string{"OFS-1", "OFS-8", "OFS-9"},
+		Width:             12,
+		Type:              "qrcode",
+	})
+	if toHTTPStatus(err) != 400 || !strings.Contains(err.Error(), "OFS-8, OFS-9") {
+		t.Fatalf("expected missing management numbers listed, got %v", err)
+	}
+	if len(store.created) != 0 {
+		t.Fatal("expected no job queued")
+	}
+}
+
+func TestPrintByManagementNumbersUsesSearch(t *testing.T) {
+	lookup := &fakeAssetLookup{found: []assets.AssetSetResponse{assetSet("OFS-3", "Camera", "Lab")}}
+	svc := newTestService(newFakePrintJobStore(), nil)
+	svc.assets = lookup
+
+	_, err := svc.PrintByManagementNumbers(context.Background(), PrintByManagementNumberRequest{
+		Search: map[string]string{"created_from": "2026-10-18", "genre_id": "3"},
+		Width:  18,
+		Type:   "code128",
+	})
+	if err != nil {
+		t.Fatalf("PrintByManagementNumbers returned error: %v", err)
+	}
+	from := time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+	if lookup.searched == nil || lookup.searched.CreatedFrom == nil || !lookup.searched.CreatedFrom.Equal(from) {
+		t.Fatalf("expected created_from passed to search, got %#v", lookup.searched)
+	}
+	if lookup.searched.GenreID == nil || *lookup.searched.GenreID != 3 {
+		t.Fatalf("expected genre_id passed to search, got %#v", lookup.searched.GenreID)
+	}
+	if lookup.searched.Limit != maxLabelsPerJob+1 {
+		t.Fatalf("expected search limited to %d rows, got %d", maxLabelsPerJob+1, lookup.searched.Limit)
+	}
+}
+
+func TestPrintByManagementNumbersRejectsInvalidRequests(t *testing.T) {
+	svc := newTestService(newFakePrintJobStore(), nil)
+	svc.assets = &fakeAssetLookup{}
+
+	cases := map[string]PrintByManagementNumberRequest{
+		"none":        {Width: 12, Type: "qrcode"},
+		"both":        {ManagementNumbers: []string{"OFS-1"}, Search: map[string]string{"q": "x"}, Width: 12, Type: "qrcode"},
+		"search key":  {Search: map[string]string{"created_form": "2026-10-18"}, Width: 12, Type: "qrcode"},
+		"no match":    {Search: map[string]string{"q": "x"}, Width: 12, Type: "qrcode"},
+		"column":      {ManagementNumbers: []string{"OFS-1"}, Columns: map[string]string{"col_f": LabelFieldName}, Width: 12, Type: "qrcode"},
+		"field":       {ManagementNumbers: []string{"OFS-1"}, Columns: map[string]string{"col_b": "price"}, Width: 12, Type: "qrcode"},
+		"empty col_e": {ManagementNumbers: []string{"OFS-1"}, Columns: map[string]string{"col_e": ""}, Width: 12, Type: "qrcode"},
+	}
+	for name, req := range cases {
+		if _, err := svc.PrintByManagementNumbers(context.Background(), req); toHTTPStatus(err) != 400 {
+			t.Fatalf("%s: expected 400, got %v", name, err)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package printLabels
 
+import "time"
+
 // ===== Requests =====
 // テンプレートダウンロード
 // GET /api/v2/assets/print/templates?width=12&type=qrcode
@@ -14,14 +16,65 @@ type PrintRequest struct {
 	Label  LabelData   `json:"label"  binding:"required"`
 	Width  int         `json:"width"  binding:"required"`
 	Type   string      `json:"type"   binding:"required"`
+	// 使うテンプレート（未指定なら幅・種類に合う最新の登録テンプレート、無ければ組み込み）
+	TemplateID *uint64 `json:"template_id,omitempty"`
+	// 印刷先プリンタ名（未指定なら幅に合うテープを装着したオンラインのプリンタを選ぶ）
+	Printer *string `json:"printer,omitempty"`
 }
 
 // BatchPrintRequest: /print/batch
 type BatchPrintRequest struct {
-	Config PrintConfig `json:"config" binding:"required"`
-	Labels []LabelData `json:"labels" binding:"required"`
-	Width  int         `json:"width"  binding:"required"`
-	Type   string      `json:"type"   binding:"required"`
+	Config     PrintConfig `json:"config" binding:"required"`
+	Labels     []LabelData `json:"labels" binding:"required"`
+	Width      int         `json:"width"  binding:"required"`
+	Type       string      `json:"type"   binding:"required"`
+	TemplateID *uint64     `json:"template_id,omitempty"`
+	Printer    *string     `json:"printer,omitempty"`
+}
+
+// PrintByManagementNumberRequest: /assets/print/by-management-number
+// management_numbers か search（/assets/search と同じキー）のどちらかを指定する
+type PrintByManagementNumberRequest struct {
+	Config            PrintConfig       `json:"config"`
+	ManagementNumbers []string          `json:"management_numbers,omitempty"`
+	Search            map[string]string `json:"search,omitempty"`  // 例: {"created_from": "2026-10-18"}
+	Columns           map[string]string `json:"columns,omitempty"` // 例: {"col_c": "location"}（未指定はテンプレート・設定値）
+	Width             int               `json:"width"  binding:"required"`
+	Type              string            `json:"type"   binding:"required"`
+	TemplateID        *uint64           `json:"template_id,omitempty"`
+	Printer           *string           `json:"printer,omitempty"`
+}
+
+// UploadLabelTemplateRequest: POST /print/templates（multipart の file 以外の項目）
+type UploadLabelTemplateRequest struct {
+	Name         string            `form:"name"  binding:"required"`
+	Width        int               `form:"width" binding:"required"`
+	Type         string            `form:"type"  binding:"required"`
+	PrinterModel *string           `form:"printer_model"`
+	FieldMapping map[string]string `form:"-"` // field_mapping（JSON 文字列）をハンドラで解釈する
+}
+
+// CreatePrinterRequest: POST /printers
+type CreatePrinterRequest struct {
+	Name     string  `json:"name" binding:"required"` // Windows のプリンタ名（SPC10 の /pt に渡す）
+	Location *string `json:"location,omitempty"`      // 例: "3F 事務室"
+	Model    *string `json:"model,omitempty"`         // 例: "SR5900P"（テンプレートの printer_model と照合）
+}
+
+// UpdatePrinterRequest: PUT /printers/:printer_id
+// location / model は空文字で消去、is_disabled=true で自動選択・指定とも不可
+type UpdatePrinterRequest struct {
+	Location   *string `json:"location,omitempty"`
+	Model      *string `json:"model,omitempty"`
+	IsDisabled *bool   `json:"is_disabled,omitempty"`
+}
+
+// RenderRequest: /assets/print/render
+type RenderRequest struct {
+	Label  LabelData `json:"label"  binding:"required"`
+	Width  int       `json:"width"  binding:"required"`
+	Type   string    `json:"type"   binding:"required"`
+	Format string    `json:"format"` // png（既定） / pdf
 }
 
 type PrintConfig struct {
@@ -39,9 +92,53 @@ type LabelData struct {
 }
 
 // ===== Responses =====
-type PrintResponse struct {
-	Success bool      `json:"success"`
-	Error   *APIError `json:"error,omitempty"`
+// PrintJobResponse: 印刷ジョブの状態（POST /assets/print の応答 / GET /print/jobs/:id）
+type PrintJobResponse struct {
+	PrintJobID    uint64     `json:"print_job_id"`
+	Status        string     `json:"status"` // queued / printing / succeeded / failed
+	PrinterName   string     `json:"printer_name"`
+	Width         int        `json:"width"`
+	Type          string     `json:"type"`
+	LabelCount    int        `json:"label_count"`
+	ResultCode    *string    `json:"result_code,omitempty"`    // PrintResult.txt のコード（"0" が成功）
+	ResultMessage *string    `json:"result_message,omitempty"` // PrintResult.txt のメッセージ
+	PrintLog      *string    `json:"print_log,omitempty"`      // enable_print_log 指定時のみ
+	Error         *APIError  `json:"error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+}
+
+// LabelTemplateResponse: ラベルテンプレートのメタデータ
+type LabelTemplateResponse struct {
+	TemplateID   uint64            `json:"template_id,omitempty"` // 組み込みテンプレートは 0
+	Name         string            `json:"name"`
+	Width        int               `json:"width"`
+	Type         string            `json:"type"`
+	PrinterModel *string           `json:"printer_model,omitempty"`
+	FieldMapping map[string]string `json:"field_mapping,omitempty"`
+	Filename     string            `json:"filename"`
+	SizeBytes    int64             `json:"size_bytes"`
+	SHA256       string            `json:"sha256,omitempty"`
+	Builtin      bool              `json:"builtin"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// PrinterResponse: 登録プリンタと最後に /GT で確認した状態
+type PrinterResponse struct {
+	PrinterID       uint64     `json:"printer_id"`
+	Name            string     `json:"name"`
+	Location        *string    `json:"location,omitempty"`
+	Model           *string    `json:"model,omitempty"`
+	LoadedTapeWidth *int       `json:"loaded_tape_width,omitempty"` // mm
+	LoadedTapeType  *string    `json:"loaded_tape_type,omitempty"`  // 例: "0x00"（Standard）
+	Online          bool       `json:"online"`
+	LastSeenAt      *time.Time `json:"last_seen_at,omitempty"` // 最後に /GT へ応答した時刻
+	LastError       *string    `json:"last_error,omitempty"`
+	IsDisabled      bool       `json:"is_disabled"`
+	QueuedJobs      int        `json:"queued_jobs"` // 待ち・印刷中のジョブ数
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 // ===== API Specific Responses =====
@@ -77,6 +174,17 @@ type ErrorResponse struct {
 	}
 */
 
+/*
+/api/v2/assets/print/by-management-number リクエスト例（本日登録分をまとめて印刷）
+{
+	"config": {"use_halfcut": true},
+	"search": {"created_from": "2026-10-18"},
+	"columns": {"col_c": "default_location"},
+	"width": 12,
+	"type": "qrcode"
+}
+*/
+
 /*
 /api/v2/assets/print/batch リクエスト例
 {
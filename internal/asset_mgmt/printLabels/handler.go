@@ -1,9 +1,14 @@
 package printLabels
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,19 +19,28 @@ func RegisterRoutes(r gin.IRoutes, svc *Service) {
 	h := &Handler{svc: svc}
 	r.POST("/assets/print", h.PrintLabels)
 	r.POST("/assets/print/batch", h.HandlePrintBatch)
+	r.POST("/assets/print/by-management-number", h.PrintByManagementNumbers)
 	r.GET("/assets/print/templates", h.DownloadTemplate)
+	r.POST("/assets/print/render", h.RenderLabel)
+	r.GET("/print/jobs/:print_job_id", h.GetPrintJob)
+	r.GET("/print/templates", h.ListLabelTemplates)
+	r.POST("/print/templates", h.UploadLabelTemplate)
+	r.DELETE("/print/templates/:template_id", h.DeleteLabelTemplate)
+	r.GET("/printers", h.ListPrinters)
+	r.POST("/printers", h.CreatePrinter)
+	r.GET("/printers/:printer_id", h.GetPrinter)
+	r.PUT("/printers/:printer_id", h.UpdatePrinter)
 }
 
 // @Summary      Print a single label
-// @Description  Print a single label using the specified configuration and data.
+// @Description  Queue a print job for a single label. Poll GET /print/jobs/{print_job_id} for the result.
 // @Tags         print
 // @Accept       json
 // @Produce      json
 // @Param        request body PrintRequest true "Print request details"
-// @Success      201 {object} PrintResponse
+// @Success      202 {object} PrintJobResponse
 // @Failure      400 {object} ErrorResponse "Invalid input"
-// @Failure      404 {object} ErrorResponse "Template not found"
-// @Failure      409 {object} ErrorResponse "Tape size not matched"
+// @Failure      409 {object} ErrorResponse "No online printer has the tape loaded"
 // @Failure      500 {object} ErrorResponse "Internal server error"
 // @Router       /assets/print [post]
 func (h *Handler) PrintLabels(c *gin.Context) {
@@ -41,19 +55,18 @@ func (h *Handler) PrintLabels(c *gin.Context) {
 		c.JSON(toHTTPStatus(err), newErrDTO(err))
 		return
 	}
-	c.JSON(http.StatusCreated, res)
+	c.JSON(http.StatusAccepted, res)
 }
 
 // @Summary      Print multiple labels
-// @Description  Print multiple labels in a batch using the specified configuration and data.
+// @Description  Queue one print job for multiple labels. Poll GET /print/jobs/{print_job_id} for the result.
 // @Tags         print
 // @Accept       json
 // @Produce      json
 // @Param        request body BatchPrintRequest true "Batch print request details"
-// @Success      201 {object} PrintResponse
+// @Success      202 {object} PrintJobResponse
 // @Failure      400 {object} ErrorResponse "Invalid input"
-// @Failure      404 {object} ErrorResponse "Template not found"
-// @Failure      409 {object} ErrorResponse "Tape size not matched"
+// @Failure      409 {object} ErrorResponse "No online printer has the tape loaded"
 // @Failure      500 {object} ErrorResponse "Internal server error"
 // @Router       /assets/print/batch [post]
 func (h *Handler) HandlePrintBatch(c *gin.Context) {
@@ -72,7 +85,33 @@ func (h *Handler) HandlePrintBatch(c *gin.Context) {
 		c.JSON(toHTTPStatus(err), newErrDTO(err))
 		return
 	}
-	c.JSON(http.StatusCreated, res)
+	c.JSON(http.StatusAccepted, res)
+}
+
+// @Summary      Print labels from management numbers
+// @Description  Look up assets by management numbers (or by /assets/search keys), fill col_b..col_e from the configured field mapping and queue one print job.
+// @Tags         print
+// @Accept       json
+// @Produce      json
+// @Param        request body PrintByManagementNumberRequest true "Management numbers or search, and print settings"
+// @Success      202 {object} PrintJobResponse
+// @Failure      400 {object} ErrorResponse "Invalid input or management number not found"
+// @Failure      409 {object} ErrorResponse "No online printer has the tape loaded"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/print/by-management-number [post]
+func (h *Handler) PrintByManagementNumbers(c *gin.Context) {
+	var req PrintByManagementNumberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrDTO(ErrInvalid("invalid json")))
+		return
+	}
+
+	res, err := h.svc.PrintByManagementNumbers(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), newErrDTO(err))
+		return
+	}
+	c.JSON(http.StatusAccepted, res)
 }
 
 func (h *Handler) DownloadTemplate(c *gin.Context) {
@@ -91,6 +130,249 @@ func (h *Handler) DownloadTemplate(c *gin.Context) {
 	c.FileAttachment(fullpath, filename)
 }
 
+// @Summary      Render a label image
+// @Description  Render a label as PNG or PDF in-process (no SPC10.exe). col_b..col_d are drawn as text and col_e as the QR code / Code128.
+// @Tags         print
+// @Accept       json
+// @Produce      png
+// @Produce      application/pdf
+// @Param        request body RenderRequest true "Render request details"
+// @Success      200 {file} file
+// @Failure      400 {object} ErrorResponse "Invalid input"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /assets/print/render [post]
+func (h *Handler) RenderLabel(c *gin.Context) {
+	var req RenderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrDTO(ErrInvalid("invalid json")))
+		return
+	}
+
+	res, err := h.svc.RenderLabel(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), newErrDTO(err))
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, res.Filename))
+	c.Data(http.StatusOK, res.ContentType, res.Body)
+}
+
+// @Summary      Get a print job
+// @Description  Get the status (queued / printing / succeeded / failed) and result of a print job.
+// @Tags         print
+// @Produce      json
+// @Param        print_job_id path int true "Print job ID"
+// @Success      200 {object} PrintJobResponse
+// @Failure      400 {object} ErrorResponse "Invalid input"
+// @Failure      404 {object} ErrorResponse "Print job not found"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /print/jobs/{print_job_id} [get]
+func (h *Handler) GetPrintJob(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("print_job_id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, newErrDTO(ErrInvalid("invalid print_job_id")))
+		return
+	}
+
+	res, err := h.svc.GetPrintJob(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), newErrDTO(err))
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary      List label templates
+// @Description  List uploaded label templates and the built-in templates shipped with the server.
+// @Tags         print
+// @Produce      json
+// @Success      200 {array} LabelTemplateResponse
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /print/templates [get]
+func (h *Handler) ListLabelTemplates(c *gin.Context) {
+	res, err := h.svc.ListLabelTemplates(c.Request.Context())
+	if err != nil {
+		c.JSON(toHTTPStatus(err), newErrDTO(err))
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary      Upload a label template
+// @Description  Upload a .lw1 template with its tape width, barcode type, optional printer model and field mapping (JSON object of col_b..col_e to asset fields).
+// @Tags         print
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file formData file true "Template file (.lw1)"
+// @Param        name formData string true "Template name"
+// @Param        width formData int true "Tape width (mm)"
+// @Param        type formData string true "qrcode or code128"
+// @Param        printer_model formData string false "Printer model"
+// @Param        field_mapping formData string false "Field mapping JSON, e.g. {\"col_c\":\"location\"}"
+// @Success      201 {object} LabelTemplateResponse
+// @Failure      400 {object} ErrorResponse "Invalid input"
+// @Failure      409 {object} ErrorResponse "Template name already exists"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /print/templates [post]
+func (h *Handler) UploadLabelTemplate(c *gin.Context) {
+	var req UploadLabelTemplateRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrDTO(ErrInvalid("name, width and type are required")))
+		return
+	}
+	if raw := strings.TrimSpace(c.PostForm("field_mapping")); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &req.FieldMapping); err != nil {
+			c.JSON(http.StatusBadRequest, newErrDTO(ErrInvalid("field_mapping must be a JSON object")))
+			return
+		}
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, newErrDTO(ErrInvalid("file is required")))
+		return
+	}
+	if !strings.EqualFold(filepath.Ext(fh.Filename), templateExt) {
+		c.JSON(http.StatusBadRequest, newErrDTO(ErrInvalid("file must be a .lw1 template")))
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, newErrDTO(ErrInvalid("failed to read file")))
+		return
+	}
+	defer f.Close()
+
+	res, err := h.svc.UploadLabelTemplate(c.Request.Context(), req, f)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), newErrDTO(err))
+		return
+	}
+	c.JSON(http.StatusCreated, res)
+}
+
+// @Summary      Delete a label template
+// @Description  Delete an uploaded label template and its file. Built-in templates cannot be deleted.
+// @Tags         print
+// @Param        template_id path int true "Template ID"
+// @Success      204
+// @Failure      400 {object} ErrorResponse "Invalid input"
+// @Failure      404 {object} ErrorResponse "Template not found"
+// @Failure      409 {object} ErrorResponse "Template is used by queued print jobs"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /print/templates/{template_id} [delete]
+func (h *Handler) DeleteLabelTemplate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("template_id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, newErrDTO(ErrInvalid("invalid template_id")))
+		return
+	}
+
+	if err := h.svc.DeleteLabelTemplate(c.Request.Context(), id); err != nil {
+		c.JSON(toHTTPStatus(err), newErrDTO(err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary      List printers
+// @Description  List registered printers with their location, the tape last seen via /GT, online state and number of queued jobs.
+// @Tags         print
+// @Produce      json
+// @Success      200 {array} PrinterResponse
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /printers [get]
+func (h *Handler) ListPrinters(c *gin.Context) {
+	res, err := h.svc.ListPrinters(c.Request.Context())
+	if err != nil {
+		c.JSON(toHTTPStatus(err), newErrDTO(err))
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary      Register a printer
+// @Description  Register a printer by its Windows printer name. Its tape and online state are filled by the next status check or print.
+// @Tags         print
+// @Accept       json
+// @Produce      json
+// @Param        request body CreatePrinterRequest true "Printer"
+// @Success      201 {object} PrinterResponse
+// @Failure      400 {object} ErrorResponse "Invalid input"
+// @Failure      409 {object} ErrorResponse "Printer name already exists"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /printers [post]
+func (h *Handler) CreatePrinter(c *gin.Context) {
+	var req CreatePrinterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrDTO(ErrInvalid("invalid json")))
+		return
+	}
+
+	res, err := h.svc.CreatePrinter(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), newErrDTO(err))
+		return
+	}
+	c.JSON(http.StatusCreated, res)
+}
+
+// @Summary      Get a printer
+// @Tags         print
+// @Produce      json
+// @Param        printer_id path int true "Printer ID"
+// @Success      200 {object} PrinterResponse
+// @Failure      400 {object} ErrorResponse "Invalid input"
+// @Failure      404 {object} ErrorResponse "Printer not found"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /printers/{printer_id} [get]
+func (h *Handler) GetPrinter(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("printer_id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, newErrDTO(ErrInvalid("invalid printer_id")))
+		return
+	}
+
+	res, err := h.svc.GetPrinter(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), newErrDTO(err))
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary      Update a printer
+// @Description  Update location, model or is_disabled. Disabled printers are neither picked nor accepted as a print target.
+// @Tags         print
+// @Accept       json
+// @Produce      json
+// @Param        printer_id path int true "Printer ID"
+// @Param        request body UpdatePrinterRequest true "Fields to update"
+// @Success      200 {object} PrinterResponse
+// @Failure      400 {object} ErrorResponse "Invalid input"
+// @Failure      404 {object} ErrorResponse "Printer not found"
+// @Failure      500 {object} ErrorResponse "Internal server error"
+// @Router       /printers/{printer_id} [put]
+func (h *Handler) UpdatePrinter(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("printer_id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, newErrDTO(ErrInvalid("invalid printer_id")))
+		return
+	}
+	var req UpdatePrinterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrDTO(ErrInvalid("invalid json")))
+		return
+	}
+
+	res, err := h.svc.UpdatePrinter(c.Request.Context(), id, req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), newErrDTO(err))
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
 // ===== helpers =====
 type errDTO struct {
 	Error *APIError `json:"error"`
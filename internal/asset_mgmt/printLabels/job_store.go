@@ -0,0 +1,273 @@
+package printLabels
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	platformdb "IRIS-backend/internal/platform/db"
+)
+
+// Store は印刷ジョブ（print_jobs）の永続化を担う
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+const printJobColumns = `
+	print_job_id,
+	printer_name,
+	status,
+	width,
+	barcode_type,
+	label_count,
+	result_code,
+	result_message,
+	print_log,
+	error_code,
+	error_message,
+	created_at,
+	started_at,
+	finished_at`
+
+func (s *Store) CreatePrintJob(ctx context.Context, in createPrintJobInput) (*PrintJobResponse, error) {
+	const q = `
+	INSERT INTO print_jobs
+		(printer_name, status, width, barcode_type, template_path, config, labels, label_count)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	config, err := json.Marshal(in.Config)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := json.Marshal(in.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.db.ExecContext(ctx, q,
+		in.PrinterName,
+		PrintJobStatusQueued,
+		in.Width,
+		in.BarcodeType,
+		in.TemplatePath,
+		config,
+		labels,
+		in.LabelCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPrintJob(ctx, uint64(id))
+}
+
+func (s *Store) GetPrintJob(ctx context.Context, id uint64) (*PrintJobResponse, error) {
+	q := `SELECT ` + printJobColumns + ` FROM print_jobs WHERE print_job_id = ?`
+	return scanPrintJob(s.db.QueryRowContext(ctx, q, id))
+}
+
+// ListQueuedPrinters は待ちジョブがあるプリンタ名を返す
+func (s *Store) ListQueuedPrinters(ctx context.Context) ([]string, error) {
+	const q = `SELECT DISTINCT printer_name FROM print_jobs WHERE status = ?`
+
+	rows, err := s.db.QueryContext(ctx, q, PrintJobStatusQueued)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		out = append(out, name)
+	}
+	return out, rows.Err()
+}
+
+// ClaimNextPrintJob はプリンタの最も古い queued ジョブを printing にして返す。無ければ sql.ErrNoRows
+// config/labels を復元できないジョブは同じトランザクションで failed にして次のジョブへ進む
+func (s *Store) ClaimNextPrintJob(ctx context.Context, printerName string, now time.Time) (*printJob, error) {
+	const sel = `
+	SELECT print_job_id, printer_name, width, barcode_type, template_path, config, labels
+	FROM print_jobs
+	WHERE status = ? AND printer_name = ?
+	ORDER BY print_job_id
+	LIMIT 1
+	FOR UPDATE`
+	const upd = `
+	UPDATE print_jobs SET status = ?, started_at = ?
+	WHERE print_job_id = ?`
+	const fail = `
+	UPDATE print_jobs SET status = ?, error_code = ?, error_message = ?, finished_at = ?
+	WHERE print_job_id = ?`
+
+	for {
+		var (
+			job       printJob
+			discarded bool
+		)
+		err := platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+			var (
+				config, labels []byte
+				templatePath   sql.NullString
+			)
+			if err := tx.QueryRowContext(ctx, sel, PrintJobStatusQueued, printerName).Scan(
+				&job.ID,
+				&job.PrinterName,
+				&job.Width,
+				&job.BarcodeType,
+				&templatePath,
+				&config,
+				&labels,
+			); err != nil {
+				return err
+			}
+			job.TemplatePath = templatePath.String
+
+			decodeErr := json.Unmarshal(config, &job.Config)
+			if decodeErr == nil {
+				decodeErr = json.Unmarshal(labels, &job.Labels)
+			}
+			if decodeErr != nil {
+				discarded = true
+				_, err := tx.ExecContext(ctx, fail,
+					PrintJobStatusFailed,
+					string(CodeInternal),
+					fmt.Sprintf("failed to decode print job: %v", decodeErr),
+					now,
+					job.ID,
+				)
+				return err
+			}
+
+			if _, err := tx.ExecContext(ctx, upd, PrintJobStatusPrinting, now, job.ID); err != nil {
+				return err
+			}
+			job.Status = PrintJobStatusPrinting
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if discarded {
+			continue
+		}
+		return &job, nil
+	}
+}
+
+func (s *Store) FinishPrintJob(ctx context.Context, in finishPrintJobInput) error {
+	const q = `
+	UPDATE print_jobs
+	SET status = ?, result_code = ?, result_message = ?, print_log = ?, error_code = ?, error_message = ?, finished_at = ?
+	WHERE print_job_id = ?`
+
+	_, err := s.db.ExecContext(ctx, q,
+		in.Status,
+		nullIfEmpty(in.Outcome.Code),
+		nullIfEmpty(in.Outcome.Message),
+		nullIfEmpty(in.Outcome.Log),
+		nullIfEmpty(in.ErrorCode),
+		nullIfEmpty(in.ErrorMessage),
+		in.FinishedAt,
+		in.ID,
+	)
+	return err
+}
+
+// FailInterruptedPrintJobs は再起動で中断された printing ジョブを failed にする
+func (s *Store) FailInterruptedPrintJobs(ctx context.Context, message string, now time.Time) (int64, error) {
+	const q = `
+	UPDATE print_jobs
+	SET status = ?, error_code = ?, error_message = ?, finished_at = ?
+	WHERE status = ?`
+
+	res, err := s.db.ExecContext(ctx, q,
+		PrintJobStatusFailed,
+		string(CodeInternal),
+		message,
+		now,
+		PrintJobStatusPrinting,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPrintJob(row rowScanner) (*PrintJobResponse, error) {
+	var (
+		out                                 PrintJobResponse
+		resultCode, resultMessage, printLog sql.NullString
+		errorCode, errorMessage             sql.NullString
+		startedAt, finishedAt               sql.NullTime
+	)
+	if err := row.Scan(
+		&out.PrintJobID,
+		&out.PrinterName,
+		&out.Status,
+		&out.Width,
+		&out.Type,
+		&out.LabelCount,
+		&resultCode,
+		&resultMessage,
+		&printLog,
+		&errorCode,
+		&errorMessage,
+		&out.CreatedAt,
+		&startedAt,
+		&finishedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	out.ResultCode = nullStringPtr(resultCode)
+	out.ResultMessage = nullStringPtr(resultMessage)
+	out.PrintLog = nullStringPtr(printLog)
+	if errorCode.Valid {
+		out.Error = &APIError{Code: Code(errorCode.String), Message: errorMessage.String}
+	}
+	if startedAt.Valid {
+		t := startedAt.Time
+		out.StartedAt = &t
+	}
+	if finishedAt.Valid {
+		t := finishedAt.Time
+		out.FinishedAt = &t
+	}
+	return &out, nil
+}
+
+func nullStringPtr(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
+	}
+	s := v.String
+	return &s
+}
+
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func isNoRows(err error) bool { return errors.Is(err, sql.ErrNoRows) }
@@ -1,5 +1,7 @@
 package printLabels
 
+import "time"
+
 // PrintRow: 印刷1行分
 type PrintRow struct {
 	Checked bool   // 印刷対象フラグ
@@ -10,10 +12,79 @@ type PrintRow struct {
 }
 
 type PrintParams struct {
-	TemplateWidthMM     int    // 期待するテンプレ幅（テンプレート名構築用）
+	TemplatePath        string // 使うテンプレート（.lw1）の絶対パス
+	TemplateWidthMM     int    // 期待するテンプレ幅
 	BarcodeType         string // バーコードのタイプ（"type"）
 	UseHalfcut          bool   // 半切
 	ConfirmTapeWidthDlg bool   // テープ幅確認ダイアログ
 	EnablePrintLog      bool   // ログ出力
 	PrinterName         string // 明示的にプリンタ指定する場合はセット（未指定なら既定）
-}
\ No newline at end of file
+}
+// PrintOutcome: PrintResult.txt の解析結果
+type PrintOutcome struct {
+	Code    string    // "0" が成功
+	Message string    // 例: "succeed"
+	Log     string    // PrintResult.txt 全体
+	Tape    *TapeInfo // /GT で読めた装着テープ（読めなかった場合は nil）
+}
+
+// printJob: print_jobs の 1 行
+type printJob struct {
+	ID          uint64
+	PrinterName string
+	Status      string
+	Width       int
+	BarcodeType  string
+	TemplatePath string
+	Config       PrintConfig
+	Labels       []LabelData
+}
+
+const (
+	PrintJobStatusQueued    = "queued"
+	PrintJobStatusPrinting  = "printing"
+	PrintJobStatusSucceeded = "succeeded"
+	PrintJobStatusFailed    = "failed"
+)
+
+type createPrintJobInput struct {
+	PrinterName  string
+	Width        int
+	BarcodeType  string
+	TemplatePath string // 受付時に解決したテンプレート
+	Config       PrintConfig
+	Labels       []LabelData
+	LabelCount   int // 印刷対象（checked かつ空でない）行数
+}
+
+type createPrinterInput struct {
+	Name     string
+	Location *string
+	Model    *string
+}
+
+// updatePrinterInput の Location / Model は空文字で消去
+type updatePrinterInput struct {
+	Location   *string
+	Model      *string
+	IsDisabled *bool
+}
+
+// printerStateInput /GT の結果。Online=false のときテープ情報は前回値を残す
+type printerStateInput struct {
+	Name      string
+	Online    bool
+	TapeWidth *int
+	TapeType  *string
+	LastError *string
+	SeenAt    time.Time
+}
+
+type finishPrintJobInput struct {
+	ID           uint64
+	Status       string
+	Outcome      PrintOutcome
+	ErrorCode    string
+	ErrorMessage string
+	FinishedAt   time.Time
+}
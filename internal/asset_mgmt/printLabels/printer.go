@@ -0,0 +1,203 @@
+package printLabels
+
+// プリンタレジストリとルーティング
+// - printers に Windows のプリンタ名・設置場所・機種と、最後に /GT で確認した装着テープ・オンライン状態を持つ
+// - 状態は印刷ジョブの実行時と RunPrintWorker の定期確認（PrinterProbeInterval）で更新する
+// - 印刷要求で printer を省略すると、テンプレート幅のテープを装着したオンラインのプリンタを選ぶ
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PrinterProbeInterval 登録プリンタへ /GT を送って状態を確認する間隔
+const PrinterProbeInterval = time.Minute
+
+// probeFunc 1 台分の /GT（既定は SPC10.exe を使う ProbePrinter）
+type probeFunc func(ctx context.Context, workDir, printerName string) (TapeInfo, error)
+
+func (s *Service) ListPrinters(ctx context.Context) ([]PrinterResponse, error) {
+	items, err := s.store.ListPrinters(ctx)
+	if err != nil {
+		return nil, ErrInternal(err.Error())
+	}
+	return items, nil
+}
+
+func (s *Service) GetPrinter(ctx context.Context, id uint64) (*PrinterResponse, error) {
+	p, err := s.store.GetPrinter(ctx, id)
+	if err != nil {
+		return nil, mapPrinterStoreError(err)
+	}
+	return p, nil
+}
+
+// CreatePrinter プリンタを登録する。状態は次回の定期確認か最初の印刷で埋まる
+func (s *Service) CreatePrinter(ctx context.Context, input CreatePrinterRequest) (*PrinterResponse, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, ErrInvalid("name is required")
+	}
+	p, err := s.store.CreatePrinter(ctx, createPrinterInput{
+		Name:     name,
+		Location: trimmedOrNil(input.Location),
+		Model:    trimmedOrNil(input.Model),
+	})
+	if err != nil {
+		return nil, mapPrinterStoreError(err)
+	}
+	return p, nil
+}
+
+// UpdatePrinter 設置場所・機種・無効化を更新する（プリンタ名はジョブが参照するため変更不可）
+func (s *Service) UpdatePrinter(ctx context.Context, id uint64, input UpdatePrinterRequest) (*PrinterResponse, error) {
+	if input.Location == nil && input.Model == nil && input.IsDisabled == nil {
+		return nil, ErrInvalid("no fields to update")
+	}
+	p, err := s.store.UpdatePrinter(ctx, id, updatePrinterInput{
+		Location:   input.Location,
+		Model:      input.Model,
+		IsDisabled: input.IsDisabled,
+	})
+	if err != nil {
+		return nil, mapPrinterStoreError(err)
+	}
+	return p, nil
+}
+
+// selectPrinter 印刷先を決める。
+// 指定があればそのプリンタ（無効・テープ幅違いは拒否）、無ければテンプレートの幅・機種に合う
+// オンラインのプリンタのうち待ちジョブの少ないものを選ぶ。プリンタ未登録なら既定プリンタ（""）
+func (s *Service) selectPrinter(ctx context.Context, requested *string, tpl *labelTemplate) (string, error) {
+	if requested != nil && strings.TrimSpace(*requested) != "" {
+		name := strings.TrimSpace(*requested)
+		p, err := s.store.GetPrinterByName(ctx, name)
+		if err != nil {
+			if isNoRows(err) {
+				return "", ErrInvalid(fmt.Sprintf("printer %q is not registered", name))
+			}
+			return "", ErrInternal(err.Error())
+		}
+		if p.IsDisabled {
+			return "", ErrInvalid(fmt.Sprintf("printer %q is disabled", name))
+		}
+		if p.LoadedTapeWidth != nil && *p.LoadedTapeWidth != tpl.Width {
+			return "", ErrConflict(fmt.Sprintf("printer %q has %dmm tape loaded (template is %dmm)", name, *p.LoadedTapeWidth, tpl.Width))
+		}
+		return p.Name, nil
+	}
+
+	printers, err := s.store.ListPrinters(ctx)
+	if err != nil {
+		return "", ErrInternal(err.Error())
+	}
+	var candidates []PrinterResponse
+	registered := 0
+	for _, p := range printers {
+		if p.IsDisabled {
+			continue
+		}
+		registered++
+		if printerAccepts(p, tpl) {
+			candidates = append(candidates, p)
+		}
+	}
+	if registered == 0 {
+		return "", nil
+	}
+	if len(candidates) == 0 {
+		return "", ErrConflict(fmt.Sprintf("no online printer has %dmm tape loaded", tpl.Width))
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].QueuedJobs != candidates[j].QueuedJobs {
+			return candidates[i].QueuedJobs < candidates[j].QueuedJobs
+		}
+		return candidates[i].Name < candidates[j].Name
+	})
+	return candidates[0].Name, nil
+}
+
+// printerAccepts オンラインで、テンプレート幅の Standard テープを装着し、機種指定があれば一致する
+func printerAccepts(p PrinterResponse, tpl *labelTemplate) bool {
+	if !p.Online || p.LoadedTapeWidth == nil || *p.LoadedTapeWidth != tpl.Width {
+		return false
+	}
+	if p.LoadedTapeType != nil && *p.LoadedTapeType != TapeTypeStandard {
+		return false
+	}
+	if tpl.PrinterModel != nil && (p.Model == nil || !strings.EqualFold(*p.Model, *tpl.PrinterModel)) {
+		return false
+	}
+	return true
+}
+
+// recordPrinterState /GT の結果をプリンタの状態に反映する。
+// テープが読めればオンライン、プリンタが応答しなければオフライン。それ以外（SPC10 が無い等）は記録しない
+func (s *Service) recordPrinterState(ctx context.Context, name string, tape *TapeInfo, err error) {
+	if name == "" {
+		return
+	}
+	in := printerStateInput{Name: name, SeenAt: s.clock.Now()}
+	switch {
+	case tape != nil:
+		in.Online = true
+		if w, convErr := strconv.Atoi(tape.Width); convErr == nil && w > 0 {
+			in.TapeWidth = &w
+		}
+		if tape.Type != "" {
+			typ := tape.Type
+			in.TapeType = &typ
+		}
+	case errors.Is(err, ErrPrinterUnreachable):
+		msg := err.Error()
+		in.LastError = &msg
+	default:
+		return
+	}
+	// ctx がキャンセルされていても状態は残す
+	if err := s.store.UpdatePrinterState(context.WithoutCancel(ctx), in); err != nil {
+		log.Printf("[WARN] printer %q: failed to record state: %v", name, err)
+	}
+}
+
+// probePrinters 登録プリンタ（無効を除く）の状態を確認する。印刷中のプリンタは飛ばす
+func (s *Service) probePrinters(ctx context.Context) {
+	printers, err := s.store.ListPrinters(ctx)
+	if err != nil {
+		log.Printf("[WARN] printer probe: %v", err)
+		return
+	}
+	for _, p := range printers {
+		if p.IsDisabled {
+			continue
+		}
+		name := p.Name
+		s.startPrinterTask(name, func() { s.probePrinter(ctx, name) })
+	}
+}
+
+func (s *Service) probePrinter(ctx context.Context, name string) {
+	workDir, err := os.MkdirTemp("", "printer-probe-")
+	if err != nil {
+		log.Printf("[WARN] printer probe(%q): %v", name, err)
+		return
+	}
+	defer os.RemoveAll(workDir)
+
+	tape, err := s.probe(ctx, workDir, name)
+	if err != nil {
+		s.recordPrinterState(ctx, name, nil, err)
+		if !errors.Is(err, ErrSPC10NotFound) {
+			log.Printf("[WARN] printer probe(%q): %v", name, err)
+		}
+		return
+	}
+	s.recordPrinterState(ctx, name, &tape, nil)
+}
@@ -0,0 +1,170 @@
+package printLabels
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// 待ち・印刷中ジョブ数はプリンタ名で print_jobs を数える
+const printerColumns = `
+	p.printer_id,
+	p.name,
+	p.location,
+	p.model,
+	p.loaded_tape_width,
+	p.loaded_tape_type,
+	p.is_online,
+	p.last_seen_at,
+	p.last_error,
+	p.is_disabled,
+	(SELECT COUNT(*) FROM print_jobs j WHERE j.printer_name = p.name AND j.status IN (?, ?)) AS queued_jobs,
+	p.created_at,
+	p.updated_at`
+
+func (s *Store) ListPrinters(ctx context.Context) ([]PrinterResponse, error) {
+	q := `SELECT ` + printerColumns + ` FROM printers p ORDER BY p.name`
+
+	rows, err := s.db.QueryContext(ctx, q, PrintJobStatusQueued, PrintJobStatusPrinting)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]PrinterResponse, 0, 8)
+	for rows.Next() {
+		p, err := scanPrinter(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *p)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) GetPrinter(ctx context.Context, id uint64) (*PrinterResponse, error) {
+	q := `SELECT ` + printerColumns + ` FROM printers p WHERE p.printer_id = ?`
+	return scanPrinter(s.db.QueryRowContext(ctx, q, PrintJobStatusQueued, PrintJobStatusPrinting, id))
+}
+
+func (s *Store) GetPrinterByName(ctx context.Context, name string) (*PrinterResponse, error) {
+	q := `SELECT ` + printerColumns + ` FROM printers p WHERE p.name = ?`
+	return scanPrinter(s.db.QueryRowContext(ctx, q, PrintJobStatusQueued, PrintJobStatusPrinting, name))
+}
+
+func (s *Store) CreatePrinter(ctx context.Context, in createPrinterInput) (*PrinterResponse, error) {
+	const q = `INSERT INTO printers (name, location, model) VALUES (?, ?, ?)`
+
+	res, err := s.db.ExecContext(ctx, q, in.Name, in.Location, in.Model)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPrinter(ctx, uint64(id))
+}
+
+func (s *Store) UpdatePrinter(ctx context.Context, id uint64, patch updatePrinterInput) (*PrinterResponse, error) {
+	sets := make([]string, 0, 3)
+	args := make([]any, 0, 4)
+
+	if patch.Location != nil {
+		sets = append(sets, "location = ?")
+		args = append(args, nullIfEmpty(strings.TrimSpace(*patch.Location)))
+	}
+	if patch.Model != nil {
+		sets = append(sets, "model = ?")
+		args = append(args, nullIfEmpty(strings.TrimSpace(*patch.Model)))
+	}
+	if patch.IsDisabled != nil {
+		sets = append(sets, "is_disabled = ?")
+		args = append(args, *patch.IsDisabled)
+	}
+
+	if len(sets) > 0 {
+		q := `UPDATE printers SET ` + strings.Join(sets, ", ") + ` WHERE printer_id = ?`
+		if _, err := s.db.ExecContext(ctx, q, append(args, id)...); err != nil {
+			return nil, err
+		}
+	}
+	// 変更なしの UPDATE は RowsAffected が 0 になるため存在確認は再取得で行う
+	return s.GetPrinter(ctx, id)
+}
+
+// UpdatePrinterState は /GT の結果を記録する。未登録のプリンタ名なら何もしない
+func (s *Store) UpdatePrinterState(ctx context.Context, in printerStateInput) error {
+	if !in.Online {
+		const q = `UPDATE printers SET is_online = FALSE, last_error = ? WHERE name = ?`
+		_, err := s.db.ExecContext(ctx, q, in.LastError, in.Name)
+		return err
+	}
+
+	const q = `
+	UPDATE printers
+	SET is_online = TRUE,
+		loaded_tape_width = ?,
+		loaded_tape_type = ?,
+		last_seen_at = ?,
+		last_error = ?
+	WHERE name = ?`
+	_, err := s.db.ExecContext(ctx, q, in.TapeWidth, in.TapeType, in.SeenAt, in.LastError, in.Name)
+	return err
+}
+
+func scanPrinter(row rowScanner) (*PrinterResponse, error) {
+	var (
+		out        PrinterResponse
+		location   sql.NullString
+		model      sql.NullString
+		tapeWidth  sql.NullInt64
+		tapeType   sql.NullString
+		lastSeenAt sql.NullTime
+		lastError  sql.NullString
+	)
+	if err := row.Scan(
+		&out.PrinterID,
+		&out.Name,
+		&location,
+		&model,
+		&tapeWidth,
+		&tapeType,
+		&out.Online,
+		&lastSeenAt,
+		&lastError,
+		&out.IsDisabled,
+		&out.QueuedJobs,
+		&out.CreatedAt,
+		&out.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	out.Location = nullStringPtr(location)
+	out.Model = nullStringPtr(model)
+	if tapeWidth.Valid {
+		w := int(tapeWidth.Int64)
+		out.LoadedTapeWidth = &w
+	}
+	out.LoadedTapeType = nullStringPtr(tapeType)
+	if lastSeenAt.Valid {
+		t := lastSeenAt.Time
+		out.LastSeenAt = &t
+	}
+	out.LastError = nullStringPtr(lastError)
+	return &out, nil
+}
+
+func mapPrinterStoreError(err error) error {
+	if isNoRows(err) {
+		return ErrNotFound("printer not found")
+	}
+	var me *mysql.MySQLError
+	if errors.As(err, &me) && me.Number == 1062 {
+		return ErrConflict("printer name already exists")
+	}
+	return ErrInternal(err.Error())
+}
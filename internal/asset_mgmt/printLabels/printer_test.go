@@ -0,0 +1,145 @@
+package printLabels
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func printer(id uint64, name string, online bool, width int) PrinterResponse {
+	typ := TapeTypeStandard
+	return PrinterResponse{PrinterID: id, Name: name, Online: online, LoadedTapeWidth: &width, LoadedTapeType: &typ}
+}
+
+func TestPrintLabelsPicksPrinterWithMatchingTape(t *testing.T) {
+	store := newFakePrintJobStore()
+	busy := printer(2, "TEPRA-2F", true, 12)
+	busy.QueuedJobs = 3
+	disabled := printer(5, "TEPRA-5F", true, 12)
+	disabled.IsDisabled = true
+	store.printers = []PrinterResponse{
+		printer(1, "TEPRA-1F", true, 9),
+		busy,
+		printer(3, "TEPRA-3F", false, 12),
+		printer(4, "TEPRA-4F", true, 12),
+		disabled,
+	}
+	svc := newTestService(store, nil)
+
+	if _, err := svc.PrintLabels(context.Background(), PrintRequest{Label: printableLabel("OFS-1"), Width: 12, Type: BarcodeTypeQRCode}); err != nil {
+		t.Fatalf("PrintLabels returned error: %v", err)
+	}
+	if got := store.created[0].PrinterName; got != "TEPRA-4F" {
+		t.Fatalf("expected idle online 12mm printer, got %q", got)
+	}
+
+	// 18mm を装着したプリンタが無ければ 409
+	_, err := svc.PrintLabels(context.Background(), PrintRequest{Label: printableLabel("OFS-2"), Width: 18, Type: BarcodeTypeQRCode})
+	if toHTTPStatus(err) != 409 {
+		t.Fatalf("expected 409 when no printer has the tape, got %v", err)
+	}
+}
+
+func TestPrintLabelsUsesDefaultPrinterWhenNoneRegistered(t *testing.T) {
+	store := newFakePrintJobStore()
+	svc := newTestService(store, nil)
+
+	if _, err := svc.PrintLabels(context.Background(), PrintRequest{Label: printableLabel("OFS-1"), Width: 12, Type: BarcodeTypeQRCode}); err != nil {
+		t.Fatalf("PrintLabels returned error: %v", err)
+	}
+	if got := store.created[0].PrinterName; got != "" {
+		t.Fatalf("expected default printer, got %q", got)
+	}
+}
+
+func TestSelectPrinterHonoursTemplatePrinterModel(t *testing.T) {
+	store := newFakePrintJobStore()
+	sr := printer(1, "TEPRA-A", true, 12)
+	model := "SR5900P"
+	sr.Model = &model
+	store.printers = []PrinterResponse{printer(2, "TEPRA-B", true, 12), sr}
+	svc := newTestService(store, nil)
+
+	want := "sr5900p"
+	name, err := svc.selectPrinter(context.Background(), nil, &labelTemplate{LabelTemplateResponse: LabelTemplateResponse{Width: 12, PrinterModel: &want}})
+	if err != nil || name != "TEPRA-A" {
+		t.Fatalf("expected printer of the template's model, got %q, %v", name, err)
+	}
+}
+
+func TestPrintLabelsWithRequestedPrinter(t *testing.T) {
+	store := newFakePrintJobStore()
+	disabled := printer(2, "TEPRA-OFF", true, 12)
+	disabled.IsDisabled = true
+	unknownTape := PrinterResponse{PrinterID: 3, Name: "TEPRA-NEW"}
+	store.printers = []PrinterResponse{printer(1, "TEPRA-9", false, 9), disabled, unknownTape}
+	svc := newTestService(store, nil)
+
+	cases := map[string]int{
+		"TEPRA-X":   400, // 未登録
+		"TEPRA-OFF": 400, // 無効
+		"TEPRA-9":   409, // テープ幅違い
+	}
+	for name, want := range cases {
+		req := PrintRequest{Label: printableLabel("OFS-1"), Width: 12, Type: BarcodeTypeQRCode, Printer: &name}
+		if _, err := svc.PrintLabels(context.Background(), req); toHTTPStatus(err) != want {
+			t.Fatalf("%s: expected %d, got %v", name, want, err)
+		}
+	}
+
+	// 状態未確認のプリンタは指定すれば積める
+	name := " TEPRA-NEW "
+	if _, err := svc.PrintLabels(context.Background(), PrintRequest{Label: printableLabel("OFS-1"), Width: 12, Type: BarcodeTypeQRCode, Printer: &name}); err != nil {
+		t.Fatalf("PrintLabels returned error: %v", err)
+	}
+	if got := store.created[0].PrinterName; got != "TEPRA-NEW" {
+		t.Fatalf("expected requested printer, got %q", got)
+	}
+}
+
+func TestRunPrintWorkerRecordsPrinterState(t *testing.T) {
+	store := newFakePrintJobStore()
+	store.printers = []PrinterResponse{{PrinterID: 1, Name: "TEPRA-1F"}, {PrinterID: 2, Name: "TEPRA-2F"}}
+	svc := newTestService(store, nil)
+	svc.probe = func(ctx context.Context, workDir, name string) (TapeInfo, error) {
+		if name == "TEPRA-2F" {
+			return TapeInfo{}, fmt.Errorf("%w: %s", ErrPrinterUnreachable, ErrorMessageGetTapeWidth)
+		}
+		return TapeInfo{Width: "18", Type: TapeTypeStandard}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	svc.RunPrintWorker(ctx, time.Hour)
+
+	states := map[string]printerStateInput{}
+	for _, st := range store.recordedStates() {
+		states[st.Name] = st
+	}
+	online := states["TEPRA-1F"]
+	if !online.Online || online.TapeWidth == nil || *online.TapeWidth != 18 || *online.TapeType != TapeTypeStandard {
+		t.Fatalf("expected TEPRA-1F online with 18mm tape, got %#v", online)
+	}
+	offline, ok := states["TEPRA-2F"]
+	if !ok || offline.Online || offline.LastError == nil {
+		t.Fatalf("expected TEPRA-2F offline with error, got %#v", offline)
+	}
+}
+
+func TestProcessPrintJobRecordsTapeSeenDuringPrint(t *testing.T) {
+	store := newFakePrintJobStore()
+	svc := newTestService(store, func(ctx context.Context, workDir string, rows []PrintRow, p PrintParams) (PrintOutcome, error) {
+		return PrintOutcome{Tape: &TapeInfo{Width: "9", Type: TapeTypeStandard}}, fmt.Errorf("%w: 12mm / 9mm", ErrTapeSizeNotMatched)
+	})
+
+	svc.processPrintJob(context.Background(), &printJob{ID: 1, PrinterName: "TEPRA-1F", Width: 12, BarcodeType: BarcodeTypeQRCode, TemplatePath: "x.lw1", Labels: []LabelData{printableLabel("OFS-1")}})
+
+	states := store.recordedStates()
+	if len(states) != 1 || !states[0].Online || *states[0].TapeWidth != 9 {
+		t.Fatalf("expected loaded tape recorded, got %#v", states)
+	}
+	if fin, _ := store.finishedJob(1); fin.ErrorCode != string(CodeConflict) {
+		t.Fatalf("expected tape mismatch recorded as conflict, got %#v", fin)
+	}
+}
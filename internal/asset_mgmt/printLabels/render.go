@@ -0,0 +1,313 @@
+package printLabels
+
+// ラベル描画（SPC10.exe を使わないプラットフォーム非依存の経路）
+// - LabelData の col_b..col_d を文字列行、col_e をコード値として描画する
+// - QR / Code128 はプロセス内で生成し PNG を作る。PDF は同じ PNG をテープ実寸で貼り付ける
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	RenderFormatPNG = "png"
+	RenderFormatPDF = "pdf"
+
+	// 描画解像度（TEPRA の印字解像度に合わせる）
+	renderDPI = 360
+	mmPerInch = 25.4
+
+	// Code128 の 1 モジュールあたりの px
+	code128ModulePx = 3
+)
+
+// 描画できるテープ幅(mm)。.lw1 テンプレートを使わないためテンプレートレジストリではなく描画側の対応幅で検証する
+var renderTapeWidths = []int{6, 9, 12, 18, 24, 36}
+
+func validateRenderRequest(width int, barcodeType string) error {
+	supported := false
+	for _, w := range renderTapeWidths {
+		if w == width {
+			supported = true
+		}
+	}
+	if !supported {
+		return ErrInvalid(fmt.Sprintf("unsupported width (must be one of %v)", renderTapeWidths))
+	}
+	if !isSupportedBarcodeType(barcodeType) {
+		return ErrInvalid("unsupported type")
+	}
+	return nil
+}
+
+// RenderedLabel 描画済みラベル
+type RenderedLabel struct {
+	ContentType string
+	Filename    string
+	Body        []byte
+}
+
+// labelLayout テープ幅から決まる描画寸法(px)
+type labelLayout struct {
+	heightPx int
+	marginPx int
+	gapPx    int
+}
+
+func newLabelLayout(widthMM int) labelLayout {
+	h := mmToPx(float64(widthMM))
+	m := h / 10
+	return labelLayout{heightPx: h, marginPx: m, gapPx: m}
+}
+
+func (l labelLayout) innerPx() int { return l.heightPx - 2*l.marginPx }
+
+func mmToPx(mm float64) int { return int(math.Round(mm / mmPerInch * renderDPI)) }
+
+func pxToMM(px int) float64 { return float64(px) / renderDPI * mmPerInch }
+
+// loadLabelFont フォントファイルを読む。未指定なら同梱の Go フォント（欧文のみ）を使う
+func loadLabelFont(path string) (*opentype.Font, error) {
+	if path == "" {
+		return opentype.Parse(goregular.TTF)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read label font %s: %w", path, err)
+	}
+	if f, err := opentype.Parse(raw); err == nil {
+		return f, nil
+	}
+	// .ttc（NotoSansCJK 等）は先頭のフォントを使う
+	col, err := opentype.ParseCollection(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse label font %s: %w", path, err)
+	}
+	return col.Font(0)
+}
+
+// ensureGlyphs フォントに無い文字があれば 400 にする（同梱の Go フォントでは日本語が豆腐になるため）
+func ensureGlyphs(ft *opentype.Font, lines []string) error {
+	var buf sfnt.Buffer
+	for _, line := range lines {
+		for _, r := range line {
+			if unicode.IsSpace(r) {
+				continue
+			}
+			idx, err := ft.GlyphIndex(&buf, r)
+			if err != nil {
+				return ErrInternal(err.Error())
+			}
+			if idx == 0 {
+				return ErrInvalid(fmt.Sprintf("label font has no glyph for %q; set label.font_path to a font that covers it", r))
+			}
+		}
+	}
+	return nil
+}
+
+func labelTextLines(l LabelData) []string {
+	lines := make([]string, 0, 3)
+	for _, s := range []string{l.ColB, l.ColC, l.ColD} {
+		if s = strings.TrimSpace(s); s != "" {
+			lines = append(lines, s)
+		}
+	}
+	return lines
+}
+
+// encodeLabelCode col_e を QR / Code128 にする
+func encodeLabelCode(value, barcodeType string) (barcode.Barcode, error) {
+	switch barcodeType {
+	case BarcodeTypeQRCode:
+		return qr.Encode(value, qr.M, qr.Auto)
+	case BarcodeTypeCode128:
+		return code128.Encode(value)
+	default:
+		return nil, ErrInvalid("unsupported type")
+	}
+}
+
+// renderLabelImage ラベル 1 枚分を描画する（横長、高さ = テープ幅）
+func renderLabelImage(ft *opentype.Font, label LabelData, width int, barcodeType string) (*image.Gray, error) {
+	if err := validateRenderRequest(width, barcodeType); err != nil {
+		return nil, err
+	}
+	value := strings.TrimSpace(label.ColE)
+	if value == "" {
+		return nil, ErrInvalid("col_e is required for the code")
+	}
+	code, err := encodeLabelCode(value, barcodeType)
+	if err != nil {
+		return nil, ErrInvalid(fmt.Sprintf("cannot encode col_e as %s: %v", barcodeType, err))
+	}
+
+	lo := newLabelLayout(width)
+	lines := labelTextLines(label)
+	if err := ensureGlyphs(ft, lines); err != nil {
+		return nil, err
+	}
+
+	if barcodeType == BarcodeTypeQRCode {
+		return renderQRLabel(ft, lo, code, lines)
+	}
+	return renderCode128Label(ft, lo, code, lines)
+}
+
+// renderQRLabel 左に QR、右に文字列行
+func renderQRLabel(ft *opentype.Font, lo labelLayout, code barcode.Barcode, lines []string) (*image.Gray, error) {
+	side := lo.innerPx()
+	scaled, err := barcode.Scale(code, side, side)
+	if err != nil {
+		return nil, ErrInvalid(fmt.Sprintf("col_e is too long for %.0fmm tape", pxToMM(lo.heightPx)))
+	}
+
+	tb, err := newTextBlock(ft, lines, side)
+	if err != nil {
+		return nil, err
+	}
+	length := lo.marginPx + side + lo.marginPx
+	if tb != nil {
+		length += lo.gapPx + tb.widthPx
+	}
+
+	img := newWhiteImage(length, lo.heightPx)
+	draw.Draw(img, image.Rect(lo.marginPx, lo.marginPx, lo.marginPx+side, lo.marginPx+side), scaled, image.Point{}, draw.Src)
+	if tb != nil {
+		tb.draw(img, lo.marginPx+side+lo.gapPx, lo.marginPx)
+	}
+	return img, nil
+}
+
+// renderCode128Label 上に文字列行、下に Code128
+func renderCode128Label(ft *opentype.Font, lo labelLayout, code barcode.Barcode, lines []string) (*image.Gray, error) {
+	inner := lo.innerPx()
+	barH := inner
+	textH := 0
+	if len(lines) > 0 {
+		barH = inner * 55 / 100
+		textH = inner - barH - lo.gapPx/2
+	}
+	barW := code.Bounds().Dx() * code128ModulePx
+	scaled, err := barcode.Scale(code, barW, barH)
+	if err != nil {
+		return nil, ErrInternal(err.Error())
+	}
+
+	tb, err := newTextBlock(ft, lines, textH)
+	if err != nil {
+		return nil, err
+	}
+	contentW := barW
+	if tb != nil && tb.widthPx > contentW {
+		contentW = tb.widthPx
+	}
+
+	img := newWhiteImage(lo.marginPx*2+contentW, lo.heightPx)
+	if tb != nil {
+		tb.draw(img, lo.marginPx, lo.marginPx)
+	}
+	barTop := lo.heightPx - lo.marginPx - barH
+	draw.Draw(img, image.Rect(lo.marginPx, barTop, lo.marginPx+barW, barTop+barH), scaled, image.Point{}, draw.Src)
+	return img, nil
+}
+
+func newWhiteImage(w, h int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	return img
+}
+
+// textBlock 高さ heightPx に収まるよう行数で割ったサイズで組んだ文字列
+type textBlock struct {
+	face    font.Face
+	lines   []string
+	lineH   int
+	widthPx int
+}
+
+func newTextBlock(ft *opentype.Font, lines []string, heightPx int) (*textBlock, error) {
+	if len(lines) == 0 || heightPx <= 0 {
+		return nil, nil
+	}
+	lineH := heightPx / len(lines)
+	face, err := opentype.NewFace(ft, &opentype.FaceOptions{
+		Size:    float64(lineH) * 0.8,
+		DPI:     72, // Size をそのまま px として扱う
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, ErrInternal(err.Error())
+	}
+
+	tb := &textBlock{face: face, lines: lines, lineH: lineH}
+	for _, s := range lines {
+		if w := font.MeasureString(face, s).Ceil(); w > tb.widthPx {
+			tb.widthPx = w
+		}
+	}
+	return tb, nil
+}
+
+func (tb *textBlock) draw(dst draw.Image, x, y int) {
+	d := &font.Drawer{Dst: dst, Src: image.NewUniform(color.Black), Face: tb.face}
+	m := tb.face.Metrics()
+	pad := (fixed.I(tb.lineH) - m.Height) / 2
+	for i, s := range tb.lines {
+		d.Dot = fixed.Point26_6{
+			X: fixed.I(x),
+			Y: fixed.I(y+i*tb.lineH) + pad + m.Ascent,
+		}
+		d.DrawString(s)
+	}
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeLabelPDF PNG をテープ実寸(mm)のページ 1 枚に貼る
+func encodeLabelPDF(pngBytes []byte, bounds image.Rectangle) ([]byte, error) {
+	w := pxToMM(bounds.Dx())
+	h := pxToMM(bounds.Dy())
+
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		OrientationStr: "P",
+		UnitStr:        "mm",
+		Size:           fpdf.SizeType{Wd: w, Ht: h},
+	})
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+	opt := fpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader("label", opt, bytes.NewReader(pngBytes))
+	pdf.ImageOptions("label", 0, 0, w, h, false, opt, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
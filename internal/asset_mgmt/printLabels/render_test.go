@@ -0,0 +1,90 @@
+package printLabels
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/png"
+	"testing"
+)
+
+func testLabel() LabelData {
+	return LabelData{
+		Checked: true,
+		ColB:    "Projector",
+		ColC:    "Office",
+		ColD:    "OFS-20250811-0001",
+		ColE:    "OFS-20250811-0001",
+	}
+}
+
+func TestRenderLabelPNGHeightMatchesTapeWidth(t *testing.T) {
+	svc := NewService(nil, Config{})
+	for _, tc := range []struct {
+		width int
+		typ   string
+	}{
+		{9, "qrcode"}, {12, "qrcode"}, {18, "qrcode"},
+		{9, "code128"}, {12, "code128"}, {18, "code128"},
+	} {
+		res, err := svc.RenderLabel(context.Background(), RenderRequest{Label: testLabel(), Width: tc.width, Type: tc.typ})
+		if err != nil {
+			t.Fatalf("%dmm %s: RenderLabel returned error: %v", tc.width, tc.typ, err)
+		}
+		if res.ContentType != "image/png" {
+			t.Fatalf("expected image/png, got %s", res.ContentType)
+		}
+		img, err := png.Decode(bytes.NewReader(res.Body))
+		if err != nil {
+			t.Fatalf("%dmm %s: invalid png: %v", tc.width, tc.typ, err)
+		}
+		if got, want := img.Bounds().Dy(), mmToPx(float64(tc.width)); got != want {
+			t.Fatalf("%dmm %s: expected height %dpx, got %d", tc.width, tc.typ, want, got)
+		}
+		if img.Bounds().Dx() <= img.Bounds().Dy() {
+			t.Fatalf("%dmm %s: expected landscape label, got %v", tc.width, tc.typ, img.Bounds())
+		}
+	}
+}
+
+func TestRenderLabelPDF(t *testing.T) {
+	res, err := NewService(nil, Config{}).RenderLabel(context.Background(), RenderRequest{
+		Label: testLabel(), Width: 12, Type: "qrcode", Format: RenderFormatPDF,
+	})
+	if err != nil {
+		t.Fatalf("RenderLabel returned error: %v", err)
+	}
+	if res.ContentType != "application/pdf" || !bytes.HasPrefix(res.Body, []byte("%PDF-")) {
+		t.Fatalf("expected pdf body, got %s %q", res.ContentType, res.Body[:8])
+	}
+	if res.Filename != "label_12_qrcode.pdf" {
+		t.Fatalf("unexpected filename %s", res.Filename)
+	}
+}
+
+func TestRenderLabelRejectsInvalidInput(t *testing.T) {
+	svc := NewService(nil, Config{})
+	cases := map[string]RenderRequest{
+		"width":  {Label: testLabel(), Width: 10, Type: "qrcode"},
+		"type":   {Label: testLabel(), Width: 12, Type: "ean13"},
+		"format": {Label: testLabel(), Width: 12, Type: "qrcode", Format: "svg"},
+		"col_e":  {Label: LabelData{Checked: true, ColB: "x"}, Width: 12, Type: "code128"},
+		"glyphs": {Label: LabelData{Checked: true, ColB: "プロジェクタ", ColE: "OFS-1"}, Width: 12, Type: "qrcode"},
+	}
+	for name, req := range cases {
+		_, err := svc.RenderLabel(context.Background(), req)
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.Code != CodeInvalidArgument {
+			t.Fatalf("%s: expected invalid argument, got %v", name, err)
+		}
+	}
+}
+
+func TestRenderLabelFailsWhenFontMissing(t *testing.T) {
+	_, err := NewService(nil, Config{FontPath: "/nonexistent/font.ttf"}).RenderLabel(context.Background(), RenderRequest{
+		Label: testLabel(), Width: 12, Type: "qrcode",
+	})
+	if toHTTPStatus(err) != 500 {
+		t.Fatalf("expected internal error, got %v", err)
+	}
+}
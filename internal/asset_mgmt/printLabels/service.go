@@ -2,111 +2,350 @@ package printLabels
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
+	"sync"
+	"time"
+
+	"IRIS-backend/internal/asset_mgmt/assets"
+
+	"golang.org/x/image/font/opentype"
 )
 
+type Clock interface{ Now() time.Time }
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now().UTC() }
+
+type printJobStore interface {
+	CreatePrintJob(ctx context.Context, in createPrintJobInput) (*PrintJobResponse, error)
+	GetPrintJob(ctx context.Context, id uint64) (*PrintJobResponse, error)
+	ListQueuedPrinters(ctx context.Context) ([]string, error)
+	ClaimNextPrintJob(ctx context.Context, printerName string, now time.Time) (*printJob, error)
+	FinishPrintJob(ctx context.Context, in finishPrintJobInput) error
+	FailInterruptedPrintJobs(ctx context.Context, message string, now time.Time) (int64, error)
+
+	ListLabelTemplates(ctx context.Context) ([]LabelTemplateResponse, error)
+	GetLabelTemplate(ctx context.Context, id uint64) (*LabelTemplateResponse, error)
+	FindLabelTemplate(ctx context.Context, width int, barcodeType string) (*LabelTemplateResponse, error)
+	CreateLabelTemplate(ctx context.Context, in createLabelTemplateInput) (*LabelTemplateResponse, error)
+	DeleteLabelTemplate(ctx context.Context, id uint64, path string) error
+
+	ListPrinters(ctx context.Context) ([]PrinterResponse, error)
+	GetPrinter(ctx context.Context, id uint64) (*PrinterResponse, error)
+	GetPrinterByName(ctx context.Context, name string) (*PrinterResponse, error)
+	CreatePrinter(ctx context.Context, in createPrinterInput) (*PrinterResponse, error)
+	UpdatePrinter(ctx context.Context, id uint64, patch updatePrinterInput) (*PrinterResponse, error)
+	UpdatePrinterState(ctx context.Context, in printerStateInput) error
+}
+
+// printFunc 1 ジョブ分の印刷（既定は SPC10.exe を使う PrintLabels）
+type printFunc func(ctx context.Context, workDir string, rows []PrintRow, p PrintParams) (PrintOutcome, error)
+
 type Service struct {
+	store printJobStore
+	print printFunc
+	probe probeFunc
+	clock Clock
+
+	mu            sync.Mutex
+	running       map[string]struct{} // ワーカー・状態確認が稼働中のプリンタ
+	workers       sync.WaitGroup
+	probeInterval time.Duration
+
+	assets  assetLookup
+	columns map[string]string // config の列割り当て
+
+	templateDir        string // アップロードされたテンプレートの保存先
+	builtinTemplateDir string // ソースツリーの組み込みテンプレート
+
+	fontPath string // ラベル描画用フォント（空なら欧文のみの同梱フォント）
+
+	fontOnce sync.Once
+	font     *opentype.Font
+	fontErr  error
 }
 
-func NewService() *Service { return &Service{} }
+// Config ラベル印刷・描画の設定（config.yaml の label）
+type Config struct {
+	FontPath string            // ラベル描画用フォント
+	Columns  map[string]string // col_b..col_e への備品項目の割り当て（未指定は DefaultLabelColumns）
+	// アップロードされたテンプレートの保存先（ソースツリーの外。未設定ならアップロードできない）
+	TemplateDir string
+}
 
-func (s *Service) ResolveTemplatePath(ctx context.Context, width int, barcodeType string) (string, string, error) {
-	baseDir, err := os.Getwd()
-	if err != nil {
-		return "", "", ErrInternal("failed to resolve working directory")
+func NewService(db *sql.DB, cfg Config) *Service {
+	svc := newServiceWithStore(NewStore(db), PrintLabels)
+	svc.assets = assets.NewStore(db)
+	svc.fontPath = cfg.FontPath
+	svc.columns = cfg.Columns
+	svc.templateDir = cfg.TemplateDir
+	return svc
+}
+
+func newServiceWithStore(store printJobStore, print printFunc) *Service {
+	builtinDir, _ := builtinTemplateRoot()
+	return &Service{
+		store:   store,
+		print:   print,
+		probe:   ProbePrinter,
+		clock:   realClock{},
+		running: map[string]struct{}{},
+
+		probeInterval: PrinterProbeInterval,
+
+		builtinTemplateDir: builtinDir,
 	}
+}
 
-	err = validateTemplateRequest(width, barcodeType)
+// ResolveTemplatePath 幅・種類に合うテンプレートのパスとファイル名を返す
+func (s *Service) ResolveTemplatePath(ctx context.Context, width int, barcodeType string) (string, string, error) {
+	t, err := s.lookupTemplate(ctx, width, barcodeType, nil)
 	if err != nil {
-		return "", "", ErrInvalid("invalid tape width or code type")
+		return "", "", err
 	}
 
-	tplDir := filepath.Join(baseDir, "internal", "asset_mgmt", "printLabels", "templates")
-	filename := fmt.Sprintf("%d_%s.lw1", width, barcodeType)
-	fullpath := filepath.Join(tplDir, filename)
-
-	if _, err = os.Stat(fullpath); err != nil {
+	if _, err = os.Stat(t.Path); err != nil {
 		if os.IsNotExist(err) {
-			return "", "", ErrNotFound(fmt.Sprintf("template not found: %s", filename))
+			return "", "", ErrNotFound(fmt.Sprintf("template not found: %s", t.Filename))
 		}
 		return "", "", ErrInternal(err.Error())
 	}
 
-	return fullpath, filename, nil
+	return t.Path, t.Filename, nil
 }
 
-func validateTemplateRequest(width int, barcodeType string) error {
-	switch width {
-	case 9, 12, 18:
-	default:
-		return ErrInvalid("unsupported width")
+func (s *Service) PrintLabels(ctx context.Context, input PrintRequest) (*PrintJobResponse, error) {
+	tpl, err := s.lookupTemplate(ctx, input.Width, input.Type, input.TemplateID)
+	if err != nil {
+		return nil, err
 	}
+	return s.enqueuePrintJob(ctx, input.Config, []LabelData{input.Label}, tpl, input.Printer)
+}
 
-	switch barcodeType {
-	case "qrcode", "code128":
-	default:
-		return ErrInvalid("unsupported type")
+func (s *Service) PrintLabelsBatch(ctx context.Context, input BatchPrintRequest) (*PrintJobResponse, error) {
+	tpl, err := s.lookupTemplate(ctx, input.Width, input.Type, input.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+	return s.enqueuePrintJob(ctx, input.Config, input.Labels, tpl, input.Printer)
+}
+
+// PrintByManagementNumbers 管理番号または検索条件から備品を引き、ラベル列を埋めて印刷ジョブを積む
+func (s *Service) PrintByManagementNumbers(ctx context.Context, input PrintByManagementNumberRequest) (*PrintJobResponse, error) {
+	tpl, err := s.lookupTemplate(ctx, input.Width, input.Type, input.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+	// 列割り当て: リクエスト > テンプレート > 設定
+	base := s.columns
+	if len(tpl.FieldMapping) > 0 {
+		base = tpl.FieldMapping
+	}
+	columns, err := resolveLabelColumns(base, input.Columns)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	found, err := s.lookupLabelAssets(ctx, input.ManagementNumbers, input.Search)
+	if err != nil {
+		return nil, err
+	}
+	labels := make([]LabelData, 0, len(found))
+	for _, a := range found {
+		labels = append(labels, buildAssetLabel(a, columns))
+	}
+	return s.enqueuePrintJob(ctx, input.Config, labels, tpl, input.Printer)
 }
 
-func (s *Service) PrintLabels(ctx context.Context, input PrintRequest) (*PrintResponse, error) {
-	rows := []PrintRow{{Checked: input.Label.Checked,
-		ColB: input.Label.ColB,
-		ColC: input.Label.ColC,
-		ColD: input.Label.ColD,
-		ColE: input.Label.ColE,
-	}}
+// enqueuePrintJob 印刷先を決めて印刷要求を print_jobs に積む。実際の印刷は RunPrintWorker が行う
+func (s *Service) enqueuePrintJob(ctx context.Context, cfg PrintConfig, labels []LabelData, tpl *labelTemplate, printer *string) (*PrintJobResponse, error) {
+	count := getPrintJobCount(toPrintRows(labels))
+	if count == 0 {
+		return nil, ErrInvalid(ErrorMessageNoPrintJob)
+	}
+	printerName, err := s.selectPrinter(ctx, printer, tpl)
+	if err != nil {
+		return nil, err
+	}
 
-	params := PrintParams{
-		TemplateWidthMM:     input.Width,
-		BarcodeType:         input.Type,
-		UseHalfcut:          input.Config.UseHalfcut,
-		ConfirmTapeWidthDlg: input.Config.ConfirmTapeWidth,
-		EnablePrintLog:      input.Config.EnablePrintLog,
-		PrinterName:         "",
-	}
-
-	if err := PrintLabels(rows, params); err != nil {
-		if errors.Is(err, ErrTapeSizeNotMatched) {
-			// テープ幅の不一致は「クライアントからの要求とサーバーの状態の競合」:409 Conflictを返す
-			log.Println("[WARN]", ErrConflict(err.Error()))
-			return nil, ErrConflict(err.Error())
+	job, err := s.store.CreatePrintJob(ctx, createPrintJobInput{
+		PrinterName:  printerName,
+		Width:        tpl.Width,
+		BarcodeType:  tpl.Type,
+		TemplatePath: tpl.Path,
+		Config:       cfg,
+		Labels:       labels,
+		LabelCount:   count,
+	})
+	if err != nil {
+		return nil, ErrInternal(err.Error())
+	}
+	return job, nil
+}
+
+func (s *Service) GetPrintJob(ctx context.Context, id uint64) (*PrintJobResponse, error) {
+	job, err := s.store.GetPrintJob(ctx, id)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, ErrNotFound("print job not found")
 		}
-		if errors.Is(err, ErrTemplateNotFound) {
-			// テンプレートが見つからない: 404 Not Found
-			log.Println("[WARN]", ErrNotFound(err.Error()))
-			return nil, ErrNotFound(err.Error())
+		return nil, ErrInternal(err.Error())
+	}
+	return job, nil
+}
+
+// RunPrintWorker は中断ジョブを failed にした後、interval ごとに待ちジョブを確認し、
+// プリンタごとに 1 つだけワーカーを起動する。登録プリンタの状態も probeInterval ごとに確認する。
+// ctx がキャンセルされると戻る。
+func (s *Service) RunPrintWorker(ctx context.Context, interval time.Duration) {
+	if n, err := s.store.FailInterruptedPrintJobs(ctx, "print job interrupted by server restart", s.clock.Now()); err != nil {
+		log.Printf("[WARN] print worker: %v", err)
+	} else if n > 0 {
+		log.Printf("[INFO] print worker: %d interrupted job(s) marked failed", n)
+	}
+
+	dispatch := func() {
+		printers, err := s.store.ListQueuedPrinters(ctx)
+		if err != nil {
+			log.Printf("[WARN] print worker: %v", err)
+			return
 		}
-		if errors.Is(err, ErrNoPrintableSelected) {
-			// 印刷対象が選択されていないのは「クライアントのリクエストが不正」:400 Bad Request
-			log.Println("[WARN]", ErrInvalid(err.Error()))
-			return nil, ErrInvalid(err.Error())
+		for _, p := range printers {
+			printer := p
+			s.startPrinterTask(printer, func() { s.drainPrinter(ctx, printer) })
 		}
-		if errors.Is(err, ErrSPC10NotFound) {
-			// SPC10.exeが見つからないのはサーバー内部の問題:500 Internal
-			// ただし、メッセージは具体的で分かりやすいものにする
-			log.Println("[ERROR]", ErrInternal(err.Error()))
-			return nil, ErrInternal(err.Error())
+	}
+
+	s.probePrinters(ctx)
+	dispatch()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	probeTicker := time.NewTicker(s.probeInterval)
+	defer probeTicker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.workers.Wait()
+			return
+		case <-ticker.C:
+			dispatch()
+		case <-probeTicker.C:
+			s.probePrinters(ctx)
 		}
+	}
+}
 
-		// その他の予期せぬエラーも500 Internal
-		log.Printf("[ERROR] %v\n", err)
-		return nil, ErrInternal(err.Error())
+// startPrinterTask プリンタで印刷・状態確認が動いていなければ fn を起動する（SPC10 を同時に叩かない）
+func (s *Service) startPrinterTask(printer string, fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.running[printer]; ok {
+		return
+	}
+	s.running[printer] = struct{}{}
+	s.workers.Add(1)
+
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			delete(s.running, printer)
+			s.mu.Unlock()
+			s.workers.Done()
+		}()
+		fn()
+	}()
+}
+
+// drainPrinter 待ちジョブが無くなるまで 1 件ずつ印刷する
+func (s *Service) drainPrinter(ctx context.Context, printer string) {
+	for ctx.Err() == nil {
+		job, err := s.store.ClaimNextPrintJob(ctx, printer, s.clock.Now())
+		if err != nil {
+			if !isNoRows(err) {
+				log.Printf("[WARN] print worker(%q): %v", printer, err)
+			}
+			return
+		}
+		s.processPrintJob(ctx, job)
+	}
+}
+
+// processPrintJob ジョブ専用の作業ディレクトリで印刷し、結果を記録する
+func (s *Service) processPrintJob(ctx context.Context, job *printJob) {
+	fin := finishPrintJobInput{ID: job.ID, Status: PrintJobStatusSucceeded}
+
+	outcome, err := s.runPrint(ctx, job)
+	s.recordPrinterState(ctx, job.PrinterName, outcome.Tape, err)
+	if err != nil {
+		apiErr := toPrintAPIError(err)
+		fin.Status = PrintJobStatusFailed
+		fin.ErrorCode = string(apiErr.Code)
+		fin.ErrorMessage = apiErr.Message
+		log.Printf("[WARN] print job %d failed: %v", job.ID, err)
+	}
+	if !job.Config.EnablePrintLog {
+		outcome.Log = ""
+	}
+	fin.Outcome = outcome
+	fin.FinishedAt = s.clock.Now()
+
+	// ctx がキャンセルされていても結果は残す
+	if err := s.store.FinishPrintJob(context.WithoutCancel(ctx), fin); err != nil {
+		log.Printf("[ERROR] print job %d: failed to record result: %v", job.ID, err)
+	}
+}
+
+func (s *Service) runPrint(ctx context.Context, job *printJob) (PrintOutcome, error) {
+	workDir, err := os.MkdirTemp("", fmt.Sprintf("print-job-%d-", job.ID))
+	if err != nil {
+		return PrintOutcome{}, err
+	}
+	defer os.RemoveAll(workDir)
+
+	if job.TemplatePath == "" {
+		// テンプレート未記録の古いジョブは今のレジストリで解決する
+		tpl, err := s.lookupTemplate(ctx, job.Width, job.BarcodeType, nil)
+		if err != nil {
+			return PrintOutcome{}, fmt.Errorf("%w: %v", ErrTemplateNotFound, err)
+		}
+		job.TemplatePath = tpl.Path
+	}
+
+	params := PrintParams{
+		TemplatePath:        job.TemplatePath,
+		TemplateWidthMM:     job.Width,
+		BarcodeType:         job.BarcodeType,
+		UseHalfcut:          job.Config.UseHalfcut,
+		ConfirmTapeWidthDlg: job.Config.ConfirmTapeWidth,
+		EnablePrintLog:      job.Config.EnablePrintLog,
+		PrinterName:         job.PrinterName,
 	}
+	return s.print(ctx, workDir, toPrintRows(job.Labels), params)
+}
 
-	return &PrintResponse{Success: true, Error: nil}, nil
+// toPrintAPIError 印刷フローのエラーをジョブに記録するエラーコードへ変換する
+func toPrintAPIError(err error) *APIError {
+	switch {
+	case errors.Is(err, ErrTapeSizeNotMatched):
+		// テープ幅の不一致は「クライアントからの要求とサーバーの状態の競合」
+		return ErrConflict(err.Error())
+	case errors.Is(err, ErrTemplateNotFound):
+		return ErrNotFound(err.Error())
+	case errors.Is(err, ErrNoPrintableSelected):
+		return ErrInvalid(err.Error())
+	default:
+		// SPC10.exe が見つからない・印刷失敗などはサーバー側の問題
+		return ErrInternal(err.Error())
+	}
 }
 
-func (s *Service) PrintLabelsBatch(ctx context.Context, input BatchPrintRequest) (*PrintResponse, error) {
-	// LabelData -> PrintRow へ変換
-	rows := make([]PrintRow, 0, len(input.Labels))
-	for _, l := range input.Labels {
+func toPrintRows(labels []LabelData) []PrintRow {
+	rows := make([]PrintRow, 0, len(labels))
+	for _, l := range labels {
 		rows = append(rows, PrintRow{
 			Checked: l.Checked,
 			ColB:    l.ColB,
@@ -115,42 +354,49 @@ func (s *Service) PrintLabelsBatch(ctx context.Context, input BatchPrintRequest)
 			ColE:    l.ColE,
 		})
 	}
+	return rows
+}
 
-	params := PrintParams{
-		TemplateWidthMM:     input.Width,
-		BarcodeType:         input.Type,
-		UseHalfcut:          input.Config.UseHalfcut,
-		ConfirmTapeWidthDlg: input.Config.ConfirmTapeWidth,
-		EnablePrintLog:      input.Config.EnablePrintLog,
-		PrinterName:         "",
-	}
-
-	if err := PrintLabels(rows, params); err != nil {
-		if errors.Is(err, ErrTapeSizeNotMatched) {
-			// テープ幅の不一致は「クライアントからの要求とサーバーの状態の競合」:409 Conflictを返す
-			log.Println("[WARN]", ErrConflict(err.Error()))
-			return nil, ErrConflict(err.Error())
-		}
-		if errors.Is(err, ErrTemplateNotFound) {
-			// テンプレートが見つからない: 404 Not Found
-			log.Println("[WARN]", ErrNotFound(err.Error()))
-			return nil, ErrNotFound(err.Error())
-		}
-		if errors.Is(err, ErrNoPrintableSelected) {
-			// 印刷対象が選択されていないのは「クライアントのリクエストが不正」:400 Bad Request
-			log.Println("[WARN]", ErrInvalid(err.Error()))
-			return nil, ErrInvalid(err.Error())
-		}
-		if errors.Is(err, ErrSPC10NotFound) {
-			// SPC10.exeが見つからないのはサーバー内部の問題:500 Internal
-			// ただし、メッセージは具体的で分かりやすいものにする
-			log.Println("[ERROR]", ErrInternal(err.Error()))
-			return nil, ErrInternal(err.Error())
-		}
+// RenderLabel ラベルを PNG / PDF に描画する（SPC10.exe 不要）
+func (s *Service) RenderLabel(ctx context.Context, input RenderRequest) (*RenderedLabel, error) {
+	format := input.Format
+	if format == "" {
+		format = RenderFormatPNG
+	}
+	if format != RenderFormatPNG && format != RenderFormatPDF {
+		return nil, ErrInvalid("format must be png or pdf")
+	}
 
-		// その他の予期せぬエラーも500 Internal
+	ft, err := s.labelFont()
+	if err != nil {
 		log.Printf("[ERROR] %v\n", err)
+		return nil, ErrInternal("failed to load label font")
+	}
+
+	img, err := renderLabelImage(ft, input.Label, input.Width, input.Type)
+	if err != nil {
+		return nil, err
+	}
+	pngBytes, err := encodePNG(img)
+	if err != nil {
 		return nil, ErrInternal(err.Error())
 	}
-	return &PrintResponse{Success: true, Error: nil}, nil
+
+	filename := fmt.Sprintf("label_%d_%s.%s", input.Width, input.Type, format)
+	if format == RenderFormatPNG {
+		return &RenderedLabel{ContentType: "image/png", Filename: filename, Body: pngBytes}, nil
+	}
+
+	pdfBytes, err := encodeLabelPDF(pngBytes, img.Bounds())
+	if err != nil {
+		return nil, ErrInternal(err.Error())
+	}
+	return &RenderedLabel{ContentType: "application/pdf", Filename: filename, Body: pdfBytes}, nil
+}
+
+func (s *Service) labelFont() (*opentype.Font, error) {
+	s.fontOnce.Do(func() {
+		s.font, s.fontErr = loadLabelFont(s.fontPath)
+	})
+	return s.font, s.fontErr
 }
@@ -0,0 +1,432 @@
+package printLabels
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakePrintJobStore struct {
+	mu          sync.Mutex
+	jobs        []*printJob
+	created     []createPrintJobInput
+	finished    map[uint64]finishPrintJobInput
+	interrupted int64
+
+	templates       []LabelTemplateResponse
+	templatesInUse  map[string]bool
+	deletedTemplate *uint64
+
+	printers      []PrinterResponse
+	printerStates []printerStateInput
+}
+
+// newTestService はパッケージ内の templates/ を組み込みテンプレートとして使う
+func newTestService(store *fakePrintJobStore, print printFunc) *Service {
+	svc := newServiceWithStore(store, print)
+	svc.builtinTemplateDir = "templates"
+	return svc
+}
+
+func newFakePrintJobStore() *fakePrintJobStore {
+	return &fakePrintJobStore{finished: map[uint64]finishPrintJobInput{}}
+}
+
+func (f *fakePrintJobStore) CreatePrintJob(ctx context.Context, in createPrintJobInput) (*PrintJobResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.created = append(f.created, in)
+	job := &printJob{
+		ID:          uint64(len(f.jobs) + 1),
+		PrinterName: in.PrinterName,
+		Status:      PrintJobStatusQueued,
+		Width:       in.Width,
+		BarcodeType: in.BarcodeType,
+		Config:      in.Config,
+		Labels:      in.Labels,
+	}
+	f.jobs = append(f.jobs, job)
+	return &PrintJobResponse{PrintJobID: job.ID, Status: job.Status, Width: in.Width, Type: in.BarcodeType, LabelCount: in.LabelCount}, nil
+}
+
+func (f *fakePrintJobStore) GetPrintJob(ctx context.Context, id uint64) (*PrintJobResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, j := range f.jobs {
+		if j.ID == id {
+			return &PrintJobResponse{PrintJobID: j.ID, Status: j.Status}, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakePrintJobStore) ListQueuedPrinters(ctx context.Context) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	seen := map[string]bool{}
+	var out []string
+	for _, j := range f.jobs {
+		if j.Status == PrintJobStatusQueued && !seen[j.PrinterName] {
+			seen[j.PrinterName] = true
+			out = append(out, j.PrinterName)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakePrintJobStore) ClaimNextPrintJob(ctx context.Context, printerName string, now time.Time) (*printJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, j := range f.jobs {
+		if j.Status == PrintJobStatusQueued && j.PrinterName == printerName {
+			j.Status = PrintJobStatusPrinting
+			cp := *j
+			return &cp, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakePrintJobStore) FinishPrintJob(ctx context.Context, in finishPrintJobInput) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, j := range f.jobs {
+		if j.ID == in.ID {
+			j.Status = in.Status
+		}
+	}
+	f.finished[in.ID] = in
+	return nil
+}
+
+func (f *fakePrintJobStore) FailInterruptedPrintJobs(ctx context.Context, message string, now time.Time) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var n int64
+	for _, j := range f.jobs {
+		if j.Status == PrintJobStatusPrinting {
+			j.Status = PrintJobStatusFailed
+			n++
+		}
+	}
+	f.interrupted = n
+	return n, nil
+}
+
+func (f *fakePrintJobStore) ListLabelTemplates(ctx context.Context) ([]LabelTemplateResponse, error) {
+	return append([]LabelTemplateResponse(nil), f.templates...), nil
+}
+
+func (f *fakePrintJobStore) GetLabelTemplate(ctx context.Context, id uint64) (*LabelTemplateResponse, error) {
+	for _, t := range f.templates {
+		if t.TemplateID == id {
+			return &t, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakePrintJobStore) FindLabelTemplate(ctx context.Context, width int, barcodeType string) (*LabelTemplateResponse, error) {
+	for i := len(f.templates) - 1; i >= 0; i-- {
+		if t := f.templates[i]; t.Width == width && t.Type == barcodeType {
+			return &t, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakePrintJobStore) CreateLabelTemplate(ctx context.Context, in createLabelTemplateInput) (*LabelTemplateResponse, error) {
+	t := LabelTemplateResponse{
+		TemplateID:   uint64(len(f.templates) + 1),
+		Name:         in.Name,
+		Width:        in.Width,
+		Type:         in.BarcodeType,
+		PrinterModel: in.PrinterModel,
+		FieldMapping: in.FieldMapping,
+		Filename:     in.Filename,
+		SizeBytes:    in.SizeBytes,
+		SHA256:       in.SHA256,
+	}
+	f.templates = append(f.templates, t)
+	return &t, nil
+}
+
+func (f *fakePrintJobStore) DeleteLabelTemplate(ctx context.Context, id uint64, path string) error {
+	if f.templatesInUse[path] {
+		return errTemplateInUse
+	}
+	for i, t := range f.templates {
+		if t.TemplateID == id {
+			f.templates = append(f.templates[:i], f.templates[i+1:]...)
+			f.deletedTemplate = &id
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (f *fakePrintJobStore) ListPrinters(ctx context.Context) ([]PrinterResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]PrinterResponse(nil), f.printers...), nil
+}
+
+func (f *fakePrintJobStore) GetPrinter(ctx context.Context, id uint64) (*PrinterResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, p := range f.printers {
+		if p.PrinterID == id {
+			return &p, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakePrintJobStore) GetPrinterByName(ctx context.Context, name string) (*PrinterResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, p := range f.printers {
+		if p.Name == name {
+			return &p, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakePrintJobStore) CreatePrinter(ctx context.Context, in createPrinterInput) (*PrinterResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p := PrinterResponse{PrinterID: uint64(len(f.printers) + 1), Name: in.Name, Location: in.Location, Model: in.Model}
+	f.printers = append(f.printers, p)
+	return &p, nil
+}
+
+func (f *fakePrintJobStore) UpdatePrinter(ctx context.Context, id uint64, patch updatePrinterInput) (*PrinterResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.printers {
+		if p := &f.printers[i]; p.PrinterID == id {
+			if patch.Location != nil {
+				p.Location = patch.Location
+			}
+			if patch.IsDisabled != nil {
+				p.IsDisabled = *patch.IsDisabled
+			}
+			cp := *p
+			return &cp, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakePrintJobStore) UpdatePrinterState(ctx context.Context, in printerStateInput) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.printerStates = append(f.printerStates, in)
+	return nil
+}
+
+func (f *fakePrintJobStore) recordedStates() []printerStateInput {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]printerStateInput(nil), f.printerStates...)
+}
+
+func (f *fakePrintJobStore) finishedJob(id uint64) (finishPrintJobInput, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	in, ok := f.finished[id]
+	return in, ok
+}
+
+func printableLabel(code string) LabelData {
+	return LabelData{Checked: true, ColB: "Switch", ColC: "NW", ColD: code, ColE: code}
+}
+
+func TestPrintLabelsBatchQueuesJob(t *testing.T) {
+	store := newFakePrintJobStore()
+	svc := newTestService(store, nil)
+
+	res, err := svc.PrintLabelsBatch(context.Background(), BatchPrintRequest{
+		Width: 18,
+		Type:  "code128",
+		Labels: []LabelData{
+			printableLabel("OFS-20250811-0001"),
+			{Checked: false, ColB: "SKIP"},
+			printableLabel("OFS-20250811-0002"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("PrintLabelsBatch returned error: %v", err)
+	}
+	if res.Status != PrintJobStatusQueued || res.LabelCount != 2 {
+		t.Fatalf("expected queued job with 2 labels, got %#v", res)
+	}
+	if len(store.created) != 1 || len(store.created[0].Labels) != 3 {
+		t.Fatalf("expected one job holding all labels, got %#v", store.created)
+	}
+}
+
+func TestPrintLabelsRejectsRequestsBeforeQueueing(t *testing.T) {
+	store := newFakePrintJobStore()
+	svc := newTestService(store, nil)
+
+	cases := map[string]PrintRequest{
+		"width":      {Label: printableLabel("OFS-1"), Width: 24, Type: "qrcode"},
+		"type":       {Label: printableLabel("OFS-1"), Width: 12, Type: "ean13"},
+		"unselected": {Label: LabelData{Checked: false, ColB: "x"}, Width: 12, Type: "qrcode"},
+	}
+	for name, req := range cases {
+		_, err := svc.PrintLabels(context.Background(), req)
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.Code != CodeInvalidArgument {
+			t.Fatalf("%s: expected invalid argument, got %v", name, err)
+		}
+	}
+	if len(store.created) != 0 {
+		t.Fatalf("expected no jobs queued, got %d", len(store.created))
+	}
+}
+
+func TestGetPrintJobNotFound(t *testing.T) {
+	svc := newTestService(newFakePrintJobStore(), nil)
+
+	_, err := svc.GetPrintJob(context.Background(), 99)
+	if toHTTPStatus(err) != 404 {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestRunPrintWorkerRecordsOutcomePerJob(t *testing.T) {
+	store := newFakePrintJobStore()
+	var (
+		mu       sync.Mutex
+		active   = map[string]int{}
+		overlap  bool
+		workDirs = map[string]bool{}
+	)
+	printFn := func(ctx context.Context, workDir string, rows []PrintRow, p PrintParams) (PrintOutcome, error) {
+		mu.Lock()
+		active[p.PrinterName]++
+		if active[p.PrinterName] > 1 {
+			overlap = true
+		}
+		if workDirs[workDir] {
+			overlap = true
+		}
+		workDirs[workDir] = true
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+		if _, err := os.Stat(workDir); err != nil {
+			t.Errorf("work dir %s not available: %v", workDir, err)
+		}
+
+		mu.Lock()
+		active[p.PrinterName]--
+		mu.Unlock()
+
+		if rows[0].ColE == "FAIL" {
+			return PrintOutcome{Code: "3", Message: "tape empty", Log: "3\ttape empty"}, fmt.Errorf("%w: 3 tape empty", ErrPrintFailed)
+		}
+		return PrintOutcome{Code: "0", Message: "succeed", Log: "0\tsucceed"}, nil
+	}
+	svc := newTestService(store, printFn)
+
+	ctx := context.Background()
+	for _, code := range []string{"OFS-1", "FAIL", "OFS-3"} {
+		if _, err := svc.PrintLabels(ctx, PrintRequest{Label: printableLabel(code), Width: 12, Type: "qrcode"}); err != nil {
+			t.Fatalf("PrintLabels returned error: %v", err)
+		}
+	}
+	store.jobs[2].Config.EnablePrintLog = true
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		svc.RunPrintWorker(runCtx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	deadline := time.After(2 * time.Second)
+	for {
+		_, ok := store.finishedJob(3)
+		if ok {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for jobs to finish")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	cancel()
+	<-done
+
+	if overlap {
+		t.Fatal("expected a single worker per printer and a separate work dir per job")
+	}
+
+	ok1, _ := store.finishedJob(1)
+	if ok1.Status != PrintJobStatusSucceeded || ok1.Outcome.Message != "succeed" || ok1.Outcome.Log != "" {
+		t.Fatalf("unexpected result for job 1: %#v", ok1)
+	}
+	failed, _ := store.finishedJob(2)
+	if failed.Status != PrintJobStatusFailed || failed.ErrorCode != string(CodeInternal) || failed.Outcome.Code != "3" {
+		t.Fatalf("unexpected result for job 2: %#v", failed)
+	}
+	withLog, _ := store.finishedJob(3)
+	if withLog.Status != PrintJobStatusSucceeded || withLog.Outcome.Log == "" {
+		t.Fatalf("expected print log kept for job 3, got %#v", withLog)
+	}
+	for dir := range workDirs {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Fatalf("expected work dir %s removed, got %v", dir, err)
+		}
+	}
+}
+
+func TestRunPrintWorkerFailsInterruptedJobs(t *testing.T) {
+	store := newFakePrintJobStore()
+	store.jobs = []*printJob{{ID: 1, Status: PrintJobStatusPrinting}}
+	svc := newTestService(store, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	svc.RunPrintWorker(ctx, time.Hour)
+
+	if store.interrupted != 1 || store.jobs[0].Status != PrintJobStatusFailed {
+		t.Fatalf("expected interrupted job marked failed, got %#v", store.jobs[0])
+	}
+}
+
+func TestToPrintAPIError(t *testing.T) {
+	cases := map[error]Code{
+		fmt.Errorf("%w: x", ErrTapeSizeNotMatched):  CodeConflict,
+		fmt.Errorf("%w: x", ErrTemplateNotFound):    CodeNotFound,
+		fmt.Errorf("%w: x", ErrNoPrintableSelected): CodeInvalidArgument,
+		fmt.Errorf("%w: x", ErrSPC10NotFound):       CodeInternal,
+		fmt.Errorf("%w: x", ErrPrintFailed):         CodeInternal,
+	}
+	for err, want := range cases {
+		if got := toPrintAPIError(err).Code; got != want {
+			t.Fatalf("%v: expected %s, got %s", err, want, got)
+		}
+	}
+}
+
+func TestParsePrintResult(t *testing.T) {
+	// SPC10 が出力した UTF-16 のサンプル
+	outcome, err := parsePrintResult(PrintLogFilename)
+	if err != nil {
+		t.Fatalf("parsePrintResult returned error: %v", err)
+	}
+	if outcome.Code != PrintResultSucceeded || outcome.Message != "succeed" {
+		t.Fatalf("unexpected outcome %#v", outcome)
+	}
+}
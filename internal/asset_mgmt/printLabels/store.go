@@ -23,6 +23,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -49,8 +50,12 @@ const (
 	TapeWidthFilename        = "TapeWidth.txt"
 	PrintCSVFilename         = "data.csv"
 	PrintLogFilename         = "PrintResult.txt"
-	WaitTapeWidthSeconds     = 3 // /GT 後の待機
+	WaitTapeWidthTimeout     = 10 * time.Second // /GT 後の出力待ち
+	WaitPrintResultTimeout   = 30 * time.Second // 印刷後の PrintResult.txt 出力待ち
+	FilePollInterval         = 200 * time.Millisecond
 	CommandTimeout           = 60 * time.Second
+	PrintResultSucceeded     = "0"
+	TapeTypeStandard         = "0x00"
 )
 
 // ===== グローバル変数・エラー定義 =====
@@ -59,6 +64,8 @@ var (
 	ErrTapeSizeNotMatched  = errors.New("tape size not matched")
 	ErrSPC10NotFound       = errors.New("SPC10.exe not found")
 	ErrNoPrintableSelected = errors.New("no printable items selected")
+	ErrPrintFailed         = errors.New("print failed")
+	ErrPrinterUnreachable  = errors.New("printer did not respond")
 )
 
 // ===== データ構造 =====
@@ -193,7 +200,7 @@ func runSPC10(ctx context.Context, spc10 string, option string, printerName stri
 		args = []string{"/p", option}
 	}
 
-	cmd := exec.Command(spc10, args...)
+	cmd := exec.CommandContext(ctx, spc10, args...)
 	switch runtime.GOOS {
 	case "windows":
 		setPlatformSysProcAttr(cmd)
@@ -201,10 +208,50 @@ func runSPC10(ctx context.Context, spc10 string, option string, printerName stri
 		// Linux では Windows 固有の SysProcAttr を設定しない。
 	}
 
-	if err := cmd.Start(); err != nil {
-		return err
+	// 終了まで待つ（Start だけだと印刷前に成功扱いになる）
+	return cmd.Run()
+}
+
+// waitForFile SPC10 が出力ファイルを書き終えるまで待つ
+func waitForFile(ctx context.Context, path string, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if info, err := os.Stat(path); err == nil && info.Size() > 0 {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(FilePollInterval):
+		}
+	}
+}
+
+// parsePrintResult PrintResult.txt（"0\tsucceed" 形式）を解析する。最後の行を結果とする
+func parsePrintResult(path string) (PrintOutcome, error) {
+	lines, err := readUTF16File(path)
+	if err != nil {
+		return PrintOutcome{}, err
+	}
+	var last string
+	for _, l := range lines {
+		if l != "" {
+			last = l
+		}
 	}
-	return nil
+	if last == "" {
+		return PrintOutcome{}, io.ErrUnexpectedEOF
+	}
+
+	code, msg, _ := strings.Cut(last, "\t")
+	return PrintOutcome{
+		Code:    strings.TrimSpace(code),
+		Message: strings.TrimSpace(msg),
+		Log:     strings.Join(lines, "\n"),
+	}, nil
 }
 
 // getTapeInfo TapeWidth.txt を解析して幅/種類を返す
@@ -255,89 +302,107 @@ func fileExists(path string) bool {
 
 // ===== メインの印刷フロー =====
 
-// PrintLabels エントリポイント
-func PrintLabels(data []PrintRow, p PrintParams) error {
-	// 0) 絶対パス類の基準（実行時のカレント＝プロジェクトルート想定）
+// builtinTemplateRoot テンプレ配置ディレクトリ（実行時のカレント＝プロジェクトルート想定）
+// ./LIMS-back/internal/asset_mgmt/printLabels/templates にテンプレを置いている前提
+func builtinTemplateRoot() (string, error) {
 	baseDir, err := os.Getwd()
 	if err != nil {
-		return err
+		return "", err
 	}
+	return filepath.Join(baseDir, "internal", "asset_mgmt", "printLabels", "templates"), nil
+}
 
-	// 0.5) テンプレ配置ディレクトリ
-	// ./LIMS-back/internal/asset_mgmt/printLabels/templates にテンプレを置いている前提
-	tplDir := filepath.Join(baseDir, "internal", "asset_mgmt", "printLabels", "templates")
-
-	// 1) SPC10.exe の場所
-	spc10, err := spc10Path()
+// readLoadedTape /GT でプリンタに装着されているテープ情報を取得する。
+// TapeWidth.txt が出力されなければ ErrPrinterUnreachable
+func readLoadedTape(ctx context.Context, spc10, workDir, printerName string) (TapeInfo, error) {
+	tplDir, err := builtinTemplateRoot()
 	if err != nil {
-		return fmt.Errorf("%w: %s", err, ErrorMessageRunPrint)
-	}
-
-	// 2) 印刷対象があるか
-	if getPrintJobCount(data) == 0 {
-		return fmt.Errorf("%w: %s", ErrNoPrintableSelected, ErrorMessageNoPrintJob)
-	}
-
-	// 3) /GT でテープ幅を取得
-	tapeWidthFile := filepath.Join(baseDir, TapeWidthFilename)
-	printCSV := filepath.Join(baseDir, PrintCSVFilename)
-	printLog := ""
-	if p.EnablePrintLog {
-		printLog = filepath.Join(baseDir, PrintLogFilename)
+		return TapeInfo{}, err
 	}
+	tapeWidthFile := filepath.Join(workDir, TapeWidthFilename)
+	gtCSV := filepath.Join(workDir, "gt_"+PrintCSVFilename)
 
 	// /GT 用ダミーテンプレ（実際に存在する .lw1 を指定）
 	dummyTpl := filepath.Join(tplDir, DefaultTemplateDummyRel)
 
 	// CSV は空でも良いが、SPC10 が参照できるように用意
-	if err := writeCSVcp932(printCSV, data); err != nil {
-		return err
+	if err := writeCSVcp932(gtCSV, nil); err != nil {
+		return TapeInfo{}, err
 	}
 
-	optGetWidth := createPrintOption(
-		dummyTpl, printCSV, 1, p.UseHalfcut, p.ConfirmTapeWidthDlg, "", tapeWidthFile,
-	)
+	optGetWidth := createPrintOption(dummyTpl, gtCSV, 1, false, false, "", tapeWidthFile)
 
-	ctx, cancel := context.WithTimeout(context.Background(), CommandTimeout)
+	ctx1, cancel := context.WithTimeout(ctx, CommandTimeout)
 	defer cancel()
-	if err := runSPC10(ctx, spc10, optGetWidth, ""); err != nil {
+	if err := runSPC10(ctx1, spc10, optGetWidth, printerName); err != nil {
 		// 実行不能（PATH/権限/存在なし等）
-		return fmt.Errorf("%w: %s (%v)", ErrSPC10NotFound, ErrorMessageRunPrint, err)
+		return TapeInfo{}, fmt.Errorf("%w: %s (%v)", ErrSPC10NotFound, ErrorMessageRunPrint, err)
 	}
 
-	// SPC10 が TapeWidth.txt を出力するのを少し待つ
-	time.Sleep(WaitTapeWidthSeconds)
-
-	if !fileExists(tapeWidthFile) {
-		return fmt.Errorf("%s", ErrorMessageGetTapeWidth)
+	// SPC10 が TapeWidth.txt を出力するのを待つ
+	if !waitForFile(ctx, tapeWidthFile, WaitTapeWidthTimeout) {
+		return TapeInfo{}, fmt.Errorf("%w: %s", ErrPrinterUnreachable, ErrorMessageGetTapeWidth)
 	}
 
 	ti, err := getTapeInfo(tapeWidthFile)
 	if err != nil {
-		return fmt.Errorf("テープ情報の読み取りに失敗: %w", err)
-	}
-	if ti.Width == "" || ti.Width == "0" {
-		return errors.New("テープ未検出、または幅0mm")
+		return TapeInfo{}, fmt.Errorf("テープ情報の読み取りに失敗: %w", err)
 	}
-	// テープ種類のチェック（Python版と同様: 0x00=Standard のみ許容）
-	if ti.Type != "0x00" {
-		return fmt.Errorf("%s (Unsupported tape type: %s)", ErrorMessageTplNotFound, ti.Type)
+	return ti, nil
+}
+
+// ProbePrinter プリンタに /GT だけを送り、装着テープを確認する（印刷はしない）
+func ProbePrinter(ctx context.Context, workDir, printerName string) (TapeInfo, error) {
+	spc10, err := spc10Path()
+	if err != nil {
+		return TapeInfo{}, fmt.Errorf("%w: %s", err, ErrorMessageRunPrint)
 	}
+	return readLoadedTape(ctx, spc10, workDir, printerName)
+}
 
-	fmt.Printf("Detected tape width: %smm\n", ti.Width)
+// PrintLabels エントリポイント
+// workDir はジョブ専用の作業ディレクトリ。data.csv / TapeWidth.txt / PrintResult.txt はここに作る
+// /GT で読めたテープ情報は成否にかかわらず PrintOutcome.Tape に入れて返す
+func PrintLabels(ctx context.Context, workDir string, data []PrintRow, p PrintParams) (PrintOutcome, error) {
+	// 1) SPC10.exe の場所
+	spc10, err := spc10Path()
+	if err != nil {
+		return PrintOutcome{}, fmt.Errorf("%w: %s", err, ErrorMessageRunPrint)
+	}
 
-	// 4) テンプレートの存在確認（ここも tplDir を使う）
-	templateFilename := fmt.Sprintf("%d_%s.lw1", p.TemplateWidthMM, p.BarcodeType)
-	templatePath := filepath.Join(tplDir, templateFilename)
+	// 2) 印刷対象があるか
+	if getPrintJobCount(data) == 0 {
+		return PrintOutcome{}, fmt.Errorf("%w: %s", ErrNoPrintableSelected, ErrorMessageNoPrintJob)
+	}
 
-	fmt.Printf("Using template file: %s\n", templatePath)
+	// 3) /GT でテープ幅を取得
+	ti, err := readLoadedTape(ctx, spc10, workDir, p.PrinterName)
+	if err != nil {
+		return PrintOutcome{}, err
+	}
+	out := PrintOutcome{Tape: &ti}
+	if ti.Width == "" || ti.Width == "0" {
+		return out, errors.New("テープ未検出、または幅0mm")
+	}
+	// テープ種類のチェック（Python版と同様: 0x00=Standard のみ許容）
+	if ti.Type != TapeTypeStandard {
+		return out, fmt.Errorf("%s (Unsupported tape type: %s)", ErrorMessageTplNotFound, ti.Type)
+	}
+	// 受付後にテープが差し替えられていないか
+	if ti.Width != strconv.Itoa(p.TemplateWidthMM) {
+		return out, fmt.Errorf("%w: テンプレート %dmm, 装着テープ %smm", ErrTapeSizeNotMatched, p.TemplateWidthMM, ti.Width)
+	}
 
-	if !fileExists(templatePath) {
-		return fmt.Errorf("%w: 幅:%dmm, タイプ:%s → %s を確認してください",
-			ErrTemplateNotFound, p.TemplateWidthMM, p.BarcodeType, templateFilename)
+	// 4) テンプレートの存在確認（受付時にレジストリで解決済み）
+	templatePath := p.TemplatePath
+	if templatePath == "" || !fileExists(templatePath) {
+		return out, fmt.Errorf("%w: 幅:%dmm, タイプ:%s → %s を確認してください",
+			ErrTemplateNotFound, p.TemplateWidthMM, p.BarcodeType, templatePath)
 	}
 
 	// 5) 最終 CSV 生成（Checked 行のみ）
+	printCSV := filepath.Join(workDir, PrintCSVFilename)
+	printLog := filepath.Join(workDir, PrintLogFilename)
 	var filtered []PrintRow
 	for _, r := range data {
 		if r.Checked {
@@ -345,20 +410,31 @@ func PrintLabels(data []PrintRow, p PrintParams) error {
 		}
 	}
 	if err := writeCSVcp932(printCSV, filtered); err != nil {
-		return err
+		return out, err
 	}
 
-	// 6) 印刷実行
+	// 6) 印刷実行（結果判定のため /L は常に指定する）
 	optPrint := createPrintOption(
 		templatePath, printCSV, 1, p.UseHalfcut, p.ConfirmTapeWidthDlg, printLog, "",
 	)
 
-	ctx2, cancel2 := context.WithTimeout(context.Background(), CommandTimeout)
+	ctx2, cancel2 := context.WithTimeout(ctx, CommandTimeout)
 	defer cancel2()
 	if err := runSPC10(ctx2, spc10, optPrint, p.PrinterName); err != nil {
-		return fmt.Errorf("%s (%v)", ErrorMessageRunPrint, err)
+		return out, fmt.Errorf("%s (%v)", ErrorMessageRunPrint, err)
 	}
 
-	fmt.Println("Print command sent successfully!")
-	return nil
+	// 7) PrintResult.txt を解析
+	if !waitForFile(ctx, printLog, WaitPrintResultTimeout) {
+		return out, fmt.Errorf("%w: %s was not written", ErrPrintFailed, PrintLogFilename)
+	}
+	outcome, err := parsePrintResult(printLog)
+	if err != nil {
+		return out, fmt.Errorf("%w: %s を解析できません (%v)", ErrPrintFailed, PrintLogFilename, err)
+	}
+	outcome.Tape = &ti
+	if outcome.Code != PrintResultSucceeded {
+		return outcome, fmt.Errorf("%w: %s %s", ErrPrintFailed, outcome.Code, outcome.Message)
+	}
+	return outcome, nil
 }
@@ -0,0 +1,261 @@
+package printLabels
+
+// ラベルテンプレート（.lw1）のレジストリ
+// - アップロードされたテンプレートは label_templates にメタデータ、Config.TemplateDir にファイルを置く
+// - ソースツリーの templates/{width}_{type}.lw1 は組み込みテンプレートとして読み取り専用で扱う
+// - 幅・種類の検証はこのレジストリに登録されているかどうかで行う
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	templateExt = ".lw1"
+
+	// アップロードできるテンプレートの最大サイズ
+	MaxTemplateBytes = 5 << 20
+
+	BarcodeTypeQRCode  = "qrcode"
+	BarcodeTypeCode128 = "code128"
+)
+
+// 組み込みテンプレートのファイル名（例: 12_qrcode.lw1）
+var builtinTemplateName = regexp.MustCompile(`^(\d+)_([a-z0-9]+)\.lw1$`)
+
+// labelTemplate 解決済みテンプレート（メタデータ + ファイルの絶対パス）
+type labelTemplate struct {
+	LabelTemplateResponse
+	Path string
+}
+
+type createLabelTemplateInput struct {
+	Name         string
+	Width        int
+	BarcodeType  string
+	PrinterModel *string
+	FieldMapping map[string]string
+	Filename     string
+	SizeBytes    int64
+	SHA256       string
+}
+
+// listBuiltinTemplates ソースツリーの templates/{width}_{type}.lw1 を列挙する
+func listBuiltinTemplates(dir string) ([]labelTemplate, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	out := make([]labelTemplate, 0, len(entries))
+	for _, e := range entries {
+		m := builtinTemplateName.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil || !isSupportedBarcodeType(m[2]) {
+			continue
+		}
+		width, _ := strconv.Atoi(m[1])
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, labelTemplate{
+			LabelTemplateResponse: LabelTemplateResponse{
+				Name:      strings.TrimSuffix(e.Name(), templateExt),
+				Width:     width,
+				Type:      m[2],
+				Filename:  e.Name(),
+				SizeBytes: info.Size(),
+				Builtin:   true,
+				CreatedAt: info.ModTime().UTC(),
+			},
+			Path: filepath.Join(dir, e.Name()),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Width != out[j].Width {
+			return out[i].Width < out[j].Width
+		}
+		return out[i].Type < out[j].Type
+	})
+	return out, nil
+}
+
+// isSupportedBarcodeType SPC10 テンプレート・描画ともに扱えるコード種別
+func isSupportedBarcodeType(t string) bool {
+	return t == BarcodeTypeQRCode || t == BarcodeTypeCode128
+}
+
+// lookupTemplate 幅・種類（template_id 指定時はそのテンプレート）に合うテンプレートを返す。
+// 登録済みテンプレートを優先し、無ければ組み込みテンプレートを使う
+func (s *Service) lookupTemplate(ctx context.Context, width int, barcodeType string, templateID *uint64) (*labelTemplate, error) {
+	if templateID != nil {
+		t, err := s.store.GetLabelTemplate(ctx, *templateID)
+		if err != nil {
+			if isNoRows(err) {
+				return nil, ErrInvalid(fmt.Sprintf("template_id %d not found", *templateID))
+			}
+			return nil, ErrInternal(err.Error())
+		}
+		if t.Width != width || t.Type != barcodeType {
+			return nil, ErrInvalid(fmt.Sprintf("template_id %d is for %dmm %s", *templateID, t.Width, t.Type))
+		}
+		return s.withTemplatePath(t), nil
+	}
+
+	t, err := s.store.FindLabelTemplate(ctx, width, barcodeType)
+	if err == nil {
+		return s.withTemplatePath(t), nil
+	}
+	if !isNoRows(err) {
+		return nil, ErrInternal(err.Error())
+	}
+
+	builtins, err := listBuiltinTemplates(s.builtinTemplateDir)
+	if err != nil {
+		return nil, ErrInternal(err.Error())
+	}
+	for _, b := range builtins {
+		if b.Width == width && b.Type == barcodeType {
+			return &b, nil
+		}
+	}
+	return nil, ErrInvalid(fmt.Sprintf("no template registered for %dmm %s", width, barcodeType))
+}
+
+func (s *Service) withTemplatePath(t *LabelTemplateResponse) *labelTemplate {
+	return &labelTemplate{LabelTemplateResponse: *t, Path: filepath.Join(s.templateDir, t.Filename)}
+}
+
+// ListLabelTemplates 登録済み・組み込みテンプレートの一覧
+func (s *Service) ListLabelTemplates(ctx context.Context) ([]LabelTemplateResponse, error) {
+	items, err := s.store.ListLabelTemplates(ctx)
+	if err != nil {
+		return nil, ErrInternal(err.Error())
+	}
+	builtins, err := listBuiltinTemplates(s.builtinTemplateDir)
+	if err != nil {
+		return nil, ErrInternal(err.Error())
+	}
+	for _, b := range builtins {
+		items = append(items, b.LabelTemplateResponse)
+	}
+	return items, nil
+}
+
+// UploadLabelTemplate テンプレートファイルを TemplateDir に保存して登録する
+func (s *Service) UploadLabelTemplate(ctx context.Context, meta UploadLabelTemplateRequest, file io.Reader) (*LabelTemplateResponse, error) {
+	name := strings.TrimSpace(meta.Name)
+	if name == "" {
+		return nil, ErrInvalid("name is required")
+	}
+	if meta.Width <= 0 {
+		return nil, ErrInvalid("width must be positive")
+	}
+	if !isSupportedBarcodeType(meta.Type) {
+		return nil, ErrInvalid("type must be qrcode or code128")
+	}
+	var mapping map[string]string
+	if len(meta.FieldMapping) > 0 {
+		// 全列を埋めた結果を保存しておく（config 変更の影響を受けない）
+		m, err := resolveLabelColumns(nil, meta.FieldMapping)
+		if err != nil {
+			return nil, err
+		}
+		mapping = m
+	}
+	if s.templateDir == "" {
+		return nil, ErrInternal("template_dir not configured")
+	}
+	if err := os.MkdirAll(s.templateDir, 0o755); err != nil {
+		return nil, ErrInternal(err.Error())
+	}
+
+	f, err := os.CreateTemp(s.templateDir, "tpl-*"+templateExt)
+	if err != nil {
+		return nil, ErrInternal(err.Error())
+	}
+	filename := filepath.Base(f.Name())
+	saved := false
+	defer func() {
+		if !saved {
+			os.Remove(f.Name())
+		}
+	}()
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), io.LimitReader(file, MaxTemplateBytes+1))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, ErrInternal(err.Error())
+	}
+	if n == 0 {
+		return nil, ErrInvalid("template file is empty")
+	}
+	if n > MaxTemplateBytes {
+		return nil, ErrInvalid(fmt.Sprintf("template file exceeds %d bytes", MaxTemplateBytes))
+	}
+
+	out, err := s.store.CreateLabelTemplate(ctx, createLabelTemplateInput{
+		Name:         name,
+		Width:        meta.Width,
+		BarcodeType:  meta.Type,
+		PrinterModel: trimmedOrNil(meta.PrinterModel),
+		FieldMapping: mapping,
+		Filename:     filename,
+		SizeBytes:    n,
+		SHA256:       hex.EncodeToString(h.Sum(nil)),
+	})
+	if err != nil {
+		return nil, mapTemplateStoreError(err)
+	}
+	saved = true
+	return out, nil
+}
+
+// DeleteLabelTemplate 登録を消してファイルも削除する。待ち・印刷中ジョブが使っていれば 409
+func (s *Service) DeleteLabelTemplate(ctx context.Context, id uint64) error {
+	t, err := s.store.GetLabelTemplate(ctx, id)
+	if err != nil {
+		if isNoRows(err) {
+			return ErrNotFound("template not found")
+		}
+		return ErrInternal(err.Error())
+	}
+	path := filepath.Join(s.templateDir, t.Filename)
+
+	if err := s.store.DeleteLabelTemplate(ctx, id, path); err != nil {
+		return mapTemplateStoreError(err)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return ErrInternal(err.Error())
+	}
+	return nil
+}
+
+func trimmedOrNil(p *string) *string {
+	if p == nil {
+		return nil
+	}
+	v := strings.TrimSpace(*p)
+	if v == "" {
+		return nil
+	}
+	return &v
+}
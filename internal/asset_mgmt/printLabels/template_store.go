@@ -0,0 +1,170 @@
+package printLabels
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	platformdb "IRIS-backend/internal/platform/db"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var errTemplateInUse = errors.New("template is used by queued print jobs")
+
+const labelTemplateColumns = `
+	label_template_id,
+	name,
+	width,
+	barcode_type,
+	printer_model,
+	field_mapping,
+	filename,
+	size_bytes,
+	sha256,
+	created_at`
+
+func (s *Store) ListLabelTemplates(ctx context.Context) ([]LabelTemplateResponse, error) {
+	q := `SELECT ` + labelTemplateColumns + ` FROM label_templates ORDER BY width, barcode_type, label_template_id`
+
+	rows, err := s.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]LabelTemplateResponse, 0, 8)
+	for rows.Next() {
+		t, err := scanLabelTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *t)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) GetLabelTemplate(ctx context.Context, id uint64) (*LabelTemplateResponse, error) {
+	q := `SELECT ` + labelTemplateColumns + ` FROM label_templates WHERE label_template_id = ?`
+	return scanLabelTemplate(s.db.QueryRowContext(ctx, q, id))
+}
+
+// FindLabelTemplate は幅・種類に合う最新の登録テンプレートを返す。無ければ sql.ErrNoRows
+func (s *Store) FindLabelTemplate(ctx context.Context, width int, barcodeType string) (*LabelTemplateResponse, error) {
+	q := `SELECT ` + labelTemplateColumns + ` FROM label_templates
+	WHERE width = ? AND barcode_type = ?
+	ORDER BY label_template_id DESC
+	LIMIT 1`
+	return scanLabelTemplate(s.db.QueryRowContext(ctx, q, width, barcodeType))
+}
+
+func (s *Store) CreateLabelTemplate(ctx context.Context, in createLabelTemplateInput) (*LabelTemplateResponse, error) {
+	const q = `
+	INSERT INTO label_templates
+		(name, width, barcode_type, printer_model, field_mapping, filename, size_bytes, sha256)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	var mapping any
+	if len(in.FieldMapping) > 0 {
+		b, err := json.Marshal(in.FieldMapping)
+		if err != nil {
+			return nil, err
+		}
+		mapping = b
+	}
+
+	res, err := s.db.ExecContext(ctx, q,
+		in.Name,
+		in.Width,
+		in.BarcodeType,
+		in.PrinterModel,
+		mapping,
+		in.Filename,
+		in.SizeBytes,
+		in.SHA256,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return s.GetLabelTemplate(ctx, uint64(id))
+}
+
+// DeleteLabelTemplate は待ち・印刷中ジョブが path を使っていなければ削除する
+func (s *Store) DeleteLabelTemplate(ctx context.Context, id uint64, path string) error {
+	const inUse = `
+	SELECT EXISTS(
+		SELECT 1 FROM print_jobs
+		WHERE template_path = ? AND status IN (?, ?)
+	)`
+	const del = `DELETE FROM label_templates WHERE label_template_id = ?`
+
+	return platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		var used bool
+		if err := tx.QueryRowContext(ctx, inUse, path, PrintJobStatusQueued, PrintJobStatusPrinting).Scan(&used); err != nil {
+			return err
+		}
+		if used {
+			return errTemplateInUse
+		}
+		res, err := tx.ExecContext(ctx, del, id)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return sql.ErrNoRows
+		}
+		return nil
+	})
+}
+
+func scanLabelTemplate(row rowScanner) (*LabelTemplateResponse, error) {
+	var (
+		out          LabelTemplateResponse
+		printerModel sql.NullString
+		mapping      []byte
+	)
+	if err := row.Scan(
+		&out.TemplateID,
+		&out.Name,
+		&out.Width,
+		&out.Type,
+		&printerModel,
+		&mapping,
+		&out.Filename,
+		&out.SizeBytes,
+		&out.SHA256,
+		&out.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	out.PrinterModel = nullStringPtr(printerModel)
+	if len(mapping) > 0 {
+		if err := json.Unmarshal(mapping, &out.FieldMapping); err != nil {
+			return nil, err
+		}
+	}
+	return &out, nil
+}
+
+func mapTemplateStoreError(err error) error {
+	if isNoRows(err) {
+		return ErrNotFound("template not found")
+	}
+	if errors.Is(err, errTemplateInUse) {
+		return ErrConflict(err.Error())
+	}
+	var me *mysql.MySQLError
+	if errors.As(err, &me) && me.Number == 1062 {
+		return ErrConflict("template name already exists")
+	}
+	return ErrInternal(err.Error())
+}
@@ -0,0 +1,195 @@
+package printLabels
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"IRIS-backend/internal/asset_mgmt/assets"
+)
+
+func TestListBuiltinTemplatesSkipsNonTemplateFiles(t *testing.T) {
+	items, err := listBuiltinTemplates("templates")
+	if err != nil {
+		t.Fatalf("listBuiltinTemplates returned error: %v", err)
+	}
+	if len(items) != 6 {
+		t.Fatalf("expected 6 built-in templates, got %d: %#v", len(items), items)
+	}
+	first := items[0]
+	if first.Width != 9 || first.Type != BarcodeTypeCode128 || !first.Builtin || first.Path != filepath.Join("templates", "9_code128.lw1") {
+		t.Fatalf("unexpected first template %#v", first)
+	}
+}
+
+func TestLookupTemplatePrefersRegisteredTemplate(t *testing.T) {
+	store := newFakePrintJobStore()
+	store.templates = []LabelTemplateResponse{{TemplateID: 7, Width: 12, Type: BarcodeTypeQRCode, Filename: "tpl-a.lw1"}}
+	svc := newTestService(store, nil)
+	svc.templateDir = "/srv/templates"
+
+	tpl, err := svc.lookupTemplate(context.Background(), 12, BarcodeTypeQRCode, nil)
+	if err != nil {
+		t.Fatalf("lookupTemplate returned error: %v", err)
+	}
+	if tpl.TemplateID != 7 || tpl.Path != filepath.Join("/srv/templates", "tpl-a.lw1") {
+		t.Fatalf("expected registered template, got %#v", tpl)
+	}
+
+	tpl, err = svc.lookupTemplate(context.Background(), 18, BarcodeTypeQRCode, nil)
+	if err != nil || !tpl.Builtin {
+		t.Fatalf("expected built-in fallback for 18mm, got %#v, %v", tpl, err)
+	}
+
+	// 登録も組み込みも無い幅・種類、幅違いの template_id は 400
+	id := uint64(7)
+	cases := map[string]struct {
+		width int
+		typ   string
+		id    *uint64
+	}{
+		"width": {24, BarcodeTypeQRCode, nil},
+		"type":  {12, "ean13", nil},
+		"id":    {18, BarcodeTypeQRCode, &id},
+	}
+	for name, tc := range cases {
+		if _, err := svc.lookupTemplate(context.Background(), tc.width, tc.typ, tc.id); toHTTPStatus(err) != 400 {
+			t.Fatalf("%s: expected 400, got %v", name, err)
+		}
+	}
+}
+
+func TestPrintLabelsRecordsResolvedTemplatePath(t *testing.T) {
+	store := newFakePrintJobStore()
+	store.templates = []LabelTemplateResponse{{TemplateID: 3, Width: 24, Type: BarcodeTypeCode128, Filename: "tpl-b.lw1"}}
+	svc := newTestService(store, nil)
+	svc.templateDir = "/srv/templates"
+
+	if _, err := svc.PrintLabels(context.Background(), PrintRequest{Label: printableLabel("OFS-1"), Width: 24, Type: BarcodeTypeCode128}); err != nil {
+		t.Fatalf("PrintLabels returned error: %v", err)
+	}
+	if got := store.created[0].TemplatePath; got != filepath.Join("/srv/templates", "tpl-b.lw1") {
+		t.Fatalf("expected registered template path recorded, got %q", got)
+	}
+}
+
+func TestPrintByManagementNumbersUsesTemplateFieldMapping(t *testing.T) {
+	store := newFakePrintJobStore()
+	store.templates = []LabelTemplateResponse{{
+		TemplateID:   1,
+		Width:        12,
+		Type:         BarcodeTypeQRCode,
+		Filename:     "tpl-c.lw1",
+		FieldMapping: map[string]string{"col_b": LabelFieldManagementNumber, "col_c": LabelFieldLocation, "col_d": "", "col_e": LabelFieldManagementNumber},
+	}}
+	svc := newTestService(store, nil)
+	svc.columns = map[string]string{"col_c": LabelFieldOwner}
+	svc.assets = &fakeAssetLookup{byMng: map[string]assets.AssetSetResponse{"OFS-1": assetSet("OFS-1", "Projector", "Office")}}
+
+	if _, err := svc.PrintByManagementNumbers(context.Background(), PrintByManagementNumberRequest{
+		ManagementNumbers: []string{"OFS-1"}, Width: 12, Type: BarcodeTypeQRCode,
+	}); err != nil {
+		t.Fatalf("PrintByManagementNumbers returned error: %v", err)
+	}
+	want := LabelData{Checked: true, ColB: "OFS-1", ColC: "B-201", ColD: "", ColE: "OFS-1"}
+	if got := store.created[0].Labels[0]; got != want {
+		t.Fatalf("expected template mapping applied, got %#v", got)
+	}
+}
+
+func TestUploadLabelTemplateStoresFileOutsideSourceTree(t *testing.T) {
+	store := newFakePrintJobStore()
+	svc := newTestService(store, nil)
+	svc.templateDir = filepath.Join(t.TempDir(), "label_templates")
+	model := " SR5900P "
+
+	res, err := svc.UploadLabelTemplate(context.Background(), UploadLabelTemplateRequest{
+		Name:         "asset-12mm",
+		Width:        12,
+		Type:         BarcodeTypeQRCode,
+		PrinterModel: &model,
+		FieldMapping: map[string]string{"col_c": LabelFieldLocation},
+	}, strings.NewReader("lw1 template body"))
+	if err != nil {
+		t.Fatalf("UploadLabelTemplate returned error: %v", err)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(svc.templateDir, res.Filename))
+	if err != nil || string(raw) != "lw1 template body" {
+		t.Fatalf("expected uploaded file saved, got %q, %v", raw, err)
+	}
+	if res.SizeBytes != int64(len(raw)) || len(res.SHA256) != 64 {
+		t.Fatalf("unexpected size/checksum %#v", res)
+	}
+	if res.PrinterModel == nil || *res.PrinterModel != "SR5900P" {
+		t.Fatalf("expected trimmed printer model, got %#v", res.PrinterModel)
+	}
+	if res.FieldMapping["col_c"] != LabelFieldLocation || res.FieldMapping["col_e"] != LabelFieldManagementNumber {
+		t.Fatalf("expected mapping filled with defaults, got %#v", res.FieldMapping)
+	}
+}
+
+func TestUploadLabelTemplateRejectsInvalidInput(t *testing.T) {
+	svc := newTestService(newFakePrintJobStore(), nil)
+	svc.templateDir = t.TempDir()
+
+	cases := map[string]struct {
+		req  UploadLabelTemplateRequest
+		body string
+	}{
+		"type":    {UploadLabelTemplateRequest{Name: "a", Width: 12, Type: "ean13"}, "x"},
+		"width":   {UploadLabelTemplateRequest{Name: "a", Width: 0, Type: BarcodeTypeQRCode}, "x"},
+		"mapping": {UploadLabelTemplateRequest{Name: "a", Width: 12, Type: BarcodeTypeQRCode, FieldMapping: map[string]string{"col_b": "price"}}, "x"},
+		"empty":   {UploadLabelTemplateRequest{Name: "a", Width: 12, Type: BarcodeTypeQRCode}, ""},
+		"size":    {UploadLabelTemplateRequest{Name: "a", Width: 12, Type: BarcodeTypeQRCode}, strings.Repeat("x", MaxTemplateBytes+1)},
+	}
+	for name, tc := range cases {
+		if _, err := svc.UploadLabelTemplate(context.Background(), tc.req, strings.NewReader(tc.body)); toHTTPStatus(err) != 400 {
+			t.Fatalf("%s: expected 400, got %v", name, err)
+		}
+	}
+	entries, _ := os.ReadDir(svc.templateDir)
+	if len(entries) != 0 {
+		t.Fatalf("expected rejected uploads removed, got %d files", len(entries))
+	}
+}
+
+func TestUploadLabelTemplateRequiresTemplateDir(t *testing.T) {
+	svc := newTestService(newFakePrintJobStore(), nil)
+	svc.templateDir = ""
+
+	req := UploadLabelTemplateRequest{Name: "a", Width: 12, Type: BarcodeTypeQRCode}
+	_, err := svc.UploadLabelTemplate(context.Background(), req, strings.NewReader("x"))
+	if toHTTPStatus(err) != 500 || !strings.Contains(err.Error(), "template_dir not configured") {
+		t.Fatalf("expected template_dir not configured, got %v", err)
+	}
+}
+
+func TestDeleteLabelTemplate(t *testing.T) {
+	store := newFakePrintJobStore()
+	svc := newTestService(store, nil)
+	svc.templateDir = t.TempDir()
+	path := filepath.Join(svc.templateDir, "tpl-d.lw1")
+	if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store.templates = []LabelTemplateResponse{{TemplateID: 4, Filename: "tpl-d.lw1"}}
+
+	store.templatesInUse = map[string]bool{path: true}
+	if err := svc.DeleteLabelTemplate(context.Background(), 4); toHTTPStatus(err) != 409 {
+		t.Fatalf("expected 409 while in use, got %v", err)
+	}
+
+	store.templatesInUse = nil
+	if err := svc.DeleteLabelTemplate(context.Background(), 4); err != nil {
+		t.Fatalf("DeleteLabelTemplate returned error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected template file removed, got %v", err)
+	}
+	if err := svc.DeleteLabelTemplate(context.Background(), 4); toHTTPStatus(err) != 404 {
+		t.Fatalf("expected 404 for deleted template, got %v", err)
+	}
+}
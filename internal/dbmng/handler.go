@@ -14,9 +14,33 @@ func RegisterRoutes(r gin.IRoutes, svc *Service) {
 	h := &Handler{svc: svc}
 	r.POST("/genres", h.CreateGenre)
 	r.GET("/genres", h.ListGenres)
+	r.GET("/genres/tree", h.ListGenreTree)
 	r.GET("/genres/:id", h.GetGenre)
 	r.PUT("/genres/:id", h.UpdateGenre)
 	r.DELETE("/genres/:id", h.DeleteGenre)
+	r.PUT("/genres/:id/parent", h.MoveGenre)
+	r.POST("/genres/:id/merge-into/:target", h.MergeGenre)
+
+	r.POST("/management-categories", h.CreateManagementCategory)
+	r.GET("/management-categories", h.ListManagementCategories)
+	r.GET("/management-categories/:id", h.GetManagementCategory)
+	r.PUT("/management-categories/:id", h.UpdateManagementCategory)
+	r.DELETE("/management-categories/:id", h.DeleteManagementCategory)
+
+	r.POST("/asset-statuses", h.CreateAssetStatus)
+	r.GET("/asset-statuses", h.ListAssetStatuses)
+	r.GET("/asset-statuses/:id", h.GetAssetStatus)
+	r.PUT("/asset-statuses/:id", h.UpdateAssetStatus)
+	r.DELETE("/asset-statuses/:id", h.DeleteAssetStatus)
+}
+
+func parseIDParam(c *gin.Context) (uint, bool) {
+	idU64, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || idU64 == 0 {
+		c.JSON(http.StatusBadRequest, ErrInvalid("invalid id"))
+		return 0, false
+	}
+	return uint(idU64), true
 }
 
 // @Summary      List genres
@@ -36,6 +60,23 @@ func (h *Handler) ListGenres(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// @Summary      List genres as a tree
+// @Description  Get asset genres nested by parent_id. Set 'all=1' to include disabled genres; genres whose parent is not listed appear at the top level.
+// @Tags         genres
+// @Produce      json
+// @Param        all query string false "Include disabled genres if '1', 'true', 'yes', or 'all'"
+// @Success      200 {array} GenreNode
+// @Failure      500 {object} APIError "Internal server error"
+// @Router       /genres/tree [get]
+func (h *Handler) ListGenreTree(c *gin.Context) {
+	resp, err := h.svc.ListGenreTree(c.Request.Context(), c.Query("all"))
+	if err != nil {
+		c.JSON(toHTTPStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
 // @Summary      Get a genre
 // @Description  Get details of an asset genre by its ID.
 // @Tags         genres
@@ -67,7 +108,7 @@ func (h *Handler) GetGenre(c *gin.Context) {
 // @Produce      json
 // @Param        request body CreateGenreRequest true "Genre details"
 // @Success      201 {object} AssetGenre
-// @Failure      400 {object} APIError "Invalid input"
+// @Failure      400 {object} APIError "Invalid input or parent genre not found"
 // @Failure      409 {object} APIError "Conflict, e.g., genre code already exists"
 // @Failure      500 {object} APIError "Internal server error"
 // @Router       /genres [post]
@@ -77,7 +118,7 @@ func (h *Handler) CreateGenre(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, ErrInvalid(err.Error()))
 		return
 	}
-	resp, err := h.svc.CreateGenre(c.Request.Context(), req.GenreName, req.GenreCode)
+	resp, err := h.svc.CreateGenre(c.Request.Context(), req.GenreName, req.GenreCode, req.ParentID)
 	if err != nil {
 		c.JSON(toHTTPStatus(err), err)
 		return
@@ -95,7 +136,7 @@ func (h *Handler) CreateGenre(c *gin.Context) {
 // @Success      200 {object} AssetGenre
 // @Failure      400 {object} APIError "Invalid input or ID"
 // @Failure      404 {object} APIError "Genre not found"
-// @Failure      409 {object} APIError "Conflict, e.g., genre code already exists"
+// @Failure      409 {object} APIError "Conflict, e.g., genre code already exists or disabling a genre with enabled children or asset masters"
 // @Failure      500 {object} APIError "Internal server error"
 // @Router       /genres/{id} [put]
 func (h *Handler) UpdateGenre(c *gin.Context) {
@@ -124,6 +165,7 @@ func (h *Handler) UpdateGenre(c *gin.Context) {
 // @Success      204 "No Content"
 // @Failure      400 {object} APIError "Invalid ID"
 // @Failure      404 {object} APIError "Genre not found"
+// @Failure      409 {object} APIError "Genre has enabled child genres or is still used by asset masters (merge it first)"
 // @Failure      500 {object} APIError "Internal server error"
 // @Router       /genres/{id} [delete]
 func (h *Handler) DeleteGenre(c *gin.Context) {
@@ -139,3 +181,309 @@ func (h *Handler) DeleteGenre(c *gin.Context) {
 	}
 	c.Status(http.StatusNoContent)
 }
+
+// @Summary      Move a genre
+// @Description  Moves a genre together with its descendants under another parent (parent_id null moves it to the top level). Management numbers keep the genre's own code.
+// @Tags         genres
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Genre ID"
+// @Param        request body MoveGenreRequest true "New parent"
+// @Success      200 {object} AssetGenre
+// @Failure      400 {object} APIError "Invalid input or parent genre not found"
+// @Failure      404 {object} APIError "Genre not found"
+// @Failure      409 {object} APIError "Parent is the genre itself or one of its descendants"
+// @Failure      500 {object} APIError "Internal server error"
+// @Router       /genres/{id}/parent [put]
+func (h *Handler) MoveGenre(c *gin.Context) {
+	idU64, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || idU64 == 0 {
+		c.JSON(http.StatusBadRequest, ErrInvalid("invalid id"))
+		return
+	}
+	var req MoveGenreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrInvalid(err.Error()))
+		return
+	}
+	resp, err := h.svc.MoveGenre(c.Request.Context(), uint(idU64), req.ParentID)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary      Merge a genre into another
+// @Description  Reassign every asset master and child genre of the genre to the target genre and disable it, in one transaction.
+// @Description  With renumber_management_numbers, management numbers starting with the source genre_code get the target's code; old numbers are kept as aliases.
+// @Tags         genres
+// @Accept       json
+// @Produce      json
+// @Param        id      path int true "Genre ID to merge (source)"
+// @Param        target  path int true "Genre ID to merge into"
+// @Param        request body MergeGenreRequest false "Merge options"
+// @Success      200 {object} GenreMergeResult
+// @Failure      400 {object} APIError "Invalid input or target genre not found"
+// @Failure      404 {object} APIError "Genre not found"
+// @Failure      409 {object} APIError "Target is a descendant of the genre, or a renumbered management number already exists"
+// @Failure      500 {object} APIError "Internal server error"
+// @Router       /genres/{id}/merge-into/{target} [post]
+func (h *Handler) MergeGenre(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	target, err := strconv.ParseUint(c.Param("target"), 10, 64)
+	if err != nil || target == 0 {
+		c.JSON(http.StatusBadRequest, ErrInvalid("invalid target"))
+		return
+	}
+	var req MergeGenreRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrInvalid(err.Error()))
+			return
+		}
+	}
+	resp, err := h.svc.MergeGenre(c.Request.Context(), id, uint(target), req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ===== management categories =====
+
+// @Summary      List management categories
+// @Description  Get management categories. Set 'all=1' to include disabled ones. is_lendable marks categories whose assets become "lent" while lends are outstanding.
+// @Tags         management-categories
+// @Produce      json
+// @Param        all query string false "Include disabled categories if '1', 'true', 'yes', or 'all'"
+// @Success      200 {array} ManagementCategory
+// @Failure      500 {object} APIError "Internal server error"
+// @Router       /management-categories [get]
+func (h *Handler) ListManagementCategories(c *gin.Context) {
+	resp, err := h.svc.ListManagementCategories(c.Request.Context(), c.Query("all"))
+	if err != nil {
+		c.JSON(toHTTPStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary      Get a management category
+// @Tags         management-categories
+// @Produce      json
+// @Param        id path int true "Management category ID"
+// @Success      200 {object} ManagementCategory
+// @Failure      400 {object} APIError "Invalid ID"
+// @Failure      404 {object} APIError "Management category not found"
+// @Failure      500 {object} APIError "Internal server error"
+// @Router       /management-categories/{id} [get]
+func (h *Handler) GetManagementCategory(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	resp, err := h.svc.GetManagementCategory(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary      Create a management category
+// @Tags         management-categories
+// @Accept       json
+// @Produce      json
+// @Param        request body CreateManagementCategoryRequest true "Management category"
+// @Success      201 {object} ManagementCategory
+// @Failure      400 {object} APIError "Invalid input"
+// @Failure      409 {object} APIError "Name already exists"
+// @Failure      500 {object} APIError "Internal server error"
+// @Router       /management-categories [post]
+func (h *Handler) CreateManagementCategory(c *gin.Context) {
+	var req CreateManagementCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrInvalid(err.Error()))
+		return
+	}
+	resp, err := h.svc.CreateManagementCategory(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// @Summary      Update a management category
+// @Tags         management-categories
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Management category ID"
+// @Param        request body UpdateManagementCategoryRequest true "Management category"
+// @Success      200 {object} ManagementCategory
+// @Failure      400 {object} APIError "Invalid input or ID"
+// @Failure      404 {object} APIError "Management category not found"
+// @Failure      409 {object} APIError "Name already exists, or disabling a category used by assets"
+// @Failure      500 {object} APIError "Internal server error"
+// @Router       /management-categories/{id} [put]
+func (h *Handler) UpdateManagementCategory(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	var req UpdateManagementCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrInvalid(err.Error()))
+		return
+	}
+	resp, err := h.svc.UpdateManagementCategory(c.Request.Context(), id, req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary      Delete a management category
+// @Description  Soft deletes (disables) a management category that no asset uses.
+// @Tags         management-categories
+// @Param        id path int true "Management category ID"
+// @Success      204 "No Content"
+// @Failure      400 {object} APIError "Invalid ID"
+// @Failure      404 {object} APIError "Management category not found"
+// @Failure      409 {object} APIError "Used by assets"
+// @Failure      500 {object} APIError "Internal server error"
+// @Router       /management-categories/{id} [delete]
+func (h *Handler) DeleteManagementCategory(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.DeleteManagementCategory(c.Request.Context(), id); err != nil {
+		c.JSON(toHTTPStatus(err), err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ===== asset statuses =====
+
+// @Summary      List asset statuses
+// @Description  Get asset statuses. Set 'all=1' to include disabled ones. system_role (normal / lent / zero_stock) marks the status assigned automatically from stock and lends.
+// @Tags         asset-statuses
+// @Produce      json
+// @Param        all query string false "Include disabled statuses if '1', 'true', 'yes', or 'all'"
+// @Success      200 {array} AssetStatus
+// @Failure      500 {object} APIError "Internal server error"
+// @Router       /asset-statuses [get]
+func (h *Handler) ListAssetStatuses(c *gin.Context) {
+	resp, err := h.svc.ListAssetStatuses(c.Request.Context(), c.Query("all"))
+	if err != nil {
+		c.JSON(toHTTPStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary      Get an asset status
+// @Tags         asset-statuses
+// @Produce      json
+// @Param        id path int true "Asset status ID"
+// @Success      200 {object} AssetStatus
+// @Failure      400 {object} APIError "Invalid ID"
+// @Failure      404 {object} APIError "Asset status not found"
+// @Failure      500 {object} APIError "Internal server error"
+// @Router       /asset-statuses/{id} [get]
+func (h *Handler) GetAssetStatus(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	resp, err := h.svc.GetAssetStatus(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary      Create an asset status
+// @Tags         asset-statuses
+// @Accept       json
+// @Produce      json
+// @Param        request body CreateAssetStatusRequest true "Asset status"
+// @Success      201 {object} AssetStatus
+// @Failure      400 {object} APIError "Invalid input"
+// @Failure      409 {object} APIError "Name or system_role already exists"
+// @Failure      500 {object} APIError "Internal server error"
+// @Router       /asset-statuses [post]
+func (h *Handler) CreateAssetStatus(c *gin.Context) {
+	var req CreateAssetStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrInvalid(err.Error()))
+		return
+	}
+	resp, err := h.svc.CreateAssetStatus(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// @Summary      Update an asset status
+// @Tags         asset-statuses
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Asset status ID"
+// @Param        request body UpdateAssetStatusRequest true "Asset status"
+// @Success      200 {object} AssetStatus
+// @Failure      400 {object} APIError "Invalid input or ID"
+// @Failure      404 {object} APIError "Asset status not found"
+// @Failure      409 {object} APIError "Name or system_role already exists, or disabling a status that is in use or has a system_role"
+// @Failure      500 {object} APIError "Internal server error"
+// @Router       /asset-statuses/{id} [put]
+func (h *Handler) UpdateAssetStatus(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	var req UpdateAssetStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrInvalid(err.Error()))
+		return
+	}
+	resp, err := h.svc.UpdateAssetStatus(c.Request.Context(), id, req)
+	if err != nil {
+		c.JSON(toHTTPStatus(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary      Delete an asset status
+// @Description  Soft deletes (disables) an asset status that has no system_role and no asset uses.
+// @Tags         asset-statuses
+// @Param        id path int true "Asset status ID"
+// @Success      204 "No Content"
+// @Failure      400 {object} APIError "Invalid ID"
+// @Failure      404 {object} APIError "Asset status not found"
+// @Failure      409 {object} APIError "Used by assets or has a system_role"
+// @Failure      500 {object} APIError "Internal server error"
+// @Router       /asset-statuses/{id} [delete]
+func (h *Handler) DeleteAssetStatus(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.DeleteAssetStatus(c.Request.Context(), id); err != nil {
+		c.JSON(toHTTPStatus(err), err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -3,6 +3,7 @@ package dbmng
 type CreateGenreRequest struct {
 	GenreName string `json:"name" binding:"required"`
 	GenreCode string `json:"code" binding:"required"`
+	ParentID  *uint  `json:"parent_id,omitempty"` // 未指定ならトップレベル
 }
 
 type UpdateGenreRequest struct {
@@ -11,9 +12,84 @@ type UpdateGenreRequest struct {
 	IsDisabled bool   `json:"is_disabled"`
 }
 
+// MoveGenreRequest: PUT /genres/:id/parent（parent_id が null ならトップレベルへ）
+type MoveGenreRequest struct {
+	ParentID *uint `json:"parent_id"`
+}
+
 type AssetGenre struct {
 	GenreID    uint   `gorm:"primaryKey;column:genre_id" json:"id"`
 	GenreName  string `gorm:"column:genre_name"          json:"name"`
 	GenreCode  string `gorm:"column:genre_code"          json:"code"`
+	ParentID   *uint  `gorm:"column:parent_genre_id"     json:"parent_id"`
 	IsDisabled bool   `gorm:"column:is_disabled"         json:"is_disabled"`
+	UsageCount int    `gorm:"-"                          json:"usage_count"` // このジャンルを付けた assets_master の件数
+}
+
+// MergeGenreRequest: POST /genres/:id/merge-into/:target（本文は省略可）
+type MergeGenreRequest struct {
+	// 統合元の genre_code で始まる管理番号を統合先のコードに付け替える（旧番号は別名として残る）
+	RenumberManagementNumbers bool `json:"renumber_management_numbers"`
+}
+
+type GenreMergeResult struct {
+	SourceID          uint        `json:"source_id"`
+	TargetID          uint        `json:"target_id"`
+	ReassignedMasters int         `json:"reassigned_masters"`
+	RenumberedMasters int         `json:"renumbered_masters"`
+	MovedChildGenres  int         `json:"moved_child_genres"`
+	Target            *AssetGenre `json:"target,omitempty"`
+}
+
+// GenreNode: GET /genres/tree の 1 ノード
+type GenreNode struct {
+	AssetGenre
+	Children []GenreNode `json:"children"`
+}
+
+// ===== management categories =====
+
+type CreateManagementCategoryRequest struct {
+	Name       string `json:"name" binding:"required"`
+	IsLendable bool   `json:"is_lendable"` // 貸出中の数量があれば状態を「貸出中」にする
+}
+
+type UpdateManagementCategoryRequest struct {
+	Name       string `json:"name" binding:"required"`
+	IsLendable bool   `json:"is_lendable"`
+	IsDisabled bool   `json:"is_disabled"`
+}
+
+type ManagementCategory struct {
+	ManagementCategoryID uint   `json:"id"`
+	Name                 string `json:"name"`
+	IsLendable           bool   `json:"is_lendable"`
+	IsDisabled           bool   `json:"is_disabled"`
+}
+
+// ===== asset statuses =====
+
+type CreateAssetStatusRequest struct {
+	Name              string  `json:"name" binding:"required"`
+	CountsAsAvailable bool    `json:"counts_as_available"`   // この状態の数量を在庫（貸出可能数の母数）に数える
+	IsTerminal        bool    `json:"is_terminal"`           // 廃棄・紛失など。自動で別の状態へ移さない
+	SystemRole        *string `json:"system_role,omitempty"` // normal / lent / zero_stock（各 1 つまで）
+}
+
+// UpdateAssetStatusRequest は全項目の置き換え（system_role 未指定・空文字は役割なし）
+type UpdateAssetStatusRequest struct {
+	Name              string  `json:"name" binding:"required"`
+	CountsAsAvailable bool    `json:"counts_as_available"`
+	IsTerminal        bool    `json:"is_terminal"`
+	SystemRole        *string `json:"system_role,omitempty"`
+	IsDisabled        bool    `json:"is_disabled"`
+}
+
+type AssetStatus struct {
+	StatusID          uint    `json:"id"`
+	Name              string  `json:"name"`
+	CountsAsAvailable bool    `json:"counts_as_available"`
+	IsTerminal        bool    `json:"is_terminal"`
+	SystemRole        *string `json:"system_role,omitempty"`
+	IsDisabled        bool    `json:"is_disabled"`
 }
@@ -6,6 +6,8 @@ import (
 	"errors"
 	mysql "github.com/go-sql-driver/mysql"
 	"strings"
+
+	"IRIS-backend/internal/asset_mgmt/inventory"
 )
 
 
@@ -65,7 +67,7 @@ func (s *Service) GetGenre(ctx context.Context, id uint) (*AssetGenre, error) {
 	return ag, nil
 }
 
-func (s *Service) CreateGenre(ctx context.Context, name string, code string) (*AssetGenre, error) {
+func (s *Service) CreateGenre(ctx context.Context, name string, code string, parentID *uint) (*AssetGenre, error) {
 	n, err := normalizeGenreName(name)
 	if err != nil {
 		return nil, err
@@ -75,8 +77,11 @@ func (s *Service) CreateGenre(ctx context.Context, name string, code string) (*A
 		return nil, err
 	}
 
-	ag, err := s.store.CreateGenre(ctx, n, c)
+	ag, err := s.store.CreateGenre(ctx, n, c, parentID)
 	if err != nil {
+		if errors.Is(err, errParentNotFound) {
+			return nil, ErrInvalid(err.Error())
+		}
 		if isDuplicateKey(err) {
 			return nil, ErrConflict("genre_code already exists")
 		}
@@ -103,6 +108,9 @@ func (s *Service) UpdateGenre(ctx context.Context, id uint, name string, code st
 		if isDuplicateKey(err) {
 			return nil, ErrConflict("genre_code already exists")
 		}
+		if errors.Is(err, errHasEnabledChildren) || errors.Is(err, errGenreInUse) {
+			return nil, ErrConflict(err.Error())
+		}
 		return nil, ErrInternal("failed to update genre")
 	}
 	return s.GetGenre(ctx, id)
@@ -114,7 +122,222 @@ func (s *Service) DeleteGenre(ctx context.Context, id uint) error {
 		if errors.Is(err, sql.ErrNoRows) {
 			return ErrNotFound("genre not found")
 		}
+		if errors.Is(err, errHasEnabledChildren) || errors.Is(err, errGenreInUse) {
+			return ErrConflict(err.Error())
+		}
 		return ErrInternal("failed to delete genre")
 	}
 	return nil
 }
+
+// ListGenreTree はジャンルを親子の木で返す。親が一覧に無い（無効化された）ジャンルはトップレベルに置く
+func (s *Service) ListGenreTree(ctx context.Context, all string) ([]GenreNode, error) {
+	genres, err := s.store.ListGenres(ctx, parseBoolish(all))
+	if err != nil {
+		return nil, ErrInternal("failed to list genres")
+	}
+	return buildGenreTree(genres), nil
+}
+
+func buildGenreTree(genres []AssetGenre) []GenreNode {
+	present := make(map[uint]bool, len(genres))
+	for _, g := range genres {
+		present[g.GenreID] = true
+	}
+	children := map[uint][]AssetGenre{}
+	var roots []AssetGenre
+	for _, g := range genres {
+		if g.ParentID != nil && present[*g.ParentID] {
+			children[*g.ParentID] = append(children[*g.ParentID], g)
+			continue
+		}
+		roots = append(roots, g)
+	}
+
+	var build func(gs []AssetGenre) []GenreNode
+	build = func(gs []AssetGenre) []GenreNode {
+		out := make([]GenreNode, 0, len(gs))
+		for _, g := range gs {
+			out = append(out, GenreNode{AssetGenre: g, Children: build(children[g.GenreID])})
+		}
+		return out
+	}
+	return build(roots)
+}
+
+// MoveGenre はジャンルを子孫ごと別の親の下（parentID が nil ならトップレベル）へ移す。
+// 管理番号の接頭辞は各備品に付いたジャンル自身の genre_code のままで、移動の影響を受けない
+func (s *Service) MoveGenre(ctx context.Context, id uint, parentID *uint) (*AssetGenre, error) {
+	if err := s.store.MoveGenre(ctx, id, parentID); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound("genre not found")
+		case errors.Is(err, errParentNotFound):
+			return nil, ErrInvalid(err.Error())
+		case errors.Is(err, errGenreCycle):
+			return nil, ErrConflict(err.Error())
+		}
+		return nil, ErrInternal("failed to move genre")
+	}
+	return s.GetGenre(ctx, id)
+}
+
+// MergeGenre は id のジャンルを target へ統合する（備品・子ジャンルを付け替えて id を無効化）
+func (s *Service) MergeGenre(ctx context.Context, id, target uint, req MergeGenreRequest) (*GenreMergeResult, error) {
+	if id == target {
+		return nil, ErrInvalid("cannot merge a genre into itself")
+	}
+	res, err := s.store.MergeGenre(ctx, id, target, req.RenumberManagementNumbers)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound("genre not found")
+		case errors.Is(err, errMergeTarget):
+			return nil, ErrInvalid(err.Error())
+		case errors.Is(err, errGenreCycle):
+			return nil, ErrConflict("cannot merge a genre into one of its descendants")
+		case errors.Is(err, errRenumberConflict), isDuplicateKey(err):
+			return nil, ErrConflict(errRenumberConflict.Error())
+		}
+		return nil, ErrInternal("failed to merge genre")
+	}
+	if res.Target, err = s.GetGenre(ctx, target); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ===== management categories =====
+
+func mapMasterDataError(err error, notFound, conflict, fallback string) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound(notFound)
+	case errors.Is(err, errInUse), errors.Is(err, errStatusHasRole):
+		return ErrConflict(err.Error())
+	case isDuplicateKey(err):
+		return ErrConflict(conflict)
+	}
+	return ErrInternal(fallback)
+}
+
+func (s *Service) ListManagementCategories(ctx context.Context, all string) ([]ManagementCategory, error) {
+	res, err := s.store.ListManagementCategories(ctx, parseBoolish(all))
+	if err != nil {
+		return nil, ErrInternal("failed to list management categories")
+	}
+	return res, nil
+}
+
+func (s *Service) GetManagementCategory(ctx context.Context, id uint) (*ManagementCategory, error) {
+	mc, err := s.store.GetManagementCategoryByID(ctx, id)
+	if err != nil {
+		return nil, mapMasterDataError(err, "management category not found", "", "failed to get management category")
+	}
+	return mc, nil
+}
+
+func (s *Service) CreateManagementCategory(ctx context.Context, req CreateManagementCategoryRequest) (*ManagementCategory, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, ErrInvalid("name is required")
+	}
+	mc, err := s.store.CreateManagementCategory(ctx, name, req.IsLendable)
+	if err != nil {
+		return nil, mapMasterDataError(err, "", "management category name already exists", "failed to create management category")
+	}
+	return mc, nil
+}
+
+func (s *Service) UpdateManagementCategory(ctx context.Context, id uint, req UpdateManagementCategoryRequest) (*ManagementCategory, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, ErrInvalid("name is required")
+	}
+	if err := s.store.UpdateManagementCategory(ctx, id, name, req.IsLendable, req.IsDisabled); err != nil {
+		return nil, mapMasterDataError(err, "management category not found", "management category name already exists", "failed to update management category")
+	}
+	return s.GetManagementCategory(ctx, id)
+}
+
+func (s *Service) DeleteManagementCategory(ctx context.Context, id uint) error {
+	if err := s.store.DisableManagementCategory(ctx, id); err != nil {
+		return mapMasterDataError(err, "management category not found", "", "failed to delete management category")
+	}
+	return nil
+}
+
+// ===== asset statuses =====
+
+// normalizeAssetStatus 役割付きの状態は在庫に数え、終端（自動で移さない）にはできない
+func normalizeAssetStatus(name string, countsAsAvailable, isTerminal bool, role *string) (assetStatusInput, error) {
+	in := assetStatusInput{
+		Name:              strings.TrimSpace(name),
+		CountsAsAvailable: countsAsAvailable,
+		IsTerminal:        isTerminal,
+	}
+	if in.Name == "" {
+		return in, ErrInvalid("name is required")
+	}
+	if role == nil || strings.TrimSpace(*role) == "" {
+		return in, nil
+	}
+	r := strings.TrimSpace(*role)
+	switch r {
+	case inventory.StatusRoleNormal, inventory.StatusRoleLent, inventory.StatusRoleZeroStock:
+	default:
+		return in, ErrInvalid("system_role must be normal, lent or zero_stock")
+	}
+	if isTerminal || !countsAsAvailable {
+		return in, ErrInvalid("a status with system_role must count as available and not be terminal")
+	}
+	in.SystemRole = &r
+	return in, nil
+}
+
+func (s *Service) ListAssetStatuses(ctx context.Context, all string) ([]AssetStatus, error) {
+	res, err := s.store.ListAssetStatuses(ctx, parseBoolish(all))
+	if err != nil {
+		return nil, ErrInternal("failed to list asset statuses")
+	}
+	return res, nil
+}
+
+func (s *Service) GetAssetStatus(ctx context.Context, id uint) (*AssetStatus, error) {
+	st, err := s.store.GetAssetStatusByID(ctx, id)
+	if err != nil {
+		return nil, mapMasterDataError(err, "asset status not found", "", "failed to get asset status")
+	}
+	return st, nil
+}
+
+func (s *Service) CreateAssetStatus(ctx context.Context, req CreateAssetStatusRequest) (*AssetStatus, error) {
+	in, err := normalizeAssetStatus(req.Name, req.CountsAsAvailable, req.IsTerminal, req.SystemRole)
+	if err != nil {
+		return nil, err
+	}
+	st, err := s.store.CreateAssetStatus(ctx, in)
+	if err != nil {
+		return nil, mapMasterDataError(err, "", "asset status name or system_role already exists", "failed to create asset status")
+	}
+	return st, nil
+}
+
+func (s *Service) UpdateAssetStatus(ctx context.Context, id uint, req UpdateAssetStatusRequest) (*AssetStatus, error) {
+	in, err := normalizeAssetStatus(req.Name, req.CountsAsAvailable, req.IsTerminal, req.SystemRole)
+	if err != nil {
+		return nil, err
+	}
+	in.IsDisabled = req.IsDisabled
+	if err := s.store.UpdateAssetStatus(ctx, id, in); err != nil {
+		return nil, mapMasterDataError(err, "asset status not found", "asset status name or system_role already exists", "failed to update asset status")
+	}
+	return s.GetAssetStatus(ctx, id)
+}
+
+func (s *Service) DeleteAssetStatus(ctx context.Context, id uint) error {
+	if err := s.store.DisableAssetStatus(ctx, id); err != nil {
+		return mapMasterDataError(err, "asset status not found", "", "failed to delete asset status")
+	}
+	return nil
+}
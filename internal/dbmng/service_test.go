@@ -0,0 +1,91 @@
+package dbmng
+
+import (
+	"context"
+	"testing"
+
+	"IRIS-backend/internal/asset_mgmt/inventory"
+)
+
+func uintPtr(v uint) *uint { return &v }
+
+func TestBuildGenreTree(t *testing.T) {
+	genres := []AssetGenre{
+		{GenreID: 1, GenreName: "情報機器", GenreCode: "IT"},
+		{GenreID: 2, GenreName: "PC", GenreCode: "PC", ParentID: uintPtr(1)},
+		{GenreID: 3, GenreName: "ノートPC", GenreCode: "NPC", ParentID: uintPtr(2)},
+		{GenreID: 4, GenreName: "事務用品", GenreCode: "OFS"},
+		// 親が一覧に無い（無効化された）ジャンル
+		{GenreID: 5, GenreName: "周辺機器", GenreCode: "PER", ParentID: uintPtr(9)},
+	}
+
+	tree := buildGenreTree(genres)
+	if len(tree) != 3 || tree[0].GenreID != 1 || tree[1].GenreID != 4 || tree[2].GenreID != 5 {
+		t.Fatalf("unexpected roots %#v", tree)
+	}
+	pc := tree[0].Children
+	if len(pc) != 1 || pc[0].GenreID != 2 || len(pc[0].Children) != 1 || pc[0].Children[0].GenreCode != "NPC" {
+		t.Fatalf("unexpected subtree %#v", pc)
+	}
+	if tree[1].Children == nil || len(tree[1].Children) != 0 {
+		t.Fatalf("expected empty children slice for leaf, got %#v", tree[1].Children)
+	}
+}
+
+func TestIsSelfOrDescendant(t *testing.T) {
+	parents := map[uint]*uint{1: nil, 2: uintPtr(1), 3: uintPtr(2), 4: nil}
+
+	cases := []struct {
+		id, target uint
+		want       bool
+	}{
+		{1, 3, true},  // 孫の下へは移せない
+		{2, 2, true},  // 自分自身
+		{3, 1, false}, // 祖先の下へは移せる
+		{2, 4, false}, // 別の木
+	}
+	for _, tc := range cases {
+		if got := isSelfOrDescendant(parents, tc.id, tc.target); got != tc.want {
+			t.Fatalf("isSelfOrDescendant(%d, %d) = %v, want %v", tc.id, tc.target, got, tc.want)
+		}
+	}
+}
+
+func TestNormalizeAssetStatus(t *testing.T) {
+	role := " lent "
+	in, err := normalizeAssetStatus(" 貸出中 ", true, false, &role)
+	if err != nil || in.Name != "貸出中" || in.SystemRole == nil || *in.SystemRole != inventory.StatusRoleLent {
+		t.Fatalf("unexpected %#v, %v", in, err)
+	}
+
+	empty := ""
+	if in, err := normalizeAssetStatus("修理中", false, false, &empty); err != nil || in.SystemRole != nil {
+		t.Fatalf("expected no role, got %#v, %v", in, err)
+	}
+
+	bad := "broken"
+	normal := inventory.StatusRoleNormal
+	cases := map[string]struct {
+		name      string
+		available bool
+		terminal  bool
+		role      *string
+	}{
+		"name":        {"  ", true, false, nil},
+		"role":        {"x", true, false, &bad},
+		"terminal":    {"x", true, true, &normal},
+		"unavailable": {"x", false, false, &normal},
+	}
+	for name, tc := range cases {
+		if _, err := normalizeAssetStatus(tc.name, tc.available, tc.terminal, tc.role); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestMergeGenreRejectsSelf(t *testing.T) {
+	svc := &Service{}
+	if _, err := svc.MergeGenre(context.Background(), 3, 3, MergeGenreRequest{}); toHTTPStatus(err) != 400 {
+		t.Fatalf("expected 400 when merging into itself, got %v", err)
+	}
+}
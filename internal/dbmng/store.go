@@ -4,23 +4,60 @@ package dbmng
 import (
 	"context"
 	"database/sql"
+	"errors"
+
+	platformdb "IRIS-backend/internal/platform/db"
+)
+
+var (
+	errParentNotFound     = errors.New("parent genre not found or disabled")
+	errGenreCycle         = errors.New("genre cannot be moved under itself or its descendants")
+	errHasEnabledChildren = errors.New("genre has enabled child genres")
+	errInUse              = errors.New("still used by assets")
+	errStatusHasRole      = errors.New("status with a system_role cannot be disabled")
+	errGenreInUse         = errors.New("genre is still used by asset masters; merge it into another genre first")
+	errMergeTarget        = errors.New("merge target genre not found or disabled")
+	errRenumberConflict   = errors.New("renumbered management number already exists")
 )
 
 type Store struct{ db *sql.DB }
 
 func NewStore(db *sql.DB) *Store { return &Store{db: db} }
 
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// usage_count はジャンルを直接付けた assets_master の件数（子孫ジャンルの分は含まない）
+const genreSelect = `
+		SELECT g.genre_id, g.genre_name, g.genre_code, g.parent_genre_id, g.is_disabled,
+			(SELECT COUNT(*) FROM assets_master m WHERE m.genre_id = g.genre_id) AS usage_count
+		FROM asset_genres g
+	`
+
+func scanGenre(row rowScanner) (*AssetGenre, error) {
+	var (
+		ag     AssetGenre
+		parent sql.NullInt64
+	)
+	if err := row.Scan(&ag.GenreID, &ag.GenreName, &ag.GenreCode, &parent, &ag.IsDisabled, &ag.UsageCount); err != nil {
+		return nil, err
+	}
+	if parent.Valid {
+		p := uint(parent.Int64)
+		ag.ParentID = &p
+	}
+	return &ag, nil
+}
+
 // GET /genres?all=1
 func (s *Store) ListGenres(ctx context.Context, includeDisabled bool) ([]AssetGenre, error) {
-	q := `
-		SELECT genre_id, genre_name, genre_code, is_disabled
-		FROM asset_genres
-	`
+	q := genreSelect
 	var args []any
 	if !includeDisabled {
-		q += ` WHERE is_disabled = 0`
+		q += ` WHERE g.is_disabled = 0`
 	}
-	q += ` ORDER BY genre_id`
+	q += ` ORDER BY g.genre_id`
 
 	rows, err := s.db.QueryContext(ctx, q, args...)
 	if err != nil {
@@ -30,11 +67,11 @@ func (s *Store) ListGenres(ctx context.Context, includeDisabled bool) ([]AssetGe
 
 	res := make([]AssetGenre, 0, 16)
 	for rows.Next() {
-		var ag AssetGenre
-		if err := rows.Scan(&ag.GenreID, &ag.GenreName, &ag.GenreCode, &ag.IsDisabled); err != nil {
+		ag, err := scanGenre(rows)
+		if err != nil {
 			return nil, err
 		}
-		res = append(res, ag)
+		res = append(res, *ag)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -43,29 +80,28 @@ func (s *Store) ListGenres(ctx context.Context, includeDisabled bool) ([]AssetGe
 }
 
 func (s *Store) GetGenreByID(ctx context.Context, id uint) (*AssetGenre, error) {
-	const q = `
-		SELECT genre_id, genre_name, genre_code, is_disabled
-		FROM asset_genres
-		WHERE genre_id = ?
-	`
-	var ag AssetGenre
-	err := s.db.QueryRowContext(ctx, q, id).Scan(&ag.GenreID, &ag.GenreName, &ag.GenreCode, &ag.IsDisabled)
-	if err != nil {
-		return nil, err
-	}
-	return &ag, nil
+	return scanGenre(s.db.QueryRowContext(ctx, genreSelect+` WHERE g.genre_id = ?`, id))
 }
 
-func (s *Store) CreateGenre(ctx context.Context, name string, code string) (*AssetGenre, error) {
+func (s *Store) CreateGenre(ctx context.Context, name string, code string, parentID *uint) (*AssetGenre, error) {
 	const q = `
-		INSERT INTO asset_genres (genre_name, genre_code, is_disabled)
-		VALUES (?, ?, 0)
+		INSERT INTO asset_genres (genre_name, genre_code, parent_genre_id, is_disabled)
+		VALUES (?, ?, ?, 0)
 	`
-	r, err := s.db.ExecContext(ctx, q, name, code)
-	if err != nil {
-		return nil, err
-	}
-	lastID, err := r.LastInsertId()
+	var lastID int64
+	err := platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		if parentID != nil {
+			if err := ensureEnabledParent(ctx, tx, *parentID); err != nil {
+				return err
+			}
+		}
+		r, err := tx.ExecContext(ctx, q, name, code, parentID)
+		if err != nil {
+			return err
+		}
+		lastID, err = r.LastInsertId()
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -73,47 +109,493 @@ func (s *Store) CreateGenre(ctx context.Context, name string, code string) (*Ass
 		GenreID:    uint(lastID),
 		GenreName:  name,
 		GenreCode:  code,
+		ParentID:   parentID,
 		IsDisabled: false,
 	}, nil
 }
 
+// ensureEnabledParent 親にするジャンルが存在し有効であること（行ロックして移動・無効化と競合させない）
+func ensureEnabledParent(ctx context.Context, tx platformdb.DBTX, parentID uint) error {
+	var disabled bool
+	err := tx.QueryRowContext(ctx, `SELECT is_disabled FROM asset_genres WHERE genre_id = ? FOR UPDATE`, parentID).Scan(&disabled)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && disabled) {
+		return errParentNotFound
+	}
+	return err
+}
+
+// ensureNoEnabledChildren 有効な子ジャンルが残っていれば無効化させない
+func ensureNoEnabledChildren(ctx context.Context, tx platformdb.DBTX, id uint) error {
+	var exists bool
+	if err := tx.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM asset_genres WHERE parent_genre_id = ? AND is_disabled = 0)`, id,
+	).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return errHasEnabledChildren
+	}
+	return nil
+}
+
+// ensureGenreUnused 備品が付いたままのジャンルは無効化させない（先に統合する）
+func ensureGenreUnused(ctx context.Context, tx platformdb.DBTX, id uint) error {
+	err := ensureUnused(ctx, tx, `SELECT EXISTS(SELECT 1 FROM assets_master WHERE genre_id = ?)`, id)
+	if errors.Is(err, errInUse) {
+		return errGenreInUse
+	}
+	return err
+}
+
 func (s *Store) UpdateGenre(ctx context.Context, id uint, name string, code string, disabled bool) error {
 	const q = `
 		UPDATE asset_genres
 		SET genre_name = ?, genre_code = ?, is_disabled = ?
 		WHERE genre_id = ?
 	`
-	r, err := s.db.ExecContext(ctx, q, name, code, disabled, id)
+	return platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		if disabled {
+			if err := ensureNoEnabledChildren(ctx, tx, id); err != nil {
+				return err
+			}
+			if err := ensureGenreUnused(ctx, tx, id); err != nil {
+				return err
+			}
+		}
+		r, err := tx.ExecContext(ctx, q, name, code, disabled, id)
+		if err != nil {
+			return err
+		}
+		aff, err := r.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if aff == 0 {
+			// 値が変わらない UPDATE も 0 件になるため存在を確かめる
+			return existsGenre(ctx, tx, id)
+		}
+		return nil
+	})
+}
+
+func existsGenre(ctx context.Context, tx platformdb.DBTX, id uint) error {
+	var one int
+	return tx.QueryRowContext(ctx, `SELECT 1 FROM asset_genres WHERE genre_id = ?`, id).Scan(&one)
+}
+
+// DELETE: is_disabled=1 にする
+func (s *Store) DisableGenre(ctx context.Context, id uint) error {
+	const q = `
+		UPDATE asset_genres
+		SET is_disabled = 1
+		WHERE genre_id = ?
+	`
+	return platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		if err := ensureNoEnabledChildren(ctx, tx, id); err != nil {
+			return err
+		}
+		if err := ensureGenreUnused(ctx, tx, id); err != nil {
+			return err
+		}
+		r, err := tx.ExecContext(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		aff, err := r.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if aff == 0 {
+			return existsGenre(ctx, tx, id)
+		}
+		return nil
+	})
+}
+
+// MoveGenre はジャンルを子孫ごと parentID の下へ移す（nil ならトップレベル）。
+// 自分自身や自分の子孫の下へは移せない
+func (s *Store) MoveGenre(ctx context.Context, id uint, parentID *uint) error {
+	return platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		parents, err := lockGenreParents(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := parents[id]; !ok {
+			return sql.ErrNoRows
+		}
+		if parentID != nil {
+			if err := ensureEnabledParent(ctx, tx, *parentID); err != nil {
+				return err
+			}
+			if isSelfOrDescendant(parents, id, *parentID) {
+				return errGenreCycle
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE asset_genres SET parent_genre_id = ? WHERE genre_id = ?`, parentID, id)
+		return err
+	})
+}
+
+// lockGenreParents 木全体をロックして親子関係を読む（同時に行われる移動・統合で循環ができないようにする）
+func lockGenreParents(ctx context.Context, tx platformdb.DBTX) (map[uint]*uint, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT genre_id, parent_genre_id FROM asset_genres FOR UPDATE`)
 	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	parents := map[uint]*uint{}
+	for rows.Next() {
+		var (
+			gid    uint
+			parent sql.NullInt64
+		)
+		if err := rows.Scan(&gid, &parent); err != nil {
+			return nil, err
+		}
+		if parent.Valid {
+			p := uint(parent.Int64)
+			parents[gid] = &p
+		} else {
+			parents[gid] = nil
+		}
+	}
+	return parents, rows.Err()
+}
+
+// MergeGenre は id のジャンルを target へ統合する。1 トランザクションで
+// assets_master のジャンルを付け替え、子ジャンルを target の下へ移し、id を無効化する。
+// renumber なら id の genre_code で始まる管理番号を target の genre_code に付け替え、旧番号を別名として残す
+func (s *Store) MergeGenre(ctx context.Context, id, target uint, renumber bool) (GenreMergeResult, error) {
+	out := GenreMergeResult{SourceID: id, TargetID: target}
+	err := platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		parents, err := lockGenreParents(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if _, ok := parents[id]; !ok {
+			return sql.ErrNoRows
+		}
+		var (
+			targetCode     string
+			targetDisabled bool
+		)
+		err = tx.QueryRowContext(ctx, `SELECT genre_code, is_disabled FROM asset_genres WHERE genre_id = ?`, target).Scan(&targetCode, &targetDisabled)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && targetDisabled) {
+			return errMergeTarget
+		}
+		if err != nil {
+			return err
+		}
+		// 子ジャンルを target の下へ移すため、target が id の子孫だと循環する
+		if isSelfOrDescendant(parents, id, target) {
+			return errGenreCycle
+		}
+
+		if renumber {
+			var sourceCode string
+			if err := tx.QueryRowContext(ctx, `SELECT genre_code FROM asset_genres WHERE genre_id = ?`, id).Scan(&sourceCode); err != nil {
+				return err
+			}
+			// LIKE だとコード中の _ や % がワイルドカードになるため先頭一致で比べる
+			const prefixed = `genre_id = ? AND LEFT(management_number, CHAR_LENGTH(?) + 1) = CONCAT(?, '-')`
+			const renumbered = `SELECT CONCAT(?, SUBSTRING(management_number, CHAR_LENGTH(?) + 1)) FROM assets_master WHERE ` + prefixed
+			// 新しい番号は現行の番号にも別名にも使われていてはいけない（NextManagementNumberTx と同じ）
+			var taken bool
+			if err := tx.QueryRowContext(ctx, `
+				SELECT EXISTS(SELECT 1 FROM assets_master WHERE management_number IN (`+renumbered+`))
+				    OR EXISTS(SELECT 1 FROM asset_management_number_aliases WHERE management_number IN (`+renumbered+`))`,
+				targetCode, sourceCode, id, sourceCode, sourceCode,
+				targetCode, sourceCode, id, sourceCode, sourceCode,
+			).Scan(&taken); err != nil {
+				return err
+			}
+			if taken {
+				return errRenumberConflict
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO asset_management_number_aliases (management_number, asset_master_id, created_at)
+				SELECT management_number, asset_master_id, UTC_TIMESTAMP()
+				FROM assets_master
+				WHERE `+prefixed, id, sourceCode, sourceCode); err != nil {
+				return err
+			}
+			r, err := tx.ExecContext(ctx, `
+				UPDATE assets_master
+				SET management_number = CONCAT(?, SUBSTRING(management_number, CHAR_LENGTH(?) + 1))
+				WHERE `+prefixed, targetCode, sourceCode, id, sourceCode, sourceCode)
+			if err != nil {
+				return err
+			}
+			n, err := r.RowsAffected()
+			if err != nil {
+				return err
+			}
+			out.RenumberedMasters = int(n)
+		}
+
+		r, err := tx.ExecContext(ctx, `UPDATE assets_master SET genre_id = ? WHERE genre_id = ?`, target, id)
+		if err != nil {
+			return err
+		}
+		n, err := r.RowsAffected()
+		if err != nil {
+			return err
+		}
+		out.ReassignedMasters = int(n)
+
+		r, err = tx.ExecContext(ctx, `UPDATE asset_genres SET parent_genre_id = ? WHERE parent_genre_id = ?`, target, id)
+		if err != nil {
+			return err
+		}
+		if n, err = r.RowsAffected(); err != nil {
+			return err
+		}
+		out.MovedChildGenres = int(n)
+
+		_, err = tx.ExecContext(ctx, `UPDATE asset_genres SET is_disabled = 1 WHERE genre_id = ?`, id)
 		return err
+	})
+	return out, err
+}
+
+// isSelfOrDescendant target から親をたどって id に行き着くか
+func isSelfOrDescendant(parents map[uint]*uint, id, target uint) bool {
+	seen := map[uint]bool{}
+	for cur := &target; cur != nil; cur = parents[*cur] {
+		if *cur == id {
+			return true
+		}
+		if seen[*cur] {
+			return true // 既存データの循環は安全側に倒す
+		}
+		seen[*cur] = true
+	}
+	return false
+}
+
+// ===== management categories =====
+
+const managementCategorySelect = `
+		SELECT management_category_id, category_name, is_lendable, is_disabled
+		FROM asset_management_categories
+	`
+
+func scanManagementCategory(row rowScanner) (*ManagementCategory, error) {
+	var mc ManagementCategory
+	if err := row.Scan(&mc.ManagementCategoryID, &mc.Name, &mc.IsLendable, &mc.IsDisabled); err != nil {
+		return nil, err
+	}
+	return &mc, nil
+}
+
+func (s *Store) ListManagementCategories(ctx context.Context, includeDisabled bool) ([]ManagementCategory, error) {
+	q := managementCategorySelect
+	if !includeDisabled {
+		q += ` WHERE is_disabled = 0`
 	}
-	aff, err := r.RowsAffected()
+	q += ` ORDER BY management_category_id`
+
+	rows, err := s.db.QueryContext(ctx, q)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if aff == 0 {
-		return sql.ErrNoRows
+	defer rows.Close()
+
+	res := make([]ManagementCategory, 0, 8)
+	for rows.Next() {
+		mc, err := scanManagementCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *mc)
 	}
-	return nil
+	return res, rows.Err()
 }
 
-// DELETE: is_disabled=1 にする
-func (s *Store) DisableGenre(ctx context.Context, id uint) error {
+func (s *Store) GetManagementCategoryByID(ctx context.Context, id uint) (*ManagementCategory, error) {
+	return scanManagementCategory(s.db.QueryRowContext(ctx, managementCategorySelect+` WHERE management_category_id = ?`, id))
+}
+
+func (s *Store) CreateManagementCategory(ctx context.Context, name string, lendable bool) (*ManagementCategory, error) {
 	const q = `
-		UPDATE asset_genres
-		SET is_disabled = 1
-		WHERE genre_id = ?
+		INSERT INTO asset_management_categories (category_name, is_lendable, is_disabled)
+		VALUES (?, ?, 0)
 	`
-	r, err := s.db.ExecContext(ctx, q, id)
+	r, err := s.db.ExecContext(ctx, q, name, lendable)
 	if err != nil {
+		return nil, err
+	}
+	lastID, err := r.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &ManagementCategory{ManagementCategoryID: uint(lastID), Name: name, IsLendable: lendable}, nil
+}
+
+func (s *Store) UpdateManagementCategory(ctx context.Context, id uint, name string, lendable bool, disabled bool) error {
+	const q = `
+		UPDATE asset_management_categories
+		SET category_name = ?, is_lendable = ?, is_disabled = ?
+		WHERE management_category_id = ?
+	`
+	return platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		if err := lockRow(ctx, tx, `SELECT 1 FROM asset_management_categories WHERE management_category_id = ? FOR UPDATE`, id); err != nil {
+			return err
+		}
+		if disabled {
+			if err := ensureUnused(ctx, tx, `SELECT EXISTS(SELECT 1 FROM assets_master WHERE management_category_id = ?)`, id); err != nil {
+				return err
+			}
+		}
+		_, err := tx.ExecContext(ctx, q, name, lendable, disabled, id)
+		return err
+	})
+}
+
+// DELETE: 備品が使っていなければ is_disabled=1 にする
+func (s *Store) DisableManagementCategory(ctx context.Context, id uint) error {
+	return platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		if err := lockRow(ctx, tx, `SELECT 1 FROM asset_management_categories WHERE management_category_id = ? FOR UPDATE`, id); err != nil {
+			return err
+		}
+		if err := ensureUnused(ctx, tx, `SELECT EXISTS(SELECT 1 FROM assets_master WHERE management_category_id = ?)`, id); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `UPDATE asset_management_categories SET is_disabled = 1 WHERE management_category_id = ?`, id)
 		return err
+	})
+}
+
+// ===== asset statuses =====
+
+type assetStatusInput struct {
+	Name              string
+	CountsAsAvailable bool
+	IsTerminal        bool
+	SystemRole        *string
+	IsDisabled        bool
+}
+
+const assetStatusSelect = `
+		SELECT status_id, status_name, counts_as_available, is_terminal, system_role, is_disabled
+		FROM asset_statuses
+	`
+
+func scanAssetStatus(row rowScanner) (*AssetStatus, error) {
+	var (
+		st   AssetStatus
+		role sql.NullString
+	)
+	if err := row.Scan(&st.StatusID, &st.Name, &st.CountsAsAvailable, &st.IsTerminal, &role, &st.IsDisabled); err != nil {
+		return nil, err
+	}
+	if role.Valid {
+		st.SystemRole = &role.String
+	}
+	return &st, nil
+}
+
+func (s *Store) ListAssetStatuses(ctx context.Context, includeDisabled bool) ([]AssetStatus, error) {
+	q := assetStatusSelect
+	if !includeDisabled {
+		q += ` WHERE is_disabled = 0`
+	}
+	q += ` ORDER BY status_id`
+
+	rows, err := s.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]AssetStatus, 0, 8)
+	for rows.Next() {
+		st, err := scanAssetStatus(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *st)
+	}
+	return res, rows.Err()
+}
+
+func (s *Store) GetAssetStatusByID(ctx context.Context, id uint) (*AssetStatus, error) {
+	return scanAssetStatus(s.db.QueryRowContext(ctx, assetStatusSelect+` WHERE status_id = ?`, id))
+}
+
+// CreateAssetStatus system_role は一意（UNIQUE 制約。重複は 1062）
+func (s *Store) CreateAssetStatus(ctx context.Context, in assetStatusInput) (*AssetStatus, error) {
+	const q = `
+		INSERT INTO asset_statuses (status_name, counts_as_available, is_terminal, system_role, is_disabled)
+		VALUES (?, ?, ?, ?, 0)
+	`
+	r, err := s.db.ExecContext(ctx, q, in.Name, in.CountsAsAvailable, in.IsTerminal, in.SystemRole)
+	if err != nil {
+		return nil, err
 	}
-	aff, err := r.RowsAffected()
+	lastID, err := r.LastInsertId()
 	if err != nil {
+		return nil, err
+	}
+	return s.GetAssetStatusByID(ctx, uint(lastID))
+}
+
+func (s *Store) UpdateAssetStatus(ctx context.Context, id uint, in assetStatusInput) error {
+	const q = `
+		UPDATE asset_statuses
+		SET status_name = ?, counts_as_available = ?, is_terminal = ?, system_role = ?, is_disabled = ?
+		WHERE status_id = ?
+	`
+	return platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		if err := lockRow(ctx, tx, `SELECT 1 FROM asset_statuses WHERE status_id = ? FOR UPDATE`, id); err != nil {
+			return err
+		}
+		if in.IsDisabled {
+			if in.SystemRole != nil {
+				return errStatusHasRole
+			}
+			if err := ensureUnused(ctx, tx, `SELECT EXISTS(SELECT 1 FROM assets WHERE status_id = ?)`, id); err != nil {
+				return err
+			}
+		}
+		_, err := tx.ExecContext(ctx, q, in.Name, in.CountsAsAvailable, in.IsTerminal, in.SystemRole, in.IsDisabled, id)
+		return err
+	})
+}
+
+// DELETE: 役割が無く、備品が使っていなければ is_disabled=1 にする
+func (s *Store) DisableAssetStatus(ctx context.Context, id uint) error {
+	return platformdb.RunInTx(ctx, s.db, nil, func(ctx context.Context, tx platformdb.DBTX) error {
+		var role sql.NullString
+		if err := tx.QueryRowContext(ctx, `SELECT system_role FROM asset_statuses WHERE status_id = ? FOR UPDATE`, id).Scan(&role); err != nil {
+			return err
+		}
+		if role.Valid {
+			return errStatusHasRole
+		}
+		if err := ensureUnused(ctx, tx, `SELECT EXISTS(SELECT 1 FROM assets WHERE status_id = ?)`, id); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `UPDATE asset_statuses SET is_disabled = 1 WHERE status_id = ?`, id)
+		return err
+	})
+}
+
+func lockRow(ctx context.Context, tx platformdb.DBTX, q string, id uint) error {
+	var one int
+	return tx.QueryRowContext(ctx, q, id).Scan(&one)
+}
+
+func ensureUnused(ctx context.Context, tx platformdb.DBTX, q string, id uint) error {
+	var used bool
+	if err := tx.QueryRowContext(ctx, q, id).Scan(&used); err != nil {
 		return err
 	}
-	if aff == 0 {
-		return sql.ErrNoRows
+	if used {
+		return errInUse
 	}
 	return nil
 }
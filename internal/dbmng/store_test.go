@@ -0,0 +1,204 @@
+package dbmng
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakeConn は流れた SQL を記録するだけの database/sql ドライバ。
+// rows / affected は SQL に含まれる部分文字列で引く
+type fakeConn struct {
+	rows     map[string][][]driver.Value
+	affected map[string]int64
+	execs    []fakeExec
+}
+
+type fakeExec struct {
+	query string
+	args  []driver.Value
+}
+
+func (c *fakeConn) Connect(context.Context) (driver.Conn, error) { return c, nil }
+func (c *fakeConn) Driver() driver.Driver                        { return nil }
+func (c *fakeConn) Prepare(string) (driver.Stmt, error)          { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error                                 { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                    { return c, nil }
+func (c *fakeConn) Commit() error                                { return nil }
+func (c *fakeConn) Rollback() error                              { return nil }
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	for key, rows := range c.rows {
+		if strings.Contains(query, key) {
+			return &fakeRows{values: rows}, nil
+		}
+	}
+	return nil, errors.New("unexpected query: " + query)
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e := fakeExec{query: query}
+	for _, a := range args {
+		e.args = append(e.args, a.Value)
+	}
+	c.execs = append(c.execs, e)
+	for key, n := range c.affected {
+		if strings.Contains(query, key) {
+			return driver.RowsAffected(n), nil
+		}
+	}
+	return driver.RowsAffected(0), nil
+}
+
+// exec は query に key を含む Exec を返す（無ければ nil）
+func (c *fakeConn) exec(key string) *fakeExec {
+	for i := range c.execs {
+		if strings.Contains(c.execs[i].query, key) {
+			return &c.execs[i]
+		}
+	}
+	return nil
+}
+
+type fakeRows struct {
+	values [][]driver.Value
+	next   int
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.values) == 0 {
+		return nil
+	}
+	return make([]string, len(r.values[0]))
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
+
+// newMergeConn はジャンル 1 → 2 → 4 と、統合先 3 の木を返す
+func newMergeConn() *fakeConn {
+	return &fakeConn{
+		rows: map[string][][]driver.Value{
+			"FROM asset_genres FOR UPDATE": {
+				{int64(1), nil}, {int64(2), int64(1)}, {int64(3), nil}, {int64(4), int64(2)},
+			},
+			"SELECT genre_code, is_disabled": {{"NEW", false}},
+			"SELECT genre_code FROM":         {{"OLD"}},
+			"management_number IN (":         {{false}},
+		},
+		affected: map[string]int64{
+			"UPDATE assets_master SET genre_id": 5,
+			"SET parent_genre_id":               1,
+		},
+	}
+}
+
+func TestStoreMergeGenreReassignsMastersAndMovesChildGenres(t *testing.T) {
+	conn := newMergeConn()
+	store := NewStore(sql.OpenDB(conn))
+
+	got, err := store.MergeGenre(context.Background(), 2, 3, false)
+	if err != nil {
+		t.Fatalf("MergeGenre returned error: %v", err)
+	}
+	if got.ReassignedMasters != 5 || got.MovedChildGenres != 1 {
+		t.Fatalf("unexpected merge result: %+v", got)
+	}
+
+	masters := conn.exec("UPDATE assets_master SET genre_id")
+	if masters == nil || masters.args[0] != int64(3) || masters.args[1] != int64(2) {
+		t.Fatalf("expected masters moved from 2 to 3, got %+v", masters)
+	}
+	children := conn.exec("SET parent_genre_id")
+	if children == nil || children.args[0] != int64(3) || children.args[1] != int64(2) {
+		t.Fatalf("expected child genres moved under 3, got %+v", children)
+	}
+	disabled := conn.exec("SET is_disabled = 1")
+	if disabled == nil || disabled.args[0] != int64(2) {
+		t.Fatalf("expected source genre disabled, got %+v", disabled)
+	}
+	if conn.exec("asset_management_number_aliases") != nil || conn.exec("SET management_number") != nil {
+		t.Fatalf("management numbers must not change without renumber, got %+v", conn.execs)
+	}
+}
+
+func TestStoreMergeGenreRenumbersManagementNumbersAndKeepsAliases(t *testing.T) {
+	conn := newMergeConn()
+	conn.affected["SET management_number"] = 2
+	store := NewStore(sql.OpenDB(conn))
+
+	got, err := store.MergeGenre(context.Background(), 2, 3, true)
+	if err != nil {
+		t.Fatalf("MergeGenre returned error: %v", err)
+	}
+	if got.RenumberedMasters != 2 || got.ReassignedMasters != 5 {
+		t.Fatalf("unexpected merge result: %+v", got)
+	}
+
+	aliases := conn.exec("INSERT INTO asset_management_number_aliases")
+	if aliases == nil || aliases.args[0] != int64(2) || aliases.args[1] != "OLD" {
+		t.Fatalf("expected old numbers of genre 2 kept as aliases, got %+v", aliases)
+	}
+	renumber := conn.exec("SET management_number")
+	if renumber == nil || renumber.args[0] != "NEW" || renumber.args[1] != "OLD" || renumber.args[2] != int64(2) {
+		t.Fatalf("expected OLD- numbers renumbered to NEW-, got %+v", renumber)
+	}
+	// 別名を残してから番号を付け替え、最後にジャンルを移す
+	var order []string
+	for _, e := range conn.execs {
+		for _, key := range []string{"asset_management_number_aliases", "SET management_number", "SET genre_id"} {
+			if strings.Contains(e.query, key) {
+				order = append(order, key)
+			}
+		}
+	}
+	if strings.Join(order, ",") != "asset_management_number_aliases,SET management_number,SET genre_id" {
+		t.Fatalf("unexpected statement order: %v", order)
+	}
+}
+
+func TestStoreMergeGenreRejectsRenumberingOntoTakenNumbers(t *testing.T) {
+	conn := newMergeConn()
+	conn.rows["management_number IN ("] = [][]driver.Value{{true}}
+
+	_, err := NewStore(sql.OpenDB(conn)).MergeGenre(context.Background(), 2, 3, true)
+	if !errors.Is(err, errRenumberConflict) {
+		t.Fatalf("expected errRenumberConflict, got %v", err)
+	}
+	if len(conn.execs) != 0 {
+		t.Fatalf("nothing should be written, got %+v", conn.execs)
+	}
+}
+
+func TestStoreMergeGenreRejectsDescendantAndDisabledTarget(t *testing.T) {
+	conn := newMergeConn()
+	// 4 は 2 の子なので、2 を 4 へ統合すると循環する
+	if _, err := NewStore(sql.OpenDB(conn)).MergeGenre(context.Background(), 2, 4, false); !errors.Is(err, errGenreCycle) {
+		t.Fatalf("expected errGenreCycle, got %v", err)
+	}
+
+	conn = newMergeConn()
+	conn.rows["SELECT genre_code, is_disabled"] = [][]driver.Value{{"NEW", true}}
+	if _, err := NewStore(sql.OpenDB(conn)).MergeGenre(context.Background(), 2, 3, false); !errors.Is(err, errMergeTarget) {
+		t.Fatalf("expected errMergeTarget, got %v", err)
+	}
+	if len(conn.execs) != 0 {
+		t.Fatalf("nothing should be written, got %+v", conn.execs)
+	}
+
+	if _, err := NewStore(sql.OpenDB(newMergeConn())).MergeGenre(context.Background(), 9, 3, false); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows for unknown source, got %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -33,6 +34,39 @@ type YahooConfig struct {
 	AppID string `yaml:"app_id"`
 }
 
+// ProductLookupConfig JAN/ISBN の商品情報検索（手入力カタログ → キャッシュ → providers の順）
+type ProductLookupConfig struct {
+	Providers             []string `yaml:"providers"`                // 外部プロバイダの問い合わせ順（openbd, yahoo）。空なら openbd, yahoo
+	CacheTTLHours         int      `yaml:"cache_ttl_hours"`          // 見つかった結果のキャッシュ期間（0 なら 30 日）
+	NegativeCacheTTLHours int      `yaml:"negative_cache_ttl_hours"` // 見つからなかった結果のキャッシュ期間（0 なら 1 日）
+	TimeoutSeconds        int      `yaml:"timeout_seconds"`          // 外部 API 1 回のタイムアウト（0 なら 5 秒）
+}
+
+// AttachmentConfig 備品の添付ファイル（写真・請求書・マニュアル）の保存先
+type AttachmentConfig struct {
+	Storage string             `yaml:"storage"`  // local（既定） / s3
+	Dir     string             `yaml:"dir"`      // local の保存先（空なら添付は無効）
+	TempDir string             `yaml:"temp_dir"` // 受信中のファイルを置く場所（空なら OS の既定）
+	MaxMB   int                `yaml:"max_mb"`   // 1 ファイルの上限（0 なら 20MB）
+	S3      AttachmentS3Config `yaml:"s3"`
+}
+
+// AttachmentS3Config S3 互換ストレージ（AWS S3 / MinIO など。パス形式でアクセスする）
+type AttachmentS3Config struct {
+	Endpoint        string `yaml:"endpoint"`
+	Region          string `yaml:"region"`
+	Bucket          string `yaml:"bucket"`
+	Prefix          string `yaml:"prefix"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+}
+
+type LabelConfig struct {
+	FontPath    string            `yaml:"font_path"`
+	Columns     map[string]string `yaml:"columns"`      // col_b..col_e に割り当てる備品項目
+	TemplateDir string            `yaml:"template_dir"` // アップロードされたラベルテンプレートの保存先
+}
+
 type Config struct {
 	Version     string         `yaml:"version"`
 	Mode        string         `yaml:"mode"`
@@ -40,6 +74,10 @@ type Config struct {
 	DB          DatabaseConfig `yaml:"database"`
 	Certificate Certs          `yaml:"certificate"`
 	Yahoo       YahooConfig    `yaml:"yahoo"`
+	Label       LabelConfig    `yaml:"label"`
+
+	ProductLookup ProductLookupConfig `yaml:"product_lookup"`
+	Attachments   AttachmentConfig    `yaml:"attachments"`
 }
 
 // LoadConfig はYAMLファイルを読み込みますが、ファイルが存在しない場合は環境変数を使用します
@@ -82,6 +120,30 @@ func loadFromEnv() *Config {
 		Yahoo: YahooConfig{
 			AppID: getEnv("YAHOO_APP_ID", ""),
 		},
+		Label: LabelConfig{
+			FontPath:    getEnv("LABEL_FONT_PATH", ""),
+			TemplateDir: getEnv("LABEL_TEMPLATE_DIR", ""),
+		},
+		ProductLookup: ProductLookupConfig{
+			Providers:             getEnvAsList("PRODUCT_LOOKUP_PROVIDERS"),
+			CacheTTLHours:         getEnvAsInt("PRODUCT_LOOKUP_CACHE_TTL_HOURS", 0),
+			NegativeCacheTTLHours: getEnvAsInt("PRODUCT_LOOKUP_NEGATIVE_CACHE_TTL_HOURS", 0),
+			TimeoutSeconds:        getEnvAsInt("PRODUCT_LOOKUP_TIMEOUT_SECONDS", 0),
+		},
+		Attachments: AttachmentConfig{
+			Storage: getEnv("ATTACHMENT_STORAGE", ""),
+			Dir:     getEnv("ATTACHMENT_DIR", ""),
+			TempDir: getEnv("ATTACHMENT_TEMP_DIR", ""),
+			MaxMB:   getEnvAsInt("ATTACHMENT_MAX_MB", 0),
+			S3: AttachmentS3Config{
+				Endpoint:        getEnv("ATTACHMENT_S3_ENDPOINT", ""),
+				Region:          getEnv("ATTACHMENT_S3_REGION", ""),
+				Bucket:          getEnv("ATTACHMENT_S3_BUCKET", ""),
+				Prefix:          getEnv("ATTACHMENT_S3_PREFIX", ""),
+				AccessKeyID:     getEnv("ATTACHMENT_S3_ACCESS_KEY_ID", ""),
+				SecretAccessKey: getEnv("ATTACHMENT_S3_SECRET_ACCESS_KEY", ""),
+			},
+		},
 	}
 }
 
@@ -104,6 +166,17 @@ func getEnvAsInt(key string, fallback int) int {
 	return fallback
 }
 
+// getEnvAsList はカンマ区切りの環境変数を返します（未設定・空なら nil）
+func getEnvAsList(key string) []string {
+	var out []string
+	for _, v := range strings.Split(getEnv(key, ""), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
 func getEnvAsBool(key string, fallback bool) bool {
 	strValue := getEnv(key, "")
 	if value, err := strconv.ParseBool(strValue); err == nil {
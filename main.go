@@ -21,7 +21,9 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 
 	"IRIS-backend/internal/asset_mgmt/assets"
+	"IRIS-backend/internal/asset_mgmt/attachments"
 	"IRIS-backend/internal/asset_mgmt/computers"
+	"IRIS-backend/internal/asset_mgmt/contracts"
 	"IRIS-backend/internal/asset_mgmt/disposals"
 	"IRIS-backend/internal/asset_mgmt/lend"
 	"IRIS-backend/internal/asset_mgmt/printLabels"
@@ -33,6 +35,9 @@ import (
 const (
 	addrListen = "0.0.0.0:8443"
 
+	contractNotifyInterval = 6 * time.Hour
+	printJobPollInterval   = 2 * time.Second
+
 	modeDev     = "dev"
 	modeRelease = "release"
 )
@@ -70,6 +75,11 @@ func main() {
 	}
 	log.Printf("[INFO] mode: %s\n", cfg.Mode)
 
+	attachmentCfg, err := attachmentConfig(cfg)
+	if err != nil {
+		log.Fatalf("[FATAL] failed to configure attachment storage: %v", err)
+	}
+
 	conn, err := db.Connect(cfg.DB)
 	if err != nil {
 		log.Fatalf("[FATAL] failed to connect DB: %v", err)
@@ -78,7 +88,7 @@ func main() {
 	log.Printf("[INFO] connected to DB: %s", cfg.DB.DBName)
 
 	// Gin ルータ生成（ファイルシステム渡しが不要に）
-	router := newRouter(cfg.Mode, conn, cfg)
+	router := newRouter(cfg.Mode, conn, cfg, attachmentCfg)
 
 	// HTTP サーバ生成
 	srv := &http.Server{
@@ -91,6 +101,14 @@ func main() {
 		log.Fatalf("[FATAL] failed to resolve TLS configuration: %v", err)
 	}
 
+	// 契約の期限切れ前通知ジョブ
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go contracts.NewService(conn).RunExpiryNotifier(jobCtx, contracts.LogNotifier{}, contractNotifyInterval)
+
+	// 印刷ジョブのワーカー（プリンタごとに 1 つ）
+	go printLabels.NewService(conn, labelConfig(cfg)).RunPrintWorker(jobCtx, printJobPollInterval)
+
 	// サーバ起動
 	go runServer(srv, certFile, keyFile)
 
@@ -100,7 +118,7 @@ func main() {
 
 // --- 初期化系 ---
 
-func newRouter(mode string, conn *sql.DB, cfg *db.Config) *gin.Engine {
+func newRouter(mode string, conn *sql.DB, cfg *db.Config, attachmentCfg attachments.Config) *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 
 	r := gin.New()
@@ -122,7 +140,7 @@ func newRouter(mode string, conn *sql.DB, cfg *db.Config) *gin.Engine {
 	r.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
 
 	// API ルート登録
-	registerAPIRoutes(r, conn, cfg)
+	registerAPIRoutes(r, conn, cfg, attachmentCfg)
 
 	return r
 }
@@ -136,7 +154,7 @@ func devCORS() gin.HandlerFunc {
 			"http://127.0.0.1:8080",
 		},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Idempotency-Key"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Disposition", "ETag"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowCredentials: true,
 	})
@@ -144,19 +162,27 @@ func devCORS() gin.HandlerFunc {
 
 // --- ルーティング ---
 
-func registerAPIRoutes(r *gin.Engine, conn *sql.DB, cfg *db.Config) {
+func registerAPIRoutes(r *gin.Engine, conn *sql.DB, cfg *db.Config, attachmentCfg attachments.Config) {
 	api := r.Group("/api/v2")
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	janClient := assets.NewJANClient(cfg.Yahoo.AppID)
+	janClient := assets.NewJANClient(janClientConfig(cfg), conn)
 
 	assets.RegisterRoutes(api, assets.NewService(conn, janClient))
-	computers.RegisterRoutes(api, computers.NewService(conn))
+	attachments.RegisterRoutes(api, attachments.NewService(conn, attachmentCfg))
+	computerSvc := computers.NewService(conn)
+	computers.RegisterRoutes(api, computerSvc)
+	contracts.RegisterRoutes(api, contracts.NewService(conn))
 	lend.RegisterRoutes(api, lend.NewService(conn))
 	disposals.RegisterRoutes(api, disposals.NewService(conn))
-	printLabels.RegisterRoutes(api, printLabels.NewService())
+	printLabels.RegisterRoutes(api, printLabels.NewService(conn, labelConfig(cfg)))
 	dbmng.RegisterRoutes(api, dbmng.NewService(conn))
 	auth.RegisterRoutes(api, auth.NewService(conn))
 
+	// 端末エージェントと取り込み確認キュー用グループ（認証必須）
+	agent := api.Group("")
+	agent.Use(auth.RequireAuth(auth.JWTSecret()))
+	computers.RegisterAgentRoutes(agent, computerSvc)
+
 	// 管理者用グループ
 	admin := api.Group("/admin")
 	admin.Use(auth.RequireAuth(auth.JWTSecret()))
@@ -172,6 +198,56 @@ func registerAPIRoutes(r *gin.Engine, conn *sql.DB, cfg *db.Config) {
 	admin.GET("/auth-ping", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
 }
 
+func janClientConfig(cfg *db.Config) assets.JANClientConfig {
+	pl := cfg.ProductLookup
+	return assets.JANClientConfig{
+		YahooAppID:       cfg.Yahoo.AppID,
+		Providers:        pl.Providers,
+		CacheTTL:         time.Duration(pl.CacheTTLHours) * time.Hour,
+		NegativeCacheTTL: time.Duration(pl.NegativeCacheTTLHours) * time.Hour,
+		Timeout:          time.Duration(pl.TimeoutSeconds) * time.Second,
+	}
+}
+
+// attachmentConfig は添付ファイルの保存先を組み立てる。local で dir が空なら添付は無効（Storage が nil）
+func attachmentConfig(cfg *db.Config) (attachments.Config, error) {
+	ac := cfg.Attachments
+	storage, err := attachments.NewStorage(attachments.StorageConfig{
+		Driver: ac.Storage,
+		Dir:    ac.Dir,
+		S3: attachments.S3Config{
+			Endpoint:        ac.S3.Endpoint,
+			Region:          ac.S3.Region,
+			Bucket:          ac.S3.Bucket,
+			Prefix:          ac.S3.Prefix,
+			AccessKeyID:     ac.S3.AccessKeyID,
+			SecretAccessKey: ac.S3.SecretAccessKey,
+		},
+	})
+	if err != nil {
+		return attachments.Config{}, err
+	}
+	if storage == nil {
+		log.Println("[WARN] attachments.dir is not set; attachment uploads are disabled")
+	}
+	return attachments.Config{
+		MaxBytes: int64(ac.MaxMB) << 20,
+		TempDir:  ac.TempDir,
+		Storage:  storage,
+	}, nil
+}
+
+func labelConfig(cfg *db.Config) printLabels.Config {
+	if cfg.Label.FontPath == "" {
+		log.Println("[WARN] label.font_path is not set; labels fall back to a Latin-only font and Japanese text will be rejected")
+	}
+	return printLabels.Config{
+		FontPath:    cfg.Label.FontPath,
+		Columns:     cfg.Label.Columns,
+		TemplateDir: cfg.Label.TemplateDir,
+	}
+}
+
 // --- TLS / サーバ起動 ---
 
 func resolveServerTLS(cfg *db.Config) (string, string, error) {
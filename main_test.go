@@ -3,14 +3,21 @@ package main
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"IRIS-backend/internal/asset_mgmt/attachments"
 	"IRIS-backend/internal/platform/db"
 )
 
+// testConfig はルート登録に使う最小の設定
+func testConfig(t *testing.T) *db.Config {
+	t.Helper()
+	return &db.Config{Mode: modeRelease}
+}
+
 func TestPrintTemplateRouteIsRegistered(t *testing.T) {
-	cfg := &db.Config{Mode: modeRelease}
-	router := newRouter(modeRelease, nil, cfg)
+	router := newRouter(modeRelease, nil, testConfig(t), attachments.Config{})
 
 	req := httptest.NewRequest(
 		http.MethodGet,
@@ -25,3 +32,55 @@ func TestPrintTemplateRouteIsRegistered(t *testing.T) {
 		t.Fatalf("expected print template route to be registered, got %d", rec.Code)
 	}
 }
+
+func TestPrintRenderRouteIsRegistered(t *testing.T) {
+	router := newRouter(modeRelease, nil, testConfig(t), attachments.Config{})
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v2/assets/print/render",
+		strings.NewReader(`{"label":{"checked":true,"col_b":"b","col_c":"c","col_d":"d","col_e":"OFS-1"},"width":12,"type":"code128"}`),
+	)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 from render route, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "image/png" {
+		t.Fatalf("expected image/png, got %s", ct)
+	}
+}
+
+func TestPrintJobRouteIsRegistered(t *testing.T) {
+	router := newRouter(modeRelease, nil, testConfig(t), attachments.Config{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/print/jobs/abc", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid print job id, got %d", rec.Code)
+	}
+}
+
+func TestAttachmentConfigDisablesStorageWithoutDir(t *testing.T) {
+	cfg := testConfig(t)
+	ac, err := attachmentConfig(cfg)
+	if err != nil || ac.Storage != nil {
+		t.Fatalf("expected attachments disabled without dir, got %v, %v", ac.Storage, err)
+	}
+
+	cfg.Attachments.Dir = t.TempDir()
+	if ac, err = attachmentConfig(cfg); err != nil || ac.Storage == nil {
+		t.Fatalf("expected local storage, got %v, %v", ac.Storage, err)
+	}
+
+	cfg.Attachments.Storage = "ftp"
+	if _, err := attachmentConfig(cfg); err == nil {
+		t.Fatal("expected unknown storage driver rejected")
+	}
+}